@@ -5,6 +5,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"time"
 
 	"go.uber.org/fx"
 
@@ -12,12 +13,20 @@ import (
 	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/internal/handler"
 	"vibe-coding-starter/internal/middleware"
+	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/repository"
 	"vibe-coding-starter/internal/server"
 	"vibe-coding-starter/internal/service"
 	"vibe-coding-starter/pkg/cache"
 	"vibe-coding-starter/pkg/database"
 	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/password"
+	"vibe-coding-starter/pkg/secretbox"
+	"vibe-coding-starter/pkg/signer"
+	"vibe-coding-starter/pkg/storage"
+	"vibe-coding-starter/pkg/token"
 )
 
 // @title Vibe Coding Starter API
@@ -58,6 +67,36 @@ func configProvider() (*config.Config, error) {
 	return config.LoadConfig(configPath)
 }
 
+// runPeriodically 在应用运行期间按固定间隔执行任务，interval 不大于 0 时不启用
+func runPeriodically(lifecycle fx.Lifecycle, interval time.Duration, task func(ctx context.Context)) {
+	if interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						task(ctx)
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
 func main() {
 	app := fx.New(
 		// 配置模块
@@ -68,29 +107,66 @@ func main() {
 			logger.New,
 			database.New,
 			cache.New,
+			storage.New,
+			signer.New,
+			token.New,
+			mailer.New,
+			secretbox.New,
+			oidc.New,
+			password.New,
+			password.NewPolicy,
 		),
 
 		// 中间件模块
 		fx.Provide(
 			middleware.NewMiddleware,
+			// 角色变更后通过中间件清除权限缓存
+			func(mw *middleware.Middleware) service.PermissionCache {
+				return mw
+			},
 		),
 
 		// 仓储模块
 		fx.Provide(
 			repository.NewUserRepository,
 			repository.NewArticleRepository,
+			repository.NewCommentRepository,
 			repository.NewFileRepository,
+			repository.NewFileBlobRepository,
 			repository.NewDictRepository,
 			repository.NewDepartmentRepository,
+			repository.NewUploadSessionRepository,
+			repository.NewStorageQuotaRepository,
+			repository.NewRefreshTokenRepository,
+			repository.NewUserSessionRepository,
+			repository.NewOneTimeTokenRepository,
+			repository.NewMFARepository,
+			repository.NewUserIdentityRepository,
+			repository.NewAPIKeyRepository,
+			repository.NewRoleRepository,
+			repository.NewAuditLogRepository,
+			repository.NewInvitationRepository,
 		),
 
 		// 服务模块
 		fx.Provide(
+			service.NewAuditService,
+			service.NewTokenService,
 			service.NewUserService,
+			service.NewPasswordResetService,
+			service.NewEmailVerificationService,
 			service.NewArticleService,
 			service.NewFileService,
 			service.NewDictService,
 			service.NewDepartmentService,
+			service.NewResumableUploadService,
+			service.NewStorageQuotaService,
+			service.NewStorageGCService,
+			service.NewAPIKeyService,
+			service.NewRoleService,
+			service.NewAdminUserService,
+			service.NewPrivacyService,
+			service.NewInvitationService,
 		),
 
 		// 处理器模块
@@ -101,11 +177,142 @@ func main() {
 			handler.NewHealthHandler,
 			handler.NewDictHandler,
 			handler.NewDepartmentHandler,
+			handler.NewUploadHandler,
+			handler.NewStorageQuotaHandler,
+			handler.NewSessionHandler,
+			handler.NewPasswordResetHandler,
+			handler.NewEmailVerificationHandler,
+			handler.NewMFAHandler,
+			handler.NewOAuthHandler,
+			handler.NewJWKSHandler,
+			handler.NewAPIKeyHandler,
+			handler.NewRoleHandler,
+			handler.NewAdminUserHandler,
+			handler.NewAuditLogHandler,
+			handler.NewPrivacyHandler,
+			handler.NewInvitationHandler,
 		),
 
 		// 服务器模块
 		fx.Provide(server.New),
 
+		// 新密码使用配置的哈希算法
+		fx.Invoke(model.SetPasswordHasher),
+
+		// 注册资源所有权查询
+		fx.Invoke(func(mw *middleware.Middleware, fileRepo repository.FileRepository) {
+			mw.RegisterOwnerLookup("file", func(ctx context.Context, fileID uint) (uint, error) {
+				file, err := fileRepo.GetByID(ctx, fileID)
+				if err != nil {
+					return 0, err
+				}
+				return file.OwnerID, nil
+			})
+		}),
+
+		// 从数据库加载用户权限
+		fx.Invoke(func(mw *middleware.Middleware, roleService service.RoleService) {
+			mw.RegisterPermissionLoader(roleService.GetUserPermissions)
+		}),
+
+		// 持久化处理器标记的安全事件
+		fx.Invoke(func(mw *middleware.Middleware, auditService service.AuditService) {
+			mw.RegisterAuditRecorder(func(ctx context.Context, action, resourceType, resourceID string) {
+				auditService.Record(ctx, &service.AuditEntry{
+					Action:       action,
+					ResourceType: resourceType,
+					ResourceID:   resourceID,
+				})
+			})
+		}),
+
+		// 后台批量写入审计日志，关闭时写入队列中剩余的日志
+		fx.Invoke(func(lifecycle fx.Lifecycle, auditService service.AuditService) {
+			lifecycle.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					auditService.Start()
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					return auditService.Stop(ctx)
+				},
+			})
+		}),
+
+		// 注册个人 API Key 验证
+		fx.Invoke(func(mw *middleware.Middleware, apiKeyService service.APIKeyService) {
+			mw.RegisterAPIKeyLookup(func(ctx context.Context, key, ip string) (*middleware.APIKeyPrincipal, error) {
+				apiKey, user, err := apiKeyService.Authenticate(ctx, key, ip)
+				if err != nil {
+					return nil, err
+				}
+				return &middleware.APIKeyPrincipal{
+					KeyID:    apiKey.ID,
+					UserID:   user.ID,
+					Username: user.Username,
+					Email:    user.Email,
+					Role:     user.Role,
+					Scopes:   apiKey.Scopes,
+				}, nil
+			})
+		}),
+
+		// 定期清理过期的上传会话
+		fx.Invoke(func(lifecycle fx.Lifecycle, cfg *config.Config, uploadService service.ResumableUploadService, logger logger.Logger) {
+			runPeriodically(lifecycle, time.Duration(cfg.Upload.CleanupInterval)*time.Second, func(ctx context.Context) {
+				if _, err := uploadService.CleanupExpired(ctx); err != nil {
+					logger.Warn("Failed to cleanup expired uploads", "error", err)
+				}
+			})
+		}),
+
+		// 定期检查存储一致性，清理孤立对象
+		fx.Invoke(func(lifecycle fx.Lifecycle, cfg *config.Config, gcService service.StorageGCService, logger logger.Logger) {
+			gc := cfg.Storage.GC
+			opts := service.StorageGCOptions{
+				DryRun:        gc.DryRun,
+				DeleteOrphans: gc.DeleteOrphans,
+				PurgeMissing:  gc.PurgeMissing,
+				GracePeriod:   time.Duration(gc.GracePeriod) * time.Second,
+			}
+
+			runPeriodically(lifecycle, time.Duration(gc.Interval)*time.Second, func(ctx context.Context) {
+				if _, err := gcService.Run(ctx, opts); err != nil {
+					logger.Warn("Failed to run storage consistency check", "error", err)
+				}
+			})
+		}),
+
+		// 定期清理过期的刷新令牌和登录会话
+		fx.Invoke(func(lifecycle fx.Lifecycle, tokenService service.TokenService, logger logger.Logger) {
+			runPeriodically(lifecycle, time.Hour, func(ctx context.Context) {
+				if _, err := tokenService.CleanupExpired(ctx); err != nil {
+					logger.Warn("Failed to cleanup expired refresh tokens", "error", err)
+				}
+			})
+		}),
+
+		// 定期清理过期的一次性令牌
+		fx.Invoke(func(lifecycle fx.Lifecycle, resetService service.PasswordResetService, logger logger.Logger) {
+			runPeriodically(lifecycle, time.Hour, func(ctx context.Context) {
+				if _, err := resetService.CleanupExpired(ctx); err != nil {
+					logger.Warn("Failed to cleanup expired one-time tokens", "error", err)
+				}
+			})
+		}),
+
+		// 定期匿名化注销冷静期已结束的账户，清理过期的个人数据导出文件
+		fx.Invoke(func(lifecycle fx.Lifecycle, cfg *config.Config, privacyService service.PrivacyService, logger logger.Logger) {
+			runPeriodically(lifecycle, time.Duration(cfg.Privacy.PurgeInterval)*time.Second, func(ctx context.Context) {
+				if _, err := privacyService.PurgeDue(ctx); err != nil {
+					logger.Warn("Failed to process scheduled account deletions", "error", err)
+				}
+				if _, err := privacyService.CleanupExports(ctx); err != nil {
+					logger.Warn("Failed to cleanup expired data exports", "error", err)
+				}
+			})
+		}),
+
 		// 启动服务器
 		fx.Invoke(func(srv *server.Server) {
 			// 服务器启动在 OnStart hook 中处理
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/storage"
+)
+
+var (
+	configFile  string
+	storageType string
+	gracePeriod time.Duration
+	jsonOutput  bool
+
+	dryRun        bool
+	deleteOrphans bool
+	purgeMissing  bool
+	assumeYes     bool
+
+	cfg       *config.Config
+	gcService service.StorageGCService
+)
+
+// rootCmd 根命令
+var rootCmd = &cobra.Command{
+	Use:   "storagectl",
+	Short: "Storage maintenance tool for vibe-coding-starter",
+	Long:  `Check consistency between stored objects and file records, and clean up orphaned objects.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		initService()
+	},
+}
+
+// checkCmd 一致性检查命令
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Report storage inconsistencies without changing anything",
+	Long: `Report objects that no file record references, file records whose objects are missing,
+and blobs whose reference count does not match their file records. Exits with status 1 when
+inconsistencies are found.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		reports := run(service.StorageGCOptions{DryRun: true})
+		for _, report := range reports {
+			if len(report.OrphanedObjects)+len(report.MissingObjects)+len(report.RefCountMismatches) > 0 {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// gcCmd 清理命令
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Repair storage inconsistencies",
+	Long: `Delete orphaned objects, fix blob reference counts and optionally purge file records
+whose objects are missing. Use --dry-run to see what would change.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !dryRun && !assumeYes {
+			fmt.Print("This will permanently delete storage objects and records. Continue? (yes/no): ")
+			var response string
+			fmt.Scanln(&response)
+
+			if response != "yes" {
+				fmt.Println("Operation cancelled.")
+				return
+			}
+		}
+
+		run(service.StorageGCOptions{
+			DryRun:        dryRun,
+			DeleteOrphans: deleteOrphans,
+			PurgeMissing:  purgeMissing,
+		})
+	},
+}
+
+func init() {
+	// 添加持久化标志
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "config file path")
+	rootCmd.PersistentFlags().StringVarP(&storageType, "storage", "s", "", "storage driver to check (default: all configured drivers)")
+	rootCmd.PersistentFlags().DurationVar(&gracePeriod, "grace", -1, "ignore objects written within this period (default: storage.gc.grace_period)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print reports as JSON")
+
+	gcCmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what would be changed without changing anything")
+	gcCmd.Flags().BoolVar(&deleteOrphans, "delete-orphans", true, "delete objects that no record references")
+	gcCmd.Flags().BoolVar(&purgeMissing, "purge-missing", false, "delete file records whose objects are missing")
+	gcCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not ask for confirmation")
+
+	// 添加子命令
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(gcCmd)
+}
+
+// initConfig 初始化配置
+func initConfig() {
+	var err error
+	cfg, err = config.LoadConfig(configFile)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+}
+
+// initService 初始化存储一致性检查服务
+func initService() {
+	appLogger, err := logger.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	db, err := database.New(cfg, appLogger)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	manager, err := storage.New(cfg, appLogger)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	quotaService := service.NewStorageQuotaService(
+		repository.NewStorageQuotaRepository(db, appLogger),
+		repository.NewUserRepository(db, appLogger),
+		cfg,
+		appLogger,
+	)
+	gcService = service.NewStorageGCService(
+		repository.NewFileRepository(db, appLogger),
+		repository.NewFileBlobRepository(db, appLogger),
+		repository.NewUploadSessionRepository(db, appLogger),
+		quotaService,
+		manager,
+		appLogger,
+	)
+}
+
+// run 执行检查并输出报告
+func run(opts service.StorageGCOptions) []*service.StorageGCReport {
+	opts.StorageType = storageType
+	opts.GracePeriod = gracePeriod
+	if opts.GracePeriod < 0 {
+		opts.GracePeriod = time.Duration(cfg.Storage.GC.GracePeriod) * time.Second
+	}
+
+	reports, err := gcService.Run(context.Background(), opts)
+	if err != nil {
+		log.Fatalf("Storage check failed: %v", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			log.Fatalf("Failed to encode reports: %v", err)
+		}
+		return reports
+	}
+
+	for _, report := range reports {
+		printReport(report)
+	}
+	return reports
+}
+
+// printReport 输出单个存储驱动的检查报告
+func printReport(report *service.StorageGCReport) {
+	mode := "repair"
+	if report.DryRun {
+		mode = "dry-run"
+	}
+	fmt.Printf("Storage %q (%s): scanned %d objects, %d file records in %s\n",
+		report.StorageType, mode, report.ScannedObjects, report.ScannedFiles,
+		report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+
+	fmt.Printf("  Orphaned objects: %d\n", len(report.OrphanedObjects))
+	for _, object := range report.OrphanedObjects {
+		fmt.Printf("    %s (%d bytes, modified %s)\n", object.Key, object.Size, object.LastModified.Format(time.RFC3339))
+	}
+
+	fmt.Printf("  Missing objects: %d\n", len(report.MissingObjects))
+	for _, missing := range report.MissingObjects {
+		fmt.Printf("    file %d (owner %d): %s\n", missing.FileID, missing.OwnerID, missing.Path)
+	}
+
+	fmt.Printf("  Reference count mismatches: %d\n", len(report.RefCountMismatches))
+	for _, mismatch := range report.RefCountMismatches {
+		fmt.Printf("    blob %d: recorded %d, actual %d (%s)\n", mismatch.BlobID, mismatch.Recorded, mismatch.Actual, mismatch.Path)
+	}
+
+	if !report.DryRun {
+		fmt.Printf("  Deleted objects: %d, purged files: %d, fixed blobs: %d\n",
+			report.DeletedObjects, report.PurgedFiles, report.FixedBlobs)
+	}
+
+	for _, message := range report.Errors {
+		fmt.Printf("  Error: %s\n", message)
+	}
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Command execution failed: %v", err)
+	}
+}
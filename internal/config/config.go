@@ -14,9 +14,16 @@ type Config struct {
 	Cache    CacheConfig    `mapstructure:"cache"`
 	Logger   LoggerConfig   `mapstructure:"logger"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
+	Auth     AuthConfig     `mapstructure:"auth"`
 	AI       AIConfig       `mapstructure:"ai"`
 	CORS     CORSConfig     `mapstructure:"cors"`
 	Security SecurityConfig `mapstructure:"security"`
+	Storage  StorageConfig  `mapstructure:"storage"`
+	Upload   UploadConfig   `mapstructure:"upload"`
+	Email    EmailConfig    `mapstructure:"email"`
+	OAuth    OAuthConfig    `mapstructure:"oauth"`
+	Privacy  PrivacyConfig  `mapstructure:"privacy"`
+	Password PasswordConfig `mapstructure:"password"`
 }
 
 // ServerConfig 服务器配置
@@ -67,9 +74,21 @@ type LoggerConfig struct {
 
 // JWTConfig JWT 配置
 type JWTConfig struct {
-	Secret     string `mapstructure:"secret"`
-	Issuer     string `mapstructure:"issuer"`
-	Expiration int    `mapstructure:"expiration"`
+	Secret            string `mapstructure:"secret"`
+	Issuer            string `mapstructure:"issuer"`
+	Expiration        int    `mapstructure:"expiration"`         // 访问令牌有效期（秒）
+	RefreshExpiration int    `mapstructure:"refresh_expiration"` // 刷新令牌有效期（秒）
+	// SigningKeyID 签发令牌使用的密钥 ID，为空时使用 keys 中第一个配置了私钥的密钥
+	SigningKeyID string `mapstructure:"signing_key_id"`
+	// Keys 非对称签名密钥（RS256/EdDSA），配置后不再使用 secret 签发和验证访问令牌
+	Keys []JWTKeyConfig `mapstructure:"keys"`
+}
+
+// JWTKeyConfig JWT 签名密钥配置，轮换时保留旧密钥的公钥直到其签发的令牌全部过期
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`               // 密钥 ID，写入令牌头部 kid
+	PrivateKeyFile string `mapstructure:"private_key_file"` // PEM 私钥文件，只用于验证的旧密钥可以不配置
+	PublicKeyFile  string `mapstructure:"public_key_file"`  // PEM 公钥文件，配置了私钥时可以省略
 }
 
 // AIConfig AI 辅助开发配置
@@ -104,8 +123,189 @@ type SecurityConfig struct {
 	IPWhitelist           []string `mapstructure:"ip_whitelist"`
 	IPBlacklist           []string `mapstructure:"ip_blacklist"`
 	BlockedUserAgents     []string `mapstructure:"blocked_user_agents"`
-	MaxRequestSize        int64    `mapstructure:"max_request_size"`
+	MaxRequestSize        int64    `mapstructure:"max_request_size"` // 请求体大小上限（字节），上传接口见 upload.max_request_size，0 表示不限制
 	RequestTimeout        int      `mapstructure:"request_timeout"`
+
+	PasswordResetExpiration int `mapstructure:"password_reset_expiration"` // 密码重置令牌有效期（秒）
+}
+
+// StorageConfig 文件存储配置
+type StorageConfig struct {
+	Default string             `mapstructure:"default"`
+	Local   LocalStorageConfig `mapstructure:"local"`
+	S3      S3StorageConfig    `mapstructure:"s3"`
+	OSS     S3StorageConfig    `mapstructure:"oss"`
+	Signing SigningConfig      `mapstructure:"signing"`
+	GC      StorageGCConfig    `mapstructure:"gc"`
+}
+
+// StorageGCConfig 存储一致性检查与孤立对象清理配置
+type StorageGCConfig struct {
+	Interval      int  `mapstructure:"interval"`       // 后台检查间隔（秒），0 表示不启用
+	GracePeriod   int  `mapstructure:"grace_period"`   // 最近写入的对象在此时间内（秒）不视为孤立对象
+	DryRun        bool `mapstructure:"dry_run"`        // 仅报告问题，不做任何修改
+	DeleteOrphans bool `mapstructure:"delete_orphans"` // 删除没有记录引用的对象
+	PurgeMissing  bool `mapstructure:"purge_missing"`  // 删除对象已丢失的文件记录
+}
+
+// LocalStorageConfig 本地磁盘存储配置
+type LocalStorageConfig struct {
+	Root    string `mapstructure:"root"`
+	BaseURL string `mapstructure:"base_url"`
+}
+
+// S3StorageConfig S3 兼容存储配置
+type S3StorageConfig struct {
+	Endpoint     string `mapstructure:"endpoint"`
+	Region       string `mapstructure:"region"`
+	Bucket       string `mapstructure:"bucket"`
+	AccessKey    string `mapstructure:"access_key"`
+	SecretKey    string `mapstructure:"secret_key"`
+	UsePathStyle bool   `mapstructure:"use_path_style"`
+	PublicURL    string `mapstructure:"public_url"`
+}
+
+// SigningConfig 签名下载链接配置
+type SigningConfig struct {
+	ActiveKey  string       `mapstructure:"active_key"`  // 当前用于签名的密钥 ID
+	Keys       []SigningKey `mapstructure:"keys"`        // 可用于验证的密钥，轮换时保留旧密钥直到其签发的链接过期
+	DefaultTTL int          `mapstructure:"default_ttl"` // 默认有效期（秒）
+	MaxTTL     int          `mapstructure:"max_ttl"`     // 最长有效期（秒）
+	BaseURL    string       `mapstructure:"base_url"`    // 链接前缀，为空时返回相对路径
+}
+
+// SigningKey 签名密钥
+type SigningKey struct {
+	ID     string `mapstructure:"id"`
+	Secret string `mapstructure:"secret"`
+}
+
+// UploadConfig 文件上传配置
+type UploadConfig struct {
+	MaxSize             int64             `mapstructure:"max_size"`             // 单个文件最大大小（字节），未匹配类型限制时使用，0 表示不限制
+	MaxRequestSize      int64             `mapstructure:"max_request_size"`     // 单次上传接口的请求体上限（字节），0 表示不限制；断点续传分片只受会话剩余长度限制
+	AllowedTypes        []string          `mapstructure:"allowed_types"`        // 允许的 MIME 类型，支持 image/* 通配，为空时不限制
+	TypeLimits          []UploadTypeLimit `mapstructure:"type_limits"`          // 按 MIME 类型限制大小，优先于 max_size
+	RoleLimits          map[string]int64  `mapstructure:"role_limits"`          // 按用户角色限制大小，与类型限制同时生效
+	RoleQuotas          map[string]int64  `mapstructure:"role_quotas"`          // 按用户角色的默认存储配额（字节），0 或未配置表示不限制
+	ResumableExpiration int               `mapstructure:"resumable_expiration"` // 断点续传会话空闲过期时间（秒）
+	CleanupInterval     int               `mapstructure:"cleanup_interval"`     // 过期会话清理间隔（秒）
+	Image               ImageConfig       `mapstructure:"image"`
+}
+
+// UploadTypeLimit 按 MIME 类型的大小限制
+type UploadTypeLimit struct {
+	Type    string `mapstructure:"type"`     // MIME 类型，支持 image/* 通配
+	MaxSize int64  `mapstructure:"max_size"` // 最大大小（字节）
+}
+
+// ImageConfig 图片变体配置
+type ImageConfig struct {
+	Variants         []ImageVariantConfig `mapstructure:"variants"`           // 可用的图片变体
+	GenerateOnUpload bool                 `mapstructure:"generate_on_upload"` // 上传时立即生成变体，否则在首次访问时生成
+	Quality          int                  `mapstructure:"quality"`            // JPEG 编码质量（1-100）
+	MaxPixels        int64                `mapstructure:"max_pixels"`         // 允许处理的最大像素数
+}
+
+// ImageVariantConfig 图片变体尺寸配置
+type ImageVariantConfig struct {
+	Name   string `mapstructure:"name"`
+	Width  int    `mapstructure:"width"`
+	Height int    `mapstructure:"height"`
+}
+
+// AuthConfig 账户认证配置
+type AuthConfig struct {
+	RequireEmailVerification    bool `mapstructure:"require_email_verification"`    // 新注册用户需验证邮箱后才能登录
+	EmailVerificationExpiration int  `mapstructure:"email_verification_expiration"` // 验证链接有效期（秒）
+	VerificationResendInterval  int  `mapstructure:"verification_resend_interval"`  // 同一邮箱重发验证邮件的最小间隔（秒）
+
+	MFAIssuer            string `mapstructure:"mfa_issuer"`             // 身份验证器应用中显示的发行方名称
+	MFAEncryptionKey     string `mapstructure:"mfa_encryption_key"`     // 加密 TOTP 密钥的口令，为空时由 jwt.secret 派生
+	MFAPendingExpiration int    `mapstructure:"mfa_pending_expiration"` // 登录第二步令牌有效期（秒）
+	RequireAdminMFA      bool   `mapstructure:"require_admin_mfa"`      // 管理员必须开启两步验证并通过验证登录才能访问管理接口
+
+	LoginMaxAttempts        int `mapstructure:"login_max_attempts"`         // 同一账户连续登录失败多少次后锁定，0 表示不锁定
+	LoginLockoutDuration    int `mapstructure:"login_lockout_duration"`     // 首次锁定时长（秒），之后每次失败翻倍
+	LoginLockoutMaxDuration int `mapstructure:"login_lockout_max_duration"` // 最长锁定时长（秒）
+
+	ImpersonationExpiration int `mapstructure:"impersonation_expiration"` // 管理员模拟登录令牌有效期（秒），不超过访问令牌有效期
+
+	RegistrationMode     string `mapstructure:"registration_mode"`     // 注册方式：open 开放注册，invite_only 只能通过邀请注册，closed 关闭注册
+	InvitationExpiration int    `mapstructure:"invitation_expiration"` // 邀请未指定过期时间时的有效期（秒）
+}
+
+// EmailConfig 邮件发送配置
+type EmailConfig struct {
+	Enabled     bool   `mapstructure:"enabled"` // 未启用时邮件只写入日志
+	Driver      string `mapstructure:"driver"`  // 启用时的发送方式：smtp 或 file
+	SMTPHost    string `mapstructure:"smtp_host"`
+	SMTPPort    int    `mapstructure:"smtp_port"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	Encryption  string `mapstructure:"encryption"` // starttls、tls 或 none
+	FromEmail   string `mapstructure:"from_email"`
+	FromName    string `mapstructure:"from_name"`
+	FileDir     string `mapstructure:"file_dir"`      // file 驱动保存邮件的目录
+	LinkBaseURL string `mapstructure:"link_base_url"` // 邮件中链接指向的前端地址
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	StateExpiration int                   `mapstructure:"state_expiration"` // 授权请求有效期（秒），超时未回调需重新发起
+	AutoRegister    bool                  `mapstructure:"auto_register"`    // 邮箱未注册时自动创建用户，关闭后只能登录已有账户
+	Providers       []OAuthProviderConfig `mapstructure:"providers"`
+}
+
+// OAuthProviderConfig OpenID Connect 提供方配置
+type OAuthProviderConfig struct {
+	Name         string   `mapstructure:"name"`          // 提供方标识，出现在登录路由中，如 google
+	DisplayName  string   `mapstructure:"display_name"`  // 登录按钮上显示的名称
+	Issuer       string   `mapstructure:"issuer"`        // 发行方地址，端点从 /.well-known/openid-configuration 获取
+	ClientID     string   `mapstructure:"client_id"`     // 在提供方处登记的客户端 ID
+	ClientSecret string   `mapstructure:"client_secret"` // 客户端密钥，公共客户端可为空
+	RedirectURL  string   `mapstructure:"redirect_url"`  // 回调地址，需与提供方处登记的一致
+	Scopes       []string `mapstructure:"scopes"`        // 为空时使用 openid email profile
+}
+
+// PrivacyConfig 个人数据导出与账户注销配置
+type PrivacyConfig struct {
+	ExportExpiration    int    `mapstructure:"export_expiration"`     // 导出文件下载链接有效期（秒），过期后删除导出文件
+	ExportInterval      int    `mapstructure:"export_interval"`       // 同一用户两次申请导出的最小间隔（秒）
+	DeletionGracePeriod int    `mapstructure:"deletion_grace_period"` // 申请注销后的冷静期（秒），期间可以撤销
+	ContentPolicy       string `mapstructure:"content_policy"`        // 匿名化时文章、评论和文件的处理方式：delete 或 reassign
+	ReassignTo          string `mapstructure:"reassign_to"`           // content_policy 为 reassign 时接收内容的用户名
+	PurgeInterval       int    `mapstructure:"purge_interval"`        // 匿名化到期账户和清理过期导出文件的间隔（秒），0 表示不启用
+}
+
+// PasswordConfig 密码哈希与密码策略配置
+type PasswordConfig struct {
+	Algorithm  string               `mapstructure:"algorithm"`   // 新密码使用的哈希算法：argon2id 或 bcrypt，两种格式的已有哈希都可以验证
+	BcryptCost int                  `mapstructure:"bcrypt_cost"` // bcrypt 计算成本
+	Argon2     Argon2Config         `mapstructure:"argon2"`
+	Policy     PasswordPolicyConfig `mapstructure:"policy"`
+}
+
+// Argon2Config Argon2id 参数，修改后旧参数的哈希会在用户下次登录时重新计算
+type Argon2Config struct {
+	Memory      uint32 `mapstructure:"memory"`      // 内存开销（KiB）
+	Iterations  uint32 `mapstructure:"iterations"`  // 迭代次数
+	Parallelism uint8  `mapstructure:"parallelism"` // 并行度
+	SaltLength  uint32 `mapstructure:"salt_length"` // 盐长度（字节）
+	KeyLength   uint32 `mapstructure:"key_length"`  // 哈希长度（字节）
+}
+
+// PasswordPolicyConfig 注册、修改和重置密码时的密码策略
+type PasswordPolicyConfig struct {
+	MinLength           int    `mapstructure:"min_length"`            // 最小长度（字符），0 时使用 8
+	MaxLength           int    `mapstructure:"max_length"`            // 最大长度（字符），0 表示不限制
+	RequireUppercase    bool   `mapstructure:"require_uppercase"`     // 必须包含大写字母
+	RequireLowercase    bool   `mapstructure:"require_lowercase"`     // 必须包含小写字母
+	RequireDigit        bool   `mapstructure:"require_digit"`         // 必须包含数字
+	RequireSymbol       bool   `mapstructure:"require_symbol"`        // 必须包含符号
+	RejectCommon        bool   `mapstructure:"reject_common"`         // 拒绝常见的泄露密码
+	CommonPasswordsFile string `mapstructure:"common_passwords_file"` // 额外的常见密码列表文件，每行一个，与内置列表合并
+	RejectUsername      bool   `mapstructure:"reject_username"`       // 拒绝包含用户名的密码
 }
 
 // New 创建新的配置实例
@@ -136,6 +336,10 @@ func New() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
@@ -182,7 +386,8 @@ func setDefaults() {
 	// JWT 默认配置
 	viper.SetDefault("jwt.secret", "your-secret-key")
 	viper.SetDefault("jwt.issuer", "vibe-coding-starter")
-	viper.SetDefault("jwt.expiration", 86400) // 24 hours
+	viper.SetDefault("jwt.expiration", 900)             // 15 minutes
+	viper.SetDefault("jwt.refresh_expiration", 2592000) // 30 days
 
 	// AI 默认配置
 	viper.SetDefault("ai.enabled", false)
@@ -194,19 +399,108 @@ func setDefaults() {
 	// CORS 默认配置
 	viper.SetDefault("cors.allow_origins", []string{"http://localhost:3000", "http://localhost:3001"})
 	viper.SetDefault("cors.allow_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"})
-	viper.SetDefault("cors.allow_headers", []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "X-Request-ID"})
-	viper.SetDefault("cors.expose_headers", []string{"Content-Length", "X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining"})
+	viper.SetDefault("cors.allow_headers", []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "X-Request-ID", "Upload-Offset", "Tus-Resumable"})
+	viper.SetDefault("cors.expose_headers", []string{"Content-Length", "X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Location", "Upload-Offset", "Upload-Length", "Tus-Resumable"})
 	viper.SetDefault("cors.allow_credentials", true)
 	viper.SetDefault("cors.max_age", 43200) // 12 hours
 
+	// Auth 默认配置
+	viper.SetDefault("auth.require_email_verification", false)
+	viper.SetDefault("auth.email_verification_expiration", 86400) // 24 hours
+	viper.SetDefault("auth.verification_resend_interval", 60)     // 1 minute
+	viper.SetDefault("auth.mfa_issuer", "Vibe Coding Starter")
+	viper.SetDefault("auth.mfa_pending_expiration", 300) // 5 minutes
+	viper.SetDefault("auth.require_admin_mfa", false)
+	viper.SetDefault("auth.login_max_attempts", 5)
+	viper.SetDefault("auth.login_lockout_duration", 900)       // 15 minutes
+	viper.SetDefault("auth.login_lockout_max_duration", 86400) // 24 hours
+	viper.SetDefault("auth.impersonation_expiration", 900)     // 15 minutes
+	viper.SetDefault("auth.registration_mode", "open")
+	viper.SetDefault("auth.invitation_expiration", 604800) // 7 days
+
 	// Security 默认配置
 	viper.SetDefault("security.enable_https", false)
 	viper.SetDefault("security.hsts_max_age", 31536000) // 1 year
 	viper.SetDefault("security.hsts_include_subdomains", true)
 	viper.SetDefault("security.hsts_preload", false)
 	viper.SetDefault("security.csp_policy", "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'")
-	viper.SetDefault("security.max_request_size", 10485760) // 10MB
-	viper.SetDefault("security.request_timeout", 30)        // 30 seconds
+	viper.SetDefault("security.max_request_size", 10485760)      // 10MB
+	viper.SetDefault("security.request_timeout", 30)             // 30 seconds
+	viper.SetDefault("security.password_reset_expiration", 3600) // 1 hour
+
+	// Storage 默认配置
+	viper.SetDefault("storage.default", "local")
+	viper.SetDefault("storage.local.root", "uploads")
+	viper.SetDefault("storage.local.base_url", "/uploads")
+	viper.SetDefault("storage.s3.region", "us-east-1")
+	viper.SetDefault("storage.s3.bucket", "")
+	viper.SetDefault("storage.s3.access_key", "")
+	viper.SetDefault("storage.s3.secret_key", "")
+	viper.SetDefault("storage.oss.region", "oss-cn-hangzhou")
+	viper.SetDefault("storage.oss.bucket", "")
+	viper.SetDefault("storage.oss.access_key", "")
+	viper.SetDefault("storage.oss.secret_key", "")
+	viper.SetDefault("storage.signing.default_ttl", 3600) // 1 hour
+	viper.SetDefault("storage.signing.max_ttl", 604800)   // 7 days
+	viper.SetDefault("storage.gc.interval", 86400)        // 24 hours
+	viper.SetDefault("storage.gc.grace_period", 3600)     // 1 hour
+	viper.SetDefault("storage.gc.dry_run", true)
+	viper.SetDefault("storage.gc.delete_orphans", true)
+	viper.SetDefault("storage.gc.purge_missing", false)
+
+	// Upload 默认配置
+	viper.SetDefault("upload.max_size", 10485760)          // 10MB
+	viper.SetDefault("upload.max_request_size", 52428800)  // 50MB
+	viper.SetDefault("upload.resumable_expiration", 86400) // 24 hours
+	viper.SetDefault("upload.cleanup_interval", 3600)      // 1 hour
+	viper.SetDefault("upload.type_limits", []map[string]interface{}{
+		{"type": "video/*", "max_size": 4294967296}, // 4GB，大文件通过断点续传上传
+	})
+	viper.SetDefault("upload.role_quotas", map[string]int64{
+		"user": 1073741824, // 1GB
+	})
+	viper.SetDefault("upload.image.variants", []map[string]interface{}{
+		{"name": "thumbnail", "width": 200, "height": 200},
+		{"name": "web", "width": 1200, "height": 1200},
+	})
+	viper.SetDefault("upload.image.generate_on_upload", false)
+	viper.SetDefault("upload.image.quality", 85)
+	viper.SetDefault("upload.image.max_pixels", 40000000)
+
+	// Email 默认配置
+	viper.SetDefault("email.enabled", false)
+	viper.SetDefault("email.driver", "smtp")
+	viper.SetDefault("email.smtp_port", 587)
+	viper.SetDefault("email.encryption", "starttls")
+	viper.SetDefault("email.from_email", "noreply@example.com")
+	viper.SetDefault("email.from_name", "Vibe Coding Starter")
+	viper.SetDefault("email.file_dir", "tmp/mail")
+	viper.SetDefault("email.link_base_url", "http://localhost:3000")
+
+	// OAuth 默认配置
+	viper.SetDefault("oauth.state_expiration", 600) // 10 minutes
+	viper.SetDefault("oauth.auto_register", true)
+
+	// Privacy 默认配置
+	viper.SetDefault("privacy.export_expiration", 86400)       // 24 hours
+	viper.SetDefault("privacy.export_interval", 3600)          // 1 hour
+	viper.SetDefault("privacy.deletion_grace_period", 2592000) // 30 days
+	viper.SetDefault("privacy.content_policy", "delete")
+	viper.SetDefault("privacy.reassign_to", "")
+	viper.SetDefault("privacy.purge_interval", 3600) // 1 hour
+
+	// Password 默认配置
+	viper.SetDefault("password.algorithm", "argon2id")
+	viper.SetDefault("password.bcrypt_cost", 10)
+	viper.SetDefault("password.argon2.memory", 65536) // 64MB
+	viper.SetDefault("password.argon2.iterations", 3)
+	viper.SetDefault("password.argon2.parallelism", 2)
+	viper.SetDefault("password.argon2.salt_length", 16)
+	viper.SetDefault("password.argon2.key_length", 32)
+	viper.SetDefault("password.policy.min_length", 8)
+	viper.SetDefault("password.policy.max_length", 128)
+	viper.SetDefault("password.policy.reject_common", true)
+	viper.SetDefault("password.policy.reject_username", true)
 }
 
 // GetDSN 获取数据库连接字符串
@@ -273,5 +567,19 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
+
+// validate 检查只能取固定值的配置项，拼写错误时启动失败，而不是按默认行为运行
+func (c *Config) validate() error {
+	switch c.Auth.RegistrationMode {
+	case "", "open", "invite_only", "closed":
+	default:
+		return fmt.Errorf("invalid auth.registration_mode %q: must be one of open, invite_only, closed", c.Auth.RegistrationMode)
+	}
+	return nil
+}
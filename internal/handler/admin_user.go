@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// AdminUserHandler 管理员用户管理处理器
+type AdminUserHandler struct {
+	adminUserService service.AdminUserService
+	logger           logger.Logger
+}
+
+// NewAdminUserHandler 创建管理员用户管理处理器
+func NewAdminUserHandler(
+	adminUserService service.AdminUserService,
+	logger logger.Logger,
+) *AdminUserHandler {
+	return &AdminUserHandler{
+		adminUserService: adminUserService,
+		logger:           logger,
+	}
+}
+
+// CreateUser 直接创建用户
+// @Summary 创建用户
+// @Description 管理员直接创建用户，可指定角色和状态，不需要验证邮箱
+// @Tags admin-users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.AdminCreateUserRequest true "用户信息"
+// @Success 201 {object} model.PublicUser
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users [post]
+func (h *AdminUserHandler) CreateUser(c *gin.Context) {
+	var req service.AdminCreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.adminUserService.CreateUser(c.Request.Context(), c.GetUint("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err, "create_user_failed", "Failed to create user")
+		return
+	}
+
+	c.Set("security_event", "user_created")
+	c.Set("security_target", strconv.FormatUint(uint64(user.ID), 10))
+	c.JSON(http.StatusCreated, user.ToPublic())
+}
+
+// UpdateStatus 修改用户状态
+// @Summary 修改用户状态
+// @Description 启用、禁用或封禁用户，禁用和封禁时撤销用户的所有会话
+// @Tags admin-users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body service.UpdateUserStatusRequest true "用户状态"
+// @Success 200 {object} model.PublicUser
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/status [put]
+func (h *AdminUserHandler) UpdateStatus(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.adminUserService.UpdateStatus(c.Request.Context(), c.GetUint("user_id"), id, req.Status)
+	if err != nil {
+		h.handleError(c, err, "update_status_failed", "Failed to update user status")
+		return
+	}
+
+	c.Set("security_event", "user_status_changed")
+	c.Set("security_target", strconv.FormatUint(uint64(id), 10))
+	c.JSON(http.StatusOK, user.ToPublic())
+}
+
+// UpdateRole 修改用户角色
+// @Summary 修改用户角色
+// @Description 修改用户的 users.role 并撤销其会话，用户需重新登录后使用新角色
+// @Tags admin-users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body service.UpdateUserRoleRequest true "用户角色"
+// @Success 200 {object} model.PublicUser
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/role [put]
+func (h *AdminUserHandler) UpdateRole(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.adminUserService.UpdateRole(c.Request.Context(), c.GetUint("user_id"), id, req.Role)
+	if err != nil {
+		h.handleError(c, err, "update_role_failed", "Failed to update user role")
+		return
+	}
+
+	c.Set("security_event", "user_role_changed")
+	c.Set("security_target", strconv.FormatUint(uint64(id), 10))
+	c.JSON(http.StatusOK, user.ToPublic())
+}
+
+// ForcePasswordReset 强制重置用户密码
+// @Summary 强制重置密码
+// @Description 使用户的旧密码立即失效并撤销所有会话，同时向用户邮箱发送重置链接
+// @Tags admin-users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/password-reset [post]
+func (h *AdminUserHandler) ForcePasswordReset(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminUserService.ForcePasswordReset(c.Request.Context(), c.GetUint("user_id"), id); err != nil {
+		h.handleError(c, err, "password_reset_failed", "Failed to reset user password")
+		return
+	}
+
+	c.Set("security_event", "password_reset_forced")
+	c.Set("security_target", strconv.FormatUint(uint64(id), 10))
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Password reset email sent",
+	})
+}
+
+// Impersonate 模拟用户登录
+// @Summary 模拟用户登录
+// @Description 签发以该用户身份访问的短期令牌，令牌的 act 声明记录实际操作的管理员，不能刷新，也不能修改密码、两步验证等账户安全设置
+// @Tags admin-users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} service.ImpersonationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (h *AdminUserHandler) Impersonate(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.adminUserService.Impersonate(c.Request.Context(), c.GetUint("user_id"), id)
+	if err != nil {
+		h.handleError(c, err, "impersonate_failed", "Failed to impersonate user")
+		return
+	}
+
+	c.Set("security_event", "impersonation_started")
+	c.Set("security_target", strconv.FormatUint(uint64(id), 10))
+	c.JSON(http.StatusOK, response)
+}
+
+// RegisterAdminRoutes 注册管理员路由
+func (h *AdminUserHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.POST("/users", h.CreateUser)
+	r.PUT("/users/:id/status", h.UpdateStatus)
+	r.PUT("/users/:id/role", h.UpdateRole)
+	r.POST("/users/:id/password-reset", h.ForcePasswordReset)
+	r.POST("/users/:id/impersonate", h.Impersonate)
+}
+
+// parseID 解析路径中的用户 ID，失败时返回 400
+func (h *AdminUserHandler) parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError 将用户管理服务的错误转换为响应
+func (h *AdminUserHandler) handleError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "user_not_found",
+			Message: "User not found",
+		})
+	case errors.Is(err, service.ErrUserExists):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "user_exists",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidUserInput):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrWeakPassword):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "weak_password",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrCannotModifySelf):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "cannot_modify_self",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "permission_not_held",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrImpersonationNotAllowed):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "impersonation_not_allowed",
+			Message: "Admins, inactive users and users holding permissions you lack cannot be impersonated",
+		})
+	default:
+		h.logger.Error(message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   code,
+			Message: message,
+		})
+	}
+}
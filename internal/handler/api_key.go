@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// APIKeyHandler 个人 API Key 处理器
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	logger        logger.Logger
+}
+
+// NewAPIKeyHandler 创建个人 API Key 处理器
+func NewAPIKeyHandler(
+	apiKeyService service.APIKeyService,
+	logger logger.Logger,
+) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// ListMyAPIKeys 获取当前用户的 API Key
+// @Summary 获取当前用户的 API Key
+// @Description 获取当前用户未撤销的 API Key，包括已过期的，不返回明文
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.APIKey
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/api-keys [get]
+func (h *APIKeyHandler) ListMyAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	keys, err := h.apiKeyService.List(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to list api keys", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_api_keys_failed",
+			Message: "Failed to list API keys",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateMyAPIKey 为当前用户创建 API Key
+// @Summary 创建 API Key
+// @Description 创建供脚本等机器客户端使用的 API Key，通过 X-API-Key 请求头认证；明文只在本次响应中返回
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateAPIKeyRequest true "API Key 名称、权限和过期时间"
+// @Success 201 {object} service.CreatedAPIKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/api-keys [post]
+func (h *APIKeyHandler) CreateMyAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req service.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" || len(req.Name) > 100 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Name is required and must be at most 100 characters",
+		})
+		return
+	}
+
+	created, err := h.apiKeyService.Create(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAPIKeyScope), errors.Is(err, service.ErrInvalidAPIKeyExpiry):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_error",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrAPIKeyLimitReached):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "api_key_limit_reached",
+				Message: err.Error(),
+			})
+		default:
+			h.logger.Error("Failed to create api key", "user_id", userID, "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "create_api_key_failed",
+				Message: "Failed to create API key",
+			})
+		}
+		return
+	}
+
+	c.Set("security_event", "api_key_created")
+	c.Set("security_target", strconv.FormatUint(uint64(created.APIKey.ID), 10))
+	c.JSON(http.StatusCreated, created)
+}
+
+// RevokeMyAPIKey 撤销当前用户的指定 API Key
+// @Summary 撤销 API Key
+// @Description 撤销当前用户的指定 API Key，立即失效
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API Key ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/me/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeMyAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid API key ID",
+		})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), userID.(uint), uint(id)); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "api_key_not_found",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to revoke api key", "user_id", userID, "api_key_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "revoke_api_key_failed",
+			Message: "Failed to revoke API key",
+		})
+		return
+	}
+
+	c.Set("security_event", "api_key_revoked")
+	c.Set("security_target", c.Param("id"))
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "API key revoked successfully",
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *APIKeyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/users/me/api-keys", h.ListMyAPIKeys)
+	r.POST("/users/me/api-keys", h.CreateMyAPIKey)
+	r.DELETE("/users/me/api-keys/:id", h.RevokeMyAPIKey)
+}
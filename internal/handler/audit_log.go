@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// maxAuditLogPageSize 审计日志每页最大条数
+const maxAuditLogPageSize = 100
+
+// AuditLogHandler 审计日志处理器
+type AuditLogHandler struct {
+	auditService service.AuditService
+	logger       logger.Logger
+}
+
+// NewAuditLogHandler 创建审计日志处理器
+func NewAuditLogHandler(
+	auditService service.AuditService,
+	logger logger.Logger,
+) *AuditLogHandler {
+	return &AuditLogHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// List 查询审计日志
+// @Summary 查询审计日志
+// @Description 按时间倒序查询安全事件和管理员操作的审计日志（需要管理员权限）
+// @Tags audit-logs
+// @Produce json
+// @Security BearerAuth
+// @Param actor_id query int false "操作人ID"
+// @Param action query string false "操作，如 article.update"
+// @Param resource_type query string false "资源类型，如 article"
+// @Param resource_id query string false "资源ID"
+// @Param request_id query string false "请求ID"
+// @Param from query string false "开始时间（RFC3339）"
+// @Param to query string false "结束时间（RFC3339）"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/audit-logs [get]
+func (h *AuditLogHandler) List(c *gin.Context) {
+	opts, err := h.parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	logs, total, err := h.auditService.List(c.Request.Context(), opts)
+	if err != nil {
+		h.logger.Error("Failed to list audit logs", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_audit_logs_failed",
+			Message: "Failed to list audit logs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{
+		Data:  logs,
+		Total: total,
+		Page:  opts.Page,
+		Size:  opts.PageSize,
+	})
+}
+
+// RegisterAdminRoutes 注册管理员路由
+func (h *AuditLogHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.GET("/audit-logs", h.List)
+}
+
+// parseListOptions 解析查询参数
+func (h *AuditLogHandler) parseListOptions(c *gin.Context) (repository.ListOptions, error) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize < 1 || pageSize > maxAuditLogPageSize {
+		pageSize = maxAuditLogPageSize
+	}
+
+	filters := make(map[string]interface{})
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := strconv.ParseUint(actorID, 10, 32)
+		if err != nil {
+			return repository.ListOptions{}, errors.New("actor_id must be a positive integer")
+		}
+		filters["actor_id"] = uint(id)
+	}
+	for _, key := range []string{"action", "resource_type", "resource_id", "request_id"} {
+		if value := c.Query(key); value != "" {
+			filters[key] = value
+		}
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return repository.ListOptions{}, errors.New("from must be an RFC3339 time")
+		}
+		filters["created_after"] = t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return repository.ListOptions{}, errors.New("to must be an RFC3339 time")
+		}
+		filters["created_before"] = t
+	}
+
+	return repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+		Filters:  filters,
+	}, nil
+}
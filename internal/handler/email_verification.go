@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// EmailVerificationHandler 邮箱验证处理器
+type EmailVerificationHandler struct {
+	verificationService service.EmailVerificationService
+	logger              logger.Logger
+}
+
+// NewEmailVerificationHandler 创建邮箱验证处理器
+func NewEmailVerificationHandler(
+	verificationService service.EmailVerificationService,
+	logger logger.Logger,
+) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationService: verificationService,
+		logger:              logger,
+	}
+}
+
+// Verify 验证邮箱
+// @Summary 验证邮箱
+// @Description 使用验证邮件中的一次性令牌确认邮箱并激活账户
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body service.VerifyEmailRequest true "验证邮箱请求"
+// @Success 200 {object} model.PublicUser
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/email/verify [post]
+func (h *EmailVerificationHandler) Verify(c *gin.Context) {
+	var req service.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Token is required",
+		})
+		return
+	}
+
+	user, err := h.verificationService.Verify(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_verification_token",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to verify email", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "verify_email_failed",
+			Message: "Failed to verify email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.ToPublic())
+}
+
+// Resend 重新发送验证邮件
+// @Summary 重新发送验证邮件
+// @Description 为尚未验证邮箱的账户重新发送验证邮件，同一邮箱在限流间隔内只能请求一次，无论邮箱是否已注册都返回相同的响应
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body service.ResendVerificationRequest true "重发验证邮件请求"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/users/email/resend [post]
+func (h *EmailVerificationHandler) Resend(c *gin.Context) {
+	var req service.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Email is required",
+		})
+		return
+	}
+
+	if err := h.verificationService.Resend(c.Request.Context(), &req); err != nil {
+		if errors.Is(err, service.ErrVerificationResendTooSoon) {
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{
+				Error:   "too_many_requests",
+				Message: err.Error(),
+			})
+			return
+		}
+		// 内部错误只记录日志，响应保持一致以免泄露邮箱是否存在
+		h.logger.Error("Failed to resend verification email", "error", err)
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "If the email is awaiting verification, a new verification link has been sent",
+	})
+}
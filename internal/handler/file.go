@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/repository"
 	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/imaging"
 	"vibe-coding-starter/pkg/logger"
 )
 
@@ -37,12 +41,26 @@ func NewFileHandler(
 // @Security BearerAuth
 // @Param file formData file true "文件"
 // @Param is_public formData bool false "是否公开" default(false)
+// @Param storage_type formData string false "存储驱动" Enums(local, s3, oss)
 // @Success 201 {object} model.File
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} PolicyErrorResponse
+// @Failure 415 {object} PolicyErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/files/upload [post]
 func (h *FileHandler) Upload(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
 	// 获取上传的文件
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -66,32 +84,34 @@ func (h *FileHandler) Upload(c *gin.Context) {
 	}
 	defer src.Close()
 
-	// 读取文件数据
-	fileData := make([]byte, file.Size)
-	if _, err := src.Read(fileData); err != nil {
-		h.logger.Error("Failed to read file data", "error", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "file_read_failed",
-			Message: "Failed to read file",
-		})
-		return
-	}
-
 	// 获取其他参数
 	isPublic := c.DefaultPostForm("is_public", "false") == "true"
+	storageType := c.PostForm("storage_type") // 为空时使用默认存储驱动
 
 	// 创建上传请求
 	req := &service.UploadRequest{
 		FileName:    file.Filename,
 		FileSize:    file.Size,
 		MimeType:    file.Header.Get("Content-Type"),
-		FileData:    fileData,
+		Reader:      src,
 		IsPublic:    isPublic,
-		StorageType: "local", // 默认本地存储
+		StorageType: storageType,
+		OwnerID:     userID.(uint),
+		OwnerRole:   c.GetString("user_role"),
 	}
 
 	uploadedFile, err := h.fileService.Upload(c.Request.Context(), req)
 	if err != nil {
+		if respondPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error:   "quota_exceeded",
+				Message: err.Error(),
+			})
+			return
+		}
 		h.logger.Error("Failed to upload file", "filename", file.Filename, "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "upload_failed",
@@ -109,8 +129,10 @@ func (h *FileHandler) Upload(c *gin.Context) {
 // @Tags files
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "文件ID"
 // @Success 200 {object} model.File
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/files/{id} [get]
@@ -134,6 +156,14 @@ func (h *FileHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	if !h.canAccess(c, file) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "forbidden",
+			Message: "You do not have access to this file",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, file)
 }
 
@@ -143,8 +173,14 @@ func (h *FileHandler) GetByID(c *gin.Context) {
 // @Tags files
 // @Accept json
 // @Produce application/octet-stream
+// @Security BearerAuth
 // @Param id path int true "文件ID"
+// @Param Range header string false "字节范围，例如 bytes=0-1023"
+// @Param If-None-Match header string false "文件哈希 ETag"
 // @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Success 304 "Not Modified"
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/files/{id}/download [get]
@@ -158,39 +194,293 @@ func (h *FileHandler) Download(c *gin.Context) {
 		return
 	}
 
+	// 先检查访问权限再打开存储对象，无权访问时不读取文件内容
+	file, err := h.fileService.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.Error("Failed to get file for download", "id", id, "error", err)
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "download_failed",
+			Message: "File not found",
+		})
+		return
+	}
+
+	if !h.canAccess(c, file) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "forbidden",
+			Message: "You do not have access to this file",
+		})
+		return
+	}
+
+	response, err := h.fileService.Download(c.Request.Context(), file.ID)
+	if err != nil {
+		h.logger.Error("Failed to download file", "id", id, "error", err)
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "download_failed",
+			Message: "File not found",
+		})
+		return
+	}
+	defer response.Content.Close()
+
+	h.serveContent(c, response)
+	h.recordDownload(c, response.File.ID)
+}
+
+// CreateSignedURL 生成签名下载链接
+// @Summary 生成签名下载链接
+// @Description 为文件生成限时签名下载链接，持有链接者无需登录即可下载
+// @Tags files
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文件ID"
+// @Param request body SignedURLRequest false "有效期"
+// @Success 201 {object} service.SignedURLResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/files/{id}/signed-url [post]
+func (h *FileHandler) CreateSignedURL(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid file ID",
+		})
+		return
+	}
+
+	var req SignedURLRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	file, err := h.fileService.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "file_not_found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if !h.canAccess(c, file) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "forbidden",
+			Message: "You do not have access to this file",
+		})
+		return
+	}
+
+	response, err := h.fileService.CreateSignedURL(c.Request.Context(), file.ID, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		h.logger.Error("Failed to create signed url", "id", id, "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "signed_url_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// DownloadSigned 通过签名链接下载文件
+// @Summary 通过签名链接下载文件
+// @Description 验证签名与有效期后下载文件，不需要认证
+// @Tags files
+// @Produce application/octet-stream
+// @Param id path int true "文件ID"
+// @Param expires query int true "过期时间戳"
+// @Param kid query string true "签名密钥ID"
+// @Param signature query string true "签名"
+// @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/files/{id}/signed [get]
+func (h *FileHandler) DownloadSigned(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid file ID",
+		})
+		return
+	}
+
+	if err := h.fileService.VerifySignedURL(c.Request.Context(), uint(id), c.Request.URL.Query()); err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "invalid_signature",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	response, err := h.fileService.Download(c.Request.Context(), uint(id))
 	if err != nil {
 		h.logger.Error("Failed to download file", "id", id, "error", err)
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "download_failed",
+			Message: "File not found",
+		})
+		return
+	}
+	defer response.Content.Close()
+
+	// 签名链接可能被转发，禁止中间缓存
+	c.Header("Cache-Control", "private, no-store")
+	h.serveContent(c, response)
+	h.recordDownload(c, response.File.ID)
+}
+
+// GetVariant 获取图片变体
+// @Summary 获取图片变体
+// @Description 获取图片文件的缩略图等变体，变体不存在时按配置尺寸生成，公开文件无需认证
+// @Tags files
+// @Produce image/jpeg
+// @Produce image/png
+// @Param id path int true "文件ID"
+// @Param name path string true "变体名称，例如 thumbnail、web"
+// @Success 200 {file} binary
+// @Success 304 "Not Modified"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/files/{id}/variants/{name} [get]
+func (h *FileHandler) GetVariant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid file ID",
+		})
+		return
+	}
+
+	file, err := h.fileService.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "file_not_found",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	// 设置响应头
-	c.Header("Content-Disposition", "attachment; filename="+response.File.OriginalName)
-	c.Header("Content-Type", response.File.MimeType)
-	c.Header("Content-Length", strconv.FormatInt(response.File.Size, 10))
+	if !h.canAccess(c, file) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "forbidden",
+			Message: "You do not have access to this file",
+		})
+		return
+	}
 
-	// 返回文件数据
-	c.Data(http.StatusOK, response.File.MimeType, response.FileData)
+	response, err := h.fileService.GetVariant(c.Request.Context(), file.ID, c.Param("name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrVariantNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "variant_not_found",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrNotImage):
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Error:   "not_an_image",
+				Message: err.Error(),
+			})
+		case errors.Is(err, imaging.ErrImageTooLarge):
+			c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error:   "image_too_large",
+				Message: err.Error(),
+			})
+		default:
+			h.logger.Error("Failed to get image variant", "id", id, "variant", c.Param("name"), "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "variant_failed",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+	defer response.Content.Close()
+
+	// 变体内容由原文件哈希和变体名称决定
+	c.Header("Content-Type", response.MimeType)
+	if response.File.Hash != "" {
+		c.Header("ETag", `"`+response.File.Hash+"-"+response.Name+`"`)
+	}
+	http.ServeContent(c.Writer, c.Request, "", response.ModTime, response.Content)
 }
 
-// List 获取文件列表
-// @Summary 获取文件列表
-// @Description 获取文件列表
+// ListUserFiles 获取当前用户的文件列表（需要认证）
+// @Summary 获取当前用户的文件列表
+// @Description 获取当前登录用户上传的文件列表
 // @Tags files
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
-// @Param search query string false "搜索关键词"
 // @Success 200 {object} ListResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/files [get]
+func (h *FileHandler) ListUserFiles(c *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	opts := h.parseListOptions(c)
+	files, total, err := h.fileService.GetByOwner(c.Request.Context(), userID.(uint), opts)
+	if err != nil {
+		h.logger.Error("Failed to get user files", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "get_files_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{
+		Data:  files,
+		Total: total,
+		Page:  opts.Page,
+		Size:  opts.PageSize,
+	})
+}
+
+// List 获取所有文件列表（管理员专用）
+// @Summary 获取所有文件列表
+// @Description 获取系统中所有文件列表，仅管理员可用
+// @Tags files
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param search query string false "搜索关键词"
+// @Success 200 {object} ListResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/files [get]
 func (h *FileHandler) List(c *gin.Context) {
 	opts := h.parseListOptions(c)
 	files, total, err := h.fileService.List(c.Request.Context(), opts)
@@ -221,6 +511,7 @@ func (h *FileHandler) List(c *gin.Context) {
 // @Param id path int true "文件ID"
 // @Success 200 {object} SuccessResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/files/{id} [delete]
@@ -249,24 +540,93 @@ func (h *FileHandler) Delete(c *gin.Context) {
 }
 
 // RegisterRoutes 注册路由
-func (h *FileHandler) RegisterRoutes(r *gin.RouterGroup) {
+func (h *FileHandler) RegisterRoutes(r *gin.RouterGroup, ownership ...gin.HandlerFunc) {
 	files := r.Group("/files")
 	{
+		// 认证在服务器层面已经处理，所有权检查由调用方传入
+		files.POST("/upload", h.Upload)
+		files.GET("", h.ListUserFiles)
 		files.GET("/:id", h.GetByID)
 		files.GET("/:id/download", h.Download)
-		
-		// 需要认证的路由
-		authenticated := files.Group("")
-		authenticated.Use(AuthMiddleware())
-		{
-			authenticated.POST("/upload", h.Upload)
-			authenticated.GET("", h.List)
-			authenticated.DELETE("/:id", h.Delete)
-		}
+		files.POST("/:id/signed-url", h.CreateSignedURL)
+		files.DELETE("/:id", append(ownership, h.Delete)...)
+	}
+}
+
+// RegisterPublicRoutes 注册公共路由（签名验证或文件公开性检查代替认证）
+func (h *FileHandler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.GET("/files/:id/signed", h.DownloadSigned)
+	r.GET("/files/:id/variants/:name", h.GetVariant)
+}
+
+// RegisterAdminRoutes 注册管理员路由
+func (h *FileHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	files := r.Group("/files")
+	{
+		files.GET("", h.List)
+		files.GET("/:id", h.GetByID)
+		files.DELETE("/:id", h.Delete)
 	}
 }
 
 // 辅助方法
+func (h *FileHandler) serveContent(c *gin.Context, response *service.DownloadResponse) {
+	// 设置响应头，ETag 使用文件内容哈希以支持 If-None-Match
+	c.Header("Content-Disposition", "attachment; filename="+response.File.OriginalName)
+	c.Header("Content-Type", response.File.MimeType)
+	if response.File.Hash != "" {
+		c.Header("ETag", `"`+response.File.Hash+`"`)
+	}
+
+	// 流式返回文件数据，支持 Range 请求
+	http.ServeContent(c.Writer, c.Request, response.File.OriginalName, response.File.UpdatedAt, response.Content)
+}
+
+// recordDownload 只有返回完整内容时才计入下载次数，范围请求和 304 响应不计入
+func (h *FileHandler) recordDownload(c *gin.Context, id uint) {
+	if c.Writer.Status() != http.StatusOK {
+		return
+	}
+	if err := h.fileService.RecordDownload(c.Request.Context(), id); err != nil {
+		h.logger.Warn("Failed to record download", "id", id, "error", err)
+	}
+}
+
+// respondPolicyError 将上传策略错误写入响应，返回是否已处理
+func respondPolicyError(c *gin.Context, err error) bool {
+	var policyErr *service.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	status := http.StatusUnsupportedMediaType
+	if policyErr.IsSizeRule() {
+		status = http.StatusRequestEntityTooLarge
+	}
+	c.JSON(status, PolicyErrorResponse{
+		Error:   "upload_policy_violation",
+		Message: policyErr.Error(),
+		Policy:  policyErr,
+	})
+	return true
+}
+
+func (h *FileHandler) canAccess(c *gin.Context, file *model.File) bool {
+	if role, exists := c.Get("user_role"); exists && role == model.UserRoleAdmin {
+		return true
+	}
+	// 管理接口已按声明的权限检查过，拥有对应权限的自定义角色同样允许
+	if _, granted := c.Get("route_permission"); granted {
+		return true
+	}
+
+	var userID uint
+	if id, exists := c.Get("user_id"); exists {
+		userID, _ = id.(uint)
+	}
+	return file.CanAccess(userID)
+}
+
 func (h *FileHandler) parseListOptions(c *gin.Context) repository.ListOptions {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
@@ -280,3 +640,15 @@ func (h *FileHandler) parseListOptions(c *gin.Context) repository.ListOptions {
 		Order:    c.DefaultQuery("order", "desc"),
 	}
 }
+
+// SignedURLRequest 生成签名下载链接请求
+type SignedURLRequest struct {
+	ExpiresIn int `json:"expires_in" validate:"min=0"` // 有效期（秒），为 0 时使用默认值
+}
+
+// PolicyErrorResponse 上传策略错误响应，policy 说明违反的规则
+type PolicyErrorResponse struct {
+	Error   string               `json:"error"`
+	Message string               `json:"message"`
+	Policy  *service.PolicyError `json:"policy"`
+}
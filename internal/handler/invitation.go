@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// maxInvitationPageSize 邀请列表每页最大数量
+const maxInvitationPageSize = 100
+
+// InvitationHandler 注册邀请处理器
+type InvitationHandler struct {
+	invitationService service.InvitationService
+	logger            logger.Logger
+}
+
+// NewInvitationHandler 创建注册邀请处理器
+func NewInvitationHandler(
+	invitationService service.InvitationService,
+	logger logger.Logger,
+) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		logger:            logger,
+	}
+}
+
+// Lookup 获取邀请信息
+// @Summary 获取邀请信息
+// @Description 根据邀请链接中的令牌获取受邀邮箱和角色，用于预填注册表单
+// @Tags invitations
+// @Produce json
+// @Param token query string true "邀请令牌"
+// @Success 200 {object} service.InvitationInfo
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/invitation [get]
+func (h *InvitationHandler) Lookup(c *gin.Context) {
+	invitation, err := h.invitationService.Lookup(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInvitation) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "invalid_invitation",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to look up invitation", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "lookup_invitation_failed",
+			Message: "Failed to look up invitation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, service.InvitationInfo{
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		ExpiresAt: invitation.ExpiresAt,
+	})
+}
+
+// Create 创建邀请
+// @Summary 创建邀请
+// @Description 向邮箱发送一次性注册邀请，通过邀请注册的用户获得指定角色；同一邮箱之前未使用的邀请会被撤销
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateInvitationRequest true "邀请信息"
+// @Success 201 {object} service.CreatedInvitation
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/invitations [post]
+func (h *InvitationHandler) Create(c *gin.Context) {
+	var req service.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	created, err := h.invitationService.Create(c.Request.Context(), c.GetUint("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err, "create_invitation_failed", "Failed to create invitation")
+		return
+	}
+
+	c.Set("security_event", "invitation_created")
+	c.Set("security_target", strconv.FormatUint(uint64(created.Invitation.ID), 10))
+	c.JSON(http.StatusCreated, created)
+}
+
+// List 查询邀请
+// @Summary 查询邀请
+// @Description 按创建时间倒序查询注册邀请（需要管理员权限）
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param email query string false "受邀邮箱"
+// @Param status query string false "状态：pending、accepted、revoked 或 expired"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/invitations [get]
+func (h *InvitationHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize < 1 || pageSize > maxInvitationPageSize {
+		pageSize = maxInvitationPageSize
+	}
+
+	filters := make(map[string]interface{})
+	if email := c.Query("email"); email != "" {
+		filters["email"] = email
+	}
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case model.InvitationStatusPending, model.InvitationStatusAccepted,
+			model.InvitationStatusRevoked, model.InvitationStatusExpired:
+			filters["status"] = status
+		default:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "status must be pending, accepted, revoked or expired",
+			})
+			return
+		}
+	}
+
+	opts := repository.ListOptions{Page: page, PageSize: pageSize, Filters: filters}
+	invitations, total, err := h.invitationService.List(c.Request.Context(), opts)
+	if err != nil {
+		h.logger.Error("Failed to list invitations", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_invitations_failed",
+			Message: "Failed to list invitations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{
+		Data:  invitations,
+		Total: total,
+		Page:  opts.Page,
+		Size:  opts.PageSize,
+	})
+}
+
+// Revoke 撤销邀请
+// @Summary 撤销邀请
+// @Description 撤销尚未使用的邀请，撤销后邀请链接立即失效
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "邀请ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/invitations/{id} [delete]
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid invitation ID",
+		})
+		return
+	}
+
+	if err := h.invitationService.Revoke(c.Request.Context(), c.GetUint("user_id"), uint(id)); err != nil {
+		h.handleError(c, err, "revoke_invitation_failed", "Failed to revoke invitation")
+		return
+	}
+
+	c.Set("security_event", "invitation_revoked")
+	c.Set("security_target", strconv.FormatUint(id, 10))
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Invitation revoked",
+	})
+}
+
+// RegisterPublicRoutes 注册邀请信息查询路由
+func (h *InvitationHandler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.GET("/users/invitation", h.Lookup)
+}
+
+// RegisterAdminRoutes 注册管理员路由
+func (h *InvitationHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.POST("/invitations", h.Create)
+	r.GET("/invitations", h.List)
+	r.DELETE("/invitations/:id", h.Revoke)
+}
+
+// handleError 将邀请服务的错误转换为响应
+func (h *InvitationHandler) handleError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidUserInput):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "permission_not_held",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrUserExists):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "user_exists",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "invitation_not_found",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvitationNotPending):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "invitation_not_pending",
+			Message: err.Error(),
+		})
+	default:
+		h.logger.Error(message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   code,
+			Message: message,
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/token"
+)
+
+// JWKSHandler 访问令牌公钥发布处理器
+type JWKSHandler struct {
+	tokens *token.Manager
+	logger logger.Logger
+}
+
+// NewJWKSHandler 创建访问令牌公钥发布处理器
+func NewJWKSHandler(
+	tokens *token.Manager,
+	logger logger.Logger,
+) *JWKSHandler {
+	return &JWKSHandler{
+		tokens: tokens,
+		logger: logger,
+	}
+}
+
+// JWKS 获取验证访问令牌的公钥
+// @Summary 获取访问令牌公钥
+// @Description 以 JWKS 格式发布验证访问令牌的公钥，其他服务按令牌头部的 kid 选择公钥独立验证；密钥轮换期间会同时返回新旧公钥
+// @Tags auth
+// @Produce json
+// @Success 200 {object} token.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	// 允许验证方短时间缓存，轮换密钥时新公钥应提前发布
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokens.JWKS())
+}
+
+// RegisterRoutes 注册路由
+func (h *JWKSHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/.well-known/jwks.json", h.JWKS)
+}
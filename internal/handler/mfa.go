@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// MFAHandler 两步验证处理器
+type MFAHandler struct {
+	userService service.UserService
+	logger      logger.Logger
+}
+
+// NewMFAHandler 创建两步验证处理器
+func NewMFAHandler(
+	userService service.UserService,
+	logger logger.Logger,
+) *MFAHandler {
+	return &MFAHandler{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// Login 两步验证登录
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的 mfa_token 和身份验证器中的验证码（或恢复码）完成登录；同一 mfa_token 连续失败 5 次后失效
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body service.MFALoginRequest true "两步验证登录请求"
+// @Success 200 {object} service.LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/login/mfa [post]
+func (h *MFAHandler) Login(c *gin.Context) {
+	var req service.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "mfa_token and code are required",
+		})
+		return
+	}
+	req.Client = clientInfo(c)
+
+	response, err := h.userService.LoginMFA(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMFAToken) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "invalid_mfa_token",
+				Message: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidMFACode) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "invalid_mfa_code",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to complete mfa login", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "login_failed",
+			Message: "Failed to complete login",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetStatus 获取两步验证状态
+// @Summary 获取两步验证状态
+// @Description 获取当前用户是否开启两步验证及剩余恢复码数量
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} service.MFAStatus
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/mfa [get]
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	status, err := h.userService.GetMFAStatus(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to get mfa status", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "get_mfa_status_failed",
+			Message: "Failed to get two-factor authentication status",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll 开始绑定身份验证器
+// @Summary 开始绑定身份验证器
+// @Description 生成新的 TOTP 密钥和 otpauth URI（可生成二维码），确认验证码后才会开启两步验证；重复调用会替换未确认的密钥
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} service.MFAEnrollment
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/mfa [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	enrollment, err := h.userService.EnrollMFA(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.respondError(c, userID, "Failed to enroll mfa", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm 确认开启两步验证
+// @Summary 确认开启两步验证
+// @Description 提交身份验证器中的验证码以开启两步验证，返回的恢复码只显示这一次
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.MFACodeRequest true "验证码"
+// @Success 200 {object} service.MFARecoveryCodes
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/mfa/confirm [post]
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req service.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Code is required",
+		})
+		return
+	}
+
+	codes, err := h.userService.ConfirmMFA(c.Request.Context(), userID.(uint), c.GetString("session_id"), &req)
+	if err != nil {
+		h.respondError(c, userID, "Failed to confirm mfa", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 提交当前密码和验证码（或恢复码）关闭两步验证，密钥和恢复码随即删除
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.DisableMFARequest true "关闭两步验证请求"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req service.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Password and code are required",
+		})
+		return
+	}
+
+	if err := h.userService.DisableMFA(c.Request.Context(), userID.(uint), &req); err != nil {
+		h.respondError(c, userID, "Failed to disable mfa", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 提交身份验证器中的验证码生成新的恢复码，旧恢复码全部失效
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.MFACodeRequest true "验证码"
+// @Success 200 {object} service.MFARecoveryCodes
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req service.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Code is required",
+		})
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		h.respondError(c, userID, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// RegisterRoutes 注册路由
+func (h *MFAHandler) RegisterRoutes(r *gin.RouterGroup) {
+	mfa := r.Group("/users/me/mfa")
+	{
+		mfa.GET("", h.GetStatus)
+		mfa.POST("", h.Enroll)
+		mfa.POST("/confirm", h.Confirm)
+		mfa.POST("/disable", h.Disable)
+		mfa.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+}
+
+// respondError 将两步验证错误映射为响应
+func (h *MFAHandler) respondError(c *gin.Context, userID interface{}, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "mfa_already_enabled",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrMFANotEnabled):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "mfa_not_enabled",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_mfa_code",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidPassword):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_password",
+			Message: err.Error(),
+		})
+	default:
+		h.logger.Error(msg, "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "mfa_failed",
+			Message: msg,
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// oauthStateCookie 保存发起授权的 state 哈希，回调时校验是同一浏览器
+const oauthStateCookie = "oauth_state"
+
+// OAuthHandler 第三方登录处理器
+type OAuthHandler struct {
+	userService service.UserService
+	logger      logger.Logger
+}
+
+// NewOAuthHandler 创建第三方登录处理器
+func NewOAuthHandler(
+	userService service.UserService,
+	logger logger.Logger,
+) *OAuthHandler {
+	return &OAuthHandler{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// ListProviders 获取第三方登录提供方
+// @Summary 获取第三方登录提供方
+// @Description 获取已配置的 OpenID Connect 登录提供方，用于展示登录按钮
+// @Tags oauth
+// @Produce json
+// @Success 200 {array} service.OAuthProvider
+// @Router /api/v1/users/oauth/providers [get]
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.userService.ListOAuthProviders(c.Request.Context()))
+}
+
+// Authorize 发起第三方登录
+// @Summary 发起第三方登录
+// @Description 生成 state、nonce 和 PKCE 验证码后重定向到提供方授权页面，state 的哈希写入 HttpOnly Cookie 用于回调校验
+// @Tags oauth
+// @Param provider path string true "提供方标识"
+// @Success 302
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/oauth/{provider} [get]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	authorization, err := h.userService.StartOAuthLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrOAuthProviderNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "provider_not_found",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to start oauth login", "provider", c.Param("provider"), "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "oauth_failed",
+			Message: "Failed to start oauth login",
+		})
+		return
+	}
+
+	// 提供方回调是跨站的顶层跳转，SameSite=Lax 才会携带 Cookie
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, authorization.Binding, int(authorization.ExpiresIn.Seconds()), oauthCookiePath(c), "", isHTTPS(c), true)
+	c.Redirect(http.StatusFound, authorization.AuthorizationURL)
+}
+
+// Callback 第三方登录回调
+// @Summary 第三方登录回调
+// @Description 校验 state 与发起授权的浏览器 Cookie 匹配后使用授权码和 PKCE 验证码换取 ID 令牌，按提供方账户或已验证邮箱登录；开启两步验证的用户返回 mfa_token
+// @Tags oauth
+// @Produce json
+// @Param provider path string true "提供方标识"
+// @Param code query string true "授权码"
+// @Param state query string true "发起授权时生成的 state"
+// @Success 200 {object} service.LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/oauth/{provider}/callback [get]
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	// 用户在提供方拒绝授权
+	if errCode := c.Query("error"); errCode != "" {
+		message := c.Query("error_description")
+		if message == "" {
+			message = errCode
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "oauth_denied",
+			Message: message,
+		})
+		return
+	}
+
+	// state Cookie 只能使用一次
+	binding, _ := c.Cookie(oauthStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, "", -1, oauthCookiePath(c), "", isHTTPS(c), true)
+
+	req := service.OAuthCallbackRequest{
+		Provider: c.Param("provider"),
+		Code:     c.Query("code"),
+		State:    c.Query("state"),
+		Binding:  binding,
+		Client:   clientInfo(c),
+	}
+	if req.Code == "" || req.State == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "code and state are required",
+		})
+		return
+	}
+
+	response, err := h.userService.CompleteOAuthLogin(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, req.Provider, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListIdentities 获取当前用户关联的第三方账户
+// @Summary 获取当前用户关联的第三方账户
+// @Description 获取当前用户通过第三方登录关联的提供方账户
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.UserIdentity
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/identities [get]
+func (h *OAuthHandler) ListIdentities(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	identities, err := h.userService.ListIdentities(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to list identities", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_identities_failed",
+			Message: "Failed to list linked accounts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// RegisterPublicRoutes 注册第三方登录路由
+func (h *OAuthHandler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	oauth := r.Group("/users/oauth")
+	{
+		oauth.GET("/providers", h.ListProviders)
+		oauth.GET("/:provider", h.Authorize)
+		oauth.GET("/:provider/callback", h.Callback)
+	}
+}
+
+// RegisterRoutes 注册需要认证的路由
+func (h *OAuthHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/users/me/identities", h.ListIdentities)
+}
+
+// oauthCookiePath state Cookie 的路径，限定为第三方登录路由
+func oauthCookiePath(c *gin.Context) string {
+	fullPath := c.FullPath()
+	if i := strings.Index(fullPath, "/:provider"); i > 0 {
+		return fullPath[:i]
+	}
+	return "/"
+}
+
+// isHTTPS 检查请求是否通过 HTTPS 访问
+func isHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+// respondError 将第三方登录错误映射为响应
+func (h *OAuthHandler) respondError(c *gin.Context, provider string, err error) {
+	switch {
+	case errors.Is(err, service.ErrOAuthProviderNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "provider_not_found",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidOAuthState):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_state",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrOAuthFailed):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "oauth_failed",
+			Message: "Failed to verify the identity provider response",
+		})
+	case errors.Is(err, service.ErrOAuthEmailNotVerified):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "email_not_verified",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrOAuthAccountNotFound):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "account_not_found",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrAccountNotActive):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "account_not_active",
+			Message: err.Error(),
+		})
+	default:
+		h.logger.Error("Failed to complete oauth login", "provider", provider, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "login_failed",
+			Message: "Failed to complete login",
+		})
+	}
+}
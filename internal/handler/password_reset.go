@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// PasswordResetHandler 密码重置处理器
+type PasswordResetHandler struct {
+	resetService service.PasswordResetService
+	logger       logger.Logger
+}
+
+// NewPasswordResetHandler 创建密码重置处理器
+func NewPasswordResetHandler(
+	resetService service.PasswordResetService,
+	logger logger.Logger,
+) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		resetService: resetService,
+		logger:       logger,
+	}
+}
+
+// Forgot 申请重置密码
+// @Summary 申请重置密码
+// @Description 向邮箱发送一次性密码重置链接，无论邮箱是否已注册都返回相同的响应
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body service.ForgotPasswordRequest true "申请重置密码请求"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/users/password/forgot [post]
+func (h *PasswordResetHandler) Forgot(c *gin.Context) {
+	var req service.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Email is required",
+		})
+		return
+	}
+
+	// 内部错误只记录日志，响应保持一致以免泄露邮箱是否存在
+	if err := h.resetService.Forgot(c.Request.Context(), &req); err != nil {
+		h.logger.Error("Failed to process password reset request", "error", err)
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// Reset 重置密码
+// @Summary 重置密码
+// @Description 使用邮件中的一次性令牌设置新密码，成功后令牌失效并撤销该用户所有登录会话
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body service.ResetPasswordRequest true "重置密码请求"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/password/reset [post]
+func (h *PasswordResetHandler) Reset(c *gin.Context) {
+	var req service.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Token and new password are required",
+		})
+		return
+	}
+
+	if err := h.resetService.Reset(c.Request.Context(), &req); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidResetToken):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_reset_token",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "weak_password",
+				Message: err.Error(),
+			})
+		default:
+			h.logger.Error("Failed to reset password", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "reset_password_failed",
+				Message: "Failed to reset password",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Password reset successfully",
+	})
+}
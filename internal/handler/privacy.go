@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// PrivacyHandler 个人数据导出与账户注销处理器
+type PrivacyHandler struct {
+	privacyService service.PrivacyService
+	logger         logger.Logger
+}
+
+// NewPrivacyHandler 创建个人数据导出与账户注销处理器
+func NewPrivacyHandler(
+	privacyService service.PrivacyService,
+	logger logger.Logger,
+) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+		logger:         logger,
+	}
+}
+
+// RequestExport 申请导出个人数据
+// @Summary 申请导出个人数据
+// @Description 在后台将个人资料、文章、评论和文件信息打包为 ZIP，完成后通过邮件发送下载链接
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/export [post]
+func (h *PrivacyHandler) RequestExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	if err := h.privacyService.RequestExport(c.Request.Context(), userID.(uint)); err != nil {
+		h.respondError(c, userID, "Failed to request data export", err)
+		return
+	}
+
+	c.Set("security_event", "data_export_requested")
+	c.JSON(http.StatusAccepted, SuccessResponse{
+		Message: "Data export requested, a download link will be sent by email",
+	})
+}
+
+// DownloadExport 下载个人数据导出文件
+// @Summary 下载个人数据导出文件
+// @Description 使用邮件中的令牌下载导出文件，令牌在有效期内可以重复使用
+// @Tags privacy
+// @Produce application/zip
+// @Param token query string true "下载令牌"
+// @Success 200 {file} binary
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/export/download [get]
+func (h *PrivacyHandler) DownloadExport(c *gin.Context) {
+	download, err := h.privacyService.OpenExport(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExportToken) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "invalid_token",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to open data export", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "download_failed",
+			Message: "Failed to download data export",
+		})
+		return
+	}
+	defer download.Content.Close()
+
+	// 导出文件包含个人数据，禁止中间缓存
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", "attachment; filename="+download.FileName)
+	c.Header("Content-Type", "application/zip")
+	http.ServeContent(c.Writer, c.Request, download.FileName, download.CreatedAt, download.Content)
+}
+
+// DeleteAccount 申请注销账户
+// @Summary 申请注销账户
+// @Description 确认密码后申请注销当前账户，冷静期结束后个人数据被匿名化，文章、评论和文件按配置删除或转移；冷静期内可以撤销
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.DeleteAccountRequest true "当前密码"
+// @Success 202 {object} service.AccountDeletionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me [delete]
+func (h *PrivacyHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req service.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Password is required",
+		})
+		return
+	}
+
+	scheduledAt, err := h.privacyService.ScheduleDeletion(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		h.respondError(c, userID, "Failed to schedule account deletion", err)
+		return
+	}
+
+	c.Set("security_event", "account_deletion_scheduled")
+	c.JSON(http.StatusAccepted, service.AccountDeletionResponse{
+		DeletionScheduledAt: scheduledAt,
+	})
+}
+
+// CancelDeletion 撤销注销申请
+// @Summary 撤销注销申请
+// @Description 在冷静期内撤销当前账户的注销申请
+// @Tags privacy
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/deletion [delete]
+func (h *PrivacyHandler) CancelDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	if err := h.privacyService.CancelDeletion(c.Request.Context(), userID.(uint)); err != nil {
+		h.respondError(c, userID, "Failed to cancel account deletion", err)
+		return
+	}
+
+	c.Set("security_event", "account_deletion_cancelled")
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Account deletion cancelled",
+	})
+}
+
+// RegisterPublicRoutes 注册导出文件下载路由
+func (h *PrivacyHandler) RegisterPublicRoutes(r *gin.RouterGroup) {
+	r.GET("/users/export/download", h.DownloadExport)
+}
+
+// RegisterRoutes 注册需要认证的路由
+func (h *PrivacyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/users/me/export", h.RequestExport)
+	r.DELETE("/users/me", h.DeleteAccount)
+	r.DELETE("/users/me/deletion", h.CancelDeletion)
+}
+
+// respondError 将服务错误映射为响应
+func (h *PrivacyHandler) respondError(c *gin.Context, userID interface{}, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrExportTooFrequent):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Error:   "export_too_frequent",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidPassword):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_password",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrDeletionNotScheduled):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "deletion_not_scheduled",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "user_not_found",
+			Message: err.Error(),
+		})
+	default:
+		h.logger.Error(msg, "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "privacy_request_failed",
+			Message: msg,
+		})
+	}
+}
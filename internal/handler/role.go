@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// RoleHandler 角色与权限管理处理器
+type RoleHandler struct {
+	roleService service.RoleService
+	logger      logger.Logger
+}
+
+// NewRoleHandler 创建角色与权限管理处理器
+func NewRoleHandler(
+	roleService service.RoleService,
+	logger logger.Logger,
+) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+		logger:      logger,
+	}
+}
+
+// ListRoles 获取所有角色
+// @Summary 获取所有角色
+// @Description 获取所有角色及其权限，仅管理员可用
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.Role
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list roles", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_roles_failed",
+			Message: "Failed to list roles",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRole 获取角色详情
+// @Summary 获取角色详情
+// @Description 获取角色及其权限，仅管理员可用
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "角色ID"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/roles/{id} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	id, ok := h.parseID(c, "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	role, err := h.roleService.GetRole(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "get_role_failed", "Failed to get role")
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// CreateRole 创建自定义角色
+// @Summary 创建角色
+// @Description 创建自定义角色，权限格式为 resource:action[:scope]，仅管理员可用
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateRoleRequest true "角色信息"
+// @Success 201 {object} model.Role
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req service.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), c.GetUint("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err, "create_role_failed", "Failed to create role")
+		return
+	}
+
+	c.Set("security_event", "role_created")
+	c.Set("security_target", role.Name)
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole 更新角色
+// @Summary 更新角色
+// @Description 更新角色的显示名称、描述和权限，permissions 为 null 时不修改权限，仅管理员可用
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "角色ID"
+// @Param request body service.UpdateRoleRequest true "角色信息"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, ok := h.parseID(c, "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), c.GetUint("user_id"), id, &req)
+	if err != nil {
+		h.handleError(c, err, "update_role_failed", "Failed to update role")
+		return
+	}
+
+	c.Set("security_event", "role_updated")
+	c.Set("security_target", role.Name)
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole 删除自定义角色
+// @Summary 删除角色
+// @Description 删除自定义角色及其用户关联，内置角色不能删除，仅管理员可用
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "角色ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, ok := h.parseID(c, "Invalid role ID")
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), id); err != nil {
+		h.handleError(c, err, "delete_role_failed", "Failed to delete role")
+		return
+	}
+
+	c.Set("security_event", "role_deleted")
+	c.Set("security_target", strconv.FormatUint(uint64(id), 10))
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Role deleted successfully",
+	})
+}
+
+// ListPermissions 获取所有已定义的权限
+// @Summary 获取所有权限
+// @Description 获取角色中出现过的所有权限，仅管理员可用
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.Permission
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list permissions", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_permissions_failed",
+			Message: "Failed to list permissions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// GetUserRoles 获取用户的角色
+// @Summary 获取用户的角色
+// @Description 获取通过 user_roles 分配给用户的角色，为空时使用 users.role 对应的角色，仅管理员可用
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {array} model.Role
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/roles [get]
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	id, ok := h.parseID(c, "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	roles, err := h.roleService.GetUserRoles(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "get_user_roles_failed", "Failed to get user roles")
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// SetUserRoles 设置用户的角色
+// @Summary 设置用户的角色
+// @Description 替换用户的全部角色并立即刷新其权限，仅管理员可用
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body service.SetUserRolesRequest true "角色名称"
+// @Success 200 {array} model.Role
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/roles [put]
+func (h *RoleHandler) SetUserRoles(c *gin.Context) {
+	id, ok := h.parseID(c, "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req service.SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	roles, err := h.roleService.SetUserRoles(c.Request.Context(), c.GetUint("user_id"), id, &req)
+	if err != nil {
+		h.handleError(c, err, "set_user_roles_failed", "Failed to set user roles")
+		return
+	}
+
+	c.Set("security_event", "user_roles_changed")
+	c.Set("security_target", strconv.FormatUint(uint64(id), 10))
+	c.JSON(http.StatusOK, roles)
+}
+
+// RegisterAdminRoutes 注册管理员路由
+func (h *RoleHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	roles := r.Group("/roles")
+	{
+		roles.GET("", h.ListRoles)
+		roles.POST("", h.CreateRole)
+		roles.GET("/:id", h.GetRole)
+		roles.PUT("/:id", h.UpdateRole)
+		roles.DELETE("/:id", h.DeleteRole)
+	}
+
+	r.GET("/permissions", h.ListPermissions)
+	r.GET("/users/:id/roles", h.GetUserRoles)
+	r.PUT("/users/:id/roles", h.SetUserRoles)
+}
+
+// parseID 解析路径中的 ID，失败时返回 400
+func (h *RoleHandler) parseID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: message,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError 将角色服务的错误转换为响应
+func (h *RoleHandler) handleError(c *gin.Context, err error, code, message string) {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "role_not_found",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "user_not_found",
+			Message: "User not found",
+		})
+	case errors.Is(err, service.ErrRoleExists):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "role_exists",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrSystemRole):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "system_role",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "permission_not_held",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrCannotModifySelf):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "cannot_modify_self",
+			Message: err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidRoleName), errors.Is(err, service.ErrInvalidPermission):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	default:
+		h.logger.Error(message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   code,
+			Message: message,
+		})
+	}
+}
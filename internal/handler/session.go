@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// SessionHandler 登录会话处理器
+type SessionHandler struct {
+	tokenService service.TokenService
+	logger       logger.Logger
+}
+
+// NewSessionHandler 创建登录会话处理器
+func NewSessionHandler(
+	tokenService service.TokenService,
+	logger logger.Logger,
+) *SessionHandler {
+	return &SessionHandler{
+		tokenService: tokenService,
+		logger:       logger,
+	}
+}
+
+// Logout 退出登录
+// @Summary 退出登录
+// @Description 撤销当前访问令牌及其所属会话，该会话的刷新令牌随即失效
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/logout [post]
+func (h *SessionHandler) Logout(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	err := h.tokenService.Logout(c.Request.Context(), userID.(uint), c.GetString("session_id"), c.GetString("token_id"))
+	if err != nil {
+		h.logger.Error("Failed to logout", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "logout_failed",
+			Message: "Failed to logout",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Logged out successfully",
+	})
+}
+
+// ListMySessions 获取当前用户的登录会话
+// @Summary 获取当前用户的登录会话
+// @Description 获取当前用户未撤销且未过期的登录会话，最近活跃时间在登录和刷新令牌时更新
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.UserSession
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/sessions [get]
+func (h *SessionHandler) ListMySessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	sessions, err := h.tokenService.ListSessions(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_sessions_failed",
+			Message: "Failed to list sessions",
+		})
+		return
+	}
+
+	currentID := c.GetString("session_id")
+	for _, session := range sessions {
+		session.Current = currentID != "" && session.FamilyID == currentID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeMySession 撤销当前用户的指定会话
+// @Summary 撤销登录会话
+// @Description 撤销当前用户的指定会话，该会话的访问令牌和刷新令牌立即失效
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "会话ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/me/sessions/{id} [delete]
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid session ID",
+		})
+		return
+	}
+
+	if err := h.tokenService.RevokeSession(c.Request.Context(), userID.(uint), uint(id)); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "session_not_found",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to revoke session", "user_id", userID, "session_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "revoke_session_failed",
+			Message: "Failed to revoke session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Session revoked successfully",
+	})
+}
+
+// ListUserSessions 获取指定用户的登录会话（管理员专用）
+// @Summary 获取指定用户的登录会话
+// @Description 获取指定用户未撤销且未过期的登录会话，仅管理员可用
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {array} model.UserSession
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/sessions [get]
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	sessions, err := h.tokenService.ListSessions(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "user_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "list_sessions_failed",
+			Message: "Failed to list sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUserSessions 撤销指定用户的所有会话（管理员专用）
+// @Summary 撤销用户所有会话
+// @Description 撤销指定用户的所有登录会话，用户需要重新登录，仅管理员可用
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/sessions [delete]
+func (h *SessionHandler) RevokeUserSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	revoked, err := h.tokenService.RevokeAllSessions(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.Error("Failed to revoke user sessions", "user_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "revoke_sessions_failed",
+			Message: "Failed to revoke sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sessions revoked successfully",
+		"revoked": revoked,
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *SessionHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/users/logout", h.Logout)
+	r.GET("/users/me/sessions", h.ListMySessions)
+	r.DELETE("/users/me/sessions/:id", h.RevokeMySession)
+}
+
+// RegisterAdminRoutes 注册管理员路由
+func (h *SessionHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.GET("/users/:id/sessions", h.ListUserSessions)
+	r.DELETE("/users/:id/sessions", h.RevokeUserSessions)
+}
+
+// clientInfo 获取发起请求的客户端信息
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// StorageQuotaHandler 存储配额处理器
+type StorageQuotaHandler struct {
+	quotaService service.StorageQuotaService
+	logger       logger.Logger
+}
+
+// NewStorageQuotaHandler 创建存储配额处理器
+func NewStorageQuotaHandler(
+	quotaService service.StorageQuotaService,
+	logger logger.Logger,
+) *StorageQuotaHandler {
+	return &StorageQuotaHandler{
+		quotaService: quotaService,
+		logger:       logger,
+	}
+}
+
+// GetMyUsage 获取当前用户的存储用量
+// @Summary 获取当前用户的存储用量
+// @Description 获取当前用户已用空间、配额和剩余空间
+// @Tags storage
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} service.StorageUsageResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/me/storage [get]
+func (h *StorageQuotaHandler) GetMyUsage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.logger.Error("Failed to get storage usage", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "get_usage_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetUserUsage 获取指定用户的存储用量（管理员专用）
+// @Summary 获取指定用户的存储用量
+// @Description 获取指定用户已用空间、配额和剩余空间，仅管理员可用
+// @Tags storage
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} service.StorageUsageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/storage [get]
+func (h *StorageQuotaHandler) GetUserUsage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "get_usage_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// SetUserQuota 设置指定用户的存储配额（管理员专用）
+// @Summary 设置用户存储配额
+// @Description 为指定用户单独设置配额，quota_bytes 为 null 时恢复角色默认配额，0 表示不允许上传
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body SetStorageQuotaRequest true "配额"
+// @Success 200 {object} service.StorageUsageResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/storage [put]
+func (h *StorageQuotaHandler) SetUserQuota(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	var req SetStorageQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	usage, err := h.quotaService.SetQuota(c.Request.Context(), uint(id), req.QuotaBytes)
+	if err != nil {
+		h.logger.Error("Failed to set storage quota", "user_id", id, "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "set_quota_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// RegisterRoutes 注册路由
+func (h *StorageQuotaHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/users/me/storage", h.GetMyUsage)
+}
+
+// RegisterAdminRoutes 注册管理员路由
+func (h *StorageQuotaHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.GET("/users/:id/storage", h.GetUserUsage)
+	r.PUT("/users/:id/storage", h.SetUserQuota)
+}
+
+// SetStorageQuotaRequest 设置存储配额请求
+type SetStorageQuotaRequest struct {
+	QuotaBytes *int64 `json:"quota_bytes" validate:"omitempty,min=0"` // 为 null 时恢复角色默认配额，0 表示不允许上传
+}
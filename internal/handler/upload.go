@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/logger"
+)
+
+const (
+	// tusResumableVersion 兼容的 tus 协议版本
+	tusResumableVersion = "1.0.0"
+	// chunkContentType 分片请求的内容类型
+	chunkContentType = "application/offset+octet-stream"
+)
+
+// UploadHandler 断点续传上传处理器
+type UploadHandler struct {
+	uploadService service.ResumableUploadService
+	logger        logger.Logger
+}
+
+// NewUploadHandler 创建断点续传上传处理器
+func NewUploadHandler(
+	uploadService service.ResumableUploadService,
+	logger logger.Logger,
+) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+		logger:        logger,
+	}
+}
+
+// Create 创建上传会话
+// @Summary 创建上传会话
+// @Description 创建断点续传上传会话，返回的 Location 用于后续分片上传
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.CreateUploadSessionRequest true "上传会话信息"
+// @Success 201 {object} model.UploadSession
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} PolicyErrorResponse
+// @Failure 415 {object} PolicyErrorResponse
+// @Router /api/v1/uploads [post]
+func (h *UploadHandler) Create(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateUploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+	req.OwnerID = userID
+	req.OwnerRole = c.GetString("user_role")
+
+	session, err := h.uploadService.Create(c.Request.Context(), &req)
+	if err != nil {
+		if respondPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error:   "quota_exceeded",
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to create upload session", "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "create_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.setUploadHeaders(c, session)
+	c.Header("Location", c.Request.URL.Path+"/"+session.UploadID)
+	c.JSON(http.StatusCreated, session)
+}
+
+// Head 查询当前上传偏移量
+// @Summary 查询上传进度
+// @Description 返回 Upload-Offset 与 Upload-Length 响应头，客户端据此续传
+// @Tags uploads
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Success 200
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Router /api/v1/uploads/{id} [head]
+func (h *UploadHandler) Head(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.uploadService.Get(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.setUploadHeaders(c, session)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// Patch 在指定偏移量追加分片
+// @Summary 上传分片
+// @Description 请求头 Upload-Offset 必须等于服务器记录的偏移量
+// @Tags uploads
+// @Accept application/offset+octet-stream
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Param Upload-Offset header int true "分片起始偏移量"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Router /api/v1/uploads/{id} [patch]
+func (h *UploadHandler) Patch(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if c.ContentType() != chunkContentType {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+			Error:   "unsupported_media_type",
+			Message: "Content-Type must be " + chunkContentType,
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_offset",
+			Message: "Upload-Offset header is required",
+		})
+		return
+	}
+
+	session, err := h.uploadService.WriteChunk(c.Request.Context(), userID, c.Param("id"), offset, c.Request.Body)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.setUploadHeaders(c, session)
+	c.Status(http.StatusNoContent)
+}
+
+// Complete 完成上传并生成文件
+// @Summary 完成上传
+// @Description 所有分片上传完成后合并为文件
+// @Tags uploads
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Success 201 {object} model.File
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 413 {object} PolicyErrorResponse
+// @Failure 415 {object} PolicyErrorResponse
+// @Router /api/v1/uploads/{id}/complete [post]
+func (h *UploadHandler) Complete(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.uploadService.Complete(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, file)
+}
+
+// Abort 取消上传
+// @Summary 取消上传
+// @Description 取消上传会话并删除已上传的分片
+// @Tags uploads
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/uploads/{id} [delete]
+func (h *UploadHandler) Abort(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.uploadService.Abort(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Tus-Resumable", tusResumableVersion)
+	c.Status(http.StatusNoContent)
+}
+
+// RegisterRoutes 注册路由
+func (h *UploadHandler) RegisterRoutes(r *gin.RouterGroup) {
+	uploads := r.Group("/uploads")
+	{
+		uploads.POST("", h.Create)
+		uploads.HEAD("/:id", h.Head)
+		uploads.PATCH("/:id", h.Patch)
+		uploads.POST("/:id/complete", h.Complete)
+		uploads.DELETE("/:id", h.Abort)
+	}
+}
+
+// 辅助方法
+func (h *UploadHandler) currentUserID(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "User not authenticated",
+		})
+		return 0, false
+	}
+	return userID.(uint), true
+}
+
+func (h *UploadHandler) setUploadHeaders(c *gin.Context, session *model.UploadSession) {
+	c.Header("Tus-Resumable", tusResumableVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(session.UploadedSize, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.TotalSize, 10))
+}
+
+func (h *UploadHandler) handleError(c *gin.Context, err error) {
+	c.Header("Tus-Resumable", tusResumableVersion)
+	if respondPolicyError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "upload_not_found", Message: err.Error()})
+	case errors.Is(err, service.ErrUploadExpired):
+		c.JSON(http.StatusGone, ErrorResponse{Error: "upload_expired", Message: err.Error()})
+	case errors.Is(err, service.ErrUploadOffsetMismatch):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "offset_mismatch", Message: err.Error()})
+	case errors.Is(err, service.ErrUploadIncomplete), errors.Is(err, service.ErrUploadClosed):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "upload_conflict", Message: err.Error()})
+	case errors.Is(err, service.ErrUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "upload_too_large", Message: err.Error()})
+	case errors.Is(err, service.ErrQuotaExceeded):
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "quota_exceeded", Message: err.Error()})
+	default:
+		h.logger.Error("Resumable upload failed", "upload_id", c.Param("id"), "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "upload_failed", Message: err.Error()})
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -31,13 +33,14 @@ func NewUserHandler(
 
 // Register 用户注册
 // @Summary 用户注册
-// @Description 注册新用户账户
+// @Description 注册新用户账户，开启邮箱验证时新用户处于未激活状态，需确认验证邮件后才能登录；携带邀请令牌注册时邮箱需与受邀邮箱一致，用户获得邀请指定的角色且不需要验证邮箱
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param request body service.RegisterRequest true "注册请求"
 // @Success 201 {object} model.User
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "已关闭注册或只能通过邀请注册"
 // @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/users/register [post]
@@ -54,11 +57,39 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 	user, err := h.userService.Register(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("Failed to register user", "username", req.Username, "email", req.Email, "error", err)
-		c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "registration_failed",
-			Message: err.Error(),
-		})
+		switch {
+		case errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "weak_password",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrRegistrationClosed):
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "registration_closed",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrInvitationRequired):
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "invitation_required",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrInvalidInvitation):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_invitation",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrInvitationEmailMismatch):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invitation_email_mismatch",
+				Message: err.Error(),
+			})
+		default:
+			h.logger.Error("Failed to register user", "username", req.Username, "email", req.Email, "error", err)
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "registration_failed",
+				Message: err.Error(),
+			})
+		}
 		return
 	}
 
@@ -75,6 +106,8 @@ func (h *UserHandler) Register(c *gin.Context) {
 // @Success 200 {object} service.LoginResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "邮箱未验证"
+// @Failure 429 {object} ErrorResponse "连续登录失败，账户被暂时锁定"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/users/login [post]
 func (h *UserHandler) Login(c *gin.Context) {
@@ -91,9 +124,28 @@ func (h *UserHandler) Login(c *gin.Context) {
 	}
 
 	h.logger.Debug("Login request parsed", "username", req.Username)
+	req.Client = clientInfo(c)
 
 	response, err := h.userService.Login(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "email_not_verified",
+				Message: "Email address has not been verified",
+			})
+			return
+		}
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Set("security_event", "account_locked")
+			c.Set("security_target", req.Username)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{
+				Error:   "account_locked",
+				Message: err.Error(),
+			})
+			return
+		}
 		h.logger.Error("Failed to login user", "username", req.Username, "error", err)
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "login_failed",
@@ -107,6 +159,55 @@ func (h *UserHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// RefreshToken 刷新访问令牌
+// @Summary 刷新访问令牌
+// @Description 使用刷新令牌换取新的访问令牌和刷新令牌，旧的刷新令牌随即失效；再次使用已轮换的刷新令牌会撤销同一次登录签发的全部刷新令牌
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body service.RefreshTokenRequest true "刷新令牌"
+// @Success 200 {object} service.LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/users/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req service.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "refresh_token is required",
+		})
+		return
+	}
+
+	req.Client = clientInfo(c)
+	response, err := h.userService.RefreshToken(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "refresh_token_reused",
+				Message: err.Error(),
+			})
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "invalid_refresh_token",
+				Message: err.Error(),
+			})
+		default:
+			h.logger.Error("Failed to refresh token", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "refresh_failed",
+				Message: "Failed to refresh token",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetProfile 获取用户资料
 // @Summary 获取用户资料
 // @Description 获取当前用户的资料信息
@@ -223,6 +324,13 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	if err := h.userService.ChangePassword(c.Request.Context(), userID, &req); err != nil {
+		if errors.Is(err, service.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "weak_password",
+				Message: err.Error(),
+			})
+			return
+		}
 		h.logger.Error("Failed to change password", "user_id", userID, "error", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "change_password_failed",
@@ -333,6 +441,51 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	})
 }
 
+// UnlockUser 解除登录锁定（管理员专用）
+// @Summary 解除登录锁定
+// @Description 清空指定用户的连续登录失败次数并解除锁定，仅管理员可用
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	if err := h.userService.UnlockUser(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "user_not_found",
+				Message: "User not found",
+			})
+			return
+		}
+		h.logger.Error("Failed to unlock user", "user_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "unlock_failed",
+			Message: "Failed to unlock user",
+		})
+		return
+	}
+
+	c.Set("security_event", "account_unlocked")
+	c.Set("security_target", strconv.FormatUint(id, 10))
+	c.JSON(http.StatusOK, SuccessResponse{
+		Message: "User unlocked successfully",
+	})
+}
+
 // RegisterRoutes 注册需要认证的路由
 func (h *UserHandler) RegisterRoutes(r *gin.RouterGroup) {
 	users := r.Group("/users")
@@ -348,6 +501,11 @@ func (h *UserHandler) RegisterRoutes(r *gin.RouterGroup) {
 	}
 }
 
+// RegisterAdminRoutes 注册管理员路由
+func (h *UserHandler) RegisterAdminRoutes(r *gin.RouterGroup) {
+	r.POST("/users/:id/unlock", h.UnlockUser)
+}
+
 // 辅助方法
 
 func (h *UserHandler) getUserIDFromContext(c *gin.Context) uint {
@@ -360,6 +518,10 @@ func (h *UserHandler) getUserIDFromContext(c *gin.Context) uint {
 }
 
 func (h *UserHandler) isAdmin(c *gin.Context) bool {
+	// 管理接口已按声明的权限检查过，拥有对应权限的自定义角色同样允许
+	if _, granted := c.Get("route_permission"); granted {
+		return true
+	}
 	if role, exists := c.Get("user_role"); exists {
 		return role == "admin"
 	}
@@ -397,11 +559,3 @@ func (h *UserHandler) parseListOptions(c *gin.Context) repository.ListOptions {
 }
 
 // 响应结构体
-
-// AuthMiddleware 认证中间件占位符
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// TODO: 实现 JWT 认证逻辑
-		c.Next()
-	}
-}
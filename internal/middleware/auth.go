@@ -8,46 +8,87 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 
 	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/pkg/cache"
 	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/token"
 )
 
+// APIKeyPrincipal 个人 API Key 认证得到的身份
+type APIKeyPrincipal struct {
+	KeyID    uint
+	UserID   uint
+	Username string
+	Email    string
+	Role     string
+	Scopes   []string // resource:action[:scope] 格式的权限
+}
+
+// APIKeyLookupFunc 验证 API Key 并返回其身份的函数类型
+type APIKeyLookupFunc func(ctx context.Context, key, ip string) (*APIKeyPrincipal, error)
+
 // AuthMiddleware JWT 认证中间件
 type AuthMiddleware struct {
-	config *config.Config
-	cache  cache.Cache
-	logger logger.Logger
+	config       *config.Config
+	tokens       *token.Manager
+	cache        cache.Cache
+	logger       logger.Logger
+	apiKeyLookup APIKeyLookupFunc
+	apiKeyRoutes map[string]Permission
+	permissions  *PermissionMiddleware
+
+	impersonationDenied map[string]bool
 }
 
 // NewAuthMiddleware 创建认证中间件
 func NewAuthMiddleware(
 	config *config.Config,
+	tokens *token.Manager,
 	cache cache.Cache,
 	logger logger.Logger,
 ) *AuthMiddleware {
 	return &AuthMiddleware{
-		config: config,
-		cache:  cache,
-		logger: logger,
+		config:       config,
+		tokens:       tokens,
+		cache:        cache,
+		logger:       logger,
+		apiKeyRoutes: make(map[string]Permission),
+		permissions:  NewPermissionMiddleware(config, cache, logger),
+
+		impersonationDenied: make(map[string]bool),
 	}
 }
 
-// JWTClaims JWT 声明
-type JWTClaims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
-	jwt.RegisteredClaims
+// RegisterAPIKeyLookup 注册 API Key 验证函数，未注册时拒绝所有 API Key
+func (m *AuthMiddleware) RegisterAPIKeyLookup(lookup APIKeyLookupFunc) {
+	m.apiKeyLookup = lookup
 }
 
+// AllowAPIKey 允许使用 API Key 访问指定接口，API Key 的权限必须包含 permission；
+// path 为注册路由时的完整路径，如 /api/v1/user/articles/:id，未声明的接口只接受登录令牌
+func (m *AuthMiddleware) AllowAPIKey(method, path string, permission Permission) {
+	m.apiKeyRoutes[routeKey(method, path)] = permission
+}
+
+// DenyImpersonation 禁止管理员模拟登录时访问指定接口，如修改密码、两步验证等账户安全操作；
+// path 为注册路由时的完整路径
+func (m *AuthMiddleware) DenyImpersonation(method, path string) {
+	m.impersonationDenied[routeKey(method, path)] = true
+}
+
+// JWTClaims JWT 声明
+type JWTClaims = token.Claims
+
 // RequireAuth 需要认证的中间件
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			m.authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		token := m.extractToken(c)
 		if token == "" {
 			m.logger.Warn("Missing authorization token", "path", c.Request.URL.Path)
@@ -71,7 +112,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		}
 
 		// 检查 token 是否被撤销
-		if m.isTokenRevoked(token) {
+		if m.isTokenRevoked(claims) {
 			m.logger.Warn("Revoked token used", "user_id", claims.UserID)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "unauthorized",
@@ -81,12 +122,21 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		if claims.Actor != nil && m.impersonationDenied[routeKey(c.Request.Method, c.FullPath())] {
+			m.logger.Warn("Impersonation denied",
+				"user_id", claims.UserID,
+				"impersonator_id", claims.Actor.UserID,
+				"path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "impersonation_not_allowed",
+				"message": "This action is not allowed while impersonating a user",
+			})
+			c.Abort()
+			return
+		}
+
 		// 设置用户信息到上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("user_role", claims.Role)
-		c.Set("token", token)
+		m.setClaims(c, token, claims)
 
 		m.logger.Debug("User authenticated",
 			"user_id", claims.UserID,
@@ -97,6 +147,61 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	}
 }
 
+// authenticateAPIKey 使用个人 API Key 认证，只允许访问声明过的接口且 API Key 的权限必须包含接口所需权限
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, apiKey string) {
+	required, allowed := m.apiKeyRoutes[routeKey(c.Request.Method, c.FullPath())]
+	if !allowed || m.apiKeyLookup == nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "api_key_not_allowed",
+			"message": "API keys cannot be used for this endpoint",
+		})
+		c.Abort()
+		return
+	}
+
+	principal, err := m.apiKeyLookup(c.Request.Context(), apiKey, c.ClientIP())
+	if err != nil {
+		m.logger.Warn("Invalid api key", "error", err, "path", c.Request.URL.Path)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Invalid or expired API key",
+		})
+		c.Abort()
+		return
+	}
+
+	scopes := parsePermissions(principal.Scopes)
+	if !permissionsCover(scopes, required) {
+		m.logger.Warn("API key scope denied",
+			"user_id", principal.UserID,
+			"api_key_id", principal.KeyID,
+			"permission", required.String(),
+			"path", c.Request.URL.Path)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "insufficient_scope",
+			"message": fmt.Sprintf("API key scope does not include %s", required.String()),
+		})
+		c.Abort()
+		return
+	}
+
+	// 与登录令牌一致地设置用户信息，API Key 不属于任何会话，也未经过两步验证
+	c.Set("user_id", principal.UserID)
+	c.Set("username", principal.Username)
+	c.Set("email", principal.Email)
+	c.Set("user_role", principal.Role)
+	c.Set("api_key_id", principal.KeyID)
+	c.Set("api_key_scopes", scopes)
+	auditMetadata(c).ActorID = principal.UserID
+
+	m.logger.Debug("User authenticated with api key",
+		"user_id", principal.UserID,
+		"api_key_id", principal.KeyID,
+		"path", c.Request.URL.Path)
+
+	c.Next()
+}
+
 // RequireRole 需要特定角色的中间件
 func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -131,10 +236,35 @@ func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
-// RequirePermission 需要特定权限的中间件
+// RequireAdminMFA 开启 require_admin_mfa 时，要求管理员通过两步验证登录
+func (m *AuthMiddleware) RequireAdminMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.config.Auth.RequireAdminMFA || c.GetString("user_role") != model.UserRoleAdmin {
+			c.Next()
+			return
+		}
+
+		if !c.GetBool("mfa") {
+			m.logger.Warn("Admin access without two-factor authentication",
+				"user_id", c.GetUint("user_id"),
+				"path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "mfa_required",
+				"message": "Two-factor authentication is required for admin access",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission 需要特定权限的中间件，permission 为 resource:action[:scope] 格式
 func (m *AuthMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	required, valid := ParsePermission(permission)
 	return func(c *gin.Context) {
-		userRole, exists := c.Get("user_role")
+		userID, exists := c.Get("user_id")
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "unauthorized",
@@ -144,9 +274,12 @@ func (m *AuthMiddleware) RequirePermission(permission string) gin.HandlerFunc {
 			return
 		}
 
-		if !m.hasPermission(userRole.(string), permission) {
+		userRole, _ := c.Get("user_role")
+		role, _ := userRole.(string)
+
+		if !valid || !m.permissions.hasPermission(userID.(uint), role, required, c) {
 			m.logger.Warn("Permission denied",
-				"user_role", userRole,
+				"user_role", role,
 				"permission", permission,
 				"path", c.Request.URL.Path)
 
@@ -178,13 +311,9 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
-		if !m.isTokenRevoked(token) {
+		if !m.isTokenRevoked(claims) {
 			// 设置用户信息到上下文
-			c.Set("user_id", claims.UserID)
-			c.Set("username", claims.Username)
-			c.Set("email", claims.Email)
-			c.Set("user_role", claims.Role)
-			c.Set("token", token)
+			m.setClaims(c, token, claims)
 		}
 
 		c.Next()
@@ -193,26 +322,7 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 
 // GenerateToken 生成 JWT token
 func (m *AuthMiddleware) GenerateToken(user *model.User) (string, error) {
-	now := time.Now()
-	expirationTime := now.Add(time.Duration(m.config.JWT.Expiration) * time.Second)
-
-	claims := &JWTClaims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Role:     user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-			Issuer:    m.config.JWT.Issuer,
-			Subject:   user.Email,
-			ID:        m.generateJTI(),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(m.config.JWT.Secret))
+	tokenString, claims, err := m.tokens.Issue(user, "", false)
 	if err != nil {
 		m.logger.Error("Failed to generate token", "error", err)
 		return "", err
@@ -225,17 +335,15 @@ func (m *AuthMiddleware) GenerateToken(user *model.User) (string, error) {
 }
 
 // RevokeToken 撤销 token
-func (m *AuthMiddleware) RevokeToken(token string) error {
-	claims, err := m.validateToken(token)
+func (m *AuthMiddleware) RevokeToken(tokenString string) error {
+	claims, err := m.validateToken(tokenString)
 	if err != nil {
 		return err
 	}
 
 	// 将 token 添加到黑名单
-	blacklistKey := "token_blacklist:" + claims.ID
 	expiration := time.Until(claims.ExpiresAt.Time)
-
-	if err := m.cache.Set(context.Background(), blacklistKey, "revoked", expiration); err != nil {
+	if err := m.cache.Set(context.Background(), token.RevokedTokenKey(claims.ID), "revoked", expiration); err != nil {
 		m.logger.Error("Failed to revoke token", "error", err)
 		return err
 	}
@@ -244,37 +352,6 @@ func (m *AuthMiddleware) RevokeToken(token string) error {
 	return nil
 }
 
-// RefreshToken 刷新 token
-func (m *AuthMiddleware) RefreshToken(token string) (string, error) {
-	claims, err := m.validateToken(token)
-	if err != nil {
-		return "", err
-	}
-
-	// 检查 token 是否即将过期（在过期前 1 小时内可以刷新）
-	if time.Until(claims.ExpiresAt.Time) > time.Hour {
-		return "", jwt.ErrTokenNotValidYet
-	}
-
-	// 创建新的 token
-	user := &model.User{
-		BaseModel: model.BaseModel{ID: claims.UserID},
-		Username:  claims.Username,
-		Email:     claims.Email,
-		Role:      claims.Role,
-	}
-
-	newToken, err := m.GenerateToken(user)
-	if err != nil {
-		return "", err
-	}
-
-	// 撤销旧 token
-	m.RevokeToken(token)
-
-	return newToken, nil
-}
-
 // extractToken 从请求中提取 token
 func (m *AuthMiddleware) extractToken(c *gin.Context) string {
 	// 从 Authorization header 提取
@@ -301,33 +378,17 @@ func (m *AuthMiddleware) extractToken(c *gin.Context) string {
 
 // validateToken 验证 token
 func (m *AuthMiddleware) validateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(m.config.JWT.Secret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, fmt.Errorf("invalid token")
+	return m.tokens.Parse(tokenString)
 }
 
-// isTokenRevoked 检查 token 是否被撤销
-func (m *AuthMiddleware) isTokenRevoked(token string) bool {
-	claims, err := m.validateToken(token)
-	if err != nil {
-		return true
+// isTokenRevoked 检查 token 或其所属会话是否被撤销
+func (m *AuthMiddleware) isTokenRevoked(claims *JWTClaims) bool {
+	keys := []string{token.RevokedTokenKey(claims.ID)}
+	if claims.SessionID != "" {
+		keys = append(keys, token.RevokedSessionKey(claims.SessionID))
 	}
 
-	blacklistKey := "token_blacklist:" + claims.ID
-	exists, err := m.cache.Exists(context.Background(), blacklistKey)
+	exists, err := m.cache.Exists(context.Background(), keys...)
 	if err != nil {
 		m.logger.Error("Failed to check token blacklist", "error", err)
 		return false
@@ -336,43 +397,39 @@ func (m *AuthMiddleware) isTokenRevoked(token string) bool {
 	return exists > 0
 }
 
-// hasPermission 检查用户是否有指定权限
-func (m *AuthMiddleware) hasPermission(role, permission string) bool {
-	// 管理员拥有所有权限
-	if role == model.UserRoleAdmin {
-		return true
-	}
-
-	// 这里可以实现更复杂的权限检查逻辑
-	// 例如从数据库或配置文件中读取角色权限映射
-	rolePermissions := map[string][]string{
-		model.UserRoleUser: {
-			"user:read",
-			"user:update_self",
-			"article:read",
-			"file:read",
-		},
-		model.UserRoleAdmin: {
-			"*", // 管理员拥有所有权限
-		},
-	}
-
-	permissions, exists := rolePermissions[role]
-	if !exists {
-		return false
-	}
-
-	for _, p := range permissions {
-		if p == "*" || p == permission {
-			return true
-		}
+// setClaims 将访问令牌中的用户信息写入上下文，模拟登录时记录实际操作的管理员
+func (m *AuthMiddleware) setClaims(c *gin.Context, token string, claims *JWTClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+	c.Set("user_role", claims.Role)
+	c.Set("token", token)
+	c.Set("token_id", claims.ID)
+	c.Set("session_id", claims.SessionID)
+	c.Set("mfa", claims.MFA)
+
+	md := auditMetadata(c)
+	md.ActorID = claims.UserID
+
+	if claims.Actor != nil {
+		c.Set("impersonator_id", claims.Actor.UserID)
+		md.ImpersonatorID = claims.Actor.UserID
+		m.logger.Info("Impersonated request",
+			"user_id", claims.UserID,
+			"impersonator_id", claims.Actor.UserID,
+			"impersonator", claims.Actor.Username,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path)
 	}
+}
 
-	return false
+// routeKey 按请求方法和注册路由时的完整路径标识接口
+func routeKey(method, path string) string {
+	return method + " " + path
 }
 
 // cacheToken 缓存 token 信息
-func (m *AuthMiddleware) cacheToken(token string, claims *JWTClaims) {
+func (m *AuthMiddleware) cacheToken(tokenString string, claims *JWTClaims) {
 	tokenKey := "token:" + claims.ID
 	expiration := time.Until(claims.ExpiresAt.Time)
 
@@ -381,25 +438,10 @@ func (m *AuthMiddleware) cacheToken(token string, claims *JWTClaims) {
 		"username": claims.Username,
 		"email":    claims.Email,
 		"role":     claims.Role,
-		"token":    token,
+		"token":    tokenString,
 	}
 
 	if err := m.cache.Set(context.Background(), tokenKey, fmt.Sprintf("%v", tokenInfo), expiration); err != nil {
 		m.logger.Error("Failed to cache token", "error", err)
 	}
 }
-
-// generateJTI 生成 JWT ID
-func (m *AuthMiddleware) generateJTI() string {
-	return fmt.Sprintf("%d_%s", time.Now().UnixNano(), generateRandomString(8))
-}
-
-// generateRandomString 生成随机字符串
-func generateRandomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
-}
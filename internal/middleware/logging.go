@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 	"github.com/google/uuid"
 
 	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/audit"
 	"vibe-coding-starter/pkg/logger"
 )
 
@@ -26,11 +28,15 @@ type LoggingConfig struct {
 	SensitiveFields []string `json:"sensitive_fields"`  // 敏感字段列表
 }
 
+// AuditRecorderFunc 将安全事件写入审计日志的函数类型
+type AuditRecorderFunc func(ctx context.Context, action, resourceType, resourceID string)
+
 // LoggingMiddleware 请求日志中间件
 type LoggingMiddleware struct {
-	config    *config.Config
-	logger    logger.Logger
-	logConfig LoggingConfig
+	config        *config.Config
+	logger        logger.Logger
+	logConfig     LoggingConfig
+	auditRecorder AuditRecorderFunc
 }
 
 // NewLoggingMiddleware 创建请求日志中间件
@@ -69,6 +75,11 @@ func NewLoggingMiddleware(
 	}
 }
 
+// RegisterAuditRecorder 注册审计日志记录函数，注册后处理器标记的安全事件会持久化
+func (m *LoggingMiddleware) RegisterAuditRecorder(recorder AuditRecorderFunc) {
+	m.auditRecorder = recorder
+}
+
 // RequestLogging 请求日志中间件
 func (m *LoggingMiddleware) RequestLogging() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -122,6 +133,9 @@ func (m *LoggingMiddleware) StructuredLogging() gin.HandlerFunc {
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
 
+		// 服务层从请求上下文中获取审计信息
+		auditMetadata(c)
+
 		// 检查是否跳过
 		if m.shouldSkip(c) {
 			c.Next()
@@ -159,6 +173,9 @@ func (m *LoggingMiddleware) StructuredLogging() gin.HandlerFunc {
 		if username != nil {
 			logFields = append(logFields, "username", username)
 		}
+		if impersonatorID, exists := c.Get("impersonator_id"); exists {
+			logFields = append(logFields, "impersonator_id", impersonatorID)
+		}
 
 		// 添加错误信息
 		if len(c.Errors) > 0 {
@@ -257,6 +274,12 @@ func (m *LoggingMiddleware) SecurityLogging() gin.HandlerFunc {
 		if c.Writer.Status() == http.StatusForbidden {
 			m.logSecurityEvent(c, "authorization_failed")
 		}
+
+		// 记录处理器标记的安全事件，如账户锁定
+		if event := c.GetString("security_event"); event != "" {
+			m.logSecurityEvent(c, event)
+			m.recordSecurityEvent(c, event)
+		}
 	}
 }
 
@@ -380,7 +403,7 @@ func (m *LoggingMiddleware) logSecurityEvent(c *gin.Context, eventType string) {
 	requestID, _ := c.Get("request_id")
 	userID, _ := c.Get("user_id")
 
-	m.logger.Warn("Security Event",
+	fields := []interface{}{
 		"event_type", eventType,
 		"request_id", requestID,
 		"user_id", userID,
@@ -388,7 +411,44 @@ func (m *LoggingMiddleware) logSecurityEvent(c *gin.Context, eventType string) {
 		"path", c.Request.URL.Path,
 		"client_ip", c.ClientIP(),
 		"user_agent", c.Request.UserAgent(),
-	)
+	}
+	// 事件涉及的账户，如被锁定的用户名
+	if target := c.GetString("security_target"); target != "" {
+		fields = append(fields, "target", target)
+	}
+	// 模拟登录时实际操作的管理员
+	if impersonatorID, exists := c.Get("impersonator_id"); exists {
+		fields = append(fields, "impersonator_id", impersonatorID)
+	}
+
+	m.logger.Warn("Security Event", fields...)
+}
+
+// recordSecurityEvent 将处理器标记的安全事件写入审计日志，服务层已记录本次请求时跳过
+func (m *LoggingMiddleware) recordSecurityEvent(c *gin.Context, eventType string) {
+	if m.auditRecorder == nil {
+		return
+	}
+	if md := audit.FromContext(c.Request.Context()); md != nil && md.Recorded {
+		return
+	}
+
+	m.auditRecorder(c.Request.Context(), eventType, "security", c.GetString("security_target"))
+}
+
+// auditMetadata 获取请求上下文中的审计信息，不存在时创建并附加到请求上下文
+func auditMetadata(c *gin.Context) *audit.Metadata {
+	if md := audit.FromContext(c.Request.Context()); md != nil {
+		return md
+	}
+
+	md := &audit.Metadata{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("request_id"),
+	}
+	c.Request = c.Request.WithContext(audit.NewContext(c.Request.Context(), md))
+	return md
 }
 
 // detectSuspiciousRequest 检测可疑请求
@@ -8,6 +8,7 @@ import (
 	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/pkg/cache"
 	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/token"
 )
 
 // Middleware 中间件管理器
@@ -28,13 +29,19 @@ func NewMiddleware(
 	config *config.Config,
 	logger logger.Logger,
 	cache cache.Cache,
+	tokens *token.Manager,
 ) *Middleware {
+	// 认证中间件与权限中间件共享权限加载函数和缓存
+	permission := NewPermissionMiddleware(config, cache, logger)
+	auth := NewAuthMiddleware(config, tokens, cache, logger)
+	auth.permissions = permission
+
 	return &Middleware{
 		config:     config,
 		logger:     logger,
 		cache:      cache,
-		auth:       NewAuthMiddleware(config, cache, logger),
-		permission: NewPermissionMiddleware(config, cache, logger),
+		auth:       auth,
+		permission: permission,
 		rateLimit:  NewRateLimitMiddleware(config, cache, logger),
 		logging:    NewLoggingMiddleware(config, logger),
 		cors:       NewCORSMiddleware(config, logger),
@@ -94,7 +101,7 @@ func (m *Middleware) SetupGlobalMiddleware(engine *gin.Engine) {
 	engine.Use(m.rateLimit.IPRateLimit(100, 200)) // 每分钟 100 次请求
 
 	// 安全检查
-	engine.Use(m.security.RequestSizeLimit(10 * 1024 * 1024)) // 10MB 限制
+	engine.Use(m.security.BodySizeLimit(m.config.Security.MaxRequestSize))
 	engine.Use(m.logging.SecurityLogging())
 }
 
@@ -122,7 +129,8 @@ func (m *Middleware) SetupAuthMiddleware() []gin.HandlerFunc {
 func (m *Middleware) SetupAdminMiddleware() []gin.HandlerFunc {
 	return []gin.HandlerFunc{
 		m.auth.RequireAuth(),
-		m.auth.RequireRole("admin"),
+		m.permission.RequireRoutePermission(),
+		m.auth.RequireAdminMFA(),
 		m.rateLimit.AdminRateLimit(),
 	}
 }
@@ -157,6 +165,46 @@ func (m *Middleware) RequireOwnership(resourceType string) gin.HandlerFunc {
 	return m.permission.RequireOwnership(resourceType)
 }
 
+// RegisterOwnerLookup 注册资源所有者查询函数
+func (m *Middleware) RegisterOwnerLookup(resourceType string, lookup OwnerLookupFunc) {
+	m.permission.RegisterOwnerLookup(resourceType, lookup)
+}
+
+// RegisterPermissionLoader 注册用户权限加载函数
+func (m *Middleware) RegisterPermissionLoader(loader PermissionLoaderFunc) {
+	m.permission.RegisterPermissionLoader(loader)
+}
+
+// RegisterAPIKeyLookup 注册个人 API Key 验证函数
+func (m *Middleware) RegisterAPIKeyLookup(lookup APIKeyLookupFunc) {
+	m.auth.RegisterAPIKeyLookup(lookup)
+}
+
+// RegisterAuditRecorder 注册审计日志记录函数
+func (m *Middleware) RegisterAuditRecorder(recorder AuditRecorderFunc) {
+	m.logging.RegisterAuditRecorder(recorder)
+}
+
+// AllowAPIKey 允许使用个人 API Key 访问指定接口
+func (m *Middleware) AllowAPIKey(method, path string, permission Permission) {
+	m.auth.AllowAPIKey(method, path, permission)
+}
+
+// SetRoutePermission 声明访问指定管理接口所需的权限
+func (m *Middleware) SetRoutePermission(method, path string, permission Permission) {
+	m.permission.SetRoutePermission(method, path, permission)
+}
+
+// SetBodyLimit 为指定接口设置请求体大小上限，0 表示不限制
+func (m *Middleware) SetBodyLimit(method, path string, maxSize int64) {
+	m.security.SetBodyLimit(method, path, maxSize)
+}
+
+// DenyImpersonation 禁止模拟登录时访问指定接口
+func (m *Middleware) DenyImpersonation(method, path string) {
+	m.auth.DenyImpersonation(method, path)
+}
+
 // IPRateLimit IP 限流
 func (m *Middleware) IPRateLimit(rate, burst int) gin.HandlerFunc {
 	return m.rateLimit.IPRateLimit(rate, burst)
@@ -224,7 +272,8 @@ func (m *Middleware) ProtectedAPI() []gin.HandlerFunc {
 func (m *Middleware) AdminAPI() []gin.HandlerFunc {
 	return []gin.HandlerFunc{
 		m.auth.RequireAuth(),
-		m.auth.RequireRole("admin"),
+		m.permission.RequireRoutePermission(),
+		m.auth.RequireAdminMFA(),
 		m.rateLimit.AdminRateLimit(),
 	}
 }
@@ -234,7 +283,6 @@ func (m *Middleware) FileUploadAPI() []gin.HandlerFunc {
 	return []gin.HandlerFunc{
 		m.auth.RequireAuth(),
 		m.rateLimit.UploadRateLimit(),
-		m.security.RequestSizeLimit(50 * 1024 * 1024), // 50MB
 	}
 }
 
@@ -299,16 +347,16 @@ func (m *Middleware) RevokeToken(token string) error {
 	return m.auth.RevokeToken(token)
 }
 
-// RefreshToken 刷新 JWT token
-func (m *Middleware) RefreshToken(token string) (string, error) {
-	return m.auth.RefreshToken(token)
-}
-
 // ClearUserPermissions 清除用户权限缓存
 func (m *Middleware) ClearUserPermissions(userID uint) error {
 	return m.permission.ClearUserPermissions(userID)
 }
 
+// ClearAllPermissions 清除所有用户的权限缓存
+func (m *Middleware) ClearAllPermissions() error {
+	return m.permission.ClearAllPermissions()
+}
+
 // GetRateLimitStatus 获取限流状态
 func (m *Middleware) GetRateLimitStatus(key string) (int, int, time.Time, error) {
 	// 使用默认配置
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -30,11 +32,68 @@ func (p Permission) String() string {
 	return fmt.Sprintf("%s:%s", p.Resource, p.Action)
 }
 
+// ParsePermission 解析 resource:action[:scope] 格式的权限
+func ParsePermission(s string) (Permission, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Permission{}, false
+	}
+
+	permission := Permission{Resource: parts[0], Action: parts[1]}
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return Permission{}, false
+		}
+		permission.Scope = parts[2]
+	}
+	return permission, true
+}
+
+// parsePermissions 解析权限列表，忽略格式不正确的项
+func parsePermissions(values []string) []Permission {
+	permissions := make([]Permission, 0, len(values))
+	for _, value := range values {
+		if permission, ok := ParsePermission(value); ok {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// permissionsCover 检查已授予的权限是否包含所需权限，不限范围的权限包含 own、all 等范围
+func permissionsCover(granted []Permission, required Permission) bool {
+	for _, p := range granted {
+		if p.Resource != "*" && p.Resource != required.Resource {
+			continue
+		}
+		if p.Action != "*" && p.Action != required.Action {
+			continue
+		}
+		if p.Scope == "" || p.Scope == "*" || p.Scope == required.Scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionLoaderFunc 加载用户权限的函数类型，返回 resource:action[:scope] 格式的权限
+type PermissionLoaderFunc func(ctx context.Context, userID uint, role string) ([]string, error)
+
+// permissionsGenerationKey 用户权限缓存版本号的缓存键
+const permissionsGenerationKey = "user_permissions:generation"
+
+// OwnerLookupFunc 根据资源 ID 查询资源所有者的函数类型
+type OwnerLookupFunc func(ctx context.Context, resourceID uint) (uint, error)
+
 // PermissionMiddleware 权限控制中间件
 type PermissionMiddleware struct {
-	config *config.Config
-	cache  cache.Cache
-	logger logger.Logger
+	config       *config.Config
+	cache        cache.Cache
+	logger       logger.Logger
+	ownerLookups map[string]OwnerLookupFunc
+
+	permissionLoader PermissionLoaderFunc
+	routePermissions map[string]Permission
 }
 
 // NewPermissionMiddleware 创建权限控制中间件
@@ -44,9 +103,69 @@ func NewPermissionMiddleware(
 	logger logger.Logger,
 ) *PermissionMiddleware {
 	return &PermissionMiddleware{
-		config: config,
-		cache:  cache,
-		logger: logger,
+		config:       config,
+		cache:        cache,
+		logger:       logger,
+		ownerLookups: make(map[string]OwnerLookupFunc),
+
+		routePermissions: make(map[string]Permission),
+	}
+}
+
+// RegisterOwnerLookup 注册资源所有者查询函数，用于所有权检查
+func (m *PermissionMiddleware) RegisterOwnerLookup(resourceType string, lookup OwnerLookupFunc) {
+	m.ownerLookups[resourceType] = lookup
+}
+
+// RegisterPermissionLoader 注册用户权限加载函数，未注册时只使用角色默认权限
+func (m *PermissionMiddleware) RegisterPermissionLoader(loader PermissionLoaderFunc) {
+	m.permissionLoader = loader
+}
+
+// SetRoutePermission 声明访问指定接口所需的权限，由 RequireRoutePermission 检查；
+// path 为注册路由时的完整路径，如 /api/v1/admin/users/:id
+func (m *PermissionMiddleware) SetRoutePermission(method, path string, permission Permission) {
+	m.routePermissions[routeKey(method, path)] = permission
+}
+
+// RequireRoutePermission 按接口声明的权限检查访问，权限来自用户在数据库中的角色；
+// 未声明权限的接口需要 *:* 权限，即只允许管理员访问。通过检查后在上下文中写入 route_permission
+func (m *PermissionMiddleware) RequireRoutePermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		userRole, _ := c.Get("user_role")
+		role, _ := userRole.(string)
+
+		permission, declared := m.routePermissions[routeKey(c.Request.Method, c.FullPath())]
+		if !declared {
+			permission = PermAdminAll
+		}
+
+		if !m.hasPermission(userID.(uint), role, permission, c) {
+			m.logger.Warn("Permission denied",
+				"user_id", userID,
+				"permission", permission.String(),
+				"path", c.Request.URL.Path)
+
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": "Insufficient permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("route_permission", permission.String())
+		c.Next()
 	}
 }
 
@@ -167,13 +286,20 @@ func (m *PermissionMiddleware) RequireOwnership(resourceType string) gin.Handler
 
 // hasPermission 检查用户是否有指定权限
 func (m *PermissionMiddleware) hasPermission(userID uint, role string, permission Permission, c *gin.Context) bool {
-	// 管理员拥有所有权限
+	// 使用 API Key 认证时，权限不能超出 API Key 的授权范围
+	if scopes, exists := c.Get("api_key_scopes"); exists {
+		if !permissionsCover(scopes.([]Permission), permission) {
+			return false
+		}
+	}
+
+	// users.role 为管理员时拥有所有权限
 	if role == model.UserRoleAdmin {
 		return true
 	}
 
 	// 从缓存获取用户权限
-	userPermissions := m.getUserPermissions(userID, role)
+	userPermissions := m.getUserPermissions(c.Request.Context(), userID, role)
 
 	// 检查精确匹配
 	for _, p := range userPermissions {
@@ -197,55 +323,51 @@ func (m *PermissionMiddleware) hasPermission(userID uint, role string, permissio
 	return false
 }
 
-// getUserPermissions 获取用户权限列表
-func (m *PermissionMiddleware) getUserPermissions(userID uint, role string) []Permission {
+// getUserPermissions 获取用户权限列表，优先从缓存读取，未命中时通过注册的加载函数从数据库加载
+func (m *PermissionMiddleware) getUserPermissions(ctx context.Context, userID uint, role string) []Permission {
 	// 尝试从缓存获取
-	cacheKey := fmt.Sprintf("user_permissions:%d", userID)
-	if cached, err := m.cache.Get(context.Background(), cacheKey); err == nil {
+	cacheKey := m.userPermissionsKey(ctx, userID)
+	if cached, err := m.cache.Get(ctx, cacheKey); err == nil {
 		var permissions []Permission
 		if err := json.Unmarshal([]byte(cached), &permissions); err == nil {
 			return permissions
 		}
 	}
 
-	// 获取角色默认权限
-	permissions := m.getRolePermissions(role)
-
-	// 这里可以添加用户特定权限的逻辑
-	// 例如从数据库查询用户的额外权限
+	var permissions []Permission
+	if m.permissionLoader != nil {
+		values, err := m.permissionLoader(ctx, userID, role)
+		if err != nil {
+			// 加载失败时拒绝访问，且不缓存结果
+			m.logger.Error("Failed to load user permissions", "user_id", userID, "error", err)
+			return []Permission{}
+		}
+		permissions = parsePermissions(values)
+	} else {
+		// 未注册加载函数时使用角色默认权限
+		permissions = m.getRolePermissions(role)
+	}
 
 	// 缓存权限列表
 	if data, err := json.Marshal(permissions); err == nil {
-		m.cache.Set(context.Background(), cacheKey, string(data), time.Hour)
+		m.cache.Set(ctx, cacheKey, string(data), time.Hour)
 	}
 
 	return permissions
 }
 
-// getRolePermissions 获取角色权限
+// userPermissionsKey 用户权限缓存键，包含缓存版本号，ClearAllPermissions 更新版本号使所有缓存失效
+func (m *PermissionMiddleware) userPermissionsKey(ctx context.Context, userID uint) string {
+	generation, err := m.cache.Get(ctx, permissionsGenerationKey)
+	if err != nil {
+		generation = "0"
+	}
+	return fmt.Sprintf("user_permissions:%s:%d", generation, userID)
+}
+
+// getRolePermissions 获取角色的内置默认权限
 func (m *PermissionMiddleware) getRolePermissions(role string) []Permission {
-	rolePermissions := map[string][]Permission{
-		model.UserRoleUser: {
-			{Resource: "user", Action: "read", Scope: "own"},
-			{Resource: "user", Action: "update", Scope: "own"},
-			{Resource: "article", Action: "read"},
-			{Resource: "article", Action: "create"},
-			{Resource: "article", Action: "update", Scope: "own"},
-			{Resource: "article", Action: "delete", Scope: "own"},
-			{Resource: "file", Action: "read"},
-			{Resource: "file", Action: "upload"},
-			{Resource: "file", Action: "delete", Scope: "own"},
-		},
-		model.UserRoleAdmin: {
-			{Resource: "*", Action: "*"}, // 管理员拥有所有权限
-		},
-	}
-
-	if permissions, exists := rolePermissions[role]; exists {
-		return permissions
-	}
-
-	return []Permission{}
+	return parsePermissions(model.DefaultRolePermissions[role])
 }
 
 // matchesWildcard 检查权限是否匹配通配符
@@ -331,28 +453,43 @@ func (m *PermissionMiddleware) checkFileOwnership(userID uint, c *gin.Context) b
 		return false
 	}
 
-	// 这里应该查询数据库检查文件所有者
-	// 为了示例，我们使用缓存检查
-	cacheKey := fmt.Sprintf("file_owner:%s", fileID)
-	if ownerID, err := m.cache.Get(context.Background(), cacheKey); err == nil {
-		return ownerID == fmt.Sprintf("%d", userID)
+	// 文件可能被删除或随用户注销转移，所有者不做缓存，每次都查询数据库
+	lookup, exists := m.ownerLookups["file"]
+	if !exists {
+		return false
 	}
 
-	// 如果缓存中没有，应该查询数据库
-	// 这里返回 false 作为安全默认值
-	return false
+	id, err := strconv.ParseUint(fileID, 10, 32)
+	if err != nil {
+		return false
+	}
+
+	ownerID, err := lookup(c.Request.Context(), uint(id))
+	if err != nil {
+		m.logger.Warn("Failed to lookup file owner", "file_id", fileID, "error", err)
+		return false
+	}
+
+	return ownerID == userID
 }
 
 // ClearUserPermissions 清除用户权限缓存
 func (m *PermissionMiddleware) ClearUserPermissions(userID uint) error {
-	cacheKey := fmt.Sprintf("user_permissions:%d", userID)
-	return m.cache.Del(context.Background(), cacheKey)
+	ctx := context.Background()
+	return m.cache.Del(ctx, m.userPermissionsKey(ctx, userID))
+}
+
+// ClearAllPermissions 清除所有用户的权限缓存，角色权限变更或角色删除后调用
+func (m *PermissionMiddleware) ClearAllPermissions() error {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 10)
+	return m.cache.Set(context.Background(), permissionsGenerationKey, generation, 0)
 }
 
 // 预定义的权限常量
 var (
 	// 用户权限
 	PermUserRead      = Permission{Resource: "user", Action: "read"}
+	PermUserReadOwn   = Permission{Resource: "user", Action: "read", Scope: "own"}
 	PermUserCreate    = Permission{Resource: "user", Action: "create"}
 	PermUserUpdate    = Permission{Resource: "user", Action: "update"}
 	PermUserUpdateOwn = Permission{Resource: "user", Action: "update", Scope: "own"}
@@ -361,6 +498,7 @@ var (
 
 	// 文章权限
 	PermArticleRead      = Permission{Resource: "article", Action: "read"}
+	PermArticleReadOwn   = Permission{Resource: "article", Action: "read", Scope: "own"}
 	PermArticleCreate    = Permission{Resource: "article", Action: "create"}
 	PermArticleUpdate    = Permission{Resource: "article", Action: "update"}
 	PermArticleUpdateOwn = Permission{Resource: "article", Action: "update", Scope: "own"}
@@ -369,35 +507,40 @@ var (
 
 	// 文件权限
 	PermFileRead      = Permission{Resource: "file", Action: "read"}
+	PermFileReadOwn   = Permission{Resource: "file", Action: "read", Scope: "own"}
 	PermFileUpload    = Permission{Resource: "file", Action: "upload"}
 	PermFileDelete    = Permission{Resource: "file", Action: "delete"}
 	PermFileDeleteOwn = Permission{Resource: "file", Action: "delete", Scope: "own"}
 
+	// 管理接口权限，all 范围表示可以操作所有用户的资源
+	PermUserReadAll        = Permission{Resource: "user", Action: "read", Scope: "all"}
+	PermUserCreateAll      = Permission{Resource: "user", Action: "create", Scope: "all"}
+	PermUserUpdateAll      = Permission{Resource: "user", Action: "update", Scope: "all"}
+	PermUserDeleteAll      = Permission{Resource: "user", Action: "delete", Scope: "all"}
+	PermUserImpersonateAll = Permission{Resource: "user", Action: "impersonate", Scope: "all"}
+	PermArticleReadAll     = Permission{Resource: "article", Action: "read", Scope: "all"}
+	PermArticleUpdateAll   = Permission{Resource: "article", Action: "update", Scope: "all"}
+	PermArticleDeleteAll   = Permission{Resource: "article", Action: "delete", Scope: "all"}
+	PermFileReadAll        = Permission{Resource: "file", Action: "read", Scope: "all"}
+	PermFileDeleteAll      = Permission{Resource: "file", Action: "delete", Scope: "all"}
+	PermQuotaReadAll       = Permission{Resource: "quota", Action: "read", Scope: "all"}
+	PermQuotaUpdateAll     = Permission{Resource: "quota", Action: "update", Scope: "all"}
+	PermSessionReadAll     = Permission{Resource: "session", Action: "read", Scope: "all"}
+	PermSessionDeleteAll   = Permission{Resource: "session", Action: "delete", Scope: "all"}
+	PermRoleRead           = Permission{Resource: "role", Action: "read"}
+	PermRoleCreate         = Permission{Resource: "role", Action: "create"}
+	PermRoleUpdate         = Permission{Resource: "role", Action: "update"}
+	PermRoleDelete         = Permission{Resource: "role", Action: "delete"}
+	PermRoleAssign         = Permission{Resource: "role", Action: "assign"}
+	PermAuditRead          = Permission{Resource: "audit", Action: "read"}
+	PermInvitationRead     = Permission{Resource: "invitation", Action: "read"}
+	PermInvitationCreate   = Permission{Resource: "invitation", Action: "create"}
+	PermInvitationDelete   = Permission{Resource: "invitation", Action: "delete"}
+	PermDepartmentRead     = Permission{Resource: "department", Action: "read"}
+	PermDepartmentCreate   = Permission{Resource: "department", Action: "create"}
+	PermDepartmentUpdate   = Permission{Resource: "department", Action: "update"}
+	PermDepartmentDelete   = Permission{Resource: "department", Action: "delete"}
+
 	// 管理权限
 	PermAdminAll = Permission{Resource: "*", Action: "*"}
 )
-
-// 便捷的权限检查函数
-func RequireUserRead() gin.HandlerFunc {
-	return NewPermissionMiddleware(nil, nil, nil).RequirePermissions(PermUserRead)
-}
-
-func RequireUserUpdate() gin.HandlerFunc {
-	return NewPermissionMiddleware(nil, nil, nil).RequirePermissions(PermUserUpdate)
-}
-
-func RequireUserUpdateOwn() gin.HandlerFunc {
-	return NewPermissionMiddleware(nil, nil, nil).RequirePermissions(PermUserUpdateOwn)
-}
-
-func RequireArticleCreate() gin.HandlerFunc {
-	return NewPermissionMiddleware(nil, nil, nil).RequirePermissions(PermArticleCreate)
-}
-
-func RequireArticleUpdateOwn() gin.HandlerFunc {
-	return NewPermissionMiddleware(nil, nil, nil).RequirePermissions(PermArticleUpdateOwn)
-}
-
-func RequireFileUpload() gin.HandlerFunc {
-	return NewPermissionMiddleware(nil, nil, nil).RequirePermissions(PermFileUpload)
-}
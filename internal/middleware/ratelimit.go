@@ -13,6 +13,7 @@ import (
 	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/pkg/cache"
 	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/token"
 )
 
 // RateLimitConfig 限流配置
@@ -133,9 +134,12 @@ func (m *RateLimitMiddleware) APIKeyRateLimit(rate, burst int) gin.HandlerFunc {
 		Burst:  burst,
 		Window: time.Minute,
 		KeyFunc: func(c *gin.Context) string {
-			apiKey := c.GetHeader("X-API-Key")
-			if apiKey != "" {
-				return "api_key:" + apiKey
+			// 认证后按 API Key 记录计数，认证前使用哈希，避免明文出现在缓存键中
+			if keyID, exists := c.Get("api_key_id"); exists {
+				return fmt.Sprintf("api_key:%v", keyID)
+			}
+			if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+				return "api_key:" + token.Hash(apiKey)
 			}
 			return "ip:" + c.ClientIP()
 		},
@@ -16,8 +16,9 @@ import (
 
 // SecurityMiddleware 安全中间件
 type SecurityMiddleware struct {
-	config *config.Config
-	logger logger.Logger
+	config     *config.Config
+	logger     logger.Logger
+	bodyLimits map[string]int64
 }
 
 // NewSecurityMiddleware 创建安全中间件
@@ -26,8 +27,9 @@ func NewSecurityMiddleware(
 	logger logger.Logger,
 ) *SecurityMiddleware {
 	return &SecurityMiddleware{
-		config: config,
-		logger: logger,
+		config:     config,
+		logger:     logger,
+		bodyLimits: make(map[string]int64),
 	}
 }
 
@@ -275,29 +277,55 @@ func (m *SecurityMiddleware) UserAgentFilter(blockedAgents []string) gin.Handler
 	}
 }
 
-// RequestSizeLimit 请求大小限制中间件
-func (m *SecurityMiddleware) RequestSizeLimit(maxSize int64) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if c.Request.ContentLength > maxSize {
-			m.logger.Warn("Request size too large",
-				"size", c.Request.ContentLength,
-				"max_size", maxSize,
-				"ip", c.ClientIP())
+// SetBodyLimit 为指定接口设置请求体大小上限，覆盖 BodySizeLimit 的默认值；
+// path 为注册路由时的完整路径，maxSize 为 0 表示不限制，由处理器自行限制读取量
+func (m *SecurityMiddleware) SetBodyLimit(method, path string, maxSize int64) {
+	m.bodyLimits[routeKey(method, path)] = maxSize
+}
 
-			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
-				"error":   "request_too_large",
-				"message": "Request entity too large",
-			})
-			c.Abort()
+// BodySizeLimit 按接口限制请求体大小，未通过 SetBodyLimit 声明的接口使用 defaultMax，0 表示不限制
+func (m *SecurityMiddleware) BodySizeLimit(defaultMax int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maxSize, ok := m.bodyLimits[routeKey(c.Request.Method, c.FullPath())]
+		if !ok {
+			maxSize = defaultMax
+		}
+		if maxSize <= 0 {
+			c.Next()
 			return
 		}
+		m.limitBody(c, maxSize)
+	}
+}
 
-		// 限制请求体读取大小
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
-		c.Next()
+// RequestSizeLimit 请求大小限制中间件
+func (m *SecurityMiddleware) RequestSizeLimit(maxSize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.limitBody(c, maxSize)
 	}
 }
 
+// limitBody 拒绝声明长度超过上限的请求，并限制请求体的读取量
+func (m *SecurityMiddleware) limitBody(c *gin.Context, maxSize int64) {
+	if c.Request.ContentLength > maxSize {
+		m.logger.Warn("Request size too large",
+			"size", c.Request.ContentLength,
+			"max_size", maxSize,
+			"ip", c.ClientIP())
+
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "request_too_large",
+			"message": "Request entity too large",
+		})
+		c.Abort()
+		return
+	}
+
+	// 限制请求体读取大小
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+	c.Next()
+}
+
 // Timeout 请求超时中间件
 func (m *SecurityMiddleware) Timeout(timeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
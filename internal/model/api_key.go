@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// APIKey 用户的个人 API Key，供脚本等机器客户端代替登录令牌使用，只保存哈希
+type APIKey struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"` // 明文的前几位，便于用户识别
+	KeyHash    string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;type:text;not null" json:"scopes"` // 允许的权限，格式与权限中间件的 resource:action[:scope] 一致
+	ExpiresAt  *time.Time `json:"expires_at"`                                       // 为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:45" json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 获取表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsActive 检查 API Key 是否仍然有效
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// AuditLog 安全相关操作和管理员操作的审计日志，只追加不修改
+type AuditLog struct {
+	ID             uint                   `gorm:"primarykey" json:"id"`
+	ActorID        *uint                  `gorm:"index" json:"actor_id"`                                      // 执行操作的用户，未认证的请求为空
+	ImpersonatorID *uint                  `json:"impersonator_id,omitempty"`                                  // 模拟登录时实际操作的管理员
+	Action         string                 `gorm:"size:100;index;not null" json:"action"`                      // 如 article.update、user.role_changed
+	ResourceType   string                 `gorm:"size:50;index:idx_audit_logs_resource" json:"resource_type"` // 如 article、department
+	ResourceID     string                 `gorm:"size:100;index:idx_audit_logs_resource" json:"resource_id"`
+	Changes        map[string]AuditChange `gorm:"serializer:json;type:text" json:"changes,omitempty"` // 字段的修改前后值
+	IP             string                 `gorm:"size:45" json:"ip"`
+	UserAgent      string                 `gorm:"size:255" json:"user_agent"`
+	RequestID      string                 `gorm:"size:64;index" json:"request_id"`
+	CreatedAt      time.Time              `gorm:"index" json:"created_at"`
+}
+
+// AuditChange 单个字段的修改，创建时 From 为空，删除时 To 为空
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// TableName 获取表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
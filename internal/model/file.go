@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -18,7 +19,8 @@ type File struct {
 	Size          int64  `gorm:"not null" json:"size" validate:"required"`
 	MimeType      string `gorm:"size:100;not null" json:"mime_type" validate:"required"`
 	Extension     string `gorm:"size:10;not null" json:"extension" validate:"required"`
-	Hash          string `gorm:"uniqueIndex;size:64;not null" json:"hash" validate:"required"`
+	Hash          string `gorm:"index;size:64;not null" json:"hash" validate:"required"`
+	BlobID        uint   `gorm:"index" json:"blob_id"`
 	StorageType   string `gorm:"size:20;default:local" json:"storage_type" validate:"oneof=local s3 oss"`
 	OwnerID       uint   `gorm:"not null" json:"owner_id" validate:"required"`
 	Owner         User   `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
@@ -26,6 +28,18 @@ type File struct {
 	DownloadCount int    `gorm:"default:0" json:"download_count"`
 }
 
+// FileBlob 物理文件对象，相同内容的多个文件记录共享同一个对象
+type FileBlob struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	StorageType string    `gorm:"size:20;not null;uniqueIndex:uk_file_blobs_storage_hash,priority:1" json:"storage_type"`
+	Hash        string    `gorm:"size:64;not null;uniqueIndex:uk_file_blobs_storage_hash,priority:2" json:"hash"`
+	Path        string    `gorm:"size:500;not null" json:"path"`
+	Size        int64     `gorm:"not null" json:"size"`
+	RefCount    int       `gorm:"not null;default:0" json:"ref_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
 // StorageType 存储类型常量
 const (
 	StorageTypeLocal = "local"
@@ -38,6 +52,11 @@ func (File) TableName() string {
 	return "files"
 }
 
+// TableName 获取表名
+func (FileBlob) TableName() string {
+	return "file_blobs"
+}
+
 // BeforeCreate GORM 钩子：创建前
 func (f *File) BeforeCreate(tx *gorm.DB) error {
 	// 调用基础模型的钩子
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// UserIdentity 用户在第三方身份提供方的账户，同一提供方的同一账户只能关联一个用户
+type UserIdentity struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Provider    string     `gorm:"size:50;not null;uniqueIndex:uk_user_identities_provider_subject" json:"provider"`
+	Subject     string     `gorm:"size:255;not null;uniqueIndex:uk_user_identities_provider_subject" json:"-"` // ID 令牌中的 sub
+	Email       string     `gorm:"size:100" json:"email"`                                                      // 最近一次登录时提供方返回的邮箱
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 获取表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 邀请状态
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// Invitation 管理员发出的注册邀请，只保存令牌哈希，每个邀请只能使用一次
+type Invitation struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	Email      string     `gorm:"size:100;index;not null" json:"email"`
+	Role       string     `gorm:"size:20;not null" json:"role"` // 通过邀请注册的用户获得的角色
+	TokenHash  string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	InvitedBy  *uint      `gorm:"index" json:"invited_by"` // 发出邀请的管理员，管理员账户删除后为空
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	AcceptedBy *uint      `json:"accepted_by"` // 通过邀请注册的用户
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Status string `gorm:"-" json:"status"` // 查询时根据接受、撤销和过期时间计算
+}
+
+// TableName 获取表名
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// AfterFind GORM 钩子：查询后计算状态
+func (i *Invitation) AfterFind(tx *gorm.DB) error {
+	i.Status = i.CurrentStatus()
+	return nil
+}
+
+// CurrentStatus 根据接受、撤销和过期时间计算邀请状态
+func (i *Invitation) CurrentStatus() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case !time.Now().Before(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
+
+// IsPending 检查邀请是否仍可用于注册
+func (i *Invitation) IsPending() bool {
+	return i.CurrentStatus() == InvitationStatusPending
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// UserMFA 用户 TOTP 两步验证配置
+type UserMFA struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	UserID       uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	Secret       string     `gorm:"size:255;not null" json:"-"`  // 加密后的 TOTP 密钥
+	ConfirmedAt  *time.Time `json:"confirmed_at"`                // 为空表示已生成密钥但尚未确认
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"` // 最近一次验证通过的时间步，拒绝重放同一验证码
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 获取表名
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// IsConfirmed 检查是否已确认开启
+func (m *UserMFA) IsConfirmed() bool {
+	return m.ConfirmedAt != nil
+}
+
+// MFARecoveryCode 两步验证恢复码，只保存哈希
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 获取表名
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
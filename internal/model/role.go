@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRolePermissions 内置角色的默认权限，数据库中没有对应角色时作为后备，格式为 resource:action[:scope]
+var DefaultRolePermissions = map[string][]string{
+	UserRoleUser: {
+		"user:read:own",
+		"user:update:own",
+		"article:read",
+		"article:create",
+		"article:update:own",
+		"article:delete:own",
+		"file:read",
+		"file:upload",
+		"file:delete:own",
+	},
+	UserRoleAdmin: {
+		"*:*", // 管理员拥有所有权限
+	},
+}
+
+// Role 角色，一个用户可以同时拥有多个角色
+type Role struct {
+	ID          uint         `gorm:"primarykey" json:"id"`
+	Name        string       `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	DisplayName string       `gorm:"size:100" json:"display_name"`
+	Description string       `gorm:"size:255" json:"description"`
+	IsSystem    bool         `gorm:"not null;default:false" json:"is_system"` // 内置角色不能删除
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// TableName 获取表名
+func (Role) TableName() string {
+	return "roles"
+}
+
+// PermissionStrings 获取角色权限的字符串表示
+func (r *Role) PermissionStrings() []string {
+	permissions := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		permissions = append(permissions, p.String())
+	}
+	return permissions
+}
+
+// Permission 权限，对资源执行某种操作，scope 限定范围如 own、all，为空表示不限
+type Permission struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Resource    string    `gorm:"size:50;not null;uniqueIndex:uk_permissions_resource_action_scope" json:"resource"`
+	Action      string    `gorm:"size:50;not null;uniqueIndex:uk_permissions_resource_action_scope" json:"action"`
+	Scope       string    `gorm:"size:20;not null;default:'';uniqueIndex:uk_permissions_resource_action_scope" json:"scope"`
+	Description string    `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 获取表名
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// String 返回权限的字符串表示，与权限中间件的格式一致
+func (p Permission) String() string {
+	if p.Scope != "" {
+		return fmt.Sprintf("%s:%s:%s", p.Resource, p.Action, p.Scope)
+	}
+	return fmt.Sprintf("%s:%s", p.Resource, p.Action)
+}
+
+// UserRole 用户与角色的关联
+type UserRole struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	RoleID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"role_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 获取表名
+func (UserRole) TableName() string {
+	return "user_roles"
+}
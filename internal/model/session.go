@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+)
+
+// UserSession 登录会话，一次登录对应一个刷新令牌家族
+type UserSession struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID   string     `gorm:"size:36;uniqueIndex;not null" json:"-"` // 对应刷新令牌家族，同时作为访问令牌的 sid 声明
+	AccessJTI  string     `gorm:"size:36" json:"-"`                      // 最近签发的访问令牌 jti
+	Device     string     `gorm:"size:100" json:"device"`
+	IP         string     `gorm:"size:45" json:"ip"`
+	UserAgent  string     `gorm:"size:500" json:"user_agent"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Current bool `gorm:"-" json:"current"` // 是否为发起请求的会话
+}
+
+// TableName 获取表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// IsActive 检查会话是否仍然有效
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// StorageQuota 用户存储配额与已用空间
+type StorageQuota struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	UserID     uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	UsedBytes  int64     `gorm:"not null;default:0" json:"used_bytes"`
+	QuotaBytes *int64    `json:"quota_bytes"` // 管理员设置的配额，为空时使用角色默认配额，0 表示不允许上传
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 获取表名
+func (StorageQuota) TableName() string {
+	return "storage_quotas"
+}
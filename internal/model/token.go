@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+)
+
+// RefreshToken 刷新令牌，只保存令牌哈希
+type RefreshToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID  string     `gorm:"size:36;index;not null" json:"family_id"` // 同一次登录轮换出的令牌属于同一家族
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	MFA       bool       `gorm:"not null;default:false" json:"mfa"` // 所属登录通过了两步验证，轮换时沿用
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at"` // 已轮换为新令牌，再次使用视为泄露
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName 获取表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// IsExpired 检查令牌是否已过期
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// 一次性令牌用途
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeDataExport        = "data_export"
+)
+
+// OneTimeToken 通过邮件发送的一次性令牌，只保存令牌哈希
+type OneTimeToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	Purpose   string     `gorm:"size:32;index;not null" json:"purpose"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 获取表名
+func (OneTimeToken) TableName() string {
+	return "one_time_tokens"
+}
+
+// IsExpired 检查令牌是否已过期
+func (t *OneTimeToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
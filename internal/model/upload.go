@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+)
+
+// UploadSession 断点续传上传会话
+type UploadSession struct {
+	BaseModel
+	UploadID     string    `gorm:"uniqueIndex;size:64;not null" json:"upload_id"`
+	OwnerID      uint      `gorm:"index;not null" json:"owner_id"`
+	OwnerRole    string    `gorm:"size:20" json:"-"` // 创建会话时的用户角色，完成时按该角色检查上传策略
+	FileName     string    `gorm:"size:255;not null" json:"file_name"`
+	MimeType     string    `gorm:"size:100" json:"mime_type"`
+	TotalSize    int64     `gorm:"not null" json:"total_size"`
+	UploadedSize int64     `gorm:"not null;default:0" json:"uploaded_size"`
+	StorageType  string    `gorm:"size:20;not null" json:"storage_type"`
+	IsPublic     bool      `gorm:"default:false" json:"is_public"`
+	Status       string    `gorm:"size:20;not null;index" json:"status"`
+	FileID       *uint     `json:"file_id,omitempty"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// UploadPart 上传会话中已接收的分片
+type UploadPart struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	SessionID  uint      `gorm:"not null;index" json:"session_id"`
+	PartOffset int64     `gorm:"not null" json:"part_offset"`
+	Size       int64     `gorm:"not null" json:"size"`
+	StorageKey string    `gorm:"size:500;not null" json:"storage_key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UploadStatus 上传会话状态常量
+const (
+	UploadStatusUploading  = "uploading"
+	UploadStatusCompleting = "completing"
+	UploadStatusCompleted  = "completed"
+	UploadStatusAborted    = "aborted"
+)
+
+// TableName 获取表名
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+// TableName 获取表名
+func (UploadPart) TableName() string {
+	return "upload_parts"
+}
+
+// IsExpired 检查会话是否已过期
+func (s *UploadSession) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// IsComplete 检查是否已接收全部数据
+func (s *UploadSession) IsComplete() bool {
+	return s.UploadedSize == s.TotalSize
+}
+
+// Remaining 获取剩余未上传的字节数
+func (s *UploadSession) Remaining() int64 {
+	return s.TotalSize - s.UploadedSize
+}
@@ -5,8 +5,18 @@ import (
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+
+	"vibe-coding-starter/pkg/password"
 )
 
+// passwordHasher 计算和验证用户密码哈希，启动时通过 SetPasswordHasher 替换为配置的算法
+var passwordHasher = password.NewBcryptHasher(bcrypt.DefaultCost)
+
+// SetPasswordHasher 设置用户密码使用的哈希器
+func SetPasswordHasher(h *password.Hasher) {
+	passwordHasher = h
+}
+
 // User 用户模型
 type User struct {
 	BaseModel
@@ -19,6 +29,11 @@ type User struct {
 	Status    string     `gorm:"size:20;default:active" json:"status" validate:"oneof=active inactive banned"`
 	LastLogin *time.Time `json:"last_login"`
 	Articles  []Article  `gorm:"foreignKey:AuthorID" json:"articles,omitempty"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"` // 为空表示邮箱尚未验证
+	MFAEnabled      bool       `gorm:"default:false" json:"mfa_enabled"`
+
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at"` // 用户申请注销后计划匿名化的时间，为空表示未申请
 }
 
 // UserRole 用户角色常量
@@ -86,18 +101,18 @@ func (u *User) BeforeUpdate(tx *gorm.DB) error {
 }
 
 // HashPassword 加密密码
-func (u *User) HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedBytes), nil
+func (u *User) HashPassword(plain string) (string, error) {
+	return passwordHasher.Hash(plain)
 }
 
-// CheckPassword 验证密码
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+// CheckPassword 验证密码，支持 argon2id 和 bcrypt 两种格式的哈希
+func (u *User) CheckPassword(plain string) bool {
+	return passwordHasher.Verify(plain, u.Password)
+}
+
+// PasswordNeedsRehash 检查密码哈希是否使用了其他算法或过时的参数
+func (u *User) PasswordNeedsRehash() bool {
+	return passwordHasher.NeedsRehash(u.Password)
 }
 
 // IsAdmin 检查是否为管理员
@@ -115,6 +130,11 @@ func (u *User) IsBanned() bool {
 	return u.Status == UserStatusBanned
 }
 
+// IsPendingVerification 检查是否为等待验证邮箱的新注册用户
+func (u *User) IsPendingVerification() bool {
+	return u.Status == UserStatusInactive && u.EmailVerifiedAt == nil
+}
+
 // UpdateLastLogin 更新最后登录时间
 func (u *User) UpdateLastLogin() {
 	now := time.Now()
@@ -134,6 +154,11 @@ func (u *User) ToPublic() *PublicUser {
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 		LastLogin: u.LastLogin,
+
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		MFAEnabled:      u.MFAEnabled,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
 	}
 }
 
@@ -149,4 +174,9 @@ type PublicUser struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	LastLogin *time.Time `json:"last_login"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
 }
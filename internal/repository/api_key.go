@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// apiKeyRepository 个人 API Key 仓储实现
+type apiKeyRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewAPIKeyRepository 创建个人 API Key 仓储
+func NewAPIKeyRepository(db database.Database, logger logger.Logger) APIKeyRepository {
+	return &apiKeyRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建 API Key
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		r.logger.Error("Failed to create api key", "user_id", key.UserID, "error", err)
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据 ID 获取 API Key
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get api key", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetByHash 根据哈希获取 API Key
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get api key by hash", "error", err)
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// ListByUser 获取用户未撤销的 API Key，包括已过期的
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		r.logger.Error("Failed to list api keys", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CountActiveByUser 获取用户未撤销且未过期的 API Key 数量
+func (r *apiKeyRepository) CountActiveByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		r.logger.Error("Failed to count api keys", "user_id", userID, "error", err)
+		return 0, fmt.Errorf("failed to count api keys: %w", err)
+	}
+	return count, nil
+}
+
+// Revoke 仅当 API Key 未撤销时标记为已撤销
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		r.logger.Error("Failed to revoke api key", "id", id, "error", result.Error)
+		return false, fmt.Errorf("failed to revoke api key: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordUsage 记录最近一次使用的时间和 IP
+func (r *apiKeyRepository) RecordUsage(ctx context.Context, id uint, ip string) error {
+	err := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"last_used_at": time.Now(),
+			"last_used_ip": ip,
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to record api key usage", "id", id, "error", err)
+		return fmt.Errorf("failed to record api key usage: %w", err)
+	}
+	return nil
+}
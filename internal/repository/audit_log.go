@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// auditLogRepository 审计日志仓储实现
+type auditLogRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewAuditLogRepository 创建审计日志仓储
+func NewAuditLogRepository(db database.Database, logger logger.Logger) AuditLogRepository {
+	return &auditLogRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// CreateBatch 批量写入审计日志
+func (r *auditLogRepository) CreateBatch(ctx context.Context, logs []*model.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(logs, 100).Error; err != nil {
+		r.logger.Error("Failed to create audit logs", "count", len(logs), "error", err)
+		return fmt.Errorf("failed to create audit logs: %w", err)
+	}
+	return nil
+}
+
+// List 按时间倒序查询审计日志
+func (r *auditLogRepository) List(ctx context.Context, opts ListOptions) ([]*model.AuditLog, int64, error) {
+	var logs []*model.AuditLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.AuditLog{})
+	query = r.applyFilters(query, opts.Filters)
+
+	if err := query.Count(&total).Error; err != nil {
+		r.logger.Error("Failed to count audit logs", "error", err)
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	query = query.Order("created_at DESC").Order("id DESC")
+	if opts.Page > 0 && opts.PageSize > 0 {
+		offset := (opts.Page - 1) * opts.PageSize
+		query = query.Offset(offset).Limit(opts.PageSize)
+	}
+
+	if err := query.Find(&logs).Error; err != nil {
+		r.logger.Error("Failed to list audit logs", "error", err)
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return logs, total, nil
+}
+
+// applyFilters 应用过滤器
+func (r *auditLogRepository) applyFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	for key, value := range filters {
+		switch key {
+		case "actor_id":
+			query = query.Where("actor_id = ?", value)
+		case "action":
+			query = query.Where("action = ?", value)
+		case "resource_type":
+			query = query.Where("resource_type = ?", value)
+		case "resource_id":
+			query = query.Where("resource_id = ?", value)
+		case "request_id":
+			query = query.Where("request_id = ?", value)
+		case "created_after":
+			query = query.Where("created_at >= ?", value)
+		case "created_before":
+			query = query.Where("created_at <= ?", value)
+		}
+	}
+	return query
+}
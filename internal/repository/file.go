@@ -182,6 +182,32 @@ func (r *fileRepository) GetByOwner(ctx context.Context, ownerID uint, opts List
 	return files, total, nil
 }
 
+// ListByStorage 按 ID 顺序分批获取指定存储驱动上的文件记录
+func (r *fileRepository) ListByStorage(ctx context.Context, storageType string, afterID uint, limit int) ([]*model.File, error) {
+	var files []*model.File
+	err := r.db.WithContext(ctx).
+		Where("storage_type = ? AND id > ?", storageType, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&files).Error
+	if err != nil {
+		r.logger.Error("Failed to list files by storage", "storage_type", storageType, "error", err)
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return files, nil
+}
+
+// IncrementDownloadCount 增加下载次数
+func (r *fileRepository) IncrementDownloadCount(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Model(&model.File{}).
+		Where("id = ?", id).
+		Update("download_count", gorm.Expr("download_count + 1")).Error; err != nil {
+		r.logger.Error("Failed to increment download count", "file_id", id, "error", err)
+		return fmt.Errorf("failed to increment download count: %w", err)
+	}
+	return nil
+}
+
 // applyFilters 应用过滤器
 func (r *fileRepository) applyFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
 	if filters == nil {
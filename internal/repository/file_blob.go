@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// fileBlobRepository 物理文件对象仓储实现
+type fileBlobRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewFileBlobRepository 创建物理文件对象仓储
+func NewFileBlobRepository(db database.Database, logger logger.Logger) FileBlobRepository {
+	return &fileBlobRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// GetByID 根据 ID 获取对象
+func (r *fileBlobRepository) GetByID(ctx context.Context, id uint) (*model.FileBlob, error) {
+	var blob model.FileBlob
+	if err := r.db.WithContext(ctx).First(&blob, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("file blob not found with id %d", id)
+		}
+		r.logger.Error("Failed to get file blob by ID", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get file blob: %w", err)
+	}
+	return &blob, nil
+}
+
+// Acquire 增加引用计数，不存在时创建对象
+func (r *fileBlobRepository) Acquire(ctx context.Context, blob *model.FileBlob) (*model.FileBlob, error) {
+	var (
+		acquired model.FileBlob
+		err      error
+	)
+
+	// 并发创建同一对象时唯一索引冲突，重试一次即可命中已创建的记录
+	for attempt := 0; attempt < 2; attempt++ {
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&model.FileBlob{}).
+				Where("storage_type = ? AND hash = ?", blob.StorageType, blob.Hash).
+				Updates(map[string]interface{}{
+					"ref_count":  gorm.Expr("ref_count + 1"),
+					"updated_at": time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				return tx.Where("storage_type = ? AND hash = ?", blob.StorageType, blob.Hash).First(&acquired).Error
+			}
+
+			acquired = *blob
+			acquired.ID = 0
+			acquired.RefCount = 1
+			return tx.Create(&acquired).Error
+		})
+		if err == nil {
+			return &acquired, nil
+		}
+	}
+
+	r.logger.Error("Failed to acquire file blob", "hash", blob.Hash, "error", err)
+	return nil, fmt.Errorf("failed to acquire file blob: %w", err)
+}
+
+// Release 减少引用计数，最后一个引用释放时删除对象记录
+func (r *fileBlobRepository) Release(ctx context.Context, id uint) (*model.FileBlob, bool, error) {
+	var (
+		blob    model.FileBlob
+		removed bool
+	)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.FileBlob{}).
+			Where("id = ? AND ref_count > 0", id).
+			Updates(map[string]interface{}{
+				"ref_count":  gorm.Expr("ref_count - 1"),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.First(&blob, id).Error; err != nil {
+			return err
+		}
+		if blob.RefCount > 0 {
+			return nil
+		}
+
+		result = tx.Where("id = ? AND ref_count <= 0", id).Delete(&model.FileBlob{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected > 0
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to release file blob", "id", id, "error", err)
+		return nil, false, fmt.Errorf("failed to release file blob: %w", err)
+	}
+
+	return &blob, removed, nil
+}
+
+// ListByStorage 按 ID 顺序分批获取指定存储驱动上的对象
+func (r *fileBlobRepository) ListByStorage(ctx context.Context, storageType string, afterID uint, limit int) ([]*model.FileBlob, error) {
+	var blobs []*model.FileBlob
+	err := r.db.WithContext(ctx).
+		Where("storage_type = ? AND id > ?", storageType, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&blobs).Error
+	if err != nil {
+		r.logger.Error("Failed to list file blobs by storage", "storage_type", storageType, "error", err)
+		return nil, fmt.Errorf("failed to list file blobs: %w", err)
+	}
+	return blobs, nil
+}
+
+// SetRefCount 修正引用计数，以当前计数作为条件避免覆盖并发上传的引用
+func (r *fileBlobRepository) SetRefCount(ctx context.Context, id uint, expected, count int) (bool, error) {
+	query := r.db.WithContext(ctx).Where("id = ? AND ref_count = ?", id, expected)
+
+	var result *gorm.DB
+	if count <= 0 {
+		result = query.Delete(&model.FileBlob{})
+	} else {
+		result = query.Model(&model.FileBlob{}).Updates(map[string]interface{}{
+			"ref_count":  count,
+			"updated_at": time.Now(),
+		})
+	}
+	if result.Error != nil {
+		r.logger.Error("Failed to set file blob ref count", "id", id, "count", count, "error", result.Error)
+		return false, fmt.Errorf("failed to set file blob ref count: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
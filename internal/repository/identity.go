@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// userIdentityRepository 第三方登录身份仓储实现
+type userIdentityRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewUserIdentityRepository 创建第三方登录身份仓储
+func NewUserIdentityRepository(db database.Database, logger logger.Logger) UserIdentityRepository {
+	return &userIdentityRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建第三方登录身份
+func (r *userIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	if err := r.db.WithContext(ctx).Create(identity).Error; err != nil {
+		r.logger.Error("Failed to create user identity", "user_id", identity.UserID, "provider", identity.Provider, "error", err)
+		return fmt.Errorf("failed to create user identity: %w", err)
+	}
+	return nil
+}
+
+// GetByProviderSubject 根据提供方和账户标识获取身份
+func (r *userIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get user identity", "provider", provider, "error", err)
+		return nil, fmt.Errorf("failed to get user identity: %w", err)
+	}
+	return &identity, nil
+}
+
+// ListByUserID 获取用户关联的全部身份
+func (r *userIdentityRepository) ListByUserID(ctx context.Context, userID uint) ([]*model.UserIdentity, error) {
+	var identities []*model.UserIdentity
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&identities).Error; err != nil {
+		r.logger.Error("Failed to list user identities", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to list user identities: %w", err)
+	}
+	return identities, nil
+}
+
+// RecordLogin 记录最近一次登录
+func (r *userIdentityRepository) RecordLogin(ctx context.Context, id uint, email string) error {
+	if err := r.db.WithContext(ctx).Model(&model.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":         email,
+			"last_login_at": time.Now(),
+		}).Error; err != nil {
+		r.logger.Error("Failed to record identity login", "identity_id", id, "error", err)
+		return fmt.Errorf("failed to record identity login: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"vibe-coding-starter/internal/model"
 )
@@ -31,6 +32,17 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 	UpdateLastLogin(ctx context.Context, userID uint) error
+	// UpdatePassword 更新已哈希的密码，不触发模型钩子
+	UpdatePassword(ctx context.Context, userID uint, hashedPassword string) error
+	// MarkEmailVerified 仅当用户仍在等待验证邮箱时记录验证时间并激活账户，返回是否成功
+	MarkEmailVerified(ctx context.Context, userID uint) (bool, error)
+	// ScheduleDeletion 设置计划匿名化的时间，为空时撤销注销申请
+	ScheduleDeletion(ctx context.Context, userID uint, at *time.Time) error
+	// ListDueForDeletion 获取计划匿名化时间早于 before 的用户
+	ListDueForDeletion(ctx context.Context, before time.Time, limit int) ([]*model.User, error)
+	// Anonymize 在事务中替换用户的个人信息并删除登录凭据和关联数据，随后软删除用户；
+	// reassignTo 不为 0 时文章、评论和文件转移给该用户，否则彻底删除文章和评论以及已删除的文件记录
+	Anonymize(ctx context.Context, userID, reassignTo uint) error
 }
 
 // ArticleRepository 文章仓储接口
@@ -73,6 +85,152 @@ type FileRepository interface {
 	Repository[model.File, uint]
 	GetByHash(ctx context.Context, hash string) (*model.File, error)
 	GetByOwner(ctx context.Context, ownerID uint, opts ListOptions) ([]*model.File, int64, error)
+	// ListByStorage 按 ID 顺序分批获取指定存储驱动上的文件记录，afterID 为上一批最后一条记录的 ID
+	ListByStorage(ctx context.Context, storageType string, afterID uint, limit int) ([]*model.File, error)
+	IncrementDownloadCount(ctx context.Context, id uint) error
+}
+
+// FileBlobRepository 物理文件对象仓储接口
+type FileBlobRepository interface {
+	GetByID(ctx context.Context, id uint) (*model.FileBlob, error)
+	// Acquire 增加相同内容对象的引用计数，不存在时以 blob 创建，返回实际引用的对象
+	Acquire(ctx context.Context, blob *model.FileBlob) (*model.FileBlob, error)
+	// Release 减少引用计数，返回对象以及是否已移除最后一个引用
+	Release(ctx context.Context, id uint) (*model.FileBlob, bool, error)
+	// ListByStorage 按 ID 顺序分批获取指定存储驱动上的对象，afterID 为上一批最后一条记录的 ID
+	ListByStorage(ctx context.Context, storageType string, afterID uint, limit int) ([]*model.FileBlob, error)
+	// SetRefCount 仅当引用计数仍为 expected 时修正为 count，count 为 0 时删除对象记录，返回是否成功
+	SetRefCount(ctx context.Context, id uint, expected, count int) (bool, error)
+}
+
+// StorageQuotaRepository 存储配额仓储接口
+type StorageQuotaRepository interface {
+	// GetByUserID 获取用户配额记录，不存在时创建
+	GetByUserID(ctx context.Context, userID uint) (*model.StorageQuota, error)
+	// Reserve 仅当占用后不超过 limit 时增加已用空间，limit 为 UnlimitedQuota 表示不限制，返回是否成功
+	Reserve(ctx context.Context, userID uint, size, limit int64) (bool, error)
+	// Release 减少已用空间，不会小于 0
+	Release(ctx context.Context, userID uint, size int64) error
+	// SetQuota 设置用户配额，为空时恢复角色默认配额
+	SetQuota(ctx context.Context, userID uint, quotaBytes *int64) (*model.StorageQuota, error)
+}
+
+// RefreshTokenRepository 刷新令牌仓储接口
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	// MarkRotated 仅当令牌未被轮换或撤销时标记为已轮换，返回是否成功
+	MarkRotated(ctx context.Context, id uint) (bool, error)
+	// RevokeFamily 撤销同一家族中所有未撤销的令牌
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeByUser 撤销用户所有未撤销的令牌，exceptFamilyID 不为空时保留该家族
+	RevokeByUser(ctx context.Context, userID uint, exceptFamilyID string) error
+	// DeleteExpired 删除 before 之前过期的令牌，返回删除数量
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserSessionRepository 登录会话仓储接口
+type UserSessionRepository interface {
+	Create(ctx context.Context, session *model.UserSession) error
+	GetByID(ctx context.Context, id uint) (*model.UserSession, error)
+	GetByFamilyID(ctx context.Context, familyID string) (*model.UserSession, error)
+	// ListActiveByUser 获取用户未撤销且未过期的会话，按最近活跃时间倒序
+	ListActiveByUser(ctx context.Context, userID uint) ([]*model.UserSession, error)
+	// Touch 令牌轮换后更新会话的访问令牌、客户端信息和活跃时间
+	Touch(ctx context.Context, session *model.UserSession) error
+	// Revoke 撤销会话，返回是否由本次调用撤销
+	Revoke(ctx context.Context, id uint) (bool, error)
+	// DeleteExpired 删除 before 之前过期的会话，返回删除数量
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OneTimeTokenRepository 一次性令牌仓储接口
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token *model.OneTimeToken) error
+	GetByHash(ctx context.Context, purpose, tokenHash string) (*model.OneTimeToken, error)
+	// Consume 仅当令牌未被使用时标记为已使用，返回是否成功
+	Consume(ctx context.Context, id uint) (bool, error)
+	// DeleteByUser 删除用户指定用途的所有令牌
+	DeleteByUser(ctx context.Context, userID uint, purpose string) error
+	// DeleteExpired 删除 before 之前过期的令牌，返回删除数量
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MFARepository 两步验证仓储接口
+type MFARepository interface {
+	GetByUserID(ctx context.Context, userID uint) (*model.UserMFA, error)
+	// SaveSecret 保存待确认的密钥，替换用户已有的未确认配置
+	SaveSecret(ctx context.Context, userID uint, secret string) error
+	// Enable 确认开启两步验证，同时写入恢复码并更新用户标记
+	Enable(ctx context.Context, userID uint, codeHashes []string) error
+	// Disable 删除两步验证配置和恢复码并清除用户标记
+	Disable(ctx context.Context, userID uint) error
+	// AdvanceStep 仅当 step 大于最近一次验证通过的时间步时更新，返回是否成功
+	AdvanceStep(ctx context.Context, userID uint, step int64) (bool, error)
+	// ReplaceRecoveryCodes 用新的恢复码替换用户全部恢复码
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+	// ConsumeRecoveryCode 仅当恢复码存在且未使用时标记为已使用，返回是否成功
+	ConsumeRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+	// CountRecoveryCodes 获取用户未使用的恢复码数量
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+}
+
+// UserIdentityRepository 第三方登录身份仓储接口
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	ListByUserID(ctx context.Context, userID uint) ([]*model.UserIdentity, error)
+	// RecordLogin 记录最近一次登录时间和提供方返回的邮箱
+	RecordLogin(ctx context.Context, id uint, email string) error
+}
+
+// APIKeyRepository 个人 API Key 仓储接口
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByID(ctx context.Context, id uint) (*model.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	// ListByUser 获取用户未撤销的 API Key
+	ListByUser(ctx context.Context, userID uint) ([]*model.APIKey, error)
+	// CountActiveByUser 获取用户未撤销且未过期的 API Key 数量
+	CountActiveByUser(ctx context.Context, userID uint) (int64, error)
+	// Revoke 仅当 API Key 未撤销时标记为已撤销，返回是否成功
+	Revoke(ctx context.Context, id uint) (bool, error)
+	// RecordUsage 记录最近一次使用的时间和 IP
+	RecordUsage(ctx context.Context, id uint, ip string) error
+}
+
+// RoleRepository 角色与权限仓储接口
+type RoleRepository interface {
+	Create(ctx context.Context, role *model.Role) error
+	// Update 更新角色的显示名称和描述
+	Update(ctx context.Context, role *model.Role) error
+	// Delete 删除角色及其权限和用户关联
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*model.Role, error)
+	GetByName(ctx context.Context, name string) (*model.Role, error)
+	List(ctx context.Context) ([]*model.Role, error)
+	// ListByNames 按名称获取角色，不存在的名称被忽略
+	ListByNames(ctx context.Context, names []string) ([]*model.Role, error)
+	// ReplacePermissions 用给定权限替换角色的全部权限，不存在的权限自动创建
+	ReplacePermissions(ctx context.Context, roleID uint, permissions []model.Permission) error
+	ListPermissions(ctx context.Context) ([]*model.Permission, error)
+	// ListUserRoles 获取用户拥有的角色，包括角色的权限
+	ListUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+	// SetUserRoles 用给定角色替换用户的全部角色
+	SetUserRoles(ctx context.Context, userID uint, roleIDs []uint) error
+}
+
+// UploadSessionRepository 断点续传会话仓储接口
+type UploadSessionRepository interface {
+	Create(ctx context.Context, session *model.UploadSession) error
+	GetByUploadID(ctx context.Context, uploadID string) (*model.UploadSession, error)
+	// AppendPart 仅当已上传字节数等于 expectedOffset 时追加分片并推进偏移量，返回是否成功
+	AppendPart(ctx context.Context, sessionID uint, expectedOffset int64, part *model.UploadPart, expiresAt time.Time) (bool, error)
+	GetParts(ctx context.Context, sessionID uint) ([]*model.UploadPart, error)
+	// UpdateStatus 仅当当前状态为 fromStatus 时更新状态，返回是否成功
+	UpdateStatus(ctx context.Context, sessionID uint, fromStatus, toStatus string, fileID *uint) (bool, error)
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*model.UploadSession, error)
+	Delete(ctx context.Context, sessionID uint) error
 }
 
 // DictCategoryRepository 数据字典分类仓储接口
@@ -115,3 +273,26 @@ type DepartmentRepository interface {
 	GetByCode(ctx context.Context, code string) (*model.Department, error)
 	GetChildrenTree(ctx context.Context, parentId uint) ([]*model.Department, error)
 }
+
+// InvitationRepository 注册邀请仓储接口
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *model.Invitation) error
+	GetByID(ctx context.Context, id uint) (*model.Invitation, error)
+	GetByHash(ctx context.Context, tokenHash string) (*model.Invitation, error)
+	// List 按创建时间倒序查询邀请，支持 email、status、invited_by 过滤
+	List(ctx context.Context, opts ListOptions) ([]*model.Invitation, int64, error)
+	// Revoke 仅当邀请未接受且未撤销时标记为已撤销，返回是否成功
+	Revoke(ctx context.Context, id uint) (bool, error)
+	// RevokePendingByEmail 撤销邮箱尚未使用的邀请，返回撤销数量
+	RevokePendingByEmail(ctx context.Context, email string) (int64, error)
+	// Accept 在同一事务中占用邀请并创建用户，邀请已被使用、撤销或过期时返回 false 且不创建用户
+	Accept(ctx context.Context, id uint, user *model.User) (bool, error)
+}
+
+// AuditLogRepository 审计日志仓储接口，审计日志只追加不修改
+type AuditLogRepository interface {
+	// CreateBatch 批量写入审计日志
+	CreateBatch(ctx context.Context, logs []*model.AuditLog) error
+	// List 按时间倒序查询审计日志，支持 actor_id、action、resource_type、resource_id、request_id、created_after、created_before 过滤
+	List(ctx context.Context, opts ListOptions) ([]*model.AuditLog, int64, error)
+}
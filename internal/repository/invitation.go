@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// invitationRepository 注册邀请仓储实现
+type invitationRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewInvitationRepository 创建注册邀请仓储
+func NewInvitationRepository(db database.Database, logger logger.Logger) InvitationRepository {
+	return &invitationRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建邀请
+func (r *invitationRepository) Create(ctx context.Context, invitation *model.Invitation) error {
+	if err := r.db.WithContext(ctx).Create(invitation).Error; err != nil {
+		r.logger.Error("Failed to create invitation", "email", invitation.Email, "error", err)
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	invitation.Status = invitation.CurrentStatus()
+	return nil
+}
+
+// GetByID 根据 ID 获取邀请
+func (r *invitationRepository) GetByID(ctx context.Context, id uint) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.WithContext(ctx).First(&invitation, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get invitation", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return &invitation, nil
+}
+
+// GetByHash 根据令牌哈希获取邀请
+func (r *invitationRepository) GetByHash(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get invitation by hash", "error", err)
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return &invitation, nil
+}
+
+// List 按创建时间倒序查询邀请
+func (r *invitationRepository) List(ctx context.Context, opts ListOptions) ([]*model.Invitation, int64, error) {
+	var invitations []*model.Invitation
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Invitation{})
+	query = r.applyFilters(query, opts.Filters)
+
+	if err := query.Count(&total).Error; err != nil {
+		r.logger.Error("Failed to count invitations", "error", err)
+		return nil, 0, fmt.Errorf("failed to count invitations: %w", err)
+	}
+
+	query = query.Order("created_at DESC").Order("id DESC")
+	if opts.Page > 0 && opts.PageSize > 0 {
+		offset := (opts.Page - 1) * opts.PageSize
+		query = query.Offset(offset).Limit(opts.PageSize)
+	}
+
+	if err := query.Find(&invitations).Error; err != nil {
+		r.logger.Error("Failed to list invitations", "error", err)
+		return nil, 0, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	return invitations, total, nil
+}
+
+// applyFilters 应用过滤器
+func (r *invitationRepository) applyFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	for key, value := range filters {
+		switch key {
+		case "email":
+			query = query.Where("email = ?", value)
+		case "invited_by":
+			query = query.Where("invited_by = ?", value)
+		case "status":
+			now := time.Now()
+			switch value {
+			case model.InvitationStatusPending:
+				query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+			case model.InvitationStatusAccepted:
+				query = query.Where("accepted_at IS NOT NULL")
+			case model.InvitationStatusRevoked:
+				query = query.Where("accepted_at IS NULL AND revoked_at IS NOT NULL")
+			case model.InvitationStatusExpired:
+				query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+			}
+		}
+	}
+	return query
+}
+
+// Revoke 仅当邀请未接受且未撤销时标记为已撤销
+func (r *invitationRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		r.logger.Error("Failed to revoke invitation", "id", id, "error", result.Error)
+		return false, fmt.Errorf("failed to revoke invitation: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokePendingByEmail 撤销邮箱尚未使用的邀请
+func (r *invitationRepository) RevokePendingByEmail(ctx context.Context, email string) (int64, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.Invitation{}).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", email).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		r.logger.Error("Failed to revoke invitations by email", "email", email, "error", result.Error)
+		return 0, fmt.Errorf("failed to revoke invitations: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Accept 在同一事务中占用邀请并创建用户
+func (r *invitationRepository) Accept(ctx context.Context, id uint, user *model.User) (bool, error) {
+	accepted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发使用同一邀请时只有一个请求成功
+		now := time.Now()
+		result := tx.Model(&model.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", id, now).
+			Updates(map[string]interface{}{
+				"accepted_at": now,
+				"updated_at":  now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Invitation{}).Where("id = ?", id).
+			UpdateColumn("accepted_by", user.ID).Error; err != nil {
+			return err
+		}
+
+		accepted = true
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to accept invitation", "id", id, "error", err)
+		return false, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return accepted, nil
+}
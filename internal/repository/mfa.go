@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// mfaRepository 两步验证仓储实现
+type mfaRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewMFARepository 创建两步验证仓储
+func NewMFARepository(db database.Database, logger logger.Logger) MFARepository {
+	return &mfaRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// GetByUserID 获取用户的两步验证配置
+func (r *mfaRepository) GetByUserID(ctx context.Context, userID uint) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get user mfa", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to get user mfa: %w", err)
+	}
+	return &mfa, nil
+}
+
+// SaveSecret 保存待确认的密钥
+func (r *mfaRepository) SaveSecret(ctx context.Context, userID uint, secret string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.UserMFA{UserID: userID, Secret: secret}).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to save mfa secret", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+	return nil
+}
+
+// Enable 确认开启两步验证
+func (r *mfaRepository) Enable(ctx context.Context, userID uint, codeHashes []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.UserMFA{}).
+			Where("user_id = ?", userID).
+			Update("confirmed_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", userID).UpdateColumn("mfa_enabled", true).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to enable mfa", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	return nil
+}
+
+// Disable 关闭两步验证
+func (r *mfaRepository) Disable(ctx context.Context, userID uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", userID).UpdateColumn("mfa_enabled", false).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to disable mfa", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+	return nil
+}
+
+// AdvanceStep 以时间步递增作为更新条件，同一验证码只能使用一次
+func (r *mfaRepository) AdvanceStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		r.logger.Error("Failed to update mfa step", "user_id", userID, "error", result.Error)
+		return false, fmt.Errorf("failed to update mfa step: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReplaceRecoveryCodes 替换恢复码
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+	if err != nil {
+		r.logger.Error("Failed to replace recovery codes", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode 以未使用作为更新条件，同一恢复码只能使用一次
+func (r *mfaRepository) ConsumeRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		r.logger.Error("Failed to consume recovery code", "user_id", userID, "error", result.Error)
+		return false, fmt.Errorf("failed to consume recovery code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes 获取未使用的恢复码数量
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		r.logger.Error("Failed to count recovery codes", "user_id", userID, "error", err)
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// replaceRecoveryCodes 在事务中删除旧恢复码并写入新恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	codes := make([]model.MFARecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, model.MFARecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
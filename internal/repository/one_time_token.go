@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// oneTimeTokenRepository 一次性令牌仓储实现
+type oneTimeTokenRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewOneTimeTokenRepository 创建一次性令牌仓储
+func NewOneTimeTokenRepository(db database.Database, logger logger.Logger) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建一次性令牌
+func (r *oneTimeTokenRepository) Create(ctx context.Context, token *model.OneTimeToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		r.logger.Error("Failed to create one-time token", "user_id", token.UserID, "purpose", token.Purpose, "error", err)
+		return fmt.Errorf("failed to create one-time token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash 根据用途和令牌哈希获取一次性令牌
+func (r *oneTimeTokenRepository) GetByHash(ctx context.Context, purpose, tokenHash string) (*model.OneTimeToken, error) {
+	var token model.OneTimeToken
+	if err := r.db.WithContext(ctx).
+		Where("purpose = ? AND token_hash = ?", purpose, tokenHash).
+		First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get one-time token", "purpose", purpose, "error", err)
+		return nil, fmt.Errorf("failed to get one-time token: %w", err)
+	}
+	return &token, nil
+}
+
+// Consume 以未使用作为更新条件，同一令牌并发使用时只有一个请求成功
+func (r *oneTimeTokenRepository) Consume(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		r.logger.Error("Failed to consume one-time token", "id", id, "error", result.Error)
+		return false, fmt.Errorf("failed to consume one-time token: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteByUser 删除用户指定用途的所有令牌
+func (r *oneTimeTokenRepository) DeleteByUser(ctx context.Context, userID uint, purpose string) error {
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND purpose = ?", userID, purpose).
+		Delete(&model.OneTimeToken{}).Error; err != nil {
+		r.logger.Error("Failed to delete one-time tokens", "user_id", userID, "purpose", purpose, "error", err)
+		return fmt.Errorf("failed to delete one-time tokens: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired 删除过期的一次性令牌
+func (r *oneTimeTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.OneTimeToken{})
+	if result.Error != nil {
+		r.logger.Error("Failed to delete expired one-time tokens", "error", result.Error)
+		return 0, fmt.Errorf("failed to delete expired one-time tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// roleRepository 角色与权限仓储实现
+type roleRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewRoleRepository 创建角色与权限仓储
+func NewRoleRepository(db database.Database, logger logger.Logger) RoleRepository {
+	return &roleRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建角色及其权限
+func (r *roleRepository) Create(ctx context.Context, role *model.Role) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		permissions := role.Permissions
+		if err := tx.Omit("Permissions").Create(role).Error; err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role, permissions)
+	})
+	if err != nil {
+		r.logger.Error("Failed to create role", "name", role.Name, "error", err)
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+	return nil
+}
+
+// Update 更新角色的显示名称和描述
+func (r *roleRepository) Update(ctx context.Context, role *model.Role) error {
+	err := r.db.WithContext(ctx).Model(&model.Role{}).
+		Where("id = ?", role.ID).
+		Updates(map[string]interface{}{
+			"display_name": role.DisplayName,
+			"description":  role.Description,
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to update role", "id", role.ID, "error", err)
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除角色及其权限和用户关联
+func (r *roleRepository) Delete(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", id).Delete(&model.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Role{ID: id}).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&model.Role{}, id).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete role", "id", id, "error", err)
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据 ID 获取角色
+func (r *roleRepository) GetByID(ctx context.Context, id uint) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").First(&role, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get role", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+// GetByName 根据名称获取角色
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get role", "name", name, "error", err)
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+// List 获取所有角色
+func (r *roleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	var roles []*model.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").Order("name ASC").Find(&roles).Error; err != nil {
+		r.logger.Error("Failed to list roles", "error", err)
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// ListByNames 按名称获取角色
+func (r *roleRepository) ListByNames(ctx context.Context, names []string) ([]*model.Role, error) {
+	var roles []*model.Role
+	if len(names) == 0 {
+		return roles, nil
+	}
+	if err := r.db.WithContext(ctx).Preload("Permissions").Where("name IN ?", names).Order("name ASC").Find(&roles).Error; err != nil {
+		r.logger.Error("Failed to list roles by name", "names", names, "error", err)
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// ReplacePermissions 替换角色的全部权限
+func (r *roleRepository) ReplacePermissions(ctx context.Context, roleID uint, permissions []model.Permission) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 同时更新角色的修改时间
+		role := &model.Role{ID: roleID}
+		if err := tx.Model(role).Update("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role, permissions)
+	})
+	if err != nil {
+		r.logger.Error("Failed to replace role permissions", "role_id", roleID, "error", err)
+		return fmt.Errorf("failed to replace role permissions: %w", err)
+	}
+	return nil
+}
+
+// ListPermissions 获取所有已定义的权限
+func (r *roleRepository) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
+	var permissions []*model.Permission
+	if err := r.db.WithContext(ctx).Order("resource ASC, action ASC, scope ASC").Find(&permissions).Error; err != nil {
+		r.logger.Error("Failed to list permissions", "error", err)
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// ListUserRoles 获取用户拥有的角色
+func (r *roleRepository) ListUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	var roles []*model.Role
+	err := r.db.WithContext(ctx).
+		Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name ASC").
+		Find(&roles).Error
+	if err != nil {
+		r.logger.Error("Failed to list user roles", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to list user roles: %w", err)
+	}
+	return roles, nil
+}
+
+// SetUserRoles 替换用户的全部角色
+func (r *roleRepository) SetUserRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserRole{}).Error; err != nil {
+			return err
+		}
+		for _, roleID := range roleIDs {
+			if err := tx.Create(&model.UserRole{UserID: userID, RoleID: roleID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to set user roles", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to set user roles: %w", err)
+	}
+	return nil
+}
+
+// replaceRolePermissions 查找或创建权限后替换角色的权限关联
+func replaceRolePermissions(tx *gorm.DB, role *model.Role, permissions []model.Permission) error {
+	resolved := make([]model.Permission, 0, len(permissions))
+	for _, p := range permissions {
+		permission := model.Permission{Resource: p.Resource, Action: p.Action, Scope: p.Scope}
+		if err := tx.Where("resource = ? AND action = ? AND scope = ?", p.Resource, p.Action, p.Scope).
+			Attrs(model.Permission{Description: p.Description}).
+			FirstOrCreate(&permission).Error; err != nil {
+			return err
+		}
+		resolved = append(resolved, permission)
+	}
+
+	if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+		return err
+	}
+	if len(resolved) == 0 {
+		role.Permissions = nil
+		return nil
+	}
+	if err := tx.Model(role).Association("Permissions").Append(resolved); err != nil {
+		return err
+	}
+	role.Permissions = resolved
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// userSessionRepository 登录会话仓储实现
+type userSessionRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewUserSessionRepository 创建登录会话仓储
+func NewUserSessionRepository(db database.Database, logger logger.Logger) UserSessionRepository {
+	return &userSessionRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建会话
+func (r *userSessionRepository) Create(ctx context.Context, session *model.UserSession) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		r.logger.Error("Failed to create user session", "user_id", session.UserID, "error", err)
+		return fmt.Errorf("failed to create user session: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据 ID 获取会话
+func (r *userSessionRepository) GetByID(ctx context.Context, id uint) (*model.UserSession, error) {
+	var session model.UserSession
+	if err := r.db.WithContext(ctx).First(&session, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get user session", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get user session: %w", err)
+	}
+	return &session, nil
+}
+
+// GetByFamilyID 根据刷新令牌家族获取会话
+func (r *userSessionRepository) GetByFamilyID(ctx context.Context, familyID string) (*model.UserSession, error) {
+	var session model.UserSession
+	if err := r.db.WithContext(ctx).Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get user session", "family_id", familyID, "error", err)
+		return nil, fmt.Errorf("failed to get user session: %w", err)
+	}
+	return &session, nil
+}
+
+// ListActiveByUser 获取用户未撤销且未过期的会话
+func (r *userSessionRepository) ListActiveByUser(ctx context.Context, userID uint) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		r.logger.Error("Failed to list user sessions", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to list user sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// Touch 更新会话的访问令牌、客户端信息和活跃时间
+func (r *userSessionRepository) Touch(ctx context.Context, session *model.UserSession) error {
+	err := r.db.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id = ?", session.ID).
+		Updates(map[string]interface{}{
+			"access_jti":   session.AccessJTI,
+			"device":       session.Device,
+			"ip":           session.IP,
+			"user_agent":   session.UserAgent,
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+			"updated_at":   time.Now(),
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to update user session", "id", session.ID, "error", err)
+		return fmt.Errorf("failed to update user session: %w", err)
+	}
+	return nil
+}
+
+// Revoke 以未撤销作为更新条件撤销会话
+func (r *userSessionRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		r.logger.Error("Failed to revoke user session", "id", id, "error", result.Error)
+		return false, fmt.Errorf("failed to revoke user session: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteExpired 删除过期的会话
+func (r *userSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.UserSession{})
+	if result.Error != nil {
+		r.logger.Error("Failed to delete expired user sessions", "error", result.Error)
+		return 0, fmt.Errorf("failed to delete expired user sessions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// storageQuotaRepository 存储配额仓储实现
+type storageQuotaRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewStorageQuotaRepository 创建存储配额仓储
+func NewStorageQuotaRepository(db database.Database, logger logger.Logger) StorageQuotaRepository {
+	return &storageQuotaRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// GetByUserID 获取用户配额记录，不存在时创建
+func (r *storageQuotaRepository) GetByUserID(ctx context.Context, userID uint) (*model.StorageQuota, error) {
+	if err := r.ensure(r.db.WithContext(ctx), userID); err != nil {
+		r.logger.Error("Failed to create storage quota", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to get storage quota: %w", err)
+	}
+
+	var quota model.StorageQuota
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&quota).Error; err != nil {
+		r.logger.Error("Failed to get storage quota", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to get storage quota: %w", err)
+	}
+	return &quota, nil
+}
+
+// UnlimitedQuota 表示不限制存储配额
+const UnlimitedQuota int64 = -1
+
+// Reserve 仅当占用后不超过 limit 时增加已用空间
+func (r *storageQuotaRepository) Reserve(ctx context.Context, userID uint, size, limit int64) (bool, error) {
+	db := r.db.WithContext(ctx)
+	if err := r.ensure(db, userID); err != nil {
+		r.logger.Error("Failed to create storage quota", "user_id", userID, "error", err)
+		return false, fmt.Errorf("failed to reserve storage quota: %w", err)
+	}
+
+	// 以剩余空间作为更新条件，并发上传时不会超出配额
+	query := db.Model(&model.StorageQuota{}).Where("user_id = ?", userID)
+	if limit >= 0 {
+		query = query.Where("used_bytes + ? <= ?", size, limit)
+	}
+	result := query.Updates(map[string]interface{}{
+		"used_bytes": gorm.Expr("used_bytes + ?", size),
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		r.logger.Error("Failed to reserve storage quota", "user_id", userID, "size", size, "error", result.Error)
+		return false, fmt.Errorf("failed to reserve storage quota: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release 减少已用空间，不会小于 0
+func (r *storageQuotaRepository) Release(ctx context.Context, userID uint, size int64) error {
+	err := r.db.WithContext(ctx).Model(&model.StorageQuota{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"used_bytes": gorm.Expr("CASE WHEN used_bytes > ? THEN used_bytes - ? ELSE 0 END", size, size),
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to release storage quota", "user_id", userID, "size", size, "error", err)
+		return fmt.Errorf("failed to release storage quota: %w", err)
+	}
+	return nil
+}
+
+// SetQuota 设置用户配额，为空时恢复角色默认配额
+func (r *storageQuotaRepository) SetQuota(ctx context.Context, userID uint, quotaBytes *int64) (*model.StorageQuota, error) {
+	db := r.db.WithContext(ctx)
+	if err := r.ensure(db, userID); err != nil {
+		r.logger.Error("Failed to create storage quota", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to set storage quota: %w", err)
+	}
+
+	err := db.Model(&model.StorageQuota{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"quota_bytes": quotaBytes,
+			"updated_at":  time.Now(),
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to set storage quota", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to set storage quota: %w", err)
+	}
+
+	return r.GetByUserID(ctx, userID)
+}
+
+// ensure 确保用户配额记录存在，并发创建时忽略唯一索引冲突
+func (r *storageQuotaRepository) ensure(db *gorm.DB, userID uint) error {
+	quota := &model.StorageQuota{UserID: userID}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(quota).Error
+}
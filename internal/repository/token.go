@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// refreshTokenRepository 刷新令牌仓储实现
+type refreshTokenRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewRefreshTokenRepository 创建刷新令牌仓储
+func NewRefreshTokenRepository(db database.Database, logger logger.Logger) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建刷新令牌
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		r.logger.Error("Failed to create refresh token", "user_id", token.UserID, "error", err)
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash 根据令牌哈希获取刷新令牌
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get refresh token", "error", err)
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkRotated 以未轮换、未撤销作为更新条件，并发刷新时只有一个请求成功
+func (r *refreshTokenRepository) MarkRotated(ctx context.Context, id uint) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"rotated_at": now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		r.logger.Error("Failed to rotate refresh token", "id", id, "error", result.Error)
+		return false, fmt.Errorf("failed to rotate refresh token: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeFamily 撤销同一家族中所有未撤销的令牌
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to revoke refresh token family", "family_id", familyID, "error", err)
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeByUser 撤销用户所有未撤销的令牌，exceptFamilyID 不为空时保留该家族
+func (r *refreshTokenRepository) RevokeByUser(ctx context.Context, userID uint, exceptFamilyID string) error {
+	now := time.Now()
+	query := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptFamilyID != "" {
+		query = query.Where("family_id <> ?", exceptFamilyID)
+	}
+	err := query.
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to revoke user refresh tokens", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired 删除过期的刷新令牌
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.RefreshToken{})
+	if result.Error != nil {
+		r.logger.Error("Failed to delete expired refresh tokens", "error", result.Error)
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/database"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// uploadSessionRepository 断点续传会话仓储实现
+type uploadSessionRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewUploadSessionRepository 创建断点续传会话仓储
+func NewUploadSessionRepository(db database.Database, logger logger.Logger) UploadSessionRepository {
+	return &uploadSessionRepository{
+		db:     db.GetDB(),
+		logger: logger,
+	}
+}
+
+// Create 创建上传会话
+func (r *uploadSessionRepository) Create(ctx context.Context, session *model.UploadSession) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		r.logger.Error("Failed to create upload session", "error", err)
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+	return nil
+}
+
+// GetByUploadID 根据上传 ID 获取会话
+func (r *uploadSessionRepository) GetByUploadID(ctx context.Context, uploadID string) (*model.UploadSession, error) {
+	var session model.UploadSession
+	if err := r.db.WithContext(ctx).Where("upload_id = ?", uploadID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		r.logger.Error("Failed to get upload session", "upload_id", uploadID, "error", err)
+		return nil, fmt.Errorf("failed to get upload session: %w", err)
+	}
+	return &session, nil
+}
+
+// AppendPart 追加分片并推进偏移量
+func (r *uploadSessionRepository) AppendPart(ctx context.Context, sessionID uint, expectedOffset int64, part *model.UploadPart, expiresAt time.Time) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 以当前偏移量作为条件更新，保证并发写入同一偏移量时只有一个成功
+		result := tx.Model(&model.UploadSession{}).
+			Where("id = ? AND uploaded_size = ? AND status = ?", sessionID, expectedOffset, model.UploadStatusUploading).
+			Updates(map[string]interface{}{
+				"uploaded_size": gorm.Expr("uploaded_size + ?", part.Size),
+				"expires_at":    expiresAt,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		part.SessionID = sessionID
+		if err := tx.Create(part).Error; err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to append upload part", "session_id", sessionID, "error", err)
+		return false, fmt.Errorf("failed to append upload part: %w", err)
+	}
+	return applied, nil
+}
+
+// GetParts 获取会话的全部分片，按偏移量排序
+func (r *uploadSessionRepository) GetParts(ctx context.Context, sessionID uint) ([]*model.UploadPart, error) {
+	var parts []*model.UploadPart
+	if err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("part_offset ASC").
+		Find(&parts).Error; err != nil {
+		r.logger.Error("Failed to get upload parts", "session_id", sessionID, "error", err)
+		return nil, fmt.Errorf("failed to get upload parts: %w", err)
+	}
+	return parts, nil
+}
+
+// UpdateStatus 按状态条件更新会话状态
+func (r *uploadSessionRepository) UpdateStatus(ctx context.Context, sessionID uint, fromStatus, toStatus string, fileID *uint) (bool, error) {
+	updates := map[string]interface{}{
+		"status":     toStatus,
+		"updated_at": time.Now(),
+	}
+	if fileID != nil {
+		updates["file_id"] = *fileID
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.UploadSession{}).
+		Where("id = ? AND status = ?", sessionID, fromStatus).
+		Updates(updates)
+	if result.Error != nil {
+		r.logger.Error("Failed to update upload session status", "session_id", sessionID, "error", result.Error)
+		return false, fmt.Errorf("failed to update upload session status: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListExpired 获取已过期的会话
+func (r *uploadSessionRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*model.UploadSession, error) {
+	var sessions []*model.UploadSession
+	if err := r.db.WithContext(ctx).
+		Where("expires_at < ?", before).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&sessions).Error; err != nil {
+		r.logger.Error("Failed to list expired upload sessions", "error", err)
+		return nil, fmt.Errorf("failed to list expired upload sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// Delete 删除会话及其分片记录
+func (r *uploadSessionRepository) Delete(ctx context.Context, sessionID uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", sessionID).Delete(&model.UploadPart{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.UploadSession{}, sessionID).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete upload session", "session_id", sessionID, "error", err)
+		return fmt.Errorf("failed to delete upload session: %w", err)
+	}
+	return nil
+}
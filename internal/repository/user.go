@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -39,7 +40,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, err
 	var user model.User
 	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("user not found with id %d", id)
+			return nil, fmt.Errorf("user not found with id %d: %w", id, err)
 		}
 		r.logger.Error("Failed to get user by ID", "id", id, "error", err)
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -150,6 +151,161 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uint) error
 	return nil
 }
 
+// UpdatePassword 更新已哈希的密码
+func (r *userRepository) UpdatePassword(ctx context.Context, userID uint, hashedPassword string) error {
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"password":   hashedPassword,
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		}).Error; err != nil {
+		r.logger.Error("Failed to update password", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailVerified 记录邮箱验证时间并激活等待验证的用户
+func (r *userRepository) MarkEmailVerified(ctx context.Context, userID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND status = ? AND email_verified_at IS NULL", userID, model.UserStatusInactive).
+		UpdateColumns(map[string]interface{}{
+			"status":            model.UserStatusActive,
+			"email_verified_at": time.Now(),
+			"updated_at":        time.Now(),
+		})
+	if result.Error != nil {
+		r.logger.Error("Failed to mark email verified", "user_id", userID, "error", result.Error)
+		return false, fmt.Errorf("failed to verify email: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ScheduleDeletion 设置或撤销计划匿名化时间
+func (r *userRepository) ScheduleDeletion(ctx context.Context, userID uint, at *time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"deletion_scheduled_at": at,
+			"updated_at":            time.Now(),
+		}).Error; err != nil {
+		r.logger.Error("Failed to schedule user deletion", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	return nil
+}
+
+// ListDueForDeletion 获取到期需要匿名化的用户
+func (r *userRepository) ListDueForDeletion(ctx context.Context, before time.Time, limit int) ([]*model.User, error) {
+	var users []*model.User
+	if err := r.db.WithContext(ctx).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", before).
+		Order("deletion_scheduled_at ASC").
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		r.logger.Error("Failed to list users due for deletion", "error", err)
+		return nil, fmt.Errorf("failed to list users due for deletion: %w", err)
+	}
+	return users, nil
+}
+
+// Anonymize 匿名化用户并删除其个人数据
+func (r *userRepository) Anonymize(ctx context.Context, userID, reassignTo uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 登录凭据和账户关联数据
+		for _, record := range []interface{}{
+			&model.RefreshToken{},
+			&model.UserSession{},
+			&model.OneTimeToken{},
+			&model.MFARecoveryCode{},
+			&model.UserMFA{},
+			&model.UserIdentity{},
+			&model.APIKey{},
+			&model.UserRole{},
+			&model.StorageQuota{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+
+		if reassignTo != 0 {
+			if err := tx.Unscoped().Model(&model.Article{}).Where("author_id = ?", userID).
+				UpdateColumn("author_id", reassignTo).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&model.Comment{}).Where("author_id = ?", userID).
+				UpdateColumn("author_id", reassignTo).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&model.File{}).Where("owner_id = ?", userID).
+				UpdateColumn("owner_id", reassignTo).Error; err != nil {
+				return err
+			}
+		} else if err := r.purgeContent(tx, userID); err != nil {
+			return err
+		}
+
+		// 用户名和邮箱替换为不可识别且唯一的值，原用户名和邮箱可以重新注册
+		now := time.Now()
+		return tx.Unscoped().Model(&model.User{}).
+			Where("id = ?", userID).
+			UpdateColumns(map[string]interface{}{
+				"username":              fmt.Sprintf("deleted-%d", userID),
+				"email":                 fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+				"password":              "",
+				"nickname":              "",
+				"avatar":                "",
+				"status":                model.UserStatusInactive,
+				"last_login":            nil,
+				"email_verified_at":     nil,
+				"mfa_enabled":           false,
+				"deletion_scheduled_at": nil,
+				"updated_at":            now,
+				"deleted_at":            now,
+			}).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to anonymize user", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+	return nil
+}
+
+// purgeContent 彻底删除用户的文章、评论和已删除的文件记录
+func (r *userRepository) purgeContent(tx *gorm.DB, userID uint) error {
+	var articleIDs []uint
+	if err := tx.Unscoped().Model(&model.Article{}).Where("author_id = ?", userID).Pluck("id", &articleIDs).Error; err != nil {
+		return err
+	}
+
+	// 用户的评论以及用户文章下的所有评论
+	var commentIDs []uint
+	if err := tx.Unscoped().Model(&model.Comment{}).
+		Where("author_id = ? OR article_id IN ?", userID, articleIDs).
+		Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+
+	// 其他用户对这些评论的回复保留为顶层评论
+	if err := tx.Unscoped().Model(&model.Comment{}).Where("parent_id IN ?", commentIDs).
+		UpdateColumn("parent_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("id IN ?", commentIDs).Delete(&model.Comment{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("DELETE FROM article_tags WHERE article_id IN ?", articleIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("id IN ?", articleIDs).Delete(&model.Article{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("owner_id = ?", userID).Delete(&model.File{}).Error
+}
+
 // applyFilters 应用过滤器
 func (r *userRepository) applyFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
 	if filters == nil {
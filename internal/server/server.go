@@ -18,15 +18,30 @@ import (
 
 // Server HTTP 服务器
 type Server struct {
-	config         *config.Config
-	logger         logger.Logger
-	httpServer     *http.Server
-	middleware     *middleware.Middleware
-	userHandler    *handler.UserHandler
-	articleHandler *handler.ArticleHandler
-	healthHandler  *handler.HealthHandler
-	dictHandler    *handler.DictHandler
-	departmentHandler *handler.DepartmentHandler
+	config              *config.Config
+	logger              logger.Logger
+	httpServer          *http.Server
+	middleware          *middleware.Middleware
+	userHandler         *handler.UserHandler
+	articleHandler      *handler.ArticleHandler
+	healthHandler       *handler.HealthHandler
+	dictHandler         *handler.DictHandler
+	departmentHandler   *handler.DepartmentHandler
+	fileHandler         *handler.FileHandler
+	uploadHandler       *handler.UploadHandler
+	quotaHandler        *handler.StorageQuotaHandler
+	sessionHandler      *handler.SessionHandler
+	resetHandler        *handler.PasswordResetHandler
+	verificationHandler *handler.EmailVerificationHandler
+	mfaHandler          *handler.MFAHandler
+	oauthHandler        *handler.OAuthHandler
+	jwksHandler         *handler.JWKSHandler
+	apiKeyHandler       *handler.APIKeyHandler
+	roleHandler         *handler.RoleHandler
+	adminUserHandler    *handler.AdminUserHandler
+	auditLogHandler     *handler.AuditLogHandler
+	privacyHandler      *handler.PrivacyHandler
+	invitationHandler   *handler.InvitationHandler
 }
 
 // New 创建新的服务器实例
@@ -39,16 +54,46 @@ func New(
 	healthHandler *handler.HealthHandler,
 	dictHandler *handler.DictHandler,
 	departmentHandler *handler.DepartmentHandler,
+	fileHandler *handler.FileHandler,
+	uploadHandler *handler.UploadHandler,
+	quotaHandler *handler.StorageQuotaHandler,
+	sessionHandler *handler.SessionHandler,
+	resetHandler *handler.PasswordResetHandler,
+	verificationHandler *handler.EmailVerificationHandler,
+	mfaHandler *handler.MFAHandler,
+	oauthHandler *handler.OAuthHandler,
+	jwksHandler *handler.JWKSHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	roleHandler *handler.RoleHandler,
+	adminUserHandler *handler.AdminUserHandler,
+	auditLogHandler *handler.AuditLogHandler,
+	privacyHandler *handler.PrivacyHandler,
+	invitationHandler *handler.InvitationHandler,
 ) *Server {
 	return &Server{
-		config:         config,
-		logger:         logger,
-		middleware:     middleware,
-		userHandler:    userHandler,
-		articleHandler: articleHandler,
-		healthHandler:  healthHandler,
-		dictHandler:    dictHandler,
-		departmentHandler: departmentHandler,
+		config:              config,
+		logger:              logger,
+		middleware:          middleware,
+		userHandler:         userHandler,
+		articleHandler:      articleHandler,
+		healthHandler:       healthHandler,
+		dictHandler:         dictHandler,
+		departmentHandler:   departmentHandler,
+		fileHandler:         fileHandler,
+		uploadHandler:       uploadHandler,
+		quotaHandler:        quotaHandler,
+		sessionHandler:      sessionHandler,
+		resetHandler:        resetHandler,
+		verificationHandler: verificationHandler,
+		mfaHandler:          mfaHandler,
+		oauthHandler:        oauthHandler,
+		jwksHandler:         jwksHandler,
+		apiKeyHandler:       apiKeyHandler,
+		roleHandler:         roleHandler,
+		adminUserHandler:    adminUserHandler,
+		auditLogHandler:     auditLogHandler,
+		privacyHandler:      privacyHandler,
+		invitationHandler:   invitationHandler,
 	}
 }
 
@@ -114,6 +159,9 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 	// 健康检查路由直接注册到引擎上
 	s.healthHandler.RegisterRoutes(engine)
 
+	// 访问令牌公钥，供其他服务独立验证令牌
+	s.jwksHandler.RegisterRoutes(engine)
+
 	// API 路由组
 	api := engine.Group("/api")
 	api.Use(s.middleware.SetupAPIMiddleware()...)
@@ -128,6 +176,15 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 				users := public.Group("/users")
 				users.POST("/register", s.userHandler.Register)
 				users.POST("/login", s.userHandler.Login)
+				users.POST("/login/mfa", s.mfaHandler.Login)
+				users.POST("/refresh", s.userHandler.RefreshToken)
+				users.POST("/password/forgot", s.resetHandler.Forgot)
+				users.POST("/password/reset", s.resetHandler.Reset)
+				users.POST("/email/verify", s.verificationHandler.Verify)
+				users.POST("/email/resend", s.verificationHandler.Resend)
+
+				// 第三方登录（OpenID Connect）
+				s.oauthHandler.RegisterPublicRoutes(public)
 
 				// 文章公共路由（查看文章列表和详情）
 				articles := public.Group("/articles")
@@ -139,6 +196,15 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 
 				// 数据字典路由（不需要认证，便于测试）
 				s.dictHandler.RegisterRoutes(public)
+
+				// 签名下载链接（由签名代替认证）
+				s.fileHandler.RegisterPublicRoutes(public)
+
+				// 个人数据导出下载（由邮件中的令牌代替认证）
+				s.privacyHandler.RegisterPublicRoutes(public)
+
+				// 邀请信息查询（由邀请令牌代替认证）
+				s.invitationHandler.RegisterPublicRoutes(public)
 			}
 
 			// 受保护的路由（需要认证）
@@ -156,8 +222,48 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 					userArticles.PUT("/:id", s.articleHandler.Update)
 					userArticles.DELETE("/:id", s.articleHandler.Delete)
 				}
+
+				// 断点续传上传路由（分片请求频繁，不使用上传限流）
+				s.uploadHandler.RegisterRoutes(protected)
+
+				// 当前用户存储用量
+				s.quotaHandler.RegisterRoutes(protected)
+
+				// 退出登录与会话管理
+				s.sessionHandler.RegisterRoutes(protected)
+
+				// 两步验证管理
+				s.mfaHandler.RegisterRoutes(protected)
+
+				// 已关联的第三方账户
+				s.oauthHandler.RegisterRoutes(protected)
+
+				// 个人 API Key 管理
+				s.apiKeyHandler.RegisterRoutes(protected)
+
+				// 个人数据导出与账户注销
+				s.privacyHandler.RegisterRoutes(protected)
+			}
+
+			// 文件路由（需要认证，删除时检查所有权）
+			files := v1.Group("")
+			files.Use(s.middleware.FileUploadAPI()...)
+			{
+				s.fileHandler.RegisterRoutes(files, s.middleware.RequireOwnership("file"))
 			}
 
+			// 允许机器客户端使用个人 API Key 访问的接口
+			s.registerAPIKeyRoutes(v1.BasePath())
+
+			// 模拟登录时禁止访问的账户安全接口
+			s.registerImpersonationRestrictions(v1.BasePath())
+
+			// 上传接口不受全局请求体大小限制
+			s.registerBodyLimits(v1.BasePath())
+
+			// 管理接口所需权限
+			s.registerAdminPermissions(v1.BasePath() + "/admin")
+
 			// 管理员路由
 			admin := v1.Group("/admin")
 			admin.Use(s.middleware.AdminAPI()...)
@@ -165,6 +271,12 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 				// 管理员专用路由
 				s.userHandler.RegisterRoutes(admin)
 
+				// 解除登录锁定
+				s.userHandler.RegisterAdminRoutes(admin)
+
+				// 创建用户、修改状态和角色、强制重置密码、模拟登录
+				s.adminUserHandler.RegisterAdminRoutes(admin)
+
 				// 管理员文章管理路由（可以操作所有文章）
 				adminArticles := admin.Group("/articles")
 				{
@@ -174,6 +286,24 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 					adminArticles.DELETE("/:id", s.articleHandler.Delete)
 				}
 
+				// 管理员文件管理路由（可以查看和删除所有文件）
+				s.fileHandler.RegisterAdminRoutes(admin)
+
+				// 管理员存储配额管理路由
+				s.quotaHandler.RegisterAdminRoutes(admin)
+
+				// 管理员会话管理路由
+				s.sessionHandler.RegisterAdminRoutes(admin)
+
+				// 角色与权限管理路由
+				s.roleHandler.RegisterAdminRoutes(admin)
+
+				// 审计日志查询路由
+				s.auditLogHandler.RegisterAdminRoutes(admin)
+
+				// 注册邀请管理路由
+				s.invitationHandler.RegisterAdminRoutes(admin)
+
 				// Department管理路由
 				s.departmentHandler.RegisterRoutes(admin)
 
@@ -187,6 +317,111 @@ func (s *Server) setupRoutes(engine *gin.Engine) {
 	}
 }
 
+// registerAPIKeyRoutes 声明可以使用个人 API Key 访问的接口及所需权限，未声明的接口（如账户和密钥管理）只接受登录令牌
+func (s *Server) registerAPIKeyRoutes(prefix string) {
+	routes := []struct {
+		method     string
+		path       string
+		permission middleware.Permission
+	}{
+		{http.MethodGet, "/users/profile", middleware.PermUserReadOwn},
+		{http.MethodGet, "/user/articles", middleware.PermArticleReadOwn},
+		{http.MethodPost, "/user/articles", middleware.PermArticleCreate},
+		{http.MethodPut, "/user/articles/:id", middleware.PermArticleUpdateOwn},
+		{http.MethodDelete, "/user/articles/:id", middleware.PermArticleDeleteOwn},
+		{http.MethodGet, "/files", middleware.PermFileReadOwn},
+		{http.MethodGet, "/files/:id", middleware.PermFileReadOwn},
+		{http.MethodGet, "/files/:id/download", middleware.PermFileReadOwn},
+		{http.MethodPost, "/files/upload", middleware.PermFileUpload},
+		{http.MethodDelete, "/files/:id", middleware.PermFileDeleteOwn},
+	}
+
+	for _, route := range routes {
+		s.middleware.AllowAPIKey(route.method, prefix+route.path, route.permission)
+	}
+}
+
+// registerAdminPermissions 声明各管理接口所需的权限，拥有对应权限的自定义角色也可以访问；未声明的接口只允许管理员访问
+func (s *Server) registerAdminPermissions(prefix string) {
+	routes := []struct {
+		method     string
+		path       string
+		permission middleware.Permission
+	}{
+		{http.MethodGet, "/users", middleware.PermUserReadAll},
+		{http.MethodPost, "/users", middleware.PermUserCreateAll},
+		{http.MethodDelete, "/users/:id", middleware.PermUserDeleteAll},
+		{http.MethodPost, "/users/:id/unlock", middleware.PermUserUpdateAll},
+		{http.MethodPut, "/users/:id/status", middleware.PermUserUpdateAll},
+		{http.MethodPut, "/users/:id/role", middleware.PermRoleAssign},
+		{http.MethodPost, "/users/:id/password-reset", middleware.PermUserUpdateAll},
+		{http.MethodPost, "/users/:id/impersonate", middleware.PermUserImpersonateAll},
+		{http.MethodGet, "/articles", middleware.PermArticleReadAll},
+		{http.MethodPost, "/articles", middleware.PermArticleCreate},
+		{http.MethodPut, "/articles/:id", middleware.PermArticleUpdateAll},
+		{http.MethodDelete, "/articles/:id", middleware.PermArticleDeleteAll},
+		{http.MethodGet, "/files", middleware.PermFileReadAll},
+		{http.MethodGet, "/files/:id", middleware.PermFileReadAll},
+		{http.MethodDelete, "/files/:id", middleware.PermFileDeleteAll},
+		{http.MethodGet, "/users/:id/storage", middleware.PermQuotaReadAll},
+		{http.MethodPut, "/users/:id/storage", middleware.PermQuotaUpdateAll},
+		{http.MethodGet, "/users/:id/sessions", middleware.PermSessionReadAll},
+		{http.MethodDelete, "/users/:id/sessions", middleware.PermSessionDeleteAll},
+		{http.MethodGet, "/roles", middleware.PermRoleRead},
+		{http.MethodGet, "/roles/:id", middleware.PermRoleRead},
+		{http.MethodPost, "/roles", middleware.PermRoleCreate},
+		{http.MethodPut, "/roles/:id", middleware.PermRoleUpdate},
+		{http.MethodDelete, "/roles/:id", middleware.PermRoleDelete},
+		{http.MethodGet, "/permissions", middleware.PermRoleRead},
+		{http.MethodGet, "/users/:id/roles", middleware.PermRoleRead},
+		{http.MethodPut, "/users/:id/roles", middleware.PermRoleAssign},
+		{http.MethodGet, "/audit-logs", middleware.PermAuditRead},
+		{http.MethodGet, "/invitations", middleware.PermInvitationRead},
+		{http.MethodPost, "/invitations", middleware.PermInvitationCreate},
+		{http.MethodDelete, "/invitations/:id", middleware.PermInvitationDelete},
+		{http.MethodGet, "/departments", middleware.PermDepartmentRead},
+		{http.MethodGet, "/departments/tree", middleware.PermDepartmentRead},
+		{http.MethodGet, "/departments/:id", middleware.PermDepartmentRead},
+		{http.MethodGet, "/departments/:id/children", middleware.PermDepartmentRead},
+		{http.MethodGet, "/departments/:id/path", middleware.PermDepartmentRead},
+		{http.MethodPost, "/departments", middleware.PermDepartmentCreate},
+		{http.MethodPut, "/departments/:id", middleware.PermDepartmentUpdate},
+		{http.MethodPut, "/departments/:id/move", middleware.PermDepartmentUpdate},
+		{http.MethodDelete, "/departments/:id", middleware.PermDepartmentDelete},
+	}
+
+	for _, route := range routes {
+		s.middleware.SetRoutePermission(route.method, prefix+route.path, route.permission)
+	}
+}
+
+// registerImpersonationRestrictions 声明模拟登录令牌不能访问的接口，防止管理员借用户身份修改其凭据
+func (s *Server) registerImpersonationRestrictions(prefix string) {
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPut, "/users/profile"},
+		{http.MethodPost, "/users/change-password"},
+		{http.MethodPost, "/users/me/mfa"},
+		{http.MethodPost, "/users/me/mfa/confirm"},
+		{http.MethodPost, "/users/me/mfa/disable"},
+		{http.MethodPost, "/users/me/mfa/recovery-codes"},
+		{http.MethodPost, "/users/me/api-keys"},
+		{http.MethodDelete, "/users/me/api-keys/:id"},
+	}
+
+	for _, route := range routes {
+		s.middleware.DenyImpersonation(route.method, prefix+route.path)
+	}
+}
+
+// registerBodyLimits 声明上传接口的请求体大小上限；断点续传分片的读取量由上传会话的剩余长度限制，因此不设上限
+func (s *Server) registerBodyLimits(prefix string) {
+	s.middleware.SetBodyLimit(http.MethodPost, prefix+"/files/upload", s.config.Upload.MaxRequestSize)
+	s.middleware.SetBodyLimit(http.MethodPatch, prefix+"/uploads/:id", 0)
+}
+
 // setupSwaggerRoutes 设置 Swagger 文档路由
 func (s *Server) setupSwaggerRoutes(engine *gin.Engine) {
 	// Swagger 文档路由
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/password"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrUserExists 用户名或邮箱已被使用
+	ErrUserExists = errors.New("user already exists")
+	// ErrInvalidUserInput 用户名、邮箱、角色或状态不正确
+	ErrInvalidUserInput = errors.New("invalid user input")
+	// ErrCannotModifySelf 管理员不能修改自己的状态、角色或模拟自己
+	ErrCannotModifySelf = errors.New("cannot perform this action on yourself")
+	// ErrImpersonationNotAllowed 不能模拟管理员或未激活的用户
+	ErrImpersonationNotAllowed = errors.New("impersonation not allowed")
+)
+
+// adminUserService 管理员用户管理服务实现
+type adminUserService struct {
+	userRepo        repository.UserRepository
+	roles           RoleService
+	tokens          TokenService
+	resets          PasswordResetService
+	permissionCache PermissionCache
+	auditService    AuditService
+	tokenManager    *token.Manager
+	policy          *password.Policy
+	config          *config.Config
+	logger          logger.Logger
+}
+
+// NewAdminUserService 创建管理员用户管理服务
+func NewAdminUserService(
+	userRepo repository.UserRepository,
+	roles RoleService,
+	tokens TokenService,
+	resets PasswordResetService,
+	permissionCache PermissionCache,
+	auditService AuditService,
+	tokenManager *token.Manager,
+	policy *password.Policy,
+	config *config.Config,
+	logger logger.Logger,
+) AdminUserService {
+	return &adminUserService{
+		userRepo:        userRepo,
+		roles:           roles,
+		tokens:          tokens,
+		resets:          resets,
+		permissionCache: permissionCache,
+		auditService:    auditService,
+		tokenManager:    tokenManager,
+		policy:          policy,
+		config:          config,
+		logger:          logger,
+	}
+}
+
+// CreateUser 直接创建用户，不需要验证邮箱，只有拥有全部权限的操作者才能创建管理员
+func (s *adminUserService) CreateUser(ctx context.Context, actorID uint, req *AdminCreateUserRequest) (*model.User, error) {
+	username := strings.TrimSpace(req.Username)
+	email := strings.TrimSpace(req.Email)
+	if len(username) < 3 || len(username) > 50 {
+		return nil, fmt.Errorf("%w: username must be 3 to 50 characters", ErrInvalidUserInput)
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, fmt.Errorf("%w: invalid email", ErrInvalidUserInput)
+	}
+	if err := s.policy.Validate(req.Password, username); err != nil {
+		return nil, err
+	}
+
+	role := req.Role
+	if role == "" {
+		role = model.UserRoleUser
+	}
+	status := req.Status
+	if status == "" {
+		status = model.UserStatusActive
+	}
+	if !validUserRole(role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidUserInput, role)
+	}
+	if !validUserStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidUserInput, status)
+	}
+	if err := checkRoleGrant(ctx, s.roles, actorID, role); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAvailable(ctx, username, email); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Username:        username,
+		Email:           email,
+		Password:        req.Password, // 密码会在 BeforeCreate 钩子中加密
+		Nickname:        req.Nickname,
+		Role:            role,
+		Status:          status,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// 审计日志会在用户注销后保留，不记录用户名和邮箱等个人信息
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.create",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(user.ID),
+		After:        map[string]interface{}{"role": user.Role, "status": user.Status},
+	})
+
+	s.logger.Info("User created by admin", "user_id", user.ID, "role", user.Role, "status", user.Status)
+	return user, nil
+}
+
+// UpdateStatus 修改用户状态，禁用或封禁时撤销用户的所有会话
+func (s *adminUserService) UpdateStatus(ctx context.Context, actorID, userID uint, status string) (*model.User, error) {
+	if !validUserStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidUserInput, status)
+	}
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == status {
+		return user, nil
+	}
+	if err := s.checkAdminTarget(ctx, actorID, user); err != nil {
+		return nil, err
+	}
+
+	previous := user.Status
+	user.Status = status
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if status != model.UserStatusActive {
+		if _, err := s.tokens.RevokeAllSessions(ctx, user.ID); err != nil {
+			s.logger.Error("Failed to revoke sessions after status change", "user_id", user.ID, "error", err)
+			return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.status_update",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(user.ID),
+		Before:       map[string]interface{}{"status": previous},
+		After:        map[string]interface{}{"status": status},
+	})
+
+	s.logger.Info("User status changed", "user_id", user.ID, "actor_id", actorID, "from", previous, "to", status)
+	return user, nil
+}
+
+// UpdateRole 修改用户的 users.role，清除其权限缓存并撤销会话，使访问令牌中的角色立即失效
+func (s *adminUserService) UpdateRole(ctx context.Context, actorID, userID uint, role string) (*model.User, error) {
+	if !validUserRole(role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidUserInput, role)
+	}
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	if err := s.checkAdminTarget(ctx, actorID, user); err != nil {
+		return nil, err
+	}
+	if err := checkRoleGrant(ctx, s.roles, actorID, role); err != nil {
+		return nil, err
+	}
+
+	previous := user.Role
+	user.Role = role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := s.permissionCache.ClearUserPermissions(user.ID); err != nil {
+		s.logger.Error("Failed to clear user permission cache", "user_id", user.ID, "error", err)
+		return nil, fmt.Errorf("failed to clear permission cache: %w", err)
+	}
+	if _, err := s.tokens.RevokeAllSessions(ctx, user.ID); err != nil {
+		s.logger.Error("Failed to revoke sessions after role change", "user_id", user.ID, "error", err)
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.role_update",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(user.ID),
+		Before:       map[string]interface{}{"role": previous},
+		After:        map[string]interface{}{"role": role},
+	})
+
+	s.logger.Info("User role changed", "user_id", user.ID, "actor_id", actorID, "from", previous, "to", role)
+	return user, nil
+}
+
+// ForcePasswordReset 强制重置密码：旧密码立即失效，撤销所有会话并向用户发送重置链接
+func (s *adminUserService) ForcePasswordReset(ctx context.Context, actorID, userID uint) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkAdminTarget(ctx, actorID, user); err != nil {
+		return err
+	}
+
+	// 替换为无人知晓的随机密码，用户只能通过重置链接设置新密码
+	random, err := token.NewOpaque()
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := user.HashPassword(random)
+	if err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if _, err := s.tokens.RevokeAllSessions(ctx, user.ID); err != nil {
+		s.logger.Error("Failed to revoke sessions after forced password reset", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if err := s.resets.Forgot(ctx, &ForgotPasswordRequest{Email: user.Email}); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.password_reset_force",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(user.ID),
+	})
+
+	s.logger.Info("Password reset forced by admin", "user_id", user.ID, "actor_id", actorID)
+	return nil
+}
+
+// Impersonate 为管理员签发以用户身份访问的短期令牌，不能模拟管理员、未激活的用户、自己或拥有操作者没有的权限的用户
+func (s *adminUserService) Impersonate(ctx context.Context, actorID, userID uint) (*ImpersonationResponse, error) {
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	actor, err := s.getUser(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsAdmin() || !user.IsActive() {
+		return nil, ErrImpersonationNotAllowed
+	}
+	// 模拟登录获得目标用户的全部权限，不能借此扩大自己的权限
+	if err := s.roles.CheckAuthority(ctx, actorID, userID); err != nil {
+		if errors.Is(err, ErrPermissionNotHeld) {
+			return nil, ErrImpersonationNotAllowed
+		}
+		return nil, err
+	}
+
+	ttl := time.Duration(s.config.Auth.ImpersonationExpiration) * time.Second
+	accessToken, claims, err := s.tokenManager.IssueImpersonation(user, actor, ttl)
+	if err != nil {
+		s.logger.Error("Failed to issue impersonation token", "user_id", user.ID, "actor_id", actor.ID, "error", err)
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.impersonate",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(user.ID),
+		After:        map[string]interface{}{"expires_at": claims.ExpiresAt.Time},
+	})
+
+	s.logger.Warn("Impersonation token issued",
+		"user_id", user.ID,
+		"actor_id", actor.ID,
+		"jti", claims.ID,
+		"expires_at", claims.ExpiresAt.Time)
+
+	return &ImpersonationResponse{
+		User:           user.ToPublic(),
+		Token:          accessToken,
+		TokenType:      "Bearer",
+		ExpiresIn:      int64(time.Until(claims.ExpiresAt.Time).Round(time.Second).Seconds()),
+		ImpersonatorID: actor.ID,
+	}, nil
+}
+
+// getUser 获取用户，不存在时返回 ErrUserNotFound
+func (s *adminUserService) getUser(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkRoleGrant 授予管理员角色时要求操作者拥有全部权限，创建用户、修改角色和邀请注册共用
+func checkRoleGrant(ctx context.Context, roles RoleService, actorID uint, role string) error {
+	if role != model.UserRoleAdmin {
+		return nil
+	}
+	return roles.CheckGrant(ctx, actorID, []string{"*:*"})
+}
+
+// checkAdminTarget 目标用户拥有全部权限时，只有同样拥有全部权限的操作者才能修改其状态、角色或密码
+func (s *adminUserService) checkAdminTarget(ctx context.Context, actorID uint, user *model.User) error {
+	if !user.IsAdmin() {
+		permissions, err := s.roles.GetUserPermissions(ctx, user.ID, user.Role)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(permissions, "*:*") {
+			return nil
+		}
+	}
+	return s.roles.CheckGrant(ctx, actorID, []string{"*:*"})
+}
+
+// checkAvailable 检查用户名和邮箱未被使用
+func (s *adminUserService) checkAvailable(ctx context.Context, username, email string) error {
+	if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		return fmt.Errorf("%w: email %s is already registered", ErrUserExists, email)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if _, err := s.userRepo.GetByUsername(ctx, username); err == nil {
+		return fmt.Errorf("%w: username %s is already taken", ErrUserExists, username)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// validUserRole 检查 users.role 的取值，自定义角色通过角色管理接口分配
+func validUserRole(role string) bool {
+	return role == model.UserRoleUser || role == model.UserRoleAdmin
+}
+
+// validUserStatus 检查用户状态的取值
+func validUserStatus(status string) bool {
+	switch status {
+	case model.UserStatusActive, model.UserStatusInactive, model.UserStatusBanned:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrAPIKeyNotFound API Key 不存在或已撤销
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIKey API Key 无效、已撤销、已过期或所属用户不可用
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInvalidAPIKeyScope 权限格式不正确
+	ErrInvalidAPIKeyScope = errors.New("invalid api key scope")
+	// ErrInvalidAPIKeyExpiry 过期时间不在将来
+	ErrInvalidAPIKeyExpiry = errors.New("api key expiry must be in the future")
+	// ErrAPIKeyLimitReached 用户有效的 API Key 数量达到上限
+	ErrAPIKeyLimitReached = errors.New("api key limit reached")
+)
+
+const (
+	// apiKeyPrefix API Key 明文前缀，便于密钥扫描工具识别泄露的 Key
+	apiKeyPrefix = "vk_"
+	// apiKeyDisplayLength 保存并展示的明文前缀长度
+	apiKeyDisplayLength = 12
+	// maxAPIKeysPerUser 每个用户有效的 API Key 数量上限
+	maxAPIKeysPerUser = 20
+	// apiKeyUsageInterval 记录最近使用时间的最小间隔，避免每个请求都写数据库
+	apiKeyUsageInterval = time.Minute
+)
+
+// apiKeyService 个人 API Key 服务实现
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+	logger     logger.Logger
+}
+
+// NewAPIKeyService 创建个人 API Key 服务
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	userRepo repository.UserRepository,
+	logger logger.Logger,
+) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		logger:     logger,
+	}
+}
+
+// Create 创建 API Key，明文只在返回值中出现一次
+func (s *apiKeyService) Create(ctx context.Context, userID uint, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	scopes, err := normalizeAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+
+	count, err := s.apiKeyRepo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, ErrAPIKeyLimitReached
+	}
+
+	secret, err := token.NewOpaque()
+	if err != nil {
+		return nil, err
+	}
+	plain := apiKeyPrefix + secret
+
+	apiKey := &model.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    plain[:apiKeyDisplayLength],
+		KeyHash:   token.Hash(plain),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("API key created", "user_id", userID, "api_key_id", apiKey.ID, "scopes", scopes)
+	return &CreatedAPIKey{APIKey: apiKey, Key: plain}, nil
+}
+
+// List 获取用户未撤销的 API Key
+func (s *apiKeyService) List(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	return s.apiKeyRepo.ListByUser(ctx, userID)
+}
+
+// Revoke 撤销用户的指定 API Key，立即生效
+func (s *apiKeyService) Revoke(ctx context.Context, userID, keyID uint) error {
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	if apiKey.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+
+	revoked, err := s.apiKeyRepo.Revoke(ctx, apiKey.ID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+
+	s.logger.Info("API key revoked", "user_id", userID, "api_key_id", apiKey.ID)
+	return nil
+}
+
+// Authenticate 验证 API Key 并返回其所属用户，同时记录最近使用时间
+func (s *apiKeyService) Authenticate(ctx context.Context, key, ip string) (*model.APIKey, *model.User, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, token.Hash(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	if !apiKey.IsActive() {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	// 被禁用或删除的用户，其 API Key 随之失效
+	user, err := s.userRepo.GetByID(ctx, apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	if !user.IsActive() {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) >= apiKeyUsageInterval || apiKey.LastUsedIP != ip {
+		if err := s.apiKeyRepo.RecordUsage(ctx, apiKey.ID, ip); err != nil {
+			s.logger.Warn("Failed to record api key usage", "api_key_id", apiKey.ID, "error", err)
+		}
+	}
+
+	return apiKey, user, nil
+}
+
+// normalizeAPIKeyScopes 校验权限格式并去重
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyScope)
+	}
+
+	normalized := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !permissionPattern.MatchString(scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAPIKeyScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
@@ -12,21 +12,24 @@ import (
 
 // articleService 文章服务实现
 type articleService struct {
-	articleRepo repository.ArticleRepository
-	cache       cache.Cache
-	logger      logger.Logger
+	articleRepo  repository.ArticleRepository
+	cache        cache.Cache
+	auditService AuditService
+	logger       logger.Logger
 }
 
 // NewArticleService 创建文章服务
 func NewArticleService(
 	articleRepo repository.ArticleRepository,
 	cache cache.Cache,
+	auditService AuditService,
 	logger logger.Logger,
 ) ArticleService {
 	return &articleService{
-		articleRepo: articleRepo,
-		cache:       cache,
-		logger:      logger,
+		articleRepo:  articleRepo,
+		cache:        cache,
+		auditService: auditService,
+		logger:       logger,
 	}
 }
 
@@ -53,6 +56,13 @@ func (s *articleService) Create(ctx context.Context, req *CreateArticleRequest)
 		return nil, fmt.Errorf("failed to create article: %w", err)
 	}
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "article.create",
+		ResourceType: "article",
+		ResourceID:   auditResourceID(article.ID),
+		After:        articleAuditState(article),
+	})
+
 	s.logger.Info("Article created successfully", "article_id", article.ID, "title", article.Title)
 	return article, nil
 }
@@ -87,6 +97,7 @@ func (s *articleService) Update(ctx context.Context, id uint, req *UpdateArticle
 		s.logger.Error("Failed to get article for update", "id", id, "error", err)
 		return nil, fmt.Errorf("failed to get article: %w", err)
 	}
+	before := *article
 
 	// 更新字段
 	if req.Title != "" {
@@ -114,6 +125,14 @@ func (s *articleService) Update(ctx context.Context, id uint, req *UpdateArticle
 		return nil, fmt.Errorf("failed to update article: %w", err)
 	}
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "article.update",
+		ResourceType: "article",
+		ResourceID:   auditResourceID(id),
+		Before:       articleAuditState(&before),
+		After:        articleAuditState(article),
+	})
+
 	s.logger.Info("Article updated successfully", "article_id", id)
 	return article, nil
 }
@@ -121,7 +140,7 @@ func (s *articleService) Update(ctx context.Context, id uint, req *UpdateArticle
 // Delete 删除文章
 func (s *articleService) Delete(ctx context.Context, id uint) error {
 	// 检查文章是否存在
-	_, err := s.articleRepo.GetByID(ctx, id)
+	article, err := s.articleRepo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.Error("Failed to get article for deletion", "id", id, "error", err)
 		return fmt.Errorf("failed to get article: %w", err)
@@ -133,6 +152,13 @@ func (s *articleService) Delete(ctx context.Context, id uint) error {
 		return fmt.Errorf("failed to delete article: %w", err)
 	}
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "article.delete",
+		ResourceType: "article",
+		ResourceID:   auditResourceID(id),
+		Before:       articleAuditState(article),
+	})
+
 	s.logger.Info("Article deleted successfully", "article_id", id)
 	return nil
 }
@@ -183,3 +209,13 @@ func (s *articleService) IncrementViewCount(ctx context.Context, articleID uint)
 
 	return nil
 }
+
+// articleAuditState 审计日志中记录的文章状态，不包含预加载的作者等关联数据
+func articleAuditState(article *model.Article) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       article.Title,
+		"status":      article.Status,
+		"category_id": article.CategoryID,
+		"author_id":   article.AuthorID,
+	}
+}
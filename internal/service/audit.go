@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/audit"
+	"vibe-coding-starter/pkg/logger"
+)
+
+const (
+	// auditQueueSize 等待写入的审计日志上限，队列已满时丢弃新的日志以免阻塞请求
+	auditQueueSize = 1024
+	// auditBatchSize 每次批量写入的最大条数
+	auditBatchSize = 100
+	// auditFlushInterval 未攒满一批时的最长写入间隔
+	auditFlushInterval = time.Second
+	// auditFlushTimeout 单次批量写入的超时时间
+	auditFlushTimeout = 10 * time.Second
+)
+
+// auditFilteredValue 敏感字段变更后记录的值
+const auditFilteredValue = "***FILTERED***"
+
+// auditSkippedFields 不记录差异的字段
+var auditSkippedFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+}
+
+// auditSensitiveFields 只记录发生变更、不记录取值的字段
+var auditSensitiveFields = []string{"password", "secret", "token", "hash"}
+
+// auditService 审计日志服务实现，日志先进入队列，由后台协程批量写入数据库
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+	logger    logger.Logger
+	queue     chan *model.AuditLog
+	quit      chan struct{}
+	stopped   chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(
+	auditRepo repository.AuditLogRepository,
+	logger logger.Logger,
+) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		logger:    logger,
+		queue:     make(chan *model.AuditLog, auditQueueSize),
+		quit:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Record 记录审计事件，操作人、IP 和请求 ID 取自上下文，队列已满时丢弃并记录警告
+func (s *auditService) Record(ctx context.Context, entry *AuditEntry) {
+	log := &model.AuditLog{
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		Changes:      diffChanges(entry.Before, entry.After),
+		CreatedAt:    time.Now(),
+	}
+	if md := audit.FromContext(ctx); md != nil {
+		if md.ActorID != 0 {
+			actorID := md.ActorID
+			log.ActorID = &actorID
+		}
+		if md.ImpersonatorID != 0 {
+			impersonatorID := md.ImpersonatorID
+			log.ImpersonatorID = &impersonatorID
+		}
+		log.IP = md.IP
+		log.UserAgent = truncate(md.UserAgent, 255)
+		log.RequestID = md.RequestID
+		md.Recorded = true
+	}
+
+	select {
+	case s.queue <- log:
+	default:
+		s.logger.Warn("Audit log queue is full, dropping entry",
+			"action", log.Action,
+			"resource_type", log.ResourceType,
+			"resource_id", log.ResourceID,
+			"request_id", log.RequestID)
+	}
+}
+
+// List 查询审计日志
+func (s *auditService) List(ctx context.Context, opts repository.ListOptions) ([]*model.AuditLog, int64, error) {
+	return s.auditRepo.List(ctx, opts)
+}
+
+// Start 启动后台写入协程
+func (s *auditService) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+// Stop 停止后台写入协程，等待队列中剩余的审计日志写入
+func (s *auditService) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+	})
+	// 未启动时也需要写入队列中已有的日志
+	s.Start()
+
+	select {
+	case <-s.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 从队列中取出审计日志，攒满一批或到达写入间隔时批量写入
+func (s *auditService) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*model.AuditLog, 0, auditBatchSize)
+	for {
+		select {
+		case log := <-s.queue:
+			batch = append(batch, log)
+			if len(batch) >= auditBatchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+		case <-s.quit:
+			for {
+				select {
+				case log := <-s.queue:
+					batch = append(batch, log)
+				default:
+					s.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush 写入一批审计日志，失败时只记录错误，不影响后续写入
+func (s *auditService) flush(batch []*model.AuditLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditFlushTimeout)
+	defer cancel()
+
+	if err := s.auditRepo.CreateBatch(ctx, batch); err != nil {
+		s.logger.Error("Failed to write audit logs", "count", len(batch), "error", err)
+	}
+}
+
+// diffChanges 按 JSON 字段比较修改前后的资源，返回发生变化的字段
+func diffChanges(before, after interface{}) map[string]model.AuditChange {
+	from := toFieldMap(before)
+	to := toFieldMap(after)
+
+	// 创建或删除时只记录有值的字段
+	changes := make(map[string]model.AuditChange)
+	for field, value := range from {
+		newValue, ok := to[field]
+		if (ok || value != nil) && !reflect.DeepEqual(value, newValue) {
+			changes[field] = model.AuditChange{From: value, To: newValue}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok && value != nil {
+			changes[field] = model.AuditChange{To: value}
+		}
+	}
+
+	for field, change := range changes {
+		if auditSkippedFields[field] {
+			delete(changes, field)
+			continue
+		}
+		if isAuditSensitiveField(field) {
+			change.From, change.To = auditFilteredValue, auditFilteredValue
+			changes[field] = change
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// toFieldMap 将资源转换为 JSON 字段到取值的映射，资源为空或无法转换时返回空
+func toFieldMap(v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// auditResourceID 将数字 ID 转换为审计日志的资源 ID
+func auditResourceID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// isAuditSensitiveField 检查字段是否为敏感字段
+func isAuditSensitiveField(field string) bool {
+	field = strings.ToLower(field)
+	for _, sensitive := range auditSensitiveFields {
+		if strings.Contains(field, sensitive) {
+			return true
+		}
+	}
+	return false
+}
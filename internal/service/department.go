@@ -27,19 +27,19 @@ type DepartmentService interface {
 // departmentService Department服务实现
 type departmentService struct {
 	departmentRepo repository.DepartmentRepository
-
+	auditService   AuditService
 	logger      logger.Logger
 }
 
 // NewDepartmentService 创建Department服务
 func NewDepartmentService(
 	departmentRepo repository.DepartmentRepository,
-
+	auditService AuditService,
 	logger logger.Logger,
 ) DepartmentService {
 	return &departmentService{
 		departmentRepo: departmentRepo,
-
+		auditService:   auditService,
 		logger:      logger,
 	}
 }
@@ -100,7 +100,12 @@ func (s *departmentService) Create(ctx context.Context, req *CreateDepartmentReq
 		return nil, fmt.Errorf("failed to create department: %w", err)
 	}
 
-
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "department.create",
+		ResourceType: "department",
+		ResourceID:   auditResourceID(entity.ID),
+		After:        entity,
+	})
 
 	s.logger.Info("Department created successfully", "id", entity.ID)
 	return entity, nil
@@ -133,6 +138,7 @@ func (s *departmentService) Update(ctx context.Context, id uint, req *UpdateDepa
 	if err != nil {
 		return nil, fmt.Errorf("failed to get department: %w", err)
 	}
+	before := *entity
 
 	// 更新字段
 	if req.Name != nil {
@@ -163,7 +169,13 @@ func (s *departmentService) Update(ctx context.Context, id uint, req *UpdateDepa
 		return nil, fmt.Errorf("failed to update department: %w", err)
 	}
 
-
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "department.update",
+		ResourceType: "department",
+		ResourceID:   auditResourceID(id),
+		Before:       &before,
+		After:        entity,
+	})
 
 	s.logger.Info("Department updated successfully", "id", id)
 	return entity, nil
@@ -172,7 +184,8 @@ func (s *departmentService) Update(ctx context.Context, id uint, req *UpdateDepa
 // Delete 删除Department
 func (s *departmentService) Delete(ctx context.Context, id uint) error {
 	// 检查实体是否存在
-	if _, err := s.departmentRepo.GetByID(ctx, id); err != nil {
+	entity, err := s.departmentRepo.GetByID(ctx, id)
+	if err != nil {
 		return fmt.Errorf("failed to get department: %w", err)
 	}
 
@@ -182,7 +195,12 @@ func (s *departmentService) Delete(ctx context.Context, id uint) error {
 		return fmt.Errorf("failed to delete department: %w", err)
 	}
 
-
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "department.delete",
+		ResourceType: "department",
+		ResourceID:   auditResourceID(id),
+		Before:       entity,
+	})
 
 	s.logger.Info("Department deleted successfully", "id", id)
 	return nil
@@ -310,6 +328,7 @@ func (s *departmentService) Move(ctx context.Context, id uint, newParentId uint)
 	if err != nil {
 		return fmt.Errorf("failed to get department: %w", err)
 	}
+	before := *dept
 
 	// 更新父部门ID
 	dept.ParentId = newParentId
@@ -341,6 +360,14 @@ func (s *departmentService) Move(ctx context.Context, id uint, newParentId uint)
 		return fmt.Errorf("failed to update children paths: %w", err)
 	}
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "department.move",
+		ResourceType: "department",
+		ResourceID:   auditResourceID(id),
+		Before:       &before,
+		After:        dept,
+	})
+
 	s.logger.Info("Department moved successfully", "id", id, "new_parent_id", newParentId)
 	return nil
 }
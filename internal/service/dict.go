@@ -14,21 +14,24 @@ import (
 
 // dictService 数据字典服务实现
 type dictService struct {
-	dictRepo repository.DictRepository
-	cache    cache.Cache
-	logger   logger.Logger
+	dictRepo     repository.DictRepository
+	cache        cache.Cache
+	auditService AuditService
+	logger       logger.Logger
 }
 
 // NewDictService 创建数据字典服务
 func NewDictService(
 	dictRepo repository.DictRepository,
 	cache cache.Cache,
+	auditService AuditService,
 	logger logger.Logger,
 ) DictService {
 	return &dictService{
-		dictRepo: dictRepo,
-		cache:    cache,
-		logger:   logger,
+		dictRepo:     dictRepo,
+		cache:        cache,
+		auditService: auditService,
+		logger:       logger,
 	}
 }
 
@@ -141,6 +144,13 @@ func (s *dictService) CreateDictCategory(ctx context.Context, req *CreateCategor
 		return nil, fmt.Errorf("failed to create dict category: %w", err)
 	}
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "dict_category.create",
+		ResourceType: "dict_category",
+		ResourceID:   auditResourceID(category.ID),
+		After:        category,
+	})
+
 	s.logger.Info("Dict category created successfully", "code", req.Code, "name", req.Name)
 	return category, nil
 }
@@ -189,6 +199,13 @@ func (s *dictService) DeleteDictCategory(ctx context.Context, id uint) error {
 		s.logger.Error("Failed to clear categories cache", "error", err)
 	}
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "dict_category.delete",
+		ResourceType: "dict_category",
+		ResourceID:   auditResourceID(id),
+		Before:       targetCategory,
+	})
+
 	s.logger.Info("Dict category deleted successfully", "id", id, "code", targetCategory.Code, "name", targetCategory.Name)
 	return nil
 }
@@ -217,6 +234,13 @@ func (s *dictService) CreateDictItem(ctx context.Context, req *CreateItemRequest
 	// 清除相关缓存
 	s.clearCache(ctx, req.CategoryCode, req.ItemKey)
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "dict_item.create",
+		ResourceType: "dict_item",
+		ResourceID:   auditResourceID(item.ID),
+		After:        item,
+	})
+
 	s.logger.Info("Dict item created successfully", "category_code", req.CategoryCode, "item_key", req.ItemKey)
 	return item, nil
 }
@@ -229,6 +253,7 @@ func (s *dictService) UpdateDictItem(ctx context.Context, id uint, req *UpdateIt
 		s.logger.Error("Failed to get dict item by ID", "id", id, "error", err)
 		return nil, fmt.Errorf("dict item not found with id %d", id)
 	}
+	before := *targetItem
 
 	// 更新字段
 	if req.ItemValue != "" {
@@ -248,6 +273,14 @@ func (s *dictService) UpdateDictItem(ctx context.Context, id uint, req *UpdateIt
 	// 清除相关缓存
 	s.clearCache(ctx, targetItem.CategoryCode, targetItem.ItemKey)
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "dict_item.update",
+		ResourceType: "dict_item",
+		ResourceID:   auditResourceID(id),
+		Before:       &before,
+		After:        targetItem,
+	})
+
 	s.logger.Info("Dict item updated successfully", "id", id, "category_code", targetItem.CategoryCode, "item_key", targetItem.ItemKey)
 	return targetItem, nil
 }
@@ -269,6 +302,13 @@ func (s *dictService) DeleteDictItem(ctx context.Context, id uint) error {
 	// 清除相关缓存
 	s.clearCache(ctx, targetItem.CategoryCode, targetItem.ItemKey)
 
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "dict_item.delete",
+		ResourceType: "dict_item",
+		ResourceID:   auditResourceID(id),
+		Before:       targetItem,
+	})
+
 	s.logger.Info("Dict item deleted successfully", "id", id, "category_code", targetItem.CategoryCode, "item_key", targetItem.ItemKey)
 	return nil
 }
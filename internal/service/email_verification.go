@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrEmailNotVerified 用户尚未验证邮箱
+	ErrEmailNotVerified = errors.New("email not verified")
+	// ErrInvalidVerificationToken 验证令牌无效、已过期或已使用
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	// ErrVerificationResendTooSoon 重发验证邮件过于频繁
+	ErrVerificationResendTooSoon = errors.New("verification email was sent recently, please try again later")
+)
+
+// emailVerificationService 邮箱验证服务实现
+type emailVerificationService struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.OneTimeTokenRepository
+	mailer    mailer.Mailer
+	cache     cache.Cache
+	config    *config.Config
+	logger    logger.Logger
+}
+
+// NewEmailVerificationService 创建邮箱验证服务
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.OneTimeTokenRepository,
+	mailer mailer.Mailer,
+	cache cache.Cache,
+	config *config.Config,
+	logger logger.Logger,
+) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		mailer:    mailer,
+		cache:     cache,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// Send 为等待验证的用户生成验证令牌并发送验证邮件，之前发送的链接随即失效
+func (s *emailVerificationService) Send(ctx context.Context, user *model.User) error {
+	if err := s.tokenRepo.DeleteByUser(ctx, user.ID, model.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	plain, err := token.NewOpaque()
+	if err != nil {
+		return err
+	}
+	record := &model.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposeEmailVerification,
+		TokenHash: token.Hash(plain),
+		ExpiresAt: time.Now().Add(s.expiration()),
+	}
+	if err := s.tokenRepo.Create(ctx, record); err != nil {
+		return err
+	}
+
+	// 记录发送时间，重发接口据此限流
+	if err := s.cache.Set(ctx, verificationResendKey(user.Email), "sent", s.resendInterval()); err != nil {
+		s.logger.Warn("Failed to record verification email send time", "user_id", user.ID, "error", err)
+	}
+
+	if err := s.mailer.Send(ctx, s.verificationMessage(user, plain)); err != nil {
+		s.logger.Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	s.logger.Info("Verification email sent", "user_id", user.ID)
+	return nil
+}
+
+// Verify 使用验证令牌确认邮箱并激活账户
+func (s *emailVerificationService) Verify(ctx context.Context, req *VerifyEmailRequest) (*model.User, error) {
+	if req.Token == "" {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	record, err := s.tokenRepo.GetByHash(ctx, model.TokenPurposeEmailVerification, token.Hash(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, err
+	}
+	if record.UsedAt != nil || record.IsExpired() {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	consumed, err := s.tokenRepo.Consume(ctx, record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	// 只激活仍在等待验证的用户，已被管理员禁用的账户不会因此恢复
+	verified, err := s.userRepo.MarkEmailVerified(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get verified user", "user_id", record.UserID, "error", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	s.logger.Info("Email verified successfully", "user_id", user.ID)
+	return user, nil
+}
+
+// Resend 重新发送验证邮件，邮箱不存在或已验证时同样返回成功，避免泄露注册信息
+func (s *emailVerificationService) Resend(ctx context.Context, req *ResendVerificationRequest) error {
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return nil
+	}
+
+	// 不论邮箱是否存在都按相同规则限流，响应不会暴露注册信息
+	sent, err := s.cache.Exists(ctx, verificationResendKey(email))
+	if err != nil {
+		s.logger.Warn("Failed to check verification resend limit", "error", err)
+	}
+	if sent > 0 {
+		return ErrVerificationResendTooSoon
+	}
+	if err := s.cache.Set(ctx, verificationResendKey(email), "sent", s.resendInterval()); err != nil {
+		s.logger.Warn("Failed to record verification email send time", "error", err)
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsPendingVerification() {
+		return nil
+	}
+
+	return s.Send(ctx, user)
+}
+
+// expiration 验证令牌有效期
+func (s *emailVerificationService) expiration() time.Duration {
+	ttl := time.Duration(s.config.Auth.EmailVerificationExpiration) * time.Second
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return ttl
+}
+
+// resendInterval 同一邮箱重发验证邮件的最小间隔
+func (s *emailVerificationService) resendInterval() time.Duration {
+	interval := time.Duration(s.config.Auth.VerificationResendInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return interval
+}
+
+// verificationMessage 生成邮箱验证邮件
+func (s *emailVerificationService) verificationMessage(user *model.User, plain string) *mailer.Message {
+	link := strings.TrimRight(s.config.Email.LinkBaseURL, "/") + "/verify-email?token=" + url.QueryEscape(plain)
+	hours := int(s.expiration().Hours())
+
+	return &mailer.Message{
+		To:      []string{user.Email},
+		Subject: "验证您的邮箱",
+		Text: fmt.Sprintf("%s，您好：\n\n感谢注册，请在 %d 小时内打开以下链接验证邮箱并激活账户：\n\n%s\n\n如果您没有注册过账户，请忽略本邮件。\n",
+			user.Username, hours, link),
+		HTML: fmt.Sprintf(`<p>%s，您好：</p><p>感谢注册，请在 %d 小时内点击以下链接验证邮箱并激活账户：</p><p><a href="%s">验证邮箱</a></p><p>如果您没有注册过账户，请忽略本邮件。</p>`,
+			html.EscapeString(user.Username), hours, html.EscapeString(link)),
+	}
+}
+
+// verificationResendKey 重发验证邮件限流的缓存键
+func verificationResendKey(email string) string {
+	return "email_verification_resend:" + strings.ToLower(strings.TrimSpace(email))
+}
@@ -1,23 +1,49 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"image"
+	"io"
+	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
+	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/repository"
 	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/imaging"
 	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/signer"
+	"vibe-coding-starter/pkg/storage"
+)
+
+var (
+	// ErrNotImage 文件不是支持生成变体的图片
+	ErrNotImage = errors.New("file is not a supported image")
+	// ErrVariantNotFound 未配置的图片变体
+	ErrVariantNotFound = errors.New("image variant not found")
 )
 
+// variantKeyPrefix 图片变体对象键前缀
+const variantKeyPrefix = "variants/"
+
 // fileService 文件服务实现
 type fileService struct {
 	fileRepo repository.FileRepository
+	blobRepo repository.FileBlobRepository
+	quota    StorageQuotaService
+	storage  *storage.Manager
+	signer   *signer.Signer
+	config   *config.Config
 	cache    cache.Cache
 	logger   logger.Logger
 }
@@ -25,11 +51,21 @@ type fileService struct {
 // NewFileService 创建文件服务
 func NewFileService(
 	fileRepo repository.FileRepository,
+	blobRepo repository.FileBlobRepository,
+	quota StorageQuotaService,
+	storage *storage.Manager,
+	signer *signer.Signer,
+	config *config.Config,
 	cache cache.Cache,
 	logger logger.Logger,
 ) FileService {
 	return &fileService{
 		fileRepo: fileRepo,
+		blobRepo: blobRepo,
+		quota:    quota,
+		storage:  storage,
+		signer:   signer,
+		config:   config,
 		cache:    cache,
 		logger:   logger,
 	}
@@ -37,52 +73,144 @@ func NewFileService(
 
 // Upload 上传文件
 func (s *fileService) Upload(ctx context.Context, req *UploadRequest) (*model.File, error) {
-	// 计算文件哈希
-	hash := s.calculateHash(req.FileData)
-
-	// 检查文件是否已存在
-	existingFile, err := s.fileRepo.GetByHash(ctx, hash)
-	if err == nil && existingFile != nil {
-		s.logger.Info("File already exists, returning existing file", "hash", hash, "file_id", existingFile.ID)
-		return existingFile, nil
-	}
-
 	// 生成唯一文件名
 	fileName := s.generateFileName(req.FileName)
 
-	// 设置存储类型
+	// 选择存储驱动
 	storageType := req.StorageType
 	if storageType == "" {
-		storageType = model.StorageTypeLocal
+		storageType = s.storage.DefaultName()
+	}
+
+	driver, err := s.storage.Get(storageType)
+	if err != nil {
+		s.logger.Error("Failed to get storage driver", "storage_type", storageType, "error", err)
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	// 读取文件头识别实际类型，不信任客户端声明的类型
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(req.Reader, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		s.logger.Error("Failed to read uploaded file", "file_name", req.FileName, "error", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	header = header[:n]
 
-	// 保存文件到存储
-	filePath, fileURL, err := s.saveFile(req.FileData, fileName, storageType)
+	policy := newUploadPolicy(s.config.Upload)
+	mimeType, err := policy.detect(header, req.FileName)
 	if err != nil {
+		return nil, err
+	}
+	if err := policy.checkType(mimeType); err != nil {
+		return nil, err
+	}
+	if req.FileSize > 0 {
+		if err := policy.checkSize(mimeType, req.OwnerRole, req.FileSize); err != nil {
+			return nil, err
+		}
+	}
+
+	// 已知大小时先占用配额，并发上传时不会超出配额，失败时释放
+	var reserved int64
+	if req.FileSize > 0 {
+		if err := s.quota.Reserve(ctx, req.OwnerID, req.FileSize); err != nil {
+			return nil, err
+		}
+		reserved = req.FileSize
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			s.releaseQuota(ctx, req.OwnerID, reserved)
+		}
+	}()
+
+	// 流式写入存储，同时计算哈希和实际大小，声明大小不可信，写入时再次检查
+	limit, rule := policy.sizeLimit(mimeType, req.OwnerRole)
+	limited := &limitedReader{
+		reader: io.MultiReader(bytes.NewReader(header), req.Reader),
+		limit:  limit,
+		err:    sizeLimitError(rule, baseMimeType(mimeType), limit),
+	}
+	hasher := sha256.New()
+	counter := &countingReader{reader: io.TeeReader(limited, hasher)}
+
+	filePath := fileName
+	if err := driver.Put(ctx, filePath, counter, req.FileSize, mimeType); err != nil {
+		if limited.exceeded {
+			if delErr := driver.Delete(ctx, filePath); delErr != nil {
+				s.logger.Warn("Failed to cleanup stored file", "path", filePath, "error", delErr)
+			}
+			return nil, limited.err
+		}
 		s.logger.Error("Failed to save file", "file_name", req.FileName, "error", err)
 		return nil, fmt.Errorf("failed to save file: %w", err)
 	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	// 按实际大小修正已占用的配额
+	if counter.count > reserved {
+		if err := s.quota.Reserve(ctx, req.OwnerID, counter.count-reserved); err != nil {
+			if delErr := driver.Delete(ctx, filePath); delErr != nil {
+				s.logger.Warn("Failed to cleanup stored file", "path", filePath, "error", delErr)
+			}
+			return nil, err
+		}
+	} else {
+		s.releaseQuota(ctx, req.OwnerID, reserved-counter.count)
+	}
+	reserved = counter.count
+
+	// 相同内容共享同一物理对象，每个用户仍拥有独立的文件记录
+	blob, err := s.blobRepo.Acquire(ctx, &model.FileBlob{
+		StorageType: storageType,
+		Hash:        hash,
+		Path:        filePath,
+		Size:        counter.count,
+	})
+	if err != nil {
+		if delErr := driver.Delete(ctx, filePath); delErr != nil {
+			s.logger.Warn("Failed to cleanup stored file", "path", filePath, "error", delErr)
+		}
+		s.logger.Error("Failed to acquire file blob", "file_name", req.FileName, "error", err)
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+	if blob.Path != filePath {
+		if delErr := driver.Delete(ctx, filePath); delErr != nil {
+			s.logger.Warn("Failed to cleanup duplicate file", "path", filePath, "error", delErr)
+		}
+		s.logger.Info("File content already stored, sharing existing blob", "hash", hash, "blob_id", blob.ID)
+	}
 
 	// 创建文件记录
 	file := &model.File{
 		Name:         fileName,
 		OriginalName: req.FileName,
-		Path:         filePath,
-		URL:          fileURL,
-		Size:         req.FileSize,
-		MimeType:     req.MimeType,
+		Path:         blob.Path,
+		URL:          driver.URL(blob.Path),
+		Size:         counter.count,
+		MimeType:     mimeType,
 		Hash:         hash,
+		BlobID:       blob.ID,
 		StorageType:  storageType,
+		OwnerID:      req.OwnerID,
 		IsPublic:     req.IsPublic,
 	}
 
 	if err := s.fileRepo.Create(ctx, file); err != nil {
-		// 如果数据库保存失败，删除已保存的文件
-		s.deletePhysicalFile(filePath)
+		// 如果数据库保存失败，释放对物理对象的引用
+		s.releaseBlob(ctx, file)
 		s.logger.Error("Failed to create file record", "file_name", req.FileName, "error", err)
 		return nil, fmt.Errorf("failed to create file record: %w", err)
 	}
 
+	// 新内容按配置立即生成图片变体，失败时在首次访问时重新生成
+	if s.config.Upload.Image.GenerateOnUpload && blob.Path == filePath && variantFormat(file.MimeType) != "" {
+		s.generateVariants(ctx, driver, file)
+	}
+
+	committed = true
 	s.logger.Info("File uploaded successfully", "file_id", file.ID, "file_name", file.Name)
 	return file, nil
 }
@@ -113,11 +241,9 @@ func (s *fileService) Delete(ctx context.Context, id uint) error {
 		return fmt.Errorf("failed to delete file record: %w", err)
 	}
 
-	// 删除物理文件
-	if err := s.deletePhysicalFile(file.Path); err != nil {
-		s.logger.Warn("Failed to delete physical file", "path", file.Path, "error", err)
-		// 不返回错误，因为数据库记录已删除
-	}
+	// 释放物理对象引用，最后一个引用删除时才删除物理文件
+	s.releaseBlob(ctx, file)
+	s.releaseQuota(ctx, file.OwnerID, file.Size)
 
 	s.logger.Info("File deleted successfully", "file_id", id)
 	return nil
@@ -154,104 +280,311 @@ func (s *fileService) Download(ctx context.Context, id uint) (*DownloadResponse,
 		return nil, fmt.Errorf("failed to get file: %w", err)
 	}
 
-	// 读取文件数据
-	fileData, err := s.readFile(file.Path)
+	// 打开文件数据流
+	content, err := s.openFile(ctx, file)
 	if err != nil {
 		s.logger.Error("Failed to read file data", "path", file.Path, "error", err)
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// 增加下载次数
-	file.IncrementDownloadCount()
-	if err := s.fileRepo.Update(ctx, file); err != nil {
-		s.logger.Warn("Failed to update download count", "file_id", id, "error", err)
-		// 不返回错误，因为文件下载成功
+	return &DownloadResponse{
+		File:    file,
+		Content: content,
+		URL:     file.URL,
+	}, nil
+}
+
+// RecordDownload 记录一次完整下载，由处理器在检查访问权限并返回完整内容后调用
+func (s *fileService) RecordDownload(ctx context.Context, id uint) error {
+	if err := s.fileRepo.IncrementDownloadCount(ctx, id); err != nil {
+		s.logger.Error("Failed to increment download count", "file_id", id, "error", err)
+		return fmt.Errorf("failed to increment download count: %w", err)
 	}
 
-	return &DownloadResponse{
-		File:     file,
-		FileData: fileData,
-		URL:      file.URL,
+	return nil
+}
+
+// CreateSignedURL 生成限时签名下载链接
+func (s *fileService) CreateSignedURL(ctx context.Context, id uint, ttl time.Duration) (*SignedURLResponse, error) {
+	if _, err := s.fileRepo.GetByID(ctx, id); err != nil {
+		s.logger.Error("Failed to get file for signed url", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	signing := s.config.Storage.Signing
+	if ttl <= 0 {
+		ttl = time.Duration(signing.DefaultTTL) * time.Second
+	}
+	if maxTTL := time.Duration(signing.MaxTTL) * time.Second; maxTTL > 0 && ttl > maxTTL {
+		return nil, fmt.Errorf("ttl exceeds maximum of %s", maxTTL)
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	query := s.signer.Sign(signedFileResource(id), expiresAt)
+	signedURL := fmt.Sprintf("%s/api/v1/files/%d/signed?%s", strings.TrimRight(signing.BaseURL, "/"), id, query.Encode())
+
+	s.logger.Info("Signed file url created", "file_id", id, "key_id", s.signer.ActiveKey(), "expires_at", expiresAt)
+	return &SignedURLResponse{
+		URL:       signedURL,
+		ExpiresAt: expiresAt,
 	}, nil
 }
 
-// calculateHash 计算文件哈希
-func (s *fileService) calculateHash(data []byte) string {
-	hash := sha256.Sum256(data)
-	return hex.EncodeToString(hash[:])
+// VerifySignedURL 验证签名下载链接
+func (s *fileService) VerifySignedURL(ctx context.Context, id uint, query url.Values) error {
+	return s.signer.Verify(signedFileResource(id), query, time.Now())
 }
 
-// generateFileName 生成唯一文件名
+// GetVariant 获取图片变体，变体不存在时生成并保存到存储
+func (s *fileService) GetVariant(ctx context.Context, id uint, name string) (*VariantResponse, error) {
+	variant, ok := s.findVariant(name)
+	if !ok {
+		return nil, ErrVariantNotFound
+	}
+
+	file, err := s.fileRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get file for variant", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	format := variantFormat(file.MimeType)
+	if format == "" {
+		return nil, ErrNotImage
+	}
+
+	driver, err := s.driverFor(file)
+	if err != nil {
+		s.logger.Error("Failed to get storage driver", "storage_type", file.StorageType, "error", err)
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	key := variantKey(file.Hash, variant, imaging.OutputExtension(format))
+	response := &VariantResponse{
+		File: file,
+		Name: variant.Name,
+	}
+
+	// 已生成的变体直接从存储读取
+	info, err := driver.Stat(ctx, key)
+	if err == nil {
+		content, err := driver.Get(ctx, key)
+		if err == nil {
+			response.MimeType = info.ContentType
+			response.Content = content
+			response.ModTime = info.LastModified
+			return response, nil
+		}
+	}
+	if err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+		s.logger.Error("Failed to read image variant", "key", key, "error", err)
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	img, err := s.decodeImage(ctx, driver, file)
+	if err != nil {
+		return nil, err
+	}
+
+	data, mimeType, err := s.encodeVariant(img, variant, format)
+	if err != nil {
+		s.logger.Error("Failed to encode image variant", "file_id", id, "variant", name, "error", err)
+		return nil, fmt.Errorf("failed to generate variant: %w", err)
+	}
+
+	if err := driver.Put(ctx, key, bytes.NewReader(data), int64(len(data)), mimeType); err != nil {
+		// 保存失败不影响本次返回，下次访问时重新生成
+		s.logger.Warn("Failed to store image variant", "key", key, "error", err)
+	}
+
+	s.logger.Info("Image variant generated", "file_id", id, "variant", name)
+	response.MimeType = mimeType
+	response.Content = nopReadSeekCloser{bytes.NewReader(data)}
+	response.ModTime = time.Now()
+	return response, nil
+}
+
+// signedFileResource 获取文件签名的资源标识
+func signedFileResource(id uint) string {
+	return fmt.Sprintf("file:%d", id)
+}
+
+// generateFileName 生成唯一文件名，随机部分不可预测，避免对象键被猜测或碰撞
 func (s *fileService) generateFileName(originalName string) string {
 	ext := filepath.Ext(originalName)
 	timestamp := time.Now().Unix()
-	return fmt.Sprintf("%d_%s%s", timestamp, generateRandomString(8), ext)
+	return fmt.Sprintf("%d_%s%s", timestamp, uuid.NewString(), ext)
 }
 
-// saveFile 保存文件到存储
-func (s *fileService) saveFile(data []byte, fileName, storageType string) (string, string, error) {
-	switch storageType {
-	case model.StorageTypeLocal:
-		return s.saveToLocal(data, fileName)
-	case model.StorageTypeS3:
-		return s.saveToS3(data, fileName)
-	case model.StorageTypeOSS:
-		return s.saveToOSS(data, fileName)
-	default:
-		return "", "", fmt.Errorf("unsupported storage type: %s", storageType)
+// driverFor 获取文件所在的存储驱动
+func (s *fileService) driverFor(file *model.File) (storage.Storage, error) {
+	storageType := file.StorageType
+	if storageType == "" {
+		storageType = model.StorageTypeLocal
 	}
+	return s.storage.Get(storageType)
 }
 
-// saveToLocal 保存到本地存储
-func (s *fileService) saveToLocal(data []byte, fileName string) (string, string, error) {
-	// 创建上传目录
-	uploadDir := "uploads"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return "", "", fmt.Errorf("failed to create upload directory: %w", err)
+// openFile 打开文件数据流
+func (s *fileService) openFile(ctx context.Context, file *model.File) (io.ReadSeekCloser, error) {
+	driver, err := s.driverFor(file)
+	if err != nil {
+		return nil, err
 	}
+	return driver.Get(ctx, file.Path)
+}
 
-	// 文件路径
-	filePath := filepath.Join(uploadDir, fileName)
+// releaseBlob 释放文件对物理对象的引用，失败时仅记录日志
+func (s *fileService) releaseBlob(ctx context.Context, file *model.File) {
+	path := file.Path
+	if file.BlobID != 0 {
+		blob, removed, err := s.blobRepo.Release(ctx, file.BlobID)
+		if err != nil {
+			s.logger.Warn("Failed to release file blob", "blob_id", file.BlobID, "error", err)
+			return
+		}
+		if !removed {
+			return
+		}
+		path = blob.Path
+	}
 
-	// 写入文件
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return "", "", fmt.Errorf("failed to write file: %w", err)
+	driver, err := s.driverFor(file)
+	if err == nil {
+		err = driver.Delete(ctx, path)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to delete physical file", "path", path, "error", err)
+		return
 	}
 
-	// 生成 URL
-	fileURL := fmt.Sprintf("/uploads/%s", fileName)
+	// 同时删除该内容生成的图片变体
+	if variantFormat(file.MimeType) == "" {
+		return
+	}
+	for _, variant := range s.config.Upload.Image.Variants {
+		for _, ext := range []string{"jpg", "png"} {
+			key := variantKey(file.Hash, variant, ext)
+			if err := driver.Delete(ctx, key); err != nil {
+				s.logger.Warn("Failed to delete image variant", "key", key, "error", err)
+			}
+		}
+	}
+}
 
-	return filePath, fileURL, nil
+// releaseQuota 释放用户已占用的配额，失败时仅记录日志
+func (s *fileService) releaseQuota(ctx context.Context, ownerID uint, size int64) {
+	if size <= 0 {
+		return
+	}
+	if err := s.quota.Release(ctx, ownerID, size); err != nil {
+		s.logger.Warn("Failed to release storage quota", "owner_id", ownerID, "size", size, "error", err)
+	}
 }
 
-// saveToS3 保存到 S3 存储 (占位符实现)
-func (s *fileService) saveToS3(data []byte, fileName string) (string, string, error) {
-	// TODO: 实现 S3 上传逻辑
-	return "", "", fmt.Errorf("S3 storage not implemented yet")
+// findVariant 根据名称查找变体配置
+func (s *fileService) findVariant(name string) (config.ImageVariantConfig, bool) {
+	for _, variant := range s.config.Upload.Image.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+	return config.ImageVariantConfig{}, false
 }
 
-// saveToOSS 保存到阿里云 OSS 存储 (占位符实现)
-func (s *fileService) saveToOSS(data []byte, fileName string) (string, string, error) {
-	// TODO: 实现 OSS 上传逻辑
-	return "", "", fmt.Errorf("OSS storage not implemented yet")
+// decodeImage 读取并解码文件中的图片
+func (s *fileService) decodeImage(ctx context.Context, driver storage.Storage, file *model.File) (image.Image, error) {
+	content, err := driver.Get(ctx, file.Path)
+	if err != nil {
+		s.logger.Error("Failed to read image file", "path", file.Path, "error", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		s.logger.Error("Failed to read image file", "path", file.Path, "error", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	img, _, err := imaging.Decode(data, s.config.Upload.Image.MaxPixels)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) {
+			return nil, ErrNotImage
+		}
+		s.logger.Warn("Failed to decode image", "file_id", file.ID, "error", err)
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
 }
 
-// readFile 读取文件数据
-func (s *fileService) readFile(filePath string) ([]byte, error) {
-	return os.ReadFile(filePath)
+// encodeVariant 缩放并编码图片变体
+func (s *fileService) encodeVariant(img image.Image, variant config.ImageVariantConfig, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mimeType, _, err := imaging.Encode(&buf, imaging.Fit(img, variant.Width, variant.Height), format, s.config.Upload.Image.Quality)
+	if err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mimeType, nil
 }
 
-// deletePhysicalFile 删除物理文件
-func (s *fileService) deletePhysicalFile(filePath string) error {
-	return os.Remove(filePath)
+// generateVariants 生成所有配置的图片变体，失败时仅记录日志
+func (s *fileService) generateVariants(ctx context.Context, driver storage.Storage, file *model.File) {
+	img, err := s.decodeImage(ctx, driver, file)
+	if err != nil {
+		s.logger.Warn("Failed to generate image variants", "file_id", file.ID, "error", err)
+		return
+	}
+
+	format := variantFormat(file.MimeType)
+	for _, variant := range s.config.Upload.Image.Variants {
+		data, mimeType, err := s.encodeVariant(img, variant, format)
+		if err == nil {
+			key := variantKey(file.Hash, variant, imaging.OutputExtension(format))
+			err = driver.Put(ctx, key, bytes.NewReader(data), int64(len(data)), mimeType)
+		}
+		if err != nil {
+			s.logger.Warn("Failed to generate image variant", "file_id", file.ID, "variant", variant.Name, "error", err)
+		}
+	}
 }
 
-// generateRandomString 生成随机字符串
-func generateRandomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+// variantFormat 根据 MIME 类型获取变体输出格式，不支持时返回空字符串
+func variantFormat(mimeType string) string {
+	switch strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0])) {
+	case "image/jpeg", "image/jpg":
+		return "jpeg"
+	case "image/png", "image/gif":
+		return "png"
+	default:
+		return ""
 	}
-	return string(b)
+}
+
+// variantKey 获取图片变体的存储键，尺寸变化后自动生成新的变体
+func variantKey(hash string, variant config.ImageVariantConfig, ext string) string {
+	return fmt.Sprintf("%s%s/%s_%dx%d.%s", variantKeyPrefix, hash, variant.Name, variant.Width, variant.Height, ext)
+}
+
+// nopReadSeekCloser 为内存数据提供空的 Close 方法
+type nopReadSeekCloser struct {
+	io.ReadSeeker
+}
+
+// Close 关闭读取器
+func (nopReadSeekCloser) Close() error {
+	return nil
+}
+
+// countingReader 统计读取字节数的读取器
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+// Read 读取数据并累计字节数
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
 }
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"vibe-coding-starter/internal/config"
+)
+
+const (
+	// sniffLen 识别内容类型需要读取的字节数
+	sniffLen = 512
+	// defaultDetectedMimeType 无法识别内容时的类型
+	defaultDetectedMimeType = "application/octet-stream"
+)
+
+// 上传策略规则
+const (
+	PolicyRuleTypeNotAllowed    = "type_not_allowed"
+	PolicyRuleExtensionMismatch = "extension_mismatch"
+	PolicyRuleMaxSize           = "max_size"
+	PolicyRuleTypeMaxSize       = "type_max_size"
+	PolicyRuleRoleMaxSize       = "role_max_size"
+)
+
+// PolicyError 上传策略校验错误，Rule 标识违反的规则
+type PolicyError struct {
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	MimeType string `json:"mime_type,omitempty"`
+	Limit    int64  `json:"limit,omitempty"`
+}
+
+// Error 实现 error 接口
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+// IsSizeRule 是否为大小限制规则
+func (e *PolicyError) IsSizeRule() bool {
+	return e.Rule == PolicyRuleMaxSize || e.Rule == PolicyRuleTypeMaxSize || e.Rule == PolicyRuleRoleMaxSize
+}
+
+// uploadPolicy 上传策略
+type uploadPolicy struct {
+	config config.UploadConfig
+}
+
+// newUploadPolicy 创建上传策略
+func newUploadPolicy(cfg config.UploadConfig) uploadPolicy {
+	return uploadPolicy{config: cfg}
+}
+
+// detect 根据文件内容识别类型，并校验与扩展名是否一致
+func (p uploadPolicy) detect(header []byte, fileName string) (string, error) {
+	detected := http.DetectContentType(header)
+	sniffed := baseMimeType(detected)
+
+	extType := ""
+	if ext := filepath.Ext(fileName); ext != "" {
+		extType = baseMimeType(mime.TypeByExtension(strings.ToLower(ext)))
+	}
+
+	if !compatibleMimeTypes(extType, sniffed) {
+		return "", &PolicyError{
+			Rule:     PolicyRuleExtensionMismatch,
+			Message:  fmt.Sprintf("file content (%s) does not match extension %q", sniffed, filepath.Ext(fileName)),
+			MimeType: sniffed,
+		}
+	}
+
+	// 内容识别结果较笼统时使用扩展名对应的具体类型
+	if extType != "" && extType != sniffed && isGenericMimeType(sniffed) {
+		return extType, nil
+	}
+	return detected, nil
+}
+
+// checkType 校验类型是否允许上传
+func (p uploadPolicy) checkType(mimeType string) error {
+	if len(p.config.AllowedTypes) == 0 {
+		return nil
+	}
+
+	base := baseMimeType(mimeType)
+	for _, pattern := range p.config.AllowedTypes {
+		if matchMimeType(pattern, base) {
+			return nil
+		}
+	}
+	return &PolicyError{
+		Rule:     PolicyRuleTypeNotAllowed,
+		Message:  fmt.Sprintf("file type %s is not allowed", base),
+		MimeType: base,
+	}
+}
+
+// sizeLimit 获取指定类型和角色的大小限制及其对应规则，0 表示不限制
+func (p uploadPolicy) sizeLimit(mimeType, role string) (int64, string) {
+	limit, rule := p.config.MaxSize, PolicyRuleMaxSize
+
+	base := baseMimeType(mimeType)
+	for _, typeLimit := range p.config.TypeLimits {
+		if matchMimeType(typeLimit.Type, base) {
+			limit, rule = typeLimit.MaxSize, PolicyRuleTypeMaxSize
+			break
+		}
+	}
+
+	if roleLimit := p.config.RoleLimits[role]; roleLimit > 0 && (limit <= 0 || roleLimit < limit) {
+		limit, rule = roleLimit, PolicyRuleRoleMaxSize
+	}
+	return limit, rule
+}
+
+// checkSize 校验文件大小
+func (p uploadPolicy) checkSize(mimeType, role string, size int64) error {
+	limit, rule := p.sizeLimit(mimeType, role)
+	if limit > 0 && size > limit {
+		return sizeLimitError(rule, baseMimeType(mimeType), limit)
+	}
+	return nil
+}
+
+// checkDeclared 按客户端声明的文件名、类型和大小预先校验，内容在上传完成后再次校验
+func (p uploadPolicy) checkDeclared(fileName, mimeType, role string, size int64) error {
+	declared := ""
+	if ext := filepath.Ext(fileName); ext != "" {
+		declared = mime.TypeByExtension(strings.ToLower(ext))
+	}
+	if declared == "" {
+		declared = mimeType
+	}
+
+	if declared != "" {
+		if err := p.checkType(declared); err != nil {
+			return err
+		}
+	}
+	return p.checkSize(declared, role, size)
+}
+
+// sizeLimitError 创建大小超限错误
+func sizeLimitError(rule, mimeType string, limit int64) *PolicyError {
+	return &PolicyError{
+		Rule:     rule,
+		Message:  fmt.Sprintf("file size exceeds the limit of %d bytes", limit),
+		MimeType: mimeType,
+		Limit:    limit,
+	}
+}
+
+// baseMimeType 去除 MIME 类型参数并转为小写
+func baseMimeType(mimeType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+}
+
+// matchMimeType 判断类型是否匹配，支持 image/* 通配
+func matchMimeType(pattern, mimeType string) bool {
+	pattern = baseMimeType(pattern)
+	if pattern == "*" || pattern == "*/*" || pattern == mimeType {
+		return true
+	}
+	return strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*"))
+}
+
+// isGenericMimeType 是否为内容识别无法进一步细分的笼统类型
+func isGenericMimeType(mimeType string) bool {
+	switch mimeType {
+	case defaultDetectedMimeType, "text/plain", "text/xml", "application/zip":
+		return true
+	default:
+		return false
+	}
+}
+
+// isTextMimeType 是否为文本类格式
+func isTextMimeType(mimeType string) bool {
+	switch {
+	case strings.HasPrefix(mimeType, "text/"),
+		strings.HasSuffix(mimeType, "+xml"),
+		strings.HasSuffix(mimeType, "+json"),
+		mimeType == "application/json",
+		mimeType == "application/javascript",
+		mimeType == "application/xml":
+		return true
+	default:
+		return false
+	}
+}
+
+// compatibleMimeTypes 判断扩展名类型与内容识别类型是否一致
+func compatibleMimeTypes(extType, sniffed string) bool {
+	switch {
+	case extType == "" || extType == sniffed:
+		return true
+	case sniffed == "text/plain":
+		// HTML 等可执行内容能被识别，伪装成文本的情况不会走到这里
+		return isTextMimeType(extType)
+	case sniffed == "text/xml":
+		return extType == "application/xml" || strings.HasSuffix(extType, "+xml")
+	case sniffed == "application/zip":
+		// Office 文档等基于 ZIP 的格式
+		return strings.HasPrefix(extType, "application/vnd.") || strings.HasSuffix(extType, "+zip") || extType == "application/java-archive"
+	case sniffed == defaultDetectedMimeType:
+		// 图片、PDF 和文本都能被识别，识别失败说明内容与扩展名不符
+		return !strings.HasPrefix(extType, "image/") && !isTextMimeType(extType) && extType != "application/pdf"
+	default:
+		return false
+	}
+}
+
+// limitedReader 超过大小限制时返回策略错误的读取器
+type limitedReader struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	err      *PolicyError
+	exceeded bool
+}
+
+// Read 读取数据并检查大小限制
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.limit > 0 && r.read > r.limit {
+		r.exceeded = true
+		return n, r.err
+	}
+	return n, err
+}
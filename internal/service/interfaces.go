@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"io"
+	"net/url"
+	"time"
 
 	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/repository"
@@ -16,6 +19,133 @@ type UserService interface {
 	ChangePassword(ctx context.Context, userID uint, req *ChangePasswordRequest) error
 	GetUsers(ctx context.Context, opts repository.ListOptions) ([]*model.User, int64, error)
 	DeleteUser(ctx context.Context, userID uint) error
+	RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*LoginResponse, error)
+	LoginMFA(ctx context.Context, req *MFALoginRequest) (*LoginResponse, error)
+	GetMFAStatus(ctx context.Context, userID uint) (*MFAStatus, error)
+	EnrollMFA(ctx context.Context, userID uint) (*MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, userID uint, sessionID string, req *MFACodeRequest) (*MFARecoveryCodes, error)
+	DisableMFA(ctx context.Context, userID uint, req *DisableMFARequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, req *MFACodeRequest) (*MFARecoveryCodes, error)
+	ListOAuthProviders(ctx context.Context) []*OAuthProvider
+	StartOAuthLogin(ctx context.Context, provider string) (*OAuthAuthorization, error)
+	CompleteOAuthLogin(ctx context.Context, req *OAuthCallbackRequest) (*LoginResponse, error)
+	ListIdentities(ctx context.Context, userID uint) ([]*model.UserIdentity, error)
+	UnlockUser(ctx context.Context, userID uint) error
+}
+
+// AdminUserService 管理员用户管理服务接口，actorID 为执行操作的管理员
+type AdminUserService interface {
+	CreateUser(ctx context.Context, actorID uint, req *AdminCreateUserRequest) (*model.User, error)
+	UpdateStatus(ctx context.Context, actorID, userID uint, status string) (*model.User, error)
+	UpdateRole(ctx context.Context, actorID, userID uint, role string) (*model.User, error)
+	ForcePasswordReset(ctx context.Context, actorID, userID uint) error
+	Impersonate(ctx context.Context, actorID, userID uint) (*ImpersonationResponse, error)
+}
+
+// TokenService 令牌与登录会话服务接口
+type TokenService interface {
+	Issue(ctx context.Context, user *model.User, client ClientInfo, mfa bool) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	Logout(ctx context.Context, userID uint, sessionID, tokenID string) error
+	ListSessions(ctx context.Context, userID uint) ([]*model.UserSession, error)
+	RevokeSession(ctx context.Context, userID, sessionID uint) error
+	RevokeAllSessions(ctx context.Context, userID uint) (int, error)
+	RevokeOtherSessions(ctx context.Context, userID uint, sessionID string) (int, error)
+	CleanupExpired(ctx context.Context) (int64, error)
+}
+
+// APIKeyService 个人 API Key 服务接口
+type APIKeyService interface {
+	Create(ctx context.Context, userID uint, req *CreateAPIKeyRequest) (*CreatedAPIKey, error)
+	List(ctx context.Context, userID uint) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, userID, keyID uint) error
+	Authenticate(ctx context.Context, key, ip string) (*model.APIKey, *model.User, error)
+}
+
+// RoleService 角色与权限服务接口
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]*model.Role, error)
+	GetRole(ctx context.Context, id uint) (*model.Role, error)
+	CreateRole(ctx context.Context, actorID uint, req *CreateRoleRequest) (*model.Role, error)
+	UpdateRole(ctx context.Context, actorID, id uint, req *UpdateRoleRequest) (*model.Role, error)
+	DeleteRole(ctx context.Context, id uint) error
+	ListPermissions(ctx context.Context) ([]*model.Permission, error)
+	GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error)
+	SetUserRoles(ctx context.Context, actorID, userID uint, req *SetUserRolesRequest) ([]*model.Role, error)
+	GetUserPermissions(ctx context.Context, userID uint, legacyRole string) ([]string, error)
+	// CheckGrant 检查操作者拥有指定的全部权限
+	CheckGrant(ctx context.Context, actorID uint, permissions []string) error
+	// CheckAuthority 检查操作者拥有目标用户的全部有效权限
+	CheckAuthority(ctx context.Context, actorID, userID uint) error
+}
+
+// PermissionCache 用户权限缓存，角色或权限变更后需要清除
+type PermissionCache interface {
+	ClearUserPermissions(userID uint) error
+	ClearAllPermissions() error
+}
+
+// AuditService 审计日志服务接口
+type AuditService interface {
+	// Record 异步记录审计事件，不阻塞调用方
+	Record(ctx context.Context, entry *AuditEntry)
+	List(ctx context.Context, opts repository.ListOptions) ([]*model.AuditLog, int64, error)
+	// Start 启动后台写入
+	Start()
+	// Stop 停止后台写入，返回前写入队列中剩余的审计日志
+	Stop(ctx context.Context) error
+}
+
+// AuditEntry 审计事件，Before 和 After 为修改前后的资源，用于计算字段差异
+type AuditEntry struct {
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Before       interface{} // 创建时为空
+	After        interface{} // 删除时为空
+}
+
+// EmailVerificationService 邮箱验证服务接口
+type EmailVerificationService interface {
+	Send(ctx context.Context, user *model.User) error
+	Verify(ctx context.Context, req *VerifyEmailRequest) (*model.User, error)
+	Resend(ctx context.Context, req *ResendVerificationRequest) error
+}
+
+// PasswordResetService 密码重置服务接口
+type PasswordResetService interface {
+	Forgot(ctx context.Context, req *ForgotPasswordRequest) error
+	Reset(ctx context.Context, req *ResetPasswordRequest) error
+	CleanupExpired(ctx context.Context) (int64, error)
+}
+
+// InvitationService 注册邀请服务接口
+type InvitationService interface {
+	// Create 创建邀请并发送邀请邮件
+	Create(ctx context.Context, actorID uint, req *CreateInvitationRequest) (*CreatedInvitation, error)
+	// Lookup 根据邀请链接中的令牌获取仍可使用的邀请
+	Lookup(ctx context.Context, token string) (*model.Invitation, error)
+	// Accept 使用邀请创建用户，邀请只能使用一次
+	Accept(ctx context.Context, invitation *model.Invitation, user *model.User) error
+	List(ctx context.Context, opts repository.ListOptions) ([]*model.Invitation, int64, error)
+	Revoke(ctx context.Context, actorID, id uint) error
+}
+
+// PrivacyService 个人数据导出与账户注销服务接口
+type PrivacyService interface {
+	// RequestExport 在后台生成个人数据导出文件，完成后通过邮件发送下载链接
+	RequestExport(ctx context.Context, userID uint) error
+	// BuildExport 生成个人数据导出文件并发送下载链接
+	BuildExport(ctx context.Context, userID uint) error
+	// OpenExport 根据下载链接中的令牌打开导出文件，调用方负责关闭
+	OpenExport(ctx context.Context, token string) (*ExportDownload, error)
+	// ScheduleDeletion 确认密码后申请注销账户，冷静期结束后匿名化，返回计划匿名化的时间
+	ScheduleDeletion(ctx context.Context, userID uint, req *DeleteAccountRequest) (time.Time, error)
+	CancelDeletion(ctx context.Context, userID uint) error
+	// PurgeDue 匿名化冷静期已结束的账户，返回处理的账户数量
+	PurgeDue(ctx context.Context) (int, error)
+	// CleanupExports 删除已过期的导出文件，返回删除数量
+	CleanupExports(ctx context.Context) (int, error)
 }
 
 // ArticleService 文章服务接口
@@ -39,6 +169,34 @@ type FileService interface {
 	List(ctx context.Context, opts repository.ListOptions) ([]*model.File, int64, error)
 	GetByOwner(ctx context.Context, ownerID uint, opts repository.ListOptions) ([]*model.File, int64, error)
 	Download(ctx context.Context, id uint) (*DownloadResponse, error)
+	RecordDownload(ctx context.Context, id uint) error
+	CreateSignedURL(ctx context.Context, id uint, ttl time.Duration) (*SignedURLResponse, error)
+	VerifySignedURL(ctx context.Context, id uint, query url.Values) error
+	GetVariant(ctx context.Context, id uint, name string) (*VariantResponse, error)
+}
+
+// StorageQuotaService 存储配额服务接口
+type StorageQuotaService interface {
+	GetUsage(ctx context.Context, userID uint) (*StorageUsageResponse, error)
+	Check(ctx context.Context, userID uint, size int64) error
+	Reserve(ctx context.Context, userID uint, size int64) error
+	Release(ctx context.Context, userID uint, size int64) error
+	SetQuota(ctx context.Context, userID uint, quotaBytes *int64) (*StorageUsageResponse, error)
+}
+
+// StorageGCService 存储一致性检查服务接口
+type StorageGCService interface {
+	Run(ctx context.Context, opts StorageGCOptions) ([]*StorageGCReport, error)
+}
+
+// ResumableUploadService 断点续传上传服务接口
+type ResumableUploadService interface {
+	Create(ctx context.Context, req *CreateUploadSessionRequest) (*model.UploadSession, error)
+	Get(ctx context.Context, ownerID uint, uploadID string) (*model.UploadSession, error)
+	WriteChunk(ctx context.Context, ownerID uint, uploadID string, offset int64, r io.Reader) (*model.UploadSession, error)
+	Complete(ctx context.Context, ownerID uint, uploadID string) (*model.File, error)
+	Abort(ctx context.Context, ownerID uint, uploadID string) error
+	CleanupExpired(ctx context.Context) (int, error)
 }
 
 // DictService 数据字典服务接口
@@ -61,18 +219,168 @@ type DictService interface {
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required"` // 长度和复杂度由密码策略检查
+	Nickname string `json:"nickname" validate:"max=50"`
+
+	InvitationToken string `json:"invitation_token"` // 邀请链接中的令牌，invite_only 模式下必填
+}
+
+type AdminCreateUserRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"` // 长度和复杂度由密码策略检查
 	Nickname string `json:"nickname" validate:"max=50"`
+	Role     string `json:"role"`   // user 或 admin，默认为 user
+	Status   string `json:"status"` // active、inactive 或 banned，默认为 active
+}
+
+type UpdateUserStatusRequest struct {
+	Status string `json:"status" validate:"required"` // active、inactive 或 banned
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required"` // user 或 admin
+}
+
+// ImpersonationResponse 模拟登录令牌，不能刷新
+type ImpersonationResponse struct {
+	User           *model.PublicUser `json:"user"`
+	Token          string            `json:"token"`
+	TokenType      string            `json:"token_type"`
+	ExpiresIn      int64             `json:"expires_in"`
+	ImpersonatorID uint              `json:"impersonator_id"`
 }
 
 type LoginRequest struct {
-	Username string `json:"username" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"required"`
+	Username string     `json:"username" validate:"required,min=3,max=50"`
+	Password string     `json:"password" validate:"required"`
+	Client   ClientInfo `json:"-"`
 }
 
 type LoginResponse struct {
-	User  *model.PublicUser `json:"user"`
-	Token string            `json:"token"`
+	User         *model.PublicUser `json:"user"`
+	Token        string            `json:"token"` // 访问令牌
+	TokenType    string            `json:"token_type"`
+	ExpiresIn    int64             `json:"expires_in"` // 访问令牌剩余有效期（秒）
+	RefreshToken string            `json:"refresh_token"`
+	MFARequired  bool              `json:"mfa_required,omitempty"` // 需要提交两步验证码，此时只返回 MFAToken
+	MFAToken     string            `json:"mfa_token,omitempty"`    // 登录第二步使用的短期令牌
+}
+
+type MFALoginRequest struct {
+	MFAToken string     `json:"mfa_token" validate:"required"`
+	Code     string     `json:"code" validate:"required"` // TOTP 验证码或恢复码
+	Client   ClientInfo `json:"-"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"` // TOTP 验证码或恢复码
+}
+
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type OAuthProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type OAuthAuthorization struct {
+	AuthorizationURL string        `json:"authorization_url"`
+	State            string        `json:"state"`
+	Binding          string        `json:"-"` // state 的哈希，写入发起授权的浏览器 Cookie，回调时需要一并提交
+	ExpiresIn        time.Duration `json:"-"` // state 有效期
+}
+
+type OAuthCallbackRequest struct {
+	Provider string     `json:"provider" validate:"required"`
+	Code     string     `json:"code" validate:"required"`  // 提供方回调返回的授权码
+	State    string     `json:"state" validate:"required"` // 发起授权时生成的 state
+	Binding  string     `json:"-"`                         // 发起授权的浏览器 Cookie 中保存的 state 哈希
+	Client   ClientInfo `json:"-"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string     `json:"refresh_token" validate:"required"`
+	Client       ClientInfo `json:"-"`
+}
+
+// ClientInfo 发起登录或刷新请求的客户端信息，用于展示登录会话
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type TokenPair struct {
+	User             *model.User
+	SessionID        string // 会话标识，即刷新令牌家族
+	AccessToken      string
+	AccessTokenID    string // 访问令牌 jti
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required"` // 权限列表，如 article:read、file:delete:own
+	ExpiresAt *time.Time `json:"expires_at"`                 // 为空表示永不过期
+}
+
+type CreatedAPIKey struct {
+	APIKey *model.APIKey `json:"api_key"`
+	Key    string        `json:"key"` // 明文只在创建时返回一次
+}
+
+type CreateInvitationRequest struct {
+	Email     string     `json:"email" validate:"required,email"`
+	Role      string     `json:"role"`       // user 或 admin，默认为 user
+	ExpiresAt *time.Time `json:"expires_at"` // 为空时使用 auth.invitation_expiration
+}
+
+type CreatedInvitation struct {
+	Invitation *model.Invitation `json:"invitation"`
+	Link       string            `json:"link"` // 邀请链接只在创建时返回，邮件未送达时管理员可以自行转发
+}
+
+// InvitationInfo 邀请链接对应的注册信息，用于预填注册表单
+type InvitationInfo struct {
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"` // 小写字母、数字、下划线和连字符
+	DisplayName string   `json:"display_name" validate:"max=100"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"` // 格式为 resource:action[:scope]
+}
+
+type UpdateRoleRequest struct {
+	DisplayName *string  `json:"display_name" validate:"omitempty,max=100"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions"` // 为 null 表示不修改，空数组表示清空
+}
+
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles"` // 角色名称，空数组表示只保留 users.role 的旧角色
 }
 
 type UpdateProfileRequest struct {
@@ -83,7 +391,38 @@ type UpdateProfileRequest struct {
 
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
-	NewPassword string `json:"new_password" validate:"required,min=6"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+type ExportDownload struct {
+	FileName  string
+	Content   io.ReadSeekCloser // 调用方负责关闭
+	CreatedAt time.Time
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
 
 // 文章相关
@@ -110,18 +449,95 @@ type UpdateArticleRequest struct {
 
 // 文件相关
 type UploadRequest struct {
+	FileName    string    `json:"file_name" validate:"required"`
+	FileSize    int64     `json:"file_size"` // -1 表示长度未知
+	MimeType    string    `json:"mime_type"` // 客户端声明的类型，实际类型根据文件内容识别
+	Reader      io.Reader `json:"-" validate:"required"`
+	IsPublic    bool      `json:"is_public"`
+	StorageType string    `json:"storage_type" validate:"oneof=local s3 oss"`
+	OwnerID     uint      `json:"owner_id,omitempty"` // 由服务器设置
+	OwnerRole   string    `json:"-"`                  // 由服务器设置，用于按角色限制大小
+}
+
+type CreateUploadSessionRequest struct {
 	FileName    string `json:"file_name" validate:"required"`
-	FileSize    int64  `json:"file_size" validate:"required"`
-	MimeType    string `json:"mime_type" validate:"required"`
-	FileData    []byte `json:"file_data" validate:"required"`
+	FileSize    int64  `json:"file_size" validate:"required,gt=0"`
+	MimeType    string `json:"mime_type"`
 	IsPublic    bool   `json:"is_public"`
-	StorageType string `json:"storage_type" validate:"oneof=local s3 oss"`
+	StorageType string `json:"storage_type" validate:"omitempty,oneof=local s3 oss"`
+	OwnerID     uint   `json:"owner_id,omitempty"` // 由服务器设置
+	OwnerRole   string `json:"-"`                  // 由服务器设置，用于按角色限制大小
 }
 
 type DownloadResponse struct {
-	File     *model.File `json:"file"`
-	FileData []byte      `json:"file_data"`
-	URL      string      `json:"url"`
+	File    *model.File       `json:"file"`
+	Content io.ReadSeekCloser `json:"-"` // 调用方负责关闭
+	URL     string            `json:"url"`
+}
+
+type SignedURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type StorageUsageResponse struct {
+	UserID         uint  `json:"user_id"`
+	UsedBytes      int64 `json:"used_bytes"`
+	QuotaBytes     int64 `json:"quota_bytes"`     // 不限制时为 0，此时 remaining_bytes 为 -1
+	RemainingBytes int64 `json:"remaining_bytes"` // 不限制时为 -1
+	IsOverride     bool  `json:"is_override"`     // 是否为管理员单独设置的配额
+}
+
+type StorageGCOptions struct {
+	StorageType   string        // 为空时检查所有已注册的驱动
+	DryRun        bool          // 仅报告问题，不做任何修改
+	DeleteOrphans bool          // 删除没有记录引用的对象
+	PurgeMissing  bool          // 删除对象已丢失的文件记录
+	GracePeriod   time.Duration // 最近写入的对象和引用不视为异常，避免误删上传中的文件
+}
+
+type StorageGCReport struct {
+	StorageType        string             `json:"storage_type"`
+	DryRun             bool               `json:"dry_run"`
+	ScannedObjects     int                `json:"scanned_objects"`
+	ScannedFiles       int                `json:"scanned_files"`
+	OrphanedObjects    []OrphanedObject   `json:"orphaned_objects"`
+	MissingObjects     []MissingObject    `json:"missing_objects"`
+	RefCountMismatches []RefCountMismatch `json:"ref_count_mismatches"`
+	DeletedObjects     int                `json:"deleted_objects"`
+	PurgedFiles        int                `json:"purged_files"`
+	FixedBlobs         int                `json:"fixed_blobs"`
+	Errors             []string           `json:"errors,omitempty"`
+	StartedAt          time.Time          `json:"started_at"`
+	FinishedAt         time.Time          `json:"finished_at"`
+}
+
+type OrphanedObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+type MissingObject struct {
+	FileID  uint   `json:"file_id"`
+	BlobID  uint   `json:"blob_id"`
+	OwnerID uint   `json:"owner_id"`
+	Path    string `json:"path"`
+}
+
+type RefCountMismatch struct {
+	BlobID   uint   `json:"blob_id"`
+	Path     string `json:"path"`
+	Recorded int    `json:"recorded"`
+	Actual   int    `json:"actual"`
+}
+
+type VariantResponse struct {
+	File     *model.File       `json:"file"`
+	Name     string            `json:"name"`
+	MimeType string            `json:"mime_type"`
+	Content  io.ReadSeekCloser `json:"-"` // 调用方负责关闭
+	ModTime  time.Time         `json:"mod_time"`
 }
 
 // 数据字典相关
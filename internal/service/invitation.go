@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrRegistrationClosed 已关闭注册
+	ErrRegistrationClosed = errors.New("registration is closed")
+	// ErrInvitationRequired 只能通过邀请注册
+	ErrInvitationRequired = errors.New("registration requires an invitation")
+	// ErrInvalidInvitation 邀请令牌无效、已过期、已撤销或已使用
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+	// ErrInvitationEmailMismatch 注册邮箱与受邀邮箱不一致
+	ErrInvitationEmailMismatch = errors.New("email does not match the invitation")
+	// ErrInvitationNotFound 邀请不存在
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationNotPending 邀请已被使用或已撤销，不能再撤销
+	ErrInvitationNotPending = errors.New("invitation has already been accepted or revoked")
+)
+
+// 注册方式
+const (
+	RegistrationModeOpen       = "open"
+	RegistrationModeInviteOnly = "invite_only"
+	RegistrationModeClosed     = "closed"
+)
+
+// invitationService 注册邀请服务实现
+type invitationService struct {
+	invitationRepo repository.InvitationRepository
+	userRepo       repository.UserRepository
+	roles          RoleService
+	auditService   AuditService
+	mailer         mailer.Mailer
+	config         *config.Config
+	logger         logger.Logger
+}
+
+// NewInvitationService 创建注册邀请服务
+func NewInvitationService(
+	invitationRepo repository.InvitationRepository,
+	userRepo repository.UserRepository,
+	roles RoleService,
+	auditService AuditService,
+	mailer mailer.Mailer,
+	config *config.Config,
+	logger logger.Logger,
+) InvitationService {
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		roles:          roles,
+		auditService:   auditService,
+		mailer:         mailer,
+		config:         config,
+		logger:         logger,
+	}
+}
+
+// Create 创建邀请并发送邀请邮件，同一邮箱之前未使用的邀请会被撤销，只有拥有全部权限的操作者才能邀请管理员
+func (s *invitationService) Create(ctx context.Context, actorID uint, req *CreateInvitationRequest) (*CreatedInvitation, error) {
+	email := strings.TrimSpace(req.Email)
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, fmt.Errorf("%w: invalid email", ErrInvalidUserInput)
+	}
+	role := req.Role
+	if role == "" {
+		role = model.UserRoleUser
+	}
+	if !validUserRole(role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidUserInput, role)
+	}
+	if err := checkRoleGrant(ctx, s.roles, actorID, role); err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(s.expiration())
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidUserInput)
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		return nil, fmt.Errorf("%w: email %s is already registered", ErrUserExists, email)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+
+	// 重新邀请时旧链接失效，保证每个邮箱最多只有一个可用邀请
+	if _, err := s.invitationRepo.RevokePendingByEmail(ctx, email); err != nil {
+		return nil, err
+	}
+
+	plain, err := token.NewOpaque()
+	if err != nil {
+		return nil, err
+	}
+	invitation := &model.Invitation{
+		Email:     email,
+		Role:      role,
+		TokenHash: token.Hash(plain),
+		InvitedBy: &actorID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "invitation.create",
+		ResourceType: "invitation",
+		ResourceID:   auditResourceID(invitation.ID),
+		After:        map[string]interface{}{"role": invitation.Role, "expires_at": invitation.ExpiresAt},
+	})
+
+	// 邮件发送失败不影响创建，管理员可以通过响应中的链接自行转发
+	link := s.link(plain)
+	if err := s.mailer.Send(ctx, s.invitationMessage(invitation, link)); err != nil {
+		s.logger.Error("Failed to send invitation email", "invitation_id", invitation.ID, "error", err)
+	}
+
+	s.logger.Info("Invitation created", "invitation_id", invitation.ID, "actor_id", actorID, "role", role)
+	return &CreatedInvitation{Invitation: invitation, Link: link}, nil
+}
+
+// Lookup 根据邀请链接中的令牌获取仍可使用的邀请
+func (s *invitationService) Lookup(ctx context.Context, plain string) (*model.Invitation, error) {
+	if plain == "" {
+		return nil, ErrInvalidInvitation
+	}
+
+	invitation, err := s.invitationRepo.GetByHash(ctx, token.Hash(plain))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+	if !invitation.IsPending() {
+		return nil, ErrInvalidInvitation
+	}
+	return invitation, nil
+}
+
+// Accept 使用邀请创建用户，邀请在创建用户的同一事务中被占用
+func (s *invitationService) Accept(ctx context.Context, invitation *model.Invitation, user *model.User) error {
+	accepted, err := s.invitationRepo.Accept(ctx, invitation.ID, user)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrInvalidInvitation
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "invitation.accept",
+		ResourceType: "invitation",
+		ResourceID:   auditResourceID(invitation.ID),
+		After:        map[string]interface{}{"accepted_by": user.ID},
+	})
+
+	s.logger.Info("Invitation accepted", "invitation_id", invitation.ID, "user_id", user.ID)
+	return nil
+}
+
+// List 查询邀请
+func (s *invitationService) List(ctx context.Context, opts repository.ListOptions) ([]*model.Invitation, int64, error) {
+	return s.invitationRepo.List(ctx, opts)
+}
+
+// Revoke 撤销尚未使用的邀请
+func (s *invitationService) Revoke(ctx context.Context, actorID, id uint) error {
+	if _, err := s.invitationRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+
+	revoked, err := s.invitationRepo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotPending
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "invitation.revoke",
+		ResourceType: "invitation",
+		ResourceID:   auditResourceID(id),
+	})
+
+	s.logger.Info("Invitation revoked", "invitation_id", id, "actor_id", actorID)
+	return nil
+}
+
+// expiration 邀请默认有效期
+func (s *invitationService) expiration() time.Duration {
+	ttl := time.Duration(s.config.Auth.InvitationExpiration) * time.Second
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return ttl
+}
+
+// link 邀请链接，前端据此预填注册表单
+func (s *invitationService) link(plain string) string {
+	return strings.TrimRight(s.config.Email.LinkBaseURL, "/") + "/register?invitation=" + url.QueryEscape(plain)
+}
+
+// invitationMessage 构造邀请邮件
+func (s *invitationService) invitationMessage(invitation *model.Invitation, link string) *mailer.Message {
+	expiresAt := invitation.ExpiresAt.Format("2006-01-02 15:04")
+
+	return &mailer.Message{
+		To:      []string{invitation.Email},
+		Subject: "您收到了注册邀请",
+		Text: fmt.Sprintf("您好：\n\n您被邀请注册账户，请在 %s 之前打开以下链接完成注册：\n\n%s\n\n该链接只能使用一次。如果您不认识发出邀请的人，请忽略本邮件。\n",
+			expiresAt, link),
+		HTML: fmt.Sprintf(`<p>您好：</p><p>您被邀请注册账户，请在 %s 之前点击以下链接完成注册：</p><p><a href="%s">接受邀请</a></p><p>该链接只能使用一次。如果您不认识发出邀请的人，请忽略本邮件。</p>`,
+			expiresAt, html.EscapeString(link)),
+	}
+}
+
+// registrationMode 获取注册方式，未配置时开放注册，无法识别的取值按关闭注册处理
+func registrationMode(cfg *config.Config) string {
+	switch cfg.Auth.RegistrationMode {
+	case "":
+		return RegistrationModeOpen
+	case RegistrationModeOpen, RegistrationModeInviteOnly, RegistrationModeClosed:
+		return cfg.Auth.RegistrationMode
+	default:
+		return RegistrationModeClosed
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/pkg/token"
+)
+
+// maxLoginBackoff 达到锁定阈值前两次尝试之间的最长间隔
+const maxLoginBackoff = time.Minute
+
+// ErrLoginLocked 账户因连续登录失败被暂时锁定
+var ErrLoginLocked = errors.New("too many failed login attempts, please try again later")
+
+// LoginLockedError 登录被暂时拒绝，RetryAfter 为剩余等待时间
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+// Error 实现 error 接口
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+// Is 使 errors.Is(err, ErrLoginLocked) 成立
+func (e *LoginLockedError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
+
+// UnlockUser 解除账户的登录锁定并清空失败次数
+func (s *userService) UnlockUser(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := s.cache.Del(ctx, loginFailuresKey(user.Username), loginLockKey(user.Username)); err != nil {
+		s.logger.Error("Failed to unlock user", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+
+	s.logger.Info("User login unlocked", "user_id", userID)
+	return nil
+}
+
+// loginLockoutEnabled 是否开启按账户的登录失败锁定
+func (s *userService) loginLockoutEnabled() bool {
+	return s.config.Auth.LoginMaxAttempts > 0
+}
+
+// checkLoginLock 账户处于退避或锁定期间时拒绝登录，不论密码是否正确
+func (s *userService) checkLoginLock(ctx context.Context, username string) error {
+	if !s.loginLockoutEnabled() {
+		return nil
+	}
+
+	ttl, err := s.cache.TTL(ctx, loginLockKey(username))
+	if err != nil {
+		// 缓存不可用时不阻止登录，由 IP 限流兜底
+		s.logger.Error("Failed to check login lock", "username", username, "error", err)
+		return nil
+	}
+	if ttl > 0 {
+		return &LoginLockedError{RetryAfter: ttl}
+	}
+	return nil
+}
+
+// recordLoginFailure 记录一次登录失败：达到阈值前从第二次失败起按 1、2、4 秒退避，之后锁定时长从 login_lockout_duration 起逐次翻倍
+func (s *userService) recordLoginFailure(ctx context.Context, username string) error {
+	if !s.loginLockoutEnabled() {
+		return nil
+	}
+
+	// 计数原子递增，并发的失败请求不会少计
+	count, err := s.cache.Incr(ctx, loginFailuresKey(username), s.loginLockoutDuration())
+	if err != nil {
+		s.logger.Error("Failed to record login failure", "username", username, "error", err)
+		return nil
+	}
+	failures := int(count)
+
+	delay := s.loginDelay(failures)
+	if delay <= 0 {
+		return nil
+	}
+	// 最近一次退避或锁定结束后再过 login_lockout_duration 没有失败，计数清零
+	if err := s.cache.Expire(ctx, loginFailuresKey(username), delay+s.loginLockoutDuration()); err != nil {
+		s.logger.Error("Failed to extend login failure window", "username", username, "error", err)
+	}
+	if err := s.cache.Set(ctx, loginLockKey(username), "locked", delay); err != nil {
+		s.logger.Error("Failed to lock login", "username", username, "error", err)
+		return nil
+	}
+
+	if failures < s.config.Auth.LoginMaxAttempts {
+		return nil
+	}
+	s.logger.Warn("Account locked after failed logins", "username", username, "failures", failures, "duration", delay)
+	return &LoginLockedError{RetryAfter: delay}
+}
+
+// resetLoginFailures 密码验证通过后清空失败次数
+func (s *userService) resetLoginFailures(ctx context.Context, username string) {
+	if !s.loginLockoutEnabled() {
+		return
+	}
+	if err := s.cache.Del(ctx, loginFailuresKey(username)); err != nil {
+		s.logger.Warn("Failed to reset login failures", "username", username, "error", err)
+	}
+}
+
+// loginDelay 第 failures 次失败后需要等待的时间
+func (s *userService) loginDelay(failures int) time.Duration {
+	threshold := s.config.Auth.LoginMaxAttempts
+	if failures < threshold {
+		// 偶尔输错一次不需要等待
+		if failures < 2 {
+			return 0
+		}
+		return min(time.Second<<(failures-2), maxLoginBackoff)
+	}
+
+	base := s.loginLockoutDuration()
+	limit := time.Duration(s.config.Auth.LoginLockoutMaxDuration) * time.Second
+	if limit < base {
+		limit = base
+	}
+
+	delay := base
+	for i := threshold; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// loginLockoutDuration 首次锁定时长
+func (s *userService) loginLockoutDuration() time.Duration {
+	duration := time.Duration(s.config.Auth.LoginLockoutDuration) * time.Second
+	if duration <= 0 {
+		duration = 15 * time.Minute
+	}
+	return duration
+}
+
+// loginFailuresKey 账户连续登录失败次数的缓存键，未注册的用户名同样计数，避免借锁定行为探测账户是否存在
+func loginFailuresKey(username string) string {
+	return "login_failures:" + token.Hash(strings.ToLower(username))
+}
+
+// loginLockKey 账户登录锁定的缓存键
+func loginLockKey(username string) string {
+	return "login_lock:" + token.Hash(strings.ToLower(username))
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/pkg/totp"
+)
+
+var (
+	// ErrMFAAlreadyEnabled 两步验证已开启
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrMFANotEnabled 两步验证未开启或尚未生成密钥
+	ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrInvalidMFACode 验证码或恢复码错误、已使用
+	ErrInvalidMFACode = errors.New("invalid two-factor authentication code")
+	// ErrInvalidMFAToken 登录第二步令牌无效、已过期或尝试次数过多
+	ErrInvalidMFAToken = errors.New("invalid or expired mfa token")
+	// ErrInvalidPassword 密码错误
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
+const (
+	// maxMFAAttempts 同一登录第二步令牌允许的最大失败次数
+	maxMFAAttempts = 5
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet 恢复码字符集，去掉了容易混淆的字符
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// LoginMFA 使用登录第二步令牌和验证码完成登录
+func (s *userService) LoginMFA(ctx context.Context, req *MFALoginRequest) (*LoginResponse, error) {
+	if req.MFAToken == "" {
+		return nil, ErrInvalidMFAToken
+	}
+
+	pendingKey := mfaPendingKey(req.MFAToken)
+	value, err := s.cache.Get(ctx, pendingKey)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	userID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, uint(userID))
+	if err != nil || !user.IsActive() || !user.MFAEnabled {
+		_ = s.cache.Del(ctx, pendingKey, mfaAttemptsKey(req.MFAToken))
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.verifyMFACode(ctx, user.ID, req.Code, true); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.recordMFAFailure(ctx, req.MFAToken)
+		}
+		return nil, err
+	}
+
+	// 登录第二步令牌只能使用一次
+	if err := s.cache.Del(ctx, pendingKey, mfaAttemptsKey(req.MFAToken)); err != nil {
+		s.logger.Warn("Failed to delete mfa pending token", "user_id", user.ID, "error", err)
+	}
+
+	return s.completeLogin(ctx, user, req.Client, true)
+}
+
+// GetMFAStatus 获取两步验证状态
+func (s *userService) GetMFAStatus(ctx context.Context, userID uint) (*MFAStatus, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	status := &MFAStatus{Enabled: user.MFAEnabled}
+	if user.MFAEnabled {
+		remaining, err := s.mfaRepo.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		status.RecoveryCodesRemaining = remaining
+	}
+	return status, nil
+}
+
+// EnrollMFA 生成新的 TOTP 密钥，确认验证码后才会开启
+func (s *userService) EnrollMFA(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.secrets.Seal(secret)
+	if err != nil {
+		s.logger.Error("Failed to encrypt mfa secret", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to encrypt mfa secret: %w", err)
+	}
+	if err := s.mfaRepo.SaveSecret(ctx, userID, sealed); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("MFA enrollment started", "user_id", userID)
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.config.Auth.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA 校验验证码后开启两步验证，撤销当前会话以外的会话，返回一次性恢复码
+func (s *userService) ConfirmMFA(ctx context.Context, userID uint, sessionID string, req *MFACodeRequest) (*MFARecoveryCodes, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if err := s.verifyTOTP(ctx, userID, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.Enable(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	// 开启前登录的其他会话没有通过两步验证，需要重新登录
+	if _, err := s.tokens.RevokeOtherSessions(ctx, userID, sessionID); err != nil {
+		s.logger.Error("Failed to revoke other sessions after enabling mfa", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.logger.Info("MFA enabled", "user_id", userID)
+	return &MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA 校验密码和验证码后关闭两步验证
+func (s *userService) DisableMFA(ctx context.Context, userID uint, req *DisableMFARequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if !user.CheckPassword(req.Password) {
+		s.logger.Warn("Invalid password when disabling mfa", "user_id", userID)
+		return ErrInvalidPassword
+	}
+
+	if err := s.verifyMFACode(ctx, userID, req.Code, true); err != nil {
+		return err
+	}
+	if err := s.mfaRepo.Disable(ctx, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("MFA disabled", "user_id", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes 校验验证码后生成新的恢复码，旧恢复码全部失效
+func (s *userService) RegenerateRecoveryCodes(ctx context.Context, userID uint, req *MFACodeRequest) (*MFARecoveryCodes, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := s.verifyTOTP(ctx, userID, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("MFA recovery codes regenerated", "user_id", userID)
+	return &MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// startMFALogin 密码验证通过后签发登录第二步令牌
+func (s *userService) startMFALogin(ctx context.Context, user *model.User) (*LoginResponse, error) {
+	plain, err := token.NewOpaque()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(s.config.Auth.MFAPendingExpiration) * time.Second
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if err := s.cache.Set(ctx, mfaPendingKey(plain), strconv.FormatUint(uint64(user.ID), 10), ttl); err != nil {
+		s.logger.Error("Failed to store mfa pending token", "user_id", user.ID, "error", err)
+		return nil, fmt.Errorf("failed to start mfa login: %w", err)
+	}
+
+	s.logger.Info("Password verified, waiting for mfa code", "user_id", user.ID)
+	return &LoginResponse{MFARequired: true, MFAToken: plain}, nil
+}
+
+// recordMFAFailure 记录验证失败次数，达到上限后登录第二步令牌失效
+func (s *userService) recordMFAFailure(ctx context.Context, pendingToken string) {
+	key := mfaAttemptsKey(pendingToken)
+
+	attempts := 0
+	if value, err := s.cache.Get(ctx, key); err == nil {
+		attempts, _ = strconv.Atoi(value)
+	}
+	attempts++
+
+	if attempts >= maxMFAAttempts {
+		_ = s.cache.Del(ctx, mfaPendingKey(pendingToken), key)
+		return
+	}
+
+	ttl, err := s.cache.TTL(ctx, mfaPendingKey(pendingToken))
+	if err != nil || ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if err := s.cache.Set(ctx, key, strconv.Itoa(attempts), ttl); err != nil {
+		s.logger.Warn("Failed to record mfa attempt", "error", err)
+	}
+}
+
+// verifyMFACode 校验 TOTP 验证码，allowRecovery 为 true 时也接受恢复码
+func (s *userService) verifyMFACode(ctx context.Context, userID uint, code string, allowRecovery bool) error {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) || !allowRecovery {
+		return s.verifyTOTP(ctx, userID, code)
+	}
+
+	consumed, err := s.mfaRepo.ConsumeRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		s.logger.Warn("Invalid mfa recovery code", "user_id", userID)
+		return ErrInvalidMFACode
+	}
+
+	s.logger.Info("MFA recovery code used", "user_id", userID)
+	return nil
+}
+
+// verifyTOTP 校验 TOTP 验证码，同一时间步的验证码只能使用一次
+func (s *userService) verifyTOTP(ctx context.Context, userID uint, code string) error {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMFANotEnabled
+		}
+		return err
+	}
+
+	secret, err := s.secrets.Open(mfa.Secret)
+	if err != nil {
+		s.logger.Error("Failed to decrypt mfa secret", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to decrypt mfa secret: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		s.logger.Warn("Invalid mfa code", "user_id", userID)
+		return ErrInvalidMFACode
+	}
+
+	advanced, err := s.mfaRepo.AdvanceStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		s.logger.Warn("Replayed mfa code", "user_id", userID)
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// generateRecoveryCodes 生成恢复码及其哈希
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	buf := make([]byte, 10)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+		}
+		chars := make([]byte, len(buf))
+		for j, b := range buf {
+			chars[j] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+		}
+
+		code := string(chars[:5]) + "-" + string(chars[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 计算恢复码哈希，忽略大小写、空格和连字符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return token.Hash(normalized)
+}
+
+// isTOTPCode 检查是否为 TOTP 验证码格式
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// mfaPendingKey 登录第二步令牌的缓存键
+func mfaPendingKey(pendingToken string) string {
+	return "mfa_pending:" + token.Hash(pendingToken)
+}
+
+// mfaAttemptsKey 登录第二步验证失败次数的缓存键
+func mfaAttemptsKey(pendingToken string) string {
+	return "mfa_attempts:" + token.Hash(pendingToken)
+}
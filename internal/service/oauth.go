@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrOAuthProviderNotFound 未配置的第三方登录提供方
+	ErrOAuthProviderNotFound = errors.New("oauth provider not found")
+	// ErrInvalidOAuthState state 无效、已过期或已使用
+	ErrInvalidOAuthState = errors.New("invalid or expired oauth state")
+	// ErrOAuthFailed 授权码换取令牌或 ID 令牌校验失败
+	ErrOAuthFailed = errors.New("oauth login failed")
+	// ErrOAuthEmailNotVerified 提供方未返回已验证的邮箱，无法关联账户
+	ErrOAuthEmailNotVerified = errors.New("oauth provider did not return a verified email")
+	// ErrOAuthAccountNotFound 邮箱未注册且未开启自动注册
+	ErrOAuthAccountNotFound = errors.New("no account is registered with this email")
+	// ErrAccountNotActive 账户已停用或被禁用
+	ErrAccountNotActive = errors.New("user account is not active")
+)
+
+// oauthState 发起授权时保存的请求信息，回调时取出校验
+type oauthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+// ListOAuthProviders 获取已配置的第三方登录提供方
+func (s *userService) ListOAuthProviders(ctx context.Context) []*OAuthProvider {
+	providers := make([]*OAuthProvider, 0, len(s.providers.Providers()))
+	for _, p := range s.providers.Providers() {
+		providers = append(providers, &OAuthProvider{Name: p.Name(), DisplayName: p.DisplayName()})
+	}
+	return providers
+}
+
+// StartOAuthLogin 生成 state、nonce 和 PKCE 验证码并返回提供方授权地址
+func (s *userService) StartOAuthLogin(ctx context.Context, providerName string) (*OAuthAuthorization, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		if values[i], err = oidc.NewCodeVerifier(); err != nil {
+			return nil, err
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		s.logger.Error("Failed to build oauth authorization url", "provider", providerName, "error", err)
+		return nil, fmt.Errorf("failed to start oauth login: %w", err)
+	}
+
+	data, err := json.Marshal(&oauthState{Provider: providerName, CodeVerifier: verifier, Nonce: nonce})
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(s.config.OAuth.StateExpiration) * time.Second
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	if err := s.cache.Set(ctx, oauthStateKey(state), string(data), ttl); err != nil {
+		s.logger.Error("Failed to store oauth state", "provider", providerName, "error", err)
+		return nil, fmt.Errorf("failed to start oauth login: %w", err)
+	}
+
+	return &OAuthAuthorization{
+		AuthorizationURL: authURL,
+		State:            state,
+		Binding:          token.Hash(state),
+		ExpiresIn:        ttl,
+	}, nil
+}
+
+// CompleteOAuthLogin 校验回调并登录关联的用户，开启两步验证的用户仍需提交验证码
+func (s *userService) CompleteOAuthLogin(ctx context.Context, req *OAuthCallbackRequest) (*LoginResponse, error) {
+	// state 必须由发起授权的同一浏览器回调，防止攻击者把自己的授权回调诱导给受害者完成登录
+	if req.Binding == "" || subtle.ConstantTimeCompare([]byte(req.Binding), []byte(token.Hash(req.State))) != 1 {
+		s.logger.Warn("OAuth state not bound to this browser", "provider", req.Provider)
+		return nil, ErrInvalidOAuthState
+	}
+
+	state, err := s.consumeOAuthState(ctx, req.State)
+	if err != nil || state.Provider != req.Provider {
+		return nil, ErrInvalidOAuthState
+	}
+
+	provider, err := s.providers.Get(req.Provider)
+	if err != nil {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	tokens, err := provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		s.logger.Warn("Failed to exchange oauth code", "provider", req.Provider, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrOAuthFailed, err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, state.Nonce)
+	if err != nil {
+		s.logger.Warn("Invalid oauth id token", "provider", req.Provider, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrOAuthFailed, err)
+	}
+
+	user, err := s.resolveIdentity(ctx, req.Provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		s.logger.Warn("OAuth login for inactive user", "user_id", user.ID, "status", user.Status)
+		return nil, ErrAccountNotActive
+	}
+
+	if user.MFAEnabled {
+		return s.startMFALogin(ctx, user)
+	}
+	return s.completeLogin(ctx, user, req.Client, false)
+}
+
+// ListIdentities 获取用户关联的第三方登录身份
+func (s *userService) ListIdentities(ctx context.Context, userID uint) ([]*model.UserIdentity, error) {
+	return s.identityRepo.ListByUserID(ctx, userID)
+}
+
+// consumeOAuthState 取出并删除 state，同一 state 只能使用一次
+func (s *userService) consumeOAuthState(ctx context.Context, state string) (*oauthState, error) {
+	if state == "" {
+		return nil, ErrInvalidOAuthState
+	}
+
+	key := oauthStateKey(state)
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if err := s.cache.Del(ctx, key); err != nil {
+		s.logger.Warn("Failed to delete oauth state", "error", err)
+	}
+
+	var stored oauthState
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	return &stored, nil
+}
+
+// resolveIdentity 查找第三方身份关联的用户，未关联时按已验证邮箱关联已有用户或创建新用户
+func (s *userService) resolveIdentity(ctx context.Context, provider string, claims *oidc.Claims) (*model.User, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, provider, claims.Subject)
+	if err == nil {
+		if err := s.identityRepo.RecordLogin(ctx, identity.ID, claims.Email); err != nil {
+			s.logger.Warn("Failed to record identity login", "identity_id", identity.ID, "error", err)
+		}
+		return s.userRepo.GetByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 只有提供方确认过的邮箱才能用于关联账户，否则可能被他人冒用
+	if claims.Email == "" || !claims.EmailVerified {
+		s.logger.Warn("OAuth identity without verified email", "provider", provider)
+		return nil, ErrOAuthEmailNotVerified
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// 提供方已验证邮箱，等待验证邮箱的用户可以直接激活
+		if user.IsPendingVerification() {
+			if _, err := s.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+				return nil, err
+			}
+			if user, err = s.userRepo.GetByID(ctx, user.ID); err != nil {
+				return nil, err
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// 只在开放注册时自动注册，邀请注册和关闭注册时只能登录已有账户
+		if !s.config.OAuth.AutoRegister || registrationMode(s.config) != RegistrationModeOpen {
+			return nil, ErrOAuthAccountNotFound
+		}
+		if user, err = s.registerOAuthUser(ctx, claims); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now()
+	identity = &model.UserIdentity{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("OAuth identity linked", "user_id", user.ID, "provider", provider)
+	return user, nil
+}
+
+// registerOAuthUser 为首次通过第三方登录的邮箱创建用户，随机密码使其只能通过第三方登录或重置密码后登录
+func (s *userService) registerOAuthUser(ctx context.Context, claims *oidc.Claims) (*model.User, error) {
+	username, err := s.availableUsername(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	password, err := token.NewOpaque()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Username:        username,
+		Email:           claims.Email,
+		Password:        password,
+		Nickname:        truncate(claims.Name, 50),
+		Avatar:          claims.Picture,
+		Role:            model.UserRoleUser,
+		Status:          model.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.logger.Error("Failed to create oauth user", "email", claims.Email, "error", err)
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.logger.Info("User registered via oauth", "user_id", user.ID, "email", user.Email)
+	return user, nil
+}
+
+// availableUsername 根据邮箱生成未被占用的用户名
+func (s *userService) availableUsername(ctx context.Context, email string) (string, error) {
+	local := email
+	if at := strings.Index(email, "@"); at >= 0 {
+		local = email[:at]
+	}
+
+	var b strings.Builder
+	for _, c := range strings.ToLower(local) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' {
+			b.WriteRune(c)
+		}
+	}
+	base := truncate(b.String(), 40)
+	if len(base) < 3 {
+		base = "user" + base
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		_, err := s.userRepo.GetByUsername(ctx, candidate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to check username: %w", err)
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%06d", base, n.Int64())
+	}
+	return "", fmt.Errorf("failed to generate an available username")
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return s
+}
+
+// oauthStateKey 授权请求 state 的缓存键
+func oauthStateKey(state string) string {
+	return "oauth_state:" + token.Hash(state)
+}
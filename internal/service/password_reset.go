@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/password"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrInvalidResetToken 重置令牌无效、已过期或已使用
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	// ErrWeakPassword 新密码不符合密码策略，长度、字符类型、常见密码和用户名检查的错误都包装了该错误
+	ErrWeakPassword = password.ErrPolicyViolation
+	// ErrPasswordTooShort 新密码长度不足
+	ErrPasswordTooShort = password.ErrTooShort
+)
+
+// passwordResetService 密码重置服务实现
+type passwordResetService struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.OneTimeTokenRepository
+	tokens    TokenService
+	policy    *password.Policy
+	mailer    mailer.Mailer
+	config    *config.Config
+	logger    logger.Logger
+}
+
+// NewPasswordResetService 创建密码重置服务
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.OneTimeTokenRepository,
+	tokens TokenService,
+	policy *password.Policy,
+	mailer mailer.Mailer,
+	config *config.Config,
+	logger logger.Logger,
+) PasswordResetService {
+	return &passwordResetService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		tokens:    tokens,
+		policy:    policy,
+		mailer:    mailer,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// Forgot 为邮箱对应的用户发送重置链接，邮箱不存在时同样返回成功，避免泄露注册信息
+func (s *passwordResetService) Forgot(ctx context.Context, req *ForgotPasswordRequest) error {
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Info("Password reset requested for unknown email")
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive() {
+		s.logger.Warn("Password reset requested for inactive user", "user_id", user.ID, "status", user.Status)
+		return nil
+	}
+
+	// 同一用户只保留最新的重置令牌
+	if err := s.tokenRepo.DeleteByUser(ctx, user.ID, model.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	plain, err := token.NewOpaque()
+	if err != nil {
+		return err
+	}
+	record := &model.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   model.TokenPurposePasswordReset,
+		TokenHash: token.Hash(plain),
+		ExpiresAt: time.Now().Add(s.expiration()),
+	}
+	if err := s.tokenRepo.Create(ctx, record); err != nil {
+		return err
+	}
+
+	// 邮件发送失败不返回给调用方，否则响应会暴露邮箱是否存在
+	if err := s.mailer.Send(ctx, s.resetMessage(user, plain)); err != nil {
+		s.logger.Error("Failed to send password reset email", "user_id", user.ID, "error", err)
+		return nil
+	}
+
+	s.logger.Info("Password reset email sent", "user_id", user.ID)
+	return nil
+}
+
+// Reset 使用重置令牌设置新密码，成功后令牌失效并撤销用户所有会话
+func (s *passwordResetService) Reset(ctx context.Context, req *ResetPasswordRequest) error {
+	if req.Token == "" {
+		return ErrInvalidResetToken
+	}
+
+	record, err := s.tokenRepo.GetByHash(ctx, model.TokenPurposePasswordReset, token.Hash(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if record.UsedAt != nil || record.IsExpired() {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get user for password reset", "user_id", record.UserID, "error", err)
+		return ErrInvalidResetToken
+	}
+	if !user.IsActive() {
+		return ErrInvalidResetToken
+	}
+	// 新密码不符合策略时不消耗令牌，用户可以换一个密码重试
+	if err := s.policy.Validate(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+
+	// 并发使用同一令牌时只有一个请求能完成重置
+	consumed, err := s.tokenRepo.Consume(ctx, record.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := user.HashPassword(req.NewPassword)
+	if err != nil {
+		s.logger.Error("Failed to hash password", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	revoked, err := s.tokens.RevokeAllSessions(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Failed to revoke sessions after password reset", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.logger.Info("Password reset successfully", "user_id", user.ID, "revoked_sessions", revoked)
+	return nil
+}
+
+// CleanupExpired 删除已过期的一次性令牌
+func (s *passwordResetService) CleanupExpired(ctx context.Context) (int64, error) {
+	deleted, err := s.tokenRepo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		s.logger.Info("Expired one-time tokens cleaned", "count", deleted)
+	}
+	return deleted, nil
+}
+
+// expiration 重置令牌有效期
+func (s *passwordResetService) expiration() time.Duration {
+	ttl := time.Duration(s.config.Security.PasswordResetExpiration) * time.Second
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return ttl
+}
+
+// resetMessage 生成密码重置邮件
+func (s *passwordResetService) resetMessage(user *model.User, plain string) *mailer.Message {
+	link := strings.TrimRight(s.config.Email.LinkBaseURL, "/") + "/reset-password?token=" + url.QueryEscape(plain)
+	minutes := int(s.expiration().Minutes())
+
+	return &mailer.Message{
+		To:      []string{user.Email},
+		Subject: "重置密码",
+		Text: fmt.Sprintf("%s，您好：\n\n我们收到了重置您账户密码的请求，请在 %d 分钟内打开以下链接设置新密码：\n\n%s\n\n如果这不是您本人的操作，请忽略本邮件，您的密码不会被修改。\n",
+			user.Username, minutes, link),
+		HTML: fmt.Sprintf(`<p>%s，您好：</p><p>我们收到了重置您账户密码的请求，请在 %d 分钟内点击以下链接设置新密码：</p><p><a href="%s">重置密码</a></p><p>如果这不是您本人的操作，请忽略本邮件，您的密码不会被修改。</p>`,
+			html.EscapeString(user.Username), minutes, html.EscapeString(link)),
+	}
+}
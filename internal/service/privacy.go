@@ -0,0 +1,525 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/storage"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrExportTooFrequent 距离上次申请导出的时间过短
+	ErrExportTooFrequent = errors.New("data export was requested recently, please try again later")
+	// ErrInvalidExportToken 导出下载令牌无效或已过期
+	ErrInvalidExportToken = errors.New("invalid or expired export token")
+	// ErrDeletionNotScheduled 账户没有待处理的注销申请
+	ErrDeletionNotScheduled = errors.New("account deletion is not scheduled")
+)
+
+// 匿名化时内容的处理方式
+const (
+	ContentPolicyDelete   = "delete"
+	ContentPolicyReassign = "reassign"
+)
+
+const (
+	// exportKeyPrefix 导出文件在存储中的键名前缀
+	exportKeyPrefix = "exports/"
+	// exportBuildTimeout 后台生成导出文件的超时时间
+	exportBuildTimeout = 5 * time.Minute
+	// purgeBatchSize 每批匿名化的账户数量
+	purgeBatchSize = 100
+)
+
+// privacyService 个人数据导出与账户注销服务实现
+type privacyService struct {
+	userRepo     repository.UserRepository
+	articleRepo  repository.ArticleRepository
+	commentRepo  repository.CommentRepository
+	fileRepo     repository.FileRepository
+	tokenRepo    repository.OneTimeTokenRepository
+	quotaRepo    repository.StorageQuotaRepository
+	files        FileService
+	tokens       TokenService
+	auditService AuditService
+	storage      *storage.Manager
+	mailer       mailer.Mailer
+	cache        cache.Cache
+	config       *config.Config
+	logger       logger.Logger
+}
+
+// NewPrivacyService 创建个人数据导出与账户注销服务
+func NewPrivacyService(
+	userRepo repository.UserRepository,
+	articleRepo repository.ArticleRepository,
+	commentRepo repository.CommentRepository,
+	fileRepo repository.FileRepository,
+	tokenRepo repository.OneTimeTokenRepository,
+	quotaRepo repository.StorageQuotaRepository,
+	files FileService,
+	tokens TokenService,
+	auditService AuditService,
+	storage *storage.Manager,
+	mailer mailer.Mailer,
+	cache cache.Cache,
+	config *config.Config,
+	logger logger.Logger,
+) PrivacyService {
+	return &privacyService{
+		userRepo:     userRepo,
+		articleRepo:  articleRepo,
+		commentRepo:  commentRepo,
+		fileRepo:     fileRepo,
+		tokenRepo:    tokenRepo,
+		quotaRepo:    quotaRepo,
+		files:        files,
+		tokens:       tokens,
+		auditService: auditService,
+		storage:      storage,
+		mailer:       mailer,
+		cache:        cache,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+// RequestExport 限制申请频率后在后台生成导出文件，生成失败时允许立即重新申请
+func (s *privacyService) RequestExport(ctx context.Context, userID uint) error {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	requested, err := s.cache.Exists(ctx, exportRequestKey(userID))
+	if err != nil {
+		return err
+	}
+	if requested > 0 {
+		return ErrExportTooFrequent
+	}
+	if err := s.cache.Set(ctx, exportRequestKey(userID), "requested", s.exportInterval()); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.data_export",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(userID),
+	})
+
+	go func() {
+		buildCtx, cancel := context.WithTimeout(context.Background(), exportBuildTimeout)
+		defer cancel()
+
+		if err := s.BuildExport(buildCtx, userID); err != nil {
+			s.logger.Error("Failed to build data export", "user_id", userID, "error", err)
+			if err := s.cache.Del(buildCtx, exportRequestKey(userID)); err != nil {
+				s.logger.Warn("Failed to clear data export request", "user_id", userID, "error", err)
+			}
+		}
+	}()
+
+	s.logger.Info("Data export requested", "user_id", userID)
+	return nil
+}
+
+// BuildExport 将资料、文章、评论和文件信息打包为 ZIP 写入存储，并发送下载链接
+func (s *privacyService) BuildExport(ctx context.Context, userID uint) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	articles, _, err := s.articleRepo.GetByAuthor(ctx, userID, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+	comments, _, err := s.commentRepo.GetByAuthor(ctx, userID, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+	files, _, err := s.fileRepo.GetByOwner(ctx, userID, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	archive, err := buildExportArchive(map[string]interface{}{
+		"profile.json":  user.ToPublic(),
+		"articles.json": articles,
+		"comments.json": comments,
+		"files.json":    files,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build export archive: %w", err)
+	}
+
+	plain, err := token.NewOpaque()
+	if err != nil {
+		return err
+	}
+	tokenHash := token.Hash(plain)
+	key := exportKey(userID, tokenHash)
+	if err := s.storage.Default().Put(ctx, key, bytes.NewReader(archive), int64(len(archive)), "application/zip"); err != nil {
+		return fmt.Errorf("failed to store export archive: %w", err)
+	}
+
+	// 只保留最新一次导出的下载链接，旧文件到期后由清理任务删除
+	if err := s.tokenRepo.DeleteByUser(ctx, userID, model.TokenPurposeDataExport); err != nil {
+		return err
+	}
+	record := &model.OneTimeToken{
+		UserID:    userID,
+		Purpose:   model.TokenPurposeDataExport,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(s.exportExpiration()),
+	}
+	if err := s.tokenRepo.Create(ctx, record); err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(ctx, s.exportMessage(user, plain)); err != nil {
+		return fmt.Errorf("failed to send export email: %w", err)
+	}
+
+	s.logger.Info("Data export built", "user_id", userID, "size", len(archive))
+	return nil
+}
+
+// OpenExport 打开导出文件，链接在有效期内可以重复下载
+func (s *privacyService) OpenExport(ctx context.Context, plain string) (*ExportDownload, error) {
+	if plain == "" {
+		return nil, ErrInvalidExportToken
+	}
+
+	record, err := s.tokenRepo.GetByHash(ctx, model.TokenPurposeDataExport, token.Hash(plain))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidExportToken
+		}
+		return nil, err
+	}
+	if record.IsExpired() {
+		return nil, ErrInvalidExportToken
+	}
+
+	content, err := s.storage.Default().Get(ctx, exportKey(record.UserID, record.TokenHash))
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, ErrInvalidExportToken
+		}
+		return nil, fmt.Errorf("failed to open export archive: %w", err)
+	}
+
+	return &ExportDownload{
+		FileName:  fmt.Sprintf("data-export-%d-%s.zip", record.UserID, record.CreatedAt.Format("20060102")),
+		Content:   content,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
+
+// ScheduleDeletion 申请注销账户，已申请时返回原计划时间
+func (s *privacyService) ScheduleDeletion(ctx context.Context, userID uint, req *DeleteAccountRequest) (time.Time, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !user.CheckPassword(req.Password) {
+		return time.Time{}, ErrInvalidPassword
+	}
+	if user.DeletionScheduledAt != nil {
+		return *user.DeletionScheduledAt, nil
+	}
+
+	scheduledAt := time.Now().Add(s.deletionGracePeriod())
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, &scheduledAt); err != nil {
+		return time.Time{}, err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.deletion_schedule",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(userID),
+		Before:       map[string]interface{}{"deletion_scheduled_at": nil},
+		After:        map[string]interface{}{"deletion_scheduled_at": scheduledAt},
+	})
+
+	// 通知邮件发送失败不影响注销申请
+	if err := s.mailer.Send(ctx, s.deletionMessage(user, scheduledAt)); err != nil {
+		s.logger.Error("Failed to send account deletion email", "user_id", userID, "error", err)
+	}
+
+	s.logger.Info("Account deletion scheduled", "user_id", userID, "scheduled_at", scheduledAt)
+	return scheduledAt, nil
+}
+
+// CancelDeletion 在冷静期内撤销注销申请
+func (s *privacyService) CancelDeletion(ctx context.Context, userID uint) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil {
+		return ErrDeletionNotScheduled
+	}
+
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, nil); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.deletion_cancel",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(userID),
+		Before:       map[string]interface{}{"deletion_scheduled_at": user.DeletionScheduledAt},
+		After:        map[string]interface{}{"deletion_scheduled_at": nil},
+	})
+
+	s.logger.Info("Account deletion cancelled", "user_id", userID)
+	return nil
+}
+
+// PurgeDue 匿名化一批到期账户，单个账户失败时记录错误并在下次执行时重试
+func (s *privacyService) PurgeDue(ctx context.Context) (int, error) {
+	var reassignTo uint
+	if s.config.Privacy.ContentPolicy == ContentPolicyReassign {
+		target, err := s.userRepo.GetByUsername(ctx, s.config.Privacy.ReassignTo)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get user %q to reassign content to: %w", s.config.Privacy.ReassignTo, err)
+		}
+		reassignTo = target.ID
+	}
+
+	users, err := s.userRepo.ListDueForDeletion(ctx, time.Now(), purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range users {
+		if user.ID == reassignTo {
+			s.logger.Warn("Skipping deletion of the user content is reassigned to", "user_id", user.ID)
+			continue
+		}
+		if err := s.anonymize(ctx, user, reassignTo); err != nil {
+			s.logger.Error("Failed to anonymize user", "user_id", user.ID, "error", err)
+			continue
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		s.logger.Info("Scheduled account deletions processed", "count", purged)
+	}
+	return purged, nil
+}
+
+// CleanupExports 删除生成时间早于下载链接有效期的导出文件
+func (s *privacyService) CleanupExports(ctx context.Context) (int, error) {
+	deleted, err := s.deleteExports(ctx, exportKeyPrefix, time.Now().Add(-s.exportExpiration()))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		s.logger.Info("Expired data exports cleaned", "count", deleted)
+	}
+	return deleted, nil
+}
+
+// anonymize 撤销会话、处理文件和导出文件后匿名化账户
+func (s *privacyService) anonymize(ctx context.Context, user *model.User, reassignTo uint) error {
+	if _, err := s.tokens.RevokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	files, _, err := s.fileRepo.GetByOwner(ctx, user.ID, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var reassignedSize int64
+	for _, file := range files {
+		if reassignTo != 0 {
+			reassignedSize += file.Size
+			continue
+		}
+		// 通过文件服务删除以释放共享的物理对象
+		if err := s.files.Delete(ctx, file.ID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.deleteExports(ctx, exportKey(user.ID, ""), time.Now()); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Anonymize(ctx, user.ID, reassignTo); err != nil {
+		return err
+	}
+
+	// 转移的文件计入接收用户的已用空间
+	if reassignedSize > 0 {
+		if _, err := s.quotaRepo.Reserve(ctx, reassignTo, reassignedSize, repository.UnlimitedQuota); err != nil {
+			s.logger.Warn("Failed to transfer storage usage", "user_id", reassignTo, "size", reassignedSize, "error", err)
+		}
+	}
+
+	// 审计日志只记录处理方式，不能保留被擦除的个人信息
+	policy := ContentPolicyDelete
+	if reassignTo != 0 {
+		policy = ContentPolicyReassign
+	}
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.anonymize",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(user.ID),
+		After: map[string]interface{}{
+			"user_id":        user.ID,
+			"content_policy": policy,
+			"reassign_to":    reassignTo,
+		},
+	})
+
+	s.logger.Info("User anonymized", "user_id", user.ID, "reassign_to", reassignTo)
+	return nil
+}
+
+// deleteExports 删除键名以 prefix 开头且生成时间早于 before 的导出文件
+func (s *privacyService) deleteExports(ctx context.Context, prefix string, before time.Time) (int, error) {
+	driver := s.storage.Default()
+
+	var keys []string
+	err := driver.List(ctx, prefix, func(info *storage.ObjectInfo) error {
+		if info.LastModified.Before(before) {
+			keys = append(keys, info.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if err := driver.Delete(ctx, key); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// getUser 获取用户，不存在时返回 ErrUserNotFound
+func (s *privacyService) getUser(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// exportExpiration 导出下载链接有效期
+func (s *privacyService) exportExpiration() time.Duration {
+	ttl := time.Duration(s.config.Privacy.ExportExpiration) * time.Second
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return ttl
+}
+
+// exportInterval 两次申请导出的最小间隔
+func (s *privacyService) exportInterval() time.Duration {
+	interval := time.Duration(s.config.Privacy.ExportInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return interval
+}
+
+// deletionGracePeriod 注销冷静期
+func (s *privacyService) deletionGracePeriod() time.Duration {
+	return time.Duration(s.config.Privacy.DeletionGracePeriod) * time.Second
+}
+
+// exportMessage 生成导出完成邮件
+func (s *privacyService) exportMessage(user *model.User, plain string) *mailer.Message {
+	link := strings.TrimRight(s.config.Email.LinkBaseURL, "/") + "/data-export?token=" + url.QueryEscape(plain)
+	hours := int(s.exportExpiration().Hours())
+
+	return &mailer.Message{
+		To:      []string{user.Email},
+		Subject: "个人数据导出已完成",
+		Text: fmt.Sprintf("%s，您好：\n\n您申请导出的个人数据已准备好，请在 %d 小时内打开以下链接下载：\n\n%s\n\n如果这不是您本人的操作，请立即修改密码。\n",
+			user.Username, hours, link),
+		HTML: fmt.Sprintf(`<p>%s，您好：</p><p>您申请导出的个人数据已准备好，请在 %d 小时内点击以下链接下载：</p><p><a href="%s">下载个人数据</a></p><p>如果这不是您本人的操作，请立即修改密码。</p>`,
+			html.EscapeString(user.Username), hours, html.EscapeString(link)),
+	}
+}
+
+// deletionMessage 生成注销申请确认邮件
+func (s *privacyService) deletionMessage(user *model.User, scheduledAt time.Time) *mailer.Message {
+	date := scheduledAt.Format("2006-01-02 15:04 MST")
+
+	return &mailer.Message{
+		To:      []string{user.Email},
+		Subject: "账户注销申请",
+		Text: fmt.Sprintf("%s，您好：\n\n我们收到了注销您账户的申请，您的个人数据将于 %s 之后被匿名化，且无法恢复。\n\n在此之前登录账户即可撤销注销申请。\n",
+			user.Username, date),
+		HTML: fmt.Sprintf(`<p>%s，您好：</p><p>我们收到了注销您账户的申请，您的个人数据将于 %s 之后被匿名化，且无法恢复。</p><p>在此之前登录账户即可撤销注销申请。</p>`,
+			html.EscapeString(user.Username), html.EscapeString(date)),
+	}
+}
+
+// buildExportArchive 将每个文件的内容编码为 JSON 并打包为 ZIP
+func buildExportArchive(entries map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, value := range entries {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		w, err := writer.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportKey 导出文件的存储键名，tokenHash 为空时返回用户导出文件的前缀
+func exportKey(userID uint, tokenHash string) string {
+	prefix := exportKeyPrefix + strconv.FormatUint(uint64(userID), 10) + "/"
+	if tokenHash == "" {
+		return prefix
+	}
+	return prefix + tokenHash + ".zip"
+}
+
+// exportRequestKey 申请导出限流的缓存键
+func exportRequestKey(userID uint) string {
+	return fmt.Sprintf("data_export:%d", userID)
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+)
+
+var (
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists 角色名称已存在
+	ErrRoleExists = errors.New("role already exists")
+	// ErrInvalidRoleName 角色名称格式不正确
+	ErrInvalidRoleName = errors.New("invalid role name")
+	// ErrInvalidPermission 权限格式不正确
+	ErrInvalidPermission = errors.New("invalid permission")
+	// ErrSystemRole 内置角色不能删除
+	ErrSystemRole = errors.New("system role cannot be deleted")
+	// ErrPermissionNotHeld 不能授予自己没有的权限
+	ErrPermissionNotHeld = errors.New("cannot grant permissions you do not hold")
+)
+
+var (
+	// permissionPattern 权限格式 resource:action[:scope]，与权限中间件一致
+	permissionPattern = regexp.MustCompile(`^([a-z_]+|\*):([a-z_]+|\*)(:(own|all|\*))?$`)
+	// roleNamePattern 角色名称格式
+	roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+)
+
+// roleService 角色与权限服务实现
+type roleService struct {
+	roleRepo        repository.RoleRepository
+	userRepo        repository.UserRepository
+	permissionCache PermissionCache
+	auditService    AuditService
+	logger          logger.Logger
+}
+
+// NewRoleService 创建角色与权限服务
+func NewRoleService(
+	roleRepo repository.RoleRepository,
+	userRepo repository.UserRepository,
+	permissionCache PermissionCache,
+	auditService AuditService,
+	logger logger.Logger,
+) RoleService {
+	return &roleService{
+		roleRepo:        roleRepo,
+		userRepo:        userRepo,
+		permissionCache: permissionCache,
+		auditService:    auditService,
+		logger:          logger,
+	}
+}
+
+// ListRoles 获取所有角色
+func (s *roleService) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+// GetRole 获取角色
+func (s *roleService) GetRole(ctx context.Context, id uint) (*model.Role, error) {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// CreateRole 创建自定义角色，角色的权限不能超出操作者自己的权限
+func (s *roleService) CreateRole(ctx context.Context, actorID uint, req *CreateRoleRequest) (*model.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidRoleName
+	}
+	permissions, err := parsePermissionStrings(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGrant(ctx, actorID, permissions); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.roleRepo.GetByName(ctx, name); err == nil {
+		return nil, ErrRoleExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	role := &model.Role{
+		Name:        name,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Description: strings.TrimSpace(req.Description),
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "role.create",
+		ResourceType: "role",
+		ResourceID:   auditResourceID(role.ID),
+		After:        roleAuditState(role),
+	})
+
+	s.logger.Info("Role created", "role_id", role.ID, "name", role.Name, "permissions", role.PermissionStrings())
+	return role, nil
+}
+
+// UpdateRole 更新角色信息和权限，权限变更后清除所有用户的权限缓存。
+// 修改权限时，操作者必须拥有新增和移除的全部权限
+func (s *roleService) UpdateRole(ctx context.Context, actorID, id uint, req *UpdateRoleRequest) (*model.Role, error) {
+	role, err := s.GetRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := roleAuditState(role)
+
+	var permissions []model.Permission
+	if req.Permissions != nil {
+		if permissions, err = parsePermissionStrings(req.Permissions); err != nil {
+			return nil, err
+		}
+		if err := s.checkGrant(ctx, actorID, changedPermissions(role.Permissions, permissions)); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.DisplayName != nil || req.Description != nil {
+		if req.DisplayName != nil {
+			role.DisplayName = strings.TrimSpace(*req.DisplayName)
+		}
+		if req.Description != nil {
+			role.Description = strings.TrimSpace(*req.Description)
+		}
+		if err := s.roleRepo.Update(ctx, role); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Permissions != nil {
+		if err := s.roleRepo.ReplacePermissions(ctx, role.ID, permissions); err != nil {
+			return nil, err
+		}
+		if err := s.permissionCache.ClearAllPermissions(); err != nil {
+			s.logger.Error("Failed to clear permission cache", "role_id", role.ID, "error", err)
+			return nil, fmt.Errorf("failed to clear permission cache: %w", err)
+		}
+		s.logger.Info("Role permissions updated", "role_id", role.ID, "name", role.Name, "permissions", req.Permissions)
+	}
+
+	updated, err := s.GetRole(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "role.update",
+		ResourceType: "role",
+		ResourceID:   auditResourceID(role.ID),
+		Before:       before,
+		After:        roleAuditState(updated),
+	})
+	return updated, nil
+}
+
+// DeleteRole 删除自定义角色，内置角色不能删除
+func (s *roleService) DeleteRole(ctx context.Context, id uint) error {
+	role, err := s.GetRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+
+	if err := s.roleRepo.Delete(ctx, role.ID); err != nil {
+		return err
+	}
+	if err := s.permissionCache.ClearAllPermissions(); err != nil {
+		s.logger.Error("Failed to clear permission cache", "role_id", role.ID, "error", err)
+		return fmt.Errorf("failed to clear permission cache: %w", err)
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "role.delete",
+		ResourceType: "role",
+		ResourceID:   auditResourceID(role.ID),
+		Before:       roleAuditState(role),
+	})
+
+	s.logger.Info("Role deleted", "role_id", role.ID, "name", role.Name)
+	return nil
+}
+
+// ListPermissions 获取所有已定义的权限
+func (s *roleService) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
+	return s.roleRepo.ListPermissions(ctx)
+}
+
+// GetUserRoles 获取用户通过 user_roles 分配的角色
+func (s *roleService) GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.ListUserRoles(ctx, userID)
+}
+
+// SetUserRoles 替换用户的全部角色并清除其权限缓存。不能修改自己的角色，
+// 操作者必须拥有新增和移除的角色的全部权限
+func (s *roleService) SetUserRoles(ctx context.Context, actorID, userID uint, req *SetUserRolesRequest) ([]*model.Role, error) {
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	previous, err := s.roleRepo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(req.Roles))
+	seen := make(map[string]bool, len(req.Roles))
+	for _, name := range req.Roles {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	roles, err := s.roleRepo.ListByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(names) {
+		found := make(map[string]bool, len(roles))
+		for _, role := range roles {
+			found[role.Name] = true
+		}
+		for _, name := range names {
+			if !found[name] {
+				return nil, fmt.Errorf("%w: %q", ErrRoleNotFound, name)
+			}
+		}
+	}
+
+	if err := s.checkGrant(ctx, actorID, changedRolePermissions(previous, roles)); err != nil {
+		return nil, err
+	}
+
+	roleIDs := make([]uint, 0, len(roles))
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.ID)
+	}
+	if err := s.roleRepo.SetUserRoles(ctx, userID, roleIDs); err != nil {
+		return nil, err
+	}
+	if err := s.permissionCache.ClearUserPermissions(userID); err != nil {
+		s.logger.Error("Failed to clear user permission cache", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to clear permission cache: %w", err)
+	}
+
+	s.auditService.Record(ctx, &AuditEntry{
+		Action:       "user.roles_update",
+		ResourceType: "user",
+		ResourceID:   auditResourceID(userID),
+		Before:       map[string]interface{}{"roles": roleNames(previous)},
+		After:        map[string]interface{}{"roles": roleNames(roles)},
+	})
+
+	s.logger.Info("User roles updated", "user_id", userID, "roles", names)
+	return s.roleRepo.ListUserRoles(ctx, userID)
+}
+
+// GetUserPermissions 获取用户所有角色的权限并集。
+// 用户没有分配任何角色时，回退到 users.role 对应的数据库角色，数据库中也没有时使用内置默认权限
+func (s *roleService) GetUserPermissions(ctx context.Context, userID uint, legacyRole string) ([]string, error) {
+	roles, err := s.roleRepo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 && legacyRole != "" {
+		role, err := s.roleRepo.GetByName(ctx, legacyRole)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			return append([]string(nil), model.DefaultRolePermissions[legacyRole]...), nil
+		}
+		roles = append(roles, role)
+	}
+
+	permissions := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		for _, permission := range role.PermissionStrings() {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// CheckGrant 检查操作者拥有指定的全部权限
+func (s *roleService) CheckGrant(ctx context.Context, actorID uint, permissions []string) error {
+	parsed, err := parsePermissionStrings(permissions)
+	if err != nil {
+		return err
+	}
+	return s.checkGrant(ctx, actorID, parsed)
+}
+
+// CheckAuthority 检查操作者拥有目标用户的全部有效权限，用于模拟登录等获得目标用户全部权限的操作
+func (s *roleService) CheckAuthority(ctx context.Context, actorID, userID uint) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	permissions, err := s.effectivePermissions(ctx, user)
+	if err != nil {
+		return err
+	}
+	return s.CheckGrant(ctx, actorID, permissions)
+}
+
+// effectivePermissions 获取用户的有效权限，users.role 为管理员时拥有所有权限，与权限中间件一致
+func (s *roleService) effectivePermissions(ctx context.Context, user *model.User) ([]string, error) {
+	if user.IsAdmin() {
+		return []string{"*:*"}, nil
+	}
+	return s.GetUserPermissions(ctx, user.ID, user.Role)
+}
+
+// checkGrant 检查操作者拥有要授予的全部权限，防止委派的角色管理员给自己或他人扩大权限
+func (s *roleService) checkGrant(ctx context.Context, actorID uint, permissions []model.Permission) error {
+	actor, err := s.getUser(ctx, actorID)
+	if err != nil {
+		return err
+	}
+	values, err := s.effectivePermissions(ctx, actor)
+	if err != nil {
+		return err
+	}
+	held, err := parsePermissionStrings(values)
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range permissions {
+		if !permissionsHeld(held, permission) {
+			s.logger.Warn("Permission grant denied", "actor_id", actorID, "permission", permission.String())
+			return fmt.Errorf("%w: %s", ErrPermissionNotHeld, permission.String())
+		}
+	}
+	return nil
+}
+
+// permissionsHeld 检查已有权限是否包含指定权限，通配符规则与权限中间件一致：
+// 资源为 * 时包含所有权限，操作为 * 时包含该资源的所有操作，范围为 * 时包含所有范围
+func permissionsHeld(held []model.Permission, permission model.Permission) bool {
+	for _, p := range held {
+		if p.Resource == "*" {
+			return true
+		}
+		if p.Resource != permission.Resource {
+			continue
+		}
+		if p.Action == "*" {
+			return true
+		}
+		if p.Action == permission.Action && (p.Scope == "*" || p.Scope == permission.Scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedPermissions 获取新旧权限列表中新增和移除的权限
+func changedPermissions(previous, next []model.Permission) []model.Permission {
+	count := make(map[string]int, len(previous)+len(next))
+	for _, p := range previous {
+		count[p.String()]++
+	}
+	for _, p := range next {
+		count[p.String()]--
+	}
+
+	changed := make([]model.Permission, 0)
+	for _, p := range append(append([]model.Permission(nil), previous...), next...) {
+		if count[p.String()] != 0 {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// changedRolePermissions 获取新增和移除的角色包含的全部权限
+func changedRolePermissions(previous, next []*model.Role) []model.Permission {
+	kept := make(map[string]bool, len(next))
+	for _, role := range next {
+		kept[role.Name] = true
+	}
+	held := make(map[string]bool, len(previous))
+	for _, role := range previous {
+		held[role.Name] = true
+	}
+
+	changed := make([]model.Permission, 0)
+	for _, role := range previous {
+		if !kept[role.Name] {
+			changed = append(changed, role.Permissions...)
+		}
+	}
+	for _, role := range next {
+		if !held[role.Name] {
+			changed = append(changed, role.Permissions...)
+		}
+	}
+	return changed
+}
+
+// roleAuditState 审计日志中记录的角色状态，权限以字符串表示
+func roleAuditState(role *model.Role) map[string]interface{} {
+	return map[string]interface{}{
+		"name":         role.Name,
+		"display_name": role.DisplayName,
+		"description":  role.Description,
+		"permissions":  role.PermissionStrings(),
+	}
+}
+
+// roleNames 获取角色名称列表
+func roleNames(roles []*model.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// getUser 获取用户，不存在时返回 ErrUserNotFound
+func (s *roleService) getUser(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// parsePermissionStrings 校验并解析 resource:action[:scope] 格式的权限，去除重复项
+func parsePermissionStrings(values []string) ([]model.Permission, error) {
+	permissions := make([]model.Permission, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		match := permissionPattern.FindStringSubmatch(value)
+		if match == nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPermission, value)
+		}
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		permissions = append(permissions, model.Permission{Resource: match[1], Action: match[2], Scope: match[4]})
+	}
+	return permissions, nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/token"
+)
+
+// maxUserAgentLength 保存的 User-Agent 最大长度
+const maxUserAgentLength = 500
+
+// Logout 撤销当前访问令牌及其所属会话
+func (s *tokenService) Logout(ctx context.Context, userID uint, sessionID, tokenID string) error {
+	if tokenID != "" {
+		if err := s.cache.Set(ctx, token.RevokedTokenKey(tokenID), "revoked", s.tokens.AccessTTL()); err != nil {
+			s.logger.Error("Failed to revoke access token", "user_id", userID, "jti", tokenID, "error", err)
+			return fmt.Errorf("failed to revoke token: %w", err)
+		}
+	}
+
+	// 不属于任何会话的访问令牌只需加入黑名单
+	if sessionID == "" {
+		return nil
+	}
+
+	session, err := s.sessionRepo.GetByFamilyID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.revokeFamily(ctx, sessionID); err != nil {
+		return err
+	}
+
+	s.logger.Info("User logged out", "user_id", userID, "session_id", session.ID)
+	return nil
+}
+
+// ListSessions 获取用户的有效会话
+func (s *tokenService) ListSessions(ctx context.Context, userID uint) ([]*model.UserSession, error) {
+	return s.sessionRepo.ListActiveByUser(ctx, userID)
+}
+
+// RevokeSession 撤销用户的指定会话
+func (s *tokenService) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID != userID || !session.IsActive() {
+		return ErrSessionNotFound
+	}
+
+	if err := s.revokeFamily(ctx, session.FamilyID); err != nil {
+		return err
+	}
+
+	s.logger.Info("User session revoked", "user_id", userID, "session_id", session.ID)
+	return nil
+}
+
+// RevokeAllSessions 撤销用户的所有会话，返回撤销的会话数量
+func (s *tokenService) RevokeAllSessions(ctx context.Context, userID uint) (int, error) {
+	count, err := s.revokeSessions(ctx, userID, "")
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("All user sessions revoked", "user_id", userID, "count", count)
+	return count, nil
+}
+
+// RevokeOtherSessions 撤销用户除当前会话外的所有会话，返回撤销的会话数量
+func (s *tokenService) RevokeOtherSessions(ctx context.Context, userID uint, sessionID string) (int, error) {
+	count, err := s.revokeSessions(ctx, userID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("Other user sessions revoked", "user_id", userID, "count", count)
+	return count, nil
+}
+
+// revokeSessions 撤销用户的会话，keepFamilyID 不为空时保留该会话
+func (s *tokenService) revokeSessions(ctx context.Context, userID uint, keepFamilyID string) (int, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, session := range sessions {
+		if keepFamilyID != "" && session.FamilyID == keepFamilyID {
+			continue
+		}
+		if err := s.revokeFamily(ctx, session.FamilyID); err != nil {
+			return 0, err
+		}
+		count++
+	}
+
+	// 会话功能上线前签发的刷新令牌没有会话记录
+	if err := s.tokenRepo.RevokeByUser(ctx, userID, keepFamilyID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// revokeFamily 撤销会话及其刷新令牌，并使该会话已签发的访问令牌失效
+func (s *tokenService) revokeFamily(ctx context.Context, familyID string) error {
+	if err := s.tokenRepo.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+
+	session, err := s.sessionRepo.GetByFamilyID(ctx, familyID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if session != nil {
+		if _, err := s.sessionRepo.Revoke(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+
+	// 访问令牌无法收回，在其最长有效期内通过会话黑名单拒绝
+	if err := s.cache.Set(ctx, token.RevokedSessionKey(familyID), "revoked", s.tokens.AccessTTL()); err != nil {
+		s.logger.Error("Failed to blacklist session", "family_id", familyID, "error", err)
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// touchSession 令牌轮换后更新会话，失败不影响已签发的令牌
+func (s *tokenService) touchSession(ctx context.Context, pair *TokenPair, client ClientInfo) {
+	session, err := s.sessionRepo.GetByFamilyID(ctx, pair.SessionID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// 会话功能上线前登录的令牌家族补建会话
+		session = &model.UserSession{UserID: pair.User.ID, FamilyID: pair.SessionID}
+		applySessionClient(session, pair, client)
+		err = s.sessionRepo.Create(ctx, session)
+	case err == nil:
+		applySessionClient(session, pair, client)
+		err = s.sessionRepo.Touch(ctx, session)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to update user session", "family_id", pair.SessionID, "error", err)
+	}
+}
+
+// applySessionClient 记录最新签发的令牌和客户端信息
+func applySessionClient(session *model.UserSession, pair *TokenPair, client ClientInfo) {
+	session.AccessJTI = pair.AccessTokenID
+	session.ExpiresAt = pair.RefreshExpiresAt
+	session.LastSeenAt = time.Now()
+
+	if client.IP != "" {
+		session.IP = client.IP
+	}
+	if client.UserAgent != "" {
+		userAgent := client.UserAgent
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		session.UserAgent = userAgent
+		session.Device = deviceName(userAgent)
+	}
+}
+
+// deviceName 根据 User-Agent 生成便于识别的设备名称，例如 "Chrome on macOS"
+func deviceName(userAgent string) string {
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/storage"
+)
+
+// storageGCBatchSize 每批加载的记录数量
+const storageGCBatchSize = 500
+
+// storageGCService 存储一致性检查服务实现
+type storageGCService struct {
+	fileRepo    repository.FileRepository
+	blobRepo    repository.FileBlobRepository
+	sessionRepo repository.UploadSessionRepository
+	quota       StorageQuotaService
+	storage     *storage.Manager
+	logger      logger.Logger
+}
+
+// storageRefs 数据库中引用的对象
+type storageRefs struct {
+	paths    map[string]bool // 被文件或对象记录引用的对象键
+	hashes   map[string]bool // 仍在使用的内容哈希，用于判断图片变体是否孤立
+	blobRefs map[uint]int    // 每个对象记录实际被引用的文件数
+	sessions map[string]bool // 已查询过的上传会话是否存在
+}
+
+// NewStorageGCService 创建存储一致性检查服务
+func NewStorageGCService(
+	fileRepo repository.FileRepository,
+	blobRepo repository.FileBlobRepository,
+	sessionRepo repository.UploadSessionRepository,
+	quota StorageQuotaService,
+	storage *storage.Manager,
+	logger logger.Logger,
+) StorageGCService {
+	return &storageGCService{
+		fileRepo:    fileRepo,
+		blobRepo:    blobRepo,
+		sessionRepo: sessionRepo,
+		quota:       quota,
+		storage:     storage,
+		logger:      logger,
+	}
+}
+
+// Run 检查存储对象与数据库记录的一致性，按选项修复或删除异常数据
+func (s *storageGCService) Run(ctx context.Context, opts StorageGCOptions) ([]*StorageGCReport, error) {
+	names := s.storage.Names()
+	if opts.StorageType != "" {
+		names = []string{opts.StorageType}
+	}
+
+	reports := make([]*StorageGCReport, 0, len(names))
+	for _, name := range names {
+		driver, err := s.storage.Get(name)
+		if err != nil {
+			return reports, err
+		}
+
+		report, err := s.check(ctx, name, driver, opts)
+		if err != nil {
+			s.logger.Error("Storage consistency check failed", "storage_type", name, "error", err)
+			return reports, fmt.Errorf("failed to check storage %s: %w", name, err)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// check 检查单个存储驱动
+func (s *storageGCService) check(ctx context.Context, name string, driver storage.Storage, opts StorageGCOptions) (*StorageGCReport, error) {
+	report := &StorageGCReport{
+		StorageType:        name,
+		DryRun:             opts.DryRun,
+		OrphanedObjects:    []OrphanedObject{},
+		MissingObjects:     []MissingObject{},
+		RefCountMismatches: []RefCountMismatch{},
+		StartedAt:          time.Now(),
+	}
+	// 宽限期内的对象和引用可能属于进行中的上传，不视为异常
+	cutoff := report.StartedAt.Add(-opts.GracePeriod)
+
+	refs := &storageRefs{
+		paths:    make(map[string]bool),
+		hashes:   make(map[string]bool),
+		blobRefs: make(map[uint]int),
+		sessions: make(map[string]bool),
+	}
+
+	if err := s.checkFiles(ctx, name, driver, opts, refs, report); err != nil {
+		return nil, err
+	}
+	if err := s.checkBlobs(ctx, name, driver, opts, cutoff, refs, report); err != nil {
+		return nil, err
+	}
+	if err := s.checkObjects(ctx, driver, opts, cutoff, refs, report); err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+	fields := []interface{}{
+		"storage_type", name,
+		"dry_run", opts.DryRun,
+		"scanned_objects", report.ScannedObjects,
+		"scanned_files", report.ScannedFiles,
+		"orphaned_objects", len(report.OrphanedObjects),
+		"missing_objects", len(report.MissingObjects),
+		"ref_count_mismatches", len(report.RefCountMismatches),
+		"deleted_objects", report.DeletedObjects,
+		"purged_files", report.PurgedFiles,
+		"fixed_blobs", report.FixedBlobs,
+	}
+	if len(report.OrphanedObjects)+len(report.MissingObjects)+len(report.RefCountMismatches)+len(report.Errors) > 0 {
+		s.logger.Warn("Storage inconsistencies found", fields...)
+	} else {
+		s.logger.Info("Storage consistency check finished", fields...)
+	}
+
+	return report, nil
+}
+
+// checkFiles 检查文件记录的对象是否存在，并统计每个对象记录的实际引用数
+func (s *storageGCService) checkFiles(ctx context.Context, name string, driver storage.Storage, opts StorageGCOptions, refs *storageRefs, report *StorageGCReport) error {
+	exists := make(map[string]bool)
+
+	var afterID uint
+	for {
+		files, err := s.fileRepo.ListByStorage(ctx, name, afterID, storageGCBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			report.ScannedFiles++
+
+			found, checked := exists[file.Path]
+			if !checked {
+				_, err := driver.Stat(ctx, file.Path)
+				if err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+					report.Errors = append(report.Errors, fmt.Sprintf("stat %s: %v", file.Path, err))
+					found = true
+				} else {
+					found = err == nil
+				}
+				exists[file.Path] = found
+			}
+
+			if !found {
+				report.MissingObjects = append(report.MissingObjects, MissingObject{
+					FileID:  file.ID,
+					BlobID:  file.BlobID,
+					OwnerID: file.OwnerID,
+					Path:    file.Path,
+				})
+				if !opts.DryRun && opts.PurgeMissing {
+					if err := s.purgeFile(ctx, file); err != nil {
+						report.Errors = append(report.Errors, fmt.Sprintf("purge file %d: %v", file.ID, err))
+					} else {
+						report.PurgedFiles++
+						continue
+					}
+				}
+			}
+
+			// 早期记录的路径与驱动遍历返回的键名可能不同
+			refs.paths[storage.ObjectKey(driver, file.Path)] = true
+			refs.hashes[file.Hash] = true
+			if file.BlobID != 0 {
+				refs.blobRefs[file.BlobID]++
+			}
+		}
+
+		if len(files) < storageGCBatchSize {
+			return nil
+		}
+		afterID = files[len(files)-1].ID
+	}
+}
+
+// checkBlobs 核对对象记录的引用计数，无引用的对象记录会被删除
+func (s *storageGCService) checkBlobs(ctx context.Context, name string, driver storage.Storage, opts StorageGCOptions, cutoff time.Time, refs *storageRefs, report *StorageGCReport) error {
+	var afterID uint
+	for {
+		blobs, err := s.blobRepo.ListByStorage(ctx, name, afterID, storageGCBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, blob := range blobs {
+			actual := refs.blobRefs[blob.ID]
+			if actual == blob.RefCount || blob.UpdatedAt.After(cutoff) {
+				refs.paths[storage.ObjectKey(driver, blob.Path)] = true
+				refs.hashes[blob.Hash] = true
+				continue
+			}
+
+			report.RefCountMismatches = append(report.RefCountMismatches, RefCountMismatch{
+				BlobID:   blob.ID,
+				Path:     blob.Path,
+				Recorded: blob.RefCount,
+				Actual:   actual,
+			})
+
+			fixed := false
+			if !opts.DryRun {
+				// 以记录的计数作为条件，期间有新的引用时放弃修正
+				fixed, err = s.blobRepo.SetRefCount(ctx, blob.ID, blob.RefCount, actual)
+				if err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("fix blob %d: %v", blob.ID, err))
+				} else if fixed {
+					report.FixedBlobs++
+				}
+			}
+
+			// 记录已删除时对象随后作为孤立对象处理
+			if !fixed || actual > 0 {
+				refs.paths[storage.ObjectKey(driver, blob.Path)] = true
+				refs.hashes[blob.Hash] = true
+			}
+		}
+
+		if len(blobs) < storageGCBatchSize {
+			return nil
+		}
+		afterID = blobs[len(blobs)-1].ID
+	}
+}
+
+// checkObjects 遍历存储对象，找出没有记录引用的孤立对象
+func (s *storageGCService) checkObjects(ctx context.Context, driver storage.Storage, opts StorageGCOptions, cutoff time.Time, refs *storageRefs, report *StorageGCReport) error {
+	return driver.List(ctx, "", func(info *storage.ObjectInfo) error {
+		report.ScannedObjects++
+		if info.LastModified.After(cutoff) {
+			return nil
+		}
+
+		orphaned, err := s.isOrphaned(ctx, info.Key, refs)
+		if err != nil {
+			return err
+		}
+		if !orphaned {
+			return nil
+		}
+
+		report.OrphanedObjects = append(report.OrphanedObjects, OrphanedObject{
+			Key:          info.Key,
+			Size:         info.Size,
+			LastModified: info.LastModified,
+		})
+		if opts.DryRun || !opts.DeleteOrphans {
+			return nil
+		}
+
+		if err := driver.Delete(ctx, info.Key); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("delete %s: %v", info.Key, err))
+			return nil
+		}
+		report.DeletedObjects++
+		return nil
+	})
+}
+
+// isOrphaned 判断对象是否没有被任何记录引用
+func (s *storageGCService) isOrphaned(ctx context.Context, key string, refs *storageRefs) (bool, error) {
+	switch {
+	case strings.HasPrefix(key, resumableKeyPrefix):
+		// 上传分片属于仍存在的会话时由会话清理任务负责
+		uploadID, _, _ := strings.Cut(strings.TrimPrefix(key, resumableKeyPrefix), "/")
+		exists, checked := refs.sessions[uploadID]
+		if !checked {
+			_, err := s.sessionRepo.GetByUploadID(ctx, uploadID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, err
+			}
+			exists = err == nil
+			refs.sessions[uploadID] = exists
+		}
+		return !exists, nil
+
+	case strings.HasPrefix(key, exportKeyPrefix):
+		// 个人数据导出文件由导出清理任务按有效期删除
+		return false, nil
+
+	case strings.HasPrefix(key, variantKeyPrefix):
+		hash, _, _ := strings.Cut(strings.TrimPrefix(key, variantKeyPrefix), "/")
+		return !refs.hashes[hash], nil
+
+	default:
+		return !refs.paths[key], nil
+	}
+}
+
+// purgeFile 删除对象已丢失的文件记录，并释放对象引用与配额
+func (s *storageGCService) purgeFile(ctx context.Context, file *model.File) error {
+	// 重新获取记录，避免与并发删除重复释放引用
+	if _, err := s.fileRepo.GetByID(ctx, file.ID); err != nil {
+		return err
+	}
+
+	if err := s.fileRepo.Delete(ctx, file.ID); err != nil {
+		return err
+	}
+
+	if file.BlobID != 0 {
+		if _, _, err := s.blobRepo.Release(ctx, file.BlobID); err != nil {
+			s.logger.Warn("Failed to release file blob", "blob_id", file.BlobID, "error", err)
+		}
+	}
+	if err := s.quota.Release(ctx, file.OwnerID, file.Size); err != nil {
+		s.logger.Warn("Failed to release storage quota", "user_id", file.OwnerID, "size", file.Size, "error", err)
+	}
+
+	s.logger.Info("Purged file record with missing object", "file_id", file.ID, "path", file.Path)
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// ErrQuotaExceeded 超出存储配额
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// storageQuotaService 存储配额服务实现
+type storageQuotaService struct {
+	quotaRepo repository.StorageQuotaRepository
+	userRepo  repository.UserRepository
+	config    *config.Config
+	logger    logger.Logger
+}
+
+// NewStorageQuotaService 创建存储配额服务
+func NewStorageQuotaService(
+	quotaRepo repository.StorageQuotaRepository,
+	userRepo repository.UserRepository,
+	config *config.Config,
+	logger logger.Logger,
+) StorageQuotaService {
+	return &storageQuotaService{
+		quotaRepo: quotaRepo,
+		userRepo:  userRepo,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// GetUsage 获取用户存储用量与配额
+func (s *storageQuotaService) GetUsage(ctx context.Context, userID uint) (*StorageUsageResponse, error) {
+	quota, limit, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return newStorageUsageResponse(quota, limit), nil
+}
+
+// Check 检查剩余配额是否足够，不占用配额
+func (s *storageQuotaService) Check(ctx context.Context, userID uint, size int64) error {
+	quota, limit, err := s.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if limit != repository.UnlimitedQuota && quota.UsedBytes+size > limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Reserve 占用配额，超出时返回 ErrQuotaExceeded
+func (s *storageQuotaService) Reserve(ctx context.Context, userID uint, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+
+	_, limit, err := s.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.quotaRepo.Reserve(ctx, userID, size, limit)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.logger.Warn("Storage quota exceeded", "user_id", userID, "size", size, "limit", limit)
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Release 释放已占用的配额
+func (s *storageQuotaService) Release(ctx context.Context, userID uint, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	return s.quotaRepo.Release(ctx, userID, size)
+}
+
+// SetQuota 设置用户配额，为空时恢复角色默认配额
+func (s *storageQuotaService) SetQuota(ctx context.Context, userID uint, quotaBytes *int64) (*StorageUsageResponse, error) {
+	if quotaBytes != nil && *quotaBytes < 0 {
+		return nil, fmt.Errorf("quota must not be negative")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	quota, err := s.quotaRepo.SetQuota(ctx, userID, quotaBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Storage quota updated", "user_id", userID, "quota_bytes", quotaBytes)
+	return newStorageUsageResponse(quota, s.limit(quota, user)), nil
+}
+
+// load 获取用户配额记录与生效的配额
+func (s *storageQuotaService) load(ctx context.Context, userID uint) (*model.StorageQuota, int64, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user for storage quota", "user_id", userID, "error", err)
+		return nil, 0, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	quota, err := s.quotaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return quota, s.limit(quota, user), nil
+}
+
+// limit 获取生效的配额，管理员单独设置的配额优先于角色默认配额，
+// 单独设置为 0 时不允许占用空间，只有角色未配置默认配额时才不限制
+func (s *storageQuotaService) limit(quota *model.StorageQuota, user *model.User) int64 {
+	if quota.QuotaBytes != nil {
+		return *quota.QuotaBytes
+	}
+	if limit := s.config.Upload.RoleQuotas[user.Role]; limit > 0 {
+		return limit
+	}
+	return repository.UnlimitedQuota
+}
+
+// newStorageUsageResponse 创建存储用量响应
+func newStorageUsageResponse(quota *model.StorageQuota, limit int64) *StorageUsageResponse {
+	response := &StorageUsageResponse{
+		UserID:         quota.UserID,
+		UsedBytes:      quota.UsedBytes,
+		QuotaBytes:     0,
+		RemainingBytes: -1,
+		IsOverride:     quota.QuotaBytes != nil,
+	}
+	if limit != repository.UnlimitedQuota {
+		response.QuotaBytes = limit
+		response.RemainingBytes = max(0, limit-quota.UsedBytes)
+	}
+	return response
+}
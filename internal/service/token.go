@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/token"
+)
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌无效、已过期或已撤销
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用，整个令牌家族已撤销
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrSessionNotFound 会话不存在、已撤销或不属于当前用户
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// tokenService 令牌与登录会话服务实现
+type tokenService struct {
+	tokenRepo   repository.RefreshTokenRepository
+	sessionRepo repository.UserSessionRepository
+	userRepo    repository.UserRepository
+	tokens      *token.Manager
+	cache       cache.Cache
+	logger      logger.Logger
+}
+
+// NewTokenService 创建令牌服务
+func NewTokenService(
+	tokenRepo repository.RefreshTokenRepository,
+	sessionRepo repository.UserSessionRepository,
+	userRepo repository.UserRepository,
+	tokens *token.Manager,
+	cache cache.Cache,
+	logger logger.Logger,
+) TokenService {
+	return &tokenService{
+		tokenRepo:   tokenRepo,
+		sessionRepo: sessionRepo,
+		userRepo:    userRepo,
+		tokens:      tokens,
+		cache:       cache,
+		logger:      logger,
+	}
+}
+
+// Issue 登录时创建会话，签发访问令牌和新家族的刷新令牌，mfa 表示本次登录通过了两步验证
+func (s *tokenService) Issue(ctx context.Context, user *model.User, client ClientInfo, mfa bool) (*TokenPair, error) {
+	pair, err := s.issue(ctx, user, uuid.NewString(), mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.UserSession{UserID: user.ID, FamilyID: pair.SessionID}
+	applySessionClient(session, pair, client)
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return pair, nil
+}
+
+// Refresh 轮换刷新令牌，已轮换的令牌再次使用时撤销整个家族
+func (s *tokenService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	current, err := s.tokenRepo.GetByHash(ctx, token.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if current.RevokedAt != nil || current.IsExpired() {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.RotatedAt != nil {
+		return nil, s.revokeReused(ctx, current)
+	}
+
+	// 并发刷新时只有一个请求能完成轮换，其余视为重复使用
+	rotated, err := s.tokenRepo.MarkRotated(ctx, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, s.revokeReused(ctx, current)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get user for token refresh", "user_id", current.UserID, "error", err)
+		return nil, ErrInvalidRefreshToken
+	}
+	if !user.IsActive() {
+		s.logger.Warn("Token refresh for inactive user", "user_id", user.ID, "status", user.Status)
+		if err := s.revokeFamily(ctx, current.FamilyID); err != nil {
+			s.logger.Warn("Failed to revoke refresh token family", "family_id", current.FamilyID, "error", err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 两步验证结果沿用登录时的记录，之后才开启两步验证的会话刷新后仍不算通过验证
+	pair, err := s.issue(ctx, user, current.FamilyID, current.MFA && user.MFAEnabled)
+	if err != nil {
+		return nil, err
+	}
+	s.touchSession(ctx, pair, client)
+
+	s.logger.Info("Refresh token rotated", "user_id", user.ID, "family_id", current.FamilyID)
+	return pair, nil
+}
+
+// CleanupExpired 删除已过期的刷新令牌和会话
+func (s *tokenService) CleanupExpired(ctx context.Context) (int64, error) {
+	now := time.Now()
+	tokens, err := s.tokenRepo.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	sessions, err := s.sessionRepo.DeleteExpired(ctx, now)
+	if err != nil {
+		return tokens, err
+	}
+	if tokens+sessions > 0 {
+		s.logger.Info("Expired refresh tokens and sessions cleaned", "tokens", tokens, "sessions", sessions)
+	}
+	return tokens + sessions, nil
+}
+
+// issue 签发访问令牌和刷新令牌
+func (s *tokenService) issue(ctx context.Context, user *model.User, familyID string, mfa bool) (*TokenPair, error) {
+	accessToken, claims, err := s.tokens.Issue(user, familyID, mfa)
+	if err != nil {
+		s.logger.Error("Failed to issue access token", "user_id", user.ID, "error", err)
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, err := token.NewOpaque()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: token.Hash(refreshToken),
+		MFA:       mfa,
+		ExpiresAt: time.Now().Add(s.tokens.RefreshTTL()),
+	}
+	if err := s.tokenRepo.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &TokenPair{
+		User:             user,
+		SessionID:        familyID,
+		AccessToken:      accessToken,
+		AccessTokenID:    claims.ID,
+		AccessExpiresAt:  claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// revokeReused 撤销被重复使用的令牌所在家族
+func (s *tokenService) revokeReused(ctx context.Context, reused *model.RefreshToken) error {
+	s.logger.Warn("Refresh token reuse detected, revoking token family",
+		"user_id", reused.UserID,
+		"family_id", reused.FamilyID)
+
+	if err := s.revokeFamily(ctx, reused.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/storage"
+)
+
+var (
+	// ErrUploadNotFound 上传会话不存在或不属于当前用户
+	ErrUploadNotFound = errors.New("upload session not found")
+	// ErrUploadExpired 上传会话已过期
+	ErrUploadExpired = errors.New("upload session expired")
+	// ErrUploadOffsetMismatch 分片偏移量与服务器记录不一致
+	ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrUploadTooLarge 上传数据超过声明的文件大小
+	ErrUploadTooLarge = errors.New("upload exceeds declared size")
+	// ErrUploadIncomplete 数据未全部上传，无法完成
+	ErrUploadIncomplete = errors.New("upload is incomplete")
+	// ErrUploadClosed 上传会话已完成、正在完成或已取消
+	ErrUploadClosed = errors.New("upload session is no longer accepting data")
+)
+
+const (
+	// expiredSessionBatchSize 每次清理的过期会话数量
+	expiredSessionBatchSize = 100
+	// resumableKeyPrefix 上传分片对象键前缀
+	resumableKeyPrefix = "resumable/"
+)
+
+// resumableUploadService 断点续传上传服务实现
+type resumableUploadService struct {
+	sessionRepo repository.UploadSessionRepository
+	fileService FileService
+	quota       StorageQuotaService
+	storage     *storage.Manager
+	config      *config.Config
+	logger      logger.Logger
+}
+
+// NewResumableUploadService 创建断点续传上传服务
+func NewResumableUploadService(
+	sessionRepo repository.UploadSessionRepository,
+	fileService FileService,
+	quota StorageQuotaService,
+	storage *storage.Manager,
+	config *config.Config,
+	logger logger.Logger,
+) ResumableUploadService {
+	return &resumableUploadService{
+		sessionRepo: sessionRepo,
+		fileService: fileService,
+		quota:       quota,
+		storage:     storage,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+// Create 创建上传会话
+func (s *resumableUploadService) Create(ctx context.Context, req *CreateUploadSessionRequest) (*model.UploadSession, error) {
+	if req.FileName == "" {
+		return nil, fmt.Errorf("file name is required")
+	}
+	if req.FileSize <= 0 {
+		return nil, fmt.Errorf("file size must be greater than 0")
+	}
+
+	// 创建会话时按声明信息预先校验上传策略，避免上传完成后才被拒绝
+	if err := newUploadPolicy(s.config.Upload).checkDeclared(req.FileName, req.MimeType, req.OwnerRole, req.FileSize); err != nil {
+		return nil, err
+	}
+	// 配额在上传完成时才占用，这里仅预先检查剩余空间
+	if err := s.quota.Check(ctx, req.OwnerID, req.FileSize); err != nil {
+		return nil, err
+	}
+
+	storageType := req.StorageType
+	if storageType == "" {
+		storageType = s.storage.DefaultName()
+	}
+	if _, err := s.storage.Get(storageType); err != nil {
+		return nil, err
+	}
+
+	session := &model.UploadSession{
+		UploadID:    uuid.New().String(),
+		OwnerID:     req.OwnerID,
+		OwnerRole:   req.OwnerRole,
+		FileName:    req.FileName,
+		MimeType:    req.MimeType,
+		TotalSize:   req.FileSize,
+		StorageType: storageType,
+		IsPublic:    req.IsPublic,
+		Status:      model.UploadStatusUploading,
+		ExpiresAt:   s.nextExpiry(),
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		s.logger.Error("Failed to create upload session", "file_name", req.FileName, "error", err)
+		return nil, fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	s.logger.Info("Upload session created", "upload_id", session.UploadID, "owner_id", session.OwnerID, "total_size", session.TotalSize)
+	return session, nil
+}
+
+// Get 获取上传会话
+func (s *resumableUploadService) Get(ctx context.Context, ownerID uint, uploadID string) (*model.UploadSession, error) {
+	session, err := s.sessionRepo.GetByUploadID(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, err
+	}
+
+	// 不暴露其他用户的会话
+	if session.OwnerID != ownerID {
+		return nil, ErrUploadNotFound
+	}
+	if session.IsExpired() {
+		return nil, ErrUploadExpired
+	}
+
+	return session, nil
+}
+
+// WriteChunk 在指定偏移量写入分片
+func (s *resumableUploadService) WriteChunk(ctx context.Context, ownerID uint, uploadID string, offset int64, r io.Reader) (*model.UploadSession, error) {
+	session, err := s.Get(ctx, ownerID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != model.UploadStatusUploading {
+		return nil, ErrUploadClosed
+	}
+	if offset != session.UploadedSize {
+		return nil, ErrUploadOffsetMismatch
+	}
+
+	driver, err := s.storage.Get(session.StorageType)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多读取一个字节用于判断是否超过声明的大小
+	remaining := session.Remaining()
+	counter := &countingReader{reader: io.LimitReader(r, remaining+1)}
+	key := fmt.Sprintf("%s%s/%020d-%s", resumableKeyPrefix, session.UploadID, offset, uuid.New().String())
+
+	if err := driver.Put(ctx, key, counter, -1, "application/octet-stream"); err != nil {
+		s.logger.Error("Failed to store upload chunk", "upload_id", uploadID, "offset", offset, "error", err)
+		return nil, fmt.Errorf("failed to store upload chunk: %w", err)
+	}
+
+	if counter.count > remaining {
+		s.deleteObject(ctx, driver, key)
+		return nil, ErrUploadTooLarge
+	}
+	if counter.count == 0 {
+		s.deleteObject(ctx, driver, key)
+		return session, nil
+	}
+
+	part := &model.UploadPart{
+		PartOffset: offset,
+		Size:       counter.count,
+		StorageKey: key,
+	}
+
+	expiresAt := s.nextExpiry()
+	applied, err := s.sessionRepo.AppendPart(ctx, session.ID, offset, part, expiresAt)
+	if err != nil {
+		s.deleteObject(ctx, driver, key)
+		return nil, err
+	}
+	if !applied {
+		// 并发请求已推进偏移量
+		s.deleteObject(ctx, driver, key)
+		return nil, ErrUploadOffsetMismatch
+	}
+
+	session.UploadedSize += counter.count
+	session.ExpiresAt = expiresAt
+	return session, nil
+}
+
+// Complete 合并分片并生成文件记录
+func (s *resumableUploadService) Complete(ctx context.Context, ownerID uint, uploadID string) (*model.File, error) {
+	session, err := s.Get(ctx, ownerID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != model.UploadStatusUploading {
+		return nil, ErrUploadClosed
+	}
+	if !session.IsComplete() {
+		return nil, ErrUploadIncomplete
+	}
+
+	// 标记为完成中，防止重复完成
+	applied, err := s.sessionRepo.UpdateStatus(ctx, session.ID, model.UploadStatusUploading, model.UploadStatusCompleting, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return nil, ErrUploadClosed
+	}
+
+	driver, err := s.storage.Get(session.StorageType)
+	if err != nil {
+		s.revertStatus(ctx, session)
+		return nil, err
+	}
+
+	parts, err := s.sessionRepo.GetParts(ctx, session.ID)
+	if err != nil {
+		s.revertStatus(ctx, session)
+		return nil, err
+	}
+
+	file, err := s.fileService.Upload(ctx, &UploadRequest{
+		FileName:    session.FileName,
+		FileSize:    session.TotalSize,
+		MimeType:    session.MimeType,
+		Reader:      &partsReader{ctx: ctx, driver: driver, parts: parts},
+		IsPublic:    session.IsPublic,
+		StorageType: session.StorageType,
+		OwnerID:     session.OwnerID,
+		OwnerRole:   session.OwnerRole,
+	})
+	if err != nil {
+		s.logger.Error("Failed to finalize upload", "upload_id", uploadID, "error", err)
+		s.revertStatus(ctx, session)
+		return nil, fmt.Errorf("failed to finalize upload: %w", err)
+	}
+
+	if _, err := s.sessionRepo.UpdateStatus(ctx, session.ID, model.UploadStatusCompleting, model.UploadStatusCompleted, &file.ID); err != nil {
+		s.logger.Warn("Failed to mark upload session completed", "upload_id", uploadID, "error", err)
+	}
+
+	// 分片已合并，清理临时数据
+	for _, part := range parts {
+		s.deleteObject(ctx, driver, part.StorageKey)
+	}
+
+	s.logger.Info("Upload session completed", "upload_id", uploadID, "file_id", file.ID)
+	return file, nil
+}
+
+// Abort 取消上传并清理分片
+func (s *resumableUploadService) Abort(ctx context.Context, ownerID uint, uploadID string) error {
+	session, err := s.sessionRepo.GetByUploadID(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUploadNotFound
+		}
+		return err
+	}
+	if session.OwnerID != ownerID {
+		return ErrUploadNotFound
+	}
+
+	applied, err := s.sessionRepo.UpdateStatus(ctx, session.ID, model.UploadStatusUploading, model.UploadStatusAborted, nil)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return ErrUploadClosed
+	}
+
+	if err := s.purge(ctx, session); err != nil {
+		// 清理失败时由过期清理任务兜底
+		s.logger.Warn("Failed to purge aborted upload", "upload_id", uploadID, "error", err)
+	}
+
+	s.logger.Info("Upload session aborted", "upload_id", uploadID)
+	return nil
+}
+
+// CleanupExpired 清理过期的上传会话及其分片
+func (s *resumableUploadService) CleanupExpired(ctx context.Context) (int, error) {
+	sessions, err := s.sessionRepo.ListExpired(ctx, time.Now(), expiredSessionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	cleaned := 0
+	for _, session := range sessions {
+		if err := s.purge(ctx, session); err != nil {
+			s.logger.Warn("Failed to purge expired upload", "upload_id", session.UploadID, "error", err)
+			continue
+		}
+		cleaned++
+	}
+
+	if cleaned > 0 {
+		s.logger.Info("Expired upload sessions cleaned", "count", cleaned)
+	}
+	return cleaned, nil
+}
+
+// purge 删除会话的全部分片对象和数据库记录
+func (s *resumableUploadService) purge(ctx context.Context, session *model.UploadSession) error {
+	parts, err := s.sessionRepo.GetParts(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+
+	if driver, err := s.storage.Get(session.StorageType); err == nil {
+		for _, part := range parts {
+			if err := driver.Delete(ctx, part.StorageKey); err != nil {
+				return fmt.Errorf("failed to delete upload part: %w", err)
+			}
+		}
+	}
+
+	return s.sessionRepo.Delete(ctx, session.ID)
+}
+
+// revertStatus 完成失败时恢复为上传中状态，允许客户端重试
+func (s *resumableUploadService) revertStatus(ctx context.Context, session *model.UploadSession) {
+	if _, err := s.sessionRepo.UpdateStatus(ctx, session.ID, model.UploadStatusCompleting, model.UploadStatusUploading, nil); err != nil {
+		s.logger.Warn("Failed to revert upload session status", "upload_id", session.UploadID, "error", err)
+	}
+}
+
+// deleteObject 删除临时对象，失败时仅记录日志
+func (s *resumableUploadService) deleteObject(ctx context.Context, driver storage.Storage, key string) {
+	if err := driver.Delete(ctx, key); err != nil {
+		s.logger.Warn("Failed to delete upload chunk", "key", key, "error", err)
+	}
+}
+
+// nextExpiry 计算会话的下一次过期时间
+func (s *resumableUploadService) nextExpiry() time.Time {
+	expiration := s.config.Upload.ResumableExpiration
+	if expiration <= 0 {
+		expiration = 86400
+	}
+	return time.Now().Add(time.Duration(expiration) * time.Second)
+}
+
+// partsReader 按顺序读取所有分片的读取器，同一时间只打开一个分片
+type partsReader struct {
+	ctx     context.Context
+	driver  storage.Storage
+	parts   []*model.UploadPart
+	current io.ReadCloser
+}
+
+// Read 读取当前分片，读完后自动切换到下一个分片
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			reader, err := r.driver.Get(r.ctx, r.parts[0].StorageKey)
+			if err != nil {
+				return 0, fmt.Errorf("failed to open upload part: %w", err)
+			}
+			r.current = reader
+			r.parts = r.parts[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
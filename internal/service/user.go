@@ -2,10 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 
 	"vibe-coding-starter/internal/config"
@@ -13,33 +14,71 @@ import (
 	"vibe-coding-starter/internal/repository"
 	"vibe-coding-starter/pkg/cache"
 	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/password"
+	"vibe-coding-starter/pkg/secretbox"
 )
 
+// ErrUserNotFound 用户不存在
+var ErrUserNotFound = errors.New("user not found")
+
 // userService 用户服务实现
 type userService struct {
-	userRepo repository.UserRepository
-	cache    cache.Cache
-	logger   logger.Logger
-	config   *config.Config
+	userRepo     repository.UserRepository
+	mfaRepo      repository.MFARepository
+	identityRepo repository.UserIdentityRepository
+	tokens       TokenService
+	verification EmailVerificationService
+	invitations  InvitationService
+	secrets      *secretbox.Box
+	providers    *oidc.Registry
+	policy       *password.Policy
+	cache        cache.Cache
+	logger       logger.Logger
+	config       *config.Config
 }
 
 // NewUserService 创建用户服务
 func NewUserService(
 	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	identityRepo repository.UserIdentityRepository,
+	tokens TokenService,
+	verification EmailVerificationService,
+	invitations InvitationService,
+	secrets *secretbox.Box,
+	providers *oidc.Registry,
+	policy *password.Policy,
 	cache cache.Cache,
 	logger logger.Logger,
 	config *config.Config,
 ) UserService {
 	return &userService{
-		userRepo: userRepo,
-		cache:    cache,
-		logger:   logger,
-		config:   config,
+		userRepo:     userRepo,
+		mfaRepo:      mfaRepo,
+		identityRepo: identityRepo,
+		tokens:       tokens,
+		verification: verification,
+		invitations:  invitations,
+		secrets:      secrets,
+		providers:    providers,
+		policy:       policy,
+		cache:        cache,
+		logger:       logger,
+		config:       config,
 	}
 }
 
 // Register 用户注册
 func (s *userService) Register(ctx context.Context, req *RegisterRequest) (*model.User, error) {
+	invitation, err := s.registrationInvitation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.policy.Validate(req.Password, req.Username); err != nil {
+		return nil, err
+	}
+
 	// 检查邮箱是否已存在
 	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil && err != gorm.ErrRecordNotFound {
@@ -70,23 +109,82 @@ func (s *userService) Register(ctx context.Context, req *RegisterRequest) (*mode
 		Status:   model.UserStatusActive,
 	}
 
+	// 邀请邮件发送到受邀邮箱，打开邀请链接即证明拥有该邮箱，不需要再次验证
+	if invitation != nil {
+		now := time.Now()
+		user.Role = invitation.Role
+		user.EmailVerifiedAt = &now
+		if err := s.invitations.Accept(ctx, invitation, user); err != nil {
+			return nil, err
+		}
+
+		s.logger.Info("User registered with invitation", "user_id", user.ID, "invitation_id", invitation.ID)
+		return user, nil
+	}
+
+	// 需要验证邮箱时，用户在确认验证链接后才会激活
+	requireVerification := s.config.Auth.RequireEmailVerification
+	if requireVerification {
+		user.Status = model.UserStatusInactive
+	}
+
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		s.logger.Error("Failed to create user", "email", req.Email, "error", err)
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	// 验证邮件发送失败不影响注册，用户可以通过重发接口再次获取
+	if requireVerification {
+		if err := s.verification.Send(ctx, user); err != nil {
+			s.logger.Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+
 	s.logger.Info("User registered successfully", "user_id", user.ID, "email", user.Email)
 	return user, nil
 }
 
+// registrationInvitation 按注册方式检查是否允许注册，携带邀请令牌时返回对应的邀请
+func (s *userService) registrationInvitation(ctx context.Context, req *RegisterRequest) (*model.Invitation, error) {
+	switch registrationMode(s.config) {
+	case RegistrationModeClosed:
+		return nil, ErrRegistrationClosed
+	case RegistrationModeInviteOnly:
+		if req.InvitationToken == "" {
+			return nil, ErrInvitationRequired
+		}
+	}
+	if req.InvitationToken == "" {
+		return nil, nil
+	}
+
+	invitation, err := s.invitations.Lookup(ctx, req.InvitationToken)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(req.Email), invitation.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+	return invitation, nil
+}
+
 // Login 用户登录
 func (s *userService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
 	s.logger.Debug("Login attempt", "username", req.Username)
 
+	// 连续失败被锁定的账户在锁定期间不验证密码
+	if err := s.checkLoginLock(ctx, req.Username); err != nil {
+		s.logger.Warn("Login attempt while locked", "username", req.Username)
+		return nil, err
+	}
+
 	// 获取用户
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		s.logger.Error("Failed to get user by username", "username", req.Username, "error", err)
+		if lockErr := s.recordLoginFailure(ctx, req.Username); lockErr != nil {
+			return nil, lockErr
+		}
 		return nil, fmt.Errorf("invalid username or password")
 	}
 
@@ -94,6 +192,16 @@ func (s *userService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 
 	// 检查用户状态
 	if !user.IsActive() {
+		// 密码正确时才提示邮箱未验证，避免他人借此探测账户状态
+		if user.IsPendingVerification() {
+			if user.CheckPassword(req.Password) {
+				s.logger.Info("Login attempt before email verification", "user_id", user.ID)
+				return nil, ErrEmailNotVerified
+			}
+			if lockErr := s.recordLoginFailure(ctx, req.Username); lockErr != nil {
+				return nil, lockErr
+			}
+		}
 		s.logger.Warn("User account is not active", "user_id", user.ID, "username", user.Username, "status", user.Status)
 		return nil, fmt.Errorf("user account is not active")
 	}
@@ -103,15 +211,51 @@ func (s *userService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 	// 验证密码
 	if !user.CheckPassword(req.Password) {
 		s.logger.Warn("Invalid password attempt", "user_id", user.ID, "username", user.Username)
+		if lockErr := s.recordLoginFailure(ctx, req.Username); lockErr != nil {
+			return nil, lockErr
+		}
 		return nil, fmt.Errorf("invalid username or password")
 	}
 
 	s.logger.Debug("Password verified successfully", "user_id", user.ID)
+	s.resetLoginFailures(ctx, req.Username)
+	s.rehashPassword(ctx, user, req.Password)
 
-	// 生成 JWT Token
-	token, err := s.generateJWTToken(user)
+	// 开启两步验证的用户需要再提交验证码才能获得令牌
+	if user.MFAEnabled {
+		return s.startMFALogin(ctx, user)
+	}
+
+	return s.completeLogin(ctx, user, req.Client, false)
+}
+
+// rehashPassword 密码哈希使用了其他算法或过时的参数时，用登录时提交的明文重新计算
+func (s *userService) rehashPassword(ctx context.Context, user *model.User, plain string) {
+	if !user.PasswordNeedsRehash() {
+		return
+	}
+
+	// 更新失败不影响本次登录，下次登录时会再次尝试
+	hashedPassword, err := user.HashPassword(plain)
+	if err != nil {
+		s.logger.Error("Failed to rehash password", "user_id", user.ID, "error", err)
+		return
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		s.logger.Error("Failed to update rehashed password", "user_id", user.ID, "error", err)
+		return
+	}
+	user.Password = hashedPassword
+
+	s.logger.Info("Password rehashed with current parameters", "user_id", user.ID)
+}
+
+// completeLogin 签发令牌并记录登录时间，mfa 表示本次登录通过了两步验证
+func (s *userService) completeLogin(ctx context.Context, user *model.User, client ClientInfo, mfa bool) (*LoginResponse, error) {
+	// 签发访问令牌和刷新令牌
+	pair, err := s.tokens.Issue(ctx, user, client, mfa)
 	if err != nil {
-		s.logger.Error("Failed to generate JWT token", "user_id", user.ID, "error", err)
+		s.logger.Error("Failed to issue tokens", "user_id", user.ID, "error", err)
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
@@ -123,10 +267,21 @@ func (s *userService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 
 	s.logger.Info("User logged in successfully", "user_id", user.ID, "email", user.Email)
 
-	return &LoginResponse{
-		User:  user.ToPublic(),
-		Token: token,
-	}, nil
+	return newLoginResponse(pair), nil
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌
+func (s *userService) RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*LoginResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pair, err := s.tokens.Refresh(ctx, req.RefreshToken, req.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLoginResponse(pair), nil
 }
 
 // GetProfile 获取用户资料
@@ -194,12 +349,19 @@ func (s *userService) ChangePassword(ctx context.Context, userID uint, req *Chan
 		s.logger.Warn("Invalid old password attempt", "user_id", userID)
 		return fmt.Errorf("invalid old password")
 	}
+	if err := s.policy.Validate(req.NewPassword, user.Username); err != nil {
+		return err
+	}
 
-	// 设置新密码
-	user.Password = req.NewPassword // 密码会在 BeforeUpdate 钩子中加密
+	// 加密新密码
+	hashedPassword, err := user.HashPassword(req.NewPassword)
+	if err != nil {
+		s.logger.Error("Failed to hash password", "user_id", userID, "error", err)
+		return fmt.Errorf("failed to update password: %w", err)
+	}
 
 	// 保存更新
-	if err := s.userRepo.Update(ctx, user); err != nil {
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
 		s.logger.Error("Failed to update user password", "user_id", userID, "error", err)
 		return fmt.Errorf("failed to update password: %w", err)
 	}
@@ -238,18 +400,13 @@ func (s *userService) DeleteUser(ctx context.Context, userID uint) error {
 	return nil
 }
 
-// generateJWTToken 生成 JWT Token
-func (s *userService) generateJWTToken(user *model.User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id":  user.ID,
-		"email":    user.Email,
-		"username": user.Username,
-		"role":     user.Role,
-		"exp":      time.Now().Add(time.Duration(s.config.JWT.Expiration) * time.Second).Unix(),
-		"iat":      time.Now().Unix(),
-		"iss":      s.config.JWT.Issuer,
+// newLoginResponse 创建登录响应
+func newLoginResponse(pair *TokenPair) *LoginResponse {
+	return &LoginResponse{
+		User:         pair.User.ToPublic(),
+		Token:        pair.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(pair.AccessExpiresAt).Round(time.Second).Seconds()),
+		RefreshToken: pair.RefreshToken,
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWT.Secret))
 }
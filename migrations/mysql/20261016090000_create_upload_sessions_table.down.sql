@@ -0,0 +1,7 @@
+-- Rollback Migration: create_upload_sessions_table
+-- Created: 20261016090000
+-- Description: Drop upload_sessions and upload_parts tables
+
+
+DROP TABLE IF EXISTS upload_parts;
+DROP TABLE IF EXISTS upload_sessions;
@@ -0,0 +1,47 @@
+-- Migration: create_upload_sessions_table
+-- Created: 20261016090000
+-- Description: Create upload_sessions and upload_parts tables for resumable uploads
+
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    upload_id VARCHAR(64) NOT NULL,
+    owner_id BIGINT UNSIGNED NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(100),
+    total_size BIGINT NOT NULL,
+    uploaded_size BIGINT NOT NULL DEFAULT 0,
+    storage_type VARCHAR(20) NOT NULL,
+    is_public BOOLEAN DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL,
+    file_id BIGINT UNSIGNED NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+
+    -- Indexes
+    UNIQUE KEY uk_upload_sessions_upload_id (upload_id),
+    KEY idx_upload_sessions_owner_id (owner_id),
+    KEY idx_upload_sessions_status (status),
+    KEY idx_upload_sessions_expires_at (expires_at),
+    KEY idx_upload_sessions_deleted_at (deleted_at),
+
+    -- Foreign keys
+    CONSTRAINT fk_upload_sessions_owner_id FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS upload_parts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    session_id BIGINT UNSIGNED NOT NULL,
+    part_offset BIGINT NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- Indexes
+    KEY idx_upload_parts_session_id (session_id),
+
+    -- Foreign keys
+    CONSTRAINT fk_upload_parts_session_id FOREIGN KEY (session_id) REFERENCES upload_sessions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
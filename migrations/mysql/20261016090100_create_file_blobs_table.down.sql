@@ -0,0 +1,16 @@
+-- Rollback Migration: create_file_blobs_table
+-- Created: 20261016090100
+-- Description: Drop file_blobs table and restore unique file hashes
+
+
+-- Restoring the unique index requires removing duplicate logical records first
+DELETE f1 FROM files f1
+JOIN files f2 ON f1.hash = f2.hash AND f1.id > f2.id;
+
+ALTER TABLE files
+    DROP INDEX idx_files_blob_id,
+    DROP INDEX idx_files_hash,
+    DROP COLUMN blob_id,
+    ADD UNIQUE KEY uk_files_hash (hash);
+
+DROP TABLE IF EXISTS file_blobs;
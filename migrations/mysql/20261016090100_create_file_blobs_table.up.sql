@@ -0,0 +1,36 @@
+-- Migration: create_file_blobs_table
+-- Created: 20261016090100
+-- Description: Split physical file objects into reference-counted file_blobs and allow duplicate file hashes
+
+
+CREATE TABLE IF NOT EXISTS file_blobs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    storage_type VARCHAR(20) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    path VARCHAR(500) NOT NULL,
+    size BIGINT NOT NULL,
+    ref_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    -- Indexes
+    UNIQUE KEY uk_file_blobs_storage_hash (storage_type, hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE files
+    ADD COLUMN blob_id BIGINT UNSIGNED NULL AFTER hash,
+    DROP INDEX uk_files_hash,
+    ADD KEY idx_files_hash (hash),
+    ADD KEY idx_files_blob_id (blob_id);
+
+-- Backfill blobs for existing files (existing uploads were stored locally)
+INSERT INTO file_blobs (storage_type, hash, path, size, ref_count)
+SELECT 'local', hash, MIN(path), MAX(size), COUNT(*)
+FROM files
+WHERE deleted_at IS NULL
+GROUP BY hash;
+
+UPDATE files f
+JOIN file_blobs b ON b.hash = f.hash AND b.storage_type = 'local'
+SET f.blob_id = b.id
+WHERE f.deleted_at IS NULL;
@@ -0,0 +1,26 @@
+-- Migration: create_storage_quotas_table
+-- Created: 20261016090200
+-- Description: Create storage_quotas table for per-user storage usage and quota overrides
+
+
+CREATE TABLE IF NOT EXISTS storage_quotas (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    used_bytes BIGINT NOT NULL DEFAULT 0,
+    quota_bytes BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    -- Indexes
+    UNIQUE KEY uk_storage_quotas_user_id (user_id),
+
+    -- Foreign keys
+    CONSTRAINT fk_storage_quotas_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Backfill usage from existing files
+INSERT INTO storage_quotas (user_id, used_bytes)
+SELECT owner_id, SUM(size)
+FROM files
+WHERE deleted_at IS NULL
+GROUP BY owner_id;
@@ -0,0 +1,6 @@
+-- Rollback Migration: create_refresh_tokens_table
+-- Created: 20261016090300
+-- Description: Drop refresh_tokens table
+
+
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,27 @@
+-- Migration: create_user_sessions_table
+-- Created: 20261016090400
+-- Description: Create user_sessions table for login session management
+
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    family_id VARCHAR(36) NOT NULL,
+    access_jti VARCHAR(36),
+    device VARCHAR(100),
+    ip VARCHAR(45),
+    user_agent VARCHAR(500),
+    last_seen_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    -- Indexes
+    UNIQUE KEY uk_user_sessions_family_id (family_id),
+    INDEX idx_user_sessions_user_id (user_id),
+    INDEX idx_user_sessions_expires_at (expires_at),
+
+    -- Foreign keys
+    CONSTRAINT fk_user_sessions_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,23 @@
+-- Migration: create_one_time_tokens_table
+-- Created: 20261016090500
+-- Description: Create one_time_tokens table for emailed single-use tokens such as password reset
+
+
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- Indexes
+    UNIQUE KEY uk_one_time_tokens_token_hash (token_hash),
+    INDEX idx_one_time_tokens_user_id (user_id),
+    INDEX idx_one_time_tokens_purpose (purpose),
+    INDEX idx_one_time_tokens_expires_at (expires_at),
+
+    -- Foreign keys
+    CONSTRAINT fk_one_time_tokens_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,6 @@
+-- Rollback Migration: add_email_verified_at_to_users
+-- Created: 20261016090600
+-- Description: Drop email_verified_at column from users table
+
+
+ALTER TABLE users DROP COLUMN email_verified_at;
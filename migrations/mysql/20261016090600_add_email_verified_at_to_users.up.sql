@@ -0,0 +1,9 @@
+-- Migration: add_email_verified_at_to_users
+-- Created: 20261016090600
+-- Description: Add email_verified_at column to users table for email verification on registration
+
+
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL AFTER last_login;
+
+-- 已有用户注册时无需验证邮箱，视为已验证
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
@@ -0,0 +1,8 @@
+-- Rollback Migration: create_user_mfa_tables
+-- Created: 20261016090700
+-- Description: Drop user_mfa and mfa_recovery_codes tables and mfa_enabled column from users table
+
+
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
+ALTER TABLE users DROP COLUMN mfa_enabled;
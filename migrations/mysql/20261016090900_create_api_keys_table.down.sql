@@ -0,0 +1,6 @@
+-- Rollback Migration: create_api_keys_table
+-- Created: 20261016090900
+-- Description: Drop api_keys table
+
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,9 @@
+-- Rollback Migration: create_rbac_tables
+-- Created: 20261016091000
+-- Description: Drop user_roles, role_permissions, permissions and roles tables
+
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
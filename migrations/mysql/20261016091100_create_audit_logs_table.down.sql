@@ -0,0 +1,6 @@
+-- Rollback Migration: create_audit_logs_table
+-- Created: 20261016091100
+-- Description: Drop audit_logs table
+
+
+DROP TABLE IF EXISTS audit_logs;
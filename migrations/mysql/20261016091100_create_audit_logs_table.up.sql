@@ -0,0 +1,25 @@
+-- Migration: create_audit_logs_table
+-- Created: 20261016091100
+-- Description: Create audit_logs table for security-relevant and admin actions
+
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    actor_id BIGINT UNSIGNED NULL,
+    impersonator_id BIGINT UNSIGNED NULL,
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(100),
+    changes TEXT,
+    ip VARCHAR(45),
+    user_agent VARCHAR(255),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- Indexes
+    INDEX idx_audit_logs_actor_id (actor_id),
+    INDEX idx_audit_logs_action (action),
+    INDEX idx_audit_logs_resource (resource_type, resource_id),
+    INDEX idx_audit_logs_request_id (request_id),
+    INDEX idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,7 @@
+-- Rollback Migration: add_deletion_scheduled_at_to_users
+-- Created: 20261016091200
+-- Description: Drop deletion_scheduled_at column from users table
+
+
+DROP INDEX idx_users_deletion_scheduled_at ON users;
+ALTER TABLE users DROP COLUMN deletion_scheduled_at;
@@ -0,0 +1,27 @@
+-- Migration: create_invitations_table
+-- Created: 20261016091300
+-- Description: Create invitations table for invite-only registration
+
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    email VARCHAR(100) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    invited_by BIGINT UNSIGNED NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP NULL,
+    accepted_by BIGINT UNSIGNED NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    -- Indexes
+    UNIQUE KEY uk_invitations_token_hash (token_hash),
+    INDEX idx_invitations_email (email),
+    INDEX idx_invitations_invited_by (invited_by),
+
+    -- Foreign keys
+    CONSTRAINT fk_invitations_invited_by FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_invitations_accepted_by FOREIGN KEY (accepted_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,6 @@
+-- Migration: add_owner_role_to_upload_sessions
+-- Created: 20261016091400
+-- Description: Add owner_role column to upload_sessions so finalizing a resumable upload applies the owner's role size limit
+
+
+ALTER TABLE upload_sessions ADD COLUMN owner_role VARCHAR(20) NULL AFTER owner_id;
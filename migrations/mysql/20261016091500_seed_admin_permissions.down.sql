@@ -0,0 +1,9 @@
+-- Rollback Migration: seed_admin_permissions
+-- Created: 20261016091500
+-- Description: Remove the admin endpoint permissions and their role assignments
+
+
+DELETE FROM role_permissions WHERE permission_id IN (
+    SELECT id FROM permissions WHERE scope = 'all' OR resource IN ('role', 'audit', 'invitation', 'department')
+);
+DELETE FROM permissions WHERE scope = 'all' OR resource IN ('role', 'audit', 'invitation', 'department');
@@ -0,0 +1,33 @@
+-- Migration: seed_admin_permissions
+-- Created: 20261016091500
+-- Description: Seed the permissions required by the admin endpoints so custom roles can be granted access
+
+
+INSERT IGNORE INTO permissions (resource, action, scope, description) VALUES
+('user', 'read', 'all', '查看所有用户'),
+('user', 'create', 'all', '创建用户'),
+('user', 'update', 'all', '修改用户状态、解锁和重置密码'),
+('user', 'delete', 'all', '删除用户'),
+('user', 'impersonate', 'all', '模拟用户登录'),
+('article', 'read', 'all', '查看所有文章'),
+('article', 'update', 'all', '修改所有文章'),
+('article', 'delete', 'all', '删除所有文章'),
+('file', 'read', 'all', '查看所有文件'),
+('file', 'delete', 'all', '删除所有文件'),
+('quota', 'read', 'all', '查看用户存储用量'),
+('quota', 'update', 'all', '设置用户存储配额'),
+('session', 'read', 'all', '查看用户会话'),
+('session', 'delete', 'all', '撤销用户会话'),
+('role', 'read', '', '查看角色和权限'),
+('role', 'create', '', '创建角色'),
+('role', 'update', '', '修改角色'),
+('role', 'delete', '', '删除角色'),
+('role', 'assign', '', '分配用户角色'),
+('audit', 'read', '', '查看审计日志'),
+('invitation', 'read', '', '查看注册邀请'),
+('invitation', 'create', '', '创建注册邀请'),
+('invitation', 'delete', '', '撤销注册邀请'),
+('department', 'read', '', '查看部门'),
+('department', 'create', '', '创建部门'),
+('department', 'update', '', '修改部门'),
+('department', 'delete', '', '删除部门');
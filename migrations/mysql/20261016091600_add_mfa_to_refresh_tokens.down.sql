@@ -0,0 +1,6 @@
+-- Rollback Migration: add_mfa_to_refresh_tokens
+-- Created: 20261016091600
+-- Description: Drop mfa column from refresh_tokens table
+
+
+ALTER TABLE refresh_tokens DROP COLUMN mfa;
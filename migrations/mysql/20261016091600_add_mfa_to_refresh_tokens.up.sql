@@ -0,0 +1,6 @@
+-- Migration: add_mfa_to_refresh_tokens
+-- Created: 20261016091600
+-- Description: Add mfa column to refresh_tokens so access tokens issued on rotation only carry the mfa claim when the login passed two-factor verification
+
+
+ALTER TABLE refresh_tokens ADD COLUMN mfa BOOLEAN NOT NULL DEFAULT FALSE AFTER token_hash;
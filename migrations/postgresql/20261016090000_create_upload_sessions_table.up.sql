@@ -0,0 +1,45 @@
+-- Migration: create_upload_sessions_table
+-- Created: 20261016090000
+-- Description: Create upload_sessions and upload_parts tables for resumable uploads
+
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    upload_id VARCHAR(64) NOT NULL,
+    owner_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(100),
+    total_size BIGINT NOT NULL,
+    uploaded_size BIGINT NOT NULL DEFAULT 0,
+    storage_type VARCHAR(20) NOT NULL,
+    is_public BOOLEAN DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL,
+    file_id BIGINT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+
+    CONSTRAINT uk_upload_sessions_upload_id UNIQUE (upload_id),
+    CONSTRAINT fk_upload_sessions_owner_id FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create indexes for upload_sessions table
+CREATE INDEX idx_upload_sessions_owner_id ON upload_sessions(owner_id);
+CREATE INDEX idx_upload_sessions_status ON upload_sessions(status);
+CREATE INDEX idx_upload_sessions_expires_at ON upload_sessions(expires_at);
+CREATE INDEX idx_upload_sessions_deleted_at ON upload_sessions(deleted_at);
+
+CREATE TABLE IF NOT EXISTS upload_parts (
+    id BIGSERIAL PRIMARY KEY,
+    session_id BIGINT NOT NULL,
+    part_offset BIGINT NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_upload_parts_session_id FOREIGN KEY (session_id) REFERENCES upload_sessions(id) ON DELETE CASCADE
+);
+
+-- Create indexes for upload_parts table
+CREATE INDEX idx_upload_parts_session_id ON upload_parts(session_id);
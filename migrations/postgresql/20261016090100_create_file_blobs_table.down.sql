@@ -0,0 +1,16 @@
+-- Rollback Migration: create_file_blobs_table
+-- Created: 20261016090100
+-- Description: Drop file_blobs table and restore unique file hashes
+
+
+-- Restoring the unique constraint requires removing duplicate logical records first
+DELETE FROM files f1
+USING files f2
+WHERE f1.hash = f2.hash AND f1.id > f2.id;
+
+DROP INDEX IF EXISTS idx_files_blob_id;
+DROP INDEX IF EXISTS idx_files_hash;
+ALTER TABLE files DROP COLUMN IF EXISTS blob_id;
+ALTER TABLE files ADD CONSTRAINT uk_files_hash UNIQUE (hash);
+
+DROP TABLE IF EXISTS file_blobs;
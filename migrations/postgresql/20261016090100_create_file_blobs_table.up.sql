@@ -0,0 +1,36 @@
+-- Migration: create_file_blobs_table
+-- Created: 20261016090100
+-- Description: Split physical file objects into reference-counted file_blobs and allow duplicate file hashes
+
+
+CREATE TABLE IF NOT EXISTS file_blobs (
+    id BIGSERIAL PRIMARY KEY,
+    storage_type VARCHAR(20) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    path VARCHAR(500) NOT NULL,
+    size BIGINT NOT NULL,
+    ref_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_file_blobs_storage_hash UNIQUE (storage_type, hash)
+);
+
+ALTER TABLE files DROP CONSTRAINT IF EXISTS uk_files_hash;
+ALTER TABLE files ADD COLUMN blob_id BIGINT;
+
+-- Create indexes for files table
+CREATE INDEX idx_files_hash ON files(hash);
+CREATE INDEX idx_files_blob_id ON files(blob_id);
+
+-- Backfill blobs for existing files (existing uploads were stored locally)
+INSERT INTO file_blobs (storage_type, hash, path, size, ref_count)
+SELECT 'local', hash, MIN(path), MAX(size), COUNT(*)
+FROM files
+WHERE deleted_at IS NULL
+GROUP BY hash;
+
+UPDATE files
+SET blob_id = b.id
+FROM file_blobs b
+WHERE b.hash = files.hash AND b.storage_type = 'local' AND files.deleted_at IS NULL;
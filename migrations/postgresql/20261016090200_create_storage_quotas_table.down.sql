@@ -0,0 +1,6 @@
+-- Rollback Migration: create_storage_quotas_table
+-- Created: 20261016090200
+-- Description: Drop storage_quotas table
+
+
+DROP TABLE IF EXISTS storage_quotas;
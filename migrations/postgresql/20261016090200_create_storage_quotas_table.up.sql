@@ -0,0 +1,23 @@
+-- Migration: create_storage_quotas_table
+-- Created: 20261016090200
+-- Description: Create storage_quotas table for per-user storage usage and quota overrides
+
+
+CREATE TABLE IF NOT EXISTS storage_quotas (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    used_bytes BIGINT NOT NULL DEFAULT 0,
+    quota_bytes BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_storage_quotas_user_id UNIQUE (user_id),
+    CONSTRAINT fk_storage_quotas_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Backfill usage from existing files
+INSERT INTO storage_quotas (user_id, used_bytes)
+SELECT owner_id, SUM(size)
+FROM files
+WHERE deleted_at IS NULL
+GROUP BY owner_id;
@@ -0,0 +1,6 @@
+-- Rollback Migration: create_user_sessions_table
+-- Created: 20261016090400
+-- Description: Drop user_sessions table
+
+
+DROP TABLE IF EXISTS user_sessions;
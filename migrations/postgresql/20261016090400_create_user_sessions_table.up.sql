@@ -0,0 +1,25 @@
+-- Migration: create_user_sessions_table
+-- Created: 20261016090400
+-- Description: Create user_sessions table for login session management
+
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    family_id VARCHAR(36) NOT NULL,
+    access_jti VARCHAR(36),
+    device VARCHAR(100),
+    ip VARCHAR(45),
+    user_agent VARCHAR(500),
+    last_seen_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_user_sessions_family_id UNIQUE (family_id),
+    CONSTRAINT fk_user_sessions_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX idx_user_sessions_expires_at ON user_sessions(expires_at);
@@ -0,0 +1,6 @@
+-- Rollback Migration: create_one_time_tokens_table
+-- Created: 20261016090500
+-- Description: Drop one_time_tokens table
+
+
+DROP TABLE IF EXISTS one_time_tokens;
@@ -0,0 +1,21 @@
+-- Migration: create_one_time_tokens_table
+-- Created: 20261016090500
+-- Description: Create one_time_tokens table for emailed single-use tokens such as password reset
+
+
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_one_time_tokens_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_one_time_tokens_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_one_time_tokens_user_id ON one_time_tokens(user_id);
+CREATE INDEX idx_one_time_tokens_purpose ON one_time_tokens(purpose);
+CREATE INDEX idx_one_time_tokens_expires_at ON one_time_tokens(expires_at);
@@ -0,0 +1,6 @@
+-- Rollback Migration: create_user_identities_table
+-- Created: 20261016090800
+-- Description: Drop user_identities table
+
+
+DROP TABLE IF EXISTS user_identities;
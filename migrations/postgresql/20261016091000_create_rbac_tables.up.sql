@@ -0,0 +1,79 @@
+-- Migration: create_rbac_tables
+-- Created: 20261016091000
+-- Description: Create roles, permissions, role_permissions and user_roles tables and seed the built-in roles
+
+
+CREATE TABLE IF NOT EXISTS roles (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    display_name VARCHAR(100),
+    description VARCHAR(255),
+    is_system BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_roles_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id BIGSERIAL PRIMARY KEY,
+    resource VARCHAR(50) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    scope VARCHAR(20) NOT NULL DEFAULT '',
+    description VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_permissions_resource_action_scope UNIQUE (resource, action, scope)
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id BIGINT NOT NULL,
+    permission_id BIGINT NOT NULL,
+
+    PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT fk_role_permissions_role_id FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission_id FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_role_permissions_permission_id ON role_permissions(permission_id);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, role_id),
+    CONSTRAINT fk_user_roles_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_roles_role_id FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_roles_role_id ON user_roles(role_id);
+
+-- Built-in roles
+INSERT INTO roles (name, display_name, description, is_system) VALUES
+('admin', '管理员', '系统管理员，拥有所有权限', TRUE),
+('user', '普通用户', '普通用户，拥有基本权限', TRUE);
+
+INSERT INTO permissions (resource, action, scope, description) VALUES
+('*', '*', '', '所有权限'),
+('user', 'read', 'own', '查看自己的资料'),
+('user', 'update', 'own', '修改自己的资料'),
+('article', 'read', '', '查看文章'),
+('article', 'create', '', '创建文章'),
+('article', 'update', 'own', '修改自己的文章'),
+('article', 'delete', 'own', '删除自己的文章'),
+('file', 'read', '', '查看文件'),
+('file', 'upload', '', '上传文件'),
+('file', 'delete', 'own', '删除自己的文件');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.resource = '*' AND p.action = '*'
+WHERE r.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.resource <> '*'
+WHERE r.name = 'user';
+
+-- Existing users keep their legacy role
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u JOIN roles r ON r.name = u.role;
@@ -0,0 +1,24 @@
+-- Migration: create_audit_logs_table
+-- Created: 20261016091100
+-- Description: Create audit_logs table for security-relevant and admin actions
+
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT,
+    impersonator_id BIGINT,
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(100),
+    changes TEXT,
+    ip VARCHAR(45),
+    user_agent VARCHAR(255),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs(actor_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs(action);
+CREATE INDEX idx_audit_logs_resource ON audit_logs(resource_type, resource_id);
+CREATE INDEX idx_audit_logs_request_id ON audit_logs(request_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
@@ -0,0 +1,7 @@
+-- Migration: add_deletion_scheduled_at_to_users
+-- Created: 20261016091200
+-- Description: Add deletion_scheduled_at column to users table for self-service account deletion with a grace period
+
+
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMP;
+CREATE INDEX idx_users_deletion_scheduled_at ON users (deletion_scheduled_at);
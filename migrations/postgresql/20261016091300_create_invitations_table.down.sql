@@ -0,0 +1,6 @@
+-- Rollback Migration: create_invitations_table
+-- Created: 20261016091300
+-- Description: Drop invitations table
+
+
+DROP TABLE IF EXISTS invitations;
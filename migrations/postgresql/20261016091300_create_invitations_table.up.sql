@@ -0,0 +1,25 @@
+-- Migration: create_invitations_table
+-- Created: 20261016091300
+-- Description: Create invitations table for invite-only registration
+
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(100) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    invited_by BIGINT,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_by BIGINT,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_invitations_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_invitations_invited_by FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_invitations_accepted_by FOREIGN KEY (accepted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_invitations_email ON invitations(email);
+CREATE INDEX idx_invitations_invited_by ON invitations(invited_by);
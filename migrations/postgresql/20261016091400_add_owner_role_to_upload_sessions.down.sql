@@ -0,0 +1,6 @@
+-- Rollback Migration: add_owner_role_to_upload_sessions
+-- Created: 20261016091400
+-- Description: Drop owner_role column from upload_sessions table
+
+
+ALTER TABLE upload_sessions DROP COLUMN owner_role;
@@ -0,0 +1,27 @@
+// Package audit 在请求上下文中传递审计所需的请求信息，供服务层记录审计日志
+package audit
+
+import "context"
+
+type contextKey struct{}
+
+// Metadata 请求的审计信息，由中间件在请求开始和认证通过后填写
+type Metadata struct {
+	ActorID        uint // 执行操作的用户，未认证时为 0
+	ImpersonatorID uint // 模拟登录时实际操作的管理员
+	IP             string
+	UserAgent      string
+	RequestID      string
+	Recorded       bool // 服务层已为本次请求记录审计日志，中间件不再重复记录安全事件
+}
+
+// NewContext 返回携带审计信息的上下文
+func NewContext(ctx context.Context, md *Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, md)
+}
+
+// FromContext 获取上下文中的审计信息，不存在时返回 nil
+func FromContext(ctx context.Context) *Metadata {
+	md, _ := ctx.Value(contextKey{}).(*Metadata)
+	return md
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -20,6 +21,8 @@ type Cache interface {
 	Exists(ctx context.Context, keys ...string) (int64, error)
 	Expire(ctx context.Context, key string, expiration time.Duration) error
 	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Incr 原子递增计数并重置过期时间，键不存在或已过期时从 0 开始
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
 	Health() error
 	Close() error
 }
@@ -156,6 +159,23 @@ func (r *redisCache) TTL(ctx context.Context, key string) (time.Duration, error)
 	return ttl, nil
 }
 
+// Incr 原子递增计数并重置过期时间
+func (r *redisCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		if expiration > 0 {
+			pipe.Expire(ctx, key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to increment cache", "key", key, "error", err)
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
 // Health 检查缓存健康状态
 func (r *redisCache) Health() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -269,6 +289,32 @@ func (m *memoryCache) TTL(ctx context.Context, key string) (time.Duration, error
 	return ttl, nil
 }
 
+// Incr 原子递增计数并重置过期时间
+func (m *memoryCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var count int64
+	if item, exists := m.data[key]; exists && (item.expiration.IsZero() || time.Now().Before(item.expiration)) {
+		n, err := strconv.ParseInt(item.value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value is not an integer: %s", key)
+		}
+		count = n
+	}
+	count++
+
+	var exp time.Time
+	if expiration > 0 {
+		exp = time.Now().Add(expiration)
+	}
+	m.data[key] = cacheItem{
+		value:      strconv.FormatInt(count, 10),
+		expiration: exp,
+	}
+	return count, nil
+}
+
 // Health 健康检查
 func (m *memoryCache) Health() error {
 	return nil // Memory cache is always healthy
@@ -0,0 +1,85 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// exifOrientationTag EXIF 方向标签
+const exifOrientationTag = 0x0112
+
+// Orientation 从 JPEG 数据中读取 EXIF 方向值，未找到时返回 1
+func Orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// 依次遍历 JPEG 段，直到找到 APP1 (Exif) 或图像数据开始
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return 1
+		}
+		marker := data[offset+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+			offset += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return parseTIFFOrientation(segment[6:])
+		}
+		offset += 2 + length
+	}
+
+	return 1
+}
+
+// parseTIFFOrientation 解析 TIFF 结构中 IFD0 的方向标签
+func parseTIFFOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) != exifOrientationTag {
+			continue
+		}
+		// 方向标签为 SHORT 类型，值直接存放在条目中
+		value := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if value < 1 || value > 8 {
+			return 1
+		}
+		return value
+	}
+
+	return 1
+}
@@ -0,0 +1,190 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册 GIF 解码器
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+var (
+	// ErrUnsupportedFormat 不支持的图片格式
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrImageTooLarge 图片像素数超过限制
+	ErrImageTooLarge = errors.New("image dimensions exceed limit")
+)
+
+// Decode 解码图片并按 EXIF 方向信息摆正，maxPixels 为 0 时不限制像素数
+func Decode(data []byte, maxPixels int64) (image.Image, string, error) {
+	// 先读取尺寸，避免解码超大图片耗尽内存
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", ErrUnsupportedFormat
+		}
+		return nil, "", fmt.Errorf("failed to read image config: %w", err)
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, "", ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if format == "jpeg" {
+		img = Orient(img, Orientation(data))
+	}
+	return img, format, nil
+}
+
+// Fit 等比缩放图片到指定范围内，不会放大图片，宽或高为 0 表示不限制
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return img
+	}
+
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale >= 1 {
+		return img
+	}
+
+	dstWidth := max(1, int(float64(width)*scale+0.5))
+	dstHeight := max(1, int(float64(height)*scale+0.5))
+	return resize(toRGBA(img), dstWidth, dstHeight)
+}
+
+// Encode 编码图片，JPEG 保持 JPEG，其他格式输出 PNG 以保留透明度，返回 MIME 类型与扩展名
+func Encode(w io.Writer, img image.Image, format string, quality int) (string, string, error) {
+	switch format {
+	case "jpeg":
+		if quality <= 0 || quality > 100 {
+			quality = jpeg.DefaultQuality
+		}
+		if err := jpeg.Encode(w, img, &jpeg.Options{Quality: quality}); err != nil {
+			return "", "", fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+		return "image/jpeg", "jpg", nil
+	case "png", "gif":
+		if err := png.Encode(w, img); err != nil {
+			return "", "", fmt.Errorf("failed to encode png: %w", err)
+		}
+		return "image/png", "png", nil
+	default:
+		return "", "", ErrUnsupportedFormat
+	}
+}
+
+// OutputExtension 获取指定源格式生成变体时使用的扩展名
+func OutputExtension(format string) string {
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return "png"
+}
+
+// Orient 按 EXIF 方向值（1-8）旋转或翻转图片
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	src := toRGBA(img)
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = width-1-x, y
+			case 3: // 旋转 180°
+				sx, sy = width-1-x, height-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, height-1-y
+			case 5: // 沿主对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转 90°
+				sx, sy = y, height-1-x
+			case 7: // 沿副对角线翻转
+				sx, sy = width-1-y, height-1-x
+			case 8: // 逆时针旋转 90°
+				sx, sy = width-1-y, x
+			}
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
+
+// toRGBA 转换为从原点开始的 RGBA 图片
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// resize 使用区域平均算法缩小图片
+func resize(src *image.RGBA, dstWidth, dstHeight int) *image.RGBA {
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		sy0 := y * srcHeight / dstHeight
+		sy1 := max(sy0+1, (y+1)*srcHeight/dstHeight)
+		for x := 0; x < dstWidth; x++ {
+			sx0 := x * srcWidth / dstWidth
+			sx1 := max(sx0+1, (x+1)*srcWidth/dstWidth)
+
+			var r, g, b, a, count uint64
+			for sy := sy0; sy < sy1; sy++ {
+				i := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					count++
+					i += 4
+				}
+			}
+
+			di := dst.PixOffset(x, y)
+			dst.Pix[di] = uint8(r / count)
+			dst.Pix[di+1] = uint8(g / count)
+			dst.Pix[di+2] = uint8(b / count)
+			dst.Pix[di+3] = uint8(a / count)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,81 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"vibe-coding-starter/pkg/logger"
+)
+
+// FileMailer 将邮件保存为 .eml 文件，用于开发调试
+type FileMailer struct {
+	from mail.Address
+	dir  string
+}
+
+// NewFileMailer 创建文件邮件发送器
+func NewFileMailer(from mail.Address, dir string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail file directory is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileMailer{from: from, dir: dir}, nil
+}
+
+// Send 将邮件写入目录
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	data, err := build(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(m.dir, time.Now().Format("20060102150405")+"-*.eml")
+	if err != nil {
+		return fmt.Errorf("failed to create mail file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+// LogMailer 只将邮件写入日志，未启用邮件发送时使用
+type LogMailer struct {
+	from   mail.Address
+	logger logger.Logger
+}
+
+// NewLogMailer 创建日志邮件发送器
+func NewLogMailer(from mail.Address, logger logger.Logger) *LogMailer {
+	return &LogMailer{from: from, logger: logger}
+}
+
+// Send 将邮件内容写入日志
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mail has no recipients")
+	}
+
+	m.logger.Info("Email not sent, logging instead",
+		"from", m.from.String(),
+		"to", strings.Join(msg.To, ", "),
+		"subject", msg.Subject,
+		"body", msg.Text)
+	return nil
+}
+
+// 确保实现了接口
+var (
+	_ Mailer = (*SMTPMailer)(nil)
+	_ Mailer = (*FileMailer)(nil)
+	_ Mailer = (*LogMailer)(nil)
+)
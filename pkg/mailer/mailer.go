@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/logger"
+)
+
+// Message 邮件内容
+type Message struct {
+	To      []string
+	Subject string
+	Text    string // 纯文本正文
+	HTML    string // HTML 正文，可为空
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New 根据配置创建邮件发送器，未启用邮件时只写入日志
+func New(cfg *config.Config, logger logger.Logger) (Mailer, error) {
+	email := cfg.Email
+	from := mail.Address{Name: email.FromName, Address: email.FromEmail}
+
+	if !email.Enabled {
+		return NewLogMailer(from, logger), nil
+	}
+
+	switch email.Driver {
+	case "smtp", "":
+		return NewSMTPMailer(from, email)
+	case "file":
+		return NewFileMailer(from, email.FileDir)
+	default:
+		return nil, fmt.Errorf("unsupported email driver: %s", email.Driver)
+	}
+}
+
+// build 生成 RFC 5322 格式的邮件，同时包含纯文本和 HTML 正文时使用 multipart/alternative
+func build(from mail.Address, msg *Message) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("mail has no recipients")
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	header("From", from.String())
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	writer := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+writer.Boundary())
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable 以 quoted-printable 编码写入正文
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID 生成邮件 Message-ID
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"vibe-coding-starter/internal/config"
+)
+
+// smtpTimeout 连接 SMTP 服务器的超时时间
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer 通过 SMTP 服务器发送邮件
+type SMTPMailer struct {
+	from       mail.Address
+	addr       string
+	host       string
+	username   string
+	password   string
+	encryption string
+	tlsConfig  *tls.Config
+}
+
+// NewSMTPMailer 创建 SMTP 邮件发送器
+func NewSMTPMailer(from mail.Address, cfg config.EmailConfig) (*SMTPMailer, error) {
+	if cfg.SMTPHost == "" {
+		return nil, fmt.Errorf("smtp host is required")
+	}
+
+	encryption := cfg.Encryption
+	if encryption == "" {
+		encryption = "starttls"
+	}
+	switch encryption {
+	case "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("unsupported smtp encryption: %s", encryption)
+	}
+
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+
+	return &SMTPMailer{
+		from:       from,
+		addr:       net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port)),
+		host:       cfg.SMTPHost,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		encryption: encryption,
+		tlsConfig:  &tls.Config{ServerName: cfg.SMTPHost},
+	}, nil
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	data, err := build(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer client.Close()
+
+	if m.encryption == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(m.tlsConfig); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start mail data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write mail data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial 连接 SMTP 服务器，tls 模式下直接建立 TLS 连接
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if m.encryption == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: m.tlsConfig}).DialContext(ctx, "tcp", m.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", m.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// jsonWebKey JWK 公钥（RFC 7517），只解析签名验证需要的字段
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jsonWebKeySet JWKS 文档
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// publicKeys 按密钥 ID 返回可用于验证签名的公钥，跳过加密用途和无法解析的密钥
+func (s jsonWebKeySet) publicKeys() map[string]interface{} {
+	keys := make(map[string]interface{}, len(s.Keys))
+	for _, k := range s.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key := k.publicKey(); key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys
+}
+
+// publicKey 解析公钥，不支持的类型返回 nil
+func (k jsonWebKey) publicKey() interface{} {
+	switch k.Kty {
+	case "RSA":
+		n, okN := decodeBigInt(k.N)
+		e, okE := decodeBigInt(k.E)
+		if !okN || !okE || !e.IsInt64() {
+			return nil
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil
+		}
+		x, okX := decodeBigInt(k.X)
+		y, okY := decodeBigInt(k.Y)
+		if !okX || !okY {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil
+		}
+		return ed25519.PublicKey(x)
+	default:
+		return nil
+	}
+}
+
+// decodeBigInt 解码 Base64URL 编码的大整数
+func decodeBigInt(s string) (*big.Int, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(b), true
+}
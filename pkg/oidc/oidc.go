@@ -0,0 +1,379 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"vibe-coding-starter/internal/config"
+)
+
+var (
+	// ErrUnknownProvider 未配置的提供方
+	ErrUnknownProvider = errors.New("unknown oidc provider")
+	// ErrInvalidIDToken ID 令牌签名、发行方、受众、有效期或 nonce 校验失败
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+const (
+	// maxResponseSize 提供方响应的最大字节数
+	maxResponseSize = 1 << 20
+	// keysRefreshInterval 遇到未知密钥 ID 时重新获取 JWKS 的最小间隔，避免被伪造令牌放大请求
+	keysRefreshInterval = 10 * time.Second
+	// clockSkew 校验令牌时间时允许的时钟偏差
+	clockSkew = time.Minute
+)
+
+// signingMethods ID 令牌允许的签名算法，只接受非对称算法
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// defaultScopes 未配置 scopes 时请求的权限
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Claims ID 令牌声明
+type Claims struct {
+	Nonce           string `json:"nonce,omitempty"`
+	Email           string `json:"email,omitempty"`
+	EmailVerified   bool   `json:"email_verified,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Picture         string `json:"picture,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Token 令牌端点响应
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+}
+
+// Discovery 提供方元数据，见 OpenID Connect Discovery 1.0
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider OpenID Connect 提供方客户端，元数据和签名公钥在首次使用时获取并缓存
+type Provider struct {
+	name         string
+	displayName  string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	client       *http.Client
+
+	mutex         sync.Mutex
+	discovery     *Discovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewProvider 创建提供方客户端
+func NewProvider(cfg config.OAuthProviderConfig, client *http.Client) (*Provider, error) {
+	if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc provider name, issuer, client_id and redirect_url are required")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+	displayName := cfg.DisplayName
+	if displayName == "" {
+		displayName = cfg.Name
+	}
+
+	return &Provider{
+		name:         cfg.Name,
+		displayName:  displayName,
+		issuer:       strings.TrimSuffix(cfg.Issuer, "/"),
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		scopes:       scopes,
+		client:       client,
+	}, nil
+}
+
+// Name 提供方标识
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// DisplayName 提供方显示名称
+func (p *Provider) DisplayName() string {
+	return p.displayName
+}
+
+// AuthCodeURL 生成授权地址，使用 S256 方式的 PKCE
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", strings.Join(p.scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange 使用授权码和 PKCE 验证码换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.clientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		// RFC 6749 2.3.1 要求对客户端凭据进行表单编码后再使用 Basic 认证
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &oauthErr)
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+	return &token, nil
+}
+
+// VerifyIDToken 使用提供方 JWKS 校验 ID 令牌，并检查发行方、受众、有效期和 nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, discovery, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// 存在多个受众时，azp 必须是本客户端
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID {
+		return nil, fmt.Errorf("%w: unexpected authorized party", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// Discover 获取提供方元数据，成功后缓存
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery Discovery
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider %s: %w", p.name, err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("oidc provider %s issuer mismatch: %s", p.name, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc provider %s metadata is incomplete", p.name)
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// key 根据密钥 ID 获取签名公钥，遇到未知密钥 ID 时重新获取 JWKS 以支持提供方轮换密钥
+func (p *Provider) key(ctx context.Context, discovery *Discovery, kid string) (interface{}, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("signing key %q not found", kid)
+	}
+
+	var set jsonWebKeySet
+	if err := p.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	p.keys = set.publicKeys()
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("signing key %q not found", kid)
+}
+
+// lookupKey 查找已缓存的公钥，令牌未指定密钥 ID 时只在仅有一个密钥时使用该密钥
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// getJSON 请求 JSON 资源
+func (p *Provider) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// Registry 已配置的提供方集合
+type Registry struct {
+	providers map[string]*Provider
+	ordered   []*Provider
+}
+
+// New 根据配置创建提供方集合，未配置提供方时返回空集合
+func New(cfg *config.Config) (*Registry, error) {
+	return NewRegistry(cfg.OAuth.Providers, nil)
+}
+
+// NewRegistry 创建提供方集合，client 为空时使用默认 HTTP 客户端
+func NewRegistry(providers []config.OAuthProviderConfig, client *http.Client) (*Registry, error) {
+	r := &Registry{providers: make(map[string]*Provider, len(providers))}
+	for _, cfg := range providers {
+		if _, exists := r.providers[cfg.Name]; exists {
+			return nil, fmt.Errorf("duplicate oidc provider: %s", cfg.Name)
+		}
+		provider, err := NewProvider(cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		r.providers[cfg.Name] = provider
+		r.ordered = append(r.ordered, provider)
+	}
+	return r, nil
+}
+
+// Get 获取提供方
+func (r *Registry) Get(name string) (*Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Providers 按配置顺序返回全部提供方
+func (r *Registry) Providers() []*Provider {
+	return r.ordered
+}
+
+// NewCodeVerifier 生成 PKCE 验证码（RFC 7636），同时可用作 state 和 nonce
+func NewCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge 计算 S256 方式的 PKCE 挑战码
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,182 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"vibe-coding-starter/internal/config"
+)
+
+// 支持的哈希算法
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// Argon2Params Argon2id 参数
+type Argon2Params struct {
+	Memory      uint32 // 内存开销（KiB）
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params 默认的 Argon2id 参数
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hasher 计算和验证密码哈希
+//
+// 新哈希使用配置的算法，验证时根据哈希前缀识别 argon2id 和 bcrypt 两种格式，
+// 因此切换算法或调整参数后已有用户仍可登录，并可通过 NeedsRehash 判断是否需要更新。
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+// New 根据配置创建密码哈希器，未配置的参数使用默认值
+func New(cfg *config.Config) (*Hasher, error) {
+	pc := cfg.Password
+	switch pc.Algorithm {
+	case "", AlgorithmArgon2id:
+		params := DefaultArgon2Params
+		if pc.Argon2.Memory > 0 {
+			params.Memory = pc.Argon2.Memory
+		}
+		if pc.Argon2.Iterations > 0 {
+			params.Iterations = pc.Argon2.Iterations
+		}
+		if pc.Argon2.Parallelism > 0 {
+			params.Parallelism = pc.Argon2.Parallelism
+		}
+		if pc.Argon2.SaltLength > 0 {
+			params.SaltLength = pc.Argon2.SaltLength
+		}
+		if pc.Argon2.KeyLength > 0 {
+			params.KeyLength = pc.Argon2.KeyLength
+		}
+		return NewArgon2idHasher(params), nil
+	case AlgorithmBcrypt:
+		cost := pc.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return NewBcryptHasher(cost), nil
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm: %s", pc.Algorithm)
+	}
+}
+
+// NewArgon2idHasher 创建使用 Argon2id 的哈希器
+func NewArgon2idHasher(params Argon2Params) *Hasher {
+	return &Hasher{algorithm: AlgorithmArgon2id, bcryptCost: bcrypt.DefaultCost, argon2: params}
+}
+
+// NewBcryptHasher 创建使用 bcrypt 的哈希器
+func NewBcryptHasher(cost int) *Hasher {
+	return &Hasher{algorithm: AlgorithmBcrypt, bcryptCost: cost, argon2: DefaultArgon2Params}
+}
+
+// Algorithm 返回新哈希使用的算法
+func (h *Hasher) Algorithm() string {
+	return h.algorithm
+}
+
+// Hash 计算密码哈希
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.algorithm == AlgorithmBcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, h.argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.argon2.Iterations, h.argon2.Memory, h.argon2.Parallelism, h.argon2.KeyLength)
+
+	// PHC 字符串格式：$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.argon2.Memory, h.argon2.Iterations, h.argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify 验证密码是否与哈希匹配，哈希格式无法识别时返回 false
+func (h *Hasher) Verify(password, encoded string) bool {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		return subtle.ConstantTimeCompare(computed, key) == 1
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+}
+
+// NeedsRehash 判断哈希是否使用了其他算法或过时的参数
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if h.algorithm == AlgorithmBcrypt {
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != h.bcryptCost
+	}
+
+	params, _, _, err := decodeArgon2id(encoded)
+	return err != nil || params != h.argon2
+}
+
+// decodeArgon2id 解析 PHC 格式的 Argon2id 哈希
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,142 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"vibe-coding-starter/internal/config"
+)
+
+// defaultMinLength 未配置最小长度时使用的值
+const defaultMinLength = 8
+
+// commonPasswords 内置的常见泄露密码列表，每行一个
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// ErrPolicyViolation 密码不符合密码策略，以下错误都包装了该错误
+var ErrPolicyViolation = errors.New("password does not meet policy")
+
+var (
+	// ErrTooShort 密码长度不足
+	ErrTooShort = fmt.Errorf("%w: too short", ErrPolicyViolation)
+	// ErrTooLong 密码超过最大长度
+	ErrTooLong = fmt.Errorf("%w: too long", ErrPolicyViolation)
+	// ErrMissingCharacterClass 密码缺少要求的字符类型
+	ErrMissingCharacterClass = fmt.Errorf("%w: missing required character types", ErrPolicyViolation)
+	// ErrCommon 密码出现在常见泄露密码列表中
+	ErrCommon = fmt.Errorf("%w: too common", ErrPolicyViolation)
+	// ErrContainsUsername 密码包含用户名
+	ErrContainsUsername = fmt.Errorf("%w: contains the username", ErrPolicyViolation)
+)
+
+// Policy 密码策略
+type Policy struct {
+	cfg    config.PasswordPolicyConfig
+	common map[string]struct{}
+}
+
+// NewPolicy 根据配置创建密码策略，开启常见密码检查时加载内置列表和配置的列表文件
+func NewPolicy(cfg *config.Config) (*Policy, error) {
+	pc := cfg.Password.Policy
+	if pc.MinLength <= 0 {
+		pc.MinLength = defaultMinLength
+	}
+
+	p := &Policy{cfg: pc, common: make(map[string]struct{})}
+	if !pc.RejectCommon {
+		return p, nil
+	}
+
+	p.addCommon(strings.NewReader(commonPasswords))
+	if pc.CommonPasswordsFile != "" {
+		f, err := os.Open(pc.CommonPasswordsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open common passwords file: %w", err)
+		}
+		defer f.Close()
+		if err := p.addCommon(f); err != nil {
+			return nil, fmt.Errorf("failed to read common passwords file: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// addCommon 读取常见密码列表，忽略空行和 # 开头的注释
+func (p *Policy) addCommon(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.common[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate 检查密码是否符合策略，username 为空时跳过用户名检查
+func (p *Policy) Validate(password, username string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		return fmt.Errorf("%w, at least %d characters required", ErrTooShort, p.cfg.MinLength)
+	}
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		return fmt.Errorf("%w, at most %d characters allowed", ErrTooLong, p.cfg.MaxLength)
+	}
+
+	if missing := p.missingClasses(password); len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingCharacterClass, strings.Join(missing, ", "))
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := p.common[lower]; ok {
+		return ErrCommon
+	}
+	if p.cfg.RejectUsername && username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		return ErrContainsUsername
+	}
+
+	return nil
+}
+
+// missingClasses 返回密码缺少的必需字符类型
+func (p *Policy) missingClasses(password string) []string {
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	var missing []string
+	if p.cfg.RequireUppercase && !upper {
+		missing = append(missing, "uppercase letter")
+	}
+	if p.cfg.RequireLowercase && !lower {
+		missing = append(missing, "lowercase letter")
+	}
+	if p.cfg.RequireDigit && !digit {
+		missing = append(missing, "digit")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		missing = append(missing, "symbol")
+	}
+	return missing
+}
@@ -0,0 +1,83 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"vibe-coding-starter/internal/config"
+)
+
+// version 密文格式版本前缀
+const version = "v1:"
+
+// ErrInvalidCiphertext 密文格式错误或无法解密
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// Box 使用 AES-256-GCM 加密保存在数据库中的敏感数据
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New 根据配置创建加密器，未配置 auth.mfa_encryption_key 时由 jwt.secret 派生密钥
+func New(cfg *config.Config) (*Box, error) {
+	key := cfg.Auth.MFAEncryptionKey
+	if key == "" {
+		key = cfg.JWT.Secret
+	}
+	return NewBox(key)
+}
+
+// NewBox 使用口令创建加密器，口令经 SHA-256 派生为 256 位密钥
+func NewBox(passphrase string) (*Box, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encryption key is required")
+	}
+
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// Seal 加密明文，返回带版本前缀的 Base64 密文
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return version + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 生成的密文
+func (b *Box) Open(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, version) {
+		return "", ErrInvalidCiphertext
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(ciphertext, version))
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,136 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"vibe-coding-starter/internal/config"
+)
+
+var (
+	// ErrInvalidSignature 签名缺失或不匹配
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrSignatureExpired 签名已过期
+	ErrSignatureExpired = errors.New("signature expired")
+)
+
+// 签名链接查询参数
+const (
+	QueryExpires   = "expires"
+	QueryKeyID     = "kid"
+	QuerySignature = "signature"
+)
+
+// fallbackKeyID 未配置密钥时由 JWT 密钥派生的签名密钥 ID
+const fallbackKeyID = "jwt"
+
+// Signer HMAC 链接签名器，使用当前密钥签名，使用全部已配置密钥验证
+type Signer struct {
+	activeKey string
+	keys      map[string][]byte
+}
+
+// New 根据配置创建签名器
+func New(cfg *config.Config) (*Signer, error) {
+	signing := cfg.Storage.Signing
+	if len(signing.Keys) == 0 {
+		// 未配置签名密钥时从 JWT 密钥派生，避免直接复用同一密钥
+		mac := hmac.New(sha256.New, []byte(cfg.JWT.Secret))
+		mac.Write([]byte("url-signing"))
+		return NewSigner(fallbackKeyID, []config.SigningKey{
+			{ID: fallbackKeyID, Secret: string(mac.Sum(nil))},
+		})
+	}
+
+	return NewSigner(signing.ActiveKey, signing.Keys)
+}
+
+// NewSigner 创建签名器，activeKey 为空时使用第一个密钥
+func NewSigner(activeKey string, keys []config.SigningKey) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one signing key is required")
+	}
+
+	s := &Signer{
+		activeKey: activeKey,
+		keys:      make(map[string][]byte, len(keys)),
+	}
+	for _, key := range keys {
+		if key.ID == "" || key.Secret == "" {
+			return nil, fmt.Errorf("signing key id and secret are required")
+		}
+		if _, exists := s.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate signing key: %s", key.ID)
+		}
+		s.keys[key.ID] = []byte(key.Secret)
+	}
+
+	if s.activeKey == "" {
+		s.activeKey = keys[0].ID
+	}
+	if _, exists := s.keys[s.activeKey]; !exists {
+		return nil, fmt.Errorf("active signing key not found: %s", s.activeKey)
+	}
+
+	return s, nil
+}
+
+// ActiveKey 获取当前用于签名的密钥 ID
+func (s *Signer) ActiveKey() string {
+	return s.activeKey
+}
+
+// Sign 为资源生成带过期时间的签名参数
+func (s *Signer) Sign(resource string, expiresAt time.Time) url.Values {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	values := url.Values{}
+	values.Set(QueryExpires, expires)
+	values.Set(QueryKeyID, s.activeKey)
+	values.Set(QuerySignature, s.sign(s.keys[s.activeKey], s.activeKey, resource, expires))
+	return values
+}
+
+// Verify 验证资源的签名参数
+func (s *Signer) Verify(resource string, values url.Values, now time.Time) error {
+	expires := values.Get(QueryExpires)
+	keyID := values.Get(QueryKeyID)
+	signature := values.Get(QuerySignature)
+	if expires == "" || keyID == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	// 已移除的密钥签发的链接立即失效
+	secret, exists := s.keys[keyID]
+	if !exists {
+		return ErrInvalidSignature
+	}
+
+	expected := s.sign(secret, keyID, resource, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if now.Unix() > expiresAt {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
+
+// sign 计算签名，密钥 ID 参与签名以防止替换
+func (s *Signer) sign(secret []byte, keyID, resource, expires string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(keyID + "\n" + resource + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"vibe-coding-starter/internal/config"
+)
+
+// legacyKeyPrefix 早期版本在 files.path 中记录的是相对工作目录的 "uploads/<name>"
+const legacyKeyPrefix = "uploads/"
+
+// localStorage 本地磁盘存储实现
+type localStorage struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStorage 创建本地磁盘存储
+func NewLocalStorage(cfg config.LocalStorageConfig) (Storage, error) {
+	root := cfg.Root
+	if root == "" {
+		root = "uploads"
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage root: %w", err)
+	}
+
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+
+	return &localStorage{
+		root:    root,
+		baseURL: baseURL,
+	}, nil
+}
+
+// Put 写入对象
+func (s *localStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// 先写入临时文件再重命名，避免读到未写完的对象
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to chmod file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return nil
+}
+
+// Get 读取对象
+func (s *localStorage) Get(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	fullPath, err := s.locate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, nil
+}
+
+// Delete 删除对象
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	fullPath, err := s.locate(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// Stat 获取对象元信息
+func (s *localStorage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	fullPath, err := s.locate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// List 遍历对象，跳过写入中的临时文件
+func (s *localStorage) List(ctx context.Context, prefix string, fn func(*ObjectInfo) error) error {
+	err := filepath.WalkDir(s.root, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, fullPath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			// 跳过与前缀无关的目录
+			if key != "." && !strings.HasPrefix(key+"/", prefix) && !strings.HasPrefix(prefix, key+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) || strings.HasPrefix(entry.Name(), ".tmp-") {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		return fn(&ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			ContentType:  mime.TypeByExtension(path.Ext(key)),
+			LastModified: info.ModTime(),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+	return nil
+}
+
+// SignedURL 本地存储不支持直接签名访问
+func (s *localStorage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
+
+// URL 获取对象的公开访问地址
+func (s *localStorage) URL(key string) string {
+	return s.baseURL + "/" + strings.TrimLeft(key, "/")
+}
+
+// ResolveKey 将早期版本记录的 "uploads/<name>" 转换为实际存放的对象键
+func (s *localStorage) ResolveKey(key string) string {
+	fullPath, err := s.locate(key)
+	if err != nil {
+		return key
+	}
+
+	rel, err := filepath.Rel(s.root, fullPath)
+	if err != nil {
+		return key
+	}
+	return filepath.ToSlash(rel)
+}
+
+// resolve 将对象键转换为磁盘路径，并拒绝越出根目录的键
+func (s *localStorage) resolve(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" {
+		return "", fmt.Errorf("invalid storage key: %q", key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// locate 获取已有对象的磁盘路径，早期版本记录的 "uploads/<name>" 不存在时回退到根目录下的 <name>
+func (s *localStorage) locate(key string) (string, error) {
+	fullPath, err := s.resolve(key)
+	if err != nil || !strings.HasPrefix(key, legacyKeyPrefix) {
+		return fullPath, err
+	}
+
+	if _, err := os.Stat(fullPath); !os.IsNotExist(err) {
+		return fullPath, nil
+	}
+	return s.resolve(strings.TrimPrefix(key, legacyKeyPrefix))
+}
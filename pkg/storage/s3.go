@@ -0,0 +1,519 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"vibe-coding-starter/internal/config"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3Service         = "s3"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+// s3Storage S3 兼容协议存储实现（AWS S3、MinIO、阿里云 OSS 等）
+type s3Storage struct {
+	endpoint     *url.URL
+	region       string
+	bucket       string
+	accessKey    string
+	secretKey    string
+	usePathStyle bool
+	publicURL    string
+	client       *http.Client
+	now          func() time.Time
+}
+
+// s3Object 支持 Seek 的 S3 对象读取器
+type s3Object struct {
+	ctx     context.Context
+	storage *s3Storage
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+// s3ListResult ListObjectsV2 响应
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// s3Error S3 错误响应
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// NewS3Storage 创建 S3 兼容存储
+func NewS3Storage(cfg config.S3StorageConfig) (Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	rawEndpoint := cfg.Endpoint
+	if rawEndpoint == "" {
+		rawEndpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	if !strings.Contains(rawEndpoint, "://") {
+		rawEndpoint = "https://" + rawEndpoint
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(rawEndpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	return &s3Storage{
+		endpoint:     endpoint,
+		region:       region,
+		bucket:       cfg.Bucket,
+		accessKey:    cfg.AccessKey,
+		secretKey:    cfg.SecretKey,
+		usePathStyle: cfg.UsePathStyle,
+		publicURL:    strings.TrimRight(cfg.PublicURL, "/"),
+		client:       &http.Client{},
+		now:          time.Now,
+	}, nil
+}
+
+// Put 写入对象
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	// S3 PUT 需要 Content-Length，长度未知时先落盘到临时文件
+	if size < 0 {
+		tmp, err := os.CreateTemp("", "s3-upload-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		size, err = io.Copy(tmp, r)
+		if err != nil {
+			return fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind upload: %w", err)
+		}
+		r = tmp
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), io.NopCloser(r))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// Get 读取对象，实际数据在首次读取时按当前偏移量通过 Range 请求获取
+func (s *s3Storage) Get(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Object{
+		ctx:     ctx,
+		storage: s,
+		key:     key,
+		size:    info.Size,
+	}, nil
+}
+
+// Delete 删除对象
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		if err == ErrObjectNotFound {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// Stat 获取对象元信息
+func (s *s3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	info := &ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+
+	return info, nil
+}
+
+// List 使用 ListObjectsV2 分页遍历对象
+func (s *s3Storage) List(ctx context.Context, prefix string, fn func(*ObjectInfo) error) error {
+	token := ""
+	for {
+		target := s.objectURL("")
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		target.RawQuery = s3CanonicalQuery(query)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := s.do(req)
+		if err != nil {
+			return err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode list response: %w", err)
+		}
+
+		for _, item := range result.Contents {
+			info := &ObjectInfo{
+				Key:          item.Key,
+				Size:         item.Size,
+				ETag:         strings.Trim(item.ETag, `"`),
+				LastModified: item.LastModified,
+			}
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// SignedURL 生成预签名的 GET 地址
+func (s *s3Storage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > 7*24*time.Hour {
+		return "", fmt.Errorf("invalid signed url expiration: %s", expires)
+	}
+
+	now := s.now().UTC()
+	target := s.objectURL(key)
+
+	query := target.Query()
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.credentialScope(now))
+	query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		s3EscapePath(target.Path),
+		s3CanonicalQuery(query),
+		"host:" + target.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	target.RawQuery = s3CanonicalQuery(query)
+
+	return target.String(), nil
+}
+
+// URL 获取对象的公开访问地址
+func (s *s3Storage) URL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + s3EscapePath(strings.TrimLeft(key, "/"))
+	}
+	return s.objectURL(key).String()
+}
+
+// objectURL 构造对象地址，支持 path-style 与 virtual-hosted-style
+func (s *s3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	key = strings.TrimLeft(key, "/")
+
+	if s.usePathStyle {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+
+	return &u
+}
+
+// do 签名并发送请求，非 2xx 响应转换为错误
+func (s *s3Storage) do(req *http.Request) (*http.Response, error) {
+	s.signRequest(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+
+	var s3Err s3Error
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err := xml.Unmarshal(body, &s3Err); err == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("s3 %s %s: %s (%s)", req.Method, req.URL.Path, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("s3 %s %s: unexpected status %d", req.Method, req.URL.Path, resp.StatusCode)
+}
+
+// signRequest 使用 AWS Signature Version 4 对请求头签名
+func (s *s3Storage) signRequest(req *http.Request) {
+	now := s.now().UTC()
+
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": s3UnsignedPayload,
+		"x-amz-date":           now.Format(s3TimeFormat),
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	if rangeHeader := req.Header.Get("Range"); rangeHeader != "" {
+		headers["range"] = rangeHeader
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, s.credentialScope(now), signedHeaders, s.signature(now, canonicalRequest)))
+}
+
+// credentialScope 签名凭证范围
+func (s *s3Storage) credentialScope(t time.Time) string {
+	return strings.Join([]string{t.Format(s3DateFormat), s.region, s3Service, "aws4_request"}, "/")
+}
+
+// signature 计算规范请求的签名
+func (s *s3Storage) signature(t time.Time, canonicalRequest string) string {
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		t.Format(s3TimeFormat),
+		s.credentialScope(t),
+		hex.EncodeToString(hashed[:]),
+	}, "\n")
+
+	key := s3HMAC([]byte("AWS4"+s.secretKey), t.Format(s3DateFormat))
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, s3Service)
+	key = s3HMAC(key, "aws4_request")
+
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+// s3HMAC 计算 HMAC-SHA256
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery 生成规范查询字符串
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath 按 SigV4 规则编码路径，保留分隔符
+func s3EscapePath(p string) string {
+	return s3Escape(p, false)
+}
+
+// s3Escape 按 SigV4 规则进行 URI 编码
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9'),
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// Read 从当前偏移量读取数据
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		req, err := http.NewRequestWithContext(o.ctx, http.MethodGet, o.storage.objectURL(o.key).String(), nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create request: %w", err)
+		}
+		if o.offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+		}
+
+		resp, err := o.storage.do(req)
+		if err != nil {
+			return 0, err
+		}
+		o.body = resp.Body
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+// Seek 移动读取偏移量，下一次读取时重新发起请求
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = o.offset + offset
+	case io.SeekEnd:
+		target = o.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	if target < 0 {
+		return 0, fmt.Errorf("negative position: %d", target)
+	}
+
+	if target != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = target
+
+	return target, nil
+}
+
+// Close 关闭读取器
+func (o *s3Object) Close() error {
+	if o.body != nil {
+		err := o.body.Close()
+		o.body = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/logger"
+)
+
+var (
+	// ErrObjectNotFound 对象不存在
+	ErrObjectNotFound = errors.New("storage: object not found")
+	// ErrNotSupported 驱动不支持该操作
+	ErrNotSupported = errors.New("storage: operation not supported")
+)
+
+// ObjectInfo 存储对象元信息
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Storage 存储驱动接口
+type Storage interface {
+	// Put 写入对象，size 为 -1 时表示长度未知
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，返回值支持 Seek 以便按范围读取，调用方负责关闭
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// Stat 获取对象元信息
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// List 遍历键名以 prefix 开头的对象，fn 返回错误时停止遍历
+	List(ctx context.Context, prefix string, fn func(*ObjectInfo) error) error
+	// SignedURL 生成带有效期的直接访问地址
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+	// URL 获取对象的公开访问地址
+	URL(key string) string
+}
+
+// KeyResolver 由存在历史键名格式的驱动实现，将记录中的路径转换为实际的对象键
+type KeyResolver interface {
+	ResolveKey(key string) string
+}
+
+// ObjectKey 获取记录中的路径在驱动中实际对应的对象键，与 List 返回的键名一致
+func ObjectKey(driver Storage, key string) string {
+	if resolver, ok := driver.(KeyResolver); ok {
+		return resolver.ResolveKey(key)
+	}
+	return key
+}
+
+// Manager 存储驱动管理器
+type Manager struct {
+	drivers       map[string]Storage
+	defaultDriver string
+	mutex         sync.RWMutex
+}
+
+// NewManager 创建存储驱动管理器
+func NewManager(defaultDriver string) *Manager {
+	return &Manager{
+		drivers:       make(map[string]Storage),
+		defaultDriver: defaultDriver,
+	}
+}
+
+// New 根据配置创建存储驱动管理器并注册已配置的驱动
+func New(cfg *config.Config, log logger.Logger) (*Manager, error) {
+	defaultDriver := cfg.Storage.Default
+	if defaultDriver == "" {
+		defaultDriver = "local"
+	}
+
+	manager := NewManager(defaultDriver)
+
+	local, err := NewLocalStorage(cfg.Storage.Local)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local storage: %w", err)
+	}
+	manager.Register("local", local)
+
+	// S3 兼容驱动仅在配置了 bucket 时启用
+	if cfg.Storage.S3.Bucket != "" {
+		s3, err := NewS3Storage(cfg.Storage.S3)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create s3 storage: %w", err)
+		}
+		manager.Register("s3", s3)
+	}
+
+	if cfg.Storage.OSS.Bucket != "" {
+		oss, err := NewS3Storage(cfg.Storage.OSS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create oss storage: %w", err)
+		}
+		manager.Register("oss", oss)
+	}
+
+	if _, err := manager.Get(defaultDriver); err != nil {
+		return nil, fmt.Errorf("default storage driver is not configured: %s", defaultDriver)
+	}
+
+	log.Info("Storage initialized", "default", defaultDriver, "drivers", manager.Names())
+
+	return manager, nil
+}
+
+// Register 注册存储驱动
+func (m *Manager) Register(name string, driver Storage) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.drivers[name] = driver
+}
+
+// Get 获取指定名称的存储驱动
+func (m *Manager) Get(name string) (Storage, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	driver, exists := m.drivers[name]
+	if !exists {
+		return nil, fmt.Errorf("unsupported storage type: %s", name)
+	}
+	return driver, nil
+}
+
+// Default 获取默认存储驱动
+func (m *Manager) Default() Storage {
+	driver, _ := m.Get(m.defaultDriver)
+	return driver
+}
+
+// DefaultName 获取默认存储驱动名称
+func (m *Manager) DefaultName() string {
+	return m.defaultDriver
+}
+
+// Names 获取已注册的驱动名称
+func (m *Manager) Names() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	names := make([]string, 0, len(m.drivers))
+	for name := range m.drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,192 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"vibe-coding-starter/internal/config"
+)
+
+// minRSAKeyBits RSA 签名密钥的最小长度
+const minRSAKeyBits = 2048
+
+// signingKey 非对称签名密钥，只用于验证的旧密钥没有私钥
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// JSONWebKey JWK 公钥（RFC 7517）
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet JWKS 文档
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// loadKeys 加载配置的签名密钥
+func loadKeys(cfgs []config.JWTKeyConfig) (map[string]*signingKey, error) {
+	keys := make(map[string]*signingKey, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.ID == "" {
+			return nil, fmt.Errorf("jwt key id is required")
+		}
+		if _, exists := keys[cfg.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", cfg.ID)
+		}
+
+		key, err := loadKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %q: %w", cfg.ID, err)
+		}
+		keys[cfg.ID] = key
+	}
+	return keys, nil
+}
+
+// loadKey 从 PEM 文件加载密钥，同时配置私钥和公钥时校验两者匹配
+func loadKey(cfg config.JWTKeyConfig) (*signingKey, error) {
+	key := &signingKey{id: cfg.ID}
+
+	if cfg.PrivateKeyFile != "" {
+		block, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+		key.public = private.Public()
+	}
+
+	if cfg.PublicKeyFile != "" {
+		block, err := readPEM(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		public, err := parsePublicKey(block)
+		if err != nil {
+			return nil, err
+		}
+		if key.public != nil && !publicKeysEqual(key.public, public) {
+			return nil, fmt.Errorf("public key does not match private key")
+		}
+		key.public = public
+	}
+
+	switch public := key.public.(type) {
+	case nil:
+		return nil, fmt.Errorf("private_key_file or public_key_file is required")
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 keys are supported", public)
+	}
+	return key, nil
+}
+
+// readPEM 读取 PEM 文件中的第一个数据块
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no pem data found in %s", path)
+	}
+	return block, nil
+}
+
+// parsePrivateKey 解析 PKCS#8 或 PKCS#1 私钥
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key pem type %q", block.Type)
+	}
+}
+
+// parsePublicKey 解析 PKIX 或 PKCS#1 公钥
+func parsePublicKey(block *pem.Block) (crypto.PublicKey, error) {
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		return key, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key pem type %q", block.Type)
+	}
+}
+
+// publicKeysEqual 比较两个公钥是否相同
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	comparable, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && comparable.Equal(b)
+}
+
+// jwk 将公钥转换为 JWK
+func (k *signingKey) jwk() JSONWebKey {
+	jwk := JSONWebKey{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
@@ -0,0 +1,252 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+)
+
+// ErrInvalidToken 令牌无效或已过期
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims 访问令牌声明
+type Claims struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // 登录会话标识，撤销会话时该会话签发的所有访问令牌失效
+	MFA       bool   `json:"mfa,omitempty"` // 登录时通过了两步验证，刷新时沿用登录时的结果
+	Actor     *Actor `json:"act,omitempty"` // 管理员模拟登录时实际操作的管理员（RFC 8693）
+	jwt.RegisteredClaims
+}
+
+// Actor 模拟登录令牌中实际操作的管理员
+type Actor struct {
+	Subject  string `json:"sub"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// Manager 访问令牌签发与验证，配置了非对称密钥时使用 RS256/EdDSA，否则使用 HS256
+type Manager struct {
+	secret     []byte
+	keys       map[string]*signingKey
+	signing    *signingKey
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// New 根据配置创建令牌管理器
+func New(cfg *config.Config) (*Manager, error) {
+	keys, err := loadKeys(cfg.JWT.Keys)
+	if err != nil {
+		return nil, err
+	}
+	signing, err := selectSigningKey(cfg.JWT, keys)
+	if err != nil {
+		return nil, err
+	}
+	if signing == nil && cfg.JWT.Secret == "" {
+		return nil, fmt.Errorf("jwt secret is required")
+	}
+
+	accessTTL := time.Duration(cfg.JWT.Expiration) * time.Second
+	if accessTTL <= 0 {
+		accessTTL = 15 * time.Minute
+	}
+	refreshTTL := time.Duration(cfg.JWT.RefreshExpiration) * time.Second
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+
+	return &Manager{
+		secret:     []byte(cfg.JWT.Secret),
+		keys:       keys,
+		signing:    signing,
+		issuer:     cfg.JWT.Issuer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}, nil
+}
+
+// Issue 为用户签发访问令牌，sessionID 为空表示不属于任何登录会话，mfa 表示所属登录通过了两步验证
+func (m *Manager) Issue(user *model.User, sessionID string, mfa bool) (string, *Claims, error) {
+	claims := m.newClaims(user, sessionID, m.accessTTL)
+	claims.MFA = mfa
+
+	signed, err := m.sign(claims)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, claims, nil
+}
+
+// IssueImpersonation 为管理员签发以 user 身份访问的短期令牌，act 声明记录实际操作的管理员。
+// 令牌不属于任何会话，也不能刷新，ttl 超过访问令牌有效期时按访问令牌有效期签发
+func (m *Manager) IssueImpersonation(user, actor *model.User, ttl time.Duration) (string, *Claims, error) {
+	if ttl <= 0 || ttl > m.accessTTL {
+		ttl = m.accessTTL
+	}
+	claims := m.newClaims(user, "", ttl)
+	claims.Actor = &Actor{
+		Subject:  actor.Email,
+		UserID:   actor.ID,
+		Username: actor.Username,
+	}
+
+	signed, err := m.sign(claims)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, claims, nil
+}
+
+// newClaims 创建访问令牌声明
+func (m *Manager) newClaims(user *model.User, sessionID string, ttl time.Duration) *Claims {
+	now := m.now()
+	return &Claims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    m.issuer,
+			Subject:   user.Email,
+			ID:        uuid.NewString(),
+		},
+	}
+}
+
+// Parse 验证访问令牌并返回声明
+func (m *Manager) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.verificationKey, jwt.WithTimeFunc(m.now))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// JWKS 获取验证访问令牌的公钥，使用 HS256 时密钥不能公开，返回空集合
+func (m *Manager) JWKS() *JSONWebKeySet {
+	set := &JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(m.keys))}
+	for _, key := range m.keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
+
+// sign 使用当前签名密钥签发令牌，非对称签名在头部写入 kid
+func (m *Manager) sign(claims *Claims) (string, error) {
+	if m.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	}
+
+	token := jwt.NewWithClaims(m.signing.method, claims)
+	token.Header["kid"] = m.signing.id
+	return token.SignedString(m.signing.private)
+}
+
+// verificationKey 按令牌头部的 kid 选择验证密钥，算法必须与密钥匹配以防止算法混淆
+func (m *Manager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if m.signing == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return m.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, exists := m.keys[kid]
+	if !exists {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// selectSigningKey 选择签发令牌使用的密钥，未配置非对称密钥时返回 nil
+func selectSigningKey(cfg config.JWTConfig, keys map[string]*signingKey) (*signingKey, error) {
+	if cfg.SigningKeyID != "" {
+		key, exists := keys[cfg.SigningKeyID]
+		if !exists {
+			return nil, fmt.Errorf("jwt signing key %q is not configured", cfg.SigningKeyID)
+		}
+		if key.private == nil {
+			return nil, fmt.Errorf("jwt signing key %q has no private key", cfg.SigningKeyID)
+		}
+		return key, nil
+	}
+
+	for _, keyCfg := range cfg.Keys {
+		if key := keys[keyCfg.ID]; key.private != nil {
+			return key, nil
+		}
+	}
+	if len(keys) > 0 {
+		return nil, fmt.Errorf("no jwt key with a private key is configured")
+	}
+	return nil, nil
+}
+
+// AccessTTL 访问令牌有效期
+func (m *Manager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+// RefreshTTL 刷新令牌有效期
+func (m *Manager) RefreshTTL() time.Duration {
+	return m.refreshTTL
+}
+
+// RevokedTokenKey 已撤销访问令牌的缓存键
+func RevokedTokenKey(jti string) string {
+	return "token_blacklist:" + jti
+}
+
+// RevokedSessionKey 已撤销会话的缓存键
+func RevokedSessionKey(sessionID string) string {
+	return "session_blacklist:" + sessionID
+}
+
+// NewOpaque 生成随机不透明令牌，用于刷新令牌等只在服务端保存哈希的场景
+func NewOpaque() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash 计算不透明令牌的哈希
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
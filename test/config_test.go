@@ -0,0 +1,23 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+)
+
+func TestLoadConfigRegistrationMode(t *testing.T) {
+	cfg, err := config.LoadConfig("../configs/config.sqlite.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "open", cfg.Auth.RegistrationMode)
+
+	// 拼写错误的注册方式在加载时报错，不会按开放注册运行
+	for _, mode := range []string{"invite-only", "Closed"} {
+		t.Setenv("VIBE_AUTH_REGISTRATION_MODE", mode)
+		_, err := config.LoadConfig("../configs/config.sqlite.yaml")
+		assert.ErrorContains(t, err, "auth.registration_mode", mode)
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// AdminUserHandlerTestSuite 管理员用户管理处理器测试套件
+type AdminUserHandlerTestSuite struct {
+	suite.Suite
+	adminUserService *mocks.MockAdminUserService
+	logger           *mocks.MockLogger
+	handler          *handler.AdminUserHandler
+	router           *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *AdminUserHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.adminUserService = new(mocks.MockAdminUserService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewAdminUserHandler(suite.adminUserService, suite.logger)
+
+	// 模拟认证中间件写入的管理员上下文
+	suite.router = gin.New()
+	admin := suite.router.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", model.UserRoleAdmin)
+		c.Next()
+	})
+	suite.handler.RegisterAdminRoutes(admin)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *AdminUserHandlerTestSuite) SetupTest() {
+	suite.adminUserService.ExpectedCalls = nil
+	suite.adminUserService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// request 发送请求
+func (suite *AdminUserHandlerTestSuite) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateUser 测试创建用户不返回密码
+func (suite *AdminUserHandlerTestSuite) TestCreateUser() {
+	suite.adminUserService.On("CreateUser", mock.Anything, uint(1), mock.MatchedBy(func(req *service.AdminCreateUserRequest) bool {
+		return req.Username == "editor" && req.Role == model.UserRoleAdmin
+	})).Return(&model.User{BaseModel: model.BaseModel{ID: 5}, Username: "editor", Email: "editor@example.com", Password: "hashed", Role: model.UserRoleAdmin}, nil)
+	suite.adminUserService.On("CreateUser", mock.Anything, uint(1), mock.MatchedBy(func(req *service.AdminCreateUserRequest) bool {
+		return req.Username == "taken"
+	})).Return(nil, service.ErrUserExists)
+
+	w := suite.request(http.MethodPost, "/api/v1/admin/users", map[string]interface{}{
+		"username": "editor",
+		"email":    "editor@example.com",
+		"password": "password123",
+		"role":     "admin",
+	})
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "hashed")
+
+	w = suite.request(http.MethodPost, "/api/v1/admin/users", map[string]interface{}{"username": "taken"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	suite.adminUserService.AssertExpectations(suite.T())
+}
+
+// TestUpdateStatus 测试修改用户状态时传入当前管理员
+func (suite *AdminUserHandlerTestSuite) TestUpdateStatus() {
+	suite.adminUserService.On("UpdateStatus", mock.Anything, uint(1), uint(7), model.UserStatusBanned).
+		Return(&model.User{BaseModel: model.BaseModel{ID: 7}, Status: model.UserStatusBanned}, nil)
+	suite.adminUserService.On("UpdateStatus", mock.Anything, uint(1), uint(1), model.UserStatusBanned).
+		Return(nil, service.ErrCannotModifySelf)
+	suite.adminUserService.On("UpdateStatus", mock.Anything, uint(1), uint(7), "deleted").
+		Return(nil, service.ErrInvalidUserInput)
+	suite.adminUserService.On("UpdateStatus", mock.Anything, uint(1), uint(2), model.UserStatusBanned).
+		Return(nil, service.ErrPermissionNotHeld)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodPut, "/api/v1/admin/users/7/status", map[string]interface{}{"status": "banned"}).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodPut, "/api/v1/admin/users/1/status", map[string]interface{}{"status": "banned"}).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodPut, "/api/v1/admin/users/7/status", map[string]interface{}{"status": "deleted"}).Code)
+	assert.Equal(suite.T(), http.StatusForbidden, suite.request(http.MethodPut, "/api/v1/admin/users/2/status", map[string]interface{}{"status": "banned"}).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodPut, "/api/v1/admin/users/abc/status", map[string]interface{}{"status": "banned"}).Code)
+	suite.adminUserService.AssertExpectations(suite.T())
+}
+
+// TestUpdateRole 测试修改用户角色
+func (suite *AdminUserHandlerTestSuite) TestUpdateRole() {
+	suite.adminUserService.On("UpdateRole", mock.Anything, uint(1), uint(7), model.UserRoleAdmin).
+		Return(&model.User{BaseModel: model.BaseModel{ID: 7}, Role: model.UserRoleAdmin}, nil)
+	suite.adminUserService.On("UpdateRole", mock.Anything, uint(1), uint(9), model.UserRoleAdmin).
+		Return(nil, service.ErrUserNotFound)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodPut, "/api/v1/admin/users/7/role", map[string]interface{}{"role": "admin"}).Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodPut, "/api/v1/admin/users/9/role", map[string]interface{}{"role": "admin"}).Code)
+	suite.adminUserService.AssertExpectations(suite.T())
+}
+
+// TestForcePasswordReset 测试强制重置密码
+func (suite *AdminUserHandlerTestSuite) TestForcePasswordReset() {
+	suite.adminUserService.On("ForcePasswordReset", mock.Anything, uint(1), uint(7)).Return(nil)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodPost, "/api/v1/admin/users/7/password-reset", nil).Code)
+	suite.adminUserService.AssertExpectations(suite.T())
+}
+
+// TestImpersonate 测试模拟登录
+func (suite *AdminUserHandlerTestSuite) TestImpersonate() {
+	suite.adminUserService.On("Impersonate", mock.Anything, uint(1), uint(7)).Return(&service.ImpersonationResponse{
+		User:           &model.PublicUser{ID: 7, Username: "member"},
+		Token:          "impersonation-token",
+		TokenType:      "Bearer",
+		ExpiresIn:      900,
+		ImpersonatorID: 1,
+	}, nil)
+	suite.adminUserService.On("Impersonate", mock.Anything, uint(1), uint(2)).Return(nil, service.ErrImpersonationNotAllowed)
+
+	w := suite.request(http.MethodPost, "/api/v1/admin/users/7/impersonate", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "impersonation-token", response["token"])
+	assert.Equal(suite.T(), float64(1), response["impersonator_id"])
+
+	assert.Equal(suite.T(), http.StatusForbidden, suite.request(http.MethodPost, "/api/v1/admin/users/2/impersonate", nil).Code)
+	suite.adminUserService.AssertExpectations(suite.T())
+}
+
+// TestAdminUserHandlerSuite 运行管理员用户管理处理器测试套件
+func TestAdminUserHandlerSuite(t *testing.T) {
+	suite.Run(t, new(AdminUserHandlerTestSuite))
+}
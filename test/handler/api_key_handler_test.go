@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// APIKeyHandlerTestSuite 个人 API Key 处理器测试套件
+type APIKeyHandlerTestSuite struct {
+	suite.Suite
+	apiKeyService *mocks.MockAPIKeyService
+	logger        *mocks.MockLogger
+	handler       *handler.APIKeyHandler
+	router        *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *APIKeyHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.apiKeyService = new(mocks.MockAPIKeyService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewAPIKeyHandler(suite.apiKeyService, suite.logger)
+
+	// 模拟认证中间件写入的上下文
+	suite.router = gin.New()
+	api := suite.router.Group("/api/v1")
+	api.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(3))
+		c.Next()
+	})
+	suite.handler.RegisterRoutes(api)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *APIKeyHandlerTestSuite) SetupTest() {
+	suite.apiKeyService.ExpectedCalls = nil
+	suite.apiKeyService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// request 发送请求
+func (suite *APIKeyHandlerTestSuite) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateMyAPIKey 测试创建 API Key 只在响应中返回一次明文
+func (suite *APIKeyHandlerTestSuite) TestCreateMyAPIKey() {
+	suite.apiKeyService.On("Create", mock.Anything, uint(3), mock.MatchedBy(func(req *service.CreateAPIKeyRequest) bool {
+		return req.Name == "ci" && len(req.Scopes) == 1 && req.Scopes[0] == "article:read"
+	})).Return(&service.CreatedAPIKey{
+		APIKey: &model.APIKey{ID: 5, UserID: 3, Name: "ci", Prefix: "vk_abcdefghi", KeyHash: "hash", Scopes: []string{"article:read"}},
+		Key:    "vk_abcdefghijklmnop",
+	}, nil)
+
+	w := suite.request(http.MethodPost, "/api/v1/users/me/api-keys", map[string]interface{}{
+		"name":   "ci",
+		"scopes": []string{"article:read"},
+	})
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "vk_abcdefghijklmnop", response["key"])
+	apiKey := response["api_key"].(map[string]interface{})
+	assert.Equal(suite.T(), "vk_abcdefghi", apiKey["prefix"])
+	assert.NotContains(suite.T(), apiKey, "key_hash")
+	suite.apiKeyService.AssertExpectations(suite.T())
+}
+
+// TestCreateMyAPIKeyErrors 测试创建 API Key 的错误响应
+func (suite *APIKeyHandlerTestSuite) TestCreateMyAPIKeyErrors() {
+	w := suite.request(http.MethodPost, "/api/v1/users/me/api-keys", map[string]interface{}{"scopes": []string{"article:read"}})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	suite.apiKeyService.On("Create", mock.Anything, uint(3), mock.MatchedBy(func(req *service.CreateAPIKeyRequest) bool {
+		return req.Name == "bad"
+	})).Return(nil, service.ErrInvalidAPIKeyScope)
+	suite.apiKeyService.On("Create", mock.Anything, uint(3), mock.MatchedBy(func(req *service.CreateAPIKeyRequest) bool {
+		return req.Name == "many"
+	})).Return(nil, service.ErrAPIKeyLimitReached)
+
+	w = suite.request(http.MethodPost, "/api/v1/users/me/api-keys", map[string]interface{}{"name": "bad", "scopes": []string{"x"}})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request(http.MethodPost, "/api/v1/users/me/api-keys", map[string]interface{}{"name": "many", "scopes": []string{"article:read"}})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+// TestListMyAPIKeys 测试获取当前用户的 API Key
+func (suite *APIKeyHandlerTestSuite) TestListMyAPIKeys() {
+	suite.apiKeyService.On("List", mock.Anything, uint(3)).Return([]*model.APIKey{
+		{ID: 1, UserID: 3, Name: "ci", Prefix: "vk_abcdefghi", KeyHash: "hash", Scopes: []string{"article:read"}},
+	}, nil)
+
+	w := suite.request(http.MethodGet, "/api/v1/users/me/api-keys", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var keys []map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &keys))
+	require.Len(suite.T(), keys, 1)
+	assert.Equal(suite.T(), []interface{}{"article:read"}, keys[0]["scopes"])
+	assert.NotContains(suite.T(), keys[0], "key_hash")
+}
+
+// TestRevokeMyAPIKey 测试撤销当前用户的 API Key
+func (suite *APIKeyHandlerTestSuite) TestRevokeMyAPIKey() {
+	suite.apiKeyService.On("Revoke", mock.Anything, uint(3), uint(2)).Return(nil)
+	suite.apiKeyService.On("Revoke", mock.Anything, uint(3), uint(9)).Return(service.ErrAPIKeyNotFound)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodDelete, "/api/v1/users/me/api-keys/2", nil).Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodDelete, "/api/v1/users/me/api-keys/9", nil).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodDelete, "/api/v1/users/me/api-keys/abc", nil).Code)
+
+	suite.apiKeyService.AssertExpectations(suite.T())
+}
+
+// TestAPIKeyHandlerSuite 运行个人 API Key 处理器测试套件
+func TestAPIKeyHandlerSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyHandlerTestSuite))
+}
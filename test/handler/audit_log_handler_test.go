@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/test/mocks"
+)
+
+// AuditLogHandlerTestSuite 审计日志处理器测试套件
+type AuditLogHandlerTestSuite struct {
+	suite.Suite
+	auditService *mocks.MockAuditService
+	logger       *mocks.MockLogger
+	handler      *handler.AuditLogHandler
+	router       *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *AuditLogHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.auditService = new(mocks.MockAuditService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewAuditLogHandler(suite.auditService, suite.logger)
+
+	suite.router = gin.New()
+	suite.handler.RegisterAdminRoutes(suite.router.Group("/api/v1/admin"))
+}
+
+// SetupTest 每个测试前的设置
+func (suite *AuditLogHandlerTestSuite) SetupTest() {
+	suite.auditService.ExpectedCalls = nil
+	suite.auditService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// request 发送请求
+func (suite *AuditLogHandlerTestSuite) request(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestList 测试查询参数转换为过滤条件
+func (suite *AuditLogHandlerTestSuite) TestList() {
+	actorID := uint(7)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	suite.auditService.On("List", mock.Anything, mock.MatchedBy(func(opts repository.ListOptions) bool {
+		return opts.Page == 2 && opts.PageSize == 100 &&
+			opts.Filters["actor_id"] == actorID &&
+			opts.Filters["action"] == "article.update" &&
+			opts.Filters["resource_type"] == "article" &&
+			opts.Filters["resource_id"] == "3" &&
+			opts.Filters["created_after"] == from &&
+			opts.Filters["created_before"] == nil
+	})).Return([]*model.AuditLog{
+		{ID: 1, ActorID: &actorID, Action: "article.update", ResourceType: "article", ResourceID: "3", Changes: map[string]model.AuditChange{
+			"title": {From: "Draft", To: "Published"},
+		}},
+	}, int64(101), nil)
+
+	w := suite.request("/api/v1/admin/audit-logs?actor_id=7&action=article.update&resource_type=article&resource_id=3&from=2026-10-01T00:00:00Z&page=2&page_size=500")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(suite.T(), float64(101), body["total"])
+	assert.Contains(suite.T(), w.Body.String(), `"changes":{"title":{"from":"Draft","to":"Published"}}`)
+	suite.auditService.AssertExpectations(suite.T())
+}
+
+// TestListInvalidQuery 测试查询参数格式错误
+func (suite *AuditLogHandlerTestSuite) TestListInvalidQuery() {
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request("/api/v1/admin/audit-logs?actor_id=abc").Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request("/api/v1/admin/audit-logs?from=yesterday").Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request("/api/v1/admin/audit-logs?to=2026-10-01").Code)
+	suite.auditService.AssertNotCalled(suite.T(), "List", mock.Anything, mock.Anything)
+}
+
+// TestAuditLogHandlerSuite 运行审计日志处理器测试套件
+func TestAuditLogHandlerSuite(t *testing.T) {
+	suite.Run(t, new(AuditLogHandlerTestSuite))
+}
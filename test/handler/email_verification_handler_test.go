@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// EmailVerificationHandlerTestSuite 邮箱验证处理器测试套件
+type EmailVerificationHandlerTestSuite struct {
+	suite.Suite
+	verificationService *mocks.MockEmailVerificationService
+	logger              *mocks.MockLogger
+	handler             *handler.EmailVerificationHandler
+	router              *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *EmailVerificationHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.verificationService = new(mocks.MockEmailVerificationService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewEmailVerificationHandler(suite.verificationService, suite.logger)
+
+	suite.router = gin.New()
+	suite.router.POST("/api/v1/users/email/verify", suite.handler.Verify)
+	suite.router.POST("/api/v1/users/email/resend", suite.handler.Resend)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *EmailVerificationHandlerTestSuite) SetupTest() {
+	suite.verificationService.ExpectedCalls = nil
+	suite.verificationService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// post 发送 JSON 请求
+func (suite *EmailVerificationHandlerTestSuite) post(path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestVerify 测试验证邮箱
+func (suite *EmailVerificationHandlerTestSuite) TestVerify() {
+	verifiedAt := time.Now()
+	req := &service.VerifyEmailRequest{Token: "token"}
+	suite.verificationService.On("Verify", mock.Anything, req).Return(&model.User{
+		BaseModel:       model.BaseModel{ID: 7},
+		Username:        "pending",
+		Status:          model.UserStatusActive,
+		EmailVerifiedAt: &verifiedAt,
+	}, nil)
+
+	w := suite.post("/api/v1/users/email/verify", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var user model.PublicUser
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(suite.T(), uint(7), user.ID)
+	assert.Equal(suite.T(), model.UserStatusActive, user.Status)
+	assert.NotNil(suite.T(), user.EmailVerifiedAt)
+}
+
+// TestVerifyInvalidToken 测试无效验证令牌
+func (suite *EmailVerificationHandlerTestSuite) TestVerifyInvalidToken() {
+	req := &service.VerifyEmailRequest{Token: "used"}
+	suite.verificationService.On("Verify", mock.Anything, req).Return(nil, service.ErrInvalidVerificationToken)
+
+	w := suite.post("/api/v1/users/email/verify", req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var response handler.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "invalid_verification_token", response.Error)
+
+	w = suite.post("/api/v1/users/email/verify", map[string]string{})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestResend 测试重发验证邮件，服务出错时响应保持一致
+func (suite *EmailVerificationHandlerTestSuite) TestResend() {
+	req := &service.ResendVerificationRequest{Email: "pending@example.com"}
+	suite.verificationService.On("Resend", mock.Anything, req).Return(nil).Once()
+
+	w := suite.post("/api/v1/users/email/resend", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	expected := w.Body.String()
+
+	suite.verificationService.On("Resend", mock.Anything, req).Return(errors.New("smtp unavailable")).Once()
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w = suite.post("/api/v1/users/email/resend", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), expected, w.Body.String())
+	suite.verificationService.AssertExpectations(suite.T())
+}
+
+// TestResendTooSoon 测试重发验证邮件限流
+func (suite *EmailVerificationHandlerTestSuite) TestResendTooSoon() {
+	req := &service.ResendVerificationRequest{Email: "pending@example.com"}
+	suite.verificationService.On("Resend", mock.Anything, req).Return(service.ErrVerificationResendTooSoon)
+
+	w := suite.post("/api/v1/users/email/resend", req)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+
+	w = suite.post("/api/v1/users/email/resend", map[string]string{})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestEmailVerificationHandlerSuite 运行邮箱验证处理器测试套件
+func TestEmailVerificationHandlerSuite(t *testing.T) {
+	suite.Run(t, new(EmailVerificationHandlerTestSuite))
+}
@@ -3,11 +3,15 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +22,7 @@ import (
 	"vibe-coding-starter/internal/handler"
 	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/signer"
 	"vibe-coding-starter/test/mocks"
 )
 
@@ -47,11 +52,14 @@ func (suite *FileHandlerTestSuite) SetupSuite() {
 	suite.router = gin.New()
 	api := suite.router.Group("/api/v1")
 	suite.handler.RegisterRoutes(api)
+	suite.handler.RegisterAdminRoutes(api.Group("/admin"))
+	suite.handler.RegisterPublicRoutes(api)
 }
 
 // SetupTest 每个测试前的设置
 func (suite *FileHandlerTestSuite) SetupTest() {
 	suite.fileService.ExpectedCalls = nil
+	suite.fileService.Calls = nil
 	suite.logger.ExpectedCalls = nil
 }
 
@@ -69,7 +77,9 @@ func (suite *FileHandlerTestSuite) TestUpload() {
 	}
 
 	// Mock 文件服务
-	suite.fileService.On("Upload", mock.Anything, mock.AnythingOfType("*service.UploadRequest")).Return(file, nil)
+	suite.fileService.On("Upload", mock.Anything, mock.MatchedBy(func(req *service.UploadRequest) bool {
+		return req.OwnerID == userID
+	})).Return(file, nil)
 
 	// 创建multipart请求
 	body := &bytes.Buffer{}
@@ -104,6 +114,45 @@ func (suite *FileHandlerTestSuite) TestUpload() {
 	suite.fileService.AssertExpectations(suite.T())
 }
 
+// TestUploadPolicyViolation 测试违反上传策略时返回违反的规则
+func (suite *FileHandlerTestSuite) TestUploadPolicyViolation() {
+	userID := uint(1)
+
+	// Mock 文件服务按角色拒绝
+	suite.fileService.On("Upload", mock.Anything, mock.MatchedBy(func(req *service.UploadRequest) bool {
+		return req.OwnerRole == model.UserRoleUser
+	})).Return(nil, &service.PolicyError{
+		Rule:    service.PolicyRuleRoleMaxSize,
+		Message: "file size exceeds the limit of 10 bytes",
+		Limit:   10,
+	})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "notes.txt")
+	part.Write([]byte("more than ten bytes"))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/files/upload", body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	c.Set("user_id", userID)
+	c.Set("user_role", model.UserRoleUser)
+
+	suite.handler.Upload(c)
+
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+
+	var response handler.PolicyErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "upload_policy_violation", response.Error)
+	require.NotNil(suite.T(), response.Policy)
+	assert.Equal(suite.T(), service.PolicyRuleRoleMaxSize, response.Policy.Rule)
+	assert.Equal(suite.T(), int64(10), response.Policy.Limit)
+	suite.fileService.AssertExpectations(suite.T())
+}
+
 // TestUploadNoFile 测试上传时没有文件
 func (suite *FileHandlerTestSuite) TestUploadNoFile() {
 	userID := uint(1)
@@ -218,12 +267,14 @@ func (suite *FileHandlerTestSuite) TestDownload() {
 	}
 
 	downloadResponse := &service.DownloadResponse{
-		File:     file,
-		FileData: []byte("fake image data"),
+		File:    file,
+		Content: nopReadSeekCloser{bytes.NewReader([]byte("fake image data"))},
 	}
 
 	// Mock 文件服务
+	suite.fileService.On("GetByID", mock.Anything, fileID).Return(file, nil)
 	suite.fileService.On("Download", mock.Anything, fileID).Return(downloadResponse, nil)
+	suite.fileService.On("RecordDownload", mock.Anything, fileID).Return(nil)
 
 	// 创建请求
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+strconv.Itoa(int(fileID))+"/download", nil)
@@ -236,12 +287,115 @@ func (suite *FileHandlerTestSuite) TestDownload() {
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 	assert.Equal(suite.T(), file.MimeType, w.Header().Get("Content-Type"))
 	assert.Equal(suite.T(), "attachment; filename="+file.OriginalName, w.Header().Get("Content-Disposition"))
-	assert.Equal(suite.T(), downloadResponse.FileData, w.Body.Bytes())
+	assert.Equal(suite.T(), "fake image data", w.Body.String())
 
 	// 验证mock调用
 	suite.fileService.AssertExpectations(suite.T())
 }
 
+// TestDownloadRange 测试按范围下载文件
+func (suite *FileHandlerTestSuite) TestDownloadRange() {
+	fileID := uint(1)
+	file := &model.File{
+		BaseModel:    model.BaseModel{ID: fileID},
+		OriginalName: "test.txt",
+		Size:         10,
+		MimeType:     "text/plain",
+		Hash:         "abc123",
+		IsPublic:     true,
+	}
+
+	// Mock 文件服务
+	suite.fileService.On("GetByID", mock.Anything, fileID).Return(file, nil)
+	suite.fileService.On("Download", mock.Anything, fileID).Return(&service.DownloadResponse{
+		File:    file,
+		Content: nopReadSeekCloser{bytes.NewReader([]byte("0123456789"))},
+	}, nil)
+
+	// 创建范围请求
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+strconv.Itoa(int(fileID))+"/download", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	w := httptest.NewRecorder()
+
+	// 执行请求
+	suite.router.ServeHTTP(w, req)
+
+	// 验证响应
+	assert.Equal(suite.T(), http.StatusPartialContent, w.Code)
+	assert.Equal(suite.T(), "bytes 2-5/10", w.Header().Get("Content-Range"))
+	assert.Equal(suite.T(), "4", w.Header().Get("Content-Length"))
+	assert.Equal(suite.T(), `"abc123"`, w.Header().Get("ETag"))
+	assert.Equal(suite.T(), "2345", w.Body.String())
+
+	// 验证mock调用，范围请求不计入下载次数
+	suite.fileService.AssertExpectations(suite.T())
+	suite.fileService.AssertNotCalled(suite.T(), "RecordDownload", mock.Anything, mock.Anything)
+}
+
+// TestDownloadNotModified 测试哈希匹配时返回 304
+func (suite *FileHandlerTestSuite) TestDownloadNotModified() {
+	fileID := uint(1)
+	file := &model.File{
+		BaseModel:    model.BaseModel{ID: fileID},
+		OriginalName: "test.txt",
+		Size:         10,
+		MimeType:     "text/plain",
+		Hash:         "abc123",
+		IsPublic:     true,
+	}
+
+	// Mock 文件服务
+	suite.fileService.On("GetByID", mock.Anything, fileID).Return(file, nil)
+	suite.fileService.On("Download", mock.Anything, fileID).Return(&service.DownloadResponse{
+		File:    file,
+		Content: nopReadSeekCloser{bytes.NewReader([]byte("0123456789"))},
+	}, nil)
+
+	// 创建条件请求
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+strconv.Itoa(int(fileID))+"/download", nil)
+	req.Header.Set("If-None-Match", `"abc123"`)
+	w := httptest.NewRecorder()
+
+	// 执行请求
+	suite.router.ServeHTTP(w, req)
+
+	// 验证响应
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+	assert.Empty(suite.T(), w.Body.Bytes())
+
+	// 验证mock调用，304 响应不计入下载次数
+	suite.fileService.AssertExpectations(suite.T())
+	suite.fileService.AssertNotCalled(suite.T(), "RecordDownload", mock.Anything, mock.Anything)
+}
+
+// TestDownloadForbidden 测试下载他人的私有文件被拒绝且不计入下载次数
+func (suite *FileHandlerTestSuite) TestDownloadForbidden() {
+	fileID := uint(2)
+	suite.fileService.On("GetByID", mock.Anything, fileID).Return(&model.File{BaseModel: model.BaseModel{ID: fileID}, IsPublic: false, OwnerID: 2}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+strconv.Itoa(int(fileID))+"/download", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	// 无权访问时不打开存储对象
+	suite.fileService.AssertNotCalled(suite.T(), "Download", mock.Anything, mock.Anything)
+	suite.fileService.AssertNotCalled(suite.T(), "RecordDownload", mock.Anything, mock.Anything)
+}
+
+// TestDownloadNotFound 测试文件不存在时不返回内部错误信息
+func (suite *FileHandlerTestSuite) TestDownloadNotFound() {
+	suite.fileService.On("GetByID", mock.Anything, uint(9)).Return(nil, errors.New("failed to get file: record not found in files table"))
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/9/download", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "files table")
+}
+
 // TestList 测试获取文件列表
 func (suite *FileHandlerTestSuite) TestList() {
 	files := []*model.File{
@@ -265,7 +419,7 @@ func (suite *FileHandlerTestSuite) TestList() {
 	suite.fileService.On("List", mock.Anything, mock.AnythingOfType("repository.ListOptions")).Return(files, int64(2), nil)
 
 	// 创建请求
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/files?page=1&page_size=10", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/files?page=1&page_size=10", nil)
 	w := httptest.NewRecorder()
 
 	// 执行请求
@@ -285,6 +439,80 @@ func (suite *FileHandlerTestSuite) TestList() {
 	suite.fileService.AssertExpectations(suite.T())
 }
 
+// TestListUserFiles 测试获取当前用户的文件列表
+func (suite *FileHandlerTestSuite) TestListUserFiles() {
+	userID := uint(1)
+	files := []*model.File{
+		{
+			BaseModel:    model.BaseModel{ID: 1},
+			Name:         "file1.jpg",
+			OriginalName: "file1.jpg",
+			Size:         1024,
+			OwnerID:      userID,
+		},
+	}
+
+	// Mock 文件服务
+	suite.fileService.On("GetByOwner", mock.Anything, userID, mock.AnythingOfType("repository.ListOptions")).Return(files, int64(1), nil)
+
+	// 创建请求
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files?page=1&page_size=10", nil)
+	w := httptest.NewRecorder()
+
+	// 创建Gin上下文并设置用户ID
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("user_id", userID)
+
+	// 直接调用处理器方法
+	suite.handler.ListUserFiles(c)
+
+	// 验证响应
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handler.ListResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), response.Total)
+
+	// 验证mock调用
+	suite.fileService.AssertExpectations(suite.T())
+}
+
+// TestGetByIDForbidden 测试获取他人的私有文件
+func (suite *FileHandlerTestSuite) TestGetByIDForbidden() {
+	fileID := uint(2)
+	file := &model.File{
+		BaseModel: model.BaseModel{ID: fileID},
+		Name:      "private.pdf",
+		IsPublic:  false,
+		OwnerID:   2,
+	}
+
+	// Mock 文件服务
+	suite.fileService.On("GetByID", mock.Anything, fileID).Return(file, nil)
+
+	// 创建请求
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+strconv.Itoa(int(fileID)), nil)
+	w := httptest.NewRecorder()
+
+	// 创建Gin上下文并设置用户ID和路由参数
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("user_id", uint(1))
+	c.Set("user_role", "user")
+	c.Params = gin.Params{gin.Param{Key: "id", Value: strconv.Itoa(int(fileID))}}
+
+	// 直接调用处理器方法
+	suite.handler.GetByID(c)
+
+	// 验证响应
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	// 验证mock调用
+	suite.fileService.AssertExpectations(suite.T())
+}
+
 // TestDelete 测试删除文件
 func (suite *FileHandlerTestSuite) TestDelete() {
 	fileID := uint(1)
@@ -355,6 +583,152 @@ func (suite *FileHandlerTestSuite) TestDeleteServiceError() {
 	suite.logger.AssertExpectations(suite.T())
 }
 
+// TestDownloadSigned 测试通过签名链接下载私有文件
+func (suite *FileHandlerTestSuite) TestDownloadSigned() {
+	fileID := uint(1)
+	file := &model.File{
+		BaseModel:    model.BaseModel{ID: fileID},
+		OriginalName: "private.txt",
+		MimeType:     "text/plain",
+		IsPublic:     false,
+		OwnerID:      2,
+	}
+
+	// Mock 签名验证与下载，请求不带用户信息
+	suite.fileService.On("VerifySignedURL", mock.Anything, fileID, mock.MatchedBy(func(query url.Values) bool {
+		return query.Get("signature") == "valid"
+	})).Return(nil)
+	suite.fileService.On("Download", mock.Anything, fileID).Return(&service.DownloadResponse{
+		File:    file,
+		Content: nopReadSeekCloser{bytes.NewReader([]byte("secret"))},
+	}, nil)
+	suite.fileService.On("RecordDownload", mock.Anything, fileID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/1/signed?expires=1&kid=k1&signature=valid", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "secret", w.Body.String())
+	assert.Equal(suite.T(), "private, no-store", w.Header().Get("Cache-Control"))
+	suite.fileService.AssertExpectations(suite.T())
+}
+
+// TestDownloadSignedInvalid 测试签名无效时拒绝下载
+func (suite *FileHandlerTestSuite) TestDownloadSignedInvalid() {
+	suite.fileService.On("VerifySignedURL", mock.Anything, uint(1), mock.Anything).Return(signer.ErrSignatureExpired)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/1/signed?expires=1&kid=k1&signature=old", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	suite.fileService.AssertNotCalled(suite.T(), "Download", mock.Anything, mock.Anything)
+}
+
+// TestCreateSignedURL 测试所有者生成签名链接
+func (suite *FileHandlerTestSuite) TestCreateSignedURL() {
+	userID := uint(2)
+	file := &model.File{BaseModel: model.BaseModel{ID: 1}, OwnerID: userID}
+	expiresAt := time.Now().Add(time.Hour)
+
+	suite.fileService.On("GetByID", mock.Anything, uint(1)).Return(file, nil)
+	suite.fileService.On("CreateSignedURL", mock.Anything, uint(1), 10*time.Minute).Return(&service.SignedURLResponse{
+		URL:       "/api/v1/files/1/signed?signature=abc",
+		ExpiresAt: expiresAt,
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files/1/signed-url", strings.NewReader(`{"expires_in":600}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Set("user_id", userID)
+
+	suite.handler.CreateSignedURL(c)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response service.SignedURLResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "/api/v1/files/1/signed?signature=abc", response.URL)
+
+	// 非所有者不能生成链接
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/files/1/signed-url", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Set("user_id", uint(3))
+
+	suite.handler.CreateSignedURL(c)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+// TestGetVariant 测试获取公开图片的变体
+func (suite *FileHandlerTestSuite) TestGetVariant() {
+	file := &model.File{
+		BaseModel: model.BaseModel{ID: 1},
+		MimeType:  "image/jpeg",
+		Hash:      "abc123",
+		IsPublic:  true,
+		OwnerID:   2,
+	}
+
+	suite.fileService.On("GetByID", mock.Anything, uint(1)).Return(file, nil)
+	suite.fileService.On("GetVariant", mock.Anything, uint(1), "thumbnail").Return(&service.VariantResponse{
+		File:     file,
+		Name:     "thumbnail",
+		MimeType: "image/jpeg",
+		Content:  nopReadSeekCloser{bytes.NewReader([]byte("thumb"))},
+		ModTime:  time.Now(),
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/1/variants/thumbnail", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "thumb", w.Body.String())
+	assert.Equal(suite.T(), "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `"abc123-thumbnail"`, w.Header().Get("ETag"))
+	suite.fileService.AssertExpectations(suite.T())
+}
+
+// TestGetVariantErrors 测试私有文件、未知变体和非图片文件
+func (suite *FileHandlerTestSuite) TestGetVariantErrors() {
+	private := &model.File{BaseModel: model.BaseModel{ID: 1}, OwnerID: 2}
+	document := &model.File{BaseModel: model.BaseModel{ID: 2}, MimeType: "text/plain", IsPublic: true}
+
+	suite.fileService.On("GetByID", mock.Anything, uint(1)).Return(private, nil)
+	suite.fileService.On("GetByID", mock.Anything, uint(2)).Return(document, nil)
+	suite.fileService.On("GetVariant", mock.Anything, uint(2), "huge").Return(nil, service.ErrVariantNotFound)
+	suite.fileService.On("GetVariant", mock.Anything, uint(2), "thumbnail").Return(nil, service.ErrNotImage)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/v1/files/1/variants/thumbnail", http.StatusForbidden},
+		{"/api/v1/files/2/variants/huge", http.StatusNotFound},
+		{"/api/v1/files/2/variants/thumbnail", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(suite.T(), tt.status, w.Code, tt.path)
+	}
+	suite.fileService.AssertNotCalled(suite.T(), "GetVariant", mock.Anything, uint(1), mock.Anything)
+}
+
+// nopReadSeekCloser 为 bytes.Reader 提供空的 Close 方法
+type nopReadSeekCloser struct {
+	*bytes.Reader
+}
+
+func (nopReadSeekCloser) Close() error { return nil }
+
 // TestFileHandlerTestSuite 运行测试套件
 func TestFileHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(FileHandlerTestSuite))
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// InvitationHandlerTestSuite 注册邀请处理器测试套件
+type InvitationHandlerTestSuite struct {
+	suite.Suite
+	invitationService *mocks.MockInvitationService
+	logger            *mocks.MockLogger
+	handler           *handler.InvitationHandler
+	router            *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *InvitationHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.invitationService = new(mocks.MockInvitationService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewInvitationHandler(suite.invitationService, suite.logger)
+
+	// 模拟认证中间件写入的管理员上下文
+	suite.router = gin.New()
+	suite.handler.RegisterPublicRoutes(suite.router.Group("/api/v1"))
+	admin := suite.router.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", model.UserRoleAdmin)
+		c.Next()
+	})
+	suite.handler.RegisterAdminRoutes(admin)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *InvitationHandlerTestSuite) SetupTest() {
+	suite.invitationService.ExpectedCalls = nil
+	suite.invitationService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// request 发送请求
+func (suite *InvitationHandlerTestSuite) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestLookup 测试邀请信息只返回预填注册表单需要的字段
+func (suite *InvitationHandlerTestSuite) TestLookup() {
+	suite.invitationService.On("Lookup", mock.Anything, "plain-token").Return(&model.Invitation{
+		ID: 3, Email: "editor@example.com", Role: model.UserRoleAdmin, TokenHash: "hashed", ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	suite.invitationService.On("Lookup", mock.Anything, "used-token").Return(nil, service.ErrInvalidInvitation)
+
+	w := suite.request(http.MethodGet, "/api/v1/users/invitation?token=plain-token", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(suite.T(), "editor@example.com", body["email"])
+	assert.Equal(suite.T(), model.UserRoleAdmin, body["role"])
+	assert.NotContains(suite.T(), body, "id")
+	assert.NotContains(suite.T(), w.Body.String(), "hashed")
+
+	w = suite.request(http.MethodGet, "/api/v1/users/invitation?token=used-token", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "invalid_invitation")
+}
+
+// TestCreate 测试创建邀请
+func (suite *InvitationHandlerTestSuite) TestCreate() {
+	suite.invitationService.On("Create", mock.Anything, uint(1), mock.MatchedBy(func(req *service.CreateInvitationRequest) bool {
+		return req.Email == "editor@example.com" && req.Role == model.UserRoleAdmin
+	})).Return(&service.CreatedInvitation{
+		Invitation: &model.Invitation{ID: 3, Email: "editor@example.com", Role: model.UserRoleAdmin, TokenHash: "hashed"},
+		Link:       "https://app.example.com/register?invitation=plain-token",
+	}, nil)
+
+	w := suite.request(http.MethodPost, "/api/v1/admin/invitations", map[string]interface{}{
+		"email": "editor@example.com",
+		"role":  model.UserRoleAdmin,
+	})
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "register?invitation=plain-token")
+	assert.NotContains(suite.T(), w.Body.String(), "hashed")
+	suite.invitationService.AssertExpectations(suite.T())
+}
+
+// TestCreateErrors 测试创建邀请的错误响应
+func (suite *InvitationHandlerTestSuite) TestCreateErrors() {
+	suite.invitationService.On("Create", mock.Anything, uint(1), mock.MatchedBy(func(req *service.CreateInvitationRequest) bool {
+		return req.Email == "admin@example.com"
+	})).Return(nil, service.ErrUserExists)
+	suite.invitationService.On("Create", mock.Anything, uint(1), mock.MatchedBy(func(req *service.CreateInvitationRequest) bool {
+		return req.Email == "new@example.com"
+	})).Return(nil, service.ErrInvalidUserInput)
+
+	w := suite.request(http.MethodPost, "/api/v1/admin/invitations", map[string]interface{}{"email": "admin@example.com"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "user_exists")
+
+	w = suite.request(http.MethodPost, "/api/v1/admin/invitations", map[string]interface{}{"email": "new@example.com", "role": "owner"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestList 测试查询参数转换为过滤条件
+func (suite *InvitationHandlerTestSuite) TestList() {
+	suite.invitationService.On("List", mock.Anything, mock.MatchedBy(func(opts repository.ListOptions) bool {
+		return opts.Page == 2 && opts.PageSize == 100 &&
+			opts.Filters["email"] == "editor@example.com" &&
+			opts.Filters["status"] == model.InvitationStatusPending
+	})).Return([]*model.Invitation{
+		{ID: 3, Email: "editor@example.com", Role: model.UserRoleUser, Status: model.InvitationStatusPending},
+	}, int64(101), nil)
+
+	w := suite.request(http.MethodGet, "/api/v1/admin/invitations?email=editor@example.com&status=pending&page=2&page_size=500", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(suite.T(), float64(101), body["total"])
+	assert.Contains(suite.T(), w.Body.String(), `"status":"pending"`)
+
+	w = suite.request(http.MethodGet, "/api/v1/admin/invitations?status=unknown", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.invitationService.AssertNumberOfCalls(suite.T(), "List", 1)
+}
+
+// TestRevoke 测试撤销邀请
+func (suite *InvitationHandlerTestSuite) TestRevoke() {
+	suite.invitationService.On("Revoke", mock.Anything, uint(1), uint(3)).Return(nil)
+	suite.invitationService.On("Revoke", mock.Anything, uint(1), uint(4)).Return(service.ErrInvitationNotPending)
+	suite.invitationService.On("Revoke", mock.Anything, uint(1), uint(5)).Return(service.ErrInvitationNotFound)
+	suite.invitationService.On("Revoke", mock.Anything, uint(1), uint(6)).Return(errors.New("db down"))
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodDelete, "/api/v1/admin/invitations/3", nil).Code)
+	assert.Equal(suite.T(), http.StatusConflict, suite.request(http.MethodDelete, "/api/v1/admin/invitations/4", nil).Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodDelete, "/api/v1/admin/invitations/5", nil).Code)
+	assert.Equal(suite.T(), http.StatusInternalServerError, suite.request(http.MethodDelete, "/api/v1/admin/invitations/6", nil).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodDelete, "/api/v1/admin/invitations/abc", nil).Code)
+}
+
+// TestInvitationHandlerSuite 运行注册邀请处理器测试套件
+func TestInvitationHandlerSuite(t *testing.T) {
+	suite.Run(t, new(InvitationHandlerTestSuite))
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// MFAHandlerTestSuite 两步验证处理器测试套件
+type MFAHandlerTestSuite struct {
+	suite.Suite
+	userService *mocks.MockUserService
+	logger      *mocks.MockLogger
+	handler     *handler.MFAHandler
+	router      *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *MFAHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.userService = new(mocks.MockUserService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewMFAHandler(suite.userService, suite.logger)
+
+	suite.router = gin.New()
+	suite.router.POST("/api/v1/users/login/mfa", suite.handler.Login)
+
+	// 模拟认证中间件写入的上下文
+	protected := suite.router.Group("/api/v1")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(5))
+		c.Set("session_id", "session-5")
+		c.Next()
+	})
+	suite.handler.RegisterRoutes(protected)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *MFAHandlerTestSuite) SetupTest() {
+	suite.userService.ExpectedCalls = nil
+	suite.userService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// post 发送 JSON 请求
+func (suite *MFAHandlerTestSuite) post(path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestLogin 测试两步验证登录
+func (suite *MFAHandlerTestSuite) TestLogin() {
+	suite.userService.On("LoginMFA", mock.Anything, mock.MatchedBy(func(req *service.MFALoginRequest) bool {
+		return req.MFAToken == "pending" && req.Code == "123456"
+	})).Return(&service.LoginResponse{Token: "access", RefreshToken: "refresh"}, nil).Once()
+
+	w := suite.post("/api/v1/users/login/mfa", map[string]string{"mfa_token": "pending", "code": "123456"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response service.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "access", response.Token)
+
+	// 验证码错误
+	suite.userService.On("LoginMFA", mock.Anything, mock.Anything).Return(nil, service.ErrInvalidMFACode).Once()
+	w = suite.post("/api/v1/users/login/mfa", map[string]string{"mfa_token": "pending", "code": "000000"})
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	var errResponse handler.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(suite.T(), "invalid_mfa_code", errResponse.Error)
+
+	// 缺少参数
+	w = suite.post("/api/v1/users/login/mfa", map[string]string{"mfa_token": "pending"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestEnroll 测试开始绑定身份验证器
+func (suite *MFAHandlerTestSuite) TestEnroll() {
+	suite.userService.On("EnrollMFA", mock.Anything, uint(5)).Return(&service.MFAEnrollment{
+		Secret: "JBSWY3DPEHPK3PXP",
+		URI:    "otpauth://totp/Vibe:user?secret=JBSWY3DPEHPK3PXP",
+	}, nil).Once()
+
+	w := suite.post("/api/v1/users/me/mfa", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "otpauth_uri")
+
+	suite.userService.On("EnrollMFA", mock.Anything, uint(5)).Return(nil, service.ErrMFAAlreadyEnabled).Once()
+	w = suite.post("/api/v1/users/me/mfa", nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+// TestConfirm 测试确认开启两步验证
+func (suite *MFAHandlerTestSuite) TestConfirm() {
+	req := &service.MFACodeRequest{Code: "123456"}
+	suite.userService.On("ConfirmMFA", mock.Anything, uint(5), "session-5", req).Return(&service.MFARecoveryCodes{
+		RecoveryCodes: []string{"abcde-fghjk"},
+	}, nil).Once()
+
+	w := suite.post("/api/v1/users/me/mfa/confirm", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var codes service.MFARecoveryCodes
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &codes))
+	assert.Equal(suite.T(), []string{"abcde-fghjk"}, codes.RecoveryCodes)
+
+	suite.userService.On("ConfirmMFA", mock.Anything, uint(5), "session-5", req).Return(nil, service.ErrInvalidMFACode).Once()
+	w = suite.post("/api/v1/users/me/mfa/confirm", req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.post("/api/v1/users/me/mfa/confirm", map[string]string{})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestDisable 测试关闭两步验证
+func (suite *MFAHandlerTestSuite) TestDisable() {
+	req := &service.DisableMFARequest{Password: "password", Code: "123456"}
+	suite.userService.On("DisableMFA", mock.Anything, uint(5), req).Return(nil).Once()
+
+	w := suite.post("/api/v1/users/me/mfa/disable", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	suite.userService.On("DisableMFA", mock.Anything, uint(5), req).Return(service.ErrInvalidPassword).Once()
+	w = suite.post("/api/v1/users/me/mfa/disable", req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var response handler.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "invalid_password", response.Error)
+}
+
+// TestMFAHandlerSuite 运行两步验证处理器测试套件
+func TestMFAHandlerSuite(t *testing.T) {
+	suite.Run(t, new(MFAHandlerTestSuite))
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// OAuthHandlerTestSuite 第三方登录处理器测试套件
+type OAuthHandlerTestSuite struct {
+	suite.Suite
+	userService *mocks.MockUserService
+	logger      *mocks.MockLogger
+	handler     *handler.OAuthHandler
+	router      *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *OAuthHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.userService = new(mocks.MockUserService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewOAuthHandler(suite.userService, suite.logger)
+
+	suite.router = gin.New()
+	suite.handler.RegisterPublicRoutes(suite.router.Group("/api/v1"))
+
+	// 模拟认证中间件写入的上下文
+	protected := suite.router.Group("/api/v1")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(5))
+		c.Next()
+	})
+	suite.handler.RegisterRoutes(protected)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *OAuthHandlerTestSuite) SetupTest() {
+	suite.userService.ExpectedCalls = nil
+	suite.userService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// get 发送 GET 请求
+func (suite *OAuthHandlerTestSuite) get(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestListProviders 测试获取提供方列表
+func (suite *OAuthHandlerTestSuite) TestListProviders() {
+	suite.userService.On("ListOAuthProviders", mock.Anything).Return([]*service.OAuthProvider{
+		{Name: "google", DisplayName: "Google"},
+	}).Once()
+
+	w := suite.get("/api/v1/users/oauth/providers")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var providers []service.OAuthProvider
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &providers))
+	require.Len(suite.T(), providers, 1)
+	assert.Equal(suite.T(), "google", providers[0].Name)
+}
+
+// TestAuthorize 测试重定向到提供方
+func (suite *OAuthHandlerTestSuite) TestAuthorize() {
+	suite.userService.On("StartOAuthLogin", mock.Anything, "google").Return(&service.OAuthAuthorization{
+		AuthorizationURL: "https://accounts.example.com/authorize?state=abc",
+		State:            "abc",
+		Binding:          "abc-hash",
+		ExpiresIn:        10 * time.Minute,
+	}, nil).Once()
+
+	w := suite.get("/api/v1/users/oauth/google")
+	assert.Equal(suite.T(), http.StatusFound, w.Code)
+	assert.Equal(suite.T(), "https://accounts.example.com/authorize?state=abc", w.Header().Get("Location"))
+
+	// state 的哈希写入只在第三方登录路由下发送的 HttpOnly Cookie
+	cookies := w.Result().Cookies()
+	require.Len(suite.T(), cookies, 1)
+	assert.Equal(suite.T(), "oauth_state", cookies[0].Name)
+	assert.Equal(suite.T(), "abc-hash", cookies[0].Value)
+	assert.Equal(suite.T(), "/api/v1/users/oauth", cookies[0].Path)
+	assert.Equal(suite.T(), 600, cookies[0].MaxAge)
+	assert.True(suite.T(), cookies[0].HttpOnly)
+	assert.Equal(suite.T(), http.SameSiteLaxMode, cookies[0].SameSite)
+
+	suite.userService.On("StartOAuthLogin", mock.Anything, "missing").Return(nil, service.ErrOAuthProviderNotFound).Once()
+	w = suite.get("/api/v1/users/oauth/missing")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestCallback 测试登录回调
+func (suite *OAuthHandlerTestSuite) TestCallback() {
+	suite.userService.On("CompleteOAuthLogin", mock.Anything, mock.MatchedBy(func(req *service.OAuthCallbackRequest) bool {
+		return req.Provider == "google" && req.Code == "code" && req.State == "state" && req.Binding == "state-hash"
+	})).Return(&service.LoginResponse{Token: "access", RefreshToken: "refresh"}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/oauth/google/callback?code=code&state=state", nil)
+	req.AddCookie(&http.Cookie{Name: "oauth_state", Value: "state-hash"})
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	// 回调后清除 state Cookie
+	cookies := w.Result().Cookies()
+	require.Len(suite.T(), cookies, 1)
+	assert.Equal(suite.T(), "oauth_state", cookies[0].Name)
+	assert.Less(suite.T(), cookies[0].MaxAge, 0)
+
+	var response service.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "access", response.Token)
+
+	// 用户在提供方拒绝授权
+	w = suite.get("/api/v1/users/oauth/google/callback?error=access_denied")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var errResponse handler.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(suite.T(), "oauth_denied", errResponse.Error)
+
+	// 缺少参数
+	w = suite.get("/api/v1/users/oauth/google/callback?code=code")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestCallbackErrors 测试登录回调错误映射
+func (suite *OAuthHandlerTestSuite) TestCallbackErrors() {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{service.ErrInvalidOAuthState, http.StatusBadRequest, "invalid_state"},
+		{service.ErrOAuthFailed, http.StatusUnauthorized, "oauth_failed"},
+		{service.ErrOAuthEmailNotVerified, http.StatusForbidden, "email_not_verified"},
+		{service.ErrOAuthAccountNotFound, http.StatusForbidden, "account_not_found"},
+		{service.ErrAccountNotActive, http.StatusForbidden, "account_not_active"},
+		{errors.New("database down"), http.StatusInternalServerError, "login_failed"},
+	}
+
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	for _, tc := range cases {
+		suite.userService.On("CompleteOAuthLogin", mock.Anything, mock.Anything).Return(nil, tc.err).Once()
+
+		w := suite.get("/api/v1/users/oauth/google/callback?code=code&state=state")
+		assert.Equal(suite.T(), tc.status, w.Code, tc.code)
+
+		var response handler.ErrorResponse
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(suite.T(), tc.code, response.Error)
+	}
+}
+
+// TestListIdentities 测试获取关联的第三方账户
+func (suite *OAuthHandlerTestSuite) TestListIdentities() {
+	now := time.Now()
+	suite.userService.On("ListIdentities", mock.Anything, uint(5)).Return([]*model.UserIdentity{
+		{ID: 1, UserID: 5, Provider: "google", Subject: "secret-subject", Email: "user@example.com", LastLoginAt: &now},
+	}, nil).Once()
+
+	w := suite.get("/api/v1/users/me/identities")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "google")
+	assert.NotContains(suite.T(), w.Body.String(), "secret-subject")
+}
+
+// TestOAuthHandlerSuite 运行第三方登录处理器测试套件
+func TestOAuthHandlerSuite(t *testing.T) {
+	suite.Run(t, new(OAuthHandlerTestSuite))
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// PasswordResetHandlerTestSuite 密码重置处理器测试套件
+type PasswordResetHandlerTestSuite struct {
+	suite.Suite
+	resetService *mocks.MockPasswordResetService
+	logger       *mocks.MockLogger
+	handler      *handler.PasswordResetHandler
+	router       *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *PasswordResetHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.resetService = new(mocks.MockPasswordResetService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewPasswordResetHandler(suite.resetService, suite.logger)
+
+	suite.router = gin.New()
+	suite.router.POST("/api/v1/users/password/forgot", suite.handler.Forgot)
+	suite.router.POST("/api/v1/users/password/reset", suite.handler.Reset)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *PasswordResetHandlerTestSuite) SetupTest() {
+	suite.resetService.ExpectedCalls = nil
+	suite.resetService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// post 发送 JSON 请求
+func (suite *PasswordResetHandlerTestSuite) post(path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestForgot 测试申请重置密码，服务出错时响应保持一致
+func (suite *PasswordResetHandlerTestSuite) TestForgot() {
+	req := &service.ForgotPasswordRequest{Email: "user@example.com"}
+	suite.resetService.On("Forgot", mock.Anything, req).Return(nil).Once()
+
+	w := suite.post("/api/v1/users/password/forgot", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	expected := w.Body.String()
+
+	suite.resetService.On("Forgot", mock.Anything, req).Return(errors.New("database unavailable")).Once()
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	w = suite.post("/api/v1/users/password/forgot", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), expected, w.Body.String())
+	suite.resetService.AssertExpectations(suite.T())
+
+	w = suite.post("/api/v1/users/password/forgot", map[string]string{})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestReset 测试重置密码
+func (suite *PasswordResetHandlerTestSuite) TestReset() {
+	req := &service.ResetPasswordRequest{Token: "token", NewPassword: "newpassword123"}
+	suite.resetService.On("Reset", mock.Anything, req).Return(nil)
+
+	w := suite.post("/api/v1/users/password/reset", req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.resetService.AssertExpectations(suite.T())
+}
+
+// TestResetErrors 测试重置密码失败
+func (suite *PasswordResetHandlerTestSuite) TestResetErrors() {
+	invalid := &service.ResetPasswordRequest{Token: "used", NewPassword: "newpassword123"}
+	short := &service.ResetPasswordRequest{Token: "token", NewPassword: "123"}
+	suite.resetService.On("Reset", mock.Anything, invalid).Return(service.ErrInvalidResetToken)
+	suite.resetService.On("Reset", mock.Anything, short).Return(service.ErrPasswordTooShort)
+
+	w := suite.post("/api/v1/users/password/reset", invalid)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	var resp handler.ErrorResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), "invalid_reset_token", resp.Error)
+
+	w = suite.post("/api/v1/users/password/reset", short)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), "weak_password", resp.Error)
+
+	w = suite.post("/api/v1/users/password/reset", map[string]string{"token": "token"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestPasswordResetHandlerSuite 运行密码重置处理器测试套件
+func TestPasswordResetHandlerSuite(t *testing.T) {
+	suite.Run(t, new(PasswordResetHandlerTestSuite))
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// PrivacyHandlerTestSuite 个人数据导出与账户注销处理器测试套件
+type PrivacyHandlerTestSuite struct {
+	suite.Suite
+	privacyService *mocks.MockPrivacyService
+	logger         *mocks.MockLogger
+	handler        *handler.PrivacyHandler
+	router         *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *PrivacyHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.privacyService = new(mocks.MockPrivacyService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewPrivacyHandler(suite.privacyService, suite.logger)
+
+	suite.router = gin.New()
+	public := suite.router.Group("/api/v1")
+	suite.handler.RegisterPublicRoutes(public)
+
+	// 模拟认证中间件写入的上下文
+	protected := suite.router.Group("/api/v1")
+	protected.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(3))
+		c.Next()
+	})
+	suite.handler.RegisterRoutes(protected)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *PrivacyHandlerTestSuite) SetupTest() {
+	suite.privacyService.ExpectedCalls = nil
+	suite.privacyService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// request 发送请求
+func (suite *PrivacyHandlerTestSuite) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestRequestExport 测试申请导出和频率限制
+func (suite *PrivacyHandlerTestSuite) TestRequestExport() {
+	suite.privacyService.On("RequestExport", mock.Anything, uint(3)).Return(nil).Once()
+	w := suite.request(http.MethodPost, "/api/v1/users/me/export", nil)
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+
+	suite.privacyService.On("RequestExport", mock.Anything, uint(3)).Return(service.ErrExportTooFrequent).Once()
+	w = suite.request(http.MethodPost, "/api/v1/users/me/export", nil)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "export_too_frequent")
+}
+
+// TestDownloadExport 测试使用令牌下载导出文件
+func (suite *PrivacyHandlerTestSuite) TestDownloadExport() {
+	suite.privacyService.On("OpenExport", mock.Anything, "valid").Return(&service.ExportDownload{
+		FileName:  "data-export-3-20261016.zip",
+		Content:   nopReadSeekCloser{bytes.NewReader([]byte("zip-content"))},
+		CreatedAt: time.Now(),
+	}, nil)
+	suite.privacyService.On("OpenExport", mock.Anything, "expired").Return(nil, service.ErrInvalidExportToken)
+
+	w := suite.request(http.MethodGet, "/api/v1/users/export/download?token=valid", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "zip-content", w.Body.String())
+	assert.Equal(suite.T(), "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "attachment; filename=data-export-3-20261016.zip", w.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), "private, no-store", w.Header().Get("Cache-Control"))
+
+	w = suite.request(http.MethodGet, "/api/v1/users/export/download?token=expired", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestDeleteAccount 测试申请注销需要确认密码
+func (suite *PrivacyHandlerTestSuite) TestDeleteAccount() {
+	scheduledAt := time.Date(2026, 11, 15, 9, 0, 0, 0, time.UTC)
+	suite.privacyService.On("ScheduleDeletion", mock.Anything, uint(3), &service.DeleteAccountRequest{Password: "secret"}).
+		Return(scheduledAt, nil)
+	suite.privacyService.On("ScheduleDeletion", mock.Anything, uint(3), &service.DeleteAccountRequest{Password: "wrong"}).
+		Return(time.Time{}, service.ErrInvalidPassword)
+
+	w := suite.request(http.MethodDelete, "/api/v1/users/me", map[string]string{"password": "secret"})
+	require.Equal(suite.T(), http.StatusAccepted, w.Code)
+	assert.JSONEq(suite.T(), `{"deletion_scheduled_at":"2026-11-15T09:00:00Z"}`, w.Body.String())
+
+	w = suite.request(http.MethodDelete, "/api/v1/users/me", map[string]string{"password": "wrong"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "invalid_password")
+
+	w = suite.request(http.MethodDelete, "/api/v1/users/me", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.privacyService.AssertNumberOfCalls(suite.T(), "ScheduleDeletion", 2)
+}
+
+// TestCancelDeletion 测试撤销注销申请
+func (suite *PrivacyHandlerTestSuite) TestCancelDeletion() {
+	suite.privacyService.On("CancelDeletion", mock.Anything, uint(3)).Return(nil).Once()
+	w := suite.request(http.MethodDelete, "/api/v1/users/me/deletion", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	suite.privacyService.On("CancelDeletion", mock.Anything, uint(3)).Return(service.ErrDeletionNotScheduled).Once()
+	w = suite.request(http.MethodDelete, "/api/v1/users/me/deletion", nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+// TestPrivacyHandlerSuite 运行个人数据导出与账户注销处理器测试套件
+func TestPrivacyHandlerSuite(t *testing.T) {
+	suite.Run(t, new(PrivacyHandlerTestSuite))
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// RoleHandlerTestSuite 角色与权限管理处理器测试套件
+type RoleHandlerTestSuite struct {
+	suite.Suite
+	roleService *mocks.MockRoleService
+	logger      *mocks.MockLogger
+	handler     *handler.RoleHandler
+	router      *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *RoleHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.roleService = new(mocks.MockRoleService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewRoleHandler(suite.roleService, suite.logger)
+
+	// 模拟认证中间件写入的操作者
+	suite.router = gin.New()
+	admin := suite.router.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	suite.handler.RegisterAdminRoutes(admin)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *RoleHandlerTestSuite) SetupTest() {
+	suite.roleService.ExpectedCalls = nil
+	suite.roleService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// request 发送请求
+func (suite *RoleHandlerTestSuite) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateRole 测试创建角色
+func (suite *RoleHandlerTestSuite) TestCreateRole() {
+	suite.roleService.On("CreateRole", mock.Anything, uint(1), mock.MatchedBy(func(req *service.CreateRoleRequest) bool {
+		return req.Name == "editor"
+	})).Return(&model.Role{ID: 3, Name: "editor", Permissions: []model.Permission{{Resource: "article", Action: "update"}}}, nil)
+	suite.roleService.On("CreateRole", mock.Anything, uint(1), mock.MatchedBy(func(req *service.CreateRoleRequest) bool {
+		return req.Name == "admin"
+	})).Return(nil, service.ErrRoleExists)
+	suite.roleService.On("CreateRole", mock.Anything, uint(1), mock.MatchedBy(func(req *service.CreateRoleRequest) bool {
+		return req.Name == "bad"
+	})).Return(nil, service.ErrInvalidPermission)
+
+	w := suite.request(http.MethodPost, "/api/v1/admin/roles", map[string]interface{}{
+		"name":        "editor",
+		"permissions": []string{"article:update"},
+	})
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var role map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &role))
+	assert.Equal(suite.T(), "editor", role["name"])
+	assert.Len(suite.T(), role["permissions"], 1)
+
+	assert.Equal(suite.T(), http.StatusConflict, suite.request(http.MethodPost, "/api/v1/admin/roles", map[string]interface{}{"name": "admin"}).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodPost, "/api/v1/admin/roles", map[string]interface{}{"name": "bad"}).Code)
+	suite.roleService.AssertExpectations(suite.T())
+}
+
+// TestUpdateRole 测试 permissions 为 null 时不修改权限
+func (suite *RoleHandlerTestSuite) TestUpdateRole() {
+	suite.roleService.On("UpdateRole", mock.Anything, uint(1), uint(3), mock.MatchedBy(func(req *service.UpdateRoleRequest) bool {
+		return req.Permissions == nil && req.DisplayName != nil && *req.DisplayName == "Editor"
+	})).Return(&model.Role{ID: 3, Name: "editor", DisplayName: "Editor"}, nil)
+	suite.roleService.On("UpdateRole", mock.Anything, uint(1), uint(4), mock.MatchedBy(func(req *service.UpdateRoleRequest) bool {
+		return req.Permissions != nil && len(req.Permissions) == 0
+	})).Return(&model.Role{ID: 4, Name: "viewer"}, nil)
+	suite.roleService.On("UpdateRole", mock.Anything, uint(1), uint(9), mock.Anything).Return(nil, service.ErrRoleNotFound)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodPut, "/api/v1/admin/roles/3", map[string]interface{}{"display_name": "Editor"}).Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodPut, "/api/v1/admin/roles/4", map[string]interface{}{"permissions": []string{}}).Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodPut, "/api/v1/admin/roles/9", map[string]interface{}{}).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodPut, "/api/v1/admin/roles/abc", map[string]interface{}{}).Code)
+	suite.roleService.AssertExpectations(suite.T())
+}
+
+// TestDeleteRole 测试删除角色，内置角色返回冲突
+func (suite *RoleHandlerTestSuite) TestDeleteRole() {
+	suite.roleService.On("DeleteRole", mock.Anything, uint(3)).Return(nil)
+	suite.roleService.On("DeleteRole", mock.Anything, uint(1)).Return(service.ErrSystemRole)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodDelete, "/api/v1/admin/roles/3", nil).Code)
+	assert.Equal(suite.T(), http.StatusConflict, suite.request(http.MethodDelete, "/api/v1/admin/roles/1", nil).Code)
+	suite.roleService.AssertExpectations(suite.T())
+}
+
+// TestSetUserRoles 测试设置用户角色
+func (suite *RoleHandlerTestSuite) TestSetUserRoles() {
+	suite.roleService.On("SetUserRoles", mock.Anything, uint(1), uint(7), mock.MatchedBy(func(req *service.SetUserRolesRequest) bool {
+		return len(req.Roles) == 2
+	})).Return([]*model.Role{{ID: 3, Name: "editor"}, {ID: 4, Name: "uploader"}}, nil)
+	suite.roleService.On("SetUserRoles", mock.Anything, uint(1), uint(8), mock.Anything).Return(nil, service.ErrUserNotFound)
+	suite.roleService.On("SetUserRoles", mock.Anything, uint(1), uint(9), mock.Anything).Return(nil, fmt.Errorf("%w: *:*", service.ErrPermissionNotHeld))
+	suite.roleService.On("SetUserRoles", mock.Anything, uint(1), uint(1), mock.Anything).Return(nil, service.ErrCannotModifySelf)
+
+	w := suite.request(http.MethodPut, "/api/v1/admin/users/7/roles", map[string]interface{}{"roles": []string{"editor", "uploader"}})
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var roles []map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &roles))
+	assert.Len(suite.T(), roles, 2)
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodPut, "/api/v1/admin/users/8/roles", map[string]interface{}{"roles": []string{"editor"}}).Code)
+	w = suite.request(http.MethodPut, "/api/v1/admin/users/9/roles", map[string]interface{}{"roles": []string{"admin"}})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "permission_not_held")
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(http.MethodPut, "/api/v1/admin/users/1/roles", map[string]interface{}{"roles": []string{"admin"}}).Code)
+	suite.roleService.AssertExpectations(suite.T())
+}
+
+// TestListPermissions 测试获取所有权限
+func (suite *RoleHandlerTestSuite) TestListPermissions() {
+	suite.roleService.On("ListPermissions", mock.Anything).Return([]*model.Permission{
+		{ID: 1, Resource: "article", Action: "read"},
+	}, nil)
+
+	w := suite.request(http.MethodGet, "/api/v1/admin/permissions", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"resource":"article"`)
+}
+
+// TestRoleHandlerSuite 运行角色与权限管理处理器测试套件
+func TestRoleHandlerSuite(t *testing.T) {
+	suite.Run(t, new(RoleHandlerTestSuite))
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// SessionHandlerTestSuite 登录会话处理器测试套件
+type SessionHandlerTestSuite struct {
+	suite.Suite
+	tokenService *mocks.MockTokenService
+	logger       *mocks.MockLogger
+	handler      *handler.SessionHandler
+	router       *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *SessionHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.tokenService = new(mocks.MockTokenService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewSessionHandler(suite.tokenService, suite.logger)
+
+	// 模拟认证中间件写入的上下文
+	suite.router = gin.New()
+	api := suite.router.Group("/api/v1")
+	api.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(3))
+		c.Set("session_id", "family-current")
+		c.Set("token_id", "jti-current")
+		c.Next()
+	})
+	suite.handler.RegisterRoutes(api)
+	suite.handler.RegisterAdminRoutes(api.Group("/admin"))
+}
+
+// SetupTest 每个测试前的设置
+func (suite *SessionHandlerTestSuite) SetupTest() {
+	suite.tokenService.ExpectedCalls = nil
+	suite.tokenService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// request 发送请求
+func (suite *SessionHandlerTestSuite) request(method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// TestLogout 测试退出登录
+func (suite *SessionHandlerTestSuite) TestLogout() {
+	suite.tokenService.On("Logout", mock.Anything, uint(3), "family-current", "jti-current").Return(nil)
+
+	w := suite.request(http.MethodPost, "/api/v1/users/logout")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.tokenService.AssertExpectations(suite.T())
+}
+
+// TestListMySessions 测试获取当前用户会话并标记当前会话
+func (suite *SessionHandlerTestSuite) TestListMySessions() {
+	suite.tokenService.On("ListSessions", mock.Anything, uint(3)).Return([]*model.UserSession{
+		{ID: 1, UserID: 3, FamilyID: "family-current", Device: "Chrome on macOS"},
+		{ID: 2, UserID: 3, FamilyID: "family-other", Device: "Firefox on Windows"},
+	}, nil)
+
+	w := suite.request(http.MethodGet, "/api/v1/users/me/sessions")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var sessions []map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &sessions))
+	require.Len(suite.T(), sessions, 2)
+	assert.Equal(suite.T(), true, sessions[0]["current"])
+	assert.Equal(suite.T(), false, sessions[1]["current"])
+	assert.NotContains(suite.T(), sessions[0], "family_id")
+}
+
+// TestRevokeMySession 测试撤销当前用户的会话
+func (suite *SessionHandlerTestSuite) TestRevokeMySession() {
+	suite.tokenService.On("RevokeSession", mock.Anything, uint(3), uint(2)).Return(nil)
+	suite.tokenService.On("RevokeSession", mock.Anything, uint(3), uint(9)).Return(service.ErrSessionNotFound)
+
+	w := suite.request(http.MethodDelete, "/api/v1/users/me/sessions/2")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request(http.MethodDelete, "/api/v1/users/me/sessions/9")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request(http.MethodDelete, "/api/v1/users/me/sessions/abc")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	suite.tokenService.AssertExpectations(suite.T())
+}
+
+// TestRevokeUserSessions 测试管理员撤销用户所有会话
+func (suite *SessionHandlerTestSuite) TestRevokeUserSessions() {
+	suite.tokenService.On("RevokeAllSessions", mock.Anything, uint(5)).Return(2, nil)
+
+	w := suite.request(http.MethodDelete, "/api/v1/admin/users/5/sessions")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), float64(2), response["revoked"])
+	suite.tokenService.AssertExpectations(suite.T())
+}
+
+// TestSessionHandlerTestSuite 运行登录会话处理器测试套件
+func TestSessionHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionHandlerTestSuite))
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// StorageQuotaHandlerTestSuite 存储配额处理器测试套件
+type StorageQuotaHandlerTestSuite struct {
+	suite.Suite
+	quotaService *mocks.MockStorageQuotaService
+	logger       *mocks.MockLogger
+	handler      *handler.StorageQuotaHandler
+	router       *gin.Engine
+}
+
+// SetupSuite 设置测试套件
+func (suite *StorageQuotaHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.quotaService = new(mocks.MockStorageQuotaService)
+	suite.logger = new(mocks.MockLogger)
+	suite.handler = handler.NewStorageQuotaHandler(suite.quotaService, suite.logger)
+
+	suite.router = gin.New()
+	api := suite.router.Group("/api/v1")
+	suite.handler.RegisterRoutes(api)
+	suite.handler.RegisterAdminRoutes(api.Group("/admin"))
+}
+
+// SetupTest 每个测试前的设置
+func (suite *StorageQuotaHandlerTestSuite) SetupTest() {
+	suite.quotaService.ExpectedCalls = nil
+	suite.quotaService.Calls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// TestGetMyUsage 测试获取当前用户的存储用量
+func (suite *StorageQuotaHandlerTestSuite) TestGetMyUsage() {
+	userID := uint(3)
+	suite.quotaService.On("GetUsage", mock.Anything, userID).Return(&service.StorageUsageResponse{
+		UserID:         userID,
+		UsedBytes:      40,
+		QuotaBytes:     100,
+		RemainingBytes: 60,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users/me/storage", nil)
+	c.Set("user_id", userID)
+
+	suite.handler.GetMyUsage(c)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response service.StorageUsageResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(40), response.UsedBytes)
+	assert.Equal(suite.T(), int64(60), response.RemainingBytes)
+
+	// 未认证时拒绝访问
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/me/storage", nil))
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+// TestSetUserQuota 测试管理员设置与恢复用户配额
+func (suite *StorageQuotaHandlerTestSuite) TestSetUserQuota() {
+	suite.quotaService.On("SetQuota", mock.Anything, uint(3), mock.MatchedBy(func(quota *int64) bool {
+		return quota != nil && *quota == 500
+	})).Return(&service.StorageUsageResponse{UserID: 3, QuotaBytes: 500, IsOverride: true}, nil)
+	suite.quotaService.On("SetQuota", mock.Anything, uint(3), (*int64)(nil)).Return(&service.StorageUsageResponse{UserID: 3, QuotaBytes: 100}, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/3/storage", strings.NewReader(`{"quota_bytes":500}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response service.StorageUsageResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(suite.T(), response.IsOverride)
+
+	// quota_bytes 为 null 时恢复角色默认配额
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/3/storage", strings.NewReader(`{"quota_bytes":null}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.quotaService.AssertExpectations(suite.T())
+}
+
+// TestStorageQuotaHandlerTestSuite 运行存储配额处理器测试套件
+func TestStorageQuotaHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(StorageQuotaHandlerTestSuite))
+}
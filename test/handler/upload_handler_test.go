@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+)
+
+// UploadHandlerTestSuite 断点续传上传处理器测试套件
+type UploadHandlerTestSuite struct {
+	suite.Suite
+	uploadService *mocks.MockResumableUploadService
+	logger        *mocks.MockLogger
+	handler       *handler.UploadHandler
+	router        *gin.Engine
+	userID        uint
+}
+
+// SetupSuite 设置测试套件
+func (suite *UploadHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	suite.uploadService = new(mocks.MockResumableUploadService)
+	suite.logger = new(mocks.MockLogger)
+	suite.userID = 1
+
+	suite.handler = handler.NewUploadHandler(suite.uploadService, suite.logger)
+
+	// 设置路由，模拟认证中间件写入用户ID
+	suite.router = gin.New()
+	api := suite.router.Group("/api/v1")
+	api.Use(func(c *gin.Context) {
+		c.Set("user_id", suite.userID)
+		c.Next()
+	})
+	suite.handler.RegisterRoutes(api)
+}
+
+// SetupTest 每个测试前的设置
+func (suite *UploadHandlerTestSuite) SetupTest() {
+	suite.uploadService.ExpectedCalls = nil
+	suite.logger.ExpectedCalls = nil
+}
+
+// TestCreate 测试创建上传会话
+func (suite *UploadHandlerTestSuite) TestCreate() {
+	session := &model.UploadSession{UploadID: "abc", TotalSize: 100, Status: model.UploadStatusUploading}
+	suite.uploadService.On("Create", mock.Anything, mock.MatchedBy(func(req *service.CreateUploadSessionRequest) bool {
+		return req.OwnerID == suite.userID && req.FileName == "video.mp4" && req.FileSize == 100
+	})).Return(session, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"file_name": "video.mp4", "file_size": 100})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/uploads", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), "/api/v1/uploads/abc", w.Header().Get("Location"))
+	assert.Equal(suite.T(), "0", w.Header().Get("Upload-Offset"))
+	assert.Equal(suite.T(), "100", w.Header().Get("Upload-Length"))
+	suite.uploadService.AssertExpectations(suite.T())
+}
+
+// TestHead 测试查询上传偏移量
+func (suite *UploadHandlerTestSuite) TestHead() {
+	session := &model.UploadSession{UploadID: "abc", TotalSize: 100, UploadedSize: 40}
+	suite.uploadService.On("Get", mock.Anything, suite.userID, "abc").Return(session, nil)
+
+	req := httptest.NewRequest(http.MethodHead, "/api/v1/uploads/abc", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "40", w.Header().Get("Upload-Offset"))
+	assert.Equal(suite.T(), "100", w.Header().Get("Upload-Length"))
+	assert.Equal(suite.T(), "no-store", w.Header().Get("Cache-Control"))
+}
+
+// TestPatch 测试上传分片
+func (suite *UploadHandlerTestSuite) TestPatch() {
+	session := &model.UploadSession{UploadID: "abc", TotalSize: 100, UploadedSize: 50}
+	suite.uploadService.On("WriteChunk", mock.Anything, suite.userID, "abc", int64(40), mock.Anything).Return(session, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/uploads/abc", strings.NewReader("0123456789"))
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "40")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), "50", w.Header().Get("Upload-Offset"))
+	suite.uploadService.AssertExpectations(suite.T())
+}
+
+// TestPatchErrors 测试分片上传的错误响应
+func (suite *UploadHandlerTestSuite) TestPatchErrors() {
+	send := func(contentType, offset string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/uploads/abc", strings.NewReader("data"))
+		req.Header.Set("Content-Type", contentType)
+		if offset != "" {
+			req.Header.Set("Upload-Offset", offset)
+		}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 内容类型错误
+	assert.Equal(suite.T(), http.StatusUnsupportedMediaType, send("application/json", "0").Code)
+
+	// 缺少偏移量
+	assert.Equal(suite.T(), http.StatusBadRequest, send("application/offset+octet-stream", "").Code)
+
+	// 偏移量不一致
+	suite.uploadService.On("WriteChunk", mock.Anything, suite.userID, "abc", int64(10), mock.Anything).Return(nil, service.ErrUploadOffsetMismatch)
+	assert.Equal(suite.T(), http.StatusConflict, send("application/offset+octet-stream", "10").Code)
+
+	// 会话已过期
+	suite.uploadService.On("WriteChunk", mock.Anything, suite.userID, "abc", int64(20), mock.Anything).Return(nil, service.ErrUploadExpired)
+	assert.Equal(suite.T(), http.StatusGone, send("application/offset+octet-stream", "20").Code)
+
+	// 超过声明大小
+	suite.uploadService.On("WriteChunk", mock.Anything, suite.userID, "abc", int64(30), mock.Anything).Return(nil, service.ErrUploadTooLarge)
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, send("application/offset+octet-stream", "30").Code)
+}
+
+// TestComplete 测试完成上传
+func (suite *UploadHandlerTestSuite) TestComplete() {
+	file := &model.File{BaseModel: model.BaseModel{ID: 7}, OriginalName: "video.mp4", OwnerID: suite.userID}
+	suite.uploadService.On("Complete", mock.Anything, suite.userID, "abc").Return(file, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/uploads/abc/complete", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response model.File
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), uint(7), response.ID)
+}
+
+// TestAbortNotFound 测试取消不存在的上传
+func (suite *UploadHandlerTestSuite) TestAbortNotFound() {
+	suite.uploadService.On("Abort", mock.Anything, suite.userID, "missing").Return(service.ErrUploadNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/uploads/missing", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestUploadHandlerTestSuite 运行断点续传上传处理器测试套件
+func TestUploadHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(UploadHandlerTestSuite))
+}
@@ -3,9 +3,11 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -26,6 +28,8 @@ type UserHandlerTestSuite struct {
 	logger      *mocks.MockLogger
 	handler     *handler.UserHandler
 	router      *gin.Engine
+	// securityEvent 最近一次请求标记的安全事件
+	securityEvent string
 }
 
 // SetupSuite 设置测试套件
@@ -44,15 +48,21 @@ func (suite *UserHandlerTestSuite) SetupSuite() {
 
 	// 设置路由
 	suite.router = gin.New()
+	suite.router.Use(func(c *gin.Context) {
+		c.Next()
+		suite.securityEvent = c.GetString("security_event")
+	})
 	api := suite.router.Group("/api/v1")
 
 	// 注册公共路由（不需要认证）
 	users := api.Group("/users")
 	users.POST("/register", suite.handler.Register)
 	users.POST("/login", suite.handler.Login)
+	users.POST("/refresh", suite.handler.RefreshToken)
 
 	// 注册需要认证的路由
 	suite.handler.RegisterRoutes(api)
+	suite.handler.RegisterAdminRoutes(api.Group("/admin"))
 }
 
 // SetupTest 每个测试前的设置
@@ -106,6 +116,58 @@ func (suite *UserHandlerTestSuite) TestRegister() {
 	suite.userService.AssertExpectations(suite.T())
 }
 
+// TestRegisterWeakPassword 测试注册密码不符合策略时返回 400
+func (suite *UserHandlerTestSuite) TestRegisterWeakPassword() {
+	reqBody := service.RegisterRequest{
+		Username: "weakuser",
+		Email:    "weak@example.com",
+		Password: "123456",
+	}
+	suite.userService.On("Register", mock.Anything, &reqBody).Return(nil, fmt.Errorf("%w, at least 8 characters required", service.ErrPasswordTooShort))
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	var response handler.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "weak_password", response.Error)
+	assert.Contains(suite.T(), response.Message, "at least 8 characters")
+}
+
+// TestRegisterClosed 测试关闭注册或需要邀请时返回 403
+func (suite *UserHandlerTestSuite) TestRegisterClosed() {
+	for _, tc := range []struct {
+		err  error
+		code string
+	}{
+		{service.ErrRegistrationClosed, "registration_closed"},
+		{service.ErrInvitationRequired, "invitation_required"},
+	} {
+		reqBody := service.RegisterRequest{
+			Username: "closeduser",
+			Email:    "closed@example.com",
+			Password: "password123",
+		}
+		suite.userService.ExpectedCalls = nil
+		suite.userService.On("Register", mock.Anything, &reqBody).Return(nil, tc.err)
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/register", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+		var response handler.ErrorResponse
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(suite.T(), tc.code, response.Error)
+	}
+}
+
 // TestRegisterInvalidJSON 测试注册时JSON格式错误
 func (suite *UserHandlerTestSuite) TestRegisterInvalidJSON() {
 	// Mock 日志
@@ -136,6 +198,7 @@ func (suite *UserHandlerTestSuite) TestLogin() {
 	reqBody := service.LoginRequest{
 		Username: "testuser",
 		Password: "password123",
+		Client:   service.ClientInfo{IP: "192.0.2.1"}, // httptest 请求的默认客户端地址
 	}
 
 	loginResponse := &service.LoginResponse{
@@ -183,6 +246,7 @@ func (suite *UserHandlerTestSuite) TestLoginServiceError() {
 	reqBody := service.LoginRequest{
 		Username: "testuser",
 		Password: "wrongpassword",
+		Client:   service.ClientInfo{IP: "192.0.2.1"}, // httptest 请求的默认客户端地址
 	}
 
 	// Mock 日志
@@ -215,6 +279,133 @@ func (suite *UserHandlerTestSuite) TestLoginServiceError() {
 	suite.logger.AssertExpectations(suite.T())
 }
 
+// TestLoginEmailNotVerified 测试邮箱未验证时登录
+func (suite *UserHandlerTestSuite) TestLoginEmailNotVerified() {
+	reqBody := service.LoginRequest{
+		Username: "pending",
+		Password: "password123",
+		Client:   service.ClientInfo{IP: "192.0.2.1"}, // httptest 请求的默认客户端地址
+	}
+
+	suite.logger.On("Debug", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return()
+	suite.userService.On("Login", mock.Anything, &reqBody).Return(nil, service.ErrEmailNotVerified)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	var response handler.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "email_not_verified", response.Error)
+	suite.userService.AssertExpectations(suite.T())
+}
+
+// TestLoginLocked 测试账户因连续登录失败被锁定
+func (suite *UserHandlerTestSuite) TestLoginLocked() {
+	reqBody := service.LoginRequest{
+		Username: "victim",
+		Password: "password123",
+		Client:   service.ClientInfo{IP: "192.0.2.1"}, // httptest 请求的默认客户端地址
+	}
+
+	suite.logger.On("Debug", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return()
+	suite.userService.On("Login", mock.Anything, &reqBody).
+		Return(nil, &service.LoginLockedError{RetryAfter: 90500 * time.Millisecond})
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	assert.Equal(suite.T(), "91", w.Header().Get("Retry-After"))
+	assert.Equal(suite.T(), "account_locked", suite.securityEvent)
+
+	var response handler.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "account_locked", response.Error)
+	suite.userService.AssertExpectations(suite.T())
+}
+
+// TestUnlockUser 测试管理员解除登录锁定
+func (suite *UserHandlerTestSuite) TestUnlockUser() {
+	suite.userService.On("UnlockUser", mock.Anything, uint(3)).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/3/unlock", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "account_unlocked", suite.securityEvent)
+
+	// 用户不存在
+	suite.userService.On("UnlockUser", mock.Anything, uint(4)).Return(service.ErrUserNotFound).Once()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/4/unlock", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// 无效的用户 ID
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/abc/unlock", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.userService.AssertExpectations(suite.T())
+}
+
+// TestRefreshToken 测试刷新令牌
+func (suite *UserHandlerTestSuite) TestRefreshToken() {
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(service.RefreshTokenRequest{RefreshToken: refreshToken})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/refresh", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	client := service.ClientInfo{IP: "192.0.2.1"}
+
+	suite.userService.On("RefreshToken", mock.Anything, &service.RefreshTokenRequest{RefreshToken: "valid", Client: client}).Return(&service.LoginResponse{
+		User:         &model.PublicUser{Username: "testuser"},
+		Token:        "new-access-token",
+		TokenType:    "Bearer",
+		ExpiresIn:    900,
+		RefreshToken: "new-refresh-token",
+	}, nil)
+	suite.userService.On("RefreshToken", mock.Anything, &service.RefreshTokenRequest{RefreshToken: "reused", Client: client}).Return(nil, service.ErrRefreshTokenReused)
+	suite.userService.On("RefreshToken", mock.Anything, &service.RefreshTokenRequest{RefreshToken: "expired", Client: client}).Return(nil, service.ErrInvalidRefreshToken)
+
+	w := refresh("valid")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response service.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "new-access-token", response.Token)
+	assert.Equal(suite.T(), "new-refresh-token", response.RefreshToken)
+
+	cases := map[string]string{
+		"reused":  "refresh_token_reused",
+		"expired": "invalid_refresh_token",
+	}
+	for refreshToken, code := range cases {
+		w = refresh(refreshToken)
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+		var errResponse handler.ErrorResponse
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &errResponse))
+		assert.Equal(suite.T(), code, errResponse.Error)
+	}
+
+	// 缺少刷新令牌
+	w = refresh("")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	suite.userService.AssertExpectations(suite.T())
+}
+
 // TestGetProfile 测试获取用户资料
 func (suite *UserHandlerTestSuite) TestGetProfile() {
 	userID := uint(1)
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/pkg/imaging"
+)
+
+// exifJPEG 在 JPEG 数据中插入带方向标签的 APP1 段
+func exifJPEG(t *testing.T, img image.Image, orientation uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	data := buf.Bytes()
+
+	// TIFF 头（大端）+ IFD0，仅包含方向标签
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1,
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, byte(orientation >> 8), byte(orientation), 0, 0,
+		0, 0, 0, 0}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+
+	result := append([]byte{}, data[:2]...)
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
+
+func TestImaging(t *testing.T) {
+	// 左半部分红色、右半部分蓝色的横向图片
+	src := image.NewRGBA(image.Rect(0, 0, 80, 40))
+	for x := 0; x < 80; x++ {
+		for y := 0; y < 40; y++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 40 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	t.Run("Orientation", func(t *testing.T) {
+		assert.Equal(t, 6, imaging.Orientation(exifJPEG(t, src, 6)))
+		assert.Equal(t, 3, imaging.Orientation(exifJPEG(t, src, 3)))
+
+		var plain bytes.Buffer
+		require.NoError(t, jpeg.Encode(&plain, src, nil))
+		assert.Equal(t, 1, imaging.Orientation(plain.Bytes()))
+		assert.Equal(t, 1, imaging.Orientation([]byte("not a jpeg")))
+	})
+
+	t.Run("Decode Applies Orientation", func(t *testing.T) {
+		// 方向 6 需要顺时针旋转 90°，旋转后左侧红色位于顶部
+		img, format, err := imaging.Decode(exifJPEG(t, src, 6), 0)
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, 40, img.Bounds().Dx())
+		assert.Equal(t, 80, img.Bounds().Dy())
+
+		r, _, b, _ := img.At(20, 10).RGBA()
+		assert.Greater(t, r, b)
+		r, _, b, _ = img.At(20, 70).RGBA()
+		assert.Greater(t, b, r)
+	})
+
+	t.Run("Decode Limits", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, src))
+
+		_, _, err := imaging.Decode(buf.Bytes(), 100)
+		assert.ErrorIs(t, err, imaging.ErrImageTooLarge)
+
+		_, _, err = imaging.Decode([]byte("plain text"), 0)
+		assert.ErrorIs(t, err, imaging.ErrUnsupportedFormat)
+	})
+
+	t.Run("Fit", func(t *testing.T) {
+		fitted := imaging.Fit(src, 20, 20)
+		assert.Equal(t, 20, fitted.Bounds().Dx())
+		assert.Equal(t, 10, fitted.Bounds().Dy())
+
+		// 不放大小图
+		assert.Equal(t, src.Bounds(), imaging.Fit(src, 200, 200).Bounds())
+	})
+
+	t.Run("Encode", func(t *testing.T) {
+		var buf bytes.Buffer
+		mimeType, ext, err := imaging.Encode(&buf, src, "jpeg", 80)
+		require.NoError(t, err)
+		assert.Equal(t, "image/jpeg", mimeType)
+		assert.Equal(t, "jpg", ext)
+
+		buf.Reset()
+		mimeType, ext, err = imaging.Encode(&buf, src, "gif", 0)
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", mimeType)
+		assert.Equal(t, "png", ext)
+
+		_, _, err = imaging.Encode(&buf, src, "webp", 0)
+		assert.ErrorIs(t, err, imaging.ErrUnsupportedFormat)
+	})
+}
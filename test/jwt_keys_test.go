@@ -0,0 +1,221 @@
+package test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/test/testutil"
+)
+
+// writeKeyPair 生成密钥并写入 PEM 文件，返回私钥和公钥文件路径
+func writeKeyPair(t *testing.T, dir, name string, private crypto.Signer) (string, string) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(dir, name+".pem")
+	publicPath := filepath.Join(dir, name+".pub.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o644))
+	return privatePath, publicPath
+}
+
+func TestAsymmetricTokens(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaPrivate, rsaPublic := writeKeyPair(t, dir, "rsa", rsaKey)
+	edPrivate, edPublic := writeKeyPair(t, dir, "ed25519", edKey)
+
+	user := &model.User{BaseModel: model.BaseModel{ID: 7}, Username: "alice", Email: "alice@example.com", Role: model.UserRoleUser}
+	newManager := func(t *testing.T, jwtCfg config.JWTConfig) *token.Manager {
+		jwtCfg.Issuer = "test-issuer"
+		tokens, err := token.New(&config.Config{JWT: jwtCfg})
+		require.NoError(t, err)
+		return tokens
+	}
+
+	t.Run("RS256", func(t *testing.T) {
+		tokens := newManager(t, config.JWTConfig{Keys: []config.JWTKeyConfig{{ID: "rsa-1", PrivateKeyFile: rsaPrivate}}})
+
+		signed, _, err := tokens.Issue(user, "session", false)
+		require.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Method.Alg())
+		assert.Equal(t, "rsa-1", parsed.Header["kid"])
+
+		claims, err := tokens.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+		assert.Equal(t, "session", claims.SessionID)
+	})
+
+	t.Run("EdDSA", func(t *testing.T) {
+		tokens := newManager(t, config.JWTConfig{Keys: []config.JWTKeyConfig{{ID: "ed-1", PrivateKeyFile: edPrivate, PublicKeyFile: edPublic}}})
+
+		signed, _, err := tokens.Issue(user, "", false)
+		require.NoError(t, err)
+		parsed, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+		_, err = tokens.Parse(signed)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Key Rotation", func(t *testing.T) {
+		// 轮换前使用 RSA 密钥签发
+		before := newManager(t, config.JWTConfig{Keys: []config.JWTKeyConfig{{ID: "rsa-1", PrivateKeyFile: rsaPrivate}}})
+		oldToken, _, err := before.Issue(user, "", false)
+		require.NoError(t, err)
+
+		// 轮换后使用 Ed25519 密钥签发，旧密钥只保留公钥用于验证
+		after := newManager(t, config.JWTConfig{
+			SigningKeyID: "ed-1",
+			Keys: []config.JWTKeyConfig{
+				{ID: "rsa-1", PublicKeyFile: rsaPublic},
+				{ID: "ed-1", PrivateKeyFile: edPrivate},
+			},
+		})
+		newToken, _, err := after.Issue(user, "", false)
+		require.NoError(t, err)
+
+		_, err = after.Parse(oldToken)
+		assert.NoError(t, err)
+		_, err = after.Parse(newToken)
+		assert.NoError(t, err)
+
+		// 旧密钥移除后其签发的令牌失效
+		_, err = before.Parse(newToken)
+		assert.ErrorIs(t, err, token.ErrInvalidToken)
+
+		jwks := after.JWKS()
+		require.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "ed-1", jwks.Keys[0].Kid)
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, "rsa-1", jwks.Keys[1].Kid)
+		assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+	})
+
+	t.Run("Rejected Tokens", func(t *testing.T) {
+		tokens := newManager(t, config.JWTConfig{
+			Secret: "legacy-secret",
+			Keys:   []config.JWTKeyConfig{{ID: "rsa-1", PrivateKeyFile: rsaPrivate}},
+		})
+		claims := jwt.MapClaims{"user_id": 7, "exp": jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+		// 配置非对称密钥后不再接受 HS256 令牌
+		hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("legacy-secret"))
+		require.NoError(t, err)
+		_, err = tokens.Parse(hmacToken)
+		assert.ErrorIs(t, err, token.ErrInvalidToken)
+
+		// 使用公钥作为 HMAC 密钥的算法混淆攻击
+		publicPEM, err := os.ReadFile(rsaPublic)
+		require.NoError(t, err)
+		confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		confused.Header["kid"] = "rsa-1"
+		confusedToken, err := confused.SignedString(publicPEM)
+		require.NoError(t, err)
+		_, err = tokens.Parse(confusedToken)
+		assert.ErrorIs(t, err, token.ErrInvalidToken)
+
+		// 未知的 kid
+		unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		unknown.Header["kid"] = "rsa-2"
+		unknownToken, err := unknown.SignedString(rsaKey)
+		require.NoError(t, err)
+		_, err = tokens.Parse(unknownToken)
+		assert.ErrorIs(t, err, token.ErrInvalidToken)
+
+		// HS256 模式不公开密钥
+		assert.Empty(t, testutil.NewTestTokenManager(t).JWKS().Keys)
+	})
+
+	t.Run("Invalid Configuration", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		_, otherPublic := writeKeyPair(t, dir, "other", otherKey)
+		weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		weakPrivate, _ := writeKeyPair(t, dir, "weak", weakKey)
+
+		cases := map[string]config.JWTConfig{
+			"missing id":        {Keys: []config.JWTKeyConfig{{PrivateKeyFile: rsaPrivate}}},
+			"duplicate id":      {Keys: []config.JWTKeyConfig{{ID: "a", PrivateKeyFile: rsaPrivate}, {ID: "a", PrivateKeyFile: edPrivate}}},
+			"missing file":      {Keys: []config.JWTKeyConfig{{ID: "a", PrivateKeyFile: filepath.Join(dir, "missing.pem")}}},
+			"mismatched pair":   {Keys: []config.JWTKeyConfig{{ID: "a", PrivateKeyFile: rsaPrivate, PublicKeyFile: otherPublic}}},
+			"weak rsa key":      {Keys: []config.JWTKeyConfig{{ID: "a", PrivateKeyFile: weakPrivate}}},
+			"unknown signing":   {SigningKeyID: "b", Keys: []config.JWTKeyConfig{{ID: "a", PrivateKeyFile: rsaPrivate}}},
+			"public signing":    {SigningKeyID: "a", Keys: []config.JWTKeyConfig{{ID: "a", PublicKeyFile: rsaPublic}}},
+			"no private key":    {Keys: []config.JWTKeyConfig{{ID: "a", PublicKeyFile: rsaPublic}}},
+			"no secret or keys": {},
+		}
+		for name, jwtCfg := range cases {
+			_, err := token.New(&config.Config{JWT: jwtCfg})
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("JWKS Endpoint", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		tokens := newManager(t, config.JWTConfig{Keys: []config.JWTKeyConfig{{ID: "rsa-1", PrivateKeyFile: rsaPrivate}}})
+		signed, _, err := tokens.Issue(user, "", false)
+		require.NoError(t, err)
+
+		engine := gin.New()
+		handler.NewJWKSHandler(tokens, testutil.NewTestLogger(t).CreateTestLogger()).RegisterRoutes(engine)
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Cache-Control"), "max-age")
+
+		var set token.JSONWebKeySet
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+		require.Len(t, set.Keys, 1)
+		assert.Empty(t, set.Keys[0].X)
+
+		// 其他服务只凭 JWKS 中的公钥即可验证令牌
+		n, err := base64.RawURLEncoding.DecodeString(set.Keys[0].N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(set.Keys[0].E)
+		require.NoError(t, err)
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+		parsed, err := jwt.Parse(signed, func(tok *jwt.Token) (interface{}, error) {
+			assert.Equal(t, set.Keys[0].Kid, tok.Header["kid"])
+			return public, nil
+		}, jwt.WithValidMethods([]string{set.Keys[0].Alg}))
+		require.NoError(t, err)
+		assert.True(t, parsed.Valid)
+	})
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/test/testutil"
+)
+
+// readMailParts 解析邮件，返回各正文部分的内容类型和解码后的内容
+func readMailParts(t *testing.T, data string) (*mail.Message, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+
+	parts := make(map[string]string)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		require.NoError(t, err)
+		parts[mediaType] = string(body)
+		return msg, parts
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		partType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		parts[partType] = string(body)
+	}
+	return msg, parts
+}
+
+func TestMailer(t *testing.T) {
+	ctx := context.Background()
+	message := &mailer.Message{
+		To:      []string{"user@example.com"},
+		Subject: "重置密码",
+		Text:    "请打开以下链接：https://app.example.com/reset-password?token=abc",
+		HTML:    `<p><a href="https://app.example.com/reset-password?token=abc">重置密码</a></p>`,
+	}
+
+	t.Run("SMTP Driver", func(t *testing.T) {
+		server := testutil.NewFakeSMTPServer(t)
+		m, err := mailer.New(&config.Config{Email: config.EmailConfig{
+			Enabled:    true,
+			Driver:     "smtp",
+			SMTPHost:   server.Host(),
+			SMTPPort:   server.Port(),
+			Encryption: "none",
+			FromEmail:  "noreply@example.com",
+			FromName:   "Vibe",
+		}}, testutil.NewTestLogger(t).CreateTestLogger())
+		require.NoError(t, err)
+		require.NoError(t, m.Send(ctx, message))
+
+		received := server.Messages()
+		require.Len(t, received, 1)
+		assert.Equal(t, "noreply@example.com", received[0].From)
+		assert.Equal(t, []string{"user@example.com"}, received[0].To)
+
+		msg, parts := readMailParts(t, received[0].Data)
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		require.NoError(t, err)
+		assert.Equal(t, message.Subject, subject)
+		assert.Equal(t, message.Text, parts["text/plain"])
+		assert.Equal(t, message.HTML, parts["text/html"])
+	})
+
+	t.Run("File Driver", func(t *testing.T) {
+		dir := t.TempDir()
+		m, err := mailer.New(&config.Config{Email: config.EmailConfig{
+			Enabled:   true,
+			Driver:    "file",
+			FileDir:   dir,
+			FromEmail: "noreply@example.com",
+		}}, testutil.NewTestLogger(t).CreateTestLogger())
+		require.NoError(t, err)
+		require.NoError(t, m.Send(ctx, &mailer.Message{To: message.To, Subject: message.Subject, Text: message.Text}))
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		data, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		msg, parts := readMailParts(t, string(data))
+		assert.Equal(t, "user@example.com", msg.Header.Get("To"))
+		assert.Equal(t, message.Text, parts["text/plain"])
+	})
+
+	t.Run("Disabled And Invalid Config", func(t *testing.T) {
+		m, err := mailer.New(&config.Config{}, testutil.NewTestLogger(t).CreateTestLogger())
+		require.NoError(t, err)
+		assert.IsType(t, &mailer.LogMailer{}, m)
+		assert.NoError(t, m.Send(ctx, message))
+		assert.Error(t, m.Send(ctx, &mailer.Message{Subject: "no recipients"}))
+
+		_, err = mailer.New(&config.Config{Email: config.EmailConfig{Enabled: true, Driver: "pigeon"}}, nil)
+		assert.Error(t, err)
+
+		_, err = mailer.New(&config.Config{Email: config.EmailConfig{Enabled: true, Driver: "smtp"}}, nil)
+		assert.Error(t, err)
+	})
+}
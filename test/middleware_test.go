@@ -2,11 +2,15 @@ package test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -14,6 +18,8 @@ import (
 
 	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/internal/middleware"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/pkg/audit"
 	"vibe-coding-starter/test/testutil"
 )
 
@@ -47,7 +53,7 @@ func TestMiddlewareIntegration(t *testing.T) {
 	defer testCacheWrapper.Close()
 
 	// 创建中间件管理器
-	mw := middleware.NewMiddleware(cfg, testLogger, testCache)
+	mw := middleware.NewMiddleware(cfg, testLogger, testCache, testutil.NewTestTokenManager(t))
 
 	t.Run("CORS Middleware", func(t *testing.T) {
 		engine := gin.New()
@@ -155,6 +161,37 @@ func TestMiddlewareIntegration(t *testing.T) {
 		// 应该返回 413 Request Entity Too Large
 		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
 	})
+
+	t.Run("Per Route Body Limit", func(t *testing.T) {
+		engine := gin.New()
+		mw.SetBodyLimit(http.MethodPost, "/upload", 1000)
+		mw.SetBodyLimit(http.MethodPatch, "/chunks/:id", 0)
+		engine.Use(mw.Security().BodySizeLimit(100))
+
+		// 读取完整请求体，超过上限时返回 413
+		read := func(c *gin.Context) {
+			if _, err := io.ReadAll(c.Request.Body); err != nil {
+				c.Status(http.StatusRequestEntityTooLarge)
+				return
+			}
+			c.Status(http.StatusOK)
+		}
+		engine.POST("/test", read)
+		engine.POST("/upload", read)
+		engine.PATCH("/chunks/:id", read)
+
+		send := func(method, path string, size int) int {
+			req := httptest.NewRequest(method, path, bytes.NewReader(make([]byte, size)))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, send(http.MethodPost, "/test", 500))
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, "/upload", 500))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, send(http.MethodPost, "/upload", 2000))
+		assert.Equal(t, http.StatusOK, send(http.MethodPatch, "/chunks/1", 1<<20))
+	})
 }
 
 func TestMiddlewareChaining(t *testing.T) {
@@ -170,7 +207,7 @@ func TestMiddlewareChaining(t *testing.T) {
 	testCacheWrapper := testutil.NewTestCache(t)
 	testCache := testCacheWrapper.CreateTestCache()
 	defer testCacheWrapper.Close()
-	mw := middleware.NewMiddleware(cfg, testLogger, testCache)
+	mw := middleware.NewMiddleware(cfg, testLogger, testCache, testutil.NewTestTokenManager(t))
 
 	t.Run("Multiple Middleware Chain", func(t *testing.T) {
 		engine := gin.New()
@@ -233,8 +270,8 @@ func TestMiddlewareConfiguration(t *testing.T) {
 		testCache := testCacheWrapper.CreateTestCache()
 		defer testCacheWrapper.Close()
 
-		devMW := middleware.NewMiddleware(devCfg, testLogger, testCache)
-		prodMW := middleware.NewMiddleware(prodCfg, testLogger, testCache)
+		devMW := middleware.NewMiddleware(devCfg, testLogger, testCache, testutil.NewTestTokenManager(t))
+		prodMW := middleware.NewMiddleware(prodCfg, testLogger, testCache, testutil.NewTestTokenManager(t))
 
 		// 测试开发环境的 CORS 配置（应该更宽松）
 		devEngine := gin.New()
@@ -270,3 +307,389 @@ func TestMiddlewareConfiguration(t *testing.T) {
 		assert.NotEqual(t, "http://random-domain.com", w2.Header().Get("Access-Control-Allow-Origin"))
 	})
 }
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testLoggerWrapper := testutil.NewTestLogger(t)
+	testCacheWrapper := testutil.NewTestCache(t)
+	defer testCacheWrapper.Close()
+
+	mw := middleware.NewMiddleware(&config.Config{}, testLoggerWrapper.CreateTestLogger(), testCacheWrapper.CreateTestCache(), testutil.NewTestTokenManager(t))
+	mw.RegisterAPIKeyLookup(func(ctx context.Context, key, ip string) (*middleware.APIKeyPrincipal, error) {
+		switch key {
+		case "vk_reader":
+			return &middleware.APIKeyPrincipal{KeyID: 1, UserID: 7, Username: "bot", Role: "user", Scopes: []string{"article:read"}}, nil
+		case "vk_admin":
+			return &middleware.APIKeyPrincipal{KeyID: 2, UserID: 1, Username: "root", Role: "admin", Scopes: []string{"article:*"}}, nil
+		case "vk_admin_own":
+			return &middleware.APIKeyPrincipal{KeyID: 3, UserID: 1, Username: "root", Role: "admin", Scopes: []string{"article:delete:own"}}, nil
+		}
+		return nil, errors.New("invalid api key")
+	})
+	mw.AllowAPIKey(http.MethodGet, "/articles", middleware.PermArticleReadOwn)
+	mw.AllowAPIKey(http.MethodPost, "/articles", middleware.PermArticleCreate)
+	mw.AllowAPIKey(http.MethodGet, "/files/:id", middleware.PermFileReadOwn)
+
+	engine := gin.New()
+	engine.Use(mw.RequireAuth())
+	respond := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"user_id":    c.GetUint("user_id"),
+			"user_role":  c.GetString("user_role"),
+			"api_key_id": c.GetUint("api_key_id"),
+		})
+	}
+	engine.GET("/articles", respond)
+	engine.POST("/articles", respond)
+	engine.GET("/files/:id", respond)
+	engine.POST("/users/me/api-keys", respond)
+	engine.DELETE("/articles/:id", mw.RequirePermission(middleware.PermArticleDelete), respond)
+
+	request := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Sets User Context", func(t *testing.T) {
+		w := request(http.MethodGet, "/articles", "vk_reader")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, float64(7), body["user_id"])
+		assert.Equal(t, "user", body["user_role"])
+		assert.Equal(t, float64(1), body["api_key_id"])
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/articles", "vk_unknown").Code)
+	})
+
+	t.Run("Scope Not Granted", func(t *testing.T) {
+		w := request(http.MethodPost, "/articles", "vk_reader")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "insufficient_scope")
+
+		assert.Equal(t, http.StatusOK, request(http.MethodPost, "/articles", "vk_admin").Code)
+	})
+
+	t.Run("Admin Role Limited By Scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/files/1", "vk_admin").Code)
+	})
+
+	t.Run("Undeclared Endpoint", func(t *testing.T) {
+		// 密钥管理等接口只接受登录令牌，防止泄露的 API Key 创建新的 Key
+		w := request(http.MethodPost, "/users/me/api-keys", "vk_admin")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "api_key_not_allowed")
+	})
+
+	t.Run("Permission Middleware Respects Scope", func(t *testing.T) {
+		mw.AllowAPIKey(http.MethodDelete, "/articles/:id", middleware.PermArticleDeleteOwn)
+		assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/articles/3", "vk_admin").Code)
+
+		// 路由只要求删除自己的文章，权限中间件要求删除任意文章；管理员角色本身拥有所有权限，但受 API Key 范围限制
+		assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/articles/3", "vk_admin_own").Code)
+	})
+
+	t.Run("Parse Permission", func(t *testing.T) {
+		permission, ok := middleware.ParsePermission("file:delete:own")
+		require.True(t, ok)
+		assert.Equal(t, middleware.PermFileDeleteOwn, permission)
+
+		for _, invalid := range []string{"", "file", "file::own", "a:b:c:d", ":read"} {
+			_, ok := middleware.ParsePermission(invalid)
+			assert.False(t, ok, invalid)
+		}
+	})
+}
+
+func TestPermissionLoader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testLoggerWrapper := testutil.NewTestLogger(t)
+	testCacheWrapper := testutil.NewTestCache(t)
+	defer testCacheWrapper.Close()
+
+	mw := middleware.NewMiddleware(&config.Config{}, testLoggerWrapper.CreateTestLogger(), testCacheWrapper.CreateTestCache(), testutil.NewTestTokenManager(t))
+
+	grants := map[uint][]string{
+		901: {"article:update"},
+		902: {"article:update"},
+	}
+	loads := 0
+	mw.RegisterPermissionLoader(func(ctx context.Context, userID uint, role string) ([]string, error) {
+		loads++
+		if userID == 903 {
+			return nil, errors.New("database unavailable")
+		}
+		return grants[userID], nil
+	})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		var userID uint
+		fmt.Sscanf(c.GetHeader("X-User-ID"), "%d", &userID)
+		c.Set("user_id", userID)
+		c.Set("user_role", c.GetHeader("X-User-Role"))
+		c.Next()
+	})
+	respond := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	}
+	engine.PUT("/articles/:id", mw.RequirePermission(middleware.PermArticleUpdate), respond)
+	engine.POST("/articles", mw.Auth().RequirePermission("article:create"), respond)
+
+	request := func(method, path string, userID uint, role string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-User-ID", fmt.Sprintf("%d", userID))
+		req.Header.Set("X-User-Role", role)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("Loads And Caches Permissions", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(http.MethodPut, "/articles/1", 901, "user"))
+		assert.Equal(t, http.StatusOK, request(http.MethodPut, "/articles/1", 901, "user"))
+		assert.Equal(t, 1, loads)
+
+		// 认证中间件的字符串权限检查使用同一份权限
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/articles", 901, "user"))
+		assert.Equal(t, 1, loads)
+	})
+
+	t.Run("Clear User Permissions", func(t *testing.T) {
+		grants[901] = []string{"article:create"}
+		assert.Equal(t, http.StatusOK, request(http.MethodPut, "/articles/1", 901, "user"))
+
+		require.NoError(t, mw.ClearUserPermissions(901))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPut, "/articles/1", 901, "user"))
+		assert.Equal(t, http.StatusOK, request(http.MethodPost, "/articles", 901, "user"))
+	})
+
+	t.Run("Clear All Permissions", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(http.MethodPut, "/articles/1", 902, "user"))
+		grants[902] = nil
+
+		require.NoError(t, mw.ClearAllPermissions())
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPut, "/articles/1", 902, "user"))
+	})
+
+	t.Run("Loader Error Denies Access", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/articles", 903, "user"))
+	})
+
+	t.Run("Legacy Admin Role", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(http.MethodPost, "/articles", 904, "admin"))
+	})
+}
+
+func TestRoutePermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testLoggerWrapper := testutil.NewTestLogger(t)
+	testCacheWrapper := testutil.NewTestCache(t)
+	defer testCacheWrapper.Close()
+
+	mw := middleware.NewMiddleware(&config.Config{}, testLoggerWrapper.CreateTestLogger(), testCacheWrapper.CreateTestCache(), testutil.NewTestTokenManager(t))
+
+	// 901 为拥有自定义审计角色的普通用户，902 只有内置 user 角色的默认权限
+	grants := map[uint][]string{
+		901: {"user:read:own", "user:read:all", "audit:read"},
+		902: model.DefaultRolePermissions[model.UserRoleUser],
+	}
+	mw.RegisterPermissionLoader(func(ctx context.Context, userID uint, role string) ([]string, error) {
+		return grants[userID], nil
+	})
+	mw.SetRoutePermission(http.MethodGet, "/admin/users", middleware.PermUserReadAll)
+	mw.SetRoutePermission(http.MethodGet, "/admin/audit-logs", middleware.PermAuditRead)
+	mw.SetRoutePermission(http.MethodGet, "/admin/files", middleware.PermFileReadAll)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		var userID uint
+		fmt.Sscanf(c.GetHeader("X-User-ID"), "%d", &userID)
+		c.Set("user_id", userID)
+		c.Set("user_role", c.GetHeader("X-User-Role"))
+		c.Next()
+	})
+	admin := engine.Group("/admin")
+	admin.Use(mw.Permission().RequireRoutePermission())
+	respond := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"permission": c.GetString("route_permission")})
+	}
+	admin.GET("/users", respond)
+	admin.GET("/audit-logs", respond)
+	admin.GET("/files", respond)
+	admin.DELETE("/users/:id", respond)
+
+	request := func(method, path string, userID uint, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-User-ID", fmt.Sprintf("%d", userID))
+		req.Header.Set("X-User-Role", role)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Custom Role Grants Access", func(t *testing.T) {
+		w := request(http.MethodGet, "/admin/users", 901, "user")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "user:read:all")
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/admin/audit-logs", 901, "user").Code)
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/admin/files", 901, "user").Code)
+	})
+
+	t.Run("Unscoped Permission Does Not Cover All", func(t *testing.T) {
+		// 默认的 file:read 只能访问自己的文件，不能进入管理接口
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/admin/files", 902, "user").Code)
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/admin/users", 902, "user").Code)
+	})
+
+	t.Run("Undeclared Route Requires Admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/admin/users/1", 901, "user").Code)
+		assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/admin/users/1", 903, "admin").Code)
+	})
+
+	t.Run("Legacy Admin Role", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/admin/files", 903, "admin").Code)
+	})
+}
+
+func TestImpersonationToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testLoggerWrapper := testutil.NewTestLogger(t)
+	testCacheWrapper := testutil.NewTestCache(t)
+	defer testCacheWrapper.Close()
+
+	tokens := testutil.NewTestTokenManager(t)
+	mw := middleware.NewMiddleware(&config.Config{}, testLoggerWrapper.CreateTestLogger(), testCacheWrapper.CreateTestCache(), tokens)
+	mw.DenyImpersonation(http.MethodPost, "/users/change-password")
+
+	engine := gin.New()
+	engine.Use(mw.RequireAuth())
+	respond := func(c *gin.Context) {
+		impersonatorID, _ := c.Get("impersonator_id")
+		c.JSON(http.StatusOK, gin.H{
+			"user_id":         c.GetUint("user_id"),
+			"impersonator_id": impersonatorID,
+		})
+	}
+	engine.GET("/users/profile", respond)
+	engine.POST("/users/change-password", respond)
+
+	user := &model.User{BaseModel: model.BaseModel{ID: 7}, Username: "member", Email: "member@example.com", Role: model.UserRoleUser}
+	admin := &model.User{BaseModel: model.BaseModel{ID: 1}, Username: "root", Email: "root@example.com", Role: model.UserRoleAdmin}
+	impersonation, _, err := tokens.IssueImpersonation(user, admin, 5*time.Minute)
+	require.NoError(t, err)
+	regular, _, err := tokens.Issue(user, "", false)
+	require.NoError(t, err)
+
+	request := func(method, path, accessToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Sets Impersonator", func(t *testing.T) {
+		w := request(http.MethodGet, "/users/profile", impersonation)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, float64(7), body["user_id"])
+		assert.Equal(t, float64(1), body["impersonator_id"])
+	})
+
+	t.Run("Denied Endpoint", func(t *testing.T) {
+		w := request(http.MethodPost, "/users/change-password", impersonation)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "impersonation_not_allowed")
+
+		// 普通令牌不受影响
+		w = request(http.MethodPost, "/users/change-password", regular)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"impersonator_id":null`)
+	})
+}
+
+func TestAuditRecorder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testLoggerWrapper := testutil.NewTestLogger(t)
+	testCacheWrapper := testutil.NewTestCache(t)
+	defer testCacheWrapper.Close()
+
+	tokens := testutil.NewTestTokenManager(t)
+	mw := middleware.NewMiddleware(&config.Config{}, testLoggerWrapper.CreateTestLogger(), testCacheWrapper.CreateTestCache(), tokens)
+
+	type recorded struct {
+		action, resourceType, resourceID string
+		metadata                         audit.Metadata
+	}
+	var events []recorded
+	mw.RegisterAuditRecorder(func(ctx context.Context, action, resourceType, resourceID string) {
+		events = append(events, recorded{action, resourceType, resourceID, *audit.FromContext(ctx)})
+	})
+
+	engine := gin.New()
+	engine.Use(mw.Logging().StructuredLogging(), mw.Logging().SecurityLogging())
+	engine.POST("/login", func(c *gin.Context) {
+		c.Set("security_event", "account_locked")
+		c.Set("security_target", "member")
+		c.JSON(http.StatusUnauthorized, gin.H{})
+	})
+	engine.POST("/roles", mw.RequireAuth(), func(c *gin.Context) {
+		md := audit.FromContext(c.Request.Context())
+		require.NotNil(t, md)
+		assert.Equal(t, uint(1), md.ActorID)
+
+		// 服务层已记录审计日志时不再重复记录安全事件
+		if c.Query("recorded") == "true" {
+			md.Recorded = true
+		}
+		c.Set("security_event", "role_created")
+		c.Set("security_target", "editor")
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	admin := &model.User{BaseModel: model.BaseModel{ID: 1}, Username: "root", Email: "root@example.com", Role: model.UserRoleAdmin}
+	accessToken, _, err := tokens.Issue(admin, "", false)
+	require.NoError(t, err)
+
+	request := func(path string, authenticated bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("User-Agent", "audit-test")
+		if authenticated {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/login", false)
+	require.Len(t, events, 1)
+	assert.Equal(t, "account_locked", events[0].action)
+	assert.Equal(t, "security", events[0].resourceType)
+	assert.Equal(t, "member", events[0].resourceID)
+	assert.Zero(t, events[0].metadata.ActorID)
+	assert.Equal(t, "audit-test", events[0].metadata.UserAgent)
+	assert.Equal(t, w.Header().Get("X-Request-ID"), events[0].metadata.RequestID)
+
+	require.Equal(t, http.StatusCreated, request("/roles", true).Code)
+	require.Len(t, events, 2)
+	assert.Equal(t, "role_created", events[1].action)
+	assert.Equal(t, uint(1), events[1].metadata.ActorID)
+
+	require.Equal(t, http.StatusCreated, request("/roles?recorded=true", true).Code)
+	assert.Len(t, events, 2)
+}
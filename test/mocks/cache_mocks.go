@@ -49,8 +49,8 @@ func (m *MockCache) TTL(ctx context.Context, key string) (time.Duration, error)
 	return args.Get(0).(time.Duration), args.Error(1)
 }
 
-func (m *MockCache) Incr(ctx context.Context, key string) (int64, error) {
-	args := m.Called(ctx, key)
+func (m *MockCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	args := m.Called(ctx, key, expiration)
 	return args.Get(0).(int64), args.Error(1)
 }
 
@@ -0,0 +1,19 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"vibe-coding-starter/pkg/mailer"
+)
+
+// MockMailer 邮件发送模拟
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
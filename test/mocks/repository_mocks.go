@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -66,6 +67,34 @@ func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, userID uint) e
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID uint, hashedPassword string) error {
+	args := m.Called(ctx, userID, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID uint) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ScheduleDeletion(ctx context.Context, userID uint, at *time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ListDueForDeletion(ctx context.Context, before time.Time, limit int) ([]*model.User, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, userID, reassignTo uint) error {
+	args := m.Called(ctx, userID, reassignTo)
+	return args.Error(0)
+}
+
 // MockArticleRepository 文章仓储模拟
 type MockArticleRepository struct {
 	mock.Mock
@@ -207,6 +236,61 @@ func (m *MockFileRepository) GetByOwner(ctx context.Context, ownerID uint, opts
 	return args.Get(0).([]*model.File), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockFileRepository) ListByStorage(ctx context.Context, storageType string, afterID uint, limit int) ([]*model.File, error) {
+	args := m.Called(ctx, storageType, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.File), args.Error(1)
+}
+
+func (m *MockFileRepository) IncrementDownloadCount(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockFileBlobRepository 物理文件对象仓储模拟
+type MockFileBlobRepository struct {
+	mock.Mock
+}
+
+func (m *MockFileBlobRepository) GetByID(ctx context.Context, id uint) (*model.FileBlob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FileBlob), args.Error(1)
+}
+
+func (m *MockFileBlobRepository) Acquire(ctx context.Context, blob *model.FileBlob) (*model.FileBlob, error) {
+	args := m.Called(ctx, blob)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.FileBlob), args.Error(1)
+}
+
+func (m *MockFileBlobRepository) Release(ctx context.Context, id uint) (*model.FileBlob, bool, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*model.FileBlob), args.Bool(1), args.Error(2)
+}
+
+func (m *MockFileBlobRepository) ListByStorage(ctx context.Context, storageType string, afterID uint, limit int) ([]*model.FileBlob, error) {
+	args := m.Called(ctx, storageType, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.FileBlob), args.Error(1)
+}
+
+func (m *MockFileBlobRepository) SetRefCount(ctx context.Context, id uint, expected, count int) (bool, error) {
+	args := m.Called(ctx, id, expected, count)
+	return args.Bool(0), args.Error(1)
+}
+
 // MockDictRepository 数据字典仓储模拟
 type MockDictRepository struct {
 	mock.Mock
@@ -320,3 +404,106 @@ func (m *MockDepartmentRepository) GetByName(ctx context.Context, name string) (
 	}
 	return args.Get(0).(*model.Department), args.Error(1)
 }
+
+func (m *MockDepartmentRepository) GetByParentId(ctx context.Context, parentId uint) ([]*model.Department, error) {
+	args := m.Called(ctx, parentId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentRepository) GetByCode(ctx context.Context, code string) (*model.Department, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentRepository) GetChildrenTree(ctx context.Context, parentId uint) ([]*model.Department, error) {
+	args := m.Called(ctx, parentId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Department), args.Error(1)
+}
+
+// MockMFARepository 两步验证仓储模拟
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetByUserID(ctx context.Context, userID uint) (*model.UserMFA, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserMFA), args.Error(1)
+}
+
+func (m *MockMFARepository) SaveSecret(ctx context.Context, userID uint, secret string) error {
+	args := m.Called(ctx, userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Enable(ctx context.Context, userID uint, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Disable(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) AdvanceStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ConsumeRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockUserIdentityRepository 第三方登录身份仓储模拟
+type MockUserIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockUserIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) ListByUserID(ctx context.Context, userID uint) ([]*model.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) RecordLogin(ctx context.Context, id uint, email string) error {
+	args := m.Called(ctx, id, email)
+	return args.Error(0)
+}
@@ -2,6 +2,9 @@ package mocks
 
 import (
 	"context"
+	"io"
+	"net/url"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -65,6 +68,150 @@ func (m *MockUserService) DeleteUser(ctx context.Context, userID uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserService) RefreshToken(ctx context.Context, req *service.RefreshTokenRequest) (*service.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.LoginResponse), args.Error(1)
+}
+
+func (m *MockUserService) LoginMFA(ctx context.Context, req *service.MFALoginRequest) (*service.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.LoginResponse), args.Error(1)
+}
+
+func (m *MockUserService) GetMFAStatus(ctx context.Context, userID uint) (*service.MFAStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.MFAStatus), args.Error(1)
+}
+
+func (m *MockUserService) EnrollMFA(ctx context.Context, userID uint) (*service.MFAEnrollment, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.MFAEnrollment), args.Error(1)
+}
+
+func (m *MockUserService) ConfirmMFA(ctx context.Context, userID uint, sessionID string, req *service.MFACodeRequest) (*service.MFARecoveryCodes, error) {
+	args := m.Called(ctx, userID, sessionID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.MFARecoveryCodes), args.Error(1)
+}
+
+func (m *MockUserService) DisableMFA(ctx context.Context, userID uint, req *service.DisableMFARequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RegenerateRecoveryCodes(ctx context.Context, userID uint, req *service.MFACodeRequest) (*service.MFARecoveryCodes, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.MFARecoveryCodes), args.Error(1)
+}
+
+func (m *MockUserService) ListOAuthProviders(ctx context.Context) []*service.OAuthProvider {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]*service.OAuthProvider)
+}
+
+func (m *MockUserService) StartOAuthLogin(ctx context.Context, provider string) (*service.OAuthAuthorization, error) {
+	args := m.Called(ctx, provider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.OAuthAuthorization), args.Error(1)
+}
+
+func (m *MockUserService) CompleteOAuthLogin(ctx context.Context, req *service.OAuthCallbackRequest) (*service.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.LoginResponse), args.Error(1)
+}
+
+func (m *MockUserService) ListIdentities(ctx context.Context, userID uint) ([]*model.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserService) UnlockUser(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockTokenService 令牌服务模拟
+type MockTokenService struct {
+	mock.Mock
+}
+
+func (m *MockTokenService) Issue(ctx context.Context, user *model.User, client service.ClientInfo, mfa bool) (*service.TokenPair, error) {
+	args := m.Called(ctx, user, client, mfa)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Refresh(ctx context.Context, refreshToken string, client service.ClientInfo) (*service.TokenPair, error) {
+	args := m.Called(ctx, refreshToken, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Logout(ctx context.Context, userID uint, sessionID, tokenID string) error {
+	args := m.Called(ctx, userID, sessionID, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) ListSessions(ctx context.Context, userID uint) ([]*model.UserSession, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.UserSession), args.Error(1)
+}
+
+func (m *MockTokenService) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) RevokeAllSessions(ctx context.Context, userID uint) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTokenService) RevokeOtherSessions(ctx context.Context, userID uint, sessionID string) (int, error) {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTokenService) CleanupExpired(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // MockArticleService 文章服务模拟
 type MockArticleService struct {
 	mock.Mock
@@ -186,6 +333,115 @@ func (m *MockFileService) GetByOwner(ctx context.Context, ownerID uint, opts rep
 	return args.Get(0).([]*model.File), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockFileService) CreateSignedURL(ctx context.Context, id uint, ttl time.Duration) (*service.SignedURLResponse, error) {
+	args := m.Called(ctx, id, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.SignedURLResponse), args.Error(1)
+}
+
+func (m *MockFileService) VerifySignedURL(ctx context.Context, id uint, query url.Values) error {
+	args := m.Called(ctx, id, query)
+	return args.Error(0)
+}
+
+func (m *MockFileService) RecordDownload(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFileService) GetVariant(ctx context.Context, id uint, name string) (*service.VariantResponse, error) {
+	args := m.Called(ctx, id, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.VariantResponse), args.Error(1)
+}
+
+// MockStorageQuotaService 存储配额服务模拟
+type MockStorageQuotaService struct {
+	mock.Mock
+}
+
+func (m *MockStorageQuotaService) GetUsage(ctx context.Context, userID uint) (*service.StorageUsageResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.StorageUsageResponse), args.Error(1)
+}
+
+func (m *MockStorageQuotaService) Check(ctx context.Context, userID uint, size int64) error {
+	args := m.Called(ctx, userID, size)
+	return args.Error(0)
+}
+
+func (m *MockStorageQuotaService) Reserve(ctx context.Context, userID uint, size int64) error {
+	args := m.Called(ctx, userID, size)
+	return args.Error(0)
+}
+
+func (m *MockStorageQuotaService) Release(ctx context.Context, userID uint, size int64) error {
+	args := m.Called(ctx, userID, size)
+	return args.Error(0)
+}
+
+func (m *MockStorageQuotaService) SetQuota(ctx context.Context, userID uint, quotaBytes *int64) (*service.StorageUsageResponse, error) {
+	args := m.Called(ctx, userID, quotaBytes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.StorageUsageResponse), args.Error(1)
+}
+
+// MockResumableUploadService 断点续传上传服务模拟
+type MockResumableUploadService struct {
+	mock.Mock
+}
+
+func (m *MockResumableUploadService) Create(ctx context.Context, req *service.CreateUploadSessionRequest) (*model.UploadSession, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UploadSession), args.Error(1)
+}
+
+func (m *MockResumableUploadService) Get(ctx context.Context, ownerID uint, uploadID string) (*model.UploadSession, error) {
+	args := m.Called(ctx, ownerID, uploadID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UploadSession), args.Error(1)
+}
+
+func (m *MockResumableUploadService) WriteChunk(ctx context.Context, ownerID uint, uploadID string, offset int64, r io.Reader) (*model.UploadSession, error) {
+	args := m.Called(ctx, ownerID, uploadID, offset, r)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UploadSession), args.Error(1)
+}
+
+func (m *MockResumableUploadService) Complete(ctx context.Context, ownerID uint, uploadID string) (*model.File, error) {
+	args := m.Called(ctx, ownerID, uploadID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.File), args.Error(1)
+}
+
+func (m *MockResumableUploadService) Abort(ctx context.Context, ownerID uint, uploadID string) error {
+	args := m.Called(ctx, ownerID, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockResumableUploadService) CleanupExpired(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
 // MockDictService 数据字典服务模拟
 type MockDictService struct {
 	mock.Mock
@@ -300,3 +556,343 @@ func (m *MockDepartmentService) List(ctx context.Context, opts *service.ListDepa
 	}
 	return args.Get(0).([]*model.Department), args.Get(1).(int64), args.Error(2)
 }
+
+func (m *MockDepartmentService) GetTree(ctx context.Context) ([]*model.Department, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentService) GetChildren(ctx context.Context, parentId uint) ([]*model.Department, error) {
+	args := m.Called(ctx, parentId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentService) GetPath(ctx context.Context, id uint) ([]*model.Department, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentService) Move(ctx context.Context, id uint, newParentId uint) error {
+	args := m.Called(ctx, id, newParentId)
+	return args.Error(0)
+}
+
+// MockPasswordResetService 密码重置服务模拟
+type MockPasswordResetService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetService) Forgot(ctx context.Context, req *service.ForgotPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetService) Reset(ctx context.Context, req *service.ResetPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetService) CleanupExpired(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockEmailVerificationService 邮箱验证服务模拟
+type MockEmailVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationService) Send(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) Verify(ctx context.Context, req *service.VerifyEmailRequest) (*model.User, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockEmailVerificationService) Resend(ctx context.Context, req *service.ResendVerificationRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+// MockAPIKeyService 个人 API Key 服务模拟
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) Create(ctx context.Context, userID uint, req *service.CreateAPIKeyRequest) (*service.CreatedAPIKey, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreatedAPIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) List(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) Revoke(ctx context.Context, userID, keyID uint) error {
+	args := m.Called(ctx, userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, key, ip string) (*model.APIKey, *model.User, error) {
+	args := m.Called(ctx, key, ip)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*model.APIKey), args.Get(1).(*model.User), args.Error(2)
+}
+
+// MockRoleService 角色与权限服务模拟
+type MockRoleService struct {
+	mock.Mock
+}
+
+func (m *MockRoleService) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRoleService) GetRole(ctx context.Context, id uint) (*model.Role, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRoleService) CreateRole(ctx context.Context, actorID uint, req *service.CreateRoleRequest) (*model.Role, error) {
+	args := m.Called(ctx, actorID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRoleService) UpdateRole(ctx context.Context, actorID, id uint, req *service.UpdateRoleRequest) (*model.Role, error) {
+	args := m.Called(ctx, actorID, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRoleService) DeleteRole(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Permission), args.Error(1)
+}
+
+func (m *MockRoleService) GetUserRoles(ctx context.Context, userID uint) ([]*model.Role, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRoleService) SetUserRoles(ctx context.Context, actorID, userID uint, req *service.SetUserRolesRequest) ([]*model.Role, error) {
+	args := m.Called(ctx, actorID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRoleService) GetUserPermissions(ctx context.Context, userID uint, legacyRole string) ([]string, error) {
+	args := m.Called(ctx, userID, legacyRole)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRoleService) CheckGrant(ctx context.Context, actorID uint, permissions []string) error {
+	args := m.Called(ctx, actorID, permissions)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) CheckAuthority(ctx context.Context, actorID, userID uint) error {
+	args := m.Called(ctx, actorID, userID)
+	return args.Error(0)
+}
+
+// MockAdminUserService 管理员用户管理服务模拟
+type MockAdminUserService struct {
+	mock.Mock
+}
+
+func (m *MockAdminUserService) CreateUser(ctx context.Context, actorID uint, req *service.AdminCreateUserRequest) (*model.User, error) {
+	args := m.Called(ctx, actorID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAdminUserService) UpdateStatus(ctx context.Context, actorID, userID uint, status string) (*model.User, error) {
+	args := m.Called(ctx, actorID, userID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAdminUserService) UpdateRole(ctx context.Context, actorID, userID uint, role string) (*model.User, error) {
+	args := m.Called(ctx, actorID, userID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAdminUserService) ForcePasswordReset(ctx context.Context, actorID, userID uint) error {
+	args := m.Called(ctx, actorID, userID)
+	return args.Error(0)
+}
+
+func (m *MockAdminUserService) Impersonate(ctx context.Context, actorID, userID uint) (*service.ImpersonationResponse, error) {
+	args := m.Called(ctx, actorID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ImpersonationResponse), args.Error(1)
+}
+
+// MockAuditService 审计日志服务模拟
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(ctx context.Context, entry *service.AuditEntry) {
+	m.Called(ctx, entry)
+}
+
+func (m *MockAuditService) List(ctx context.Context, opts repository.ListOptions) ([]*model.AuditLog, int64, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*model.AuditLog), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAuditService) Start() {
+	m.Called()
+}
+
+func (m *MockAuditService) Stop(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// MockPrivacyService 个人数据导出与账户注销服务模拟
+type MockPrivacyService struct {
+	mock.Mock
+}
+
+func (m *MockPrivacyService) RequestExport(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockPrivacyService) BuildExport(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockPrivacyService) OpenExport(ctx context.Context, token string) (*service.ExportDownload, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ExportDownload), args.Error(1)
+}
+
+func (m *MockPrivacyService) ScheduleDeletion(ctx context.Context, userID uint, req *service.DeleteAccountRequest) (time.Time, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockPrivacyService) CancelDeletion(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockPrivacyService) PurgeDue(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPrivacyService) CleanupExports(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+// MockInvitationService 注册邀请服务模拟
+type MockInvitationService struct {
+	mock.Mock
+}
+
+func (m *MockInvitationService) Create(ctx context.Context, actorID uint, req *service.CreateInvitationRequest) (*service.CreatedInvitation, error) {
+	args := m.Called(ctx, actorID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreatedInvitation), args.Error(1)
+}
+
+func (m *MockInvitationService) Lookup(ctx context.Context, token string) (*model.Invitation, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) Accept(ctx context.Context, invitation *model.Invitation, user *model.User) error {
+	args := m.Called(ctx, invitation, user)
+	return args.Error(0)
+}
+
+func (m *MockInvitationService) List(ctx context.Context, opts repository.ListOptions) ([]*model.Invitation, int64, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*model.Invitation), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockInvitationService) Revoke(ctx context.Context, actorID, id uint) error {
+	args := m.Called(ctx, actorID, id)
+	return args.Error(0)
+}
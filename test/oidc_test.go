@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/test/testutil"
+)
+
+func TestOIDCProvider(t *testing.T) {
+	ctx := context.Background()
+	fake := testutil.NewFakeOIDCProvider(t)
+	user := testutil.FakeOIDCUser{Subject: "user-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+	fake.SetUser(user)
+
+	provider, err := oidc.NewProvider(fake.Config("mock"), nil)
+	require.NoError(t, err)
+
+	t.Run("Authorization Code With PKCE", func(t *testing.T) {
+		verifier, err := oidc.NewCodeVerifier()
+		require.NoError(t, err)
+
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+		require.NoError(t, err)
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+		assert.Equal(t, oidc.CodeChallenge(verifier), parsed.Query().Get("code_challenge"))
+		assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+
+		code, state := fake.Authorize(t, authURL)
+		assert.Equal(t, "state-1", state)
+
+		token, err := provider.Exchange(ctx, code, verifier)
+		require.NoError(t, err)
+		claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, user.Subject, claims.Subject)
+		assert.Equal(t, user.Email, claims.Email)
+		assert.True(t, claims.EmailVerified)
+
+		// 授权码只能使用一次
+		_, err = provider.Exchange(ctx, code, verifier)
+		assert.Error(t, err)
+	})
+
+	t.Run("Wrong Code Verifier", func(t *testing.T) {
+		verifier, err := oidc.NewCodeVerifier()
+		require.NoError(t, err)
+		authURL, err := provider.AuthCodeURL(ctx, "state-2", "nonce-2", verifier)
+		require.NoError(t, err)
+
+		code, _ := fake.Authorize(t, authURL)
+		_, err = provider.Exchange(ctx, code, "another-verifier")
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid ID Tokens", func(t *testing.T) {
+		valid := fake.IDTokenClaims(user, "nonce")
+		_, err := provider.VerifyIDToken(ctx, fake.SignIDToken(valid), "nonce")
+		require.NoError(t, err)
+
+		cases := map[string]func(claims jwt.MapClaims){
+			"wrong audience":  func(c jwt.MapClaims) { c["aud"] = "other-client" },
+			"wrong issuer":    func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+			"expired":         func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			"nonce mismatch":  func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+			"missing subject": func(c jwt.MapClaims) { delete(c, "sub") },
+			"foreign azp": func(c jwt.MapClaims) {
+				c["aud"] = []string{fake.ClientID, "other-client"}
+				c["azp"] = "other-client"
+			},
+		}
+		for name, mutate := range cases {
+			claims := fake.IDTokenClaims(user, "nonce")
+			mutate(claims)
+			_, err := provider.VerifyIDToken(ctx, fake.SignIDToken(claims), "nonce")
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken, name)
+		}
+
+		// 对称算法签名和签名被篡改的令牌无效
+		hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid).SignedString([]byte(fake.ClientSecret))
+		require.NoError(t, err)
+		_, err = provider.VerifyIDToken(ctx, hmacToken, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+		signed := fake.SignIDToken(valid)
+		_, err = provider.VerifyIDToken(ctx, signed[:len(signed)-4]+"AAAA", "nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("Registry", func(t *testing.T) {
+		registry, err := oidc.NewRegistry([]config.OAuthProviderConfig{fake.Config("mock")}, nil)
+		require.NoError(t, err)
+		found, err := registry.Get("mock")
+		require.NoError(t, err)
+		assert.Equal(t, "Mock SSO", found.DisplayName())
+
+		_, err = registry.Get("missing")
+		assert.ErrorIs(t, err, oidc.ErrUnknownProvider)
+
+		_, err = oidc.NewRegistry([]config.OAuthProviderConfig{fake.Config("mock"), fake.Config("mock")}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/password"
+)
+
+// fastArgon2Params 测试使用的低开销参数
+var fastArgon2Params = password.Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestPasswordHasher(t *testing.T) {
+	t.Run("Argon2id PHC Format", func(t *testing.T) {
+		hasher := password.NewArgon2idHasher(fastArgon2Params)
+		hashed, err := hasher.Hash("correct horse")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+		assert.True(t, hasher.Verify("correct horse", hashed))
+		assert.False(t, hasher.Verify("wrong horse", hashed))
+		assert.False(t, hasher.NeedsRehash(hashed))
+
+		// 相同密码每次使用不同的盐
+		again, err := hasher.Hash("correct horse")
+		require.NoError(t, err)
+		assert.NotEqual(t, hashed, again)
+	})
+
+	t.Run("Outdated Parameters Need Rehash", func(t *testing.T) {
+		old, err := password.NewArgon2idHasher(fastArgon2Params).Hash("correct horse")
+		require.NoError(t, err)
+
+		stronger := fastArgon2Params
+		stronger.Iterations = 2
+		hasher := password.NewArgon2idHasher(stronger)
+		assert.True(t, hasher.Verify("correct horse", old))
+		assert.True(t, hasher.NeedsRehash(old))
+	})
+
+	t.Run("Switch Between Algorithms", func(t *testing.T) {
+		bcryptHasher := password.NewBcryptHasher(4)
+		argonHasher := password.NewArgon2idHasher(fastArgon2Params)
+
+		legacy, err := bcryptHasher.Hash("correct horse")
+		require.NoError(t, err)
+		assert.True(t, argonHasher.Verify("correct horse", legacy))
+		assert.True(t, argonHasher.NeedsRehash(legacy))
+
+		current, err := argonHasher.Hash("correct horse")
+		require.NoError(t, err)
+		assert.True(t, bcryptHasher.Verify("correct horse", current))
+		assert.True(t, bcryptHasher.NeedsRehash(current))
+
+		assert.False(t, bcryptHasher.NeedsRehash(legacy))
+		assert.True(t, password.NewBcryptHasher(5).NeedsRehash(legacy))
+	})
+
+	t.Run("Malformed Hash", func(t *testing.T) {
+		hasher := password.NewArgon2idHasher(fastArgon2Params)
+		assert.False(t, hasher.Verify("x", "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5"))
+		assert.False(t, hasher.Verify("x", "$argon2id$garbage"))
+		assert.False(t, hasher.Verify("x", ""))
+		assert.True(t, hasher.NeedsRehash(""))
+	})
+
+	t.Run("From Config", func(t *testing.T) {
+		hasher, err := password.New(&config.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, password.AlgorithmArgon2id, hasher.Algorithm())
+
+		hasher, err = password.New(&config.Config{Password: config.PasswordConfig{Algorithm: "bcrypt", BcryptCost: 4}})
+		require.NoError(t, err)
+		assert.Equal(t, password.AlgorithmBcrypt, hasher.Algorithm())
+
+		_, err = password.New(&config.Config{Password: config.PasswordConfig{Algorithm: "bcrypt", BcryptCost: 99}})
+		assert.Error(t, err)
+		_, err = password.New(&config.Config{Password: config.PasswordConfig{Algorithm: "md5"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	t.Run("Length", func(t *testing.T) {
+		policy, err := password.NewPolicy(&config.Config{Password: config.PasswordConfig{
+			Policy: config.PasswordPolicyConfig{MaxLength: 12},
+		}})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, policy.Validate("short", ""), password.ErrTooShort)
+		assert.ErrorIs(t, policy.Validate("密码太短了", ""), password.ErrTooShort)
+		assert.ErrorIs(t, policy.Validate("much-too-long-password", ""), password.ErrTooLong)
+		assert.NoError(t, policy.Validate("just-right", ""))
+		assert.ErrorIs(t, policy.Validate("short", ""), password.ErrPolicyViolation)
+	})
+
+	t.Run("Character Classes", func(t *testing.T) {
+		policy, err := password.NewPolicy(&config.Config{Password: config.PasswordConfig{
+			Policy: config.PasswordPolicyConfig{
+				RequireUppercase: true,
+				RequireLowercase: true,
+				RequireDigit:     true,
+				RequireSymbol:    true,
+			},
+		}})
+		require.NoError(t, err)
+
+		err = policy.Validate("alllowercase", "")
+		assert.ErrorIs(t, err, password.ErrMissingCharacterClass)
+		assert.Contains(t, err.Error(), "uppercase letter, digit, symbol")
+		assert.NoError(t, policy.Validate("Tr0ub4dor&3", ""))
+	})
+
+	t.Run("Common Passwords", func(t *testing.T) {
+		list := filepath.Join(t.TempDir(), "common.txt")
+		require.NoError(t, os.WriteFile(list, []byte("# 公司内部常见密码\ncompany2026\n"), 0o644))
+
+		policy, err := password.NewPolicy(&config.Config{Password: config.PasswordConfig{
+			Policy: config.PasswordPolicyConfig{RejectCommon: true, CommonPasswordsFile: list},
+		}})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, policy.Validate("Password123", ""), password.ErrCommon)
+		assert.ErrorIs(t, policy.Validate("COMPANY2026", ""), password.ErrCommon)
+		assert.NoError(t, policy.Validate("correct horse battery", ""))
+
+		_, err = password.NewPolicy(&config.Config{Password: config.PasswordConfig{
+			Policy: config.PasswordPolicyConfig{RejectCommon: true, CommonPasswordsFile: filepath.Join(t.TempDir(), "missing.txt")},
+		}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Username", func(t *testing.T) {
+		policy, err := password.NewPolicy(&config.Config{Password: config.PasswordConfig{
+			Policy: config.PasswordPolicyConfig{RejectUsername: true},
+		}})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, policy.Validate("Alice-2026!", "alice"), password.ErrContainsUsername)
+		assert.NoError(t, policy.Validate("Alice-2026!", "bob"))
+		assert.NoError(t, policy.Validate("Alice-2026!", ""))
+	})
+}
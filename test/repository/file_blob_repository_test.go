@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/test/testutil"
+)
+
+// FileBlobRepositoryTestSuite 物理文件对象仓储测试套件
+type FileBlobRepositoryTestSuite struct {
+	suite.Suite
+	db     *testutil.TestDatabase
+	logger *testutil.TestLogger
+	repo   repository.FileBlobRepository
+	ctx    context.Context
+}
+
+// SetupSuite 设置测试套件
+func (suite *FileBlobRepositoryTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	suite.repo = repository.NewFileBlobRepository(
+		suite.db.CreateTestDatabase(),
+		suite.logger.CreateTestLogger(),
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *FileBlobRepositoryTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *FileBlobRepositoryTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+}
+
+// TestAcquireAndRelease 测试引用计数
+func (suite *FileBlobRepositoryTestSuite) TestAcquireAndRelease() {
+	first, err := suite.repo.Acquire(suite.ctx, &model.FileBlob{
+		StorageType: model.StorageTypeLocal,
+		Hash:        "hash-1",
+		Path:        "first.jpg",
+		Size:        10,
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, first.RefCount)
+	assert.Equal(suite.T(), "first.jpg", first.Path)
+
+	// 相同内容复用已有对象
+	second, err := suite.repo.Acquire(suite.ctx, &model.FileBlob{
+		StorageType: model.StorageTypeLocal,
+		Hash:        "hash-1",
+		Path:        "second.jpg",
+		Size:        10,
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), first.ID, second.ID)
+	assert.Equal(suite.T(), 2, second.RefCount)
+	assert.Equal(suite.T(), "first.jpg", second.Path)
+
+	// 不同存储驱动分别存储
+	other, err := suite.repo.Acquire(suite.ctx, &model.FileBlob{
+		StorageType: model.StorageTypeS3,
+		Hash:        "hash-1",
+		Path:        "remote.jpg",
+	})
+	require.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), first.ID, other.ID)
+
+	blob, removed, err := suite.repo.Release(suite.ctx, first.ID)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), removed)
+	assert.Equal(suite.T(), 1, blob.RefCount)
+
+	blob, removed, err = suite.repo.Release(suite.ctx, first.ID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), removed)
+	assert.Equal(suite.T(), "first.jpg", blob.Path)
+
+	_, err = suite.repo.GetByID(suite.ctx, first.ID)
+	assert.Error(suite.T(), err)
+}
+
+// TestConcurrentAcquire 测试并发引用同一内容
+func (suite *FileBlobRepositoryTestSuite) TestConcurrentAcquire() {
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := suite.repo.Acquire(suite.ctx, &model.FileBlob{
+				StorageType: model.StorageTypeLocal,
+				Hash:        "hash-concurrent",
+				Path:        "concurrent.jpg",
+			})
+			assert.NoError(suite.T(), err)
+		}()
+	}
+	wg.Wait()
+
+	var blobs []model.FileBlob
+	require.NoError(suite.T(), suite.db.GetDB().Where("hash = ?", "hash-concurrent").Find(&blobs).Error)
+	require.Len(suite.T(), blobs, 1)
+	assert.Equal(suite.T(), 5, blobs[0].RefCount)
+}
+
+// TestFileBlobRepositoryTestSuite 运行物理文件对象仓储测试套件
+func TestFileBlobRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(FileBlobRepositoryTestSuite))
+}
@@ -91,7 +91,7 @@ func (suite *FileRepositoryTestSuite) TestCreate() {
 	assert.NotZero(suite.T(), file.UpdatedAt)
 }
 
-// TestCreateDuplicateHash 测试创建重复hash的文件
+// TestCreateDuplicateHash 测试相同内容可以有多个文件记录
 func (suite *FileRepositoryTestSuite) TestCreateDuplicateHash() {
 	hash := "duplicate-hash-123"
 
@@ -122,8 +122,9 @@ func (suite *FileRepositoryTestSuite) TestCreateDuplicateHash() {
 	err := suite.repo.Create(suite.ctx, file1)
 	require.NoError(suite.T(), err)
 
+	// 物理对象由 file_blobs 去重，文件记录允许相同hash
 	err = suite.repo.Create(suite.ctx, file2)
-	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), err)
 }
 
 // TestGetByID 测试根据ID获取文件
@@ -141,6 +142,18 @@ func (suite *FileRepositoryTestSuite) TestGetByID() {
 	assert.Equal(suite.T(), suite.owner.ID, foundFile.Owner.ID)
 }
 
+// TestIncrementDownloadCount 测试增加下载次数
+func (suite *FileRepositoryTestSuite) TestIncrementDownloadCount() {
+	file := suite.createTestFile("download.jpg", "download-hash")
+
+	require.NoError(suite.T(), suite.repo.IncrementDownloadCount(suite.ctx, file.ID))
+	require.NoError(suite.T(), suite.repo.IncrementDownloadCount(suite.ctx, file.ID))
+
+	foundFile, err := suite.repo.GetByID(suite.ctx, file.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, foundFile.DownloadCount)
+}
+
 // TestGetByIDNotFound 测试获取不存在的文件
 func (suite *FileRepositoryTestSuite) TestGetByIDNotFound() {
 	_, err := suite.repo.GetByID(suite.ctx, 999)
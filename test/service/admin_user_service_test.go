@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// AdminUserServiceTestSuite 管理员用户管理服务测试套件
+type AdminUserServiceTestSuite struct {
+	suite.Suite
+	db           *testutil.TestDatabase
+	cache        *testutil.TestCache
+	logger       *testutil.TestLogger
+	resets       *mocks.MockPasswordResetService
+	permissions  *fakePermissionCache
+	audit        *mocks.MockAuditService
+	tokenManager *token.Manager
+	tokens       service.TokenService
+	roles        service.RoleService
+	service      service.AdminUserService
+	ctx          context.Context
+	admin        *model.User
+	user         *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *AdminUserServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+	suite.tokenManager = testutil.NewTestTokenManager(suite.T())
+}
+
+// TearDownSuite 清理测试套件
+func (suite *AdminUserServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *AdminUserServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+	userRepo := repository.NewUserRepository(database, testLogger)
+
+	suite.resets = new(mocks.MockPasswordResetService)
+	suite.permissions = &fakePermissionCache{}
+	suite.audit = new(mocks.MockAuditService)
+	suite.audit.On("Record", mock.Anything, mock.Anything).Maybe().Return()
+	suite.tokens = service.NewTokenService(
+		repository.NewRefreshTokenRepository(database, testLogger),
+		repository.NewUserSessionRepository(database, testLogger),
+		userRepo,
+		suite.tokenManager,
+		suite.cache.CreateTestCache(),
+		testLogger,
+	)
+	suite.roles = service.NewRoleService(
+		repository.NewRoleRepository(database, testLogger),
+		userRepo,
+		suite.permissions,
+		suite.audit,
+		testLogger,
+	)
+	suite.service = service.NewAdminUserService(
+		userRepo,
+		suite.roles,
+		suite.tokens,
+		suite.resets,
+		suite.permissions,
+		suite.audit,
+		suite.tokenManager,
+		testutil.NewTestPasswordPolicy(suite.T()),
+		&config.Config{Auth: config.AuthConfig{ImpersonationExpiration: 300}},
+		testLogger,
+	)
+
+	suite.admin = &model.User{Username: "admin", Email: "admin@example.com", Password: "password123", Role: model.UserRoleAdmin}
+	suite.user = &model.User{Username: "member", Email: "member@example.com", Password: "password123", Role: model.UserRoleUser}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.admin).Error)
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+}
+
+// TestCreateUser 测试管理员直接创建用户
+func (suite *AdminUserServiceTestSuite) TestCreateUser() {
+	user, err := suite.service.CreateUser(suite.ctx, suite.admin.ID, &service.AdminCreateUserRequest{
+		Username: "editor",
+		Email:    "editor@example.com",
+		Password: "password123",
+		Role:     model.UserRoleAdmin,
+	})
+	suite.Require().NoError(err)
+	suite.Equal(model.UserRoleAdmin, user.Role)
+	suite.Equal(model.UserStatusActive, user.Status)
+	suite.NotNil(user.EmailVerifiedAt)
+	suite.True(user.CheckPassword("password123"))
+
+	// 审计日志不记录用户名和邮箱
+	suite.audit.AssertCalled(suite.T(), "Record", mock.Anything, &service.AuditEntry{
+		Action:       "user.create",
+		ResourceType: "user",
+		ResourceID:   strconv.FormatUint(uint64(user.ID), 10),
+		After:        map[string]interface{}{"role": model.UserRoleAdmin, "status": model.UserStatusActive},
+	})
+
+	_, err = suite.service.CreateUser(suite.ctx, suite.admin.ID, &service.AdminCreateUserRequest{Username: "other", Email: "editor@example.com", Password: "password123"})
+	suite.ErrorIs(err, service.ErrUserExists)
+	_, err = suite.service.CreateUser(suite.ctx, suite.admin.ID, &service.AdminCreateUserRequest{Username: "editor", Email: "other@example.com", Password: "password123"})
+	suite.ErrorIs(err, service.ErrUserExists)
+	_, err = suite.service.CreateUser(suite.ctx, suite.admin.ID, &service.AdminCreateUserRequest{Username: "other", Email: "other@example.com", Password: "password123", Role: "owner"})
+	suite.ErrorIs(err, service.ErrInvalidUserInput)
+	_, err = suite.service.CreateUser(suite.ctx, suite.admin.ID, &service.AdminCreateUserRequest{Username: "other", Email: "not-an-email", Password: "password123"})
+	suite.ErrorIs(err, service.ErrInvalidUserInput)
+	_, err = suite.service.CreateUser(suite.ctx, suite.admin.ID, &service.AdminCreateUserRequest{Username: "other", Email: "other@example.com", Password: "123"})
+	suite.ErrorIs(err, service.ErrPasswordTooShort)
+}
+
+// TestBanRevokesSessions 测试封禁用户时撤销其所有会话
+func (suite *AdminUserServiceTestSuite) TestBanRevokesSessions() {
+	_, err := suite.tokens.Issue(suite.ctx, suite.user, service.ClientInfo{}, false)
+	suite.Require().NoError(err)
+
+	user, err := suite.service.UpdateStatus(suite.ctx, suite.admin.ID, suite.user.ID, model.UserStatusBanned)
+	suite.Require().NoError(err)
+	suite.True(user.IsBanned())
+
+	sessions, err := suite.tokens.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Empty(sessions)
+
+	user, err = suite.service.UpdateStatus(suite.ctx, suite.admin.ID, suite.user.ID, model.UserStatusActive)
+	suite.Require().NoError(err)
+	suite.True(user.IsActive())
+
+	_, err = suite.service.UpdateStatus(suite.ctx, suite.admin.ID, suite.user.ID, "deleted")
+	suite.ErrorIs(err, service.ErrInvalidUserInput)
+	_, err = suite.service.UpdateStatus(suite.ctx, suite.admin.ID, suite.admin.ID, model.UserStatusBanned)
+	suite.ErrorIs(err, service.ErrCannotModifySelf)
+	_, err = suite.service.UpdateStatus(suite.ctx, suite.admin.ID, suite.user.ID+100, model.UserStatusBanned)
+	suite.ErrorIs(err, service.ErrUserNotFound)
+}
+
+// TestUpdateRole 测试修改角色时清除权限缓存并撤销会话
+func (suite *AdminUserServiceTestSuite) TestUpdateRole() {
+	_, err := suite.tokens.Issue(suite.ctx, suite.user, service.ClientInfo{}, false)
+	suite.Require().NoError(err)
+
+	user, err := suite.service.UpdateRole(suite.ctx, suite.admin.ID, suite.user.ID, model.UserRoleAdmin)
+	suite.Require().NoError(err)
+	suite.Equal(model.UserRoleAdmin, user.Role)
+	suite.Equal([]uint{suite.user.ID}, suite.permissions.clearedUsers)
+	suite.audit.AssertCalled(suite.T(), "Record", mock.Anything, &service.AuditEntry{
+		Action:       "user.role_update",
+		ResourceType: "user",
+		ResourceID:   strconv.FormatUint(uint64(suite.user.ID), 10),
+		Before:       map[string]interface{}{"role": model.UserRoleUser},
+		After:        map[string]interface{}{"role": model.UserRoleAdmin},
+	})
+
+	sessions, err := suite.tokens.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Empty(sessions)
+
+	var stored model.User
+	suite.Require().NoError(suite.db.GetDB().First(&stored, suite.user.ID).Error)
+	suite.Equal(model.UserRoleAdmin, stored.Role)
+	suite.True(stored.CheckPassword("password123"))
+
+	_, err = suite.service.UpdateRole(suite.ctx, suite.admin.ID, suite.admin.ID, model.UserRoleUser)
+	suite.ErrorIs(err, service.ErrCannotModifySelf)
+}
+
+// TestForcePasswordReset 测试强制重置后旧密码失效并发送重置邮件
+func (suite *AdminUserServiceTestSuite) TestForcePasswordReset() {
+	suite.resets.On("Forgot", mock.Anything, &service.ForgotPasswordRequest{Email: suite.user.Email}).Return(nil)
+
+	suite.Require().NoError(suite.service.ForcePasswordReset(suite.ctx, suite.admin.ID, suite.user.ID))
+
+	var stored model.User
+	suite.Require().NoError(suite.db.GetDB().First(&stored, suite.user.ID).Error)
+	suite.False(stored.CheckPassword("password123"))
+	suite.resets.AssertExpectations(suite.T())
+}
+
+// TestImpersonate 测试模拟登录令牌携带 act 声明且有效期受限
+func (suite *AdminUserServiceTestSuite) TestImpersonate() {
+	response, err := suite.service.Impersonate(suite.ctx, suite.admin.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal(suite.admin.ID, response.ImpersonatorID)
+	suite.Equal(suite.user.ID, response.User.ID)
+	suite.LessOrEqual(response.ExpiresIn, int64(300))
+
+	claims, err := suite.tokenManager.Parse(response.Token)
+	suite.Require().NoError(err)
+	suite.Equal(suite.user.ID, claims.UserID)
+	suite.Empty(claims.SessionID)
+	suite.Require().NotNil(claims.Actor)
+	suite.Equal(suite.admin.ID, claims.Actor.UserID)
+	suite.Equal(suite.admin.Email, claims.Actor.Subject)
+	suite.WithinDuration(time.Now().Add(5*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+
+	// 不能模拟管理员、未激活的用户或自己
+	other := &model.User{Username: "admin2", Email: "admin2@example.com", Password: "password123", Role: model.UserRoleAdmin}
+	suite.Require().NoError(suite.db.GetDB().Create(other).Error)
+	_, err = suite.service.Impersonate(suite.ctx, suite.admin.ID, other.ID)
+	suite.ErrorIs(err, service.ErrImpersonationNotAllowed)
+	_, err = suite.service.Impersonate(suite.ctx, suite.admin.ID, suite.admin.ID)
+	suite.ErrorIs(err, service.ErrCannotModifySelf)
+
+	suite.Require().NoError(suite.db.GetDB().Model(suite.user).Update("status", model.UserStatusBanned).Error)
+	_, err = suite.service.Impersonate(suite.ctx, suite.admin.ID, suite.user.ID)
+	suite.ErrorIs(err, service.ErrImpersonationNotAllowed)
+}
+
+// TestNonAdminCannotEscalate 测试没有全部权限的用户管理员不能创建或提升管理员、不能操作管理员，也不能模拟拥有自己没有的权限的用户
+func (suite *AdminUserServiceTestSuite) TestNonAdminCannotEscalate() {
+	_, err := suite.roles.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{
+		Name:        "user_manager",
+		Permissions: []string{"user:create", "user:update", "user:impersonate", "article:read"},
+	})
+	suite.Require().NoError(err)
+	_, err = suite.roles.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{Name: "superuser", Permissions: []string{"*:*"}})
+	suite.Require().NoError(err)
+	_, err = suite.roles.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{Name: "reader", Permissions: []string{"article:read"}})
+	suite.Require().NoError(err)
+
+	manager := &model.User{Username: "manager", Email: "manager@example.com", Password: "password123", Role: model.UserRoleUser, Status: model.UserStatusActive}
+	superuser := &model.User{Username: "superuser", Email: "superuser@example.com", Password: "password123", Role: model.UserRoleUser, Status: model.UserStatusActive}
+	reader := &model.User{Username: "reader", Email: "reader@example.com", Password: "password123", Role: model.UserRoleUser, Status: model.UserStatusActive}
+	for _, user := range []*model.User{manager, superuser, reader} {
+		suite.Require().NoError(suite.db.GetDB().Create(user).Error)
+	}
+	for user, role := range map[*model.User]string{manager: "user_manager", superuser: "superuser", reader: "reader"} {
+		_, err := suite.roles.SetUserRoles(suite.ctx, suite.admin.ID, user.ID, &service.SetUserRolesRequest{Roles: []string{role}})
+		suite.Require().NoError(err)
+	}
+
+	// 不能创建或提升管理员
+	_, err = suite.service.CreateUser(suite.ctx, manager.ID, &service.AdminCreateUserRequest{
+		Username: "escalated",
+		Email:    "escalated@example.com",
+		Password: "password123",
+		Role:     model.UserRoleAdmin,
+	})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+	_, err = suite.service.CreateUser(suite.ctx, manager.ID, &service.AdminCreateUserRequest{
+		Username: "member2",
+		Email:    "member2@example.com",
+		Password: "password123",
+	})
+	suite.NoError(err)
+	_, err = suite.service.UpdateRole(suite.ctx, manager.ID, suite.user.ID, model.UserRoleAdmin)
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+
+	// 不能修改拥有全部权限的用户
+	for _, target := range []*model.User{suite.admin, superuser} {
+		_, err = suite.service.UpdateStatus(suite.ctx, manager.ID, target.ID, model.UserStatusBanned)
+		suite.ErrorIs(err, service.ErrPermissionNotHeld)
+		suite.ErrorIs(suite.service.ForcePasswordReset(suite.ctx, manager.ID, target.ID), service.ErrPermissionNotHeld)
+	}
+	_, err = suite.service.UpdateRole(suite.ctx, manager.ID, suite.admin.ID, model.UserRoleUser)
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+	_, err = suite.service.UpdateStatus(suite.ctx, manager.ID, suite.user.ID, model.UserStatusInactive)
+	suite.NoError(err)
+
+	// 只能模拟权限不超过自己的用户
+	_, err = suite.service.Impersonate(suite.ctx, manager.ID, superuser.ID)
+	suite.ErrorIs(err, service.ErrImpersonationNotAllowed)
+	_, err = suite.service.Impersonate(suite.ctx, manager.ID, reader.ID)
+	suite.NoError(err)
+
+	var stored model.User
+	suite.Require().NoError(suite.db.GetDB().First(&stored, suite.admin.ID).Error)
+	suite.True(stored.IsActive())
+	suite.True(stored.CheckPassword("password123"))
+	suite.resets.AssertNotCalled(suite.T(), "Forgot", mock.Anything, mock.Anything)
+}
+
+// TestAdminUserServiceSuite 运行管理员用户管理服务测试套件
+func TestAdminUserServiceSuite(t *testing.T) {
+	suite.Run(t, new(AdminUserServiceTestSuite))
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/test/testutil"
+)
+
+// APIKeyServiceTestSuite 个人 API Key 服务测试套件
+type APIKeyServiceTestSuite struct {
+	suite.Suite
+	db      *testutil.TestDatabase
+	logger  *testutil.TestLogger
+	service service.APIKeyService
+	ctx     context.Context
+	user    *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *APIKeyServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+	suite.service = service.NewAPIKeyService(
+		repository.NewAPIKeyRepository(database, testLogger),
+		repository.NewUserRepository(database, testLogger),
+		testLogger,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *APIKeyServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *APIKeyServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+
+	suite.user = &model.User{
+		Username: "apikeyuser",
+		Email:    "apikey@example.com",
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+}
+
+// create 创建 API Key
+func (suite *APIKeyServiceTestSuite) create(scopes ...string) *service.CreatedAPIKey {
+	created, err := suite.service.Create(suite.ctx, suite.user.ID, &service.CreateAPIKeyRequest{Name: "ci", Scopes: scopes})
+	suite.Require().NoError(err)
+	return created
+}
+
+// TestCreateAndAuthenticate 测试创建后使用明文认证，数据库只保存哈希
+func (suite *APIKeyServiceTestSuite) TestCreateAndAuthenticate() {
+	created := suite.create("Article:Read", "file:upload", "article:read")
+	suite.True(strings.HasPrefix(created.Key, "vk_"))
+	suite.Equal(created.Key[:len(created.APIKey.Prefix)], created.APIKey.Prefix)
+	suite.Equal([]string{"article:read", "file:upload"}, created.APIKey.Scopes)
+
+	var stored model.APIKey
+	suite.Require().NoError(suite.db.GetDB().First(&stored, created.APIKey.ID).Error)
+	suite.Equal(token.Hash(created.Key), stored.KeyHash)
+	suite.NotContains(stored.KeyHash, created.Key)
+	suite.Nil(stored.LastUsedAt)
+
+	apiKey, user, err := suite.service.Authenticate(suite.ctx, created.Key, "10.0.0.1")
+	suite.Require().NoError(err)
+	suite.Equal(created.APIKey.ID, apiKey.ID)
+	suite.Equal(suite.user.ID, user.ID)
+
+	// 认证时记录最近使用时间和 IP
+	suite.Require().NoError(suite.db.GetDB().First(&stored, created.APIKey.ID).Error)
+	suite.Require().NotNil(stored.LastUsedAt)
+	suite.Equal("10.0.0.1", stored.LastUsedIP)
+
+	_, _, err = suite.service.Authenticate(suite.ctx, created.Key+"x", "10.0.0.1")
+	suite.ErrorIs(err, service.ErrInvalidAPIKey)
+	_, _, err = suite.service.Authenticate(suite.ctx, "not-an-api-key", "10.0.0.1")
+	suite.ErrorIs(err, service.ErrInvalidAPIKey)
+}
+
+// TestCreateValidation 测试权限和过期时间校验
+func (suite *APIKeyServiceTestSuite) TestCreateValidation() {
+	cases := map[string][]string{
+		"no scopes":      nil,
+		"missing action": {"article"},
+		"invalid scope":  {"article:read:everyone"},
+		"invalid chars":  {"article:read;drop"},
+	}
+	for name, scopes := range cases {
+		_, err := suite.service.Create(suite.ctx, suite.user.ID, &service.CreateAPIKeyRequest{Name: "ci", Scopes: scopes})
+		suite.ErrorIs(err, service.ErrInvalidAPIKeyScope, name)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	_, err := suite.service.Create(suite.ctx, suite.user.ID, &service.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"*:*"}, ExpiresAt: &past})
+	suite.ErrorIs(err, service.ErrInvalidAPIKeyExpiry)
+}
+
+// TestExpiredKey 测试过期的 API Key 无法认证
+func (suite *APIKeyServiceTestSuite) TestExpiredKey() {
+	created := suite.create("article:read")
+	suite.Require().NoError(suite.db.GetDB().Model(&model.APIKey{}).
+		Where("id = ?", created.APIKey.ID).
+		Update("expires_at", time.Now().Add(-time.Second)).Error)
+
+	_, _, err := suite.service.Authenticate(suite.ctx, created.Key, "10.0.0.1")
+	suite.ErrorIs(err, service.ErrInvalidAPIKey)
+}
+
+// TestRevoke 测试撤销后立即失效，且不能撤销其他用户的 API Key
+func (suite *APIKeyServiceTestSuite) TestRevoke() {
+	created := suite.create("article:read")
+
+	suite.ErrorIs(suite.service.Revoke(suite.ctx, suite.user.ID+1, created.APIKey.ID), service.ErrAPIKeyNotFound)
+	suite.Require().NoError(suite.service.Revoke(suite.ctx, suite.user.ID, created.APIKey.ID))
+	suite.ErrorIs(suite.service.Revoke(suite.ctx, suite.user.ID, created.APIKey.ID), service.ErrAPIKeyNotFound)
+
+	_, _, err := suite.service.Authenticate(suite.ctx, created.Key, "10.0.0.1")
+	suite.ErrorIs(err, service.ErrInvalidAPIKey)
+
+	keys, err := suite.service.List(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Empty(keys)
+}
+
+// TestInactiveUser 测试被禁用用户的 API Key 无法认证
+func (suite *APIKeyServiceTestSuite) TestInactiveUser() {
+	created := suite.create("article:read")
+	suite.Require().NoError(suite.db.GetDB().Model(suite.user).Update("status", model.UserStatusBanned).Error)
+
+	_, _, err := suite.service.Authenticate(suite.ctx, created.Key, "10.0.0.1")
+	suite.ErrorIs(err, service.ErrInvalidAPIKey)
+}
+
+// TestLimit 测试每个用户有效的 API Key 数量上限
+func (suite *APIKeyServiceTestSuite) TestLimit() {
+	for i := 0; i < 20; i++ {
+		suite.create("article:read")
+	}
+	_, err := suite.service.Create(suite.ctx, suite.user.ID, &service.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"article:read"}})
+	suite.ErrorIs(err, service.ErrAPIKeyLimitReached)
+}
+
+// TestAPIKeyServiceSuite 运行个人 API Key 服务测试套件
+func TestAPIKeyServiceSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyServiceTestSuite))
+}
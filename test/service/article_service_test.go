@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 
@@ -20,17 +21,19 @@ import (
 // ArticleServiceTestSuite 文章服务测试套件
 type ArticleServiceTestSuite struct {
 	suite.Suite
-	articleRepo *mocks.MockArticleRepository
-	cache       *mocks.MockCache
-	logger      *mocks.MockLogger
-	service     service.ArticleService
-	ctx         context.Context
+	articleRepo  *mocks.MockArticleRepository
+	cache        *mocks.MockCache
+	auditService *mocks.MockAuditService
+	logger       *mocks.MockLogger
+	service      service.ArticleService
+	ctx          context.Context
 }
 
 // SetupSuite 设置测试套件
 func (suite *ArticleServiceTestSuite) SetupSuite() {
 	suite.articleRepo = new(mocks.MockArticleRepository)
 	suite.cache = new(mocks.MockCache)
+	suite.auditService = new(mocks.MockAuditService)
 	suite.logger = new(mocks.MockLogger)
 	suite.ctx = context.Background()
 
@@ -38,6 +41,7 @@ func (suite *ArticleServiceTestSuite) SetupSuite() {
 	suite.service = service.NewArticleService(
 		suite.articleRepo,
 		suite.cache,
+		suite.auditService,
 		suite.logger,
 	)
 }
@@ -48,6 +52,9 @@ func (suite *ArticleServiceTestSuite) SetupTest() {
 	suite.articleRepo.ExpectedCalls = nil
 	suite.cache.ExpectedCalls = nil
 	suite.logger.ExpectedCalls = nil
+	suite.auditService.ExpectedCalls = nil
+	suite.auditService.Calls = nil
+	suite.auditService.On("Record", mock.Anything, mock.Anything).Maybe().Return()
 }
 
 // TestCreate 测试创建文章
@@ -231,6 +238,8 @@ func (suite *ArticleServiceTestSuite) TestUpdate() {
 		Title:     "Old Title",
 		Content:   "Old content",
 		Status:    model.ArticleStatusDraft,
+		AuthorID:  7,
+		Author:    model.User{BaseModel: model.BaseModel{ID: 7}, Username: "author", Email: "author@example.com"},
 	}
 
 	req := &service.UpdateArticleRequest{
@@ -258,6 +267,21 @@ func (suite *ArticleServiceTestSuite) TestUpdate() {
 	assert.Equal(suite.T(), req.Content, result.Content)
 	assert.Equal(suite.T(), req.Status, result.Status)
 
+	// 审计日志只记录文章的关键字段，不包含预加载的作者信息
+	suite.auditService.AssertCalled(suite.T(), "Record", suite.ctx, mock.MatchedBy(func(entry *service.AuditEntry) bool {
+		before, ok := entry.Before.(map[string]interface{})
+		after, _ := entry.After.(map[string]interface{})
+		return ok && entry.Action == "article.update" && entry.ResourceID == "1" &&
+			before["title"] == "Old Title" && before["status"] == model.ArticleStatusDraft &&
+			after["title"] == "New Title" && after["status"] == model.ArticleStatusPublished &&
+			after["author_id"] == uint(7)
+	}))
+	entry := suite.auditService.Calls[0].Arguments.Get(1).(*service.AuditEntry)
+	data, err := json.Marshal(entry)
+	require.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), string(data), "author@example.com")
+	assert.NotContains(suite.T(), string(data), "New content")
+
 	// 验证mock调用
 	suite.articleRepo.AssertExpectations(suite.T())
 	suite.logger.AssertExpectations(suite.T())
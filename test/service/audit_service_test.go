@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/audit"
+	"vibe-coding-starter/test/testutil"
+)
+
+// AuditServiceTestSuite 审计日志服务测试套件
+type AuditServiceTestSuite struct {
+	suite.Suite
+	db      *testutil.TestDatabase
+	logger  *testutil.TestLogger
+	service service.AuditService
+	ctx     context.Context
+}
+
+// SetupSuite 设置测试套件
+func (suite *AuditServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+}
+
+// TearDownSuite 清理测试套件
+func (suite *AuditServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *AuditServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+
+	testLogger := suite.logger.CreateTestLogger()
+	suite.service = service.NewAuditService(
+		repository.NewAuditLogRepository(suite.db.CreateTestDatabase(), testLogger),
+		testLogger,
+	)
+	suite.ctx = audit.NewContext(context.Background(), &audit.Metadata{
+		ActorID:        7,
+		ImpersonatorID: 1,
+		IP:             "192.0.2.1",
+		UserAgent:      "audit-test",
+		RequestID:      "req-1",
+	})
+}
+
+// stop 停止服务并写入队列中的审计日志
+func (suite *AuditServiceTestSuite) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	suite.Require().NoError(suite.service.Stop(ctx))
+}
+
+// TestRecordDiff 测试记录操作人和修改前后的字段差异
+func (suite *AuditServiceTestSuite) TestRecordDiff() {
+	suite.service.Start()
+
+	before := &model.Article{BaseModel: model.BaseModel{ID: 3}, Title: "Draft", Status: model.ArticleStatusDraft}
+	after := *before
+	after.Title = "Published"
+	after.Status = model.ArticleStatusPublished
+	after.UpdatedAt = time.Now()
+	suite.service.Record(suite.ctx, &service.AuditEntry{
+		Action:       "article.update",
+		ResourceType: "article",
+		ResourceID:   "3",
+		Before:       before,
+		After:        &after,
+	})
+	suite.service.Record(suite.ctx, &service.AuditEntry{
+		Action:       "user.update",
+		ResourceType: "user",
+		ResourceID:   "7",
+		Before:       map[string]interface{}{"password_hash": "old", "nickname": "a"},
+		After:        map[string]interface{}{"password_hash": "new", "nickname": "a"},
+	})
+	suite.stop()
+	suite.True(audit.FromContext(suite.ctx).Recorded)
+
+	logs, total, err := suite.service.List(context.Background(), repository.ListOptions{
+		Filters: map[string]interface{}{"resource_type": "article", "resource_id": "3"},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), total)
+
+	log := logs[0]
+	suite.Equal("article.update", log.Action)
+	suite.Require().NotNil(log.ActorID)
+	suite.Equal(uint(7), *log.ActorID)
+	suite.Require().NotNil(log.ImpersonatorID)
+	suite.Equal(uint(1), *log.ImpersonatorID)
+	suite.Equal("192.0.2.1", log.IP)
+	suite.Equal("req-1", log.RequestID)
+	suite.Equal(map[string]model.AuditChange{
+		"title":  {From: "Draft", To: "Published"},
+		"status": {From: model.ArticleStatusDraft, To: model.ArticleStatusPublished},
+	}, log.Changes)
+
+	// 敏感字段只记录发生了变更
+	logs, _, err = suite.service.List(context.Background(), repository.ListOptions{
+		Filters: map[string]interface{}{"action": "user.update"},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(logs, 1)
+	suite.Equal(map[string]model.AuditChange{
+		"password_hash": {From: "***FILTERED***", To: "***FILTERED***"},
+	}, logs[0].Changes)
+}
+
+// TestRecordDoesNotBlock 测试队列已满时丢弃审计日志而不阻塞调用方
+func (suite *AuditServiceTestSuite) TestRecordDoesNotBlock() {
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2000; i++ {
+			suite.service.Record(context.Background(), &service.AuditEntry{Action: "dict_item.update", ResourceType: "dict_item"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		suite.FailNow("Record blocked while the audit worker was not running")
+	}
+
+	// 停止时写入队列中已有的日志，未认证的请求没有操作人
+	suite.stop()
+	logs, total, err := suite.service.List(context.Background(), repository.ListOptions{Page: 1, PageSize: 10})
+	suite.Require().NoError(err)
+	suite.Equal(int64(1024), total)
+	suite.Len(logs, 10)
+	suite.Nil(logs[0].ActorID)
+}
+
+// TestListFilters 测试按操作人和时间范围过滤
+func (suite *AuditServiceTestSuite) TestListFilters() {
+	suite.service.Start()
+	suite.service.Record(suite.ctx, &service.AuditEntry{Action: "role.create", ResourceType: "role", ResourceID: "1"})
+	suite.service.Record(context.Background(), &service.AuditEntry{Action: "account_locked", ResourceType: "security", ResourceID: "member"})
+	suite.stop()
+
+	logs, total, err := suite.service.List(context.Background(), repository.ListOptions{
+		Filters: map[string]interface{}{"actor_id": uint(7)},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), total)
+	suite.Equal("role.create", logs[0].Action)
+
+	_, total, err = suite.service.List(context.Background(), repository.ListOptions{
+		Filters: map[string]interface{}{"created_after": time.Now().Add(time.Hour)},
+	})
+	suite.Require().NoError(err)
+	suite.Zero(total)
+
+	_, total, err = suite.service.List(context.Background(), repository.ListOptions{
+		Filters: map[string]interface{}{"created_before": time.Now().Add(time.Hour), "request_id": "req-1"},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), total)
+}
+
+// TestAuditServiceSuite 运行审计日志服务测试套件
+func TestAuditServiceSuite(t *testing.T) {
+	suite.Run(t, new(AuditServiceTestSuite))
+}
@@ -18,20 +18,25 @@ type DepartmentServiceTestSuite struct {
 	suite.Suite
 	service    service.DepartmentService
 	mockRepo   *mocks.MockDepartmentRepository
+	mockAudit  *mocks.MockAuditService
 	mockLogger *mocks.MockLogger
 	ctx        context.Context
 }
 
 func (suite *DepartmentServiceTestSuite) SetupTest() {
 	suite.mockRepo = &mocks.MockDepartmentRepository{}
+	suite.mockAudit = &mocks.MockAuditService{}
 	suite.mockLogger = &mocks.MockLogger{}
 	suite.ctx = context.Background()
 
+	suite.mockAudit.On("Record", mock.Anything, mock.Anything).Maybe().Return()
+
 	// 设置logger mock的期望调用
 	suite.mockLogger.On("Info", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).Return()
 
 	suite.service = service.NewDepartmentService(
 		suite.mockRepo,
+		suite.mockAudit,
 		suite.mockLogger,
 	)
 }
@@ -23,11 +23,12 @@ func boolPtr(b bool) *bool {
 // DictServiceTestSuite 数据字典服务测试套件
 type DictServiceTestSuite struct {
 	suite.Suite
-	dictRepo *mocks.MockDictRepository
-	cache    *mocks.MockCache
-	logger   *mocks.MockLogger
-	service  service.DictService
-	ctx      context.Context
+	dictRepo     *mocks.MockDictRepository
+	cache        *mocks.MockCache
+	auditService *mocks.MockAuditService
+	logger       *mocks.MockLogger
+	service      service.DictService
+	ctx          context.Context
 }
 
 // SetupTest 设置每个测试
@@ -35,11 +36,15 @@ func (suite *DictServiceTestSuite) SetupTest() {
 	suite.ctx = context.Background()
 	suite.dictRepo = &mocks.MockDictRepository{}
 	suite.cache = &mocks.MockCache{}
+	suite.auditService = &mocks.MockAuditService{}
 	suite.logger = &mocks.MockLogger{}
 
+	suite.auditService.On("Record", mock.Anything, mock.Anything).Maybe().Return()
+
 	suite.service = service.NewDictService(
 		suite.dictRepo,
 		suite.cache,
+		suite.auditService,
 		suite.logger,
 	)
 }
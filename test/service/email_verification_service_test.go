@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// verifyLinkPattern 从邮件正文中提取验证令牌
+var verifyLinkPattern = regexp.MustCompile(`https://app\.example\.com/verify-email\?token=([^\s"]+)`)
+
+// EmailVerificationServiceTestSuite 邮箱验证服务测试套件
+type EmailVerificationServiceTestSuite struct {
+	suite.Suite
+	db       *testutil.TestDatabase
+	cache    *testutil.TestCache
+	store    cache.Cache
+	logger   *testutil.TestLogger
+	mailer   *mocks.MockMailer
+	userRepo repository.UserRepository
+	service  service.EmailVerificationService
+	ctx      context.Context
+	user     *model.User
+	sent     []*mailer.Message
+}
+
+// SetupSuite 设置测试套件
+func (suite *EmailVerificationServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			RequireEmailVerification:    true,
+			EmailVerificationExpiration: 86400,
+			VerificationResendInterval:  60,
+		},
+		Email: config.EmailConfig{LinkBaseURL: "https://app.example.com"},
+	}
+
+	suite.store = suite.cache.CreateTestCache()
+	suite.mailer = new(mocks.MockMailer)
+	suite.userRepo = repository.NewUserRepository(database, testLogger)
+	suite.service = service.NewEmailVerificationService(
+		suite.userRepo,
+		repository.NewOneTimeTokenRepository(database, testLogger),
+		suite.mailer,
+		suite.store,
+		cfg,
+		testLogger,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *EmailVerificationServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *EmailVerificationServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+	suite.Require().NoError(suite.store.Del(suite.ctx,
+		"email_verification_resend:pending-user@example.com",
+		"email_verification_resend:nobody@example.com"))
+
+	suite.sent = nil
+	suite.mailer.ExpectedCalls = nil
+	suite.mailer.Calls = nil
+	suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		suite.sent = append(suite.sent, args.Get(1).(*mailer.Message))
+	}).Return(nil)
+
+	suite.user = &model.User{
+		Username: "pending-user",
+		Email:    "pending-user@example.com",
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusInactive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+}
+
+// lastToken 返回最近一封验证邮件中的令牌
+func (suite *EmailVerificationServiceTestSuite) lastToken() string {
+	suite.Require().NotEmpty(suite.sent)
+	msg := suite.sent[len(suite.sent)-1]
+	suite.Equal([]string{suite.user.Email}, msg.To)
+
+	match := verifyLinkPattern.FindStringSubmatch(msg.Text)
+	suite.Require().Len(match, 2)
+	plain, err := url.QueryUnescape(match[1])
+	suite.Require().NoError(err)
+	return plain
+}
+
+// TestVerify 测试验证邮箱后激活账户
+func (suite *EmailVerificationServiceTestSuite) TestVerify() {
+	suite.Require().NoError(suite.service.Send(suite.ctx, suite.user))
+	plain := suite.lastToken()
+
+	user, err := suite.service.Verify(suite.ctx, &service.VerifyEmailRequest{Token: plain})
+	suite.Require().NoError(err)
+	suite.True(user.IsActive())
+	suite.NotNil(user.EmailVerifiedAt)
+
+	// 令牌只能使用一次
+	_, err = suite.service.Verify(suite.ctx, &service.VerifyEmailRequest{Token: plain})
+	suite.ErrorIs(err, service.ErrInvalidVerificationToken)
+}
+
+// TestVerifyInvalidToken 测试无效和过期的验证令牌
+func (suite *EmailVerificationServiceTestSuite) TestVerifyInvalidToken() {
+	_, err := suite.service.Verify(suite.ctx, &service.VerifyEmailRequest{Token: "unknown"})
+	suite.ErrorIs(err, service.ErrInvalidVerificationToken)
+
+	suite.Require().NoError(suite.service.Send(suite.ctx, suite.user))
+	plain := suite.lastToken()
+	suite.Require().NoError(suite.db.GetDB().Model(&model.OneTimeToken{}).
+		Where("user_id = ?", suite.user.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	_, err = suite.service.Verify(suite.ctx, &service.VerifyEmailRequest{Token: plain})
+	suite.ErrorIs(err, service.ErrInvalidVerificationToken)
+}
+
+// TestVerifyDoesNotReactivateBannedUser 测试验证令牌不会恢复已禁用的账户
+func (suite *EmailVerificationServiceTestSuite) TestVerifyDoesNotReactivateBannedUser() {
+	suite.Require().NoError(suite.service.Send(suite.ctx, suite.user))
+	plain := suite.lastToken()
+	suite.Require().NoError(suite.db.GetDB().Model(suite.user).Update("status", model.UserStatusBanned).Error)
+
+	_, err := suite.service.Verify(suite.ctx, &service.VerifyEmailRequest{Token: plain})
+	suite.ErrorIs(err, service.ErrInvalidVerificationToken)
+
+	user, err := suite.userRepo.GetByID(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal(model.UserStatusBanned, user.Status)
+}
+
+// TestResend 测试重发验证邮件及限流
+func (suite *EmailVerificationServiceTestSuite) TestResend() {
+	req := &service.ResendVerificationRequest{Email: suite.user.Email}
+	suite.Require().NoError(suite.service.Resend(suite.ctx, req))
+	first := suite.lastToken()
+
+	// 限流间隔内再次请求
+	suite.ErrorIs(suite.service.Resend(suite.ctx, req), service.ErrVerificationResendTooSoon)
+	suite.Len(suite.sent, 1)
+
+	// 间隔过后重发，旧链接失效
+	suite.Require().NoError(suite.store.Del(suite.ctx, "email_verification_resend:"+suite.user.Email))
+	suite.Require().NoError(suite.service.Resend(suite.ctx, req))
+	second := suite.lastToken()
+	suite.NotEqual(first, second)
+
+	_, err := suite.service.Verify(suite.ctx, &service.VerifyEmailRequest{Token: first})
+	suite.ErrorIs(err, service.ErrInvalidVerificationToken)
+	_, err = suite.service.Verify(suite.ctx, &service.VerifyEmailRequest{Token: second})
+	suite.NoError(err)
+}
+
+// TestResendDoesNotRevealEmail 测试未注册和已验证的邮箱同样返回成功且同样限流
+func (suite *EmailVerificationServiceTestSuite) TestResendDoesNotRevealEmail() {
+	unknown := &service.ResendVerificationRequest{Email: "nobody@example.com"}
+	suite.NoError(suite.service.Resend(suite.ctx, unknown))
+	suite.ErrorIs(suite.service.Resend(suite.ctx, unknown), service.ErrVerificationResendTooSoon)
+
+	suite.Require().NoError(suite.db.GetDB().Model(suite.user).Update("status", model.UserStatusActive).Error)
+	suite.NoError(suite.service.Resend(suite.ctx, &service.ResendVerificationRequest{Email: suite.user.Email}))
+	suite.Empty(suite.sent)
+}
+
+// TestEmailVerificationServiceSuite 运行邮箱验证服务测试套件
+func TestEmailVerificationServiceSuite(t *testing.T) {
+	suite.Run(t, new(EmailVerificationServiceTestSuite))
+}
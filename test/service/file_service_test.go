@@ -1,9 +1,20 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -11,9 +22,12 @@ import (
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
 
+	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/repository"
 	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/signer"
+	"vibe-coding-starter/pkg/storage"
 	"vibe-coding-starter/test/mocks"
 )
 
@@ -21,6 +35,10 @@ import (
 type FileServiceTestSuite struct {
 	suite.Suite
 	fileRepo *mocks.MockFileRepository
+	blobRepo *mocks.MockFileBlobRepository
+	quota    *mocks.MockStorageQuotaService
+	storage  *storage.Manager
+	root     string
 	cache    *mocks.MockCache
 	logger   *mocks.MockLogger
 	service  service.FileService
@@ -30,13 +48,47 @@ type FileServiceTestSuite struct {
 // SetupSuite 设置测试套件
 func (suite *FileServiceTestSuite) SetupSuite() {
 	suite.fileRepo = new(mocks.MockFileRepository)
+	suite.blobRepo = new(mocks.MockFileBlobRepository)
+	suite.quota = new(mocks.MockStorageQuotaService)
 	suite.cache = new(mocks.MockCache)
 	suite.logger = new(mocks.MockLogger)
 	suite.ctx = context.Background()
 
+	// 使用临时目录作为本地存储
+	suite.root = suite.T().TempDir()
+	local, err := storage.NewLocalStorage(config.LocalStorageConfig{Root: suite.root})
+	suite.Require().NoError(err)
+	suite.storage = storage.NewManager(model.StorageTypeLocal)
+	suite.storage.Register(model.StorageTypeLocal, local)
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			Signing: config.SigningConfig{
+				ActiveKey:  "k1",
+				Keys:       []config.SigningKey{{ID: "k1", Secret: "test-signing-secret"}},
+				DefaultTTL: 3600,
+				MaxTTL:     86400,
+			},
+		},
+		Upload: config.UploadConfig{
+			Image: config.ImageConfig{
+				Variants:  []config.ImageVariantConfig{{Name: "thumbnail", Width: 200, Height: 200}},
+				Quality:   85,
+				MaxPixels: 40000000,
+			},
+		},
+	}
+	urlSigner, err := signer.New(cfg)
+	suite.Require().NoError(err)
+
 	// 创建文件服务
 	suite.service = service.NewFileService(
 		suite.fileRepo,
+		suite.blobRepo,
+		suite.quota,
+		suite.storage,
+		urlSigner,
+		cfg,
 		suite.cache,
 		suite.logger,
 	)
@@ -46,6 +98,11 @@ func (suite *FileServiceTestSuite) SetupSuite() {
 func (suite *FileServiceTestSuite) SetupTest() {
 	// 重置所有mock
 	suite.fileRepo.ExpectedCalls = nil
+	suite.fileRepo.Calls = nil
+	suite.blobRepo.ExpectedCalls = nil
+	suite.blobRepo.Calls = nil
+	suite.quota.ExpectedCalls = nil
+	suite.quota.Calls = nil
 	suite.cache.ExpectedCalls = nil
 	suite.logger.ExpectedCalls = nil
 }
@@ -53,16 +110,26 @@ func (suite *FileServiceTestSuite) SetupTest() {
 // TestUpload 测试上传文件
 func (suite *FileServiceTestSuite) TestUpload() {
 	req := &service.UploadRequest{
-		FileName:    "test.jpg",
+		FileName:    "test.txt",
 		FileSize:    1024,
 		MimeType:    "image/jpeg",
-		FileData:    []byte("fake image data"),
+		Reader:      strings.NewReader("fake image data"),
 		IsPublic:    true,
 		StorageType: model.StorageTypeLocal,
 	}
 
-	// Mock 检查文件是否已存在（不存在）
-	suite.fileRepo.On("GetByHash", suite.ctx, mock.AnythingOfType("string")).Return(nil, gorm.ErrRecordNotFound)
+	// Mock 按声明大小占用配额，写入后按实际大小释放多占用的部分
+	suite.quota.On("Reserve", suite.ctx, uint(0), int64(1024)).Return(nil)
+	suite.quota.On("Release", suite.ctx, uint(0), int64(1024-len("fake image data"))).Return(nil)
+
+	// Mock 内容尚未存储，以新写入的对象创建物理对象记录
+	acquire := suite.blobRepo.On("Acquire", suite.ctx, mock.AnythingOfType("*model.FileBlob"))
+	acquire.Run(func(args mock.Arguments) {
+		created := *args.Get(1).(*model.FileBlob)
+		created.ID = 1
+		created.RefCount = 1
+		acquire.Return(&created, nil)
+	})
 
 	// Mock 创建文件记录
 	suite.fileRepo.On("Create", suite.ctx, mock.AnythingOfType("*model.File")).Return(nil).Run(func(args mock.Arguments) {
@@ -80,35 +147,66 @@ func (suite *FileServiceTestSuite) TestUpload() {
 	require.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), file)
 	assert.Equal(suite.T(), req.FileName, file.OriginalName)
-	assert.Equal(suite.T(), req.FileSize, file.Size)
-	assert.Equal(suite.T(), req.MimeType, file.MimeType)
+	assert.Equal(suite.T(), int64(len("fake image data")), file.Size)
+	assert.Equal(suite.T(), "5b3397652358a6663a0225ee76466d4e4fd6c58d484d1aa25170bb617d6bb086", file.Hash)
+	assert.Equal(suite.T(), "text/plain; charset=utf-8", file.MimeType) // 按内容识别，不使用客户端声明的类型
 	assert.Equal(suite.T(), req.IsPublic, file.IsPublic)
 
 	// 验证mock调用
 	suite.fileRepo.AssertExpectations(suite.T())
+	suite.quota.AssertExpectations(suite.T())
 	suite.logger.AssertExpectations(suite.T())
 }
 
-// TestUploadExistingFile 测试上传已存在的文件
-func (suite *FileServiceTestSuite) TestUploadExistingFile() {
+// TestUploadQuotaExceeded 测试超出存储配额时拒绝上传
+func (suite *FileServiceTestSuite) TestUploadQuotaExceeded() {
+	suite.quota.On("Reserve", suite.ctx, uint(2), int64(15)).Return(service.ErrQuotaExceeded)
+
+	_, err := suite.service.Upload(suite.ctx, &service.UploadRequest{
+		FileName:    "test.txt",
+		FileSize:    15,
+		Reader:      strings.NewReader("fake image data"),
+		StorageType: model.StorageTypeLocal,
+		OwnerID:     2,
+	})
+
+	assert.ErrorIs(suite.T(), err, service.ErrQuotaExceeded)
+	suite.blobRepo.AssertNotCalled(suite.T(), "Acquire", mock.Anything, mock.Anything)
+	suite.quota.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestUploadSharesExistingBlob 测试相同内容共享物理对象但创建独立的文件记录
+func (suite *FileServiceTestSuite) TestUploadSharesExistingBlob() {
 	req := &service.UploadRequest{
-		FileName: "existing.jpg",
+		FileName: "existing.txt",
 		FileSize: 1024,
 		MimeType: "image/jpeg",
-		FileData: []byte("fake image data"),
+		Reader:   strings.NewReader("fake image data"),
+		OwnerID:  2,
 	}
 
-	existingFile := &model.File{
-		BaseModel:    model.BaseModel{ID: 1},
-		Name:         "existing-file.jpg",
-		OriginalName: "existing.jpg",
-		Size:         1024,
-		MimeType:     "image/jpeg",
-		Hash:         "existing-hash",
+	existingBlob := &model.FileBlob{
+		ID:          5,
+		StorageType: model.StorageTypeLocal,
+		Hash:        "5b3397652358a6663a0225ee76466d4e4fd6c58d484d1aa25170bb617d6bb086",
+		Path:        "shared-object.jpg",
+		RefCount:    2,
 	}
 
-	// Mock 检查文件已存在
-	suite.fileRepo.On("GetByHash", suite.ctx, mock.AnythingOfType("string")).Return(existingFile, nil)
+	suite.quota.On("Reserve", suite.ctx, req.OwnerID, mock.Anything).Return(nil)
+	suite.quota.On("Release", suite.ctx, req.OwnerID, mock.Anything).Return(nil)
+
+	// Mock 内容已由其他用户存储
+	suite.blobRepo.On("Acquire", suite.ctx, mock.MatchedBy(func(blob *model.FileBlob) bool {
+		return blob.Hash == existingBlob.Hash
+	})).Return(existingBlob, nil)
+
+	// Mock 为当前用户创建独立的文件记录
+	suite.fileRepo.On("Create", suite.ctx, mock.MatchedBy(func(file *model.File) bool {
+		return file.OwnerID == req.OwnerID && file.BlobID == existingBlob.ID && file.Path == existingBlob.Path
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.File).ID = 10
+	})
 
 	// Mock 日志
 	suite.logger.On("Info", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
@@ -116,14 +214,71 @@ func (suite *FileServiceTestSuite) TestUploadExistingFile() {
 	// 执行上传
 	file, err := suite.service.Upload(suite.ctx, req)
 
-	// 验证结果
+	// 验证结果：不会返回其他用户的文件
 	require.NoError(suite.T(), err)
-	assert.NotNil(suite.T(), file)
-	assert.Equal(suite.T(), existingFile.ID, file.ID)
+	assert.Equal(suite.T(), uint(10), file.ID)
+	assert.Equal(suite.T(), req.OwnerID, file.OwnerID)
+	assert.Equal(suite.T(), existingBlob.Path, file.Path)
 
 	// 验证mock调用
 	suite.fileRepo.AssertExpectations(suite.T())
-	suite.logger.AssertExpectations(suite.T())
+	suite.blobRepo.AssertExpectations(suite.T())
+}
+
+// TestUploadPolicy 测试按内容识别类型并执行上传策略
+func (suite *FileServiceTestSuite) TestUploadPolicy() {
+	cfg := &config.Config{
+		Upload: config.UploadConfig{
+			MaxSize:      100,
+			AllowedTypes: []string{"image/*", "text/plain"},
+			TypeLimits:   []config.UploadTypeLimit{{Type: "image/*", MaxSize: 50}},
+			RoleLimits:   map[string]int64{model.UserRoleUser: 20},
+		},
+	}
+	urlSigner, err := signer.New(&config.Config{JWT: config.JWTConfig{Secret: "test-secret"}})
+	require.NoError(suite.T(), err)
+	policyService := service.NewFileService(suite.fileRepo, suite.blobRepo, suite.quota, suite.storage, urlSigner, cfg, suite.cache, suite.logger)
+
+	var pngData bytes.Buffer
+	require.NoError(suite.T(), png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 16, 16))))
+	require.Greater(suite.T(), pngData.Len(), 50)
+
+	tests := []struct {
+		name     string
+		fileName string
+		size     int64
+		content  string
+		role     string
+		rule     string
+	}{
+		{"Extension Mismatch", "photo.png", -1, "plain text pretending to be an image", model.UserRoleAdmin, service.PolicyRuleExtensionMismatch},
+		{"Type Not Allowed", "doc.pdf", -1, "%PDF-1.4 document", model.UserRoleAdmin, service.PolicyRuleTypeNotAllowed},
+		{"Type Size", "icon.png", -1, pngData.String(), model.UserRoleAdmin, service.PolicyRuleTypeMaxSize},
+		{"Role Size", "notes.txt", 30, strings.Repeat("a", 30), model.UserRoleUser, service.PolicyRuleRoleMaxSize},
+		{"Max Size While Streaming", "notes.txt", -1, strings.Repeat("a", 150), model.UserRoleAdmin, service.PolicyRuleMaxSize},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, err := policyService.Upload(suite.ctx, &service.UploadRequest{
+				FileName:    tt.fileName,
+				FileSize:    tt.size,
+				MimeType:    "image/png",
+				Reader:      strings.NewReader(tt.content),
+				StorageType: model.StorageTypeLocal,
+				OwnerID:     1,
+				OwnerRole:   tt.role,
+			})
+
+			var policyErr *service.PolicyError
+			require.ErrorAs(suite.T(), err, &policyErr)
+			assert.Equal(suite.T(), tt.rule, policyErr.Rule)
+		})
+	}
+
+	// 违反策略时不会创建物理对象和文件记录
+	suite.blobRepo.AssertNotCalled(suite.T(), "Acquire", mock.Anything, mock.Anything)
+	suite.fileRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
 }
 
 // TestGetByID 测试根据ID获取文件
@@ -208,37 +363,214 @@ func (suite *FileServiceTestSuite) TestDownload() {
 	suite.logger.AssertExpectations(suite.T())
 }
 
-// TestDelete 测试删除文件
+// TestDownloadLegacyPath 测试下载早期版本记录为 "uploads/<name>" 的文件
+func (suite *FileServiceTestSuite) TestDownloadLegacyPath() {
+	suite.Require().NoError(os.WriteFile(filepath.Join(suite.root, "1700000000_abcdefgh.txt"), []byte("legacy file"), 0644))
+	file := &model.File{
+		BaseModel:    model.BaseModel{ID: 9},
+		Name:         "1700000000_abcdefgh.txt",
+		OriginalName: "legacy.txt",
+		Path:         "uploads/1700000000_abcdefgh.txt",
+		URL:          "/uploads/1700000000_abcdefgh.txt",
+		StorageType:  model.StorageTypeLocal,
+		Size:         11,
+		MimeType:     "text/plain",
+	}
+	suite.fileRepo.On("GetByID", suite.ctx, file.ID).Return(file, nil)
+
+	response, err := suite.service.Download(suite.ctx, file.ID)
+	suite.Require().NoError(err)
+	data, err := io.ReadAll(response.Content)
+	response.Content.Close()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "legacy file", string(data))
+}
+
+// TestRecordDownload 测试记录下载次数
+func (suite *FileServiceTestSuite) TestRecordDownload() {
+	suite.fileRepo.On("IncrementDownloadCount", suite.ctx, uint(1)).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.RecordDownload(suite.ctx, 1))
+	suite.fileRepo.AssertExpectations(suite.T())
+}
+
+// TestCreateSignedURL 测试生成并验证签名下载链接
+func (suite *FileServiceTestSuite) TestCreateSignedURL() {
+	fileID := uint(1)
+	file := &model.File{BaseModel: model.BaseModel{ID: fileID}, Name: "private.pdf"}
+
+	suite.fileRepo.On("GetByID", suite.ctx, fileID).Return(file, nil)
+	suite.logger.On("Info", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	response, err := suite.service.CreateSignedURL(suite.ctx, fileID, 10*time.Minute)
+	require.NoError(suite.T(), err)
+	assert.WithinDuration(suite.T(), time.Now().Add(10*time.Minute), response.ExpiresAt, time.Second)
+
+	signedURL, err := url.Parse(response.URL)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "/api/v1/files/1/signed", signedURL.Path)
+	assert.Equal(suite.T(), "k1", signedURL.Query().Get("kid"))
+
+	// 签名有效
+	assert.NoError(suite.T(), suite.service.VerifySignedURL(suite.ctx, fileID, signedURL.Query()))
+
+	// 签名不能用于其他文件
+	assert.ErrorIs(suite.T(), suite.service.VerifySignedURL(suite.ctx, 2, signedURL.Query()), signer.ErrInvalidSignature)
+
+	// 篡改过期时间
+	tampered := signedURL.Query()
+	tampered.Set("expires", strconv.FormatInt(time.Now().Add(48*time.Hour).Unix(), 10))
+	assert.ErrorIs(suite.T(), suite.service.VerifySignedURL(suite.ctx, fileID, tampered), signer.ErrInvalidSignature)
+
+	// 超过最长有效期
+	_, err = suite.service.CreateSignedURL(suite.ctx, fileID, 48*time.Hour)
+	assert.Error(suite.T(), err)
+}
+
+// TestDelete 测试删除仍被引用的文件时保留物理对象
 func (suite *FileServiceTestSuite) TestDelete() {
 	fileID := uint(1)
 	file := &model.File{
 		BaseModel:   model.BaseModel{ID: fileID},
 		Name:        "test-file.jpg",
-		Path:        "/uploads/test-file.jpg",
+		Path:        "shared/test-file.jpg",
+		BlobID:      3,
 		StorageType: model.StorageTypeLocal,
 	}
 
+	driver, err := suite.storage.Get(model.StorageTypeLocal)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), driver.Put(suite.ctx, file.Path, strings.NewReader("data"), 4, "image/jpeg"))
+
 	// Mock 获取文件
 	suite.fileRepo.On("GetByID", suite.ctx, fileID).Return(file, nil)
 
 	// Mock 删除文件记录
 	suite.fileRepo.On("Delete", suite.ctx, fileID).Return(nil)
 
+	// Mock 其他文件仍引用该物理对象
+	suite.blobRepo.On("Release", suite.ctx, file.BlobID).Return(&model.FileBlob{ID: 3, Path: file.Path, RefCount: 1}, false, nil)
+
 	// Mock 日志
 	suite.logger.On("Info", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return()
-	suite.logger.On("Warn", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).Return()
 
 	// 执行删除
-	err := suite.service.Delete(suite.ctx, fileID)
+	err = suite.service.Delete(suite.ctx, fileID)
 
 	// 验证结果
 	require.NoError(suite.T(), err)
+	_, err = driver.Stat(suite.ctx, file.Path)
+	assert.NoError(suite.T(), err)
 
 	// 验证mock调用
 	suite.fileRepo.AssertExpectations(suite.T())
+	suite.blobRepo.AssertExpectations(suite.T())
+	suite.logger.AssertExpectations(suite.T())
+}
+
+// TestDeleteLastReference 测试删除最后一个引用时删除物理对象
+func (suite *FileServiceTestSuite) TestDeleteLastReference() {
+	fileID := uint(1)
+	file := &model.File{
+		BaseModel:   model.BaseModel{ID: fileID},
+		Path:        "shared/last.jpg",
+		Size:        4,
+		BlobID:      3,
+		StorageType: model.StorageTypeLocal,
+		OwnerID:     2,
+	}
+
+	driver, err := suite.storage.Get(model.StorageTypeLocal)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), driver.Put(suite.ctx, file.Path, strings.NewReader("data"), 4, "image/jpeg"))
+
+	suite.fileRepo.On("GetByID", suite.ctx, fileID).Return(file, nil)
+	suite.fileRepo.On("Delete", suite.ctx, fileID).Return(nil)
+	suite.blobRepo.On("Release", suite.ctx, file.BlobID).Return(&model.FileBlob{ID: 3, Path: file.Path}, true, nil)
+	suite.quota.On("Release", suite.ctx, file.OwnerID, file.Size).Return(nil)
+	suite.logger.On("Info", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return()
+
+	require.NoError(suite.T(), suite.service.Delete(suite.ctx, fileID))
+
+	_, err = driver.Stat(suite.ctx, file.Path)
+	assert.ErrorIs(suite.T(), err, storage.ErrObjectNotFound)
+	suite.quota.AssertExpectations(suite.T())
+}
+
+// TestGetVariant 测试首次访问时生成图片变体并缓存到存储
+func (suite *FileServiceTestSuite) TestGetVariant() {
+	fileID := uint(1)
+	file := &model.File{
+		BaseModel:   model.BaseModel{ID: fileID},
+		Path:        "images/photo.png",
+		MimeType:    "image/png",
+		Hash:        "photohash",
+		StorageType: model.StorageTypeLocal,
+	}
+
+	var buf bytes.Buffer
+	src := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 100; y++ {
+			src.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	require.NoError(suite.T(), png.Encode(&buf, src))
+
+	driver, err := suite.storage.Get(model.StorageTypeLocal)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), driver.Put(suite.ctx, file.Path, bytes.NewReader(buf.Bytes()), int64(buf.Len()), "image/png"))
+
+	suite.fileRepo.On("GetByID", suite.ctx, fileID).Return(file, nil)
+	suite.logger.On("Info", "Image variant generated", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Once()
+
+	// 首次访问时生成变体
+	response, err := suite.service.GetVariant(suite.ctx, fileID, "thumbnail")
+	require.NoError(suite.T(), err)
+	data, err := io.ReadAll(response.Content)
+	response.Content.Close()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "image/png", response.MimeType)
+
+	variant, err := png.Decode(bytes.NewReader(data))
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 200, variant.Bounds().Dx())
+	assert.Equal(suite.T(), 50, variant.Bounds().Dy())
+
+	// 再次访问时直接读取已保存的变体
+	response, err = suite.service.GetVariant(suite.ctx, fileID, "thumbnail")
+	require.NoError(suite.T(), err)
+	cached, err := io.ReadAll(response.Content)
+	response.Content.Close()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), data, cached)
+
+	// 最后一个引用删除时同时删除变体
+	file.BlobID = 3
+	suite.fileRepo.On("Delete", suite.ctx, fileID).Return(nil)
+	suite.blobRepo.On("Release", suite.ctx, file.BlobID).Return(&model.FileBlob{ID: 3, Path: file.Path}, true, nil)
+	suite.logger.On("Info", "File deleted successfully", mock.Anything, mock.Anything).Return()
+	require.NoError(suite.T(), suite.service.Delete(suite.ctx, fileID))
+
+	_, err = driver.Stat(suite.ctx, "variants/photohash/thumbnail_200x200.png")
+	assert.ErrorIs(suite.T(), err, storage.ErrObjectNotFound)
 	suite.logger.AssertExpectations(suite.T())
 }
 
+// TestGetVariantErrors 测试未配置的变体和非图片文件
+func (suite *FileServiceTestSuite) TestGetVariantErrors() {
+	_, err := suite.service.GetVariant(suite.ctx, 1, "unknown")
+	assert.ErrorIs(suite.T(), err, service.ErrVariantNotFound)
+
+	suite.fileRepo.On("GetByID", suite.ctx, uint(2)).Return(&model.File{
+		BaseModel: model.BaseModel{ID: 2},
+		Path:      "docs/readme.txt",
+		MimeType:  "text/plain",
+	}, nil)
+	_, err = suite.service.GetVariant(suite.ctx, 2, "thumbnail")
+	assert.ErrorIs(suite.T(), err, service.ErrNotImage)
+}
+
 // TestList 测试获取文件列表
 func (suite *FileServiceTestSuite) TestList() {
 	files := []*model.File{
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/secretbox"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// InvitationServiceTestSuite 注册邀请服务测试套件
+type InvitationServiceTestSuite struct {
+	suite.Suite
+	db          *testutil.TestDatabase
+	cache       *testutil.TestCache
+	logger      *testutil.TestLogger
+	mailer      *mocks.MockMailer
+	config      *config.Config
+	service     service.InvitationService
+	roles       service.RoleService
+	userService service.UserService
+	ctx         context.Context
+	admin       *model.User
+	sent        []*mailer.Message
+}
+
+// SetupSuite 设置测试套件
+func (suite *InvitationServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+	testCache := suite.cache.CreateTestCache()
+
+	suite.config = &config.Config{
+		JWT:   config.JWTConfig{Secret: testutil.TestJWTSecret},
+		Email: config.EmailConfig{LinkBaseURL: "https://app.example.com/"},
+	}
+	audit := new(mocks.MockAuditService)
+	audit.On("Record", mock.Anything, mock.Anything).Maybe().Return()
+	suite.mailer = new(mocks.MockMailer)
+
+	userRepo := repository.NewUserRepository(database, testLogger)
+	suite.roles = service.NewRoleService(
+		repository.NewRoleRepository(database, testLogger),
+		userRepo,
+		&fakePermissionCache{},
+		audit,
+		testLogger,
+	)
+	suite.service = service.NewInvitationService(
+		repository.NewInvitationRepository(database, testLogger),
+		userRepo,
+		suite.roles,
+		audit,
+		suite.mailer,
+		suite.config,
+		testLogger,
+	)
+
+	secrets, err := secretbox.New(suite.config)
+	suite.Require().NoError(err)
+	providers, err := oidc.NewRegistry(nil, nil)
+	suite.Require().NoError(err)
+	suite.userService = service.NewUserService(
+		userRepo,
+		repository.NewMFARepository(database, testLogger),
+		repository.NewUserIdentityRepository(database, testLogger),
+		new(mocks.MockTokenService),
+		new(mocks.MockEmailVerificationService),
+		suite.service,
+		secrets,
+		providers,
+		testutil.NewTestPasswordPolicy(suite.T()),
+		testCache,
+		testLogger,
+		suite.config,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *InvitationServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *InvitationServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+	suite.config.Auth.RegistrationMode = service.RegistrationModeInviteOnly
+	suite.config.Auth.InvitationExpiration = 3600
+
+	suite.sent = nil
+	suite.mailer.ExpectedCalls = nil
+	suite.mailer.Calls = nil
+	suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		suite.sent = append(suite.sent, args.Get(1).(*mailer.Message))
+	}).Return(nil)
+
+	suite.admin = &model.User{Username: "admin", Email: "admin@example.com", Password: "password123", Role: model.UserRoleAdmin}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.admin).Error)
+}
+
+// invite 创建邀请并返回链接中的令牌
+func (suite *InvitationServiceTestSuite) invite(email, role string) (*model.Invitation, string) {
+	created, err := suite.service.Create(suite.ctx, suite.admin.ID, &service.CreateInvitationRequest{Email: email, Role: role})
+	suite.Require().NoError(err)
+
+	link, err := url.Parse(created.Link)
+	suite.Require().NoError(err)
+	return created.Invitation, link.Query().Get("invitation")
+}
+
+// TestCreate 测试创建邀请并发送邮件
+func (suite *InvitationServiceTestSuite) TestCreate() {
+	invitation, plain := suite.invite("editor@example.com", model.UserRoleAdmin)
+	suite.NotEmpty(plain)
+	suite.Equal(model.InvitationStatusPending, invitation.Status)
+	suite.Equal(suite.admin.ID, *invitation.InvitedBy)
+	suite.WithinDuration(time.Now().Add(time.Hour), invitation.ExpiresAt, time.Minute)
+
+	suite.Require().Len(suite.sent, 1)
+	suite.Equal([]string{"editor@example.com"}, suite.sent[0].To)
+	suite.Contains(suite.sent[0].Text, "https://app.example.com/register?invitation="+plain)
+
+	// 链接令牌可以查询到受邀邮箱和角色，用于预填注册表单
+	found, err := suite.service.Lookup(suite.ctx, plain)
+	suite.Require().NoError(err)
+	suite.Equal("editor@example.com", found.Email)
+	suite.Equal(model.UserRoleAdmin, found.Role)
+}
+
+// TestCreateInvalid 测试创建邀请的参数检查
+func (suite *InvitationServiceTestSuite) TestCreateInvalid() {
+	past := time.Now().Add(-time.Minute)
+	_, err := suite.service.Create(suite.ctx, suite.admin.ID, &service.CreateInvitationRequest{Email: "not-an-email"})
+	suite.ErrorIs(err, service.ErrInvalidUserInput)
+	_, err = suite.service.Create(suite.ctx, suite.admin.ID, &service.CreateInvitationRequest{Email: "new@example.com", Role: "owner"})
+	suite.ErrorIs(err, service.ErrInvalidUserInput)
+	_, err = suite.service.Create(suite.ctx, suite.admin.ID, &service.CreateInvitationRequest{Email: "new@example.com", ExpiresAt: &past})
+	suite.ErrorIs(err, service.ErrInvalidUserInput)
+	_, err = suite.service.Create(suite.ctx, suite.admin.ID, &service.CreateInvitationRequest{Email: suite.admin.Email})
+	suite.ErrorIs(err, service.ErrUserExists)
+	suite.Empty(suite.sent)
+}
+
+// TestInviteAdminRequiresAllPermissions 测试没有全部权限的操作者不能邀请管理员
+func (suite *InvitationServiceTestSuite) TestInviteAdminRequiresAllPermissions() {
+	_, err := suite.roles.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{Name: "inviter", Permissions: []string{"invitation:create"}})
+	suite.Require().NoError(err)
+	inviter := &model.User{Username: "inviter", Email: "inviter@example.com", Password: "password123", Role: model.UserRoleUser}
+	suite.Require().NoError(suite.db.GetDB().Create(inviter).Error)
+	_, err = suite.roles.SetUserRoles(suite.ctx, suite.admin.ID, inviter.ID, &service.SetUserRolesRequest{Roles: []string{"inviter"}})
+	suite.Require().NoError(err)
+
+	_, err = suite.service.Create(suite.ctx, inviter.ID, &service.CreateInvitationRequest{Email: "boss@example.com", Role: model.UserRoleAdmin})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+	suite.Empty(suite.sent)
+
+	created, err := suite.service.Create(suite.ctx, inviter.ID, &service.CreateInvitationRequest{Email: "member@example.com"})
+	suite.Require().NoError(err)
+	suite.Equal(model.UserRoleUser, created.Invitation.Role)
+}
+
+// TestReinviteRevokesPrevious 测试重新邀请同一邮箱时旧链接失效
+func (suite *InvitationServiceTestSuite) TestReinviteRevokesPrevious() {
+	first, oldToken := suite.invite("new@example.com", "")
+	_, newToken := suite.invite("new@example.com", "")
+
+	_, err := suite.service.Lookup(suite.ctx, oldToken)
+	suite.ErrorIs(err, service.ErrInvalidInvitation)
+	_, err = suite.service.Lookup(suite.ctx, newToken)
+	suite.NoError(err)
+
+	revoked, total, err := suite.service.List(suite.ctx, repository.ListOptions{
+		Page: 1, PageSize: 10, Filters: map[string]interface{}{"status": model.InvitationStatusRevoked},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), total)
+	suite.Equal(first.ID, revoked[0].ID)
+	suite.Equal(model.InvitationStatusRevoked, revoked[0].Status)
+}
+
+// TestRevoke 测试撤销邀请
+func (suite *InvitationServiceTestSuite) TestRevoke() {
+	invitation, plain := suite.invite("new@example.com", "")
+
+	suite.Require().NoError(suite.service.Revoke(suite.ctx, suite.admin.ID, invitation.ID))
+	_, err := suite.service.Lookup(suite.ctx, plain)
+	suite.ErrorIs(err, service.ErrInvalidInvitation)
+
+	suite.ErrorIs(suite.service.Revoke(suite.ctx, suite.admin.ID, invitation.ID), service.ErrInvitationNotPending)
+	suite.ErrorIs(suite.service.Revoke(suite.ctx, suite.admin.ID, 9999), service.ErrInvitationNotFound)
+}
+
+// TestRegisterWithInvitation 测试通过邀请注册获得指定角色且邀请只能使用一次
+func (suite *InvitationServiceTestSuite) TestRegisterWithInvitation() {
+	invitation, plain := suite.invite("Editor@Example.com", model.UserRoleAdmin)
+
+	user, err := suite.userService.Register(suite.ctx, &service.RegisterRequest{
+		Username:        "editor",
+		Email:           "editor@example.com",
+		Password:        "password123",
+		InvitationToken: plain,
+	})
+	suite.Require().NoError(err)
+	suite.Equal(model.UserRoleAdmin, user.Role)
+	suite.True(user.IsActive())
+	suite.NotNil(user.EmailVerifiedAt)
+
+	accepted, _, err := suite.service.List(suite.ctx, repository.ListOptions{
+		Filters: map[string]interface{}{"status": model.InvitationStatusAccepted},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(accepted, 1)
+	suite.Equal(invitation.ID, accepted[0].ID)
+	suite.Equal(user.ID, *accepted[0].AcceptedBy)
+
+	// 已使用的邀请不能再次注册，也不能撤销
+	_, err = suite.userService.Register(suite.ctx, &service.RegisterRequest{
+		Username:        "editor2",
+		Email:           "editor@example.com",
+		Password:        "password123",
+		InvitationToken: plain,
+	})
+	suite.ErrorIs(err, service.ErrInvalidInvitation)
+	suite.ErrorIs(suite.service.Revoke(suite.ctx, suite.admin.ID, invitation.ID), service.ErrInvitationNotPending)
+}
+
+// TestRegisterInvitationChecks 测试邀请注册的各种失败情况
+func (suite *InvitationServiceTestSuite) TestRegisterInvitationChecks() {
+	_, plain := suite.invite("new@example.com", "")
+
+	_, err := suite.userService.Register(suite.ctx, &service.RegisterRequest{
+		Username: "newuser", Email: "new@example.com", Password: "password123",
+	})
+	suite.ErrorIs(err, service.ErrInvitationRequired)
+
+	_, err = suite.userService.Register(suite.ctx, &service.RegisterRequest{
+		Username: "newuser", Email: "other@example.com", Password: "password123", InvitationToken: plain,
+	})
+	suite.ErrorIs(err, service.ErrInvitationEmailMismatch)
+
+	_, err = suite.userService.Register(suite.ctx, &service.RegisterRequest{
+		Username: "newuser", Email: "new@example.com", Password: "password123", InvitationToken: strings.Repeat("x", 43),
+	})
+	suite.ErrorIs(err, service.ErrInvalidInvitation)
+
+	// 用户名冲突时邀请不会被占用
+	_, err = suite.userService.Register(suite.ctx, &service.RegisterRequest{
+		Username: suite.admin.Username, Email: "new@example.com", Password: "password123", InvitationToken: plain,
+	})
+	suite.Error(err)
+	_, err = suite.service.Lookup(suite.ctx, plain)
+	suite.NoError(err)
+
+	suite.config.Auth.RegistrationMode = service.RegistrationModeClosed
+	_, err = suite.userService.Register(suite.ctx, &service.RegisterRequest{
+		Username: "newuser", Email: "new@example.com", Password: "password123", InvitationToken: plain,
+	})
+	suite.ErrorIs(err, service.ErrRegistrationClosed)
+
+	// 无法识别的注册方式按关闭注册处理，不会退回开放注册
+	for _, mode := range []string{"invite-only", "Closed", "Open"} {
+		suite.config.Auth.RegistrationMode = mode
+		_, err = suite.userService.Register(suite.ctx, &service.RegisterRequest{
+			Username: "newuser", Email: "new@example.com", Password: "password123",
+		})
+		suite.ErrorIs(err, service.ErrRegistrationClosed, mode)
+	}
+}
+
+// TestExpiredInvitation 测试过期的邀请不能使用
+func (suite *InvitationServiceTestSuite) TestExpiredInvitation() {
+	invitation, plain := suite.invite("new@example.com", "")
+	suite.Require().NoError(suite.db.GetDB().Model(&model.Invitation{}).
+		Where("id = ?", invitation.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	_, err := suite.service.Lookup(suite.ctx, plain)
+	suite.ErrorIs(err, service.ErrInvalidInvitation)
+
+	expired, total, err := suite.service.List(suite.ctx, repository.ListOptions{
+		Filters: map[string]interface{}{"status": model.InvitationStatusExpired},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), total)
+	suite.Equal(model.InvitationStatusExpired, expired[0].Status)
+}
+
+// TestInvitationServiceSuite 运行注册邀请服务测试套件
+func TestInvitationServiceSuite(t *testing.T) {
+	suite.Run(t, new(InvitationServiceTestSuite))
+}
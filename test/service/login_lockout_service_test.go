@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/secretbox"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// LoginLockoutTestSuite 登录失败锁定测试套件
+type LoginLockoutTestSuite struct {
+	suite.Suite
+	db      *testutil.TestDatabase
+	cache   *testutil.TestCache
+	store   cache.Cache
+	logger  *testutil.TestLogger
+	service service.UserService
+	ctx     context.Context
+	user    *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *LoginLockoutTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:            "test-secret-key",
+			Issuer:            "test-issuer",
+			Expiration:        900,
+			RefreshExpiration: 86400,
+		},
+		Auth: config.AuthConfig{
+			LoginMaxAttempts:        3,
+			LoginLockoutDuration:    60,
+			LoginLockoutMaxDuration: 180,
+		},
+	}
+
+	tokens, err := token.New(cfg)
+	suite.Require().NoError(err)
+	secrets, err := secretbox.New(cfg)
+	suite.Require().NoError(err)
+	providers, err := oidc.New(cfg)
+	suite.Require().NoError(err)
+
+	suite.store = suite.cache.CreateTestCache()
+	userRepo := repository.NewUserRepository(database, testLogger)
+	tokenService := service.NewTokenService(
+		repository.NewRefreshTokenRepository(database, testLogger),
+		repository.NewUserSessionRepository(database, testLogger),
+		userRepo,
+		tokens,
+		suite.store,
+		testLogger,
+	)
+	suite.service = service.NewUserService(
+		userRepo,
+		repository.NewMFARepository(database, testLogger),
+		repository.NewUserIdentityRepository(database, testLogger),
+		tokenService,
+		new(mocks.MockEmailVerificationService),
+		new(mocks.MockInvitationService),
+		secrets,
+		providers,
+		testutil.NewTestPasswordPolicy(suite.T()),
+		suite.store,
+		testLogger,
+		cfg,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *LoginLockoutTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *LoginLockoutTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	// 内存缓存不会在测试之间清空，每个测试使用不同的用户名
+	suite.user = &model.User{
+		Username: "lockout_" + strings.ToLower(strings.TrimPrefix(suite.T().Name(), "TestLoginLockoutSuite/Test")),
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.user.Email = suite.user.Username + "@example.com"
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+}
+
+// login 使用指定密码登录
+func (suite *LoginLockoutTestSuite) login(username, password string) error {
+	_, err := suite.service.Login(suite.ctx, &service.LoginRequest{Username: username, Password: password})
+	return err
+}
+
+// waitBackoff 模拟等待退避或锁定结束
+func (suite *LoginLockoutTestSuite) waitBackoff(username string) {
+	suite.Require().NoError(suite.store.Del(suite.ctx, "login_lock:"+token.Hash(strings.ToLower(username))))
+}
+
+// lockedFor 断言登录被锁定并返回剩余等待时间
+func (suite *LoginLockoutTestSuite) lockedFor(err error) time.Duration {
+	var locked *service.LoginLockedError
+	suite.Require().True(errors.As(err, &locked), "expected login to be locked, got %v", err)
+	suite.ErrorIs(err, service.ErrLoginLocked)
+	return locked.RetryAfter
+}
+
+// TestBackoff 测试达到阈值前的退避
+func (suite *LoginLockoutTestSuite) TestBackoff() {
+	// 第一次失败不需要等待
+	err := suite.login(suite.user.Username, "wrong")
+	suite.Require().Error(err)
+	suite.NotErrorIs(err, service.ErrLoginLocked)
+
+	err = suite.login(suite.user.Username, "wrong")
+	suite.Require().Error(err)
+	suite.NotErrorIs(err, service.ErrLoginLocked)
+
+	// 退避期间即使密码正确也拒绝
+	retryAfter := suite.lockedFor(suite.login(suite.user.Username, "password123"))
+	suite.LessOrEqual(retryAfter, time.Second)
+
+	suite.waitBackoff(suite.user.Username)
+	suite.NoError(suite.login(suite.user.Username, "password123"))
+}
+
+// TestProgressiveLockout 测试连续失败后锁定且锁定时长逐次翻倍
+func (suite *LoginLockoutTestSuite) TestProgressiveLockout() {
+	for i := 0; i < 2; i++ {
+		suite.Require().NotErrorIs(suite.login(suite.user.Username, "wrong"), service.ErrLoginLocked)
+		suite.waitBackoff(suite.user.Username)
+	}
+
+	// 第三次失败触发锁定
+	suite.Equal(60*time.Second, suite.lockedFor(suite.login(suite.user.Username, "wrong")))
+	retryAfter := suite.lockedFor(suite.login(suite.user.Username, "password123"))
+	suite.Greater(retryAfter, 50*time.Second)
+
+	// 锁定结束后再次失败，锁定时长翻倍并受最长时长限制
+	suite.waitBackoff(suite.user.Username)
+	suite.Equal(120*time.Second, suite.lockedFor(suite.login(suite.user.Username, "wrong")))
+	suite.waitBackoff(suite.user.Username)
+	suite.Equal(180*time.Second, suite.lockedFor(suite.login(suite.user.Username, "wrong")))
+
+	// 管理员解除锁定后可以立即登录，失败次数重新计算
+	suite.Require().NoError(suite.service.UnlockUser(suite.ctx, suite.user.ID))
+	suite.NoError(suite.login(suite.user.Username, "password123"))
+}
+
+// TestSuccessResetsFailures 测试登录成功后清空失败次数
+func (suite *LoginLockoutTestSuite) TestSuccessResetsFailures() {
+	for i := 0; i < 2; i++ {
+		suite.Require().Error(suite.login(suite.user.Username, "wrong"))
+		suite.waitBackoff(suite.user.Username)
+	}
+	suite.Require().NoError(suite.login(suite.user.Username, "password123"))
+
+	// 计数已清零，再次失败不会锁定
+	err := suite.login(suite.user.Username, "wrong")
+	suite.Require().Error(err)
+	suite.NotErrorIs(err, service.ErrLoginLocked)
+}
+
+// TestUnknownUsername 测试未注册的用户名同样被锁定，避免探测账户是否存在
+func (suite *LoginLockoutTestSuite) TestUnknownUsername() {
+	username := suite.user.Username + "_missing"
+	for i := 0; i < 2; i++ {
+		suite.Require().NotErrorIs(suite.login(username, "wrong"), service.ErrLoginLocked)
+		suite.waitBackoff(username)
+	}
+	suite.Equal(60*time.Second, suite.lockedFor(suite.login(username, "wrong")))
+
+	// 大小写不同的用户名共享计数
+	suite.lockedFor(suite.login(strings.ToUpper(username), "wrong"))
+}
+
+// TestUnlockUnknownUser 测试解除不存在用户的锁定
+func (suite *LoginLockoutTestSuite) TestUnlockUnknownUser() {
+	suite.ErrorIs(suite.service.UnlockUser(suite.ctx, 99999), service.ErrUserNotFound)
+}
+
+// TestConcurrentFailures 测试并发的失败计数不会丢失
+func (suite *LoginLockoutTestSuite) TestConcurrentFailures() {
+	key := "login_failures:" + token.Hash(strings.ToLower(suite.user.Username))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := suite.store.Incr(suite.ctx, key, time.Minute)
+			suite.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	value, err := suite.store.Get(suite.ctx, key)
+	suite.Require().NoError(err)
+	suite.Equal("20", value)
+
+	ttl, err := suite.store.TTL(suite.ctx, key)
+	suite.Require().NoError(err)
+	suite.Greater(ttl, time.Duration(0))
+}
+
+// TestLoginLockoutSuite 运行登录失败锁定测试套件
+func TestLoginLockoutSuite(t *testing.T) {
+	suite.Run(t, new(LoginLockoutTestSuite))
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/secretbox"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/pkg/totp"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// MFAServiceTestSuite 两步验证测试套件
+type MFAServiceTestSuite struct {
+	suite.Suite
+	db      *testutil.TestDatabase
+	cache   *testutil.TestCache
+	store   cache.Cache
+	logger  *testutil.TestLogger
+	tokens  *token.Manager
+	service service.UserService
+	ctx     context.Context
+	user    *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *MFAServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:            "test-secret-key",
+			Issuer:            "test-issuer",
+			Expiration:        900,
+			RefreshExpiration: 86400,
+		},
+		Auth: config.AuthConfig{
+			MFAIssuer:            "Vibe Test",
+			MFAPendingExpiration: 300,
+		},
+	}
+
+	var err error
+	suite.tokens, err = token.New(cfg)
+	suite.Require().NoError(err)
+	secrets, err := secretbox.New(cfg)
+	suite.Require().NoError(err)
+	providers, err := oidc.New(cfg)
+	suite.Require().NoError(err)
+
+	suite.store = suite.cache.CreateTestCache()
+	userRepo := repository.NewUserRepository(database, testLogger)
+	tokenService := service.NewTokenService(
+		repository.NewRefreshTokenRepository(database, testLogger),
+		repository.NewUserSessionRepository(database, testLogger),
+		userRepo,
+		suite.tokens,
+		suite.store,
+		testLogger,
+	)
+	suite.service = service.NewUserService(
+		userRepo,
+		repository.NewMFARepository(database, testLogger),
+		repository.NewUserIdentityRepository(database, testLogger),
+		tokenService,
+		new(mocks.MockEmailVerificationService),
+		new(mocks.MockInvitationService),
+		secrets,
+		providers,
+		testutil.NewTestPasswordPolicy(suite.T()),
+		suite.store,
+		testLogger,
+		cfg,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *MFAServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *MFAServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	suite.user = &model.User{
+		Username: "mfa-user",
+		Email:    "mfa-user@example.com",
+		Password: "password123",
+		Role:     model.UserRoleAdmin,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+}
+
+// enable 绑定并开启两步验证，返回密钥和恢复码
+func (suite *MFAServiceTestSuite) enable() (string, []string) {
+	enrollment, err := suite.service.EnrollMFA(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	suite.Require().NoError(err)
+	codes, err := suite.service.ConfirmMFA(suite.ctx, suite.user.ID, "", &service.MFACodeRequest{Code: code})
+	suite.Require().NoError(err)
+	return enrollment.Secret, codes.RecoveryCodes
+}
+
+// login 使用密码登录
+func (suite *MFAServiceTestSuite) login() *service.LoginResponse {
+	response, err := suite.service.Login(suite.ctx, &service.LoginRequest{
+		Username: suite.user.Username,
+		Password: "password123",
+	})
+	suite.Require().NoError(err)
+	return response
+}
+
+// TestEnrollAndConfirm 测试绑定身份验证器并开启两步验证
+func (suite *MFAServiceTestSuite) TestEnrollAndConfirm() {
+	enrollment, err := suite.service.EnrollMFA(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Contains(enrollment.URI, "otpauth://totp/")
+	suite.Contains(enrollment.URI, "secret="+enrollment.Secret)
+	suite.Contains(enrollment.URI, "issuer=Vibe")
+
+	// 数据库中只保存加密后的密钥
+	var stored model.UserMFA
+	suite.Require().NoError(suite.db.GetDB().Where("user_id = ?", suite.user.ID).First(&stored).Error)
+	suite.NotContains(stored.Secret, enrollment.Secret)
+	suite.False(stored.IsConfirmed())
+
+	// 错误的验证码不能开启
+	_, err = suite.service.ConfirmMFA(suite.ctx, suite.user.ID, "", &service.MFACodeRequest{Code: "000000"})
+	suite.ErrorIs(err, service.ErrInvalidMFACode)
+
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	suite.Require().NoError(err)
+	codes, err := suite.service.ConfirmMFA(suite.ctx, suite.user.ID, "", &service.MFACodeRequest{Code: code})
+	suite.Require().NoError(err)
+	suite.Len(codes.RecoveryCodes, 10)
+
+	status, err := suite.service.GetMFAStatus(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.True(status.Enabled)
+	suite.Equal(int64(10), status.RecoveryCodesRemaining)
+
+	_, err = suite.service.EnrollMFA(suite.ctx, suite.user.ID)
+	suite.ErrorIs(err, service.ErrMFAAlreadyEnabled)
+}
+
+// TestLoginRequiresCode 测试开启后登录需要提交验证码
+func (suite *MFAServiceTestSuite) TestLoginRequiresCode() {
+	secret, _ := suite.enable()
+
+	pending := suite.login()
+	suite.True(pending.MFARequired)
+	suite.NotEmpty(pending.MFAToken)
+	suite.Empty(pending.Token)
+
+	// 开启时使用过的验证码不能再次使用
+	used, err := totp.Code(secret, time.Now())
+	suite.Require().NoError(err)
+	_, err = suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: used})
+	suite.ErrorIs(err, service.ErrInvalidMFACode)
+
+	next, err := totp.Code(secret, time.Now().Add(totp.Period))
+	suite.Require().NoError(err)
+	response, err := suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: next})
+	suite.Require().NoError(err)
+	suite.NotEmpty(response.Token)
+	suite.NotEmpty(response.RefreshToken)
+	suite.True(response.User.MFAEnabled)
+
+	claims, err := suite.tokens.Parse(response.Token)
+	suite.Require().NoError(err)
+	suite.True(claims.MFA)
+
+	// 登录第二步令牌只能使用一次
+	_, err = suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: next})
+	suite.ErrorIs(err, service.ErrInvalidMFAToken)
+}
+
+// TestEnableRevokesOtherSessions 测试开启两步验证后撤销其他会话，且之前登录的会话刷新后不会获得 mfa 声明
+func (suite *MFAServiceTestSuite) TestEnableRevokesOtherSessions() {
+	current := suite.login()
+	other := suite.login()
+	claims, err := suite.tokens.Parse(current.Token)
+	suite.Require().NoError(err)
+
+	enrollment, err := suite.service.EnrollMFA(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	suite.Require().NoError(err)
+	_, err = suite.service.ConfirmMFA(suite.ctx, suite.user.ID, claims.SessionID, &service.MFACodeRequest{Code: code})
+	suite.Require().NoError(err)
+
+	_, err = suite.service.RefreshToken(suite.ctx, &service.RefreshTokenRequest{RefreshToken: other.RefreshToken})
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+
+	refreshed, err := suite.service.RefreshToken(suite.ctx, &service.RefreshTokenRequest{RefreshToken: current.RefreshToken})
+	suite.Require().NoError(err)
+	claims, err = suite.tokens.Parse(refreshed.Token)
+	suite.Require().NoError(err)
+	suite.False(claims.MFA)
+
+	// 通过两步验证登录的会话刷新后保留 mfa 声明
+	pending := suite.login()
+	next, err := totp.Code(enrollment.Secret, time.Now().Add(totp.Period))
+	suite.Require().NoError(err)
+	response, err := suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: next})
+	suite.Require().NoError(err)
+	refreshed, err = suite.service.RefreshToken(suite.ctx, &service.RefreshTokenRequest{RefreshToken: response.RefreshToken})
+	suite.Require().NoError(err)
+	claims, err = suite.tokens.Parse(refreshed.Token)
+	suite.Require().NoError(err)
+	suite.True(claims.MFA)
+}
+
+// TestLoginAttemptsLimited 测试连续失败后登录第二步令牌失效
+func (suite *MFAServiceTestSuite) TestLoginAttemptsLimited() {
+	secret, _ := suite.enable()
+	pending := suite.login()
+
+	for i := 0; i < 5; i++ {
+		_, err := suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: "000000"})
+		suite.ErrorIs(err, service.ErrInvalidMFACode)
+	}
+
+	next, err := totp.Code(secret, time.Now().Add(totp.Period))
+	suite.Require().NoError(err)
+	_, err = suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: next})
+	suite.ErrorIs(err, service.ErrInvalidMFAToken)
+}
+
+// TestRecoveryCodes 测试恢复码只能使用一次
+func (suite *MFAServiceTestSuite) TestRecoveryCodes() {
+	_, codes := suite.enable()
+
+	pending := suite.login()
+	response, err := suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{
+		MFAToken: pending.MFAToken,
+		Code:     " " + codes[0] + " ",
+	})
+	suite.Require().NoError(err)
+	suite.NotEmpty(response.Token)
+
+	pending = suite.login()
+	_, err = suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: codes[0]})
+	suite.ErrorIs(err, service.ErrInvalidMFACode)
+
+	status, err := suite.service.GetMFAStatus(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(9), status.RecoveryCodesRemaining)
+}
+
+// TestRegenerateRecoveryCodes 测试重新生成恢复码后旧恢复码失效
+func (suite *MFAServiceTestSuite) TestRegenerateRecoveryCodes() {
+	secret, old := suite.enable()
+
+	// 恢复码不能用于重新生成恢复码
+	_, err := suite.service.RegenerateRecoveryCodes(suite.ctx, suite.user.ID, &service.MFACodeRequest{Code: old[0]})
+	suite.ErrorIs(err, service.ErrInvalidMFACode)
+
+	next, err := totp.Code(secret, time.Now().Add(totp.Period))
+	suite.Require().NoError(err)
+	fresh, err := suite.service.RegenerateRecoveryCodes(suite.ctx, suite.user.ID, &service.MFACodeRequest{Code: next})
+	suite.Require().NoError(err)
+	suite.Len(fresh.RecoveryCodes, 10)
+
+	pending := suite.login()
+	_, err = suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: old[1]})
+	suite.ErrorIs(err, service.ErrInvalidMFACode)
+	_, err = suite.service.LoginMFA(suite.ctx, &service.MFALoginRequest{MFAToken: pending.MFAToken, Code: fresh.RecoveryCodes[0]})
+	suite.NoError(err)
+}
+
+// TestDisable 测试关闭两步验证
+func (suite *MFAServiceTestSuite) TestDisable() {
+	_, codes := suite.enable()
+
+	err := suite.service.DisableMFA(suite.ctx, suite.user.ID, &service.DisableMFARequest{Password: "wrong", Code: codes[0]})
+	suite.ErrorIs(err, service.ErrInvalidPassword)
+
+	err = suite.service.DisableMFA(suite.ctx, suite.user.ID, &service.DisableMFARequest{Password: "password123", Code: codes[0]})
+	suite.Require().NoError(err)
+
+	status, err := suite.service.GetMFAStatus(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.False(status.Enabled)
+
+	var count int64
+	suite.Require().NoError(suite.db.GetDB().Model(&model.MFARecoveryCode{}).Where("user_id = ?", suite.user.ID).Count(&count).Error)
+	suite.Zero(count)
+
+	// 关闭后直接签发令牌
+	response := suite.login()
+	suite.False(response.MFARequired)
+	suite.NotEmpty(response.Token)
+
+	err = suite.service.DisableMFA(suite.ctx, suite.user.ID, &service.DisableMFARequest{Password: "password123", Code: codes[1]})
+	suite.ErrorIs(err, service.ErrMFANotEnabled)
+}
+
+// TestMFAServiceSuite 运行两步验证测试套件
+func TestMFAServiceSuite(t *testing.T) {
+	suite.Run(t, new(MFAServiceTestSuite))
+}
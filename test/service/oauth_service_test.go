@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/secretbox"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// OAuthServiceTestSuite 第三方登录测试套件
+type OAuthServiceTestSuite struct {
+	suite.Suite
+	db       *testutil.TestDatabase
+	cache    *testutil.TestCache
+	logger   *testutil.TestLogger
+	provider *testutil.FakeOIDCProvider
+	config   *config.Config
+	service  service.UserService
+	ctx      context.Context
+}
+
+// SetupSuite 设置测试套件
+func (suite *OAuthServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.provider = testutil.NewFakeOIDCProvider(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	suite.config = &config.Config{
+		JWT: config.JWTConfig{
+			Secret:            "test-secret-key",
+			Issuer:            "test-issuer",
+			Expiration:        900,
+			RefreshExpiration: 86400,
+		},
+		OAuth: config.OAuthConfig{
+			StateExpiration: 600,
+			AutoRegister:    true,
+			Providers:       []config.OAuthProviderConfig{suite.provider.Config("mock")},
+		},
+	}
+
+	tokens, err := token.New(suite.config)
+	suite.Require().NoError(err)
+	secrets, err := secretbox.New(suite.config)
+	suite.Require().NoError(err)
+	providers, err := oidc.New(suite.config)
+	suite.Require().NoError(err)
+
+	store := suite.cache.CreateTestCache()
+	userRepo := repository.NewUserRepository(database, testLogger)
+	tokenService := service.NewTokenService(
+		repository.NewRefreshTokenRepository(database, testLogger),
+		repository.NewUserSessionRepository(database, testLogger),
+		userRepo,
+		tokens,
+		store,
+		testLogger,
+	)
+	suite.service = service.NewUserService(
+		userRepo,
+		repository.NewMFARepository(database, testLogger),
+		repository.NewUserIdentityRepository(database, testLogger),
+		tokenService,
+		new(mocks.MockEmailVerificationService),
+		new(mocks.MockInvitationService),
+		secrets,
+		providers,
+		testutil.NewTestPasswordPolicy(suite.T()),
+		store,
+		testLogger,
+		suite.config,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *OAuthServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *OAuthServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+	suite.config.OAuth.AutoRegister = true
+}
+
+// login 以指定提供方用户完成一次授权并回调
+func (suite *OAuthServiceTestSuite) login(user testutil.FakeOIDCUser) (*service.LoginResponse, error) {
+	suite.provider.SetUser(user)
+
+	authorization, err := suite.service.StartOAuthLogin(suite.ctx, "mock")
+	suite.Require().NoError(err)
+	code, state := suite.provider.Authorize(suite.T(), authorization.AuthorizationURL)
+	suite.Require().Equal(authorization.State, state)
+
+	return suite.service.CompleteOAuthLogin(suite.ctx, &service.OAuthCallbackRequest{
+		Provider: "mock",
+		Code:     code,
+		State:    state,
+		Binding:  authorization.Binding,
+	})
+}
+
+// TestRegisterNewUser 测试首次登录自动创建用户
+func (suite *OAuthServiceTestSuite) TestRegisterNewUser() {
+	user := testutil.FakeOIDCUser{Subject: "sub-new", Email: "new.user@example.com", EmailVerified: true, Name: "New User"}
+
+	response, err := suite.login(user)
+	suite.Require().NoError(err)
+	suite.NotEmpty(response.Token)
+	suite.NotEmpty(response.RefreshToken)
+	suite.Equal("new.user", response.User.Username)
+	suite.Equal("New User", response.User.Nickname)
+	suite.NotNil(response.User.EmailVerifiedAt)
+
+	identities, err := suite.service.ListIdentities(suite.ctx, response.User.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(identities, 1)
+	suite.Equal("mock", identities[0].Provider)
+
+	// 再次登录使用已关联的身份，不会重复创建用户
+	again, err := suite.login(user)
+	suite.Require().NoError(err)
+	suite.Equal(response.User.ID, again.User.ID)
+
+	var count int64
+	suite.Require().NoError(suite.db.GetDB().Model(&model.User{}).Count(&count).Error)
+	suite.Equal(int64(1), count)
+}
+
+// TestLinkExistingUser 测试按已验证邮箱关联已有用户
+func (suite *OAuthServiceTestSuite) TestLinkExistingUser() {
+	existing := &model.User{
+		Username: "existing",
+		Email:    "existing@example.com",
+		Password: "password123",
+		Status:   model.UserStatusInactive, // 等待验证邮箱
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(existing).Error)
+
+	response, err := suite.login(testutil.FakeOIDCUser{Subject: "sub-existing", Email: existing.Email, EmailVerified: true})
+	suite.Require().NoError(err)
+	suite.Equal(existing.ID, response.User.ID)
+	suite.Equal(model.UserStatusActive, response.User.Status)
+	suite.NotNil(response.User.EmailVerifiedAt)
+
+	// 用户名冲突时生成带后缀的用户名
+	response, err = suite.login(testutil.FakeOIDCUser{Subject: "sub-other", Email: "existing@other.example.com", EmailVerified: true})
+	suite.Require().NoError(err)
+	suite.NotEqual(existing.ID, response.User.ID)
+	suite.Regexp(`^existing_\d{6}$`, response.User.Username)
+}
+
+// TestUnverifiedEmailRejected 测试未验证的邮箱不能关联已有用户
+func (suite *OAuthServiceTestSuite) TestUnverifiedEmailRejected() {
+	victim := &model.User{Username: "victim", Email: "victim@example.com", Password: "password123"}
+	suite.Require().NoError(suite.db.GetDB().Create(victim).Error)
+
+	_, err := suite.login(testutil.FakeOIDCUser{Subject: "sub-attacker", Email: victim.Email, EmailVerified: false})
+	suite.ErrorIs(err, service.ErrOAuthEmailNotVerified)
+
+	var count int64
+	suite.Require().NoError(suite.db.GetDB().Model(&model.UserIdentity{}).Count(&count).Error)
+	suite.Zero(count)
+}
+
+// TestAutoRegisterDisabled 测试关闭自动注册后只能登录已有账户
+func (suite *OAuthServiceTestSuite) TestAutoRegisterDisabled() {
+	suite.config.OAuth.AutoRegister = false
+
+	_, err := suite.login(testutil.FakeOIDCUser{Subject: "sub-unknown", Email: "unknown@example.com", EmailVerified: true})
+	suite.ErrorIs(err, service.ErrOAuthAccountNotFound)
+}
+
+// TestStateValidation 测试 state 只能使用一次，且必须匹配提供方和发起授权的浏览器
+func (suite *OAuthServiceTestSuite) TestStateValidation() {
+	suite.provider.SetUser(testutil.FakeOIDCUser{Subject: "sub-state", Email: "state@example.com", EmailVerified: true})
+
+	authorization, err := suite.service.StartOAuthLogin(suite.ctx, "mock")
+	suite.Require().NoError(err)
+	code, state := suite.provider.Authorize(suite.T(), authorization.AuthorizationURL)
+
+	binding := authorization.Binding
+	_, err = suite.service.CompleteOAuthLogin(suite.ctx, &service.OAuthCallbackRequest{Provider: "mock", Code: code, State: "forged", Binding: binding})
+	suite.ErrorIs(err, service.ErrInvalidOAuthState)
+
+	// 没有或不匹配发起授权的浏览器 Cookie 时拒绝，state 仍可由原浏览器使用
+	_, err = suite.service.CompleteOAuthLogin(suite.ctx, &service.OAuthCallbackRequest{Provider: "mock", Code: code, State: state})
+	suite.ErrorIs(err, service.ErrInvalidOAuthState)
+	other, err := suite.service.StartOAuthLogin(suite.ctx, "mock")
+	suite.Require().NoError(err)
+	_, err = suite.service.CompleteOAuthLogin(suite.ctx, &service.OAuthCallbackRequest{Provider: "mock", Code: code, State: state, Binding: other.Binding})
+	suite.ErrorIs(err, service.ErrInvalidOAuthState)
+
+	_, err = suite.service.CompleteOAuthLogin(suite.ctx, &service.OAuthCallbackRequest{Provider: "mock", Code: code, State: state, Binding: binding})
+	suite.Require().NoError(err)
+
+	_, err = suite.service.CompleteOAuthLogin(suite.ctx, &service.OAuthCallbackRequest{Provider: "mock", Code: code, State: state, Binding: binding})
+	suite.ErrorIs(err, service.ErrInvalidOAuthState)
+
+	_, err = suite.service.StartOAuthLogin(suite.ctx, "missing")
+	suite.ErrorIs(err, service.ErrOAuthProviderNotFound)
+}
+
+// TestInvalidCode 测试授权码无效时登录失败
+func (suite *OAuthServiceTestSuite) TestInvalidCode() {
+	authorization, err := suite.service.StartOAuthLogin(suite.ctx, "mock")
+	suite.Require().NoError(err)
+
+	_, err = suite.service.CompleteOAuthLogin(suite.ctx, &service.OAuthCallbackRequest{
+		Provider: "mock",
+		Code:     "forged-code",
+		State:    authorization.State,
+		Binding:  authorization.Binding,
+	})
+	suite.ErrorIs(err, service.ErrOAuthFailed)
+}
+
+// TestMFAStillRequired 测试开启两步验证的用户通过第三方登录后仍需提交验证码
+func (suite *OAuthServiceTestSuite) TestMFAStillRequired() {
+	user := &model.User{Username: "mfa-oauth", Email: "mfa-oauth@example.com", Password: "password123"}
+	suite.Require().NoError(suite.db.GetDB().Create(user).Error)
+	suite.Require().NoError(suite.db.GetDB().Model(user).UpdateColumn("mfa_enabled", true).Error)
+
+	response, err := suite.login(testutil.FakeOIDCUser{Subject: "sub-mfa", Email: user.Email, EmailVerified: true})
+	suite.Require().NoError(err)
+	suite.True(response.MFARequired)
+	suite.NotEmpty(response.MFAToken)
+	suite.Empty(response.Token)
+}
+
+// TestBannedUserRejected 测试被禁用的用户不能通过第三方登录
+func (suite *OAuthServiceTestSuite) TestBannedUserRejected() {
+	now := time.Now()
+	user := &model.User{
+		Username:        "banned",
+		Email:           "banned@example.com",
+		Password:        "password123",
+		Status:          model.UserStatusBanned,
+		EmailVerifiedAt: &now,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(user).Error)
+
+	_, err := suite.login(testutil.FakeOIDCUser{Subject: "sub-banned", Email: user.Email, EmailVerified: true})
+	suite.ErrorIs(err, service.ErrAccountNotActive)
+}
+
+// TestOAuthServiceSuite 运行第三方登录测试套件
+func TestOAuthServiceSuite(t *testing.T) {
+	suite.Run(t, new(OAuthServiceTestSuite))
+}
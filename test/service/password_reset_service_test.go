@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// resetLinkPattern 从邮件正文中提取重置令牌
+var resetLinkPattern = regexp.MustCompile(`https://app\.example\.com/reset-password\?token=([^\s"]+)`)
+
+// PasswordResetServiceTestSuite 密码重置服务测试套件
+type PasswordResetServiceTestSuite struct {
+	suite.Suite
+	db       *testutil.TestDatabase
+	cache    *testutil.TestCache
+	logger   *testutil.TestLogger
+	mailer   *mocks.MockMailer
+	userRepo repository.UserRepository
+	tokens   service.TokenService
+	service  service.PasswordResetService
+	ctx      context.Context
+	user     *model.User
+	sent     []*mailer.Message
+}
+
+// SetupSuite 设置测试套件
+func (suite *PasswordResetServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	cfg := &config.Config{
+		Security: config.SecurityConfig{PasswordResetExpiration: 1800},
+		Email:    config.EmailConfig{LinkBaseURL: "https://app.example.com/"},
+	}
+
+	suite.mailer = new(mocks.MockMailer)
+	suite.userRepo = repository.NewUserRepository(database, testLogger)
+	suite.tokens = service.NewTokenService(
+		repository.NewRefreshTokenRepository(database, testLogger),
+		repository.NewUserSessionRepository(database, testLogger),
+		suite.userRepo,
+		testutil.NewTestTokenManager(suite.T()),
+		suite.cache.CreateTestCache(),
+		testLogger,
+	)
+	suite.service = service.NewPasswordResetService(
+		suite.userRepo,
+		repository.NewOneTimeTokenRepository(database, testLogger),
+		suite.tokens,
+		testutil.NewTestPasswordPolicy(suite.T()),
+		suite.mailer,
+		cfg,
+		testLogger,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *PasswordResetServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *PasswordResetServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	suite.sent = nil
+	suite.mailer.ExpectedCalls = nil
+	suite.mailer.Calls = nil
+	suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		suite.sent = append(suite.sent, args.Get(1).(*mailer.Message))
+	}).Return(nil)
+
+	suite.user = &model.User{
+		Username: "reset-user",
+		Email:    "reset-user@example.com",
+		Password: "oldpassword",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+}
+
+// forgot 申请重置并返回邮件中的令牌
+func (suite *PasswordResetServiceTestSuite) forgot() string {
+	suite.Require().NoError(suite.service.Forgot(suite.ctx, &service.ForgotPasswordRequest{Email: suite.user.Email}))
+	suite.Require().NotEmpty(suite.sent)
+
+	msg := suite.sent[len(suite.sent)-1]
+	suite.Equal([]string{suite.user.Email}, msg.To)
+
+	match := resetLinkPattern.FindStringSubmatch(msg.Text)
+	suite.Require().Len(match, 2)
+	plain, err := url.QueryUnescape(match[1])
+	suite.Require().NoError(err)
+	return plain
+}
+
+// TestResetPassword 测试通过邮件令牌重置密码
+func (suite *PasswordResetServiceTestSuite) TestResetPassword() {
+	plain := suite.forgot()
+
+	// 数据库中只保存哈希
+	var record model.OneTimeToken
+	suite.Require().NoError(suite.db.GetDB().First(&record).Error)
+	suite.NotEqual(plain, record.TokenHash)
+	suite.Equal(model.TokenPurposePasswordReset, record.Purpose)
+	suite.WithinDuration(time.Now().Add(30*time.Minute), record.ExpiresAt, time.Minute)
+
+	suite.Require().NoError(suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{
+		Token:       plain,
+		NewPassword: "newpassword123",
+	}))
+
+	user, err := suite.userRepo.GetByID(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.True(user.CheckPassword("newpassword123"))
+	suite.False(user.CheckPassword("oldpassword"))
+}
+
+// TestResetTokenSingleUse 测试重置令牌只能使用一次
+func (suite *PasswordResetServiceTestSuite) TestResetTokenSingleUse() {
+	plain := suite.forgot()
+
+	req := &service.ResetPasswordRequest{Token: plain, NewPassword: "newpassword123"}
+	suite.Require().NoError(suite.service.Reset(suite.ctx, req))
+
+	req.NewPassword = "anotherpassword"
+	suite.ErrorIs(suite.service.Reset(suite.ctx, req), service.ErrInvalidResetToken)
+}
+
+// TestResetRevokesSessions 测试重置密码后撤销所有会话
+func (suite *PasswordResetServiceTestSuite) TestResetRevokesSessions() {
+	pair, err := suite.tokens.Issue(suite.ctx, suite.user, service.ClientInfo{}, false)
+	suite.Require().NoError(err)
+
+	plain := suite.forgot()
+	suite.Require().NoError(suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{
+		Token:       plain,
+		NewPassword: "newpassword123",
+	}))
+
+	sessions, err := suite.tokens.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Empty(sessions)
+
+	_, err = suite.tokens.Refresh(suite.ctx, pair.RefreshToken, service.ClientInfo{})
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+}
+
+// TestNewRequestInvalidatesPrevious 测试再次申请后旧令牌失效
+func (suite *PasswordResetServiceTestSuite) TestNewRequestInvalidatesPrevious() {
+	first := suite.forgot()
+	second := suite.forgot()
+
+	err := suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{Token: first, NewPassword: "newpassword123"})
+	suite.ErrorIs(err, service.ErrInvalidResetToken)
+
+	suite.NoError(suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{Token: second, NewPassword: "newpassword123"}))
+}
+
+// TestResetExpiredToken 测试过期令牌
+func (suite *PasswordResetServiceTestSuite) TestResetExpiredToken() {
+	plain := suite.forgot()
+	suite.Require().NoError(suite.db.GetDB().Model(&model.OneTimeToken{}).
+		Where("user_id = ?", suite.user.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	err := suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{Token: plain, NewPassword: "newpassword123"})
+	suite.ErrorIs(err, service.ErrInvalidResetToken)
+
+	deleted, err := suite.service.CleanupExpired(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), deleted)
+}
+
+// TestResetInvalidRequests 测试无效令牌和过短密码
+func (suite *PasswordResetServiceTestSuite) TestResetInvalidRequests() {
+	err := suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{Token: "unknown", NewPassword: "newpassword123"})
+	suite.ErrorIs(err, service.ErrInvalidResetToken)
+
+	plain := suite.forgot()
+	err = suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{Token: plain, NewPassword: "123"})
+	suite.ErrorIs(err, service.ErrPasswordTooShort)
+
+	// 密码过短时令牌不会被消耗
+	suite.NoError(suite.service.Reset(suite.ctx, &service.ResetPasswordRequest{Token: plain, NewPassword: "newpassword123"}))
+}
+
+// TestForgotDoesNotRevealEmail 测试未注册邮箱、禁用用户和发送失败时同样返回成功
+func (suite *PasswordResetServiceTestSuite) TestForgotDoesNotRevealEmail() {
+	suite.NoError(suite.service.Forgot(suite.ctx, &service.ForgotPasswordRequest{Email: "nobody@example.com"}))
+	suite.Empty(suite.sent)
+
+	suite.Require().NoError(suite.db.GetDB().Model(suite.user).Update("status", model.UserStatusInactive).Error)
+	suite.NoError(suite.service.Forgot(suite.ctx, &service.ForgotPasswordRequest{Email: suite.user.Email}))
+	suite.Empty(suite.sent)
+
+	suite.Require().NoError(suite.db.GetDB().Model(suite.user).Update("status", model.UserStatusActive).Error)
+	suite.mailer.ExpectedCalls = nil
+	suite.mailer.On("Send", mock.Anything, mock.Anything).Return(errors.New("smtp unavailable"))
+	suite.NoError(suite.service.Forgot(suite.ctx, &service.ForgotPasswordRequest{Email: suite.user.Email}))
+}
+
+// TestPasswordResetServiceSuite 运行密码重置服务测试套件
+func TestPasswordResetServiceSuite(t *testing.T) {
+	suite.Run(t, new(PasswordResetServiceTestSuite))
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/signer"
+	"vibe-coding-starter/pkg/storage"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// exportLinkPattern 从邮件正文中提取导出下载令牌
+var exportLinkPattern = regexp.MustCompile(`https://app\.example\.com/data-export\?token=([^\s"]+)`)
+
+// PrivacyServiceTestSuite 个人数据导出与账户注销服务测试套件
+type PrivacyServiceTestSuite struct {
+	suite.Suite
+	db          *testutil.TestDatabase
+	cache       *testutil.TestCache
+	logger      *testutil.TestLogger
+	mailer      *mocks.MockMailer
+	audit       *mocks.MockAuditService
+	manager     *storage.Manager
+	driver      storage.Storage
+	config      *config.Config
+	fileService service.FileService
+	service     service.PrivacyService
+	ctx         context.Context
+	user        *model.User
+	sent        chan *mailer.Message
+
+	auditMu sync.Mutex
+	audits  []*service.AuditEntry
+}
+
+// SetupSuite 设置测试套件
+func (suite *PrivacyServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+	testCache := suite.cache.CreateTestCache()
+
+	suite.config = &config.Config{
+		JWT:   config.JWTConfig{Secret: "test-secret"},
+		Email: config.EmailConfig{LinkBaseURL: "https://app.example.com/"},
+	}
+	urlSigner, err := signer.New(suite.config)
+	suite.Require().NoError(err)
+
+	userRepo := repository.NewUserRepository(database, testLogger)
+	fileRepo := repository.NewFileRepository(database, testLogger)
+	quotaRepo := repository.NewStorageQuotaRepository(database, testLogger)
+	suite.manager = storage.NewManager(model.StorageTypeLocal)
+	suite.mailer = new(mocks.MockMailer)
+	suite.audit = new(mocks.MockAuditService)
+	suite.fileService = service.NewFileService(
+		fileRepo,
+		repository.NewFileBlobRepository(database, testLogger),
+		service.NewStorageQuotaService(quotaRepo, userRepo, suite.config, testLogger),
+		suite.manager,
+		urlSigner,
+		suite.config,
+		testCache,
+		testLogger,
+	)
+	tokens := service.NewTokenService(
+		repository.NewRefreshTokenRepository(database, testLogger),
+		repository.NewUserSessionRepository(database, testLogger),
+		userRepo,
+		testutil.NewTestTokenManager(suite.T()),
+		testCache,
+		testLogger,
+	)
+	suite.service = service.NewPrivacyService(
+		userRepo,
+		repository.NewArticleRepository(database, testLogger),
+		repository.NewCommentRepository(database, testLogger),
+		fileRepo,
+		repository.NewOneTimeTokenRepository(database, testLogger),
+		quotaRepo,
+		suite.fileService,
+		tokens,
+		suite.audit,
+		suite.manager,
+		suite.mailer,
+		testCache,
+		suite.config,
+		testLogger,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *PrivacyServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *PrivacyServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	// 每个测试使用独立的存储目录
+	local, err := storage.NewLocalStorage(config.LocalStorageConfig{Root: suite.T().TempDir()})
+	suite.Require().NoError(err)
+	suite.manager.Register(model.StorageTypeLocal, local)
+	suite.driver = local
+
+	suite.config.Privacy = config.PrivacyConfig{
+		ExportExpiration:    3600,
+		ExportInterval:      3600,
+		DeletionGracePeriod: 86400,
+		ContentPolicy:       service.ContentPolicyDelete,
+	}
+
+	suite.audits = nil
+	suite.audit.ExpectedCalls = nil
+	suite.audit.Calls = nil
+	suite.audit.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		suite.auditMu.Lock()
+		defer suite.auditMu.Unlock()
+		suite.audits = append(suite.audits, args.Get(1).(*service.AuditEntry))
+	}).Return()
+
+	suite.sent = make(chan *mailer.Message, 10)
+	suite.mailer.ExpectedCalls = nil
+	suite.mailer.Calls = nil
+	suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		suite.sent <- args.Get(1).(*mailer.Message)
+	}).Return(nil)
+
+	suite.user = suite.createUser("privacy-user")
+}
+
+// createUser 创建测试用户
+func (suite *PrivacyServiceTestSuite) createUser(username string) *model.User {
+	user := &model.User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "password123",
+		Nickname: "Nick " + username,
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(user).Error)
+	return user
+}
+
+// createContent 为用户创建文章、评论和文件，返回文章和文件
+func (suite *PrivacyServiceTestSuite) createContent(user *model.User) (*model.Article, *model.File) {
+	article := &model.Article{
+		Title:    "Article by " + user.Username,
+		Content:  "content",
+		Status:   model.ArticleStatusPublished,
+		AuthorID: user.ID,
+		Tags:     []model.Tag{{Name: "tag-" + user.Username}},
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(article).Error)
+	suite.Require().NoError(suite.db.GetDB().Create(&model.Comment{
+		Content:   "comment by " + user.Username,
+		ArticleID: article.ID,
+		AuthorID:  user.ID,
+	}).Error)
+
+	content := "file of " + user.Username
+	file, err := suite.fileService.Upload(suite.ctx, &service.UploadRequest{
+		FileName: user.Username + ".txt",
+		FileSize: int64(len(content)),
+		Reader:   strings.NewReader(content),
+		OwnerID:  user.ID,
+	})
+	suite.Require().NoError(err)
+	return article, file
+}
+
+// nextMail 等待下一封邮件
+func (suite *PrivacyServiceTestSuite) nextMail() *mailer.Message {
+	select {
+	case msg := <-suite.sent:
+		return msg
+	case <-time.After(5 * time.Second):
+		suite.FailNow("no email was sent")
+		return nil
+	}
+}
+
+// exportToken 从导出完成邮件中提取下载令牌
+func (suite *PrivacyServiceTestSuite) exportToken(msg *mailer.Message) string {
+	suite.Equal([]string{suite.user.Email}, msg.To)
+	match := exportLinkPattern.FindStringSubmatch(msg.Text)
+	suite.Require().Len(match, 2)
+	plain, err := url.QueryUnescape(match[1])
+	suite.Require().NoError(err)
+	return plain
+}
+
+// TestBuildExport 测试导出文件包含个人数据且链接可以重复下载
+func (suite *PrivacyServiceTestSuite) TestBuildExport() {
+	suite.createContent(suite.user)
+	other := suite.createUser("other-user")
+	suite.createContent(other)
+
+	suite.Require().NoError(suite.service.BuildExport(suite.ctx, suite.user.ID))
+	plain := suite.exportToken(suite.nextMail())
+
+	for i := 0; i < 2; i++ {
+		download, err := suite.service.OpenExport(suite.ctx, plain)
+		suite.Require().NoError(err)
+		data, err := io.ReadAll(download.Content)
+		suite.Require().NoError(download.Content.Close())
+		suite.Require().NoError(err)
+
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		suite.Require().NoError(err)
+		entries := make(map[string]string)
+		for _, f := range reader.File {
+			rc, err := f.Open()
+			suite.Require().NoError(err)
+			content, err := io.ReadAll(rc)
+			suite.Require().NoError(rc.Close())
+			suite.Require().NoError(err)
+			entries[f.Name] = string(content)
+		}
+
+		suite.Len(entries, 4)
+		suite.Contains(entries["profile.json"], `"email": "privacy-user@example.com"`)
+		suite.NotContains(entries["profile.json"], "password")
+		suite.Contains(entries["articles.json"], "Article by privacy-user")
+		suite.Contains(entries["comments.json"], "comment by privacy-user")
+		suite.Contains(entries["files.json"], "privacy-user.txt")
+		for _, content := range entries {
+			suite.NotContains(content, "other-user")
+		}
+	}
+
+	_, err := suite.service.OpenExport(suite.ctx, "unknown")
+	suite.True(errors.Is(err, service.ErrInvalidExportToken))
+	_, err = suite.service.OpenExport(suite.ctx, "")
+	suite.True(errors.Is(err, service.ErrInvalidExportToken))
+}
+
+// TestRequestExport 测试后台生成导出文件并限制申请频率
+func (suite *PrivacyServiceTestSuite) TestRequestExport() {
+	suite.Require().NoError(suite.service.RequestExport(suite.ctx, suite.user.ID))
+	suite.exportToken(suite.nextMail())
+
+	err := suite.service.RequestExport(suite.ctx, suite.user.ID)
+	suite.True(errors.Is(err, service.ErrExportTooFrequent))
+
+	err = suite.service.RequestExport(suite.ctx, 9999)
+	suite.True(errors.Is(err, service.ErrUserNotFound))
+}
+
+// TestCleanupExports 测试删除超过有效期的导出文件
+func (suite *PrivacyServiceTestSuite) TestCleanupExports() {
+	suite.Require().NoError(suite.service.BuildExport(suite.ctx, suite.user.ID))
+	plain := suite.exportToken(suite.nextMail())
+
+	deleted, err := suite.service.CleanupExports(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Zero(deleted)
+
+	suite.config.Privacy.ExportExpiration = 1
+	time.Sleep(1100 * time.Millisecond)
+	deleted, err = suite.service.CleanupExports(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Equal(1, deleted)
+
+	_, err = suite.service.OpenExport(suite.ctx, plain)
+	suite.True(errors.Is(err, service.ErrInvalidExportToken))
+}
+
+// TestScheduleAndCancelDeletion 测试申请和撤销注销
+func (suite *PrivacyServiceTestSuite) TestScheduleAndCancelDeletion() {
+	_, err := suite.service.ScheduleDeletion(suite.ctx, suite.user.ID, &service.DeleteAccountRequest{Password: "wrong"})
+	suite.True(errors.Is(err, service.ErrInvalidPassword))
+
+	scheduledAt, err := suite.service.ScheduleDeletion(suite.ctx, suite.user.ID, &service.DeleteAccountRequest{Password: "password123"})
+	suite.Require().NoError(err)
+	suite.WithinDuration(time.Now().Add(24*time.Hour), scheduledAt, time.Minute)
+	suite.Contains(suite.nextMail().Text, "匿名化")
+
+	// 重复申请返回原计划时间
+	again, err := suite.service.ScheduleDeletion(suite.ctx, suite.user.ID, &service.DeleteAccountRequest{Password: "password123"})
+	suite.Require().NoError(err)
+	suite.WithinDuration(scheduledAt, again, time.Second)
+
+	// 冷静期内不会被匿名化
+	purged, err := suite.service.PurgeDue(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Zero(purged)
+
+	suite.Require().NoError(suite.service.CancelDeletion(suite.ctx, suite.user.ID))
+	var user model.User
+	suite.Require().NoError(suite.db.GetDB().First(&user, suite.user.ID).Error)
+	suite.Nil(user.DeletionScheduledAt)
+
+	err = suite.service.CancelDeletion(suite.ctx, suite.user.ID)
+	suite.True(errors.Is(err, service.ErrDeletionNotScheduled))
+}
+
+// scheduleNow 申请注销并立即到期
+func (suite *PrivacyServiceTestSuite) scheduleNow(user *model.User) {
+	suite.config.Privacy.DeletionGracePeriod = 0
+	_, err := suite.service.ScheduleDeletion(suite.ctx, user.ID, &service.DeleteAccountRequest{Password: "password123"})
+	suite.Require().NoError(err)
+	suite.nextMail()
+}
+
+// anonymizeAudit 返回匿名化操作的审计记录序列化后的内容
+func (suite *PrivacyServiceTestSuite) anonymizeAudit() string {
+	suite.auditMu.Lock()
+	defer suite.auditMu.Unlock()
+
+	for _, entry := range suite.audits {
+		if entry.Action == "user.anonymize" {
+			data, err := json.Marshal(entry)
+			suite.Require().NoError(err)
+			return string(data)
+		}
+	}
+	suite.FailNow("anonymize audit entry not recorded")
+	return ""
+}
+
+// TestPurgeDueDeletesContent 测试匿名化账户并彻底删除其内容
+func (suite *PrivacyServiceTestSuite) TestPurgeDueDeletesContent() {
+	article, file := suite.createContent(suite.user)
+	other := suite.createUser("other-user")
+	_, otherFile := suite.createContent(other)
+
+	// 其他用户对该用户评论的回复
+	var comment model.Comment
+	suite.Require().NoError(suite.db.GetDB().Where("author_id = ?", suite.user.ID).First(&comment).Error)
+	var otherArticle model.Article
+	suite.Require().NoError(suite.db.GetDB().Where("author_id = ?", other.ID).First(&otherArticle).Error)
+	reply := &model.Comment{Content: "reply", ArticleID: otherArticle.ID, AuthorID: other.ID, ParentID: &comment.ID}
+	suite.Require().NoError(suite.db.GetDB().Create(reply).Error)
+	suite.Require().NoError(suite.db.GetDB().Create(&model.Comment{Content: "on other article", ArticleID: otherArticle.ID, AuthorID: suite.user.ID}).Error)
+	suite.Require().NoError(suite.db.GetDB().Create(&model.UserSession{UserID: suite.user.ID, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}).Error)
+
+	suite.Require().NoError(suite.service.BuildExport(suite.ctx, suite.user.ID))
+	suite.nextMail()
+	suite.scheduleNow(suite.user)
+
+	purged, err := suite.service.PurgeDue(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Equal(1, purged)
+
+	var user model.User
+	suite.Require().NoError(suite.db.GetDB().Unscoped().First(&user, suite.user.ID).Error)
+	suite.Equal("deleted-"+itoa(suite.user.ID), user.Username)
+	suite.Equal("deleted-"+itoa(suite.user.ID)+"@deleted.invalid", user.Email)
+	suite.Empty(user.Nickname)
+	suite.Empty(user.Password)
+	suite.Nil(user.DeletionScheduledAt)
+	suite.True(user.DeletedAt.Valid)
+
+	// 审计日志不能保留被擦除的个人信息
+	entry := suite.anonymizeAudit()
+	suite.Contains(entry, `"content_policy":"delete"`)
+	suite.NotContains(entry, suite.user.Email)
+	suite.NotContains(entry, suite.user.Username)
+	suite.NotContains(entry, suite.user.Nickname)
+
+	// 原用户名和邮箱可以重新注册
+	suite.Require().NoError(suite.db.GetDB().Create(&model.User{Username: "privacy-user", Email: "privacy-user@example.com", Password: "password123"}).Error)
+
+	var count int64
+	suite.db.GetDB().Unscoped().Model(&model.Article{}).Where("id = ?", article.ID).Count(&count)
+	suite.Zero(count)
+	suite.db.GetDB().Unscoped().Model(&model.Comment{}).Where("author_id = ?", suite.user.ID).Count(&count)
+	suite.Zero(count)
+	suite.db.GetDB().Unscoped().Model(&model.File{}).Where("owner_id = ?", suite.user.ID).Count(&count)
+	suite.Zero(count)
+	suite.db.GetDB().Table("article_tags").Where("article_id = ?", article.ID).Count(&count)
+	suite.Zero(count)
+	suite.db.GetDB().Model(&model.UserSession{}).Where("user_id = ?", suite.user.ID).Count(&count)
+	suite.Zero(count)
+
+	_, err = suite.driver.Stat(suite.ctx, file.Path)
+	suite.True(errors.Is(err, storage.ErrObjectNotFound))
+	suite.Require().NoError(suite.driver.List(suite.ctx, "exports/", func(info *storage.ObjectInfo) error {
+		suite.Failf("export was not deleted", "key %s", info.Key)
+		return nil
+	}))
+
+	// 其他用户的回复保留为顶层评论，文件不受影响
+	suite.Require().NoError(suite.db.GetDB().First(reply, reply.ID).Error)
+	suite.Nil(reply.ParentID)
+	_, err = suite.fileService.GetByID(suite.ctx, otherFile.ID)
+	suite.NoError(err)
+
+	// 已匿名化的账户不会重复处理
+	purged, err = suite.service.PurgeDue(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Zero(purged)
+}
+
+// TestPurgeDueReassignsContent 测试匿名化账户并将内容转移给指定用户
+func (suite *PrivacyServiceTestSuite) TestPurgeDueReassignsContent() {
+	archive := suite.createUser("archive")
+	suite.config.Privacy.ContentPolicy = service.ContentPolicyReassign
+	suite.config.Privacy.ReassignTo = "missing"
+
+	article, file := suite.createContent(suite.user)
+	suite.scheduleNow(suite.user)
+
+	_, err := suite.service.PurgeDue(suite.ctx)
+	suite.Error(err)
+
+	suite.config.Privacy.ReassignTo = archive.Username
+	purged, err := suite.service.PurgeDue(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Equal(1, purged)
+
+	var reassigned model.Article
+	suite.Require().NoError(suite.db.GetDB().First(&reassigned, article.ID).Error)
+	suite.Equal(archive.ID, reassigned.AuthorID)
+	var count int64
+	suite.db.GetDB().Model(&model.Comment{}).Where("author_id = ?", archive.ID).Count(&count)
+	suite.Equal(int64(1), count)
+
+	reassignedFile, err := suite.fileService.GetByID(suite.ctx, file.ID)
+	suite.Require().NoError(err)
+	suite.Equal(archive.ID, reassignedFile.OwnerID)
+	var quota model.StorageQuota
+	suite.Require().NoError(suite.db.GetDB().Where("user_id = ?", archive.ID).First(&quota).Error)
+	suite.Equal(file.Size, quota.UsedBytes)
+
+	suite.True(errors.Is(suite.db.GetDB().First(&model.User{}, suite.user.ID).Error, gorm.ErrRecordNotFound))
+
+	entry := suite.anonymizeAudit()
+	suite.Contains(entry, `"content_policy":"reassign"`)
+	suite.Contains(entry, `"reassign_to":`+itoa(archive.ID))
+	suite.NotContains(entry, suite.user.Email)
+	suite.NotContains(entry, suite.user.Username)
+	suite.NotContains(entry, suite.user.Nickname)
+}
+
+// itoa 将用户 ID 转换为字符串
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// TestPrivacyServiceSuite 运行个人数据导出与账户注销服务测试套件
+func TestPrivacyServiceSuite(t *testing.T) {
+	suite.Run(t, new(PrivacyServiceTestSuite))
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
+)
+
+// fakePermissionCache 记录权限缓存清除调用
+type fakePermissionCache struct {
+	clearedUsers []uint
+	clearedAll   int
+}
+
+func (c *fakePermissionCache) ClearUserPermissions(userID uint) error {
+	c.clearedUsers = append(c.clearedUsers, userID)
+	return nil
+}
+
+func (c *fakePermissionCache) ClearAllPermissions() error {
+	c.clearedAll++
+	return nil
+}
+
+// RoleServiceTestSuite 角色与权限服务测试套件
+type RoleServiceTestSuite struct {
+	suite.Suite
+	db      *testutil.TestDatabase
+	logger  *testutil.TestLogger
+	cache   *fakePermissionCache
+	audit   *mocks.MockAuditService
+	service service.RoleService
+	ctx     context.Context
+	admin   *model.User
+	user    *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *RoleServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+}
+
+// TearDownSuite 清理测试套件
+func (suite *RoleServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *RoleServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+	suite.cache = &fakePermissionCache{}
+	suite.audit = new(mocks.MockAuditService)
+	suite.audit.On("Record", mock.Anything, mock.Anything).Maybe().Return()
+	suite.service = service.NewRoleService(
+		repository.NewRoleRepository(database, testLogger),
+		repository.NewUserRepository(database, testLogger),
+		suite.cache,
+		suite.audit,
+		testLogger,
+	)
+
+	suite.user = &model.User{
+		Username: "roleuser",
+		Email:    "role@example.com",
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+
+	suite.admin = &model.User{
+		Username: "roleadmin",
+		Email:    "roleadmin@example.com",
+		Password: "password123",
+		Role:     model.UserRoleAdmin,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.admin).Error)
+}
+
+// createRole 创建角色
+func (suite *RoleServiceTestSuite) createRole(name string, permissions ...string) *model.Role {
+	role, err := suite.service.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{Name: name, Permissions: permissions})
+	suite.Require().NoError(err)
+	return role
+}
+
+// TestCreateRole 测试创建角色时校验名称和权限格式，并复用已有权限
+func (suite *RoleServiceTestSuite) TestCreateRole() {
+	role := suite.createRole("Editor", "article:update", "Article:Read", "article:read")
+	suite.Equal("editor", role.Name)
+	suite.ElementsMatch([]string{"article:update", "article:read"}, role.PermissionStrings())
+
+	suite.createRole("reader", "article:read")
+	permissions, err := suite.service.ListPermissions(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Len(permissions, 2)
+
+	_, err = suite.service.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{Name: "editor"})
+	suite.ErrorIs(err, service.ErrRoleExists)
+	_, err = suite.service.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{Name: "bad name"})
+	suite.ErrorIs(err, service.ErrInvalidRoleName)
+	_, err = suite.service.CreateRole(suite.ctx, suite.admin.ID, &service.CreateRoleRequest{Name: "auditor", Permissions: []string{"article"}})
+	suite.ErrorIs(err, service.ErrInvalidPermission)
+}
+
+// TestGetUserPermissionsFallback 测试未分配角色时回退到 users.role
+func (suite *RoleServiceTestSuite) TestGetUserPermissionsFallback() {
+	// 数据库中没有 user 角色时使用内置默认权限
+	permissions, err := suite.service.GetUserPermissions(suite.ctx, suite.user.ID, model.UserRoleUser)
+	suite.Require().NoError(err)
+	suite.Equal(model.DefaultRolePermissions[model.UserRoleUser], permissions)
+
+	// 数据库中有 user 角色时使用数据库中的权限
+	suite.createRole(model.UserRoleUser, "article:read")
+	permissions, err = suite.service.GetUserPermissions(suite.ctx, suite.user.ID, model.UserRoleUser)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"article:read"}, permissions)
+
+	permissions, err = suite.service.GetUserPermissions(suite.ctx, suite.user.ID, "unknown")
+	suite.Require().NoError(err)
+	suite.Empty(permissions)
+}
+
+// TestSetUserRoles 测试一个用户拥有多个角色时权限取并集，并清除该用户的权限缓存
+func (suite *RoleServiceTestSuite) TestSetUserRoles() {
+	suite.createRole("editor", "article:update", "article:read")
+	suite.createRole("uploader", "file:upload", "article:read")
+
+	roles, err := suite.service.SetUserRoles(suite.ctx, suite.admin.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{"editor", "Uploader", "editor"}})
+	suite.Require().NoError(err)
+	suite.Len(roles, 2)
+	suite.Equal([]uint{suite.user.ID}, suite.cache.clearedUsers)
+
+	permissions, err := suite.service.GetUserPermissions(suite.ctx, suite.user.ID, model.UserRoleUser)
+	suite.Require().NoError(err)
+	suite.ElementsMatch([]string{"article:update", "article:read", "file:upload"}, permissions)
+
+	_, err = suite.service.SetUserRoles(suite.ctx, suite.admin.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{"editor", "missing"}})
+	suite.ErrorIs(err, service.ErrRoleNotFound)
+	_, err = suite.service.SetUserRoles(suite.ctx, suite.admin.ID, suite.user.ID+100, &service.SetUserRolesRequest{Roles: []string{"editor"}})
+	suite.ErrorIs(err, service.ErrUserNotFound)
+
+	// 清空角色后回退到 users.role
+	roles, err = suite.service.SetUserRoles(suite.ctx, suite.admin.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{}})
+	suite.Require().NoError(err)
+	suite.Empty(roles)
+	permissions, err = suite.service.GetUserPermissions(suite.ctx, suite.user.ID, model.UserRoleUser)
+	suite.Require().NoError(err)
+	suite.Equal(model.DefaultRolePermissions[model.UserRoleUser], permissions)
+}
+
+// TestUpdateRole 测试更新角色权限后清除所有用户的权限缓存
+func (suite *RoleServiceTestSuite) TestUpdateRole() {
+	role := suite.createRole("editor", "article:update")
+	_, err := suite.service.SetUserRoles(suite.ctx, suite.admin.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{"editor"}})
+	suite.Require().NoError(err)
+
+	displayName := "Editor"
+	updated, err := suite.service.UpdateRole(suite.ctx, suite.admin.ID, role.ID, &service.UpdateRoleRequest{DisplayName: &displayName})
+	suite.Require().NoError(err)
+	suite.Equal("Editor", updated.DisplayName)
+	suite.Equal([]string{"article:update"}, updated.PermissionStrings())
+	suite.Equal(0, suite.cache.clearedAll)
+
+	updated, err = suite.service.UpdateRole(suite.ctx, suite.admin.ID, role.ID, &service.UpdateRoleRequest{Permissions: []string{"article:delete:own"}})
+	suite.Require().NoError(err)
+	suite.Equal([]string{"article:delete:own"}, updated.PermissionStrings())
+	suite.Equal(1, suite.cache.clearedAll)
+
+	// 审计日志记录修改前后的权限
+	suite.audit.AssertCalled(suite.T(), "Record", mock.Anything, mock.MatchedBy(func(entry *service.AuditEntry) bool {
+		before, _ := entry.Before.(map[string]interface{})
+		after, _ := entry.After.(map[string]interface{})
+		return entry.Action == "role.update" &&
+			reflect.DeepEqual(before["permissions"], []string{"article:update"}) &&
+			reflect.DeepEqual(after["permissions"], []string{"article:delete:own"})
+	}))
+
+	permissions, err := suite.service.GetUserPermissions(suite.ctx, suite.user.ID, model.UserRoleUser)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"article:delete:own"}, permissions)
+
+	_, err = suite.service.UpdateRole(suite.ctx, suite.admin.ID, role.ID+100, &service.UpdateRoleRequest{})
+	suite.ErrorIs(err, service.ErrRoleNotFound)
+}
+
+// TestRoleManagerCannotEscalate 测试委派的角色管理员不能授予自己没有的权限，也不能修改自己的角色
+func (suite *RoleServiceTestSuite) TestRoleManagerCannotEscalate() {
+	superuser := suite.createRole("superuser", "*:*")
+	managerRole := suite.createRole("role_manager", "role:create", "role:update", "role:assign", "article:read")
+	suite.createRole("reader", "article:read")
+
+	manager := &model.User{
+		Username: "manager",
+		Email:    "manager@example.com",
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(manager).Error)
+	_, err := suite.service.SetUserRoles(suite.ctx, suite.admin.ID, manager.ID, &service.SetUserRolesRequest{Roles: []string{"role_manager"}})
+	suite.Require().NoError(err)
+
+	// 不能给自己分配角色
+	_, err = suite.service.SetUserRoles(suite.ctx, manager.ID, manager.ID, &service.SetUserRolesRequest{Roles: []string{"role_manager", "superuser"}})
+	suite.ErrorIs(err, service.ErrCannotModifySelf)
+
+	// 不能分配或移除包含自己没有的权限的角色
+	_, err = suite.service.SetUserRoles(suite.ctx, manager.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{"superuser"}})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+	_, err = suite.service.SetUserRoles(suite.ctx, suite.admin.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{"superuser"}})
+	suite.Require().NoError(err)
+	_, err = suite.service.SetUserRoles(suite.ctx, manager.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{"reader"}})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+
+	// 不能创建或修改出超出自己权限的角色
+	_, err = suite.service.CreateRole(suite.ctx, manager.ID, &service.CreateRoleRequest{Name: "escalate", Permissions: []string{"*:*"}})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+	_, err = suite.service.CreateRole(suite.ctx, manager.ID, &service.CreateRoleRequest{Name: "uploader", Permissions: []string{"article:read:all"}})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+	_, err = suite.service.UpdateRole(suite.ctx, manager.ID, managerRole.ID, &service.UpdateRoleRequest{
+		Permissions: append(managerRole.PermissionStrings(), "user:impersonate:all"),
+	})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+	_, err = suite.service.UpdateRole(suite.ctx, manager.ID, superuser.ID, &service.UpdateRoleRequest{Permissions: []string{"article:read"}})
+	suite.ErrorIs(err, service.ErrPermissionNotHeld)
+
+	permissions, err := suite.service.GetUserPermissions(suite.ctx, manager.ID, model.UserRoleUser)
+	suite.Require().NoError(err)
+	suite.ElementsMatch(managerRole.PermissionStrings(), permissions)
+
+	// 自己拥有的权限可以授予
+	_, err = suite.service.CreateRole(suite.ctx, manager.ID, &service.CreateRoleRequest{Name: "viewer", Permissions: []string{"article:read"}})
+	suite.NoError(err)
+	other := &model.User{Username: "other", Email: "other@example.com", Password: "password123", Role: model.UserRoleUser, Status: model.UserStatusActive}
+	suite.Require().NoError(suite.db.GetDB().Create(other).Error)
+	_, err = suite.service.SetUserRoles(suite.ctx, manager.ID, other.ID, &service.SetUserRolesRequest{Roles: []string{"viewer", "reader"}})
+	suite.NoError(err)
+}
+
+// TestDeleteRole 测试删除角色后用户失去该角色，内置角色不能删除
+func (suite *RoleServiceTestSuite) TestDeleteRole() {
+	role := suite.createRole("editor", "article:update")
+	_, err := suite.service.SetUserRoles(suite.ctx, suite.admin.ID, suite.user.ID, &service.SetUserRolesRequest{Roles: []string{"editor"}})
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.service.DeleteRole(suite.ctx, role.ID))
+	suite.Equal(1, suite.cache.clearedAll)
+	suite.ErrorIs(suite.service.DeleteRole(suite.ctx, role.ID), service.ErrRoleNotFound)
+
+	roles, err := suite.service.GetUserRoles(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Empty(roles)
+
+	system := &model.Role{Name: model.UserRoleAdmin, IsSystem: true}
+	suite.Require().NoError(suite.db.GetDB().Create(system).Error)
+	suite.ErrorIs(suite.service.DeleteRole(suite.ctx, system.ID), service.ErrSystemRole)
+}
+
+// TestRoleServiceSuite 运行角色与权限服务测试套件
+func TestRoleServiceSuite(t *testing.T) {
+	suite.Run(t, new(RoleServiceTestSuite))
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/signer"
+	"vibe-coding-starter/pkg/storage"
+	"vibe-coding-starter/test/testutil"
+)
+
+// StorageGCServiceTestSuite 存储一致性检查服务测试套件
+type StorageGCServiceTestSuite struct {
+	suite.Suite
+	db          *testutil.TestDatabase
+	cache       *testutil.TestCache
+	logger      *testutil.TestLogger
+	manager     *storage.Manager
+	driver      storage.Storage
+	fileService service.FileService
+	quota       service.StorageQuotaService
+	service     service.StorageGCService
+	ctx         context.Context
+	owner       *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *StorageGCServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+	cfg := &config.Config{JWT: config.JWTConfig{Secret: "test-secret"}}
+
+	urlSigner, err := signer.New(cfg)
+	suite.Require().NoError(err)
+
+	fileRepo := repository.NewFileRepository(database, testLogger)
+	blobRepo := repository.NewFileBlobRepository(database, testLogger)
+	suite.manager = storage.NewManager(model.StorageTypeLocal)
+	suite.quota = service.NewStorageQuotaService(
+		repository.NewStorageQuotaRepository(database, testLogger),
+		repository.NewUserRepository(database, testLogger),
+		cfg,
+		testLogger,
+	)
+	suite.fileService = service.NewFileService(
+		fileRepo,
+		blobRepo,
+		suite.quota,
+		suite.manager,
+		urlSigner,
+		cfg,
+		suite.cache.CreateTestCache(),
+		testLogger,
+	)
+	suite.service = service.NewStorageGCService(
+		fileRepo,
+		blobRepo,
+		repository.NewUploadSessionRepository(database, testLogger),
+		suite.quota,
+		suite.manager,
+		testLogger,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *StorageGCServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *StorageGCServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	// 每个测试使用独立的存储目录
+	local, err := storage.NewLocalStorage(config.LocalStorageConfig{Root: suite.T().TempDir()})
+	suite.Require().NoError(err)
+	suite.manager.Register(model.StorageTypeLocal, local)
+	suite.driver = local
+
+	suite.owner = &model.User{
+		Username: "gc-owner",
+		Email:    "gc-owner@example.com",
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.owner).Error)
+}
+
+// upload 上传测试文件
+func (suite *StorageGCServiceTestSuite) upload(name, content string) *model.File {
+	file, err := suite.fileService.Upload(suite.ctx, &service.UploadRequest{
+		FileName: name,
+		FileSize: int64(len(content)),
+		Reader:   strings.NewReader(content),
+		OwnerID:  suite.owner.ID,
+	})
+	suite.Require().NoError(err)
+	return file
+}
+
+// put 直接写入存储对象，模拟进程在写入对象后、创建记录前退出
+func (suite *StorageGCServiceTestSuite) put(key string) {
+	suite.Require().NoError(suite.driver.Put(suite.ctx, key, strings.NewReader("orphan"), 6, ""))
+}
+
+// run 执行检查并返回本地存储的报告
+func (suite *StorageGCServiceTestSuite) run(opts service.StorageGCOptions) *service.StorageGCReport {
+	reports, err := suite.service.Run(suite.ctx, opts)
+	suite.Require().NoError(err)
+	suite.Require().Len(reports, 1)
+	return reports[0]
+}
+
+// exists 检查对象是否存在
+func (suite *StorageGCServiceTestSuite) exists(key string) bool {
+	_, err := suite.driver.Stat(suite.ctx, key)
+	return err == nil
+}
+
+// TestConsistentStorage 测试一致的存储不报告问题
+func (suite *StorageGCServiceTestSuite) TestConsistentStorage() {
+	suite.upload("a.txt", "first file")
+	suite.upload("b.txt", "first file")
+
+	report := suite.run(service.StorageGCOptions{DryRun: true})
+	suite.Equal(1, report.ScannedObjects)
+	suite.Equal(2, report.ScannedFiles)
+	suite.Empty(report.OrphanedObjects)
+	suite.Empty(report.MissingObjects)
+	suite.Empty(report.RefCountMismatches)
+	suite.Empty(report.Errors)
+}
+
+// TestOrphanedObjects 测试找出并删除孤立对象
+func (suite *StorageGCServiceTestSuite) TestOrphanedObjects() {
+	file := suite.upload("kept.txt", "kept content")
+	suite.put("1700000000_orphan.txt")
+	suite.put("variants/" + file.Hash + "/thumbnail_200x200.jpg")
+	suite.put("variants/unknownhash/thumbnail_200x200.jpg")
+	suite.put("resumable/missing-session/00000000000000000000-part")
+	suite.put("exports/1/exporthash.zip")
+
+	// 宽限期内的对象可能属于进行中的上传，不会被报告
+	report := suite.run(service.StorageGCOptions{DryRun: true, DeleteOrphans: true, GracePeriod: time.Hour})
+	suite.Empty(report.OrphanedObjects)
+
+	report = suite.run(service.StorageGCOptions{DryRun: true, DeleteOrphans: true})
+	keys := make([]string, 0, len(report.OrphanedObjects))
+	for _, object := range report.OrphanedObjects {
+		keys = append(keys, object.Key)
+	}
+	suite.ElementsMatch([]string{
+		"1700000000_orphan.txt",
+		"variants/unknownhash/thumbnail_200x200.jpg",
+		"resumable/missing-session/00000000000000000000-part",
+	}, keys)
+	suite.Equal(0, report.DeletedObjects)
+	suite.True(suite.exists("1700000000_orphan.txt"))
+
+	report = suite.run(service.StorageGCOptions{DeleteOrphans: true})
+	suite.Equal(3, report.DeletedObjects)
+	suite.False(suite.exists("1700000000_orphan.txt"))
+	suite.False(suite.exists("variants/unknownhash/thumbnail_200x200.jpg"))
+	suite.True(suite.exists(file.Path))
+	suite.True(suite.exists("variants/" + file.Hash + "/thumbnail_200x200.jpg"))
+	suite.True(suite.exists("exports/1/exporthash.zip"))
+}
+
+// TestMissingObjects 测试找出并清理对象已丢失的文件记录
+func (suite *StorageGCServiceTestSuite) TestMissingObjects() {
+	file := suite.upload("lost.txt", "lost content")
+	suite.Require().NoError(suite.driver.Delete(suite.ctx, file.Path))
+
+	report := suite.run(service.StorageGCOptions{DryRun: true, PurgeMissing: true})
+	suite.Require().Len(report.MissingObjects, 1)
+	suite.Equal(file.ID, report.MissingObjects[0].FileID)
+	suite.Equal(0, report.PurgedFiles)
+
+	_, err := suite.fileService.GetByID(suite.ctx, file.ID)
+	suite.NoError(err)
+
+	report = suite.run(service.StorageGCOptions{PurgeMissing: true})
+	suite.Equal(1, report.PurgedFiles)
+
+	_, err = suite.fileService.GetByID(suite.ctx, file.ID)
+	suite.Error(err)
+
+	// 清理后释放对象引用与配额
+	var blobs int64
+	suite.Require().NoError(suite.db.GetDB().Model(&model.FileBlob{}).Count(&blobs).Error)
+	suite.Equal(int64(0), blobs)
+	usage, err := suite.quota.GetUsage(suite.ctx, suite.owner.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(0), usage.UsedBytes)
+}
+
+// TestRefCountMismatch 测试修正对象记录的引用计数
+func (suite *StorageGCServiceTestSuite) TestRefCountMismatch() {
+	file := suite.upload("shared.txt", "shared content")
+	suite.Require().NoError(suite.db.GetDB().Model(&model.FileBlob{}).Where("id = ?", file.BlobID).Update("ref_count", 3).Error)
+
+	// 没有文件引用的对象记录
+	suite.put("1700000000_unused.txt")
+	unused := &model.FileBlob{StorageType: model.StorageTypeLocal, Hash: "unused", Path: "1700000000_unused.txt", Size: 6, RefCount: 1}
+	suite.Require().NoError(suite.db.GetDB().Create(unused).Error)
+
+	report := suite.run(service.StorageGCOptions{DryRun: true, DeleteOrphans: true})
+	suite.Len(report.RefCountMismatches, 2)
+	suite.Empty(report.OrphanedObjects)
+
+	report = suite.run(service.StorageGCOptions{DeleteOrphans: true})
+	suite.Equal(2, report.FixedBlobs)
+	suite.Equal(1, report.DeletedObjects)
+	suite.False(suite.exists("1700000000_unused.txt"))
+
+	var blob model.FileBlob
+	suite.Require().NoError(suite.db.GetDB().First(&blob, file.BlobID).Error)
+	suite.Equal(1, blob.RefCount)
+	suite.Error(suite.db.GetDB().First(&model.FileBlob{}, unused.ID).Error)
+
+	report = suite.run(service.StorageGCOptions{DryRun: true})
+	suite.Empty(report.RefCountMismatches)
+	suite.Empty(report.OrphanedObjects)
+}
+
+// TestLegacyPathFiles 测试早期版本记录 "uploads/<name>" 路径的文件不会被当作孤立对象
+func (suite *StorageGCServiceTestSuite) TestLegacyPathFiles() {
+	suite.put("1600000000_legacy.txt")
+	legacy := &model.File{
+		Name:         "1600000000_legacy.txt",
+		OriginalName: "legacy.txt",
+		Path:         "uploads/1600000000_legacy.txt",
+		Size:         6,
+		MimeType:     "text/plain",
+		Extension:    ".txt",
+		Hash:         "legacyhash",
+		StorageType:  model.StorageTypeLocal,
+		OwnerID:      suite.owner.ID,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(legacy).Error)
+
+	report := suite.run(service.StorageGCOptions{DeleteOrphans: true, PurgeMissing: true})
+	suite.Empty(report.OrphanedObjects)
+	suite.Empty(report.MissingObjects)
+	suite.Equal(0, report.DeletedObjects)
+	suite.True(suite.exists("1600000000_legacy.txt"))
+}
+
+// TestStorageGCServiceTestSuite 运行存储一致性检查服务测试套件
+func TestStorageGCServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(StorageGCServiceTestSuite))
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/test/testutil"
+)
+
+// StorageQuotaServiceTestSuite 存储配额服务测试套件
+type StorageQuotaServiceTestSuite struct {
+	suite.Suite
+	db      *testutil.TestDatabase
+	logger  *testutil.TestLogger
+	service service.StorageQuotaService
+	ctx     context.Context
+	user    *model.User
+	admin   *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *StorageQuotaServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	cfg := &config.Config{
+		Upload: config.UploadConfig{
+			RoleQuotas: map[string]int64{model.UserRoleUser: 100},
+		},
+	}
+	suite.service = service.NewStorageQuotaService(
+		repository.NewStorageQuotaRepository(database, testLogger),
+		repository.NewUserRepository(database, testLogger),
+		cfg,
+		testLogger,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *StorageQuotaServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *StorageQuotaServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+
+	suite.user = &model.User{Username: "quota-user", Email: "quota-user@example.com", Password: "password123", Role: model.UserRoleUser}
+	suite.admin = &model.User{Username: "quota-admin", Email: "quota-admin@example.com", Password: "password123", Role: model.UserRoleAdmin}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+	suite.Require().NoError(suite.db.GetDB().Create(suite.admin).Error)
+}
+
+// TestRoleDefaults 测试按角色使用默认配额
+func (suite *StorageQuotaServiceTestSuite) TestRoleDefaults() {
+	usage, err := suite.service.GetUsage(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(0), usage.UsedBytes)
+	suite.Equal(int64(100), usage.QuotaBytes)
+	suite.Equal(int64(100), usage.RemainingBytes)
+	suite.False(usage.IsOverride)
+
+	// 未配置配额的角色不限制
+	usage, err = suite.service.GetUsage(suite.ctx, suite.admin.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(0), usage.QuotaBytes)
+	suite.Equal(int64(-1), usage.RemainingBytes)
+	suite.NoError(suite.service.Reserve(suite.ctx, suite.admin.ID, 1000))
+}
+
+// TestReserveAndRelease 测试占用与释放配额
+func (suite *StorageQuotaServiceTestSuite) TestReserveAndRelease() {
+	suite.Require().NoError(suite.service.Reserve(suite.ctx, suite.user.ID, 60))
+	suite.ErrorIs(suite.service.Reserve(suite.ctx, suite.user.ID, 50), service.ErrQuotaExceeded)
+	suite.ErrorIs(suite.service.Check(suite.ctx, suite.user.ID, 50), service.ErrQuotaExceeded)
+	suite.NoError(suite.service.Check(suite.ctx, suite.user.ID, 40))
+
+	suite.Require().NoError(suite.service.Release(suite.ctx, suite.user.ID, 60))
+	suite.Require().NoError(suite.service.Reserve(suite.ctx, suite.user.ID, 100))
+
+	// 释放超过已用空间时不会变为负数
+	suite.Require().NoError(suite.service.Release(suite.ctx, suite.user.ID, 500))
+	usage, err := suite.service.GetUsage(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(0), usage.UsedBytes)
+}
+
+// TestSetQuota 测试管理员单独设置配额
+func (suite *StorageQuotaServiceTestSuite) TestSetQuota() {
+	quota := int64(200)
+	usage, err := suite.service.SetQuota(suite.ctx, suite.user.ID, &quota)
+	suite.Require().NoError(err)
+	suite.Equal(int64(200), usage.QuotaBytes)
+	suite.True(usage.IsOverride)
+	suite.NoError(suite.service.Reserve(suite.ctx, suite.user.ID, 150))
+
+	// 恢复角色默认配额后已用空间保留
+	usage, err = suite.service.SetQuota(suite.ctx, suite.user.ID, nil)
+	suite.Require().NoError(err)
+	suite.Equal(int64(100), usage.QuotaBytes)
+	suite.Equal(int64(150), usage.UsedBytes)
+	suite.Equal(int64(0), usage.RemainingBytes)
+	suite.False(usage.IsOverride)
+
+	negative := int64(-1)
+	_, err = suite.service.SetQuota(suite.ctx, suite.user.ID, &negative)
+	suite.Error(err)
+}
+
+// TestZeroQuotaOverride 测试单独设置为 0 时不允许上传，而不是不限制
+func (suite *StorageQuotaServiceTestSuite) TestZeroQuotaOverride() {
+	zero := int64(0)
+	usage, err := suite.service.SetQuota(suite.ctx, suite.admin.ID, &zero)
+	suite.Require().NoError(err)
+	suite.Equal(int64(0), usage.QuotaBytes)
+	suite.Equal(int64(0), usage.RemainingBytes)
+	suite.True(usage.IsOverride)
+
+	suite.ErrorIs(suite.service.Check(suite.ctx, suite.admin.ID, 1), service.ErrQuotaExceeded)
+	suite.ErrorIs(suite.service.Reserve(suite.ctx, suite.admin.ID, 1), service.ErrQuotaExceeded)
+
+	usage, err = suite.service.GetUsage(suite.ctx, suite.admin.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(0), usage.UsedBytes)
+
+	// 恢复后角色未配置默认配额，仍不限制
+	_, err = suite.service.SetQuota(suite.ctx, suite.admin.ID, nil)
+	suite.Require().NoError(err)
+	suite.NoError(suite.service.Reserve(suite.ctx, suite.admin.ID, 1000))
+}
+
+// TestConcurrentReserve 测试并发上传不会超出配额
+func (suite *StorageQuotaServiceTestSuite) TestConcurrentReserve() {
+	var (
+		wg       sync.WaitGroup
+		reserved atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := suite.service.Reserve(suite.ctx, suite.user.ID, 20)
+			if err == nil {
+				reserved.Add(1)
+			} else if !errors.Is(err, service.ErrQuotaExceeded) {
+				suite.Fail("unexpected error", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	suite.Equal(int32(5), reserved.Load())
+	usage, err := suite.service.GetUsage(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(100), usage.UsedBytes)
+}
+
+// TestStorageQuotaServiceTestSuite 运行存储配额服务测试套件
+func TestStorageQuotaServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(StorageQuotaServiceTestSuite))
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/cache"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/test/testutil"
+)
+
+// TokenServiceTestSuite 令牌服务测试套件
+type TokenServiceTestSuite struct {
+	suite.Suite
+	db      *testutil.TestDatabase
+	cache   *testutil.TestCache
+	store   cache.Cache
+	logger  *testutil.TestLogger
+	tokens  *token.Manager
+	service service.TokenService
+	ctx     context.Context
+	user    *model.User
+	client  service.ClientInfo
+}
+
+// SetupSuite 设置测试套件
+func (suite *TokenServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	suite.store = suite.cache.CreateTestCache()
+	suite.tokens = testutil.NewTestTokenManager(suite.T())
+	suite.service = service.NewTokenService(
+		repository.NewRefreshTokenRepository(database, testLogger),
+		repository.NewUserSessionRepository(database, testLogger),
+		repository.NewUserRepository(database, testLogger),
+		suite.tokens,
+		suite.store,
+		testLogger,
+	)
+	suite.client = service.ClientInfo{
+		IP:        "203.0.113.7",
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	}
+}
+
+// TearDownSuite 清理测试套件
+func (suite *TokenServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *TokenServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	suite.user = &model.User{
+		Username: "token-user",
+		Email:    "token-user@example.com",
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	suite.Require().NoError(suite.db.GetDB().Create(suite.user).Error)
+}
+
+// TestIssue 测试签发令牌对
+func (suite *TokenServiceTestSuite) TestIssue() {
+	pair, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	suite.NotEmpty(pair.AccessToken)
+	suite.NotEmpty(pair.RefreshToken)
+
+	claims, err := suite.tokens.Parse(pair.AccessToken)
+	suite.Require().NoError(err)
+	suite.Equal(suite.user.ID, claims.UserID)
+	suite.Equal(pair.SessionID, claims.SessionID)
+	suite.Equal(pair.AccessTokenID, claims.ID)
+
+	// 数据库只保存刷新令牌的哈希
+	var record model.RefreshToken
+	suite.Require().NoError(suite.db.GetDB().First(&record).Error)
+	suite.Equal(token.Hash(pair.RefreshToken), record.TokenHash)
+	suite.NotEqual(pair.RefreshToken, record.TokenHash)
+
+	// 登录时创建会话
+	sessions, err := suite.service.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 1)
+	suite.Equal("Chrome on macOS", sessions[0].Device)
+	suite.Equal(suite.client.IP, sessions[0].IP)
+	suite.Equal(pair.AccessTokenID, sessions[0].AccessJTI)
+}
+
+// TestRefreshRotation 测试刷新令牌轮换
+func (suite *TokenServiceTestSuite) TestRefreshRotation() {
+	pair, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+
+	rotated, err := suite.service.Refresh(suite.ctx, pair.RefreshToken, suite.client)
+	suite.Require().NoError(err)
+	suite.NotEqual(pair.RefreshToken, rotated.RefreshToken)
+	suite.Equal(suite.user.ID, rotated.User.ID)
+
+	next, err := suite.service.Refresh(suite.ctx, rotated.RefreshToken, service.ClientInfo{IP: "198.51.100.1", UserAgent: "curl/8.0"})
+	suite.Require().NoError(err)
+	suite.NotEmpty(next.RefreshToken)
+	suite.Equal(pair.SessionID, next.SessionID)
+
+	// 轮换后更新会话的访问令牌和客户端信息
+	sessions, err := suite.service.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 1)
+	suite.Equal(next.AccessTokenID, sessions[0].AccessJTI)
+	suite.Equal("198.51.100.1", sessions[0].IP)
+	suite.Equal("curl", sessions[0].Device)
+
+	// 同一家族内的令牌
+	var families int64
+	suite.Require().NoError(suite.db.GetDB().Model(&model.RefreshToken{}).Distinct("family_id").Count(&families).Error)
+	suite.Equal(int64(1), families)
+}
+
+// TestRefreshReuse 测试重复使用已轮换的令牌会撤销整个家族
+func (suite *TokenServiceTestSuite) TestRefreshReuse() {
+	pair, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	other, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+
+	rotated, err := suite.service.Refresh(suite.ctx, pair.RefreshToken, suite.client)
+	suite.Require().NoError(err)
+
+	_, err = suite.service.Refresh(suite.ctx, pair.RefreshToken, suite.client)
+	suite.ErrorIs(err, service.ErrRefreshTokenReused)
+
+	// 攻击者或合法用户手中的最新令牌同样失效
+	_, err = suite.service.Refresh(suite.ctx, rotated.RefreshToken, suite.client)
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+
+	// 其他登录会话不受影响
+	_, err = suite.service.Refresh(suite.ctx, other.RefreshToken, suite.client)
+	suite.NoError(err)
+
+	sessions, err := suite.service.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 1)
+	suite.Equal(other.SessionID, sessions[0].FamilyID)
+	suite.True(suite.revoked(rotated))
+}
+
+// TestRefreshInvalid 测试无效、过期和非活跃用户的令牌
+func (suite *TokenServiceTestSuite) TestRefreshInvalid() {
+	_, err := suite.service.Refresh(suite.ctx, "unknown", suite.client)
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+
+	expired, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.GetDB().Model(&model.RefreshToken{}).
+		Where("token_hash = ?", token.Hash(expired.RefreshToken)).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	_, err = suite.service.Refresh(suite.ctx, expired.RefreshToken, suite.client)
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+
+	inactive, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.GetDB().Model(suite.user).Update("status", model.UserStatusInactive).Error)
+	_, err = suite.service.Refresh(suite.ctx, inactive.RefreshToken, suite.client)
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+}
+
+// revoked 检查访问令牌是否已被加入黑名单
+func (suite *TokenServiceTestSuite) revoked(pair *service.TokenPair) bool {
+	count, err := suite.store.Exists(suite.ctx,
+		token.RevokedTokenKey(pair.AccessTokenID),
+		token.RevokedSessionKey(pair.SessionID))
+	suite.Require().NoError(err)
+	return count > 0
+}
+
+// TestLogout 测试退出登录撤销当前会话
+func (suite *TokenServiceTestSuite) TestLogout() {
+	current, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	other, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.service.Logout(suite.ctx, suite.user.ID, current.SessionID, current.AccessTokenID))
+	suite.True(suite.revoked(current))
+	suite.False(suite.revoked(other))
+
+	_, err = suite.service.Refresh(suite.ctx, current.RefreshToken, suite.client)
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+
+	sessions, err := suite.service.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 1)
+	suite.Equal(other.SessionID, sessions[0].FamilyID)
+
+	// 不能退出其他用户的会话
+	suite.ErrorIs(suite.service.Logout(suite.ctx, suite.user.ID+1, other.SessionID, ""), service.ErrSessionNotFound)
+}
+
+// TestRevokeSession 测试撤销指定会话
+func (suite *TokenServiceTestSuite) TestRevokeSession() {
+	pair, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	sessions, err := suite.service.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 1)
+
+	suite.ErrorIs(suite.service.RevokeSession(suite.ctx, suite.user.ID+1, sessions[0].ID), service.ErrSessionNotFound)
+	suite.Require().NoError(suite.service.RevokeSession(suite.ctx, suite.user.ID, sessions[0].ID))
+	suite.True(suite.revoked(pair))
+
+	// 已撤销的会话不能再次撤销
+	suite.ErrorIs(suite.service.RevokeSession(suite.ctx, suite.user.ID, sessions[0].ID), service.ErrSessionNotFound)
+
+	_, err = suite.service.Refresh(suite.ctx, pair.RefreshToken, suite.client)
+	suite.ErrorIs(err, service.ErrInvalidRefreshToken)
+}
+
+// TestRevokeAllSessions 测试撤销用户所有会话
+func (suite *TokenServiceTestSuite) TestRevokeAllSessions() {
+	first, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	second, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+
+	revoked, err := suite.service.RevokeAllSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal(2, revoked)
+	suite.True(suite.revoked(first))
+	suite.True(suite.revoked(second))
+
+	sessions, err := suite.service.ListSessions(suite.ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Empty(sessions)
+}
+
+// TestCleanupExpired 测试清理过期的刷新令牌
+func (suite *TokenServiceTestSuite) TestCleanupExpired() {
+	_, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	expired, err := suite.service.Issue(suite.ctx, suite.user, suite.client, false)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.GetDB().Model(&model.RefreshToken{}).
+		Where("token_hash = ?", token.Hash(expired.RefreshToken)).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	suite.Require().NoError(suite.db.GetDB().Model(&model.UserSession{}).
+		Where("family_id = ?", expired.SessionID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	deleted, err := suite.service.CleanupExpired(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Equal(int64(2), deleted)
+}
+
+// TestTokenServiceTestSuite 运行令牌服务测试套件
+func TestTokenServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenServiceTestSuite))
+}
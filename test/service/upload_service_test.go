@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/internal/model"
+	"vibe-coding-starter/internal/repository"
+	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/signer"
+	"vibe-coding-starter/pkg/storage"
+	"vibe-coding-starter/test/testutil"
+)
+
+// ResumableUploadServiceTestSuite 断点续传上传服务测试套件
+type ResumableUploadServiceTestSuite struct {
+	suite.Suite
+	db          *testutil.TestDatabase
+	cache       *testutil.TestCache
+	logger      *testutil.TestLogger
+	sessionRepo repository.UploadSessionRepository
+	storage     *storage.Manager
+	fileService service.FileService
+	quota       service.StorageQuotaService
+	config      *config.Config
+	service     service.ResumableUploadService
+	ctx         context.Context
+	owner       *model.User
+}
+
+// SetupSuite 设置测试套件
+func (suite *ResumableUploadServiceTestSuite) SetupSuite() {
+	suite.db = testutil.NewTestDatabase(suite.T())
+	suite.cache = testutil.NewTestCache(suite.T())
+	suite.logger = testutil.NewTestLogger(suite.T())
+	suite.ctx = context.Background()
+
+	testLogger := suite.logger.CreateTestLogger()
+	database := suite.db.CreateTestDatabase()
+
+	local, err := storage.NewLocalStorage(config.LocalStorageConfig{Root: suite.T().TempDir()})
+	suite.Require().NoError(err)
+	suite.storage = storage.NewManager(model.StorageTypeLocal)
+	suite.storage.Register(model.StorageTypeLocal, local)
+
+	cfg := &config.Config{
+		JWT:    config.JWTConfig{Secret: "test-secret"},
+		Upload: config.UploadConfig{ResumableExpiration: 3600},
+	}
+	suite.config = cfg
+	urlSigner, err := signer.New(cfg)
+	suite.Require().NoError(err)
+
+	suite.sessionRepo = repository.NewUploadSessionRepository(database, testLogger)
+	suite.quota = service.NewStorageQuotaService(
+		repository.NewStorageQuotaRepository(database, testLogger),
+		repository.NewUserRepository(database, testLogger),
+		cfg,
+		testLogger,
+	)
+	suite.fileService = service.NewFileService(
+		repository.NewFileRepository(database, testLogger),
+		repository.NewFileBlobRepository(database, testLogger),
+		suite.quota,
+		suite.storage,
+		urlSigner,
+		cfg,
+		suite.cache.CreateTestCache(),
+		testLogger,
+	)
+	suite.service = service.NewResumableUploadService(
+		suite.sessionRepo,
+		suite.fileService,
+		suite.quota,
+		suite.storage,
+		cfg,
+		testLogger,
+	)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *ResumableUploadServiceTestSuite) TearDownSuite() {
+	suite.db.Close()
+	suite.cache.Close()
+	suite.logger.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *ResumableUploadServiceTestSuite) SetupTest() {
+	suite.db.Clean(suite.T())
+	suite.cache.Clean(suite.T())
+
+	suite.owner = &model.User{
+		Username: "uploader",
+		Email:    "uploader@example.com",
+		Password: "password123",
+		Role:     model.UserRoleUser,
+		Status:   model.UserStatusActive,
+	}
+	require.NoError(suite.T(), suite.db.GetDB().Create(suite.owner).Error)
+}
+
+// createSession 创建测试用上传会话
+func (suite *ResumableUploadServiceTestSuite) createSession(size int64) *model.UploadSession {
+	session, err := suite.service.Create(suite.ctx, &service.CreateUploadSessionRequest{
+		FileName: "notes.txt",
+		FileSize: size,
+		MimeType: "text/plain",
+		OwnerID:  suite.owner.ID,
+	})
+	suite.Require().NoError(err)
+	return session
+}
+
+// TestUploadInChunks 测试分片上传并合并为文件
+func (suite *ResumableUploadServiceTestSuite) TestUploadInChunks() {
+	content := "0123456789abcdefghij"
+	session := suite.createSession(int64(len(content)))
+	suite.Equal(model.UploadStatusUploading, session.Status)
+	suite.Equal(model.StorageTypeLocal, session.StorageType)
+
+	updated, err := suite.service.WriteChunk(suite.ctx, suite.owner.ID, session.UploadID, 0, strings.NewReader(content[:8]))
+	suite.Require().NoError(err)
+	suite.Equal(int64(8), updated.UploadedSize)
+
+	// 未上传完成时不能合并
+	_, err = suite.service.Complete(suite.ctx, suite.owner.ID, session.UploadID)
+	suite.ErrorIs(err, service.ErrUploadIncomplete)
+
+	// 偏移量不一致时拒绝写入
+	_, err = suite.service.WriteChunk(suite.ctx, suite.owner.ID, session.UploadID, 4, strings.NewReader(content[4:]))
+	suite.ErrorIs(err, service.ErrUploadOffsetMismatch)
+
+	// 重新查询偏移量后续传
+	current, err := suite.service.Get(suite.ctx, suite.owner.ID, session.UploadID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(8), current.UploadedSize)
+
+	_, err = suite.service.WriteChunk(suite.ctx, suite.owner.ID, session.UploadID, 8, strings.NewReader(content[8:]))
+	suite.Require().NoError(err)
+
+	file, err := suite.service.Complete(suite.ctx, suite.owner.ID, session.UploadID)
+	suite.Require().NoError(err)
+	suite.Equal("notes.txt", file.OriginalName)
+	suite.Equal(int64(len(content)), file.Size)
+	suite.Equal(suite.owner.ID, file.OwnerID)
+
+	download, err := suite.fileService.Download(suite.ctx, file.ID)
+	suite.Require().NoError(err)
+	data, err := io.ReadAll(download.Content)
+	download.Content.Close()
+	suite.Require().NoError(err)
+	suite.Equal(content, string(data))
+
+	// 合并后的文件计入用户已用空间
+	usage, err := suite.quota.GetUsage(suite.ctx, suite.owner.ID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(len(content)), usage.UsedBytes)
+
+	// 分片对象已清理
+	parts, err := suite.sessionRepo.GetParts(suite.ctx, session.ID)
+	suite.Require().NoError(err)
+	driver, _ := suite.storage.Get(model.StorageTypeLocal)
+	for _, part := range parts {
+		_, err := driver.Stat(suite.ctx, part.StorageKey)
+		suite.ErrorIs(err, storage.ErrObjectNotFound)
+	}
+
+	// 已完成的会话不能再次完成
+	_, err = suite.service.Complete(suite.ctx, suite.owner.ID, session.UploadID)
+	suite.ErrorIs(err, service.ErrUploadClosed)
+}
+
+// TestWriteChunkTooLarge 测试超过声明大小的分片
+func (suite *ResumableUploadServiceTestSuite) TestWriteChunkTooLarge() {
+	session := suite.createSession(4)
+
+	_, err := suite.service.WriteChunk(suite.ctx, suite.owner.ID, session.UploadID, 0, strings.NewReader("too large"))
+	suite.ErrorIs(err, service.ErrUploadTooLarge)
+
+	current, err := suite.service.Get(suite.ctx, suite.owner.ID, session.UploadID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(0), current.UploadedSize)
+}
+
+// TestCreateChecksPolicy 测试创建会话时按声明信息校验上传策略
+func (suite *ResumableUploadServiceTestSuite) TestCreateChecksPolicy() {
+	cfg := &config.Config{
+		Upload: config.UploadConfig{
+			ResumableExpiration: 3600,
+			AllowedTypes:        []string{"text/plain"},
+			RoleLimits:          map[string]int64{model.UserRoleUser: 10},
+		},
+	}
+	policyService := service.NewResumableUploadService(suite.sessionRepo, suite.fileService, suite.quota, suite.storage, cfg, suite.logger.CreateTestLogger())
+
+	_, err := policyService.Create(suite.ctx, &service.CreateUploadSessionRequest{
+		FileName:  "movie.mp4",
+		FileSize:  4,
+		MimeType:  "video/mp4",
+		OwnerID:   suite.owner.ID,
+		OwnerRole: model.UserRoleUser,
+	})
+	var policyErr *service.PolicyError
+	suite.Require().ErrorAs(err, &policyErr)
+	suite.Equal(service.PolicyRuleTypeNotAllowed, policyErr.Rule)
+
+	_, err = policyService.Create(suite.ctx, &service.CreateUploadSessionRequest{
+		FileName:  "notes.txt",
+		FileSize:  20,
+		MimeType:  "text/plain",
+		OwnerID:   suite.owner.ID,
+		OwnerRole: model.UserRoleUser,
+	})
+	suite.Require().ErrorAs(err, &policyErr)
+	suite.Equal(service.PolicyRuleRoleMaxSize, policyErr.Rule)
+}
+
+// TestCompleteChecksOwnerRole 测试合并时按创建会话时的角色校验上传策略
+func (suite *ResumableUploadServiceTestSuite) TestCompleteChecksOwnerRole() {
+	suite.config.Upload.RoleLimits = map[string]int64{model.UserRoleUser: 100}
+	defer func() { suite.config.Upload.RoleLimits = nil }()
+
+	content := "0123456789abcdefghij"
+	session, err := suite.service.Create(suite.ctx, &service.CreateUploadSessionRequest{
+		FileName:  "notes.txt",
+		FileSize:  int64(len(content)),
+		MimeType:  "text/plain",
+		OwnerID:   suite.owner.ID,
+		OwnerRole: model.UserRoleUser,
+	})
+	suite.Require().NoError(err)
+
+	stored, err := suite.sessionRepo.GetByUploadID(suite.ctx, session.UploadID)
+	suite.Require().NoError(err)
+	suite.Equal(model.UserRoleUser, stored.OwnerRole)
+
+	_, err = suite.service.WriteChunk(suite.ctx, suite.owner.ID, session.UploadID, 0, strings.NewReader(content))
+	suite.Require().NoError(err)
+
+	// 上传过程中收紧角色限制，合并时按会话记录的角色重新校验
+	suite.config.Upload.RoleLimits[model.UserRoleUser] = 10
+	_, err = suite.service.Complete(suite.ctx, suite.owner.ID, session.UploadID)
+	var policyErr *service.PolicyError
+	suite.Require().ErrorAs(err, &policyErr)
+	suite.Equal(service.PolicyRuleRoleMaxSize, policyErr.Rule)
+
+	suite.config.Upload.RoleLimits[model.UserRoleUser] = 100
+	file, err := suite.service.Complete(suite.ctx, suite.owner.ID, session.UploadID)
+	suite.Require().NoError(err)
+	suite.Equal(int64(len(content)), file.Size)
+}
+
+// TestSessionOwnership 测试不能访问其他用户的会话
+func (suite *ResumableUploadServiceTestSuite) TestSessionOwnership() {
+	session := suite.createSession(4)
+
+	_, err := suite.service.Get(suite.ctx, suite.owner.ID+1, session.UploadID)
+	suite.ErrorIs(err, service.ErrUploadNotFound)
+
+	_, err = suite.service.WriteChunk(suite.ctx, suite.owner.ID+1, session.UploadID, 0, strings.NewReader("data"))
+	suite.ErrorIs(err, service.ErrUploadNotFound)
+}
+
+// TestAbort 测试取消上传
+func (suite *ResumableUploadServiceTestSuite) TestAbort() {
+	session := suite.createSession(8)
+	_, err := suite.service.WriteChunk(suite.ctx, suite.owner.ID, session.UploadID, 0, strings.NewReader("data"))
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.service.Abort(suite.ctx, suite.owner.ID, session.UploadID))
+
+	_, err = suite.service.Get(suite.ctx, suite.owner.ID, session.UploadID)
+	suite.ErrorIs(err, service.ErrUploadNotFound)
+}
+
+// TestCleanupExpired 测试清理过期会话
+func (suite *ResumableUploadServiceTestSuite) TestCleanupExpired() {
+	expired := suite.createSession(8)
+	active := suite.createSession(8)
+
+	_, err := suite.service.WriteChunk(suite.ctx, suite.owner.ID, expired.UploadID, 0, strings.NewReader("data"))
+	suite.Require().NoError(err)
+	parts, err := suite.sessionRepo.GetParts(suite.ctx, expired.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(parts, 1)
+
+	// 将会话过期时间调整到过去
+	suite.Require().NoError(suite.db.GetDB().Model(&model.UploadSession{}).
+		Where("id = ?", expired.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	_, err = suite.service.Get(suite.ctx, suite.owner.ID, expired.UploadID)
+	suite.ErrorIs(err, service.ErrUploadExpired)
+
+	cleaned, err := suite.service.CleanupExpired(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Equal(1, cleaned)
+
+	_, err = suite.service.Get(suite.ctx, suite.owner.ID, expired.UploadID)
+	suite.ErrorIs(err, service.ErrUploadNotFound)
+	_, err = suite.service.Get(suite.ctx, suite.owner.ID, active.UploadID)
+	suite.NoError(err)
+
+	driver, _ := suite.storage.Get(model.StorageTypeLocal)
+	_, err = driver.Stat(suite.ctx, parts[0].StorageKey)
+	suite.ErrorIs(err, storage.ErrObjectNotFound)
+}
+
+// TestResumableUploadServiceTestSuite 运行断点续传上传服务测试套件
+func TestResumableUploadServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ResumableUploadServiceTestSuite))
+}
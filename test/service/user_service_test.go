@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,12 +11,16 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/repository"
 	"vibe-coding-starter/internal/service"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/password"
+	"vibe-coding-starter/pkg/secretbox"
 	"vibe-coding-starter/test/mocks"
 )
 
@@ -23,9 +28,15 @@ import (
 type UserServiceTestSuite struct {
 	suite.Suite
 	userRepo *mocks.MockUserRepository
+	mfaRepo  *mocks.MockMFARepository
+	identity *mocks.MockUserIdentityRepository
+	tokens   *mocks.MockTokenService
+	verifier *mocks.MockEmailVerificationService
+	invites  *mocks.MockInvitationService
 	cache    *mocks.MockCache
 	logger   *mocks.MockLogger
 	config   *config.Config
+	policy   *password.Policy
 	service  service.UserService
 	ctx      context.Context
 }
@@ -33,6 +44,11 @@ type UserServiceTestSuite struct {
 // SetupSuite 设置测试套件
 func (suite *UserServiceTestSuite) SetupSuite() {
 	suite.userRepo = new(mocks.MockUserRepository)
+	suite.mfaRepo = new(mocks.MockMFARepository)
+	suite.identity = new(mocks.MockUserIdentityRepository)
+	suite.tokens = new(mocks.MockTokenService)
+	suite.verifier = new(mocks.MockEmailVerificationService)
+	suite.invites = new(mocks.MockInvitationService)
 	suite.cache = new(mocks.MockCache)
 	suite.logger = new(mocks.MockLogger)
 	suite.ctx = context.Background()
@@ -46,9 +62,26 @@ func (suite *UserServiceTestSuite) SetupSuite() {
 		},
 	}
 
+	secrets, err := secretbox.NewBox(suite.config.JWT.Secret)
+	suite.Require().NoError(err)
+	providers, err := oidc.NewRegistry(nil, nil)
+	suite.Require().NoError(err)
+	suite.policy, err = password.NewPolicy(&config.Config{Password: config.PasswordConfig{
+		Policy: config.PasswordPolicyConfig{RejectUsername: true},
+	}})
+	suite.Require().NoError(err)
+
 	// 创建用户服务
 	suite.service = service.NewUserService(
 		suite.userRepo,
+		suite.mfaRepo,
+		suite.identity,
+		suite.tokens,
+		suite.verifier,
+		suite.invites,
+		secrets,
+		providers,
+		suite.policy,
 		suite.cache,
 		suite.logger,
 		suite.config,
@@ -59,6 +92,13 @@ func (suite *UserServiceTestSuite) SetupSuite() {
 func (suite *UserServiceTestSuite) SetupTest() {
 	// 重置所有mock
 	suite.userRepo.ExpectedCalls = nil
+	suite.mfaRepo.ExpectedCalls = nil
+	suite.identity.ExpectedCalls = nil
+	suite.tokens.ExpectedCalls = nil
+	suite.verifier.ExpectedCalls = nil
+	suite.verifier.Calls = nil
+	suite.invites.ExpectedCalls = nil
+	suite.invites.Calls = nil
 	suite.cache.ExpectedCalls = nil
 	suite.logger.ExpectedCalls = nil
 }
@@ -106,6 +146,94 @@ func (suite *UserServiceTestSuite) TestRegister() {
 	suite.logger.AssertExpectations(suite.T())
 }
 
+// TestRegisterWeakPassword 测试注册时密码不符合策略
+func (suite *UserServiceTestSuite) TestRegisterWeakPassword() {
+	suite.userRepo.Calls = nil
+
+	_, err := suite.service.Register(suite.ctx, &service.RegisterRequest{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "short",
+	})
+	assert.ErrorIs(suite.T(), err, service.ErrPasswordTooShort)
+
+	_, err = suite.service.Register(suite.ctx, &service.RegisterRequest{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "testuser2026",
+	})
+	assert.ErrorIs(suite.T(), err, service.ErrWeakPassword)
+
+	// 密码不符合策略时不查询和创建用户
+	suite.userRepo.AssertNotCalled(suite.T(), "GetByEmail", mock.Anything, mock.Anything)
+	suite.userRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+// TestRegisterRequiresEmailVerification 测试开启邮箱验证时注册
+func (suite *UserServiceTestSuite) TestRegisterRequiresEmailVerification() {
+	suite.config.Auth.RequireEmailVerification = true
+	defer func() { suite.config.Auth.RequireEmailVerification = false }()
+
+	req := &service.RegisterRequest{
+		Username: "pending",
+		Email:    "pending@example.com",
+		Password: "password123",
+	}
+
+	suite.userRepo.On("GetByEmail", suite.ctx, req.Email).Return(nil, gorm.ErrRecordNotFound)
+	suite.userRepo.On("GetByUsername", suite.ctx, req.Username).Return(nil, gorm.ErrRecordNotFound)
+	suite.userRepo.On("Create", suite.ctx, mock.AnythingOfType("*model.User")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.User).ID = 2
+	})
+	suite.verifier.On("Send", suite.ctx, mock.MatchedBy(func(user *model.User) bool {
+		return user.ID == 2
+	})).Return(errors.New("smtp unavailable"))
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	suite.logger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	// 验证邮件发送失败不影响注册
+	user, err := suite.service.Register(suite.ctx, req)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.UserStatusInactive, user.Status)
+	assert.True(suite.T(), user.IsPendingVerification())
+
+	suite.userRepo.AssertExpectations(suite.T())
+	suite.verifier.AssertExpectations(suite.T())
+}
+
+// TestLoginEmailNotVerified 测试邮箱未验证时登录
+func (suite *UserServiceTestSuite) TestLoginEmailNotVerified() {
+	user := &model.User{
+		BaseModel: model.BaseModel{ID: 1},
+		Username:  "pending",
+		Email:     "pending@example.com",
+		Password:  "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // bcrypt hash of "password"
+		Role:      model.UserRoleUser,
+		Status:    model.UserStatusInactive,
+	}
+
+	suite.userRepo.On("GetByUsername", suite.ctx, user.Username).Return(user, nil)
+	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Info", mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Warn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+
+	_, err := suite.service.Login(suite.ctx, &service.LoginRequest{Username: user.Username, Password: "password"})
+	assert.ErrorIs(suite.T(), err, service.ErrEmailNotVerified)
+
+	// 密码错误时不提示邮箱未验证
+	_, err = suite.service.Login(suite.ctx, &service.LoginRequest{Username: user.Username, Password: "wrong"})
+	assert.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, service.ErrEmailNotVerified)
+
+	// 已验证邮箱但被停用的账户
+	verifiedAt := time.Now()
+	user.EmailVerifiedAt = &verifiedAt
+	_, err = suite.service.Login(suite.ctx, &service.LoginRequest{Username: user.Username, Password: "password"})
+	assert.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, service.ErrEmailNotVerified)
+}
+
 // TestRegisterDuplicateEmail 测试注册重复邮箱
 func (suite *UserServiceTestSuite) TestRegisterDuplicateEmail() {
 	req := &service.RegisterRequest{
@@ -191,6 +319,15 @@ func (suite *UserServiceTestSuite) TestLogin() {
 	// Mock 更新最后登录时间
 	suite.userRepo.On("UpdateLastLogin", suite.ctx, user.ID).Return(nil)
 
+	// Mock 签发令牌
+	suite.tokens.On("Issue", suite.ctx, user, service.ClientInfo{}, false).Return(&service.TokenPair{
+		User:             user,
+		AccessToken:      "access-token",
+		AccessExpiresAt:  time.Now().Add(15 * time.Minute),
+		RefreshToken:     "refresh-token",
+		RefreshExpiresAt: time.Now().Add(30 * 24 * time.Hour),
+	}, nil)
+
 	// Mock 日志 - 匹配展开后的参数
 	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
 	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
@@ -202,15 +339,90 @@ func (suite *UserServiceTestSuite) TestLogin() {
 	// 验证结果
 	require.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), response)
-	assert.NotEmpty(suite.T(), response.Token)
+	assert.Equal(suite.T(), "access-token", response.Token)
+	assert.Equal(suite.T(), "refresh-token", response.RefreshToken)
+	assert.Equal(suite.T(), "Bearer", response.TokenType)
+	assert.Equal(suite.T(), int64(900), response.ExpiresIn)
 	assert.NotNil(suite.T(), response.User)
 	assert.Equal(suite.T(), user.ID, response.User.ID)
 
 	// 验证mock调用
 	suite.userRepo.AssertExpectations(suite.T())
+	suite.tokens.AssertExpectations(suite.T())
 	suite.logger.AssertExpectations(suite.T())
 }
 
+// TestLoginRehashesPassword 测试登录成功后将旧算法的哈希更新为当前配置
+func (suite *UserServiceTestSuite) TestLoginRehashesPassword() {
+	model.SetPasswordHasher(password.NewArgon2idHasher(password.Argon2Params{
+		Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32,
+	}))
+	defer model.SetPasswordHasher(password.NewBcryptHasher(bcrypt.DefaultCost))
+	suite.userRepo.Calls = nil
+
+	user := &model.User{
+		BaseModel: model.BaseModel{ID: 1},
+		Username:  "testuser",
+		Email:     "test@example.com",
+		Password:  "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // bcrypt hash of "password"
+		Role:      model.UserRoleUser,
+		Status:    model.UserStatusActive,
+	}
+
+	suite.userRepo.On("GetByUsername", suite.ctx, user.Username).Return(user, nil)
+	suite.userRepo.On("UpdatePassword", suite.ctx, user.ID, mock.MatchedBy(func(hashed string) bool {
+		return strings.HasPrefix(hashed, "$argon2id$") && (&model.User{Password: hashed}).CheckPassword("password")
+	})).Return(nil).Once()
+	suite.userRepo.On("UpdateLastLogin", suite.ctx, user.ID).Return(nil)
+	suite.tokens.On("Issue", suite.ctx, user, service.ClientInfo{}, false).Return(&service.TokenPair{User: user}, nil)
+	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Info", mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+
+	_, err := suite.service.Login(suite.ctx, &service.LoginRequest{Username: user.Username, Password: "password"})
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), user.PasswordNeedsRehash())
+
+	// 哈希已是当前参数时不再更新
+	_, err = suite.service.Login(suite.ctx, &service.LoginRequest{Username: user.Username, Password: "password"})
+	require.NoError(suite.T(), err)
+	suite.userRepo.AssertNumberOfCalls(suite.T(), "UpdatePassword", 1)
+}
+
+// TestLoginMFARequired 测试开启两步验证的用户登录
+func (suite *UserServiceTestSuite) TestLoginMFARequired() {
+	user := &model.User{
+		BaseModel:  model.BaseModel{ID: 1},
+		Username:   "mfauser",
+		Email:      "mfa@example.com",
+		Password:   "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // bcrypt hash of "password"
+		Role:       model.UserRoleAdmin,
+		Status:     model.UserStatusActive,
+		MFAEnabled: true,
+	}
+
+	suite.userRepo.On("GetByUsername", suite.ctx, user.Username).Return(user, nil)
+	suite.cache.On("Set", suite.ctx, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "mfa_pending:")
+	}), "1", 5*time.Minute).Return(nil)
+	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Debug", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+	suite.logger.On("Info", mock.Anything, mock.Anything, mock.Anything).Maybe().Return()
+
+	// 密码正确时只返回登录第二步令牌，不签发访问令牌
+	response, err := suite.service.Login(suite.ctx, &service.LoginRequest{Username: user.Username, Password: "password"})
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), response.MFARequired)
+	assert.NotEmpty(suite.T(), response.MFAToken)
+	assert.Empty(suite.T(), response.Token)
+	assert.Empty(suite.T(), response.RefreshToken)
+	assert.Nil(suite.T(), response.User)
+
+	suite.userRepo.AssertExpectations(suite.T())
+	suite.cache.AssertExpectations(suite.T())
+}
+
 // TestLoginInvalidUsername 测试登录无效用户名
 func (suite *UserServiceTestSuite) TestLoginInvalidUsername() {
 	req := &service.LoginRequest{
@@ -385,8 +597,10 @@ func (suite *UserServiceTestSuite) TestChangePassword() {
 	// Mock 获取用户
 	suite.userRepo.On("GetByID", suite.ctx, userID).Return(user, nil)
 
-	// Mock 更新用户
-	suite.userRepo.On("Update", suite.ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	// Mock 更新密码，保存的必须是新密码的哈希
+	suite.userRepo.On("UpdatePassword", suite.ctx, userID, mock.MatchedBy(func(hashed string) bool {
+		return (&model.User{Password: hashed}).CheckPassword("newpassword123")
+	})).Return(nil)
 
 	// Mock 日志
 	suite.logger.On("Info", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return()
@@ -437,6 +651,29 @@ func (suite *UserServiceTestSuite) TestChangePasswordWrongOldPassword() {
 	suite.logger.AssertExpectations(suite.T())
 }
 
+// TestChangePasswordWeakPassword 测试新密码不符合策略
+func (suite *UserServiceTestSuite) TestChangePasswordWeakPassword() {
+	suite.userRepo.Calls = nil
+
+	userID := uint(1)
+	user := &model.User{
+		BaseModel: model.BaseModel{ID: userID},
+		Username:  "testuser",
+		Email:     "test@example.com",
+		Password:  "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // bcrypt hash of "password"
+		Role:      model.UserRoleUser,
+		Status:    model.UserStatusActive,
+	}
+	suite.userRepo.On("GetByID", suite.ctx, userID).Return(user, nil)
+
+	err := suite.service.ChangePassword(suite.ctx, userID, &service.ChangePasswordRequest{
+		OldPassword: "password",
+		NewPassword: "my-testuser-password",
+	})
+	assert.ErrorIs(suite.T(), err, service.ErrWeakPassword)
+	suite.userRepo.AssertNotCalled(suite.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
 // TestGetUsers 测试获取用户列表
 func (suite *UserServiceTestSuite) TestGetUsers() {
 	users := []*model.User{
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/signer"
+)
+
+func TestSigner(t *testing.T) {
+	oldKey := config.SigningKey{ID: "2025-01", Secret: "old-secret"}
+	newKey := config.SigningKey{ID: "2025-06", Secret: "new-secret"}
+	now := time.Now()
+
+	t.Run("Sign And Verify", func(t *testing.T) {
+		s, err := signer.NewSigner("", []config.SigningKey{oldKey})
+		require.NoError(t, err)
+		assert.Equal(t, oldKey.ID, s.ActiveKey())
+
+		values := s.Sign("file:1", now.Add(time.Minute))
+		assert.NoError(t, s.Verify("file:1", values, now))
+		assert.ErrorIs(t, s.Verify("file:2", values, now), signer.ErrInvalidSignature)
+		assert.ErrorIs(t, s.Verify("file:1", values, now.Add(2*time.Minute)), signer.ErrSignatureExpired)
+
+		values.Del(signer.QuerySignature)
+		assert.ErrorIs(t, s.Verify("file:1", values, now), signer.ErrInvalidSignature)
+	})
+
+	t.Run("Key Rotation", func(t *testing.T) {
+		before, err := signer.NewSigner(oldKey.ID, []config.SigningKey{oldKey})
+		require.NoError(t, err)
+		issued := before.Sign("file:1", now.Add(time.Hour))
+
+		// 切换到新密钥后，旧密钥签发的链接仍然有效
+		rotated, err := signer.NewSigner(newKey.ID, []config.SigningKey{newKey, oldKey})
+		require.NoError(t, err)
+		assert.NoError(t, rotated.Verify("file:1", issued, now))
+		assert.Equal(t, newKey.ID, rotated.Sign("file:1", now.Add(time.Hour)).Get(signer.QueryKeyID))
+
+		// 移除旧密钥后，旧链接失效
+		retired, err := signer.NewSigner(newKey.ID, []config.SigningKey{newKey})
+		require.NoError(t, err)
+		assert.ErrorIs(t, retired.Verify("file:1", issued, now), signer.ErrInvalidSignature)
+
+		// 伪造密钥 ID 无效
+		forged := rotated.Sign("file:1", now.Add(time.Hour))
+		forged.Set(signer.QueryKeyID, oldKey.ID)
+		assert.ErrorIs(t, rotated.Verify("file:1", forged, now), signer.ErrInvalidSignature)
+	})
+
+	t.Run("Invalid Config", func(t *testing.T) {
+		_, err := signer.NewSigner("missing", []config.SigningKey{oldKey})
+		assert.Error(t, err)
+
+		_, err = signer.NewSigner("", []config.SigningKey{oldKey, oldKey})
+		assert.Error(t, err)
+
+		// 未配置密钥时从 JWT 密钥派生
+		s, err := signer.New(&config.Config{JWT: config.JWTConfig{Secret: "jwt-secret"}})
+		require.NoError(t, err)
+		assert.NoError(t, s.Verify("file:1", s.Sign("file:1", now.Add(time.Minute)), now))
+	})
+}
@@ -0,0 +1,265 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/storage"
+	"vibe-coding-starter/test/testutil"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	driver, err := storage.NewLocalStorage(config.LocalStorageConfig{Root: root, BaseURL: "/files/"})
+	require.NoError(t, err)
+
+	t.Run("Put Get Stat Delete", func(t *testing.T) {
+		err := driver.Put(ctx, "docs/readme.txt", strings.NewReader("hello"), 5, "text/plain")
+		require.NoError(t, err)
+
+		reader, err := driver.Get(ctx, "docs/readme.txt")
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+
+		info, err := driver.Stat(ctx, "docs/readme.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), info.Size)
+
+		assert.Equal(t, "/files/docs/readme.txt", driver.URL("docs/readme.txt"))
+
+		require.NoError(t, driver.Delete(ctx, "docs/readme.txt"))
+		_, err = driver.Get(ctx, "docs/readme.txt")
+		assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+
+		// 删除不存在的对象不报错
+		assert.NoError(t, driver.Delete(ctx, "docs/readme.txt"))
+	})
+
+	t.Run("Keys Stay Inside Root", func(t *testing.T) {
+		err := driver.Put(ctx, "../escape.txt", strings.NewReader("x"), 1, "text/plain")
+		require.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(root, "escape.txt"))
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(filepath.Dir(root), "escape.txt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		listRoot := t.TempDir()
+		lister, err := storage.NewLocalStorage(config.LocalStorageConfig{Root: listRoot})
+		require.NoError(t, err)
+
+		for _, key := range []string{"a.txt", "variants/abc/thumb.jpg", "variants/def/thumb.jpg", "resumable/x/0001"} {
+			require.NoError(t, lister.Put(ctx, key, strings.NewReader("data"), 4, ""))
+		}
+		// 写入中的临时文件不会被列出
+		require.NoError(t, os.WriteFile(filepath.Join(listRoot, ".tmp-123"), []byte("x"), 0644))
+
+		var keys []string
+		require.NoError(t, lister.List(ctx, "", func(info *storage.ObjectInfo) error {
+			keys = append(keys, info.Key)
+			return nil
+		}))
+		assert.ElementsMatch(t, []string{"a.txt", "variants/abc/thumb.jpg", "variants/def/thumb.jpg", "resumable/x/0001"}, keys)
+
+		keys = nil
+		require.NoError(t, lister.List(ctx, "variants/", func(info *storage.ObjectInfo) error {
+			keys = append(keys, info.Key)
+			assert.Equal(t, int64(4), info.Size)
+			return nil
+		}))
+		assert.ElementsMatch(t, []string{"variants/abc/thumb.jpg", "variants/def/thumb.jpg"}, keys)
+	})
+
+	t.Run("Legacy Upload Paths", func(t *testing.T) {
+		// 早期版本把文件写在 uploads/<name>，files.path 也记录为 "uploads/<name>"
+		require.NoError(t, os.WriteFile(filepath.Join(root, "1700000000_legacy.txt"), []byte("legacy"), 0644))
+
+		reader, err := driver.Get(ctx, "uploads/1700000000_legacy.txt")
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "legacy", string(data))
+
+		info, err := driver.Stat(ctx, "uploads/1700000000_legacy.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), info.Size)
+
+		require.NoError(t, driver.Delete(ctx, "uploads/1700000000_legacy.txt"))
+		_, err = os.Stat(filepath.Join(root, "1700000000_legacy.txt"))
+		assert.True(t, os.IsNotExist(err))
+
+		// 真正位于 uploads 子目录下的对象优先
+		require.NoError(t, driver.Put(ctx, "uploads/nested.txt", strings.NewReader("nested"), 6, "text/plain"))
+		info, err = driver.Stat(ctx, "uploads/nested.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), info.Size)
+	})
+
+	t.Run("Signed URL Not Supported", func(t *testing.T) {
+		_, err := driver.SignedURL(ctx, "docs/readme.txt", time.Minute)
+		assert.ErrorIs(t, err, storage.ErrNotSupported)
+	})
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	server := testutil.NewFakeS3Server(t)
+
+	driver, err := storage.NewS3Storage(server.Config())
+	require.NoError(t, err)
+
+	t.Run("Put Get Stat Delete", func(t *testing.T) {
+		err := driver.Put(ctx, "2024/01/photo one.jpg", strings.NewReader("image-bytes"), 11, "image/jpeg")
+		require.NoError(t, err)
+
+		stored, exists := server.Object("2024/01/photo one.jpg")
+		require.True(t, exists)
+		assert.Equal(t, "image-bytes", string(stored))
+
+		reader, err := driver.Get(ctx, "2024/01/photo one.jpg")
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "image-bytes", string(data))
+
+		info, err := driver.Stat(ctx, "2024/01/photo one.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), info.Size)
+		assert.Equal(t, "image/jpeg", info.ContentType)
+		assert.NotEmpty(t, info.ETag)
+
+		require.NoError(t, driver.Delete(ctx, "2024/01/photo one.jpg"))
+		_, err = driver.Stat(ctx, "2024/01/photo one.jpg")
+		assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+	})
+
+	t.Run("Get Seek", func(t *testing.T) {
+		require.NoError(t, driver.Put(ctx, "seek.txt", strings.NewReader("0123456789"), 10, "text/plain"))
+
+		reader, err := driver.Get(ctx, "seek.txt")
+		require.NoError(t, err)
+		defer reader.Close()
+
+		size, err := reader.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), size)
+
+		_, err = reader.Seek(4, io.SeekStart)
+		require.NoError(t, err)
+		buf := make([]byte, 3)
+		_, err = io.ReadFull(reader, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "456", string(buf))
+
+		rest, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "789", string(rest))
+	})
+
+	t.Run("Put Unknown Size", func(t *testing.T) {
+		err := driver.Put(ctx, "stream.bin", strings.NewReader("streamed"), -1, "")
+		require.NoError(t, err)
+
+		stored, exists := server.Object("stream.bin")
+		require.True(t, exists)
+		assert.Equal(t, "streamed", string(stored))
+	})
+
+	t.Run("Signed URL", func(t *testing.T) {
+		require.NoError(t, driver.Put(ctx, "signed.txt", strings.NewReader("secret"), 6, "text/plain"))
+
+		signedURL, err := driver.SignedURL(ctx, "signed.txt", time.Minute)
+		require.NoError(t, err)
+
+		resp, err := http.Get(signedURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "secret", string(body))
+	})
+
+	t.Run("List Paginated", func(t *testing.T) {
+		listServer := testutil.NewFakeS3Server(t)
+		listServer.ListPageSize = 2
+		lister, err := storage.NewS3Storage(listServer.Config())
+		require.NoError(t, err)
+
+		for _, key := range []string{"a.txt", "b.txt", "dir/c.txt", "dir/d e.txt", "other.txt"} {
+			require.NoError(t, lister.Put(ctx, key, strings.NewReader("data"), 4, "text/plain"))
+		}
+
+		var keys []string
+		require.NoError(t, lister.List(ctx, "", func(info *storage.ObjectInfo) error {
+			keys = append(keys, info.Key)
+			assert.Equal(t, int64(4), info.Size)
+			assert.False(t, info.LastModified.IsZero())
+			return nil
+		}))
+		assert.Equal(t, []string{"a.txt", "b.txt", "dir/c.txt", "dir/d e.txt", "other.txt"}, keys)
+
+		keys = nil
+		require.NoError(t, lister.List(ctx, "dir/", func(info *storage.ObjectInfo) error {
+			keys = append(keys, info.Key)
+			return nil
+		}))
+		assert.Equal(t, []string{"dir/c.txt", "dir/d e.txt"}, keys)
+	})
+
+	t.Run("Wrong Credentials Rejected", func(t *testing.T) {
+		cfg := server.Config()
+		cfg.SecretKey = "wrong-secret"
+		badDriver, err := storage.NewS3Storage(cfg)
+		require.NoError(t, err)
+
+		err = badDriver.Put(ctx, "denied.txt", strings.NewReader("x"), 1, "text/plain")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "SignatureDoesNotMatch")
+	})
+}
+
+func TestStorageManager(t *testing.T) {
+	server := testutil.NewFakeS3Server(t)
+	testLogger := testutil.NewTestLogger(t).CreateTestLogger()
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			Default: "s3",
+			Local:   config.LocalStorageConfig{Root: t.TempDir()},
+			S3:      server.Config(),
+		},
+	}
+
+	manager, err := storage.New(cfg, testLogger)
+	require.NoError(t, err)
+	assert.Equal(t, "s3", manager.DefaultName())
+	assert.Equal(t, []string{"local", "s3"}, manager.Names())
+
+	_, err = manager.Get("oss")
+	assert.Error(t, err)
+
+	// 默认驱动未配置时创建失败
+	cfg.Storage.Default = "oss"
+	_, err = storage.New(cfg, testLogger)
+	assert.Error(t, err)
+}
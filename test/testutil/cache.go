@@ -3,6 +3,7 @@ package testutil
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -114,9 +115,20 @@ func (tca *testCacheAdapter) TTL(ctx context.Context, key string) (time.Duration
 	return tca.client.TTL(ctx, key).Result()
 }
 
-// Incr 递增
-func (tca *testCacheAdapter) Incr(ctx context.Context, key string) (int64, error) {
-	return tca.client.Incr(ctx, key).Result()
+// Incr 原子递增计数并重置过期时间
+func (tca *testCacheAdapter) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := tca.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		if expiration > 0 {
+			pipe.Expire(ctx, key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
 }
 
 // Decr 递减
@@ -284,6 +296,32 @@ func (mc *memoryCache) TTL(ctx context.Context, key string) (time.Duration, erro
 	return ttl, nil
 }
 
+// Incr 原子递增计数并重置过期时间
+func (mc *memoryCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	var count int64
+	if item, exists := mc.data[key]; exists && (item.expiration.IsZero() || time.Now().Before(item.expiration)) {
+		n, err := strconv.ParseInt(item.value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value is not an integer: %s", key)
+		}
+		count = n
+	}
+	count++
+
+	var exp time.Time
+	if expiration > 0 {
+		exp = time.Now().Add(expiration)
+	}
+	mc.data[key] = cacheItem{
+		value:      strconv.FormatInt(count, 10),
+		expiration: exp,
+	}
+	return count, nil
+}
+
 // Health 健康检查
 func (mc *memoryCache) Health() error {
 	return nil // 内存缓存总是健康的
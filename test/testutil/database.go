@@ -71,9 +71,25 @@ func (td *TestDatabase) Migrate(t *testing.T) {
 		&model.Article{},
 		&model.Comment{},
 		&model.File{},
+		&model.FileBlob{},
 		&model.DictCategory{},
 		&model.DictItem{},
 		&model.Department{},
+		&model.UploadSession{},
+		&model.UploadPart{},
+		&model.StorageQuota{},
+		&model.RefreshToken{},
+		&model.UserSession{},
+		&model.OneTimeToken{},
+		&model.UserMFA{},
+		&model.MFARecoveryCode{},
+		&model.UserIdentity{},
+		&model.APIKey{},
+		&model.Role{},
+		&model.Permission{},
+		&model.UserRole{},
+		&model.AuditLog{},
+		&model.Invitation{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
@@ -84,9 +100,26 @@ func (td *TestDatabase) Migrate(t *testing.T) {
 func (td *TestDatabase) Clean(t *testing.T) {
 	// 按依赖关系顺序删除数据
 	tables := []string{
+		"audit_logs",
+		"upload_parts",
+		"upload_sessions",
+		"storage_quotas",
+		"refresh_tokens",
+		"user_sessions",
+		"one_time_tokens",
+		"mfa_recovery_codes",
+		"user_mfa",
+		"user_identities",
+		"api_keys",
+		"invitations",
+		"user_roles",
+		"role_permissions",
+		"permissions",
+		"roles",
 		"article_tags",
 		"comments",
 		"files",
+		"file_blobs",
 		"articles",
 		"tags",
 		"categories",
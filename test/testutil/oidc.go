@@ -0,0 +1,250 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/oidc"
+)
+
+// FakeOIDCUser 模拟提供方当前登录的用户
+type FakeOIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// FakeOIDCProvider 本地运行的 OpenID Connect 提供方，支持授权码、PKCE 和 JWKS，用于离线测试第三方登录
+type FakeOIDCProvider struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	KeyID        string
+
+	mutex sync.Mutex
+	key   *rsa.PrivateKey
+	user  FakeOIDCUser
+	codes map[string]fakeOIDCCode
+}
+
+type fakeOIDCCode struct {
+	user          FakeOIDCUser
+	nonce         string
+	redirectURI   string
+	codeChallenge string
+}
+
+// NewFakeOIDCProvider 创建并启动模拟提供方
+func NewFakeOIDCProvider(t *testing.T) *FakeOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate oidc signing key: %v", err)
+	}
+
+	provider := &FakeOIDCProvider{
+		ClientID:     "test-client",
+		ClientSecret: "test-client-secret",
+		RedirectURL:  "http://localhost/api/v1/users/oauth/mock/callback",
+		KeyID:        "test-key",
+		key:          key,
+		codes:        make(map[string]fakeOIDCCode),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.handleDiscovery)
+	mux.HandleFunc("/authorize", provider.handleAuthorize)
+	mux.HandleFunc("/token", provider.handleToken)
+	mux.HandleFunc("/jwks", provider.handleJWKS)
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+
+	return provider
+}
+
+// Config 获取连接模拟提供方的配置
+func (p *FakeOIDCProvider) Config(name string) config.OAuthProviderConfig {
+	return config.OAuthProviderConfig{
+		Name:         name,
+		DisplayName:  "Mock SSO",
+		Issuer:       p.URL,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  p.RedirectURL,
+	}
+}
+
+// SetUser 设置后续授权请求登录的用户
+func (p *FakeOIDCProvider) SetUser(user FakeOIDCUser) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.user = user
+}
+
+// Authorize 模拟浏览器访问授权地址并同意授权，返回回调中的 code 和 state
+func (p *FakeOIDCProvider) Authorize(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Failed to request authorization url: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Authorization request failed with status %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Invalid authorization redirect: %v", err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+// SignIDToken 使用提供方密钥签发任意声明的 ID 令牌
+func (p *FakeOIDCProvider) SignIDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.KeyID
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// IDTokenClaims 生成有效的 ID 令牌声明
+func (p *FakeOIDCProvider) IDTokenClaims(user FakeOIDCUser, nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            p.URL,
+		"sub":            user.Subject,
+		"aud":            p.ClientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	}
+}
+
+func (p *FakeOIDCProvider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/authorize",
+		"token_endpoint":                        p.URL + "/token",
+		"jwks_uri":                              p.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *FakeOIDCProvider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != p.ClientID ||
+		query.Get("redirect_uri") != p.RedirectURL || query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code, err := oidc.NewCodeVerifier()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.mutex.Lock()
+	p.codes[code] = fakeOIDCCode{
+		user:          p.user,
+		nonce:         query.Get("nonce"),
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	p.mutex.Unlock()
+
+	callback := url.Values{}
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	http.Redirect(w, r, p.RedirectURL+"?"+callback.Encode(), http.StatusFound)
+}
+
+func (p *FakeOIDCProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+	if !ok || clientID != p.ClientID || clientSecret != p.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// 授权码只能使用一次
+	p.mutex.Lock()
+	code, exists := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mutex.Unlock()
+
+	if !exists || code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != code.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.SignIDToken(p.IDTokenClaims(code.user, code.nonce)),
+	})
+}
+
+func (p *FakeOIDCProvider) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	public := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": p.KeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
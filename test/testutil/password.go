@@ -0,0 +1,17 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/password"
+)
+
+// NewTestPasswordPolicy 创建只检查默认最小长度的测试密码策略
+func NewTestPasswordPolicy(t *testing.T) *password.Policy {
+	policy, err := password.NewPolicy(&config.Config{})
+	require.NoError(t, err)
+	return policy
+}
@@ -0,0 +1,329 @@
+package testutil
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"vibe-coding-starter/internal/config"
+)
+
+// FakeS3Server 内存实现的 S3 兼容服务，用于替代 MinIO 测试 S3 驱动
+type FakeS3Server struct {
+	*httptest.Server
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// ListPageSize 每页返回的最大对象数，用于测试分页遍历
+	ListPageSize int
+
+	mutex   sync.RWMutex
+	objects map[string]fakeS3Object
+}
+
+type fakeS3Object struct {
+	data         []byte
+	contentType  string
+	etag         string
+	lastModified time.Time
+}
+
+// NewFakeS3Server 创建并启动模拟 S3 服务
+func NewFakeS3Server(t *testing.T) *FakeS3Server {
+	server := &FakeS3Server{
+		Bucket:       "test-bucket",
+		Region:       "us-east-1",
+		AccessKey:    "test-access-key",
+		SecretKey:    "test-secret-key",
+		ListPageSize: 1000,
+		objects:      make(map[string]fakeS3Object),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// Config 获取连接模拟服务的存储配置
+func (s *FakeS3Server) Config() config.S3StorageConfig {
+	return config.S3StorageConfig{
+		Endpoint:     s.URL,
+		Region:       s.Region,
+		Bucket:       s.Bucket,
+		AccessKey:    s.AccessKey,
+		SecretKey:    s.SecretKey,
+		UsePathStyle: true,
+	}
+}
+
+// Object 获取已存储对象的内容
+func (s *FakeS3Server) Object(key string) ([]byte, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	obj, exists := s.objects[key]
+	return obj.data, exists
+}
+
+func (s *FakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	if err := s.verify(r); err != nil {
+		s.writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	prefix := "/" + s.Bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		s.writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	if key == "" && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+		s.list(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		if r.ContentLength >= 0 && int64(len(data)) != r.ContentLength {
+			s.writeError(w, http.StatusBadRequest, "IncompleteBody", "body length mismatch")
+			return
+		}
+		sum := md5.Sum(data)
+		etag := hex.EncodeToString(sum[:])
+
+		s.mutex.Lock()
+		s.objects[key] = fakeS3Object{
+			data:         data,
+			contentType:  r.Header.Get("Content-Type"),
+			etag:         etag,
+			lastModified: time.Now().UTC(),
+		}
+		s.mutex.Unlock()
+
+		w.Header().Set("ETag", `"`+etag+`"`)
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodGet, http.MethodHead:
+		s.mutex.RLock()
+		obj, exists := s.objects[key]
+		s.mutex.RUnlock()
+		if !exists {
+			s.writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist")
+			return
+		}
+
+		w.Header().Set("ETag", `"`+obj.etag+`"`)
+		w.Header().Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+		if obj.contentType != "" {
+			w.Header().Set("Content-Type", obj.contentType)
+		}
+		// 仅支持 bytes=N- 与 bytes=N-M 形式的范围请求
+		data, status := obj.data, http.StatusOK
+		if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+			var start, end int
+			spec := strings.TrimPrefix(rangeHeader, "bytes=")
+			if strings.HasSuffix(spec, "-") {
+				start, _ = strconv.Atoi(strings.TrimSuffix(spec, "-"))
+				end = len(obj.data) - 1
+			} else if _, err := fmt.Sscanf(spec, "%d-%d", &start, &end); err != nil {
+				s.writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "invalid range")
+				return
+			}
+			if start < 0 || start >= len(obj.data) || end < start {
+				s.writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "invalid range")
+				return
+			}
+			if end >= len(obj.data) {
+				end = len(obj.data) - 1
+			}
+			data, status = obj.data[start:end+1], http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(obj.data)))
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+
+	case http.MethodDelete:
+		s.mutex.Lock()
+		delete(s.objects, key)
+		s.mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	}
+}
+
+// list 按键名顺序返回对象列表，continuation-token 为上一页最后一个键
+func (s *FakeS3Server) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	after := query.Get("continuation-token")
+
+	s.mutex.RLock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	truncated := len(keys) > s.ListPageSize
+	if truncated {
+		keys = keys[:s.ListPageSize]
+	}
+
+	var body strings.Builder
+	body.WriteString("<ListBucketResult>")
+	fmt.Fprintf(&body, "<IsTruncated>%t</IsTruncated>", truncated)
+	if truncated {
+		fmt.Fprintf(&body, "<NextContinuationToken>%s</NextContinuationToken>", keys[len(keys)-1])
+	}
+	for _, key := range keys {
+		obj := s.objects[key]
+		fmt.Fprintf(&body, "<Contents><Key>%s</Key><Size>%d</Size><ETag>&quot;%s&quot;</ETag><LastModified>%s</LastModified></Contents>",
+			key, len(obj.data), obj.etag, obj.lastModified.Format(time.RFC3339))
+	}
+	body.WriteString("</ListBucketResult>")
+	s.mutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, body.String())
+}
+
+// verify 校验请求头签名或预签名查询参数
+func (s *FakeS3Server) verify(r *http.Request) error {
+	query := r.URL.Query()
+
+	var (
+		amzDate       string
+		signedHeaders []string
+		signature     string
+		payloadHash   string
+	)
+
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		fields := strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ", ")
+		values := make(map[string]string)
+		for _, field := range fields {
+			if kv := strings.SplitN(field, "=", 2); len(kv) == 2 {
+				values[kv[0]] = kv[1]
+			}
+		}
+		if !strings.HasPrefix(values["Credential"], s.AccessKey+"/") {
+			return fmt.Errorf("invalid access key")
+		}
+		amzDate = r.Header.Get("X-Amz-Date")
+		signedHeaders = strings.Split(values["SignedHeaders"], ";")
+		signature = values["Signature"]
+		payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+	} else if query.Get("X-Amz-Signature") != "" {
+		if !strings.HasPrefix(query.Get("X-Amz-Credential"), s.AccessKey+"/") {
+			return fmt.Errorf("invalid access key")
+		}
+		amzDate = query.Get("X-Amz-Date")
+		signedHeaders = strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+		signature = query.Get("X-Amz-Signature")
+		payloadHash = "UNSIGNED-PAYLOAD"
+
+		signedAt, err := time.Parse("20060102T150405Z", amzDate)
+		if err != nil {
+			return fmt.Errorf("invalid date")
+		}
+		expires, _ := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if time.Now().After(signedAt.Add(time.Duration(expires) * time.Second)) {
+			return fmt.Errorf("request has expired")
+		}
+		query.Del("X-Amz-Signature")
+	} else {
+		return fmt.Errorf("missing authentication")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		fakeS3CanonicalQuery(query),
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	if len(amzDate) < 8 {
+		return fmt.Errorf("invalid date")
+	}
+	scope := amzDate[:8] + "/" + s.Region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := fakeS3HMAC([]byte("AWS4"+s.SecretKey), amzDate[:8])
+	key = fakeS3HMAC(key, s.Region)
+	key = fakeS3HMAC(key, "s3")
+	key = fakeS3HMAC(key, "aws4_request")
+	expected := hex.EncodeToString(fakeS3HMAC(key, stringToSign))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (s *FakeS3Server) writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
+
+func fakeS3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func fakeS3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, strings.ReplaceAll(url.QueryEscape(key), "+", "%20")+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,119 @@
+package testutil
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// SMTPMessage 假 SMTP 服务收到的邮件
+type SMTPMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// FakeSMTPServer 只支持明文连接的最小 SMTP 服务，用于测试 SMTP 邮件发送器
+type FakeSMTPServer struct {
+	listener net.Listener
+
+	mutex    sync.Mutex
+	messages []SMTPMessage
+}
+
+// NewFakeSMTPServer 启动假 SMTP 服务，测试结束时自动关闭
+func NewFakeSMTPServer(t *testing.T) *FakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start fake smtp server: %v", err)
+	}
+
+	s := &FakeSMTPServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+// Host 监听地址
+func (s *FakeSMTPServer) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port 监听端口
+func (s *FakeSMTPServer) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Messages 获取已收到的邮件
+func (s *FakeSMTPServer) Messages() []SMTPMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]SMTPMessage(nil), s.messages...)
+}
+
+func (s *FakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *FakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP fake")
+	var msg SMTPMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg = SMTPMessage{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.To = append(msg.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			msg.Data = data.String()
+
+			s.mutex.Lock()
+			s.messages = append(s.messages, msg)
+			s.mutex.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
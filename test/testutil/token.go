@@ -0,0 +1,20 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/internal/config"
+	"vibe-coding-starter/pkg/token"
+)
+
+// TestJWTSecret 测试使用的 JWT 密钥
+const TestJWTSecret = "test-secret"
+
+// NewTestTokenManager 创建测试令牌管理器
+func NewTestTokenManager(t *testing.T) *token.Manager {
+	tokens, err := token.New(&config.Config{JWT: config.JWTConfig{Secret: TestJWTSecret}})
+	require.NoError(t, err)
+	return tokens
+}
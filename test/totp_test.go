@@ -0,0 +1,109 @@
+package test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vibe-coding-starter/pkg/secretbox"
+	"vibe-coding-starter/pkg/totp"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 附录 B 的 SHA1 测试向量
+	seed := []byte("12345678901234567890")
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(seed)
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	t.Run("RFC 6238 Vectors", func(t *testing.T) {
+		for _, v := range vectors {
+			at := time.Unix(v.unix, 0)
+			assert.Equal(t, v.code, totp.HOTP(seed, uint64(totp.Step(at)), 8), "time %d", v.unix)
+
+			// 6 位验证码是 8 位结果的后 6 位
+			code, err := totp.Code(secret, at)
+			require.NoError(t, err)
+			assert.Equal(t, v.code[2:], code, "time %d", v.unix)
+		}
+	})
+
+	t.Run("Validate With Skew", func(t *testing.T) {
+		now := time.Unix(1234567890, 0)
+		code, err := totp.Code(secret, now)
+		require.NoError(t, err)
+
+		step, ok := totp.Validate(secret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now), step)
+
+		// 前后一个时间步内有效，超出后无效
+		_, ok = totp.Validate(secret, code, now.Add(totp.Period))
+		assert.True(t, ok)
+		_, ok = totp.Validate(secret, code, now.Add(2*totp.Period))
+		assert.False(t, ok)
+
+		_, ok = totp.Validate(secret, "12345", now)
+		assert.False(t, ok)
+		_, ok = totp.Validate("not base32!", code, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("Secret And URI", func(t *testing.T) {
+		generated, err := totp.GenerateSecret()
+		require.NoError(t, err)
+		assert.Len(t, generated, 32)
+
+		uri, err := url.Parse(totp.URI("Vibe Coding", "alice@example.com", generated))
+		require.NoError(t, err)
+		assert.Equal(t, "otpauth", uri.Scheme)
+		assert.Equal(t, "totp", uri.Host)
+		assert.Equal(t, "/Vibe Coding:alice@example.com", uri.Path)
+		assert.Equal(t, generated, uri.Query().Get("secret"))
+		assert.Equal(t, "Vibe Coding", uri.Query().Get("issuer"))
+		assert.Equal(t, "6", uri.Query().Get("digits"))
+	})
+}
+
+func TestSecretBox(t *testing.T) {
+	box, err := secretbox.NewBox("passphrase")
+	require.NoError(t, err)
+
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", opened)
+
+	// 每次加密使用随机 nonce
+	again, err := box.Seal("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	// 不同口令无法解密
+	other, err := secretbox.NewBox("other")
+	require.NoError(t, err)
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, secretbox.ErrInvalidCiphertext)
+
+	_, err = box.Open("garbage")
+	assert.ErrorIs(t, err, secretbox.ErrInvalidCiphertext)
+
+	_, err = secretbox.NewBox("")
+	assert.Error(t, err)
+}
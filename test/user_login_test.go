@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/mail"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -13,12 +14,19 @@ import (
 
 	"vibe-coding-starter/internal/config"
 	"vibe-coding-starter/internal/handler"
+	"vibe-coding-starter/internal/middleware"
 	"vibe-coding-starter/internal/model"
 	"vibe-coding-starter/internal/repository"
 	"vibe-coding-starter/internal/service"
 	"vibe-coding-starter/pkg/cache"
 	"vibe-coding-starter/pkg/database"
 	"vibe-coding-starter/pkg/logger"
+	"vibe-coding-starter/pkg/mailer"
+	"vibe-coding-starter/pkg/oidc"
+	"vibe-coding-starter/pkg/secretbox"
+	"vibe-coding-starter/pkg/token"
+	"vibe-coding-starter/test/mocks"
+	"vibe-coding-starter/test/testutil"
 )
 
 func TestUserLoginWithUsername(t *testing.T) {
@@ -50,6 +58,12 @@ func TestUserLoginWithUsername(t *testing.T) {
 		&model.File{},
 		&model.DictCategory{},
 		&model.DictItem{},
+		&model.RefreshToken{},
+		&model.UserSession{},
+		&model.OneTimeToken{},
+		&model.UserMFA{},
+		&model.MFARecoveryCode{},
+		&model.UserIdentity{},
 	)
 	require.NoError(t, err)
 
@@ -60,11 +74,49 @@ func TestUserLoginWithUsername(t *testing.T) {
 	// 创建仓储
 	userRepo := repository.NewUserRepository(db, log)
 
+	// 创建令牌管理器
+	tokens, err := token.New(cfg)
+	require.NoError(t, err)
+
 	// 创建服务
-	userService := service.NewUserService(userRepo, cacheInstance, log, cfg)
+	tokenService := service.NewTokenService(
+		repository.NewRefreshTokenRepository(db, log),
+		repository.NewUserSessionRepository(db, log),
+		userRepo,
+		tokens,
+		cacheInstance,
+		log,
+	)
+	verificationService := service.NewEmailVerificationService(
+		userRepo,
+		repository.NewOneTimeTokenRepository(db, log),
+		mailer.NewLogMailer(mail.Address{Address: cfg.Email.FromEmail}, log),
+		cacheInstance,
+		cfg,
+		log,
+	)
+	secrets, err := secretbox.New(cfg)
+	require.NoError(t, err)
+	providers, err := oidc.New(cfg)
+	require.NoError(t, err)
+	userService := service.NewUserService(
+		userRepo,
+		repository.NewMFARepository(db, log),
+		repository.NewUserIdentityRepository(db, log),
+		tokenService,
+		verificationService,
+		new(mocks.MockInvitationService),
+		secrets,
+		providers,
+		testutil.NewTestPasswordPolicy(t),
+		cacheInstance,
+		log,
+		cfg,
+	)
 
 	// 创建处理器
 	userHandler := handler.NewUserHandler(userService, log)
+	sessionHandler := handler.NewSessionHandler(tokenService, log)
 
 	// 设置路由
 	router := gin.New()
@@ -74,6 +126,12 @@ func TestUserLoginWithUsername(t *testing.T) {
 	users := api.Group("/users")
 	users.POST("/register", userHandler.Register)
 	users.POST("/login", userHandler.Login)
+	users.POST("/refresh", userHandler.RefreshToken)
+
+	// 注册需要认证的会话路由
+	protected := api.Group("")
+	protected.Use(middleware.NewAuthMiddleware(cfg, tokens, cacheInstance, log).RequireAuth())
+	sessionHandler.RegisterRoutes(protected)
 
 	// 测试用户注册
 	t.Run("Register User", func(t *testing.T) {
@@ -123,10 +181,52 @@ func TestUserLoginWithUsername(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.NotEmpty(t, response.Token)
+		assert.NotEmpty(t, response.RefreshToken)
 		assert.Equal(t, "testuser", response.User.Username)
 		assert.Equal(t, "test@example.com", response.User.Email)
 	})
 
+	// 测试刷新令牌轮换与重复使用检测
+	t.Run("Refresh Token Rotation", func(t *testing.T) {
+		refresh := func(refreshToken string) *httptest.ResponseRecorder {
+			reqBody, _ := json.Marshal(service.RefreshTokenRequest{RefreshToken: refreshToken})
+			req := httptest.NewRequest("POST", "/api/v1/users/refresh", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		reqBody, _ := json.Marshal(service.LoginRequest{Username: "testuser", Password: "password123"})
+		req := httptest.NewRequest("POST", "/api/v1/users/login", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var login service.LoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+
+		// 正常刷新返回新的令牌对
+		w = refresh(login.RefreshToken)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var rotated service.LoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+		assert.NotEmpty(t, rotated.Token)
+		assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+
+		// 再次使用已轮换的令牌会撤销整个家族
+		w = refresh(login.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "refresh_token_reused")
+
+		w = refresh(rotated.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_refresh_token")
+	})
+
 	// 测试错误的用户名
 	t.Run("Login with Invalid Username", func(t *testing.T) {
 		loginReq := service.LoginRequest{
@@ -198,4 +298,62 @@ func TestUserLoginWithUsername(t *testing.T) {
 		assert.Equal(t, "registration_failed", response["error"])
 		assert.Contains(t, response["message"], "already exists")
 	})
+
+	// 测试会话列表与退出登录
+	t.Run("Sessions and Logout", func(t *testing.T) {
+		login := func() service.LoginResponse {
+			reqBody, _ := json.Marshal(service.LoginRequest{Username: "testuser", Password: "password123"})
+			req := httptest.NewRequest("POST", "/api/v1/users/login", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Firefox/120.0")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response service.LoginResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response
+		}
+		call := func(method, path, accessToken string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		current := login()
+		other := login()
+
+		w := call("GET", "/api/v1/users/me/sessions", current.Token)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var sessions []model.UserSession
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+		var currentCount int
+		for _, session := range sessions {
+			if session.Current {
+				currentCount++
+				assert.Equal(t, "Firefox on Windows", session.Device)
+			}
+		}
+		assert.Equal(t, 1, currentCount)
+
+		// 退出后当前会话的访问令牌和刷新令牌失效，其他会话不受影响
+		w = call("POST", "/api/v1/users/logout", current.Token)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = call("GET", "/api/v1/users/me/sessions", current.Token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		reqBody, _ := json.Marshal(service.RefreshTokenRequest{RefreshToken: current.RefreshToken})
+		req := httptest.NewRequest("POST", "/api/v1/users/refresh", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = call("GET", "/api/v1/users/me/sessions", other.Token)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
 }